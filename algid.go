@@ -0,0 +1,81 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+)
+
+// OIDEd25519 and OIDX25519 are id-Ed25519 and id-X25519 from RFC 8410,
+// Section 3, for use in ASN.1 structures that this package doesn't build
+// itself. They must not be modified.
+var (
+	OIDEd25519 = asn1.ObjectIdentifier{1, 3, 101, 112}
+	OIDX25519  = asn1.ObjectIdentifier{1, 3, 101, 110}
+)
+
+var errBadAlgorithmIdentifier = errors.New("ed25519: malformed algorithm identifier")
+
+// MarshalAlgorithmIdentifier returns the DER encoding of the
+// AlgorithmIdentifier for oid, which must be OIDEd25519 or OIDX25519, with the
+// parameters absent as RFC 8410 requires.
+func MarshalAlgorithmIdentifier(oid asn1.ObjectIdentifier) ([]byte, error) {
+	if !oid.Equal(OIDEd25519) && !oid.Equal(OIDX25519) {
+		return nil, errors.New("ed25519: unsupported algorithm " + oid.String())
+	}
+	return asn1.Marshal(pkix.AlgorithmIdentifier{Algorithm: oid})
+}
+
+// ParseAlgorithmIdentifier parses a DER AlgorithmIdentifier for Ed25519 or
+// X25519 and returns its OID.
+//
+// RFC 8410 requires the parameters to be absent, but some encoders write an
+// explicit NULL, as is done for RSA. ParseAlgorithmIdentifier accepts that and
+// sets nullParameters, so that the caller can warn about it or reject it. Any
+// other parameters are an error.
+func ParseAlgorithmIdentifier(der []byte) (oid asn1.ObjectIdentifier, nullParameters bool, err error) {
+	var algorithm pkix.AlgorithmIdentifier
+	rest, err := asn1.Unmarshal(der, &algorithm)
+	if err != nil || len(rest) != 0 {
+		return nil, false, errBadAlgorithmIdentifier
+	}
+	if !algorithm.Algorithm.Equal(OIDEd25519) && !algorithm.Algorithm.Equal(OIDX25519) {
+		return nil, false, errors.New("ed25519: unsupported algorithm " + algorithm.Algorithm.String())
+	}
+	switch params := algorithm.Parameters.FullBytes; {
+	case len(params) == 0:
+	case bytes.Equal(params, []byte{asn1.TagNull, 0}):
+		nullParameters = true
+	default:
+		return nil, false, errors.New("ed25519: algorithm identifier has parameters")
+	}
+	return algorithm.Algorithm, nullParameters, nil
+}
+
+// MarshalKeyBitString returns the DER BIT STRING that holds a raw 32-byte
+// Ed25519 or X25519 public key in a SubjectPublicKeyInfo, with no unused bits.
+func MarshalKeyBitString(key []byte) ([]byte, error) {
+	if len(key) != PublicKeySize {
+		return nil, ErrBadPublicKeyLength
+	}
+	return asn1.Marshal(asn1.BitString{Bytes: key, BitLength: 8 * len(key)})
+}
+
+// ParseKeyBitString parses a DER BIT STRING as written by MarshalKeyBitString
+// and returns a copy of the 32-byte key in it.
+func ParseKeyBitString(der []byte) ([]byte, error) {
+	var bits asn1.BitString
+	rest, err := asn1.Unmarshal(der, &bits)
+	if err != nil || len(rest) != 0 {
+		return nil, errors.New("ed25519: malformed key bit string")
+	}
+	if bits.BitLength != 8*PublicKeySize || len(bits.Bytes) != PublicKeySize {
+		return nil, ErrBadPublicKeyLength
+	}
+	return append([]byte(nil), bits.Bytes...), nil
+}
@@ -0,0 +1,103 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"testing"
+)
+
+func TestAlgorithmIdentifierOpenSSL(t *testing.T) {
+	for _, tc := range []struct {
+		path string
+		oid  asn1.ObjectIdentifier
+	}{
+		{"testdata/openssl_ed25519_pub.pem", OIDEd25519},
+		{"testdata/openssl_x25519_pub.pem", OIDX25519},
+	} {
+		var spki struct{ Algorithm, PublicKey asn1.RawValue }
+		if _, err := asn1.Unmarshal(readPEM(t, tc.path, "PUBLIC KEY"), &spki); err != nil {
+			t.Fatal(err)
+		}
+
+		oid, null, err := ParseAlgorithmIdentifier(spki.Algorithm.FullBytes)
+		if err != nil || !oid.Equal(tc.oid) || null {
+			t.Errorf("%s: ParseAlgorithmIdentifier = %v, %v, %v", tc.path, oid, null, err)
+		}
+		if der, err := MarshalAlgorithmIdentifier(tc.oid); err != nil || !bytes.Equal(der, spki.Algorithm.FullBytes) {
+			t.Errorf("%s: MarshalAlgorithmIdentifier = %x, %v, want %x", tc.path, der, err, spki.Algorithm.FullBytes)
+		}
+
+		key, err := ParseKeyBitString(spki.PublicKey.FullBytes)
+		if err != nil {
+			t.Fatalf("%s: %s", tc.path, err)
+		}
+		if der, err := MarshalKeyBitString(key); err != nil || !bytes.Equal(der, spki.PublicKey.FullBytes) {
+			t.Errorf("%s: MarshalKeyBitString = %x, %v, want %x", tc.path, der, err, spki.PublicKey.FullBytes)
+		}
+	}
+
+	if !OIDEd25519.Equal(oidEd25519) {
+		t.Error("OIDEd25519 differs from the OID used by the PKIX and PKCS #8 functions")
+	}
+}
+
+func TestAlgorithmIdentifierParameters(t *testing.T) {
+	// Explicit NULL parameters, as written by some encoders, are tolerated
+	// and reported.
+	for _, tc := range []struct {
+		der string
+		oid asn1.ObjectIdentifier
+	}{
+		{"300706032b65700500", OIDEd25519},
+		{"300706032b656e0500", OIDX25519},
+	} {
+		oid, null, err := ParseAlgorithmIdentifier(decodeHex(t, tc.der))
+		if err != nil || !oid.Equal(tc.oid) || !null {
+			t.Errorf("%s: ParseAlgorithmIdentifier = %v, %v, %v", tc.der, oid, null, err)
+		}
+	}
+
+	for _, der := range []string{
+		"",
+		"300506032b6570ff",
+		"300506032b65",
+		"300806032b6570020100",     // INTEGER parameters
+		"300906032b65700403616263", // OCTET STRING parameters
+		"300506032b6571",           // id-Ed448
+		"300d06092a864886f70d0101010500",
+	} {
+		if _, _, err := ParseAlgorithmIdentifier(decodeHex(t, der)); err == nil {
+			t.Errorf("%s: accepted", der)
+		}
+	}
+	if _, err := MarshalAlgorithmIdentifier(asn1.ObjectIdentifier{1, 3, 101, 113}); err == nil {
+		t.Error("MarshalAlgorithmIdentifier accepted id-Ed448")
+	}
+}
+
+func TestKeyBitStringErrors(t *testing.T) {
+	key := make([]byte, PublicKeySize)
+	if _, err := MarshalKeyBitString(key[:31]); err != ErrBadPublicKeyLength {
+		t.Errorf("short key gave %v", err)
+	}
+	for _, bits := range []asn1.BitString{
+		{Bytes: key[:31], BitLength: 248},
+		{Bytes: append(key, 0), BitLength: 264},
+		{Bytes: key, BitLength: 255},
+	} {
+		der, _ := asn1.Marshal(bits)
+		if _, err := ParseKeyBitString(der); err != ErrBadPublicKeyLength {
+			t.Errorf("%d-bit string gave %v", bits.BitLength, err)
+		}
+	}
+	der, _ := MarshalKeyBitString(key)
+	for _, bad := range [][]byte{nil, der[:len(der)-1], append(der, 0), append([]byte{asn1.TagOctetString}, der[1:]...)} {
+		if _, err := ParseKeyBitString(bad); err == nil {
+			t.Errorf("%x: accepted", bad)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+)
+
+// base58Alphabet is the Bitcoin base58 alphabet, which multibase calls
+// base58btc.
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// base58Digits maps a byte of base58Alphabet to its value plus one, and other
+// bytes to zero.
+var base58Digits = func() (digits [256]byte) {
+	for i := 0; i < len(base58Alphabet); i++ {
+		digits[base58Alphabet[i]] = byte(i + 1)
+	}
+	return
+}()
+
+// encodeBase58 returns b in base58, with a '1' for every leading zero byte.
+//
+// The conversion is quadratic in the length of b, which is fine for the short
+// keys and hashes that base58 is used for.
+func encodeBase58(b []byte) string {
+	zeros := 0
+	for zeros < len(b) && b[zeros] == 0 {
+		zeros++
+	}
+
+	// digits holds the value of b in base 58, least significant digit first.
+	digits := make([]byte, 0, len(b)*138/100+1)
+	for _, c := range b[zeros:] {
+		carry := int(c)
+		for i := range digits {
+			carry += int(digits[i]) << 8
+			digits[i] = byte(carry % 58)
+			carry /= 58
+		}
+		for carry > 0 {
+			digits = append(digits, byte(carry%58))
+			carry /= 58
+		}
+	}
+
+	out := make([]byte, zeros+len(digits))
+	for i := 0; i < zeros; i++ {
+		out[i] = '1'
+	}
+	for i, d := range digits {
+		out[len(out)-1-i] = base58Alphabet[d]
+	}
+	return string(out)
+}
+
+// decodeBase58 decodes s, or returns false if it contains a byte outside the
+// base58 alphabet. Every encoding decodes to a single value and that value
+// has a single encoding, so no separate canonicality check is needed.
+func decodeBase58(s string) ([]byte, bool) {
+	zeros := 0
+	for zeros < len(s) && s[zeros] == '1' {
+		zeros++
+	}
+
+	// value holds the decoded bytes, least significant first.
+	value := make([]byte, 0, len(s)*733/1000+1)
+	for i := zeros; i < len(s); i++ {
+		d := base58Digits[s[i]]
+		if d == 0 {
+			return nil, false
+		}
+		carry := int(d - 1)
+		for j := range value {
+			carry += int(value[j]) * 58
+			value[j] = byte(carry)
+			carry >>= 8
+		}
+		for carry > 0 {
+			value = append(value, byte(carry))
+			carry >>= 8
+		}
+	}
+
+	out := make([]byte, zeros+len(value))
+	for i, c := range value {
+		out[len(out)-1-i] = c
+	}
+	return out, true
+}
+
+// ErrBase58Checksum is returned when the checksum of a base58check string,
+// such as a Tezos key or address, doesn't match.
+var ErrBase58Checksum = errors.New("ed25519: base58check checksum mismatch")
+
+var errBadBase58Check = errors.New("ed25519: malformed base58check string")
+
+// encodeBase58Check returns prefix and payload followed by the first 4 bytes
+// of their double SHA-256 hash, in base58.
+func encodeBase58Check(prefix, payload []byte) string {
+	b := append(append([]byte(nil), prefix...), payload...)
+	b = append(b, base58CheckSum(b)...)
+	s := encodeBase58(b)
+	wipeBytes(b)
+	return s
+}
+
+// decodeBase58Check decodes s and checks its checksum, and returns the bytes
+// before the checksum.
+func decodeBase58Check(s string) ([]byte, error) {
+	b, ok := decodeBase58(s)
+	if !ok || len(b) < 4 {
+		return nil, errBadBase58Check
+	}
+	data, sum := b[:len(b)-4], b[len(b)-4:]
+	if subtle.ConstantTimeCompare(base58CheckSum(data), sum) != 1 {
+		wipeBytes(b)
+		return nil, ErrBase58Checksum
+	}
+	return data, nil
+}
+
+func base58CheckSum(b []byte) []byte {
+	h := sha256.Sum256(b)
+	h = sha256.Sum256(h[:])
+	return h[:4]
+}
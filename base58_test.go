@@ -0,0 +1,71 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+// base58Tests are from draft-msporny-base58.
+var base58Tests = []struct {
+	decoded, encoded string
+}{
+	{"", ""},
+	{"\x00", "1"},
+	{"\x00\x00\x28\x7f\xb4\xcd", "11233QC4"},
+	{"Hello World!", "2NEpo7TZRRrLZSi2U"},
+	{"The quick brown fox jumps over the lazy dog.", "USm3fpXnKG5EUBx2ndxBDMPVciP5hGey2Jh4NDv6gmeo1LkMeiKrLJUUBk6Z"},
+}
+
+func TestBase58(t *testing.T) {
+	for _, test := range base58Tests {
+		if got := encodeBase58([]byte(test.decoded)); got != test.encoded {
+			t.Errorf("encodeBase58(%q) = %q, want %q", test.decoded, got, test.encoded)
+		}
+		got, ok := decodeBase58(test.encoded)
+		if !ok || string(got) != test.decoded {
+			t.Errorf("decodeBase58(%q) = %q, %v, want %q", test.encoded, got, ok, test.decoded)
+		}
+	}
+
+	for _, bad := range []string{"0", "O", "I", "l", "2NEpo7TZRRrLZSi2U ", "+"} {
+		if _, ok := decodeBase58(bad); ok {
+			t.Errorf("decodeBase58(%q) succeeded", bad)
+		}
+	}
+
+	b := make([]byte, 40)
+	for i := 0; i < 100; i++ {
+		rand.Read(b)
+		b[0], b[1] = 0, byte(i%2)
+		got, ok := decodeBase58(encodeBase58(b))
+		if !ok || !bytes.Equal(got, b) {
+			t.Fatalf("%x didn't round-trip", b)
+		}
+	}
+}
+
+func TestBase58Check(t *testing.T) {
+	// The Bitcoin address of the hash160 of the genesis block's key.
+	const address = "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa"
+	payload, err := decodeBase58Check(address)
+	if err != nil || len(payload) != 21 || payload[0] != 0 {
+		t.Fatalf("decodeBase58Check(%s) = %x, %v", address, payload, err)
+	}
+	if got := encodeBase58Check(payload[:1], payload[1:]); got != address {
+		t.Errorf("encodeBase58Check = %s, want %s", got, address)
+	}
+
+	if _, err := decodeBase58Check("1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNb"); err != ErrBase58Checksum {
+		t.Errorf("bad checksum gave %v", err)
+	}
+	for _, bad := range []string{"", "1", "2NEp", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfN0"} {
+		if _, err := decodeBase58Check(bad); err != errBadBase58Check {
+			t.Errorf("decodeBase58Check(%q) gave %v", bad, err)
+		}
+	}
+}
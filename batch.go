@@ -0,0 +1,265 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	cryptorand "crypto/rand"
+	"crypto/sha3"
+	"encoding/binary"
+	"errors"
+	"runtime"
+	"sync"
+
+	"github.com/agl/ed25519/edwards25519"
+)
+
+// ErrBatchFull is returned by BatchVerifier.Add when the batch already holds
+// the maximum number of signatures it was configured for.
+var ErrBatchFull = errors.New("ed25519: batch is full")
+
+// scMinusOne is L-1 in little-endian form, i.e. -1 mod L.
+var scMinusOne = [32]byte{
+	0xec, 0xd3, 0xf5, 0x5c, 0x1a, 0x63, 0x12, 0x58, 0xd6, 0x9c, 0xf7, 0xa2, 0xde, 0xf9, 0xde, 0x14, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x10,
+}
+
+// batchEntry holds a signature that has been decoded by BatchVerifier.Add.
+// The message is hashed when it is added so that it need not be retained.
+type batchEntry struct {
+	A, R edwards25519.ExtendedGroupElement
+	s    [32]byte
+	k    [32]byte // SHA-512(R || A || M) mod L
+}
+
+// minParallelBatch is the smallest number of signatures that VerifyAll hands
+// to a single goroutine. Below this the multiscalar multiplication is cheap
+// enough that the goroutine overhead isn't worth it.
+const minParallelBatch = 64
+
+// BatchOptions configures a BatchVerifier.
+type BatchOptions struct {
+	// MaxSize is the maximum number of signatures that the batch may
+	// hold. If zero the batch may grow without limit.
+	MaxSize int
+
+	// Parallelism is the maximum number of goroutines between which
+	// VerifyAll splits the batch. Each goroutine checks an independent
+	// sub-batch. If zero, runtime.GOMAXPROCS(0) is used.
+	Parallelism int
+
+	// RandomizerSeed, if not empty, makes VerifyAll deterministic: the
+	// 128-bit coefficient of the i-th signature in the batch, counting
+	// from zero since the last reset, is the first 16 bytes of
+	// SHAKE256(RandomizerSeed || uint64(i)), with i big-endian, instead of
+	// being read from crypto/rand. The same seed and signatures then
+	// always give the same result, on any machine and with any
+	// Parallelism, which helps to replay and debug consensus failures.
+	//
+	// The security of batch verification rests on the signers not
+	// knowing the coefficients: a signer who can predict them can craft
+	// invalid signatures whose errors cancel out, so that the batch is
+	// accepted. The seed must therefore be unpredictable to everyone who
+	// contributed a signature until all of them are fixed, for example a
+	// hash of a transcript that includes every signature in the batch
+	// together with a secret or a value from after the signatures were
+	// committed. A constant or guessable seed makes VerifyAll unsafe.
+	RandomizerSeed []byte
+}
+
+// BatchVerifier accumulates signatures so that they can be checked together,
+// which is considerably faster than calling Verify on each one.
+//
+// Batch verification follows the ZIP-215 rules, exactly like VerifyZIP215. In
+// particular it uses the cofactored equation [8][S]B = [8]R + [8][k]A, so it
+// accepts a few signatures with small-order components that Verify rejects.
+// Signatures produced by Sign are accepted by both.
+//
+// A BatchVerifier is not safe for concurrent use by multiple goroutines,
+// although VerifyAll itself may use several. The zero value is an empty batch
+// with the default options.
+type BatchVerifier struct {
+	opts    BatchOptions
+	entries []batchEntry
+}
+
+// NewBatchVerifier returns an empty BatchVerifier configured by opts, which
+// may be nil.
+func NewBatchVerifier(opts *BatchOptions) *BatchVerifier {
+	v := new(BatchVerifier)
+	if opts != nil {
+		v.opts = *opts
+	}
+	return v
+}
+
+// Add decodes sig and publicKey and queues them for verification by
+// VerifyAll. It returns an error, without modifying the batch, if the batch
+// is full or if either value is malformed. Such a signature would never be
+// valid so there is no need to wait for VerifyAll to reject it.
+func (v *BatchVerifier) Add(publicKey PublicKey, message, sig []byte) error {
+	if len(publicKey) != PublicKeySize {
+		return ErrBadPublicKeyLength
+	}
+
+	var A edwards25519.ExtendedGroupElement
+	var publicKeyBytes [32]byte
+	copy(publicKeyBytes[:], publicKey)
+	if !A.FromBytes(&publicKeyBytes) {
+		return ErrInvalidPublicKey
+	}
+
+	return v.add(&A, publicKey, message, sig)
+}
+
+// AddPrecomputed is like Add but takes a public key that has already been
+// decoded.
+func (v *BatchVerifier) AddPrecomputed(publicKey *PrecomputedPublicKey, message, sig []byte) error {
+	return v.add(&publicKey.A, publicKey.publicKey[:], message, sig)
+}
+
+// add queues sig, given the decoded public key A and its encoding.
+func (v *BatchVerifier) add(A *edwards25519.ExtendedGroupElement, publicKey, message, sig []byte) error {
+	if v.opts.MaxSize > 0 && len(v.entries) >= v.opts.MaxSize {
+		return ErrBatchFull
+	}
+	if len(sig) != SignatureSize {
+		return ErrBadSignatureLength
+	}
+
+	e := batchEntry{A: *A}
+	copy(e.s[:], sig[32:])
+	if edwards25519.ScIsCanonical(&e.s) != 1 {
+		return ErrInvalidSignature
+	}
+	var RBytes [32]byte
+	copy(RBytes[:], sig[:32])
+	if !e.R.FromBytes(&RBytes) {
+		return ErrInvalidSignature
+	}
+
+	computeK(&e.k, nil, sig[:32], publicKey, message)
+
+	v.entries = append(v.entries, e)
+	return nil
+}
+
+// Len returns the number of signatures waiting to be verified.
+func (v *BatchVerifier) Len() int {
+	return len(v.entries)
+}
+
+// Reset discards all the queued signatures. The batch keeps its size limit.
+func (v *BatchVerifier) Reset() {
+	v.entries = v.entries[:0]
+}
+
+// VerifyAll reports whether every signature added since the last reset is
+// valid, and then resets the batch. An empty batch is trivially valid.
+//
+// If VerifyAll returns false then at least one signature is invalid, but it
+// does not report which. Callers that need to know can fall back to Verify.
+func (v *BatchVerifier) VerifyAll() bool {
+	defer v.Reset()
+
+	if len(v.entries) == 0 {
+		return true
+	}
+
+	workers := v.opts.Parallelism
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if max := len(v.entries) / minParallelBatch; workers > max {
+		workers = max
+	}
+	if workers <= 1 {
+		return verifyBatch(v.entries, 0, v.opts.RandomizerSeed)
+	}
+
+	return verifyBatchParallel(v.entries, workers, v.opts.RandomizerSeed)
+}
+
+// verifyBatchParallel splits entries into workers sub-batches of nearly equal
+// size and verifies them concurrently. seed is as for verifyBatch.
+func verifyBatchParallel(entries []batchEntry, workers int, seed []byte) bool {
+	results := make([]bool, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		start := i * len(entries) / workers
+		end := (i + 1) * len(entries) / workers
+
+		wg.Add(1)
+		go func(i, start int, sub []batchEntry) {
+			defer wg.Done()
+			results[i] = verifyBatch(sub, start, seed)
+		}(i, start, entries[start:end])
+	}
+	wg.Wait()
+
+	for _, ok := range results {
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// verifyBatch checks the random linear combination
+//
+//	[8](-(Σ z_i s_i)B + Σ z_i R_i + Σ (z_i k_i) A_i) = 0
+//
+// where the z_i are random 128-bit coefficients. entries start at position
+// offset in the batch, and seed is BatchOptions.RandomizerSeed.
+func verifyBatch(entries []batchEntry, offset int, seed []byte) bool {
+	scalars := make([][32]byte, 2*len(entries))
+	points := make([]edwards25519.ExtendedGroupElement, 2*len(entries))
+
+	var zero, z, bScalar [32]byte
+	randomness, err := batchCoefficients(len(entries), offset, seed)
+	if err != nil {
+		return false
+	}
+
+	for i := range entries {
+		e := &entries[i]
+		copy(z[:16], randomness[16*i:])
+
+		scalars[2*i] = z
+		points[2*i] = e.R
+		edwards25519.ScMulAdd(&scalars[2*i+1], &z, &e.k, &zero)
+		points[2*i+1] = e.A
+		edwards25519.ScMulAdd(&bScalar, &z, &e.s, &bScalar)
+	}
+	edwards25519.ScMulAdd(&bScalar, &scMinusOne, &bScalar, &zero)
+
+	var check edwards25519.ProjectiveGroupElement
+	edwards25519.GeMultiScalarMultVartime(&check, scalars, points, &bScalar)
+	check.MultByCofactor()
+
+	return check.IsIdentity()
+}
+
+// batchCoefficients returns the 16-byte coefficients of n signatures starting
+// at position offset in the batch, concatenated. They are read from
+// crypto/rand unless seed is not empty, in which case they are derived from
+// it as described for BatchOptions.RandomizerSeed.
+func batchCoefficients(n, offset int, seed []byte) ([]byte, error) {
+	randomness := make([]byte, 16*n)
+	if len(seed) == 0 {
+		if _, err := cryptorand.Read(randomness); err != nil {
+			return nil, err
+		}
+		return randomness, nil
+	}
+
+	var index [8]byte
+	for i := 0; i < n; i++ {
+		binary.BigEndian.PutUint64(index[:], uint64(offset+i))
+		h := sha3.NewSHAKE256()
+		h.Write(seed)
+		h.Write(index[:])
+		h.Read(randomness[16*i : 16*(i+1)])
+	}
+	return randomness, nil
+}
@@ -0,0 +1,263 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"testing"
+)
+
+type signedMessage struct {
+	public  PublicKey
+	message []byte
+	sig     []byte
+}
+
+func newSignedMessages(t testing.TB, n int) []signedMessage {
+	out := make([]signedMessage, n)
+	for i := range out {
+		public, private, err := GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		message := []byte(fmt.Sprintf("message %d", i))
+		out[i] = signedMessage{public, message, mustSign(t, private, message)}
+	}
+	return out
+}
+
+func TestBatchVerifier(t *testing.T) {
+	msgs := newSignedMessages(t, 64)
+
+	var v BatchVerifier
+	if !v.VerifyAll() {
+		t.Errorf("empty batch rejected")
+	}
+
+	for _, m := range msgs {
+		if err := v.Add(m.public, m.message, m.sig); err != nil {
+			t.Fatalf("Add: %s", err)
+		}
+	}
+	if v.Len() != len(msgs) {
+		t.Errorf("Len() = %d, want %d", v.Len(), len(msgs))
+	}
+	if !v.VerifyAll() {
+		t.Errorf("valid batch rejected")
+	}
+	if v.Len() != 0 {
+		t.Errorf("VerifyAll did not reset the batch")
+	}
+}
+
+func TestBatchVerifierInterleaved(t *testing.T) {
+	msgs := newSignedMessages(t, 40)
+	v := NewBatchVerifier(nil)
+
+	// Flush after a varying number of additions, corrupting every third
+	// flush. A failed batch must not affect the following one.
+	for start, round := 0, 0; start < len(msgs); round++ {
+		end := start + 1 + round%7
+		if end > len(msgs) {
+			end = len(msgs)
+		}
+		bad := round%3 == 2
+		for i, m := range msgs[start:end] {
+			message := m.message
+			if bad && i == 0 {
+				message = []byte("tampered")
+			}
+			if err := v.Add(m.public, message, m.sig); err != nil {
+				t.Fatalf("round %d: Add: %s", round, err)
+			}
+		}
+		if ok := v.VerifyAll(); ok == bad {
+			t.Errorf("round %d: VerifyAll() = %v, want %v", round, ok, !bad)
+		}
+		start = end
+	}
+}
+
+func TestBatchVerifierReset(t *testing.T) {
+	msgs := newSignedMessages(t, 4)
+	var v BatchVerifier
+
+	if err := v.Add(msgs[0].public, []byte("tampered"), msgs[0].sig); err != nil {
+		t.Fatal(err)
+	}
+	v.Reset()
+	for _, m := range msgs {
+		v.Add(m.public, m.message, m.sig)
+	}
+	if !v.VerifyAll() {
+		t.Errorf("batch rejected after Reset discarded the bad signature")
+	}
+}
+
+func TestBatchVerifierMaxSize(t *testing.T) {
+	msgs := newSignedMessages(t, 3)
+	v := NewBatchVerifier(&BatchOptions{MaxSize: 2})
+
+	for _, m := range msgs[:2] {
+		if err := v.Add(m.public, m.message, m.sig); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := v.Add(msgs[2].public, msgs[2].message, msgs[2].sig); err != ErrBatchFull {
+		t.Errorf("Add to full batch returned %v, want ErrBatchFull", err)
+	}
+	if !v.VerifyAll() {
+		t.Errorf("valid batch rejected")
+	}
+	if err := v.Add(msgs[2].public, msgs[2].message, msgs[2].sig); err != nil {
+		t.Errorf("Add after VerifyAll: %s", err)
+	}
+}
+
+func TestBatchVerifierMalformed(t *testing.T) {
+	m := newSignedMessages(t, 1)[0]
+	var v BatchVerifier
+
+	if err := v.Add(m.public[:31], m.message, m.sig); err == nil {
+		t.Errorf("short public key accepted")
+	}
+	if err := v.Add(m.public, m.message, m.sig[:63]); err == nil {
+		t.Errorf("short signature accepted")
+	}
+	highS := append([]byte(nil), m.sig...)
+	highS[63] |= 0xe0
+	if err := v.Add(m.public, m.message, highS); err == nil {
+		t.Errorf("signature with high bits set in S accepted")
+	}
+	if v.Len() != 0 {
+		t.Errorf("rejected signatures were queued")
+	}
+}
+
+func TestBatchVerifierParallel(t *testing.T) {
+	msgs := newSignedMessages(t, 4*minParallelBatch+3)
+
+	for _, workers := range []int{1, 2, 3, 4, 8} {
+		v := NewBatchVerifier(&BatchOptions{Parallelism: workers})
+		for _, bad := range []int{-1, 0, minParallelBatch + 5, len(msgs) - 1} {
+			for i, m := range msgs {
+				message := m.message
+				if i == bad {
+					message = []byte("tampered")
+				}
+				v.Add(m.public, message, m.sig)
+			}
+			if ok := v.VerifyAll(); ok != (bad < 0) {
+				t.Errorf("%d workers, bad signature at %d: VerifyAll() = %v", workers, bad, ok)
+			}
+		}
+	}
+}
+
+func BenchmarkVerifyBatchParallel(b *testing.B) {
+	msgs := newSignedMessages(b, 8192)
+	for _, workers := range []int{1, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			v := NewBatchVerifier(&BatchOptions{Parallelism: workers})
+			for i := 0; i < b.N; i++ {
+				for _, m := range msgs {
+					v.Add(m.public, m.message, m.sig)
+				}
+				if !v.VerifyAll() {
+					b.Fatal("valid batch rejected")
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkVerifyBatch(b *testing.B) {
+	for _, n := range []int{1, 8, 64, 1024} {
+		msgs := newSignedMessages(b, n)
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			v := NewBatchVerifier(&BatchOptions{Parallelism: 1})
+			for i := 0; i < b.N; i++ {
+				for _, m := range msgs {
+					v.Add(m.public, m.message, m.sig)
+				}
+				if !v.VerifyAll() {
+					b.Fatal("valid batch rejected")
+				}
+			}
+		})
+	}
+}
+
+func TestBatchRandomizerSeed(t *testing.T) {
+	seed := []byte("transcript")
+
+	// The coefficients are SHAKE256(seed || uint64(i)), as computed with
+	// Python's hashlib.
+	for _, test := range []struct {
+		index int
+		want  string
+	}{
+		{0, "871d2f86877e94fcbe039258d66501b9"},
+		{1, "d6d51debc249b2fcaa3e72a13aac054a"},
+		{1000, "4c4f12082d21cbf707f19f30e674b2c9"},
+	} {
+		z, err := batchCoefficients(1, test.index, seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(z); got != test.want {
+			t.Errorf("coefficient %d is %s, want %s", test.index, got, test.want)
+		}
+	}
+
+	// They are the same however the batch is split.
+	all, _ := batchCoefficients(200, 0, seed)
+	again, _ := batchCoefficients(200, 0, seed)
+	head, _ := batchCoefficients(73, 0, seed)
+	tail, _ := batchCoefficients(127, 73, seed)
+	if !bytes.Equal(all, again) || !bytes.Equal(all, append(head, tail...)) {
+		t.Error("coefficients depend on how the batch is split")
+	}
+	other, _ := batchCoefficients(200, 0, []byte("another transcript"))
+	if bytes.Equal(all, other) {
+		t.Error("different seeds give the same coefficients")
+	}
+	random1, _ := batchCoefficients(4, 0, nil)
+	random2, _ := batchCoefficients(4, 0, nil)
+	if bytes.Equal(random1, random2) {
+		t.Error("coefficients without a seed are not random")
+	}
+
+	msgs := newSignedMessages(t, 2*minParallelBatch)
+	bad := append([]signedMessage{}, msgs...)
+	bad[len(bad)/2].message = []byte("tampered")
+
+	for _, s := range [][]byte{seed, []byte("another transcript")} {
+		for _, workers := range []int{1, 2} {
+			v := NewBatchVerifier(&BatchOptions{RandomizerSeed: s, Parallelism: workers})
+			for repeat := 0; repeat < 2; repeat++ {
+				for _, m := range msgs {
+					if err := v.Add(m.public, m.message, m.sig); err != nil {
+						t.Fatal(err)
+					}
+				}
+				if !v.VerifyAll() {
+					t.Errorf("seed %q, %d workers: valid batch rejected", s, workers)
+				}
+				for _, m := range bad {
+					if err := v.Add(m.public, m.message, m.sig); err != nil {
+						t.Fatal(err)
+					}
+				}
+				if v.VerifyAll() {
+					t.Errorf("seed %q, %d workers: invalid batch accepted", s, workers)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"errors"
+	"strings"
+)
+
+// Bech32, from BIP-173, is a human-readable part, the separator '1', and the
+// data in groups of 5 bits followed by a 6-character BCH checksum over both,
+// using the alphabet below. Cosmos SDK chains write Ed25519 consensus keys
+// this way with prefixes such as "cosmosvalconspub".
+
+const bech32Alphabet = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// bech32MaxLength is the length limit of BIP-173.
+const bech32MaxLength = 90
+
+// ErrBech32Checksum is returned by DecodeBech32 when the checksum of a bech32
+// string doesn't match.
+var ErrBech32Checksum = errors.New("ed25519: bech32 checksum mismatch")
+
+var errBadBech32 = errors.New("ed25519: malformed bech32 string")
+
+// EncodeBech32 returns publicKey as a bech32 string with the human-readable
+// part hrp, which must be 1 to 83 characters in the range 33 to 126 and the
+// result at most 90 characters long. It writes lower case, so upper case
+// letters in hrp are lowered.
+func EncodeBech32(hrp string, publicKey PublicKey) (string, error) {
+	if len(publicKey) != PublicKeySize {
+		return "", ErrBadPublicKeyLength
+	}
+	hrp = strings.ToLower(hrp)
+	if !validBech32HRP(hrp) || len(hrp)+1+(8*PublicKeySize+4)/5+6 > bech32MaxLength {
+		return "", errors.New("ed25519: bad bech32 human-readable part")
+	}
+	return bech32Encode(hrp, convertBits(publicKey, 8, 5, true)), nil
+}
+
+// DecodeBech32 parses a bech32 string holding a 32-byte public key and returns
+// its human-readable part, in lower case, and the key. The string must be
+// all upper or all lower case and at most 90 characters long, and the checksum
+// must match or the error is ErrBech32Checksum. The key is not checked to be
+// a valid point.
+func DecodeBech32(s string) (hrp string, publicKey PublicKey, err error) {
+	hrp, data, err := bech32Decode(s)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(data) != (8*PublicKeySize+4)/5 || !validPadding(data) {
+		return "", nil, ErrBadPublicKeyLength
+	}
+	return hrp, PublicKey(convertBits(data, 5, 8, false)), nil
+}
+
+// bech32Encode returns the bech32 string of hrp and the 5-bit groups data.
+func bech32Encode(hrp string, data []byte) string {
+	checksum := bech32Polymod(append(bech32ExpandHRP(hrp), append(append([]byte(nil), data...), 0, 0, 0, 0, 0, 0)...)) ^ 1
+	var sb strings.Builder
+	sb.WriteString(hrp)
+	sb.WriteByte('1')
+	for _, d := range data {
+		sb.WriteByte(bech32Alphabet[d])
+	}
+	for i := 0; i < 6; i++ {
+		sb.WriteByte(bech32Alphabet[checksum>>(5*(5-i))&31])
+	}
+	return sb.String()
+}
+
+// bech32Decode checks s and returns its human-readable part, in lower case,
+// and its data without the checksum as 5-bit groups.
+func bech32Decode(s string) (hrp string, data []byte, err error) {
+	if len(s) > bech32MaxLength {
+		return "", nil, errBadBech32
+	}
+	lower := strings.ToLower(s)
+	if lower != s && strings.ToUpper(s) != s {
+		return "", nil, errors.New("ed25519: mixed case in bech32 string")
+	}
+	i := strings.LastIndexByte(lower, '1')
+	if i < 1 || len(lower)-i-1 < 6 {
+		return "", nil, errBadBech32
+	}
+	hrp = lower[:i]
+	if !validBech32HRP(hrp) {
+		return "", nil, errBadBech32
+	}
+	data = make([]byte, len(lower)-i-1)
+	for j := range data {
+		d := strings.IndexByte(bech32Alphabet, lower[i+1+j])
+		if d < 0 {
+			return "", nil, errBadBech32
+		}
+		data[j] = byte(d)
+	}
+	if bech32Polymod(append(bech32ExpandHRP(hrp), data...)) != 1 {
+		return "", nil, ErrBech32Checksum
+	}
+	return hrp, data[:len(data)-6], nil
+}
+
+func validBech32HRP(hrp string) bool {
+	if len(hrp) < 1 || len(hrp) > 83 {
+		return false
+	}
+	for i := 0; i < len(hrp); i++ {
+		if hrp[i] < 33 || hrp[i] > 126 {
+			return false
+		}
+	}
+	return true
+}
+
+func bech32ExpandHRP(hrp string) []byte {
+	b := make([]byte, 0, 2*len(hrp)+1)
+	for i := 0; i < len(hrp); i++ {
+		b = append(b, hrp[i]>>5)
+	}
+	b = append(b, 0)
+	for i := 0; i < len(hrp); i++ {
+		b = append(b, hrp[i]&31)
+	}
+	return b
+}
+
+func bech32Polymod(values []byte) uint32 {
+	generator := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i, g := range generator {
+			if top>>i&1 != 0 {
+				chk ^= g
+			}
+		}
+	}
+	return chk
+}
+
+// convertBits regroups the fromBits-bit groups of data into toBits-bit
+// groups. With pad, a last partial group is padded with zero bits; without
+// it, the leftover bits are dropped and must be checked by validPadding.
+func convertBits(data []byte, fromBits, toBits uint, pad bool) []byte {
+	var acc, bits uint
+	var out []byte
+	for _, d := range data {
+		acc = acc<<fromBits | uint(d)
+		bits += fromBits
+		for bits >= toBits {
+			bits -= toBits
+			out = append(out, byte(acc>>bits&(1<<toBits-1)))
+		}
+	}
+	if pad && bits > 0 {
+		out = append(out, byte(acc<<(toBits-bits)&(1<<toBits-1)))
+	}
+	return out
+}
+
+// validPadding reports whether the 5-bit groups data end with fewer than 5
+// leftover bits when regrouped into bytes, and whether those are zero, as
+// BIP-173 requires.
+func validPadding(data []byte) bool {
+	bits := 5 * uint(len(data)) % 8
+	if bits >= 5 {
+		return false
+	}
+	return len(data) == 0 || data[len(data)-1]&(1<<bits-1) == 0
+}
@@ -0,0 +1,116 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestBech32BIP173(t *testing.T) {
+	// The valid and invalid bech32 strings of BIP-173.
+	for _, s := range []string{
+		"A12UEL5L",
+		"a12uel5l",
+		"an83characterlonghumanreadablepartthatcontainsthenumber1andtheexcludedcharactersbio1tt5tgs",
+		"abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw",
+		"11qqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqc8247j",
+		"split1checkupstagehandshakeupstreamerranterredcaperred2y9e3w",
+		"?1ezyfcl",
+	} {
+		hrp, data, err := bech32Decode(s)
+		if err != nil {
+			t.Errorf("%s: %s", s, err)
+			continue
+		}
+		if got := bech32Encode(hrp, data); got != strings.ToLower(s) {
+			t.Errorf("%s: re-encoded as %s", s, got)
+		}
+	}
+	for _, s := range []string{
+		"\x201nwldj5",
+		"\x7f1axkwrx",
+		"\x801eym55h",
+		"an84characterslonghumanreadablepartthatcontainsthenumber1andtheexcludedcharactersbio1569pvx",
+		"pzry9x0s0muk",
+		"1pzry9x0s0muk",
+		"x1b4n0q5v",
+		"li1dgmt3",
+		"de1lg7wt\xff",
+		"A1G7SGD8",
+		"10a06t8",
+		"1qzzfhee",
+	} {
+		if _, _, err := bech32Decode(s); err == nil {
+			t.Errorf("%q accepted", s)
+		}
+	}
+}
+
+func TestBech32Tendermint(t *testing.T) {
+	data, err := os.ReadFile("testdata/priv_validator_key.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var key struct {
+		PubKey  struct{ Value string } `json:"pub_key"`
+		PrivKey struct{ Value string } `json:"priv_key"`
+	}
+	if err := json.Unmarshal(data, &key); err != nil {
+		t.Fatal(err)
+	}
+	public, _ := base64.StdEncoding.DecodeString(key.PubKey.Value)
+	private, _ := base64.StdEncoding.DecodeString(key.PrivKey.Value)
+	if len(private) != PrivateKeySize || !bytes.Equal(NewKeyFromSeed(private[:SeedSize]).Public().(PublicKey), public) {
+		t.Fatal("validator key doesn't match its public key")
+	}
+
+	const want = "cosmosvalconspub1qr3ttflr7ehsnljkjgzlccstrsvct5w43yehmvm5hjtzw3gnyspqma72uh"
+	got, err := EncodeBech32("cosmosvalconspub", public)
+	if err != nil || got != want {
+		t.Fatalf("EncodeBech32 = %q, %v, want %q", got, err, want)
+	}
+	for _, s := range []string{want, strings.ToUpper(want)} {
+		hrp, pub, err := DecodeBech32(s)
+		if err != nil || hrp != "cosmosvalconspub" || !bytes.Equal(pub, public) {
+			t.Errorf("DecodeBech32(%q) = %q, %x, %v", s, hrp, pub, err)
+		}
+	}
+}
+
+func TestBech32Errors(t *testing.T) {
+	const valid = "cosmosvalconspub1qr3ttflr7ehsnljkjgzlccstrsvct5w43yehmvm5hjtzw3gnyspqma72uh"
+
+	if _, _, err := DecodeBech32(valid[:len(valid)-1] + "j"); err != ErrBech32Checksum {
+		t.Errorf("bad checksum gave %v", err)
+	}
+	if _, _, err := DecodeBech32("cosmosvalconspub1Qr3ttflr7ehsnljkjgzlccstrsvct5w43yehmvm5hjtzw3gnyspqma72uh"); err == nil {
+		t.Error("mixed case accepted")
+	}
+	// The 20-byte consensus address of the same validator.
+	if _, _, err := DecodeBech32("cosmosvalcons19ulzz3mcnarrsy6fanvekl75e8mhzkm7hja29r"); err != ErrBadPublicKeyLength {
+		t.Errorf("20-byte payload gave %v", err)
+	}
+	// 32 bytes with a nonzero padding bit.
+	hrp, data, _ := bech32Decode(valid)
+	data[len(data)-1] |= 1
+	if _, _, err := DecodeBech32(bech32Encode(hrp, data)); err != ErrBadPublicKeyLength {
+		t.Errorf("nonzero padding gave %v", err)
+	}
+
+	public := make(PublicKey, PublicKeySize)
+	if _, err := EncodeBech32("cosmos", public[:31]); err != ErrBadPublicKeyLength {
+		t.Errorf("short key gave %v", err)
+	}
+	for _, hrp := range []string{"", "a b", strings.Repeat("a", 32)} {
+		if _, err := EncodeBech32(hrp, public); err == nil {
+			t.Errorf("human-readable part %q accepted", hrp)
+		}
+	}
+}
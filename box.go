@@ -0,0 +1,92 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import "github.com/agl/ed25519/internal/secretbox"
+
+// NaCl's crypto_box encrypts and authenticates a message from one X25519 key
+// to another with XSalsa20-Poly1305, under a key that is HSalsa20 of their
+// shared secret. Here both keys are converted from Ed25519 identities. The
+// layout is that of libsodium's crypto_box_easy and of tweetnacl-js's
+// nacl.box, the tag followed by the ciphertext, without the zero padding of
+// the original crypto_box.
+//
+// Either party can make a box that the other can open, so a box proves to
+// its recipient that it came from the holder of the other key, but not to
+// anyone else. The nonce is the caller's: it needn't be secret, but must never
+// be reused for two messages between the same pair of keys. A random nonce
+// is long enough for that.
+
+const (
+	// BoxNonceSize is the size, in bytes, of a box nonce,
+	// crypto_box_NONCEBYTES.
+	BoxNonceSize = 24
+	// BoxOverhead is the number of bytes that Box adds to a message,
+	// crypto_box_MACBYTES.
+	BoxOverhead = secretbox.Overhead
+)
+
+// BoxPrecompute returns the key of crypto_box_beforenm for privateKey and
+// peerPublicKey, both converted to X25519, which BoxAfterPrecompute and
+// OpenBoxAfterPrecompute use in place of the key pair to skip the
+// Diffie-Hellman for each message. Both parties compute the same key.
+//
+// peerPublicKey is converted as by PublicKeyToX25519, with its errors, and an
+// all-zero shared secret is rejected with ErrSmallOrderKey. The result is
+// secret, and worth wiping when no longer needed.
+func BoxPrecompute(peerPublicKey PublicKey, privateKey PrivateKey) (*[32]byte, error) {
+	pub, err := peerPublicKey.ToECDH()
+	if err != nil {
+		return nil, err
+	}
+	priv, err := privateKey.ToECDH()
+	if err != nil {
+		return nil, err
+	}
+	return boxKey(priv, pub)
+}
+
+// Box appends to out the box of message from privateKey to peerPublicKey
+// under nonce, as crypto_box_easy of libsodium and nacl.box of tweetnacl-js
+// make it for the converted keys. The result is BoxOverhead bytes longer than
+// message, and out must not overlap message. It returns the errors of
+// BoxPrecompute.
+func Box(out, message []byte, nonce *[BoxNonceSize]byte, peerPublicKey PublicKey, privateKey PrivateKey) ([]byte, error) {
+	key, err := BoxPrecompute(peerPublicKey, privateKey)
+	if err != nil {
+		return nil, err
+	}
+	defer wipeBytes(key[:])
+	return BoxAfterPrecompute(out, message, nonce, key), nil
+}
+
+// OpenBox opens box, from peerPublicKey to privateKey under nonce, and
+// appends the message to out, which must not overlap box. It returns
+// ErrBoxAuthentication if box doesn't open, and otherwise the errors of
+// BoxPrecompute.
+func OpenBox(out, box []byte, nonce *[BoxNonceSize]byte, peerPublicKey PublicKey, privateKey PrivateKey) ([]byte, error) {
+	key, err := BoxPrecompute(peerPublicKey, privateKey)
+	if err != nil {
+		return nil, err
+	}
+	defer wipeBytes(key[:])
+	return OpenBoxAfterPrecompute(out, box, nonce, key)
+}
+
+// BoxAfterPrecompute is Box with a key from BoxPrecompute, as
+// crypto_box_easy_afternm.
+func BoxAfterPrecompute(out, message []byte, nonce *[BoxNonceSize]byte, sharedKey *[32]byte) []byte {
+	return secretbox.Seal(out, message, nonce, sharedKey)
+}
+
+// OpenBoxAfterPrecompute is OpenBox with a key from BoxPrecompute, as
+// crypto_box_open_easy_afternm.
+func OpenBoxAfterPrecompute(out, box []byte, nonce *[BoxNonceSize]byte, sharedKey *[32]byte) ([]byte, error) {
+	message, ok := secretbox.Open(out, box, nonce, sharedKey)
+	if !ok {
+		return nil, ErrBoxAuthentication
+	}
+	return message, nil
+}
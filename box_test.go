@@ -0,0 +1,133 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+)
+
+// boxTests are boxes between the keys of libsodiumTests, converted with
+// crypto_sign_ed25519_pk_to_curve25519 and crypto_sign_ed25519_sk_to_curve25519
+// of libsodium 1.0.18: the first made with its crypto_box_easy, the second
+// with nacl.box of tweetnacl-js 0.14.5.
+var boxTests = []struct {
+	from, to            int
+	nonce, message, box string
+}{
+	{
+		0, 1,
+		"000102030405060708090a0b0c0d0e0f1011121314151617",
+		"66726f6d206b6579203020746f206b65792031206279206c6962736f6469756d2063727970746f5f626f785f65617379",
+		"1abba137cb9065d7875d4f386cceb83e2452cc3fcbeeba6022d9677d1700adc357c71f4bc30c14d2ff67d414cd28c885350531f147f53457cfa3dddabaeb71e0",
+	},
+	{
+		1, 0,
+		"a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5",
+		"66726f6d206b6579203120746f206b657920302062792074776565746e61636c2d6a73206e61636c2e626f78",
+		"674782e180697bee6112a60c21bc0a09462879fc307954258d39d3309bcb392186efe925cd4bf9d4c2731b8badff9a368911c1025dc4111c106f5af4",
+	},
+}
+
+func TestBoxInterop(t *testing.T) {
+	for i, test := range boxTests {
+		from := PrivateKey(decodeHex(t, libsodiumTests[test.from].sk))
+		to := PrivateKey(decodeHex(t, libsodiumTests[test.to].sk))
+		nonce := (*[BoxNonceSize]byte)(decodeHex(t, test.nonce))
+		message := decodeHex(t, test.message)
+
+		box, err := Box(nil, message, nonce, to.Public().(PublicKey), from)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(box); got != test.box {
+			t.Errorf("%d: got %s, want %s", i, got, test.box)
+		}
+		opened, err := OpenBox(nil, decodeHex(t, test.box), nonce, from.Public().(PublicKey), to)
+		if err != nil || !bytes.Equal(opened, message) {
+			t.Errorf("%d: opened to %x, %v", i, opened, err)
+		}
+	}
+
+	// crypto_box_beforenm of libsodium for the keys of the first test.
+	key, err := BoxPrecompute(decodeHex(t, libsodiumTests[1].pk), decodeHex(t, libsodiumTests[0].sk))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hex.EncodeToString(key[:]); got != "23a22f163193a8c0354e14f567888a9133d733c1cb948e2853c093f09ce831f9" {
+		t.Errorf("got shared key %s", got)
+	}
+}
+
+func TestBoxPrecompute(t *testing.T) {
+	alicePub, alice, _ := GenerateKey(rand.Reader)
+	bobPub, bob, _ := GenerateKey(rand.Reader)
+	aliceKey, err := BoxPrecompute(bobPub, alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bobKey, err := BoxPrecompute(alicePub, bob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *aliceKey != *bobKey {
+		t.Fatalf("shared keys %x and %x differ", aliceKey, bobKey)
+	}
+
+	var nonce [BoxNonceSize]byte
+	rand.Read(nonce[:])
+	message := []byte("hello, bob")
+	box := BoxAfterPrecompute([]byte("prefix"), message, &nonce, aliceKey)
+	if !bytes.HasPrefix(box, []byte("prefix")) || len(box) != len("prefix")+len(message)+BoxOverhead {
+		t.Fatalf("BoxAfterPrecompute gave %x", box)
+	}
+	opened, err := OpenBox(nil, box[len("prefix"):], &nonce, alicePub, bob)
+	if err != nil || !bytes.Equal(opened, message) {
+		t.Errorf("opened to %q, %v", opened, err)
+	}
+}
+
+func TestOpenBoxErrors(t *testing.T) {
+	test := boxTests[0]
+	from := decodeHex(t, libsodiumTests[test.from].pk)
+	to := PrivateKey(decodeHex(t, libsodiumTests[test.to].sk))
+	nonce := (*[BoxNonceSize]byte)(decodeHex(t, test.nonce))
+	box := decodeHex(t, test.box)
+
+	wrongNonce := *nonce
+	wrongNonce[23] ^= 1
+	if _, err := OpenBox(nil, box, &wrongNonce, from, to); err != ErrBoxAuthentication {
+		t.Errorf("wrong nonce gave %v", err)
+	}
+	for _, n := range []int{0, BoxOverhead - 1, BoxOverhead, len(box) - 1} {
+		if _, err := OpenBox(nil, box[:n], nonce, from, to); err != ErrBoxAuthentication {
+			t.Errorf("box truncated to %d bytes gave %v", n, err)
+		}
+	}
+	for i := range box {
+		bad := append([]byte(nil), box...)
+		bad[i] ^= 0x40
+		if _, err := OpenBox(nil, bad, nonce, from, to); err != ErrBoxAuthentication {
+			t.Errorf("box with byte %d changed gave %v", i, err)
+		}
+	}
+
+	// The right recipient but the wrong sender.
+	other := decodeHex(t, libsodiumTests[2].pk)
+	if _, err := OpenBox(nil, box, nonce, other, to); err != ErrBoxAuthentication {
+		t.Errorf("box from another sender gave %v", err)
+	}
+
+	for _, enc := range smallOrderEncodings {
+		if _, err := Box(nil, nil, nonce, decodeHex(t, enc), to); err == nil {
+			t.Errorf("small-order %s accepted", enc)
+		}
+	}
+	if _, err := OpenBox(nil, box, nonce, from, to[:32]); err != ErrBadPrivateKeyLength {
+		t.Errorf("short private key gave %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import "github.com/agl/ed25519/edwards25519"
+
+// IsCanonicalSignature reports whether sig is SignatureSize bytes long and
+// its S component is reduced, that is S < L. If checkR is true it also
+// requires R to be the canonical encoding of a point.
+//
+// Every valid signature has a second, non-canonical, form with L added to S,
+// which some verifiers accept. Systems that index or deduplicate signatures
+// should only store canonical ones.
+func IsCanonicalSignature(sig []byte, checkR bool) bool {
+	if len(sig) != SignatureSize {
+		return false
+	}
+
+	var s [32]byte
+	copy(s[:], sig[32:])
+	if edwards25519.ScIsCanonical(&s) != 1 {
+		return false
+	}
+	return !checkR || isCanonicalR(sig)
+}
+
+// CanonicalizeSignature returns a copy of sig with S reduced modulo L, and
+// true, if that is all sig needs to become canonical. It returns nil and
+// false if sig has the wrong length or if R is not the canonical encoding of
+// a point, which no change to S can fix. A signature that is already
+// canonical is returned unchanged.
+//
+// Reducing S doesn't change the result of the verification equation, so the
+// canonical signature is valid under the cofactored rules exactly when the
+// original satisfies the same equation. The verification functions of this
+// package reject the original, as they require S < L, but other
+// implementations may not.
+func CanonicalizeSignature(sig []byte) ([]byte, bool) {
+	if len(sig) != SignatureSize || !isCanonicalR(sig) {
+		return nil, false
+	}
+
+	var wide [64]byte
+	copy(wide[:], sig[32:])
+	var s [32]byte
+	edwards25519.ScReduce(&s, &wide)
+
+	out := make([]byte, SignatureSize)
+	copy(out, sig[:32])
+	copy(out[32:], s[:])
+	return out, true
+}
+
+// isCanonicalR reports whether the first 32 bytes of sig are the canonical
+// encoding of a point.
+func isCanonicalR(sig []byte) bool {
+	var R edwards25519.ExtendedGroupElement
+	var RBytes [32]byte
+	copy(RBytes[:], sig[:32])
+	return R.FromBytes(&RBytes) && isCanonicalEncoding(&R, &RBytes)
+}
@@ -0,0 +1,89 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+func TestCanonicalizeSignature(t *testing.T) {
+	for i, m := range newSignedMessages(t, 16) {
+		bumped := append(m.sig[:32:32], addL(m.sig[32:])...)
+
+		if !IsCanonicalSignature(m.sig, false) || !IsCanonicalSignature(m.sig, true) {
+			t.Errorf("#%d: signature from Sign is not canonical", i)
+		}
+		if IsCanonicalSignature(bumped, false) || IsCanonicalSignature(bumped, true) {
+			t.Errorf("#%d: S + L is canonical", i)
+		}
+		if VerifyCofactored(m.public, m.message, bumped) {
+			t.Errorf("#%d: VerifyCofactored accepted S + L", i)
+		}
+
+		fixed, ok := CanonicalizeSignature(bumped)
+		if !ok {
+			t.Errorf("#%d: CanonicalizeSignature failed", i)
+			continue
+		}
+		if !bytes.Equal(fixed, m.sig) {
+			t.Errorf("#%d: CanonicalizeSignature returned %x, want %x", i, fixed, m.sig)
+		}
+		if !VerifyCofactored(m.public, m.message, fixed) {
+			t.Errorf("#%d: canonicalized signature rejected", i)
+		}
+		if same, ok := CanonicalizeSignature(m.sig); !ok || !bytes.Equal(same, m.sig) {
+			t.Errorf("#%d: canonical signature changed", i)
+		}
+
+		// An invalid signature stays invalid.
+		if VerifyCofactored(m.public, append(m.message, 'x'), fixed) {
+			t.Errorf("#%d: canonicalized signature valid for another message", i)
+		}
+	}
+}
+
+func TestCanonicalizeSignatureLargeS(t *testing.T) {
+	public, private, _ := GenerateKey(rand.Reader)
+	message := []byte("message")
+	sig := mustSign(t, private, message)
+
+	// S + 14L < 15L is still below 2^256.
+	bumped := append([]byte{}, sig...)
+	for i := 0; i < 14; i++ {
+		bumped = append(bumped[:32:32], addL(bumped[32:])...)
+	}
+	fixed, ok := CanonicalizeSignature(bumped)
+	if !ok || !bytes.Equal(fixed, sig) || !VerifyCofactored(public, message, fixed) {
+		t.Errorf("S + 14L: got %x, %v, want %x", fixed, ok, sig)
+	}
+}
+
+func TestCanonicalizeSignatureR(t *testing.T) {
+	_, private, _ := GenerateKey(rand.Reader)
+	sig := mustSign(t, private, []byte("message"))
+	nonCanonical := decodeHex(t, "f0ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f")
+
+	for _, R := range [][]byte{nonCanonical, offCurve} {
+		bad := append(append([]byte{}, R...), sig[32:]...)
+		if IsCanonicalSignature(bad, true) {
+			t.Errorf("R = %x: canonical", R)
+		}
+		if !IsCanonicalSignature(bad, false) {
+			t.Errorf("R = %x: S not canonical", R)
+		}
+		if _, ok := CanonicalizeSignature(bad); ok {
+			t.Errorf("R = %x: CanonicalizeSignature succeeded", R)
+		}
+	}
+
+	if IsCanonicalSignature(sig[:63], false) {
+		t.Error("short signature is canonical")
+	}
+	if _, ok := CanonicalizeSignature(sig[:63]); ok {
+		t.Error("CanonicalizeSignature accepted a short signature")
+	}
+}
@@ -0,0 +1,283 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"sort"
+)
+
+// This file implements the subset of CBOR (RFC 8949) needed for COSE: integers
+// that fit in an int64, byte and text strings, arrays, maps, tags, booleans
+// and null. Encoding follows the core deterministic encoding requirements of
+// Section 4.2.1. Decoding accepts the same encoding except that map keys may
+// be in any order, as long as they are unique. Lengths and integers must be
+// in their shortest form, and indefinite lengths are rejected. Decoded values have the types int64, []byte, string,
+// []interface{}, map[interface{}]interface{} with int64 or string keys,
+// cborTag, bool and nil.
+
+var errBadCBOR = errors.New("ed25519: malformed CBOR")
+
+const (
+	cborUnsigned = 0
+	cborNegative = 1
+	cborBytes    = 2
+	cborText     = 3
+	cborArray    = 4
+	cborMap      = 5
+	cborTagged   = 6
+	cborSimple   = 7
+)
+
+// maxCBORDepth bounds the nesting of decoded values.
+const maxCBORDepth = 16
+
+// cborTag is a tagged CBOR value.
+type cborTag struct {
+	Number uint64
+	Value  interface{}
+}
+
+// appendCBORHead appends the initial byte and argument of a data item of type
+// major to b.
+func appendCBORHead(b []byte, major byte, n uint64) []byte {
+	major <<= 5
+	switch {
+	case n < 24:
+		return append(b, major|byte(n))
+	case n <= math.MaxUint8:
+		return append(b, major|24, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, major|25), uint16(n))
+	case n <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, major|26), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, major|27), n)
+	}
+}
+
+// appendCBOR appends the deterministic encoding of v to b. v must be one of
+// the types produced by decodeCBOR, or an int.
+func appendCBOR(b []byte, v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case int:
+		return appendCBOR(b, int64(v))
+	case int64:
+		if v < 0 {
+			return appendCBORHead(b, cborNegative, uint64(-(v + 1))), nil
+		}
+		return appendCBORHead(b, cborUnsigned, uint64(v)), nil
+	case []byte:
+		return append(appendCBORHead(b, cborBytes, uint64(len(v))), v...), nil
+	case string:
+		return append(appendCBORHead(b, cborText, uint64(len(v))), v...), nil
+	case []interface{}:
+		b = appendCBORHead(b, cborArray, uint64(len(v)))
+		for _, item := range v {
+			var err error
+			if b, err = appendCBOR(b, item); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case map[interface{}]interface{}:
+		return appendCBORMap(b, v)
+	case COSEHeader:
+		return appendCBORMap(b, v)
+	case cborTag:
+		return appendCBOR(appendCBORHead(b, cborTagged, v.Number), v.Value)
+	case bool:
+		if v {
+			return append(b, 0xf5), nil
+		}
+		return append(b, 0xf4), nil
+	case nil:
+		return append(b, 0xf6), nil
+	default:
+		return nil, errors.New("ed25519: unsupported type in CBOR value")
+	}
+}
+
+// appendCBORMap appends m to b with its keys, which must be integers or
+// strings, sorted by their encoding.
+func appendCBORMap(b []byte, m map[interface{}]interface{}) ([]byte, error) {
+	type entry struct{ key, value []byte }
+	entries := make([]entry, 0, len(m))
+	for k, v := range m {
+		switch k.(type) {
+		case int, int64, string:
+		default:
+			return nil, errors.New("ed25519: CBOR map key must be an integer or a string")
+		}
+		key, err := appendCBOR(nil, k)
+		if err != nil {
+			return nil, err
+		}
+		value, err := appendCBOR(nil, v)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry{key, value})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].key, entries[j].key) < 0
+	})
+
+	b = appendCBORHead(b, cborMap, uint64(len(entries)))
+	for i, e := range entries {
+		if i > 0 && bytes.Equal(e.key, entries[i-1].key) {
+			// An int and an int64 with the same value.
+			return nil, errors.New("ed25519: duplicate CBOR map key")
+		}
+		b = append(append(b, e.key...), e.value...)
+	}
+	return b, nil
+}
+
+// decodeCBOR decodes the single data item that is b.
+func decodeCBOR(b []byte) (interface{}, error) {
+	d := cborDecoder{b: b}
+	v, err := d.value(0)
+	if err != nil {
+		return nil, err
+	}
+	if len(d.b) != 0 {
+		return nil, errBadCBOR
+	}
+	return v, nil
+}
+
+type cborDecoder struct {
+	b []byte
+}
+
+// head reads the initial byte and argument of a data item, which must be in
+// its shortest form.
+func (d *cborDecoder) head() (major byte, n uint64, err error) {
+	if len(d.b) == 0 {
+		return 0, 0, errBadCBOR
+	}
+	major, info := d.b[0]>>5, d.b[0]&0x1f
+	d.b = d.b[1:]
+	if info < 24 {
+		return major, uint64(info), nil
+	}
+	if major == cborSimple {
+		// Floats and the long forms of simple values aren't used by COSE.
+		return 0, 0, errBadCBOR
+	}
+	var size int
+	var min uint64
+	switch info {
+	case 24:
+		size, min = 1, 24
+	case 25:
+		size, min = 2, math.MaxUint8+1
+	case 26:
+		size, min = 4, math.MaxUint16+1
+	case 27:
+		size, min = 8, math.MaxUint32+1
+	default:
+		return 0, 0, errBadCBOR
+	}
+	if len(d.b) < size {
+		return 0, 0, errBadCBOR
+	}
+	for _, c := range d.b[:size] {
+		n = n<<8 | uint64(c)
+	}
+	d.b = d.b[size:]
+	if n < min {
+		return 0, 0, errBadCBOR
+	}
+	return major, n, nil
+}
+
+func (d *cborDecoder) value(depth int) (interface{}, error) {
+	if depth > maxCBORDepth {
+		return nil, errBadCBOR
+	}
+	major, n, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+	switch major {
+	case cborUnsigned:
+		if n > math.MaxInt64 {
+			return nil, errBadCBOR
+		}
+		return int64(n), nil
+	case cborNegative:
+		if n > math.MaxInt64 {
+			return nil, errBadCBOR
+		}
+		return -1 - int64(n), nil
+	case cborBytes, cborText:
+		if n > uint64(len(d.b)) {
+			return nil, errBadCBOR
+		}
+		s := d.b[:n]
+		d.b = d.b[n:]
+		if major == cborText {
+			return string(s), nil
+		}
+		return append([]byte{}, s...), nil
+	case cborArray:
+		if n > uint64(len(d.b)) {
+			return nil, errBadCBOR
+		}
+		items := make([]interface{}, 0, n)
+		for i := uint64(0); i < n; i++ {
+			item, err := d.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	case cborMap:
+		if n > uint64(len(d.b)) {
+			return nil, errBadCBOR
+		}
+		m := make(map[interface{}]interface{}, n)
+		for i := uint64(0); i < n; i++ {
+			k, err := d.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			switch k.(type) {
+			case int64, string:
+			default:
+				return nil, errBadCBOR
+			}
+			if _, ok := m[k]; ok {
+				return nil, errBadCBOR
+			}
+			if m[k], err = d.value(depth + 1); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	case cborTagged:
+		v, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return cborTag{n, v}, nil
+	default: // cborSimple
+		switch n {
+		case 20:
+			return false, nil
+		case 21:
+			return true, nil
+		case 22:
+			return nil, nil
+		}
+		return nil, errBadCBOR
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"encoding/hex"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestCBOR(t *testing.T) {
+	// Encodings from RFC 8949, Appendix A, and Section 4.2.1.
+	for _, tc := range []struct {
+		value interface{}
+		hex   string
+	}{
+		{int64(0), "00"},
+		{int64(23), "17"},
+		{int64(24), "1818"},
+		{int64(1000), "1903e8"},
+		{int64(1000000), "1a000f4240"},
+		{int64(1000000000000), "1b000000e8d4a51000"},
+		{int64(math.MaxInt64), "1b7fffffffffffffff"},
+		{int64(-1), "20"},
+		{int64(-8), "27"},
+		{int64(-1000), "3903e7"},
+		{int64(math.MinInt64), "3b7fffffffffffffff"},
+		{[]byte{}, "40"},
+		{[]byte{1, 2, 3, 4}, "4401020304"},
+		{"", "60"},
+		{"IETF", "6449455446"},
+		{"ü", "62c3bc"},
+		{[]interface{}{}, "80"},
+		{[]interface{}{int64(1), []interface{}{int64(2), int64(3)}}, "8201820203"},
+		{map[interface{}]interface{}{}, "a0"},
+		{map[interface{}]interface{}{int64(1): int64(2), int64(3): int64(4)}, "a201020304"},
+		{map[interface{}]interface{}{"a": int64(1), int64(-1): "x", int64(10): true}, "a30af52061786161 01"},
+		{cborTag{18, []interface{}{}}, "d280"},
+		{false, "f4"},
+		{true, "f5"},
+		{nil, "f6"},
+	} {
+		want, _ := hex.DecodeString(stripSpaces(tc.hex))
+		got, err := appendCBOR(nil, tc.value)
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("encoding %#v: got %x, %v, want %x", tc.value, got, err, want)
+		}
+		decoded, err := decodeCBOR(want)
+		if err != nil || !reflect.DeepEqual(decoded, tc.value) {
+			t.Errorf("decoding %x: got %#v, %v", want, decoded, err)
+		}
+	}
+}
+
+func TestCBORErrors(t *testing.T) {
+	for _, h := range []string{
+		"",
+		"1817",               // non-minimal integer
+		"190017",             // non-minimal integer
+		"1b8000000000000000", // too large for an int64
+		"3b8000000000000000", // too small for an int64
+		"5801ff",             // non-minimal length
+		"5f4101ff",           // indefinite length
+		"9f01ff",             // indefinite length
+		"44010203",           // truncated
+		"82010203",           // trailing data
+		"a10102 01",          // trailing data
+		"a2010201 03",        // duplicate key
+		"a1400102",           // byte string key
+		"a1f401",             // boolean key
+		"f7",                 // undefined
+		"f93c00",             // float
+		"fb3ff0000000000000",
+		"1c",                                     // reserved additional information
+		"81818181818181818181818181818181818100", // too deep
+	} {
+		b, _ := hex.DecodeString(stripSpaces(h))
+		if v, err := decodeCBOR(b); err == nil {
+			t.Errorf("%s: decoded to %#v", h, v)
+		}
+	}
+
+	if _, err := appendCBOR(nil, 1.5); err == nil {
+		t.Error("float encoded")
+	}
+	if _, err := appendCBOR(nil, map[interface{}]interface{}{1: 1, int64(1): 2}); err == nil {
+		t.Error("duplicate key encoded")
+	}
+	if _, err := appendCBOR(nil, map[interface{}]interface{}{true: 1}); err == nil {
+		t.Error("boolean key encoded")
+	}
+}
+
+func stripSpaces(s string) string {
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] != ' ' {
+			b = append(b, s[i])
+		}
+	}
+	return string(b)
+}
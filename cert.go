@@ -0,0 +1,185 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"crypto"
+	stded25519 "crypto/ed25519"
+	cryptorand "crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"net/url"
+	"time"
+)
+
+// crypto/x509 signs with Ed25519 only if the signer's public key is a
+// crypto/ed25519 PublicKey, and then passes the whole TBSCertificate with
+// crypto.Hash(0) as the options. The helpers here wrap any signer accepted by
+// SignerPublicKey so that x509 sees such a key.
+
+// CertificateOptions configures CreateSelfSignedCert and
+// CreateCertificateRequest. The zero value selects the defaults.
+type CertificateOptions struct {
+	// Subject is the subject name, which is also the issuer name of a
+	// self-signed certificate.
+	Subject pkix.Name
+
+	// DNSNames, EmailAddresses, IPAddresses and URIs are the subject
+	// alternative names.
+	DNSNames       []string
+	EmailAddresses []string
+	IPAddresses    []net.IP
+	URIs           []*url.URL
+
+	// NotBefore and NotAfter bound the validity period of a certificate.
+	// If NotBefore is zero the current time is used, and if NotAfter is
+	// zero it is a year after NotBefore.
+	NotBefore, NotAfter time.Time
+
+	// SerialNumber is the serial number of a certificate. If nil, a random
+	// 127-bit one is used.
+	SerialNumber *big.Int
+
+	// IsCA makes a certificate a CA certificate, with the certificate and
+	// CRL signing key usages.
+	IsCA bool
+
+	// ExtKeyUsage is the extended key usage of a certificate. If empty,
+	// the certificate is for TLS servers and clients.
+	ExtKeyUsage []x509.ExtKeyUsage
+
+	// Rand is the source of the serial number. If nil, crypto/rand.Reader
+	// is used.
+	Rand io.Reader
+}
+
+// CreateSelfSignedCert returns the DER of an X.509 certificate for the key of
+// signer, signed by signer, as described by opts. opts may be nil for the
+// defaults. The certificate has the digital signature key usage, which is the
+// only one that fits an Ed25519 key, plus the CA usages if opts.IsCA is set.
+func CreateSelfSignedCert(signer crypto.Signer, opts *CertificateOptions) ([]byte, error) {
+	var o CertificateOptions
+	if opts != nil {
+		o = *opts
+	}
+	s, err := newX509Signer(signer)
+	if err != nil {
+		return nil, err
+	}
+
+	if o.Rand == nil {
+		o.Rand = cryptorand.Reader
+	}
+	serial := o.SerialNumber
+	if serial == nil {
+		serial, err = cryptorand.Int(o.Rand, new(big.Int).Lsh(big.NewInt(1), 127))
+		if err != nil {
+			return nil, err
+		}
+	}
+	if o.NotBefore.IsZero() {
+		o.NotBefore = time.Now()
+	}
+	if o.NotAfter.IsZero() {
+		o.NotAfter = o.NotBefore.AddDate(1, 0, 0)
+	}
+	if len(o.ExtKeyUsage) == 0 {
+		o.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+	}
+	keyUsage := x509.KeyUsageDigitalSignature
+	if o.IsCA {
+		keyUsage |= x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               o.Subject,
+		DNSNames:              o.DNSNames,
+		EmailAddresses:        o.EmailAddresses,
+		IPAddresses:           o.IPAddresses,
+		URIs:                  o.URIs,
+		NotBefore:             o.NotBefore,
+		NotAfter:              o.NotAfter,
+		KeyUsage:              keyUsage,
+		ExtKeyUsage:           o.ExtKeyUsage,
+		BasicConstraintsValid: true,
+		IsCA:                  o.IsCA,
+	}
+	return x509.CreateCertificate(o.Rand, template, template, s.public, s)
+}
+
+// CreateCertificateRequest returns the DER of a PKCS #10 certificate request
+// for the key of signer, with the subject and subject alternative names of
+// opts. The other fields of opts are ignored. opts may be nil.
+func CreateCertificateRequest(signer crypto.Signer, opts *CertificateOptions) ([]byte, error) {
+	var o CertificateOptions
+	if opts != nil {
+		o = *opts
+	}
+	s, err := newX509Signer(signer)
+	if err != nil {
+		return nil, err
+	}
+	if o.Rand == nil {
+		o.Rand = cryptorand.Reader
+	}
+	template := &x509.CertificateRequest{
+		Subject:        o.Subject,
+		DNSNames:       o.DNSNames,
+		EmailAddresses: o.EmailAddresses,
+		IPAddresses:    o.IPAddresses,
+		URIs:           o.URIs,
+	}
+	return x509.CreateCertificateRequest(o.Rand, template, s)
+}
+
+// ParseCertificatePublicKey parses the DER of an X.509 certificate and returns
+// its Ed25519 public key, which must be the canonical encoding of a point of
+// large order. It doesn't check the signature or validity of the certificate.
+func ParseCertificatePublicKey(der []byte) (PublicKey, error) {
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	pub, ok := cert.PublicKey.(stded25519.PublicKey)
+	if !ok {
+		return nil, errors.New("ed25519: certificate doesn't have an Ed25519 public key")
+	}
+	publicKey := append(PublicKey(nil), pub...)
+	if err := checkPublicKey(publicKey); err != nil {
+		return nil, err
+	}
+	return publicKey, nil
+}
+
+// x509Signer presents a signer with a crypto/ed25519 public key to
+// crypto/x509.
+type x509Signer struct {
+	signer crypto.Signer
+	public stded25519.PublicKey
+}
+
+func newX509Signer(signer crypto.Signer) (*x509Signer, error) {
+	publicKey, err := SignerPublicKey(signer)
+	if err != nil {
+		return nil, err
+	}
+	return &x509Signer{signer, stded25519.PublicKey(publicKey)}, nil
+}
+
+func (s *x509Signer) Public() crypto.PublicKey {
+	return s.public
+}
+
+func (s *x509Signer) Sign(_ io.Reader, message []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if opts.HashFunc() != 0 {
+		return nil, errors.New("ed25519: certificates can't use Ed25519ph")
+	}
+	return signWith(s.signer, message)
+}
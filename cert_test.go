@@ -0,0 +1,189 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	stded25519 "crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestCreateSelfSignedCert(t *testing.T) {
+	public, private, _ := GenerateKey(rand.Reader)
+	notBefore := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	opts := &CertificateOptions{
+		Subject:      pkix.Name{CommonName: "example.org", Organization: []string{"Example"}},
+		DNSNames:     []string{"example.org", "www.example.org"},
+		IPAddresses:  []net.IP{net.IPv4(192, 0, 2, 1)},
+		NotBefore:    notBefore,
+		SerialNumber: big.NewInt(42),
+	}
+	der, err := CreateSelfSignedCert(private, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cert.CheckSignatureFrom(cert); err == nil {
+		t.Error("CheckSignatureFrom accepted a certificate that isn't a CA")
+	}
+	if err := cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature); err != nil {
+		t.Error(err)
+	}
+	if cert.SignatureAlgorithm != x509.PureEd25519 || cert.PublicKeyAlgorithm != x509.Ed25519 {
+		t.Errorf("algorithms %s and %s", cert.SignatureAlgorithm, cert.PublicKeyAlgorithm)
+	}
+	if cert.Subject.CommonName != "example.org" || len(cert.DNSNames) != 2 || !cert.IPAddresses[0].Equal(net.IPv4(192, 0, 2, 1)) {
+		t.Errorf("unexpected names %v, %v, %v", cert.Subject, cert.DNSNames, cert.IPAddresses)
+	}
+	if !cert.NotBefore.Equal(notBefore) || !cert.NotAfter.Equal(notBefore.AddDate(1, 0, 0)) || cert.SerialNumber.Int64() != 42 {
+		t.Errorf("unexpected validity %s to %s or serial %s", cert.NotBefore, cert.NotAfter, cert.SerialNumber)
+	}
+	if cert.KeyUsage != x509.KeyUsageDigitalSignature || cert.IsCA {
+		t.Errorf("key usage %#x, CA %v", cert.KeyUsage, cert.IsCA)
+	}
+	if got, err := ParseCertificatePublicKey(der); err != nil || !bytes.Equal(got, public) {
+		t.Errorf("ParseCertificatePublicKey = %x, %v", got, err)
+	}
+
+	// The certificate is good for TLS under the given name.
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	if _, err := cert.Verify(x509.VerifyOptions{DNSName: "www.example.org", Roots: roots, CurrentTime: notBefore.Add(time.Hour)}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCreateSelfSignedCA(t *testing.T) {
+	_, caKey, _ := GenerateKey(rand.Reader)
+	der, err := CreateSelfSignedCert(caKey, &CertificateOptions{Subject: pkix.Name{CommonName: "Example CA"}, IsCA: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ca.CheckSignatureFrom(ca); err != nil {
+		t.Error(err)
+	}
+	if !ca.IsCA || ca.KeyUsage&x509.KeyUsageCertSign == 0 {
+		t.Errorf("CA %v, key usage %#x", ca.IsCA, ca.KeyUsage)
+	}
+	if time.Since(ca.NotBefore) < 0 || time.Since(ca.NotBefore) > time.Minute {
+		t.Errorf("default NotBefore is %s", ca.NotBefore)
+	}
+	if ca.SerialNumber.Sign() <= 0 {
+		t.Errorf("serial number %s", ca.SerialNumber)
+	}
+}
+
+func TestCreateCertificateRequest(t *testing.T) {
+	public, private, _ := GenerateKey(rand.Reader)
+	der, err := CreateCertificateRequest(private, &CertificateOptions{
+		Subject:        pkix.Name{CommonName: "example.org"},
+		DNSNames:       []string{"example.org"},
+		EmailAddresses: []string{"admin@example.org"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		t.Error(err)
+	}
+	if csr.Subject.CommonName != "example.org" || csr.DNSNames[0] != "example.org" || csr.EmailAddresses[0] != "admin@example.org" {
+		t.Errorf("unexpected names %v, %v, %v", csr.Subject, csr.DNSNames, csr.EmailAddresses)
+	}
+	if !bytes.Equal(csr.PublicKey.(stded25519.PublicKey), public) {
+		t.Error("wrong public key")
+	}
+}
+
+func TestCertificateOpenSSL(t *testing.T) {
+	private, err := ParsePKCS8PrivateKey(readPEM(t, "testdata/openssl_ed25519.pem", "PRIVATE KEY"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	der := readPEM(t, "testdata/openssl_ed25519_cert.pem", "CERTIFICATE")
+	public, err := ParseCertificatePublicKey(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(public, private[32:]) {
+		t.Error("certificate key isn't the key of openssl_ed25519.pem")
+	}
+	cert, _ := x509.ParseCertificate(der)
+	if err := cert.CheckSignatureFrom(cert); err != nil {
+		t.Error(err)
+	}
+	csr, err := x509.ParseCertificateRequest(readPEM(t, "testdata/openssl_ed25519_csr.pem", "CERTIFICATE REQUEST"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		t.Error(err)
+	}
+
+	// A request made here must verify with the same key as OpenSSL's.
+	ours, err := CreateCertificateRequest(private, &CertificateOptions{Subject: csr.Subject})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed, err := x509.ParseCertificateRequest(ours); err != nil || parsed.CheckSignature() != nil {
+		t.Errorf("request for the OpenSSL key doesn't verify: %v", err)
+	}
+}
+
+func TestParseCertificatePublicKeyErrors(t *testing.T) {
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{SerialNumber: big.NewInt(1), NotAfter: time.Now().Add(time.Hour)}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &ecdsaKey.PublicKey, ecdsaKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseCertificatePublicKey(der); err == nil {
+		t.Error("ECDSA certificate accepted")
+	}
+	if _, err := ParseCertificatePublicKey([]byte("not a certificate")); err == nil {
+		t.Error("garbage accepted")
+	}
+
+	// A certificate for a small order point, signed by another key.
+	_, private, _ := GenerateKey(rand.Reader)
+	small := make([]byte, PublicKeySize)
+	small[0] = 1
+	der, err = x509.CreateCertificate(rand.Reader, template, template, stded25519.PublicKey(small), mustX509Signer(t, private))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseCertificatePublicKey(der); err != ErrInvalidPublicKey {
+		t.Errorf("small order key gave %v", err)
+	}
+}
+
+func mustX509Signer(t *testing.T, private PrivateKey) *x509Signer {
+	s, err := newX509Signer(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
@@ -0,0 +1,41 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import "github.com/agl/ed25519/edwards25519"
+
+// ComputeChallenge returns the challenge scalar
+//
+//	k = SHA-512(dom2 || R || A || message) mod L
+//
+// exactly as the signing and verification functions compute it, where R is
+// the encoded commitment, A is publicKey and dom2 is the prefix selected by
+// opts, which is empty for regular Ed25519. A signature (R, S) is valid
+// under the cofactorless rules when [S]B = R + [k]A. It is meant for
+// protocols built on Ed25519, such as adaptor or blind signatures, which
+// need to compute the same challenge.
+//
+// R and publicKey are hashed as given and need not be valid points. For
+// Ed25519ph message must be the 64-byte SHA-512 digest, as for
+// VerifyWithOptions. ComputeChallenge returns ErrBadPublicKeyLength or an
+// error from opts if it can't compute the challenge.
+func ComputeChallenge(R [32]byte, publicKey PublicKey, message []byte, opts *Options) (edwards25519.Scalar, error) {
+	if len(publicKey) != PublicKeySize {
+		return edwards25519.Scalar{}, ErrBadPublicKeyLength
+	}
+	dom, err := opts.dom(message)
+	if err != nil {
+		return edwards25519.Scalar{}, err
+	}
+
+	var k [32]byte
+	computeK(&k, dom, R[:], publicKey, message)
+
+	var s edwards25519.Scalar
+	if _, err := s.SetCanonicalBytes(k[:]); err != nil {
+		panic("ed25519: internal error: reduced challenge is not canonical")
+	}
+	return s, nil
+}
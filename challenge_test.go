@@ -0,0 +1,119 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha512"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/agl/ed25519/edwards25519"
+)
+
+// verifyWithChallenge reimplements Verify on top of ComputeChallenge and the
+// edwards25519 Point and Scalar types: it checks that R = [S]B - [k]A, with
+// R compared as bytes.
+func verifyWithChallenge(publicKey PublicKey, message, sig []byte, opts *Options) bool {
+	if len(publicKey) != PublicKeySize || len(sig) != SignatureSize {
+		return false
+	}
+	A, err := new(edwards25519.Point).SetBytes(publicKey)
+	if err != nil {
+		return false
+	}
+	S, err := new(edwards25519.Scalar).SetCanonicalBytes(sig[32:])
+	if err != nil {
+		return false
+	}
+	var R [32]byte
+	copy(R[:], sig[:32])
+	k, err := ComputeChallenge(R, publicKey, message, opts)
+	if err != nil {
+		return false
+	}
+
+	minusA := new(edwards25519.Point).Negate(A)
+	check := new(edwards25519.Point).VarTimeDoubleScalarBaseMult(&k, minusA, S)
+	return bytes.Equal(check.Bytes(), R[:])
+}
+
+func TestComputeChallengeWycheproof(t *testing.T) {
+	data, err := os.ReadFile(wycheproofFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vectors wycheproofVectors
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, group := range vectors.TestGroups {
+		public := PublicKey(decodeHex(t, group.PublicKey.PK))
+		for _, test := range group.Tests {
+			message, sig := decodeHex(t, test.Msg), decodeHex(t, test.Sig)
+			if got, want := verifyWithChallenge(public, message, sig, nil), Verify(public, message, sig); got != want {
+				t.Errorf("tcId %d: got %v, Verify returned %v", test.TcID, got, want)
+			}
+		}
+	}
+}
+
+func TestComputeChallengeEdgeCases(t *testing.T) {
+	for _, c := range edgeCases {
+		public := PublicKey(decodeHex(t, c.A))
+		sig := append(decodeHex(t, c.R), decodeHex(t, c.S)...)
+		if got := verifyWithChallenge(public, []byte(c.M), sig, nil); got != c.verify {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.verify)
+		}
+	}
+}
+
+func TestComputeChallengeModes(t *testing.T) {
+	public, private, _ := GenerateKey(zeroReader{})
+	message := []byte("message")
+
+	for _, mode := range signingModes {
+		msg := message
+		if mode.prehash {
+			h := sha512.Sum512(message)
+			msg = h[:]
+		}
+		sig, err := SignWithRand(nil, private, msg, mode.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !verifyWithChallenge(public, msg, sig, mode.opts) {
+			t.Errorf("%s: valid signature rejected", mode.name)
+		}
+		if verifyWithChallenge(public, msg, sig, &Options{Context: "other"}) {
+			t.Errorf("%s: signature accepted with another context", mode.name)
+		}
+
+		// The challenge is the one hashed into the signature: S = r + k*s.
+		var R [32]byte
+		copy(R[:], sig[:32])
+		k, err := ComputeChallenge(R, public, msg, mode.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var want [32]byte
+		dom, _ := mode.opts.dom(msg)
+		computeK(&want, dom, sig[:32], public, msg)
+		if !bytes.Equal(k.Bytes(), want[:]) {
+			t.Errorf("%s: ComputeChallenge differs from the internal challenge", mode.name)
+		}
+	}
+
+	var R [32]byte
+	if _, err := ComputeChallenge(R, public[:31], message, nil); err != ErrBadPublicKeyLength {
+		t.Errorf("short public key: got %v, want ErrBadPublicKeyLength", err)
+	}
+	if _, err := ComputeChallenge(R, public, message, &Options{Hash: crypto.SHA512}); err == nil {
+		t.Error("Ed25519ph with an unhashed message succeeded")
+	}
+}
@@ -0,0 +1,271 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"crypto"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+)
+
+// Chunked signatures sign a large input as a Merkle tree of fixed-size
+// chunks, so that a verifier can check any chunk on its own once it has
+// checked the signature of the tree.
+//
+// The tree is that of RFC 6962, Section 2.1, with SHA-512: a leaf is
+// SHA-512(0x00 || chunk), an interior node is SHA-512(0x01 || left ||
+// right), where the left subtree holds the largest power of two of leaves
+// that is smaller than the total, and an empty input has the root SHA-512("").
+// Every chunk is ChunkSize bytes long except for the last one, which holds
+// the remaining 1 to ChunkSize bytes.
+//
+// The manifest, which is what is signed, is encoded as
+//
+//	version (1 byte, currently 1) || ChunkSize (4 bytes) || Length (8 bytes) || Root (64 bytes)
+//
+// with integers in big-endian order, and is signed as by SignWithDomain with
+// the domain manifestDomain.
+
+// ManifestSize is the size, in bytes, of an encoded Manifest.
+const ManifestSize = 1 + 4 + 8 + sha512.Size
+
+const (
+	manifestVersion = 1
+	manifestDomain  = "ed25519 chunked manifest"
+)
+
+var (
+	errUnknownManifestVersion = errors.New("ed25519: unknown chunked manifest version")
+	errBadManifest            = errors.New("ed25519: malformed chunked manifest")
+	errBadChunkSize           = errors.New("ed25519: chunk size must be between 1 and 2^32-1")
+	errBadChunk               = errors.New("ed25519: chunk does not match the manifest")
+	errNoProofs               = errors.New("ed25519: manifest was not built from the input")
+)
+
+// Manifest describes an input that has been split into chunks.
+type Manifest struct {
+	// ChunkSize is the size of every chunk but the last.
+	ChunkSize int
+	// Length is the total length of the input.
+	Length uint64
+	// Root is the Merkle tree hash of the chunks.
+	Root [sha512.Size]byte
+
+	// leaves holds the leaf hashes when the manifest was built by
+	// NewManifest, so that inclusion proofs can be generated.
+	leaves [][sha512.Size]byte
+}
+
+// NewManifest reads r to the end, splitting it into chunks of chunkSize
+// bytes, and returns the resulting manifest. The manifest remembers the hash
+// of every chunk so that its Proof method can be used.
+func NewManifest(r io.Reader, chunkSize int) (*Manifest, error) {
+	if chunkSize <= 0 || uint64(chunkSize) > math.MaxUint32 {
+		return nil, errBadChunkSize
+	}
+
+	m := &Manifest{ChunkSize: chunkSize}
+	buf := make([]byte, chunkSize)
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			m.leaves = append(m.leaves, leafHash(buf[:n]))
+			m.Length += uint64(n)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	m.Root = treeHash(m.leaves)
+	return m, nil
+}
+
+// SignChunked reads r to the end and signs it in chunks of chunkSize bytes.
+// It returns the manifest and its signature; use Manifest.Proof to obtain
+// the proofs needed to verify individual chunks. signer is usually a
+// PrivateKey, but may be any crypto.Signer with an Ed25519 public key.
+func SignChunked(signer crypto.Signer, r io.Reader, chunkSize int) (*Manifest, []byte, error) {
+	if _, err := SignerPublicKey(signer); err != nil {
+		return nil, nil, err
+	}
+	m, err := NewManifest(r, chunkSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	sig, err := SignWithDomain(signer, manifestDomain, m.Bytes())
+	if err != nil {
+		return nil, nil, err
+	}
+	return m, sig, nil
+}
+
+// VerifyManifest checks that sig is a valid signature of m by publicKey. The
+// chunks of m can be trusted, using VerifyChunk, only after this succeeds.
+func VerifyManifest(publicKey PublicKey, m *Manifest, sig []byte) error {
+	if m.ChunkSize <= 0 || uint64(m.ChunkSize) > math.MaxUint32 {
+		return errBadChunkSize
+	}
+	framed, err := frameDomain(manifestDomain, m.Bytes())
+	if err != nil {
+		return err
+	}
+	return CheckSignature(publicKey, framed, sig)
+}
+
+// NumChunks returns the number of chunks described by m.
+func (m *Manifest) NumChunks() uint64 {
+	if m.ChunkSize <= 0 {
+		return 0
+	}
+	return (m.Length + uint64(m.ChunkSize) - 1) / uint64(m.ChunkSize)
+}
+
+// Bytes returns the encoding of m.
+func (m *Manifest) Bytes() []byte {
+	out := make([]byte, ManifestSize)
+	out[0] = manifestVersion
+	binary.BigEndian.PutUint32(out[1:], uint32(m.ChunkSize))
+	binary.BigEndian.PutUint64(out[5:], m.Length)
+	copy(out[13:], m.Root[:])
+	return out
+}
+
+// ParseManifest decodes a manifest encoded by Manifest.Bytes. It returns an
+// error if the version is not one this package understands.
+func ParseManifest(b []byte) (*Manifest, error) {
+	if len(b) == 0 {
+		return nil, errBadManifest
+	}
+	if b[0] != manifestVersion {
+		return nil, errUnknownManifestVersion
+	}
+	if len(b) != ManifestSize {
+		return nil, errBadManifest
+	}
+	m := &Manifest{
+		ChunkSize: int(binary.BigEndian.Uint32(b[1:])),
+		Length:    binary.BigEndian.Uint64(b[5:]),
+	}
+	if m.ChunkSize == 0 {
+		return nil, errBadChunkSize
+	}
+	copy(m.Root[:], b[13:])
+	return m, nil
+}
+
+// Proof returns the inclusion proof for chunk index, which is its audit path
+// in the Merkle tree. It is only available for manifests returned by
+// NewManifest or SignChunked.
+func (m *Manifest) Proof(index uint64) ([][sha512.Size]byte, error) {
+	if m.leaves == nil && m.Length > 0 {
+		return nil, errNoProofs
+	}
+	if index >= uint64(len(m.leaves)) {
+		return nil, errors.New("ed25519: chunk index out of range")
+	}
+	return auditPath(int(index), m.leaves), nil
+}
+
+// VerifyChunk checks that chunk is chunk number index of the input described
+// by m, using proof as returned by Manifest.Proof. m must have been verified
+// with VerifyManifest first.
+func VerifyChunk(m *Manifest, index uint64, chunk []byte, proof [][sha512.Size]byte) error {
+	n := m.NumChunks()
+	if index >= n {
+		return errBadChunk
+	}
+	want := uint64(m.ChunkSize)
+	if index == n-1 {
+		want = m.Length - (n-1)*uint64(m.ChunkSize)
+	}
+	if uint64(len(chunk)) != want {
+		return errBadChunk
+	}
+
+	// RFC 9162, Section 2.1.3.2.
+	fn, sn := index, n-1
+	r := leafHash(chunk)
+	for _, p := range proof {
+		if sn == 0 {
+			return errBadChunk
+		}
+		if fn&1 == 1 || fn == sn {
+			r = nodeHash(&p, &r)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			r = nodeHash(&r, &p)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	if sn != 0 || subtle.ConstantTimeCompare(r[:], m.Root[:]) != 1 {
+		return errBadChunk
+	}
+	return nil
+}
+
+func leafHash(chunk []byte) [sha512.Size]byte {
+	h := sha512.New()
+	h.Write([]byte{0})
+	h.Write(chunk)
+	var out [sha512.Size]byte
+	h.Sum(out[:0])
+	return out
+}
+
+func nodeHash(left, right *[sha512.Size]byte) [sha512.Size]byte {
+	h := sha512.New()
+	h.Write([]byte{1})
+	h.Write(left[:])
+	h.Write(right[:])
+	var out [sha512.Size]byte
+	h.Sum(out[:0])
+	return out
+}
+
+// splitPoint returns the largest power of two smaller than n, which must be
+// at least 2.
+func splitPoint(n int) int {
+	k := 1
+	for k<<1 < n {
+		k <<= 1
+	}
+	return k
+}
+
+// treeHash returns the Merkle tree hash of the given leaf hashes.
+func treeHash(leaves [][sha512.Size]byte) [sha512.Size]byte {
+	switch len(leaves) {
+	case 0:
+		return sha512.Sum512(nil)
+	case 1:
+		return leaves[0]
+	}
+	k := splitPoint(len(leaves))
+	left, right := treeHash(leaves[:k]), treeHash(leaves[k:])
+	return nodeHash(&left, &right)
+}
+
+// auditPath returns the audit path of leaf m, from the bottom of the tree up.
+func auditPath(m int, leaves [][sha512.Size]byte) [][sha512.Size]byte {
+	if len(leaves) <= 1 {
+		return nil
+	}
+	k := splitPoint(len(leaves))
+	if m < k {
+		return append(auditPath(m, leaves[:k]), treeHash(leaves[k:]))
+	}
+	return append(auditPath(m-k, leaves[k:]), treeHash(leaves[:k]))
+}
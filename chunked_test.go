@@ -0,0 +1,200 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"testing"
+	"testing/iotest"
+)
+
+func chunkedInput(n int) []byte {
+	input := make([]byte, n)
+	for i := range input {
+		input[i] = byte(i * 7)
+	}
+	return input
+}
+
+func TestSignChunked(t *testing.T) {
+	public, private, _ := GenerateKey(zeroReader{})
+	const chunkSize = 16
+
+	for _, length := range []int{0, 1, chunkSize - 1, chunkSize, chunkSize + 1, 2 * chunkSize, 5*chunkSize + 3, 17 * chunkSize} {
+		input := chunkedInput(length)
+		m, sig, err := SignChunked(private, iotest.OneByteReader(bytes.NewReader(input)), chunkSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Length != uint64(length) || m.ChunkSize != chunkSize {
+			t.Errorf("length %d: manifest has length %d and chunk size %d", length, m.Length, m.ChunkSize)
+		}
+
+		// A verifier only sees the encoded manifest and the signature.
+		parsed, err := ParseManifest(m.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := VerifyManifest(public, parsed, sig); err != nil {
+			t.Errorf("length %d: VerifyManifest: %s", length, err)
+		}
+
+		for i := uint64(0); i < m.NumChunks(); i++ {
+			start := int(i) * chunkSize
+			end := start + chunkSize
+			if end > length {
+				end = length
+			}
+			proof, err := m.Proof(i)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := VerifyChunk(parsed, i, input[start:end], proof); err != nil {
+				t.Errorf("length %d: chunk %d: %s", length, i, err)
+			}
+		}
+		if err := VerifyChunk(parsed, m.NumChunks(), nil, nil); err == nil {
+			t.Errorf("length %d: chunk past the end accepted", length)
+		}
+	}
+}
+
+func TestChunkedTreeHash(t *testing.T) {
+	input := chunkedInput(40)
+	m, err := NewManifest(bytes.NewReader(input), 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// With three chunks the tree is ((l0, l1), l2).
+	l0, l1, l2 := leafHash(input[:16]), leafHash(input[16:32]), leafHash(input[32:])
+	node := func(a, b [sha512.Size]byte) [sha512.Size]byte {
+		return sha512.Sum512(append(append([]byte{1}, a[:]...), b[:]...))
+	}
+	if want := node(node(l0, l1), l2); m.Root != want {
+		t.Errorf("root is %x, want %x", m.Root, want)
+	}
+	if want := sha512.Sum512(append([]byte{0}, input[:16]...)); l0 != want {
+		t.Errorf("leaf hash is not SHA-512(0x00 || chunk)")
+	}
+
+	empty, err := NewManifest(bytes.NewReader(nil), 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if empty.Root != sha512.Sum512(nil) || empty.NumChunks() != 0 {
+		t.Errorf("unexpected manifest for the empty input")
+	}
+}
+
+func TestChunkedTampering(t *testing.T) {
+	public, private, _ := GenerateKey(zeroReader{})
+	input := chunkedInput(100)
+	m, sig, err := SignChunked(private, bytes.NewReader(input), 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := m.Proof(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunk := input[32:48]
+
+	tampered := append([]byte(nil), chunk...)
+	tampered[3] ^= 1
+	if err := VerifyChunk(m, 2, tampered, proof); err == nil {
+		t.Errorf("tampered chunk accepted")
+	}
+	if err := VerifyChunk(m, 3, chunk, proof); err == nil {
+		t.Errorf("chunk accepted at the wrong index")
+	}
+	if err := VerifyChunk(m, 2, chunk[:15], proof); err == nil {
+		t.Errorf("truncated chunk accepted")
+	}
+	if err := VerifyChunk(m, 2, chunk, proof[:len(proof)-1]); err == nil {
+		t.Errorf("truncated proof accepted")
+	}
+	if err := VerifyChunk(m, 2, chunk, append(proof, proof[0])); err == nil {
+		t.Errorf("proof with an extra element accepted")
+	}
+	badProof := append([][sha512.Size]byte(nil), proof...)
+	badProof[1][0] ^= 1
+	if err := VerifyChunk(m, 2, chunk, badProof); err == nil {
+		t.Errorf("tampered proof accepted")
+	}
+	// The last chunk is only 4 bytes long.
+	lastProof, _ := m.Proof(6)
+	if err := VerifyChunk(m, 6, append(input[96:], 0), lastProof); err == nil {
+		t.Errorf("padded last chunk accepted")
+	}
+
+	// Every field of the manifest is covered by the signature.
+	for i := 0; i < ManifestSize; i++ {
+		b := m.Bytes()
+		b[i] ^= 1
+		parsed, err := ParseManifest(b)
+		if err != nil {
+			continue
+		}
+		if err := VerifyManifest(public, parsed, sig); err == nil {
+			t.Errorf("manifest with byte %d modified accepted", i)
+		}
+	}
+	if err := VerifyManifest(public, m, mustSign(t, private, m.Bytes())); err == nil {
+		t.Errorf("manifest signed without the domain accepted")
+	}
+}
+
+func TestParseManifest(t *testing.T) {
+	m, err := NewManifest(bytes.NewReader(chunkedInput(50)), 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded := m.Bytes()
+	if len(encoded) != ManifestSize {
+		t.Fatalf("encoding is %d bytes, want %d", len(encoded), ManifestSize)
+	}
+	parsed, err := ParseManifest(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.ChunkSize != m.ChunkSize || parsed.Length != m.Length || parsed.Root != m.Root {
+		t.Errorf("manifest does not round-trip")
+	}
+	if _, err := parsed.Proof(0); err == nil {
+		t.Errorf("parsed manifest produced a proof")
+	}
+
+	unknown := append([]byte(nil), encoded...)
+	unknown[0] = 2
+	if _, err := ParseManifest(unknown); err != errUnknownManifestVersion {
+		t.Errorf("version 2: got %v, want errUnknownManifestVersion", err)
+	}
+	for _, b := range [][]byte{nil, encoded[:ManifestSize-1], append(encoded, 0)} {
+		if _, err := ParseManifest(b); err == nil {
+			t.Errorf("%d-byte manifest accepted", len(b))
+		}
+	}
+	zeroChunks := append([]byte(nil), encoded...)
+	copy(zeroChunks[1:5], []byte{0, 0, 0, 0})
+	if _, err := ParseManifest(zeroChunks); err == nil {
+		t.Errorf("manifest with zero chunk size accepted")
+	}
+}
+
+func TestSignChunkedErrors(t *testing.T) {
+	_, private, _ := GenerateKey(zeroReader{})
+	if _, _, err := SignChunked(private, bytes.NewReader(nil), 0); err == nil {
+		t.Errorf("zero chunk size accepted")
+	}
+	if _, _, err := SignChunked(private[:10], bytes.NewReader(nil), 16); err != ErrBadPrivateKeyLength {
+		t.Errorf("short key: got %v, want ErrBadPrivateKeyLength", err)
+	}
+	r := iotest.TimeoutReader(bytes.NewReader(chunkedInput(100)))
+	if _, _, err := SignChunked(private, r, 16); err != iotest.ErrTimeout {
+		t.Errorf("read error: got %v, want %v", err, iotest.ErrTimeout)
+	}
+}
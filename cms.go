@@ -0,0 +1,344 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto"
+	stded25519 "crypto/ed25519"
+	"crypto/sha512"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"sort"
+)
+
+// CMS SignedData is specified in RFC 5652, and its use with Ed25519 in RFC
+// 8419. The signature algorithm is id-Ed25519 with absent parameters, and
+// when there are signed attributes the digest algorithm must be SHA-512: the
+// message-digest attribute holds the SHA-512 of the content, and Ed25519
+// signs the DER of the attributes with the tag of a SET OF instead of the
+// [0] IMPLICIT tag they are sent with. Without signed attributes Ed25519
+// signs the content itself.
+
+var (
+	oidSignedData    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidContentType   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidSHA512        = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+)
+
+var errBadCMS = errors.New("ed25519: malformed CMS SignedData")
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo encapsulatedContentInfo
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      []signerInfo  `asn1:"set"`
+}
+
+type encapsulatedContentInfo struct {
+	EContentType asn1.ObjectIdentifier
+	EContent     asn1.RawValue `asn1:"optional,tag:0"`
+}
+
+type signerInfo struct {
+	Version            int
+	SID                asn1.RawValue
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue `asn1:"optional,tag:0"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+	UnsignedAttrs      asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type issuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type cmsAttribute struct {
+	Type   asn1.ObjectIdentifier
+	Values []asn1.RawValue `asn1:"set"`
+}
+
+// CreateCMSSignedData returns the DER of a CMS ContentInfo holding a
+// SignedData of content by signer, following RFC 8419. cert is the DER of
+// the X.509 certificate of signer, which is included and identified by its
+// issuer and serial number. The signed attributes are the content type,
+// id-data, and the SHA-512 message digest of content. If detached is set the
+// content is left out, and must be given to VerifyCMSSignedData.
+func CreateCMSSignedData(signer crypto.Signer, cert, content []byte, detached bool) ([]byte, error) {
+	c, err := x509.ParseCertificate(cert)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := SignerPublicKey(signer)
+	if err != nil {
+		return nil, err
+	}
+	if pub, ok := c.PublicKey.(stded25519.PublicKey); !ok || !bytes.Equal(pub, publicKey) {
+		return nil, ErrKeyMismatch
+	}
+
+	digest := sha512.Sum512(content)
+	contentType, err := asn1.Marshal(oidData)
+	if err != nil {
+		return nil, err
+	}
+	messageDigest, err := asn1.Marshal(digest[:])
+	if err != nil {
+		return nil, err
+	}
+	attrs, err := marshalCMSAttributes([]cmsAttribute{
+		{Type: oidContentType, Values: []asn1.RawValue{{FullBytes: contentType}}},
+		{Type: oidMessageDigest, Values: []asn1.RawValue{{FullBytes: messageDigest}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	sig, err := signWith(signer, attrs)
+	if err != nil {
+		return nil, err
+	}
+
+	sid, err := asn1.Marshal(issuerAndSerialNumber{asn1.RawValue{FullBytes: c.RawIssuer}, c.SerialNumber})
+	if err != nil {
+		return nil, err
+	}
+	attrs[0] = 0xa0 // [0] IMPLICIT
+	sha512Algorithm := pkix.AlgorithmIdentifier{Algorithm: oidSHA512}
+	sd := signedData{
+		Version:          1,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{sha512Algorithm},
+		EncapContentInfo: encapsulatedContentInfo{EContentType: oidData},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: cert},
+		SignerInfos: []signerInfo{{
+			Version:            1,
+			SID:                asn1.RawValue{FullBytes: sid},
+			DigestAlgorithm:    sha512Algorithm,
+			SignedAttrs:        asn1.RawValue{FullBytes: attrs},
+			SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: OIDEd25519},
+			Signature:          sig,
+		}},
+	}
+	if !detached {
+		octets, err := asn1.Marshal(content)
+		if err != nil {
+			return nil, err
+		}
+		sd.EncapContentInfo.EContent = explicitTag0(octets)
+	}
+	der, err := asn1.Marshal(sd)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(contentInfo{ContentType: oidSignedData, Content: explicitTag0(der)})
+}
+
+// VerifyCMSSignedData checks the DER CMS ContentInfo der, which must hold a
+// SignedData with a single Ed25519 signer following RFC 8419, and returns the
+// certificate of the signer, which must be included. The certificate itself
+// is not verified, so the caller must check that it is trusted, for instance
+// with x509.Certificate.Verify.
+//
+// content is the signed content if it is detached, and must otherwise be nil
+// or the content held in der. If there are signed attributes they must
+// include the content type and a SHA-512 message digest of the content;
+// other attributes, such as the signing time, are signed but not checked. The
+// signer may be identified by issuer and serial number or by subject key
+// identifier.
+func VerifyCMSSignedData(der, content []byte) (*x509.Certificate, error) {
+	sd, err := parseCMSSignedData(der)
+	if err != nil {
+		return nil, err
+	}
+	if len(sd.SignerInfos) != 1 {
+		return nil, errors.New("ed25519: CMS SignedData must have a single signer")
+	}
+	si := &sd.SignerInfos[0]
+
+	encapsulated, err := cmsEncapsulatedContent(sd)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case encapsulated != nil && content != nil:
+		if !bytes.Equal(encapsulated, content) {
+			return nil, ErrInvalidSignature
+		}
+	case encapsulated != nil:
+		content = encapsulated
+	case content == nil:
+		return nil, errors.New("ed25519: CMS SignedData is detached but no content was given")
+	}
+
+	cert, err := cmsSignerCertificate(sd, si)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, ok := cert.PublicKey.(stded25519.PublicKey)
+	if !ok {
+		return nil, errors.New("ed25519: CMS signer's certificate doesn't have an Ed25519 public key")
+	}
+	if !si.SignatureAlgorithm.Algorithm.Equal(OIDEd25519) || len(si.SignatureAlgorithm.Parameters.FullBytes) != 0 {
+		return nil, errors.New("ed25519: CMS signature algorithm is not Ed25519")
+	}
+	if !si.DigestAlgorithm.Algorithm.Equal(oidSHA512) {
+		return nil, errors.New("ed25519: CMS digest algorithm is not SHA-512")
+	}
+
+	message := content
+	if len(si.SignedAttrs.FullBytes) != 0 {
+		if message, err = checkCMSSignedAttributes(si.SignedAttrs, sd.EncapContentInfo.EContentType, content); err != nil {
+			return nil, err
+		}
+	}
+	if err := CheckSignature(PublicKey(publicKey), message, si.Signature); err != nil {
+		return nil, ErrInvalidSignature
+	}
+	return cert, nil
+}
+
+// parseCMSSignedData returns the SignedData in the ContentInfo der.
+func parseCMSSignedData(der []byte) (*signedData, error) {
+	var ci contentInfo
+	if rest, err := asn1.Unmarshal(der, &ci); err != nil || len(rest) != 0 {
+		return nil, errBadCMS
+	}
+	if !ci.ContentType.Equal(oidSignedData) {
+		return nil, errors.New("ed25519: CMS content type is not SignedData")
+	}
+	sd := new(signedData)
+	if err := unmarshalExplicitTag0(ci.Content, sd); err != nil {
+		return nil, errBadCMS
+	}
+	return sd, nil
+}
+
+// cmsEncapsulatedContent returns the content of sd, or nil if it is detached.
+func cmsEncapsulatedContent(sd *signedData) ([]byte, error) {
+	if len(sd.EncapContentInfo.EContent.FullBytes) == 0 {
+		return nil, nil
+	}
+	var content []byte
+	if err := unmarshalExplicitTag0(sd.EncapContentInfo.EContent, &content); err != nil {
+		return nil, errBadCMS
+	}
+	if content == nil {
+		content = []byte{}
+	}
+	return content, nil
+}
+
+// cmsSignerCertificate returns the certificate in sd named by the signer
+// identifier of si.
+func cmsSignerCertificate(sd *signedData, si *signerInfo) (*x509.Certificate, error) {
+	var ias issuerAndSerialNumber
+	var subjectKeyID []byte
+	switch sid := si.SID; {
+	case sid.Class == asn1.ClassUniversal && sid.Tag == asn1.TagSequence:
+		if rest, err := asn1.Unmarshal(sid.FullBytes, &ias); err != nil || len(rest) != 0 {
+			return nil, errBadCMS
+		}
+	case sid.Class == asn1.ClassContextSpecific && sid.Tag == 0 && !sid.IsCompound:
+		subjectKeyID = sid.Bytes
+	default:
+		return nil, errBadCMS
+	}
+
+	for rest := sd.Certificates.Bytes; len(rest) > 0; {
+		var v asn1.RawValue
+		var err error
+		if rest, err = asn1.Unmarshal(rest, &v); err != nil {
+			return nil, errBadCMS
+		}
+		if v.Class != asn1.ClassUniversal || v.Tag != asn1.TagSequence {
+			// Attribute certificates and other formats.
+			continue
+		}
+		cert, err := x509.ParseCertificate(v.FullBytes)
+		if err != nil {
+			return nil, err
+		}
+		if subjectKeyID != nil {
+			if bytes.Equal(cert.SubjectKeyId, subjectKeyID) {
+				return cert, nil
+			}
+		} else if bytes.Equal(cert.RawIssuer, ias.Issuer.FullBytes) && cert.SerialNumber.Cmp(ias.SerialNumber) == 0 {
+			return cert, nil
+		}
+	}
+	return nil, errors.New("ed25519: CMS SignedData doesn't include the signer's certificate")
+}
+
+// checkCMSSignedAttributes checks the content type and message digest
+// attributes in signedAttrs, and returns the message that the signature
+// covers: the attributes as received, with the tag of a SET OF.
+func checkCMSSignedAttributes(signedAttrs asn1.RawValue, contentType asn1.ObjectIdentifier, content []byte) ([]byte, error) {
+	if signedAttrs.Class != asn1.ClassContextSpecific || signedAttrs.Tag != 0 || !signedAttrs.IsCompound {
+		return nil, errBadCMS
+	}
+	message := append([]byte(nil), signedAttrs.FullBytes...)
+	message[0] = 0x31 // SET OF
+	var attrs []cmsAttribute
+	if rest, err := asn1.UnmarshalWithParams(message, &attrs, "set"); err != nil || len(rest) != 0 {
+		return nil, errBadCMS
+	}
+
+	var gotContentType, gotDigest bool
+	for _, attr := range attrs {
+		switch {
+		case attr.Type.Equal(oidContentType):
+			var oid asn1.ObjectIdentifier
+			if gotContentType || len(attr.Values) != 1 || unmarshalCMSValue(attr.Values[0], &oid) != nil {
+				return nil, errBadCMS
+			}
+			if !oid.Equal(contentType) {
+				return nil, errors.New("ed25519: CMS content type attribute doesn't match the content")
+			}
+			gotContentType = true
+		case attr.Type.Equal(oidMessageDigest):
+			var digest []byte
+			if gotDigest || len(attr.Values) != 1 || unmarshalCMSValue(attr.Values[0], &digest) != nil {
+				return nil, errBadCMS
+			}
+			if sum := sha512.Sum512(content); !bytes.Equal(digest, sum[:]) {
+				return nil, ErrInvalidSignature
+			}
+			gotDigest = true
+		}
+	}
+	if !gotContentType || !gotDigest {
+		return nil, errors.New("ed25519: CMS signed attributes lack the content type or message digest")
+	}
+	return message, nil
+}
+
+func unmarshalCMSValue(v asn1.RawValue, out interface{}) error {
+	if rest, err := asn1.Unmarshal(v.FullBytes, out); err != nil || len(rest) != 0 {
+		return errBadCMS
+	}
+	return nil
+}
+
+// marshalCMSAttributes returns the DER of attrs as a SET OF, with the
+// attributes sorted by their encoding as DER requires.
+func marshalCMSAttributes(attrs []cmsAttribute) ([]byte, error) {
+	encoded := make([][]byte, len(attrs))
+	for i, attr := range attrs {
+		b, err := asn1.Marshal(attr)
+		if err != nil {
+			return nil, err
+		}
+		encoded[i] = b
+	}
+	sort.Slice(encoded, func(i, j int) bool { return bytes.Compare(encoded[i], encoded[j]) < 0 })
+	return asn1.Marshal(asn1.RawValue{Tag: asn1.TagSet, IsCompound: true, Bytes: bytes.Join(encoded, nil)})
+}
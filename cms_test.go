@@ -0,0 +1,142 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"testing"
+)
+
+// TestCMSOpenSSL checks SignedData whose signatures were made by "openssl
+// pkeyutl -sign -rawin" with testdata/openssl_ed25519.pem, in the layout that
+// "openssl cms -sign" writes: signed attributes with the signing time and
+// S/MIME capabilities, no signed attributes with -noattr, and a subject key
+// identifier with -keyid.
+func TestCMSOpenSSL(t *testing.T) {
+	cert := readPEM(t, "testdata/openssl_ed25519_cert.pem", "CERTIFICATE")
+	message := readTestdata(t, "cms_message.txt")
+
+	for _, tc := range []struct {
+		name     string
+		detached bool
+	}{
+		{"cms_attached.p7s", false},
+		{"cms_detached.p7s", true},
+		{"cms_noattr.p7s", true},
+		{"cms_keyid.p7s", true},
+		{"cms_go_attached.p7s", false},
+		{"cms_go_detached.p7s", true},
+	} {
+		der := readTestdata(t, tc.name)
+		signer, err := VerifyCMSSignedData(der, message)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if !bytes.Equal(signer.Raw, cert) {
+			t.Errorf("%s: wrong signer certificate", tc.name)
+		}
+		if _, err := VerifyCMSSignedData(der, append(message, '\n')); err != ErrInvalidSignature {
+			t.Errorf("%s: other content gave %v", tc.name, err)
+		}
+		_, err = VerifyCMSSignedData(der, nil)
+		if tc.detached && err == nil {
+			t.Errorf("%s: detached signature verified without content", tc.name)
+		} else if !tc.detached && err != nil {
+			t.Errorf("%s: attached content: %v", tc.name, err)
+		}
+	}
+}
+
+// TestCMSGo checks that CreateCMSSignedData writes the fixtures that
+// "openssl pkeyutl -verify -rawin" accepts.
+func TestCMSGo(t *testing.T) {
+	private, err := ParsePKCS8PrivateKey(readPEM(t, "testdata/openssl_ed25519.pem", "PRIVATE KEY"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := readPEM(t, "testdata/openssl_ed25519_cert.pem", "CERTIFICATE")
+	message := readTestdata(t, "cms_message.txt")
+	for name, detached := range map[string]bool{"cms_go_attached.p7s": false, "cms_go_detached.p7s": true} {
+		der, err := CreateCMSSignedData(private, cert, message, detached)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(der, readTestdata(t, name)) {
+			t.Errorf("output differs from %s", name)
+		}
+	}
+}
+
+func TestCMSRoundTrip(t *testing.T) {
+	_, private, _ := GenerateKey(rand.Reader)
+	cert, err := CreateSelfSignedCert(private, &CertificateOptions{Subject: pkix.Name{CommonName: "signer"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, content := range [][]byte{[]byte("content"), {}} {
+		for _, detached := range []bool{false, true} {
+			der, err := CreateCMSSignedData(private, cert, content, detached)
+			if err != nil {
+				t.Fatal(err)
+			}
+			signer, err := VerifyCMSSignedData(der, content)
+			if err != nil {
+				t.Errorf("%q, detached %v: %v", content, detached, err)
+			} else if signer.Subject.CommonName != "signer" {
+				t.Errorf("signer %s", signer.Subject)
+			}
+		}
+	}
+
+	_, other, _ := GenerateKey(rand.Reader)
+	if _, err := CreateCMSSignedData(other, cert, nil, false); err != ErrKeyMismatch {
+		t.Errorf("other key gave %v", err)
+	}
+	if _, err := CreateCMSSignedData(private, cert[:len(cert)-1], nil, false); err == nil {
+		t.Error("truncated certificate accepted")
+	}
+}
+
+func TestCMSErrors(t *testing.T) {
+	der := readTestdata(t, "cms_go_attached.p7s")
+	message := readTestdata(t, "cms_message.txt")
+
+	for i := 0; i < len(der); i += 7 {
+		if _, err := VerifyCMSSignedData(der[:i], message); err == nil {
+			t.Errorf("SignedData truncated to %d bytes accepted", i)
+		}
+	}
+	if _, err := VerifyCMSSignedData(append(der, 0), message); err == nil {
+		t.Error("trailing data accepted")
+	}
+
+	// The signature is the last 64 bytes.
+	bad := append([]byte(nil), der...)
+	bad[len(bad)-1] ^= 1
+	if _, err := VerifyCMSSignedData(bad, message); err != ErrInvalidSignature {
+		t.Errorf("bad signature gave %v", err)
+	}
+
+	// Change the signature algorithm, id-Ed25519, to id-Ed448.
+	bad = append([]byte(nil), der...)
+	i := bytes.LastIndex(bad, []byte{0x06, 0x03, 0x2b, 0x65, 0x70})
+	bad[i+4] = 0x71
+	if _, err := VerifyCMSSignedData(bad, message); err == nil || err == ErrInvalidSignature {
+		t.Errorf("Ed448 signature algorithm gave %v", err)
+	}
+
+	// Change the digest algorithm of the signer from SHA-512 to SHA-384.
+	bad = append([]byte(nil), der...)
+	sha512OID, _ := asn1.Marshal(oidSHA512)
+	i = bytes.LastIndex(bad, sha512OID)
+	bad[i+len(sha512OID)-1] = 2
+	if _, err := VerifyCMSSignedData(bad, message); err == nil || err == ErrInvalidSignature {
+		t.Errorf("SHA-384 digest algorithm gave %v", err)
+	}
+}
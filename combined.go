@@ -0,0 +1,38 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import "crypto"
+
+// SignCombined signs message with signer and returns the signature
+// followed by the message, which is the "signed message" format produced by
+// crypto_sign in NaCl, libsodium and TweetNaCl. The result is
+// SignatureSize+len(message) bytes long, including for an empty message.
+// signer is usually a PrivateKey, but may be any crypto.Signer with an
+// Ed25519 public key.
+func SignCombined(signer crypto.Signer, message []byte) ([]byte, error) {
+	sig, err := signWith(signer, message)
+	if err != nil {
+		return nil, err
+	}
+	return append(sig, message...), nil
+}
+
+// OpenCombined verifies a signed message in the format produced by
+// SignCombined and crypto_sign, and returns a copy of the message without
+// the signature. Like crypto_sign_open it fails if signedMessage is shorter
+// than SignatureSize, in which case the error is ErrBadSignatureLength, or
+// if the signature is invalid, in which case the error is one of those
+// returned by CheckSignature. Messages are only returned once verified.
+func OpenCombined(publicKey PublicKey, signedMessage []byte) ([]byte, error) {
+	if len(signedMessage) < SignatureSize {
+		return nil, ErrBadSignatureLength
+	}
+	sig, message := signedMessage[:SignatureSize], signedMessage[SignatureSize:]
+	if err := CheckSignature(publicKey, message, sig); err != nil {
+		return nil, err
+	}
+	return append([]byte{}, message...), nil
+}
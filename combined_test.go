@@ -0,0 +1,109 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+// tweetNaClFile holds signed messages produced by nacl.sign from
+// tweetnacl-js 0.14.5, with keys from nacl.sign.keyPair.fromSeed.
+const tweetNaClFile = "testdata/tweetnacl_sign.json"
+
+func TestCombinedTweetNaCl(t *testing.T) {
+	data, err := os.ReadFile(tweetNaClFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vectors []struct {
+		Seed, PublicKey, Message, SignedMessage string
+	}
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatal(err)
+	}
+	if len(vectors) == 0 {
+		t.Fatal("no vectors")
+	}
+
+	for i, v := range vectors {
+		private := NewKeyFromSeed(decodeHex(t, v.Seed))
+		public := PublicKey(decodeHex(t, v.PublicKey))
+		message := decodeHex(t, v.Message)
+		signedMessage := decodeHex(t, v.SignedMessage)
+
+		if !bytes.Equal(private[32:], public) {
+			t.Errorf("#%d: public key differs from TweetNaCl", i)
+		}
+		got, err := SignCombined(private, message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, signedMessage) {
+			t.Errorf("#%d: SignCombined = %x, TweetNaCl produced %x", i, got, signedMessage)
+		}
+		opened, err := OpenCombined(public, signedMessage)
+		if err != nil {
+			t.Errorf("#%d: OpenCombined: %s", i, err)
+		} else if !bytes.Equal(opened, message) {
+			t.Errorf("#%d: OpenCombined = %x, want %x", i, opened, message)
+		}
+	}
+}
+
+// TestCombinedRoundTrip checks the framing for a few message lengths.
+// TestSignInput also checks SignCombined against the crypto_sign output of
+// the reference implementation.
+func TestCombinedRoundTrip(t *testing.T) {
+	public, private, _ := GenerateKey(zeroReader{})
+	for _, message := range [][]byte{nil, {}, []byte("m"), bytes.Repeat([]byte{0xff}, 1000)} {
+		signed, err := SignCombined(private, message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(signed) != SignatureSize+len(message) || !bytes.Equal(signed[SignatureSize:], message) {
+			t.Errorf("bad framing for a %d-byte message", len(message))
+		}
+		opened, err := OpenCombined(public, signed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if opened == nil || !bytes.Equal(opened, message) {
+			t.Errorf("OpenCombined returned %x, want %x", opened, message)
+		}
+	}
+}
+
+func TestOpenCombinedErrors(t *testing.T) {
+	public, private, _ := GenerateKey(zeroReader{})
+	signed, err := SignCombined(private, []byte("message"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, l := range []int{0, 1, SignatureSize - 1} {
+		if _, err := OpenCombined(public, signed[:l]); err != ErrBadSignatureLength {
+			t.Errorf("%d-byte signed message: got %v, want ErrBadSignatureLength", l, err)
+		}
+	}
+
+	tampered := append([]byte(nil), signed...)
+	tampered[len(tampered)-1] ^= 1
+	if m, err := OpenCombined(public, tampered); err != ErrInvalidSignature || m != nil {
+		t.Errorf("tampered message: got %q, %v", m, err)
+	}
+	truncated := signed[:len(signed)-1]
+	if _, err := OpenCombined(public, truncated); err != ErrInvalidSignature {
+		t.Errorf("truncated message: got %v, want ErrInvalidSignature", err)
+	}
+	if _, err := OpenCombined(public[:31], signed); err != ErrBadPublicKeyLength {
+		t.Errorf("short public key: got %v, want ErrBadPublicKeyLength", err)
+	}
+	if _, err := SignCombined(private[:63], nil); err != ErrBadPrivateKeyLength {
+		t.Errorf("short private key: got %v, want ErrBadPrivateKeyLength", err)
+	}
+}
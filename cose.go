@@ -0,0 +1,306 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"crypto"
+	"errors"
+)
+
+// COSE (RFC 9052 and RFC 9053) labels and values used here.
+const (
+	coseKeyType      = 1
+	coseKeyID        = 2
+	coseKeyAlgorithm = 3
+	coseKeyCurve     = -1
+	coseKeyX         = -2
+	coseKeyD         = -4
+
+	coseKeyTypeOKP   = 1
+	coseCurveEd25519 = 6
+
+	coseHeaderAlgorithm = 1
+	coseHeaderCritical  = 2
+
+	coseSign1Tag = 18
+)
+
+// COSEAlgorithmEdDSA is the COSE algorithm identifier of EdDSA.
+const COSEAlgorithmEdDSA = -8
+
+var (
+	// ErrCOSEAlgorithm is returned by VerifyCOSESign1 when the protected
+	// header doesn't have alg EdDSA.
+	ErrCOSEAlgorithm = errors.New("ed25519: COSE algorithm is not EdDSA")
+
+	// ErrCOSECritical is returned by VerifyCOSESign1 when the protected
+	// header has a crit parameter, none of which are understood.
+	ErrCOSECritical = errors.New("ed25519: COSE message has critical header parameters")
+
+	errBadCOSEKey   = errors.New("ed25519: malformed COSE_Key")
+	errBadCOSESign1 = errors.New("ed25519: malformed COSE_Sign1")
+)
+
+// COSEKeyOptions holds the optional parameters of a COSE_Key.
+type COSEKeyOptions struct {
+	KeyID []byte // kid
+
+	// Algorithm is the alg parameter. If not zero, it must be
+	// COSEAlgorithmEdDSA.
+	Algorithm int64
+}
+
+// MarshalCOSEKey returns key, a PublicKey or a PrivateKey, as a COSE_Key with
+// kty OKP and crv Ed25519, as defined by RFC 9053, Section 7.2. The public
+// key is the x parameter and, for a private key, the seed is the d
+// parameter. opts may be nil. The encoding is deterministic.
+func MarshalCOSEKey(key interface{}, opts *COSEKeyOptions) ([]byte, error) {
+	m := map[interface{}]interface{}{
+		coseKeyType:  coseKeyTypeOKP,
+		coseKeyCurve: coseCurveEd25519,
+	}
+	switch key := key.(type) {
+	case PublicKey:
+		if len(key) != PublicKeySize {
+			return nil, ErrBadPublicKeyLength
+		}
+		m[coseKeyX] = []byte(key)
+	case PrivateKey:
+		if len(key) != PrivateKeySize {
+			return nil, ErrBadPrivateKeyLength
+		}
+		m[coseKeyX] = []byte(key[32:])
+		m[coseKeyD] = []byte(key[:32])
+	default:
+		return nil, errors.New("ed25519: COSE_Key must be a PublicKey or a PrivateKey")
+	}
+	if opts != nil {
+		if len(opts.KeyID) > 0 {
+			m[coseKeyID] = opts.KeyID
+		}
+		switch opts.Algorithm {
+		case 0:
+		case COSEAlgorithmEdDSA:
+			m[coseKeyAlgorithm] = opts.Algorithm
+		default:
+			return nil, ErrCOSEAlgorithm
+		}
+	}
+	return appendCBOR(nil, m)
+}
+
+// ParseCOSEKey parses an Ed25519 COSE_Key and returns the key, a PrivateKey
+// if it has a d parameter and otherwise a PublicKey, together with its
+// optional parameters. Other parameters, such as key_ops, are ignored.
+//
+// kty must be OKP, crv must be Ed25519 and alg, if present, must be EdDSA. x
+// and d must be 32 bytes long. A private key may omit x; if it doesn't, x
+// must be the public key of d or the error is ErrKeyMismatch.
+func ParseCOSEKey(data []byte) (key interface{}, opts *COSEKeyOptions, err error) {
+	v, err := decodeCBOR(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	m, ok := v.(map[interface{}]interface{})
+	if !ok {
+		return nil, nil, errBadCOSEKey
+	}
+	if m[int64(coseKeyType)] != int64(coseKeyTypeOKP) {
+		return nil, nil, errors.New("ed25519: COSE_Key key type is not OKP")
+	}
+	if m[int64(coseKeyCurve)] != int64(coseCurveEd25519) {
+		return nil, nil, errors.New("ed25519: COSE_Key curve is not Ed25519")
+	}
+
+	opts = new(COSEKeyOptions)
+	if alg, ok := m[int64(coseKeyAlgorithm)]; ok {
+		if alg != int64(COSEAlgorithmEdDSA) {
+			return nil, nil, ErrCOSEAlgorithm
+		}
+		opts.Algorithm = COSEAlgorithmEdDSA
+	}
+	if kid, ok := m[int64(coseKeyID)]; ok {
+		if opts.KeyID, ok = kid.([]byte); !ok {
+			return nil, nil, errBadCOSEKey
+		}
+	}
+
+	x, hasX := m[int64(coseKeyX)]
+	publicKey, ok := x.([]byte)
+	if hasX && (!ok || len(publicKey) != PublicKeySize) {
+		return nil, nil, errBadCOSEKey
+	}
+	d, hasD := m[int64(coseKeyD)]
+	if !hasD {
+		if !hasX {
+			return nil, nil, errBadCOSEKey
+		}
+		return PublicKey(publicKey), opts, nil
+	}
+	seed, ok := d.([]byte)
+	if !ok || len(seed) != SeedSize {
+		return nil, nil, errBadCOSEKey
+	}
+	defer wipeBytes(seed)
+	privateKey := NewKeyFromSeed(seed)
+	if hasX && !PublicKey(publicKey).Equal(privateKey.Public()) {
+		wipeBytes(privateKey)
+		return nil, nil, ErrKeyMismatch
+	}
+	return privateKey, opts, nil
+}
+
+// COSEHeader is a COSE header map. Labels are ints, int64s or strings, and
+// values are int, int64, string, []byte, bool, nil, []interface{} or
+// COSEHeader of those. Parsed headers hold int64 labels and integers, and
+// map[interface{}]interface{} for nested maps.
+type COSEHeader map[interface{}]interface{}
+
+// COSESign1 is a verified COSE_Sign1 message.
+type COSESign1 struct {
+	Protected   COSEHeader
+	Unprotected COSEHeader
+	Payload     []byte
+}
+
+// SignCOSESign1 returns the tagged COSE_Sign1 message that signs payload with
+// signer using EdDSA, as defined by RFC 9052, Section 4.2. The alg parameter
+// is added to the protected header, which may otherwise be empty or nil, as
+// may the unprotected header. externalAAD is signed but not included in the
+// message, and may be nil.
+//
+// It is an error for either header to have a different alg, for a label to
+// appear in both headers or for the protected header to have a crit
+// parameter, since VerifyCOSESign1 would reject such a message.
+func SignCOSESign1(signer crypto.Signer, protected, unprotected COSEHeader, payload, externalAAD []byte) ([]byte, error) {
+	p := COSEHeader{int64(coseHeaderAlgorithm): int64(COSEAlgorithmEdDSA)}
+	for label, value := range protected {
+		label = coseLabel(label)
+		if label == int64(coseHeaderAlgorithm) {
+			if coseLabel(value) != int64(COSEAlgorithmEdDSA) {
+				return nil, ErrCOSEAlgorithm
+			}
+			continue
+		}
+		if label == int64(coseHeaderCritical) {
+			return nil, ErrCOSECritical
+		}
+		p[label] = value
+	}
+	u := COSEHeader{}
+	for label, value := range unprotected {
+		label = coseLabel(label)
+		if label == int64(coseHeaderAlgorithm) {
+			return nil, errors.New("ed25519: COSE alg must be in the protected header")
+		}
+		if _, ok := p[label]; ok {
+			return nil, errors.New("ed25519: COSE header label in both protected and unprotected headers")
+		}
+		u[label] = value
+	}
+
+	encodedProtected, err := appendCBOR(nil, p)
+	if err != nil {
+		return nil, err
+	}
+	toBeSigned, err := coseSigStructure(encodedProtected, externalAAD, payload)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := signWith(signer, toBeSigned)
+	if err != nil {
+		return nil, err
+	}
+	if payload == nil {
+		payload = []byte{}
+	}
+	return appendCBOR(nil, cborTag{coseSign1Tag, []interface{}{encodedProtected, u, payload, sig}})
+}
+
+// VerifyCOSESign1 checks that message, a COSE_Sign1 with or without its tag,
+// is signed by publicKey with externalAAD, which may be nil, and returns its
+// contents. Messages with a detached payload are not supported.
+//
+// The protected header must have alg EdDSA, and no crit parameter. The
+// errors are ErrCOSEAlgorithm, ErrCOSECritical, ErrInvalidSignature, or
+// others if message is malformed or a label appears in both headers.
+func VerifyCOSESign1(publicKey PublicKey, message, externalAAD []byte) (*COSESign1, error) {
+	if len(publicKey) != PublicKeySize {
+		return nil, ErrBadPublicKeyLength
+	}
+	v, err := decodeCBOR(message)
+	if err != nil {
+		return nil, err
+	}
+	if tag, ok := v.(cborTag); ok {
+		if tag.Number != coseSign1Tag {
+			return nil, errBadCOSESign1
+		}
+		v = tag.Value
+	}
+	items, ok := v.([]interface{})
+	if !ok || len(items) != 4 {
+		return nil, errBadCOSESign1
+	}
+	encodedProtected, ok1 := items[0].([]byte)
+	unprotected, ok2 := items[1].(map[interface{}]interface{})
+	payload, ok3 := items[2].([]byte)
+	sig, ok4 := items[3].([]byte)
+	if !ok1 || !ok2 || !ok3 || !ok4 {
+		return nil, errBadCOSESign1
+	}
+
+	protected := map[interface{}]interface{}{}
+	if len(encodedProtected) > 0 {
+		v, err := decodeCBOR(encodedProtected)
+		if err != nil {
+			return nil, err
+		}
+		if protected, ok = v.(map[interface{}]interface{}); !ok {
+			return nil, errBadCOSESign1
+		}
+	}
+	if protected[int64(coseHeaderAlgorithm)] != int64(COSEAlgorithmEdDSA) {
+		return nil, ErrCOSEAlgorithm
+	}
+	if _, ok := protected[int64(coseHeaderCritical)]; ok {
+		return nil, ErrCOSECritical
+	}
+	for label := range unprotected {
+		if _, ok := protected[label]; ok {
+			return nil, errors.New("ed25519: COSE header label in both protected and unprotected headers")
+		}
+	}
+
+	toBeSigned, err := coseSigStructure(encodedProtected, externalAAD, payload)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckSignature(publicKey, toBeSigned, sig); err != nil {
+		return nil, ErrInvalidSignature
+	}
+	return &COSESign1{Protected: protected, Unprotected: unprotected, Payload: payload}, nil
+}
+
+// coseSigStructure returns the encoded Sig_structure of a COSE_Sign1 message,
+// which is what is signed.
+func coseSigStructure(encodedProtected, externalAAD, payload []byte) ([]byte, error) {
+	if externalAAD == nil {
+		externalAAD = []byte{}
+	}
+	if payload == nil {
+		payload = []byte{}
+	}
+	return appendCBOR(nil, []interface{}{"Signature1", encodedProtected, externalAAD, payload})
+}
+
+// coseLabel returns label, converted to an int64 if it is an int, so that
+// labels can be compared with those of parsed headers.
+func coseLabel(label interface{}) interface{} {
+	if n, ok := label.(int); ok {
+		return int64(n)
+	}
+	return label
+}
@@ -0,0 +1,236 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/rand"
+	"reflect"
+	"testing"
+)
+
+// coseSign1Vectors were computed with an independent implementation, with
+// the key of RFC 8032, Section 7.1, TEST 1, following the layout of the COSE
+// WG eddsa-sign1 examples: protected {1: -8}, unprotected {4: "11"} and the
+// payload "This is the content.".
+var coseSign1Vectors = []struct {
+	externalAAD, message string
+}{
+	{
+		"",
+		"d28443a10127a10442313154546869732069732074686520636f6e74656e742e58406354488f9f290e36cd80e23762e664a5cb03e4267c66a8cffaef7c66d89a40bf2cbb8222432a08e5ee410d8b540c6931d26fb6af673f7e2100655d8bae765c04",
+	},
+	{
+		"11aa22bb33cc44dd55006699",
+		"d28443a10127a10442313154546869732069732074686520636f6e74656e742e5840aa0e29d45e315ee58384dceb8a2953123199a9570865963a2c5c4792fe16545f43e53faab34d332e58fc88e88f3d6fae3dcf4d9f7c3f34dc405f163e4bb22c0c",
+	},
+}
+
+func TestCOSEKey(t *testing.T) {
+	private := NewKeyFromSeed(decodeHex(t, rfc8037Seed))
+	public := PublicKey(private[32:])
+
+	// {1: 1, -1: 6, -2: x, -4: d}, in deterministic order.
+	wantPrivate := decodeHex(t, "a4010120062158"+"20"+rfc8037PublicKey+"2358"+"20"+rfc8037Seed)
+	wantPublic := decodeHex(t, "a3010120062158"+"20"+rfc8037PublicKey)
+	for _, tc := range []struct {
+		key  interface{}
+		want []byte
+	}{
+		{private, wantPrivate},
+		{public, wantPublic},
+	} {
+		got, err := MarshalCOSEKey(tc.key, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, tc.want) {
+			t.Errorf("%T: got %x, want %x", tc.key, got, tc.want)
+		}
+		parsed, opts, err := ParseCOSEKey(tc.want)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(parsed, tc.key) || opts.KeyID != nil || opts.Algorithm != 0 {
+			t.Errorf("%T: parsed %x, %+v", tc.key, parsed, opts)
+		}
+	}
+
+	opts := &COSEKeyOptions{KeyID: []byte("11"), Algorithm: COSEAlgorithmEdDSA}
+	got, err := MarshalCOSEKey(public, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// {1: 1, 2: "11", 3: -8, -1: 6, -2: x}
+	want := decodeHex(t, "a5010102423131032720062158"+"20"+rfc8037PublicKey)
+	if !bytes.Equal(got, want) {
+		t.Errorf("got %x, want %x", got, want)
+	}
+	_, gotOpts, err := ParseCOSEKey(got)
+	if err != nil || !reflect.DeepEqual(gotOpts, opts) {
+		t.Errorf("parsed options %+v, %v", gotOpts, err)
+	}
+
+	// A private key without x.
+	key, _, err := ParseCOSEKey(decodeHex(t, "a30101200623582"+"0"+rfc8037Seed))
+	if err != nil || !reflect.DeepEqual(key, private) {
+		t.Errorf("private key without x: %x, %v", key, err)
+	}
+}
+
+func TestCOSEKeyErrors(t *testing.T) {
+	x := "5820" + rfc8037PublicKey
+	for _, tc := range []struct {
+		name, hex string
+		want      error
+	}{
+		{"not a map", "80", nil},
+		{"EC2", "a3010220062158" + x[2:], nil},
+		{"X25519", "a3010120042158" + x[2:], nil},
+		{"no crv", "a2010121" + x, nil},
+		{"alg", "a4010103262006" + "21" + x, ErrCOSEAlgorithm},
+		{"kid text", "a401010262313120062158" + x[2:], nil},
+		{"short x", "a30101200621581f" + rfc8037PublicKey[2:], nil},
+		{"x text", "a3010120062161" + "78", nil},
+		{"no x or d", "a201012006", nil},
+		{"short d", "a4010120062158" + x[2:] + "23581f" + rfc8037Seed[2:], nil},
+		{"mismatch", "a4010120062158" + x[2:] + "235820" + rfc8037PublicKey, ErrKeyMismatch},
+	} {
+		if key, _, err := ParseCOSEKey(decodeHex(t, tc.hex)); err == nil || (tc.want != nil && err != tc.want) {
+			t.Errorf("%s: got %x, %v", tc.name, key, err)
+		}
+	}
+
+	public, _, _ := GenerateKey(rand.Reader)
+	if _, err := MarshalCOSEKey(public, &COSEKeyOptions{Algorithm: -7}); err != ErrCOSEAlgorithm {
+		t.Errorf("ES256 gave %v", err)
+	}
+	if _, err := MarshalCOSEKey(public[:31], nil); err != ErrBadPublicKeyLength {
+		t.Errorf("short key gave %v", err)
+	}
+	if _, err := MarshalCOSEKey([]byte(public), nil); err == nil {
+		t.Error("[]byte accepted")
+	}
+}
+
+func TestCOSESign1Vectors(t *testing.T) {
+	private := NewKeyFromSeed(decodeHex(t, rfc8037Seed))
+	public := PublicKey(private[32:])
+	payload := []byte("This is the content.")
+
+	for _, v := range coseSign1Vectors {
+		aad := decodeHex(t, v.externalAAD)
+		message := decodeHex(t, v.message)
+		got, err := SignCOSESign1(private, nil, COSEHeader{4: []byte("11")}, payload, aad)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, message) {
+			t.Errorf("aad %s: got %x, want %x", v.externalAAD, got, message)
+		}
+
+		msg, err := VerifyCOSESign1(public, message, aad)
+		if err != nil {
+			t.Fatalf("aad %s: %v", v.externalAAD, err)
+		}
+		if !bytes.Equal(msg.Payload, payload) ||
+			!reflect.DeepEqual(msg.Protected, COSEHeader{int64(1): int64(-8)}) ||
+			!reflect.DeepEqual(msg.Unprotected, COSEHeader{int64(4): []byte("11")}) {
+			t.Errorf("aad %s: got %+v", v.externalAAD, msg)
+		}
+
+		// The external AAD must match.
+		if _, err := VerifyCOSESign1(public, message, append(aad, 0)); err != ErrInvalidSignature {
+			t.Errorf("aad %s: wrong external AAD gave %v", v.externalAAD, err)
+		}
+		// The untagged message verifies too.
+		if _, err := VerifyCOSESign1(public, message[1:], aad); err != nil {
+			t.Errorf("aad %s: untagged: %v", v.externalAAD, err)
+		}
+	}
+}
+
+func TestCOSESign1(t *testing.T) {
+	public, private, _ := GenerateKey(rand.Reader)
+	protected := COSEHeader{3: "text/plain", "custom": int64(7)}
+	unprotected := COSEHeader{4: []byte("key-1")}
+	message, err := SignCOSESign1(private, protected, unprotected, []byte("hello"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := VerifyCOSESign1(public, message, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := COSEHeader{int64(1): int64(-8), int64(3): "text/plain", "custom": int64(7)}
+	if !reflect.DeepEqual(msg.Protected, want) || string(msg.Payload) != "hello" {
+		t.Errorf("got %+v", msg)
+	}
+
+	other, _, _ := GenerateKey(rand.Reader)
+	if _, err := VerifyCOSESign1(other, message, nil); err != ErrInvalidSignature {
+		t.Errorf("other key gave %v", err)
+	}
+
+	for name, headers := range map[string][2]COSEHeader{
+		"protected ES256": {{1: -7}, nil},
+		"unprotected alg": {nil, {1: -8}},
+		"crit":            {{2: []interface{}{int64(3)}}, nil},
+		"both":            {{4: []byte("a")}, {int64(4): []byte("b")}},
+	} {
+		if _, err := SignCOSESign1(private, headers[0], headers[1], nil, nil); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+	if _, err := SignCOSESign1(private, COSEHeader{1: int64(-8)}, nil, nil, nil); err != nil {
+		t.Errorf("explicit EdDSA alg: %v", err)
+	}
+}
+
+func TestCOSESign1Errors(t *testing.T) {
+	public, private, _ := GenerateKey(rand.Reader)
+	payload := []byte("payload")
+	sign := func(protected []byte, unprotected interface{}) []byte {
+		tbs, _ := coseSigStructure(protected, nil, payload)
+		sig, _ := Sign(private, tbs)
+		b, _ := appendCBOR(nil, cborTag{coseSign1Tag, []interface{}{protected, unprotected, payload, sig}})
+		return b
+	}
+	empty := map[interface{}]interface{}{}
+	valid := sign([]byte{0xa1, 0x01, 0x27}, empty)
+	if _, err := VerifyCOSESign1(public, valid, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	tampered := append([]byte{}, valid...)
+	tampered[len(tampered)-1] ^= 1
+	wrongTag := append([]byte{0xd1}, valid[1:]...) // COSE_Mac0
+	for _, tc := range []struct {
+		name    string
+		message []byte
+		want    error
+	}{
+		{"ES256", sign([]byte{0xa1, 0x01, 0x26}, empty), ErrCOSEAlgorithm},
+		{"no protected header", sign([]byte{}, empty), ErrCOSEAlgorithm},
+		{"alg unprotected", sign([]byte{0xa0}, map[interface{}]interface{}{int64(1): int64(-8)}), ErrCOSEAlgorithm},
+		{"text alg", sign([]byte{0xa1, 0x01, 0x65, 'E', 'd', 'D', 'S', 'A'}, empty), ErrCOSEAlgorithm},
+		{"crit", sign([]byte{0xa2, 0x01, 0x27, 0x02, 0x81, 0x03}, empty), ErrCOSECritical},
+		{"both", sign([]byte{0xa2, 0x01, 0x27, 0x04, 0x40}, map[interface{}]interface{}{int64(4): []byte{}}), nil},
+		{"protected not a map", sign([]byte{0x80}, empty), nil},
+		{"unprotected not a map", sign([]byte{0xa1, 0x01, 0x27}, []interface{}{}), nil},
+		{"tampered", tampered, ErrInvalidSignature},
+		{"wrong tag", wrongTag, nil},
+		{"truncated", valid[:len(valid)-1], nil},
+		{"three items", append([]byte{0x83}, valid[2:len(valid)-66]...), nil},
+	} {
+		if _, err := VerifyCOSESign1(public, tc.message, nil); err == nil || (tc.want != nil && err != tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, err, tc.want)
+		}
+	}
+
+	if _, err := VerifyCOSESign1(public[:31], valid, nil); err != ErrBadPublicKeyLength {
+		t.Errorf("short public key gave %v", err)
+	}
+}
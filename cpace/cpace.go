@@ -0,0 +1,221 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package cpace implements the CPace balanced password-authenticated key
+// exchange of draft-irtf-cfrg-cpace with the CPACE-RISTR255-SHA512 suite, in
+// the initiator-responder setting.
+//
+// Both parties hash the password, the channel identifier and the session
+// identifier to a generator g of ristretto255, and exchange ya*g and yb*g.
+// Each computes K = ya*yb*g and derives the intermediate session key ISK
+// from K and the transcript. Only someone who knew the password when the
+// messages were sent gets the same ISK, and a wrong password can only be
+// tested once per exchange. CPace has no key confirmation: a party with a
+// wrong password simply gets a different ISK, which the application detects
+// when it first uses the key.
+package cpace
+
+import (
+	cryptorand "crypto/rand"
+	"crypto/sha512"
+	"errors"
+	"io"
+
+	"github.com/agl/ed25519/edwards25519"
+	"github.com/agl/ed25519/ristretto255"
+)
+
+// dsi is the domain separation identifier of the suite.
+const dsi = "CPaceRistretto255"
+
+// sInBytes is the input block size of SHA-512, to which the generator string
+// pads the password.
+const sInBytes = 128
+
+// ISKSize is the size, in bytes, of the intermediate session key.
+const ISKSize = sha512.Size
+
+var (
+	errMessage  = errors.New("cpace: invalid message")
+	errIdentity = errors.New("cpace: shared point is the identity")
+)
+
+// appendLen appends x to b after its length, as prepend_len of the draft:
+// the length as an unsigned LEB128 integer.
+func appendLen(b, x []byte) []byte {
+	n := len(x)
+	for n >= 0x80 {
+		b = append(b, byte(n)|0x80)
+		n >>= 7
+	}
+	b = append(b, byte(n))
+	return append(b, x...)
+}
+
+// lvCat implements lv_cat, the concatenation of each of parts after its
+// length.
+func lvCat(parts ...[]byte) []byte {
+	var b []byte
+	for _, p := range parts {
+		b = appendLen(b, p)
+	}
+	return b
+}
+
+// parseLV splits b, the lv_cat encoding of exactly n parts, into those parts.
+func parseLV(b []byte, n int) ([][]byte, bool) {
+	parts := make([][]byte, n)
+	for i := range parts {
+		var length, shift uint
+		for {
+			if len(b) == 0 || shift > 21 {
+				return nil, false
+			}
+			c := b[0]
+			b = b[1:]
+			length |= uint(c&0x7f) << shift
+			shift += 7
+			if c&0x80 == 0 {
+				break
+			}
+		}
+		if uint(len(b)) < length {
+			return nil, false
+		}
+		parts[i], b = b[:length], b[length:]
+	}
+	return parts, len(b) == 0
+}
+
+// generatorString implements generator_string, which pads the password to
+// the end of the first hash block so that its processing can't be told apart
+// from that of other passwords of the same small size.
+func generatorString(prs, ci, sid []byte) []byte {
+	zpadLen := sInBytes - 1 - len(appendLen(nil, prs)) - len(appendLen(nil, []byte(dsi)))
+	if zpadLen < 0 {
+		zpadLen = 0
+	}
+	return lvCat([]byte(dsi), prs, make([]byte, zpadLen), ci, sid)
+}
+
+// calculateGenerator implements calculate_generator, which hashes the
+// password related string prs, the channel identifier ci and the session
+// identifier sid to an element of unknown discrete logarithm.
+func calculateGenerator(prs, ci, sid []byte) *ristretto255.Element {
+	h := sha512.Sum512(generatorString(prs, ci, sid))
+	g, err := new(ristretto255.Element).SetUniformBytes(h[:])
+	if err != nil {
+		panic("cpace: internal error: " + err.Error())
+	}
+	return g
+}
+
+func sampleScalar(rand io.Reader) (*edwards25519.Scalar, error) {
+	if rand == nil {
+		rand = cryptorand.Reader
+	}
+	var b [64]byte
+	if _, err := io.ReadFull(rand, b[:]); err != nil {
+		return nil, err
+	}
+	return edwards25519.NewScalar().SetUniformBytes(b[:])
+}
+
+// scalarMultVfy implements scalar_mult_vfy: it decodes the element encoded
+// as x and returns the encoding of y times it, or an error if the encoding
+// is invalid or the product is the identity.
+func scalarMultVfy(y *edwards25519.Scalar, x []byte) ([]byte, error) {
+	X, err := new(ristretto255.Element).SetCanonicalBytes(x)
+	if err != nil {
+		return nil, errMessage
+	}
+	K := new(ristretto255.Element).ScalarMult(y, X)
+	if K.Equal(ristretto255.NewIdentityElement()) == 1 {
+		return nil, errIdentity
+	}
+	return K.Bytes(), nil
+}
+
+// deriveISK returns the ISK of the initiator-responder setting,
+// H(lv_cat(DSI || "_ISK", sid, K) || lv_cat(Ya, ADa) || lv_cat(Yb, ADb)).
+func deriveISK(sid, K, msgA, msgB []byte) []byte {
+	b := lvCat([]byte(dsi+"_ISK"), sid, K)
+	b = append(b, msgA...)
+	b = append(b, msgB...)
+	isk := sha512.Sum512(b)
+	return isk[:]
+}
+
+// Initiator is the state of the initiator between Start and Finish.
+type Initiator struct {
+	sid  []byte
+	y    *edwards25519.Scalar
+	msgA []byte
+}
+
+// Start begins an exchange as the initiator, with the password prs, the
+// channel identifier ci, which names both parties, the session identifier
+// sid and associated data ad for the responder, and returns the message
+// MSGa to send to the responder. sid should be unique to the session, and
+// may be empty if no such value is available. The secret scalar comes from
+// rand, or from crypto/rand.Reader if rand is nil.
+func Start(prs, ci, sid, ad []byte, rand io.Reader) (*Initiator, []byte, error) {
+	y, err := sampleScalar(rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	i, msgA := start(prs, ci, sid, ad, y)
+	return i, msgA, nil
+}
+
+func start(prs, ci, sid, ad []byte, y *edwards25519.Scalar) (*Initiator, []byte) {
+	g := calculateGenerator(prs, ci, sid)
+	Ya := new(ristretto255.Element).ScalarMult(y, g)
+	msgA := lvCat(Ya.Bytes(), ad)
+	return &Initiator{sid: append([]byte(nil), sid...), y: y, msgA: msgA}, append([]byte(nil), msgA...)
+}
+
+// Finish completes the exchange with the message MSGb of the responder, and
+// returns its associated data and the ISKSize-byte ISK. It returns an error
+// if the message is malformed or leads to the identity, in which case the
+// exchange must be aborted.
+func (i *Initiator) Finish(msgB []byte) (ad, isk []byte, err error) {
+	parts, ok := parseLV(msgB, 2)
+	if !ok {
+		return nil, nil, errMessage
+	}
+	K, err := scalarMultVfy(i.y, parts[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	return parts[1], deriveISK(i.sid, K, i.msgA, msgB), nil
+}
+
+// Respond answers the message MSGa of an initiator with the same password,
+// channel identifier and session identifier as in Start, and the associated
+// data ad for the initiator. It returns the message MSGb to send back, the
+// associated data of the initiator, and the ISKSize-byte ISK. It returns an
+// error if msgA is malformed or leads to the identity.
+func Respond(prs, ci, sid, ad, msgA []byte, rand io.Reader) (msgB, adA, isk []byte, err error) {
+	y, err := sampleScalar(rand)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return respond(prs, ci, sid, ad, msgA, y)
+}
+
+func respond(prs, ci, sid, ad, msgA []byte, y *edwards25519.Scalar) (msgB, adA, isk []byte, err error) {
+	parts, ok := parseLV(msgA, 2)
+	if !ok {
+		return nil, nil, nil, errMessage
+	}
+	K, err := scalarMultVfy(y, parts[0])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	g := calculateGenerator(prs, ci, sid)
+	Yb := new(ristretto255.Element).ScalarMult(y, g)
+	msgB = lvCat(Yb.Bytes(), ad)
+	return msgB, parts[1], deriveISK(sid, K, msgA, msgB), nil
+}
@@ -0,0 +1,222 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cpace
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"encoding/hex"
+	"testing"
+
+	"github.com/agl/ed25519/edwards25519"
+	"github.com/agl/ed25519/ristretto255"
+)
+
+// The inputs of the ristretto255 test vectors of draft-irtf-cfrg-cpace.
+var (
+	vectorPRS = []byte("Password")
+	vectorCI  = []byte("\nAinitiator\nBresponder")
+	vectorSID = decodeHexString("7e4b4791d6a8ef019b936c79fb7f2c57")
+)
+
+func decodeHexString(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func decodeScalar(t *testing.T, s string) *edwards25519.Scalar {
+	t.Helper()
+	x, err := edwards25519.NewScalar().SetCanonicalBytes(decodeHexString(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return x
+}
+
+func TestCalculateGenerator(t *testing.T) {
+	// The generator string pads the password with 100 zero bytes to the end
+	// of the first block of SHA-512.
+	gs := generatorString(vectorPRS, vectorCI, vectorSID)
+	want := "11435061636552697374726574746f3235350850617373776f72646400000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000160a41696e69746961746f720a42726573706f6e646572107e4b4791d6a8ef019b936c79fb7f2c57"
+	if got := hex.EncodeToString(gs); got != want {
+		t.Errorf("got generator string %s, want %s", got, want)
+	}
+	g := calculateGenerator(vectorPRS, vectorCI, vectorSID)
+	if got, want := hex.EncodeToString(g.Bytes()), "5e25411ca1ad7c9debfd0b33ad987a95cefef2d3f15dcc8bd26415a5dfe2e15a"; got != want {
+		t.Errorf("got generator %s, want %s", got, want)
+	}
+}
+
+// TestExchangeVector runs an exchange on the inputs of the draft's
+// ristretto255 vectors, with its scalars ya and yb, and checks Ya, Yb, K and
+// ISK_IR against their definitions in the draft, computed here from the
+// generator that TestCalculateGenerator pins to the appendix.
+func TestExchangeVector(t *testing.T) {
+	ya := decodeScalar(t, "da3d23700a9e5699258aef94dc060dfda5ebb61f02a5ea77fad53f4ff0976d08")
+	yb := decodeScalar(t, "d2316b454718c35362d83d69df6320f38578ed5984651435e2949762d900b80d")
+	i, msgA := start(vectorPRS, vectorCI, vectorSID, []byte("ADa"), ya)
+	msgB, adA, iskB, err := respond(vectorPRS, vectorCI, vectorSID, []byte("ADb"), msgA, yb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	adB, iskA, err := i.Finish(msgB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(adA) != "ADa" || string(adB) != "ADb" {
+		t.Errorf("got associated data %q and %q", adA, adB)
+	}
+
+	// Ya = ya*g and Yb = yb*g, sent as lv_cat(Ya, ADa) and lv_cat(Yb, ADb).
+	g := calculateGenerator(vectorPRS, vectorCI, vectorSID)
+	Ya := new(ristretto255.Element).ScalarMult(ya, g).Bytes()
+	Yb := new(ristretto255.Element).ScalarMult(yb, g).Bytes()
+	if want := lvCat(Ya, []byte("ADa")); !bytes.Equal(msgA, want) {
+		t.Errorf("MSGa: got %x, want %x", msgA, want)
+	}
+	if want := lvCat(Yb, []byte("ADb")); !bytes.Equal(msgB, want) {
+		t.Errorf("MSGb: got %x, want %x", msgB, want)
+	}
+
+	// K = ya*Yb = yb*Ya = ya*yb*g.
+	yayb := edwards25519.NewScalar().Multiply(ya, yb)
+	K := new(ristretto255.Element).ScalarMult(yayb, g).Bytes()
+	kA, err := scalarMultVfy(ya, Yb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kB, err := scalarMultVfy(yb, Ya)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(kA, K) || !bytes.Equal(kB, K) {
+		t.Errorf("K: got %x and %x, want %x", kA, kB, K)
+	}
+
+	// ISK_IR = H(lv_cat(DSI || "_ISK", sid, K) || MSGa || MSGb).
+	transcript := lvCat([]byte("CPaceRistretto255_ISK"), vectorSID, K)
+	transcript = append(transcript, lvCat(Ya, []byte("ADa"))...)
+	transcript = append(transcript, lvCat(Yb, []byte("ADb"))...)
+	want := sha512.Sum512(transcript)
+	if !bytes.Equal(iskA, want[:]) || !bytes.Equal(iskB, want[:]) {
+		t.Errorf("ISK: got %x and %x, want %x", iskA, iskB, want)
+	}
+}
+
+// exchange runs an exchange with random scalars and returns the ISKs of the
+// initiator and the responder.
+func exchange(t *testing.T, prsA, prsB, sid []byte) (iskA, iskB []byte) {
+	t.Helper()
+	i, msgA, err := Start(prsA, vectorCI, sid, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgB, _, iskB, err := Respond(prsB, vectorCI, sid, nil, msgA, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, iskA, err = i.Finish(msgB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return iskA, iskB
+}
+
+func TestExchange(t *testing.T) {
+	iskA, iskB := exchange(t, vectorPRS, vectorPRS, vectorSID)
+	if !bytes.Equal(iskA, iskB) || len(iskA) != ISKSize {
+		t.Fatalf("ISKs differ: %x, %x", iskA, iskB)
+	}
+	if again, _ := exchange(t, vectorPRS, vectorPRS, vectorSID); bytes.Equal(again, iskA) {
+		t.Error("two exchanges gave the same ISK")
+	}
+	if iskA, iskB := exchange(t, vectorPRS, vectorPRS, nil); !bytes.Equal(iskA, iskB) {
+		t.Error("ISKs differ without a session identifier")
+	}
+	if iskA, iskB := exchange(t, vectorPRS, []byte("password"), vectorSID); bytes.Equal(iskA, iskB) {
+		t.Error("different passwords gave the same ISK")
+	}
+
+	// A responder with another session identifier derives another generator.
+	i, msgA, _ := Start(vectorPRS, vectorCI, vectorSID, nil, nil)
+	msgB, _, iskB, err := Respond(vectorPRS, vectorCI, []byte("other"), nil, msgA, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, iskA, _ := i.Finish(msgB); bytes.Equal(iskA, iskB) {
+		t.Error("different session identifiers gave the same ISK")
+	}
+}
+
+func TestSwappedRoles(t *testing.T) {
+	// Two initiators that take each other's message for that of a
+	// responder order the transcript differently, and get different ISKs.
+	i1, msg1, _ := Start(vectorPRS, vectorCI, vectorSID, nil, nil)
+	i2, msg2, _ := Start(vectorPRS, vectorCI, vectorSID, nil, nil)
+	_, isk1, err := i1.Finish(msg2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, isk2, err := i2.Finish(msg1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(isk1, isk2) {
+		t.Error("two initiators agreed on an ISK")
+	}
+
+	// Nor does an initiator agree with itself on a reflected message.
+	i, msgA, _ := Start(vectorPRS, vectorCI, vectorSID, nil, nil)
+	msgB, _, iskB, _ := Respond(vectorPRS, vectorCI, vectorSID, nil, msgA, nil)
+	if _, isk, _ := i.Finish(msgA); bytes.Equal(isk, iskB) {
+		t.Error("reflected message gave the ISK of the responder")
+	}
+	if _, iskA, _ := i.Finish(msgB); !bytes.Equal(iskA, iskB) {
+		t.Error("Finish after a reflected message failed")
+	}
+}
+
+func TestBadMessages(t *testing.T) {
+	i, msgA, _ := Start(vectorPRS, vectorCI, vectorSID, nil, nil)
+	identity := lvCat(ristretto255.NewIdentityElement().Bytes(), nil)
+	if _, _, err := i.Finish(identity); err != errIdentity {
+		t.Errorf("identity: got %v", err)
+	}
+	if _, _, _, err := Respond(vectorPRS, vectorCI, vectorSID, nil, identity, nil); err != errIdentity {
+		t.Errorf("identity: got %v", err)
+	}
+	for _, msg := range [][]byte{
+		nil,
+		msgA[:len(msgA)-1],
+		append(append([]byte(nil), msgA...), 0),
+		lvCat(bytes.Repeat([]byte{0xff}, 32), nil),
+		lvCat(make([]byte, 31), nil),
+		{0x80, 0x80, 0x80, 0x80, 0x01},
+	} {
+		if _, _, err := i.Finish(msg); err != errMessage {
+			t.Errorf("%x: got %v", msg, err)
+		}
+		if _, _, _, err := Respond(vectorPRS, vectorCI, vectorSID, nil, msg, nil); err != errMessage {
+			t.Errorf("%x: got %v", msg, err)
+		}
+	}
+}
+
+func TestLVCat(t *testing.T) {
+	for _, n := range []int{0, 1, 127, 128, 300, 16384} {
+		a, b := bytes.Repeat([]byte{1}, n), []byte("b")
+		enc := lvCat(a, b)
+		parts, ok := parseLV(enc, 2)
+		if !ok || !bytes.Equal(parts[0], a) || !bytes.Equal(parts[1], b) {
+			t.Errorf("%d: round trip failed", n)
+		}
+	}
+	if got := hex.EncodeToString(appendLen(nil, make([]byte, 300))[:2]); got != "ac02" {
+		t.Errorf("length 300 encoded as %s", got)
+	}
+}
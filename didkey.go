@@ -0,0 +1,88 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"errors"
+	"strings"
+)
+
+// A did:key identifier is "did:key:" followed by a multibase string: 'z', for
+// base58btc, and the base58 of the multicodec varint of the key type followed
+// by the key. The varints of Ed25519 (0xed) and X25519 (0xec) public keys
+// are two bytes long, which gives the familiar "z6Mk" and "z6LS" prefixes.
+
+const didKeyPrefix = "did:key:z"
+
+var (
+	didKeyCodecEd25519 = []byte{0xed, 0x01}
+	didKeyCodecX25519  = []byte{0xec, 0x01}
+)
+
+// ErrBadDIDKey is returned by ParseDIDKey and ParseX25519DIDKey when a string
+// is not a did:key identifier of the expected key type.
+var ErrBadDIDKey = errors.New("ed25519: malformed did:key identifier")
+
+// FormatDIDKey returns the did:key identifier of publicKey, which starts with
+// "did:key:z6Mk". It returns the empty string if len(publicKey) is not
+// PublicKeySize.
+func FormatDIDKey(publicKey PublicKey) string {
+	if len(publicKey) != PublicKeySize {
+		return ""
+	}
+	return formatDIDKey(didKeyCodecEd25519, publicKey)
+}
+
+// ParseDIDKey parses a did:key identifier of an Ed25519 public key. The
+// identifier must be exactly as FormatDIDKey writes it: a DID URL with a path
+// or a fragment, another multibase encoding, another multicodec, a
+// non-minimal varint or a key of the wrong length all give ErrBadDIDKey. The
+// key itself is not checked to be a valid point.
+func ParseDIDKey(did string) (PublicKey, error) {
+	b, err := parseDIDKey(didKeyCodecEd25519, did)
+	if err != nil {
+		return nil, err
+	}
+	return PublicKey(b), nil
+}
+
+// FormatX25519DIDKey returns the did:key identifier of an X25519 public key,
+// which starts with "did:key:z6LS". Such identifiers usually name the key
+// agreement key of an Ed25519 identity, whose public key can be converted
+// with extra25519.PublicKeyToCurve25519.
+func FormatX25519DIDKey(x25519PublicKey *[32]byte) string {
+	return formatDIDKey(didKeyCodecX25519, x25519PublicKey[:])
+}
+
+// ParseX25519DIDKey parses a did:key identifier of an X25519 public key, with
+// the same strictness as ParseDIDKey.
+func ParseX25519DIDKey(did string) (*[32]byte, error) {
+	b, err := parseDIDKey(didKeyCodecX25519, did)
+	if err != nil {
+		return nil, err
+	}
+	x25519PublicKey := new([32]byte)
+	copy(x25519PublicKey[:], b)
+	return x25519PublicKey, nil
+}
+
+// formatDIDKey returns the did:key identifier of key, with the multicodec
+// varint codec.
+func formatDIDKey(codec, key []byte) string {
+	return didKeyPrefix + encodeBase58(append(append([]byte(nil), codec...), key...))
+}
+
+// parseDIDKey returns the 32-byte key in did, which must use codec.
+func parseDIDKey(codec []byte, did string) ([]byte, error) {
+	encoded, ok := strings.CutPrefix(did, didKeyPrefix)
+	if !ok {
+		return nil, ErrBadDIDKey
+	}
+	b, ok := decodeBase58(encoded)
+	if !ok || len(b) != len(codec)+32 || string(b[:len(codec)]) != string(codec) {
+		return nil, ErrBadDIDKey
+	}
+	return b[len(codec):], nil
+}
@@ -0,0 +1,111 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/agl/ed25519/extra25519"
+)
+
+// didKeyTests are Ed25519 identifiers from the test vectors of the did:key
+// specification.
+var didKeyTests = []struct {
+	did, publicKey string
+}{
+	{"did:key:z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK", "2e6fcce36701dc791488e0d0b1745cc1e33a4c1c9fcc41c63bd343dbbe0970e6"},
+	{"did:key:z6MkiTBz1ymuepAQ4HEHYSF1H8quG5GLVVQR3djdX3mDooWp", "3b6a27bcceb6a42d62a3a8d02a6f0d73653215771de243a63ac048a18b59da29"},
+	{"did:key:z6MkjchhfUsD6mmvni8mCdXHw216Xrm9bQe2mBH1P5RDjVJG", "4cb5abf6ad79fbf5abbccafcc269d85cd2651ed4b885b5869f241aedf0a5ba29"},
+	{"did:key:z6MknGc3ocHs3zdPiJbnaaqDi58NGb4pk1Sp9WxWufuXSdxf", "7422b9887598068e32c4448a949adb290d0f4e35b9e01b0ee5f1a1e600fe2674"},
+}
+
+func TestDIDKey(t *testing.T) {
+	for _, test := range didKeyTests {
+		publicKey, err := ParseDIDKey(test.did)
+		if err != nil {
+			t.Errorf("%s: %s", test.did, err)
+			continue
+		}
+		if got := hex.EncodeToString(publicKey); got != test.publicKey {
+			t.Errorf("%s: public key %s, want %s", test.did, got, test.publicKey)
+		}
+		if got := FormatDIDKey(publicKey); got != test.did {
+			t.Errorf("FormatDIDKey(%s) = %s", test.publicKey, got)
+		}
+	}
+
+	public, _, _ := GenerateKey(rand.Reader)
+	did := FormatDIDKey(public)
+	if !strings.HasPrefix(did, "did:key:z6Mk") {
+		t.Errorf("FormatDIDKey gave %s", did)
+	}
+	if FormatDIDKey(public[:31]) != "" {
+		t.Error("FormatDIDKey accepted a short key")
+	}
+}
+
+func TestX25519DIDKey(t *testing.T) {
+	// The example of the did:key specification lists this X25519 key
+	// agreement key for its Ed25519 identity.
+	const (
+		ed25519DID = "did:key:z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK"
+		x25519DID  = "did:key:z6LSj72tK8brWgZja8NLRwPigth2T9QRiG1uH9oKZuKjdh9p"
+	)
+	publicKey, err := ParseDIDKey(ed25519DID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var edPublic, x25519Public [32]byte
+	copy(edPublic[:], publicKey)
+	if !extra25519.PublicKeyToCurve25519(&x25519Public, &edPublic) {
+		t.Fatal("conversion failed")
+	}
+	if got := FormatX25519DIDKey(&x25519Public); got != x25519DID {
+		t.Errorf("FormatX25519DIDKey = %s, want %s", got, x25519DID)
+	}
+	parsed, err := ParseX25519DIDKey(x25519DID)
+	if err != nil || *parsed != x25519Public {
+		t.Errorf("ParseX25519DIDKey = %x, %v", parsed, err)
+	}
+
+	if _, err := ParseX25519DIDKey(ed25519DID); err != ErrBadDIDKey {
+		t.Errorf("Ed25519 identifier as X25519 gave %v", err)
+	}
+	if _, err := ParseDIDKey(x25519DID); err != ErrBadDIDKey {
+		t.Errorf("X25519 identifier as Ed25519 gave %v", err)
+	}
+}
+
+func TestDIDKeyErrors(t *testing.T) {
+	good := didKeyTests[0].did
+	var publicKey [32]byte
+	long := "did:key:z" + encodeBase58(append([]byte{0xed, 0x01}, make([]byte, 33)...))
+	nonMinimal := "did:key:z" + encodeBase58(append([]byte{0xed, 0x81, 0x00}, publicKey[:]...))
+	secp256k1 := "did:key:z" + encodeBase58(append([]byte{0xe7, 0x01}, make([]byte, 33)...))
+
+	for _, bad := range []string{
+		"",
+		"did:key:",
+		"did:key:z",
+		strings.Replace(good, "did:key:z", "did:key:f", 1),
+		strings.Replace(good, "did:key:", "did:web:", 1),
+		strings.Replace(good, "did:key:", "DID:key:", 1),
+		good + "#" + strings.TrimPrefix(good, "did:key:"),
+		good + "/path",
+		" " + good,
+		good[:len(good)-1],
+		good + "0",
+		long,
+		nonMinimal,
+		secp256k1,
+	} {
+		if _, err := ParseDIDKey(bad); err != ErrBadDIDKey {
+			t.Errorf("ParseDIDKey(%q) gave %v", bad, err)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"crypto"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// DNSSEC uses Ed25519 as algorithm 15, from RFC 8080. The DNSKEY record holds
+// the bare 32-byte public key and the RRSIG record the bare 64-byte
+// signature, which covers the RRSIG fields before it followed by the RRset in
+// the canonical form of RFC 4034, section 6.
+
+const (
+	// DNSSECAlgorithmEd25519 is the DNSSEC algorithm number of Ed25519.
+	DNSSECAlgorithmEd25519 = 15
+
+	// DNSKEYFlagZone and DNSKEYFlagSEP are the Zone Key and Secure Entry
+	// Point flags of a DNSKEY record. Zone signing keys usually have the
+	// flags 256 and key signing keys 257.
+	DNSKEYFlagZone = 0x0100
+	DNSKEYFlagSEP  = 0x0001
+
+	dnskeyProtocol = 3
+)
+
+var (
+	// ErrRRSIGKey is returned by VerifyRRSIG when the signature names a
+	// key tag other than that of the key, or when the key is not a zone
+	// key.
+	ErrRRSIGKey = errors.New("ed25519: RRSIG is not by this DNSKEY")
+
+	// ErrRRSIGTime is returned by VerifyRRSIG when the signature is not
+	// valid at the given time.
+	ErrRRSIGTime = errors.New("ed25519: RRSIG is outside its validity period")
+)
+
+var errBadDNSSEC = errors.New("ed25519: malformed DNSSEC record")
+
+// RRSIG holds the fields of an RRSIG record that uses Ed25519.
+type RRSIG struct {
+	TypeCovered uint16
+	Labels      uint8
+	OriginalTTL uint32
+
+	// Expiration and Inception are the ends of the validity period, in
+	// seconds since the Unix epoch modulo 2^32.
+	Expiration, Inception uint32
+
+	KeyTag uint16
+
+	// SignerName is the name of the zone in uncompressed wire form, in
+	// lower case, for example "\x07example\x03com\x00".
+	SignerName []byte
+
+	Signature []byte
+}
+
+// MarshalDNSKEY returns the RDATA of a DNSKEY record for publicKey with the
+// given flags: the flags, the protocol, 3, and the algorithm, 15, followed by
+// the key.
+func MarshalDNSKEY(publicKey PublicKey, flags uint16) ([]byte, error) {
+	if len(publicKey) != PublicKeySize {
+		return nil, ErrBadPublicKeyLength
+	}
+	b := []byte{byte(flags >> 8), byte(flags), dnskeyProtocol, DNSSECAlgorithmEd25519}
+	return append(b, publicKey...), nil
+}
+
+// FormatDNSKEY returns the presentation form of the RDATA of a DNSKEY record
+// for publicKey, as in "257 3 15 l02Woi0iS8Aa25FQkUd9RMzZHJpBoRQwAQEX1SxZJA4=".
+// It returns the empty string if len(publicKey) is not PublicKeySize.
+func FormatDNSKEY(publicKey PublicKey, flags uint16) string {
+	if len(publicKey) != PublicKeySize {
+		return ""
+	}
+	return strconv.Itoa(int(flags)) + " 3 15 " + base64.StdEncoding.EncodeToString(publicKey)
+}
+
+// ParseDNSKEY parses the RDATA of a DNSKEY record with an Ed25519 key.
+func ParseDNSKEY(rdata []byte) (publicKey PublicKey, flags uint16, err error) {
+	if len(rdata) != 4+PublicKeySize || rdata[2] != dnskeyProtocol {
+		return nil, 0, errBadDNSSEC
+	}
+	if rdata[3] != DNSSECAlgorithmEd25519 {
+		return nil, 0, errors.New("ed25519: unsupported DNSKEY algorithm")
+	}
+	return append(PublicKey(nil), rdata[4:]...), binary.BigEndian.Uint16(rdata), nil
+}
+
+// DNSKeyTag returns the key tag of the DNSKEY record with the given RDATA,
+// computed as in RFC 4034, appendix B.
+func DNSKeyTag(rdata []byte) uint16 {
+	var ac uint32
+	for i, b := range rdata {
+		if i&1 == 0 {
+			ac += uint32(b) << 8
+		} else {
+			ac += uint32(b)
+		}
+	}
+	ac += ac >> 16 & 0xffff
+	return uint16(ac)
+}
+
+// SignRRSIG signs rrset with signer and sets sig.Signature. rrset must be the
+// RRset that sig covers in canonical form: every record with its owner name
+// and the domain names in its RDATA in uncompressed wire form and in lower
+// case, with the TTL set to sig.OriginalTTL, and the records sorted by their
+// RDATA, as specified in RFC 4034, section 6. The other fields of sig must be
+// set, and sig.KeyTag must be the key tag of the DNSKEY record of signer.
+func SignRRSIG(signer crypto.Signer, sig *RRSIG, rrset []byte) error {
+	if !validDNSName(sig.SignerName) {
+		return errBadDNSSEC
+	}
+	s, err := signWith(signer, append(sig.signedFields(), rrset...))
+	if err != nil {
+		return err
+	}
+	sig.Signature = s
+	return nil
+}
+
+// VerifyRRSIG checks that sig is a valid signature of rrset, in canonical
+// form as for SignRRSIG, by the key in the DNSKEY record with RDATA dnskey,
+// at time now.
+//
+// It returns ErrRRSIGKey if the key tag of dnskey is not sig.KeyTag or if it
+// lacks the Zone Key flag, ErrRRSIGTime if now is not between sig.Inception
+// and sig.Expiration, compared with serial number arithmetic, and
+// ErrInvalidSignature if the signature doesn't verify. Checking that the
+// signer name is the zone of the key and of the RRset is left to the caller.
+func VerifyRRSIG(dnskey []byte, sig *RRSIG, rrset []byte, now time.Time) error {
+	publicKey, flags, err := ParseDNSKEY(dnskey)
+	if err != nil {
+		return err
+	}
+	if !validDNSName(sig.SignerName) {
+		return errBadDNSSEC
+	}
+	if DNSKeyTag(dnskey) != sig.KeyTag || flags&DNSKEYFlagZone == 0 {
+		return ErrRRSIGKey
+	}
+	t := uint32(now.Unix())
+	if int32(t-sig.Inception) < 0 || int32(sig.Expiration-t) < 0 {
+		return ErrRRSIGTime
+	}
+	return CheckSignature(publicKey, append(sig.signedFields(), rrset...), sig.Signature)
+}
+
+// MarshalRRSIG returns the RDATA of an RRSIG record with the fields of sig.
+func MarshalRRSIG(sig *RRSIG) ([]byte, error) {
+	if !validDNSName(sig.SignerName) {
+		return nil, errBadDNSSEC
+	}
+	if len(sig.Signature) != SignatureSize {
+		return nil, ErrBadSignatureLength
+	}
+	return append(sig.signedFields(), sig.Signature...), nil
+}
+
+// ParseRRSIG parses the RDATA of an RRSIG record that uses Ed25519.
+func ParseRRSIG(rdata []byte) (*RRSIG, error) {
+	if len(rdata) < 18 {
+		return nil, errBadDNSSEC
+	}
+	if rdata[2] != DNSSECAlgorithmEd25519 {
+		return nil, errors.New("ed25519: unsupported RRSIG algorithm")
+	}
+	n := dnsNameLength(rdata[18:])
+	if n < 0 || len(rdata) != 18+n+SignatureSize {
+		return nil, errBadDNSSEC
+	}
+	return &RRSIG{
+		TypeCovered: binary.BigEndian.Uint16(rdata),
+		Labels:      rdata[3],
+		OriginalTTL: binary.BigEndian.Uint32(rdata[4:]),
+		Expiration:  binary.BigEndian.Uint32(rdata[8:]),
+		Inception:   binary.BigEndian.Uint32(rdata[12:]),
+		KeyTag:      binary.BigEndian.Uint16(rdata[16:]),
+		SignerName:  append([]byte(nil), rdata[18:18+n]...),
+		Signature:   append([]byte(nil), rdata[18+n:]...),
+	}, nil
+}
+
+// signedFields returns the RDATA of sig without the signature, which is what
+// the signature covers before the RRset.
+func (sig *RRSIG) signedFields() []byte {
+	b := make([]byte, 18, 18+len(sig.SignerName)+SignatureSize)
+	binary.BigEndian.PutUint16(b, sig.TypeCovered)
+	b[2] = DNSSECAlgorithmEd25519
+	b[3] = sig.Labels
+	binary.BigEndian.PutUint32(b[4:], sig.OriginalTTL)
+	binary.BigEndian.PutUint32(b[8:], sig.Expiration)
+	binary.BigEndian.PutUint32(b[12:], sig.Inception)
+	binary.BigEndian.PutUint16(b[16:], sig.KeyTag)
+	return append(b, sig.SignerName...)
+}
+
+// validDNSName reports whether name is exactly one uncompressed domain name
+// in canonical wire form.
+func validDNSName(name []byte) bool {
+	return dnsNameLength(name) == len(name)
+}
+
+// dnsNameLength returns the length of the uncompressed, lower case domain
+// name at the start of b, or -1 if there isn't one.
+func dnsNameLength(b []byte) int {
+	for n := 0; n < len(b) && n < 255; {
+		l := int(b[n])
+		if l == 0 {
+			return n + 1
+		}
+		if l > 63 || n+1+l > len(b) {
+			return -1
+		}
+		for _, c := range b[n+1 : n+1+l] {
+			if 'A' <= c && c <= 'Z' {
+				return -1
+			}
+		}
+		n += 1 + l
+	}
+	return -1
+}
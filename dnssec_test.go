@@ -0,0 +1,163 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+// rfc8080Examples are the examples of RFC 8080, section 6: an MX record of
+// example.com signed by two keys.
+var rfc8080Examples = []struct {
+	privateKey string // the PrivateKey field of the key file, the seed
+	dnskey     string
+	keyTag     uint16
+	signature  string
+}{
+	{
+		"ODIyNjAzODQ2MjgwODAxMjI2NDUxOTAyMDQxNDIyNjI=",
+		"257 3 15 l02Woi0iS8Aa25FQkUd9RMzZHJpBoRQwAQEX1SxZJA4=",
+		3613,
+		"oL9krJun7xfBOIWcGHi7mag5/hdZrKWw15jPGrHpjQeRAvTdszaPD+QLs3fx8A4M3e23mRZ9VrbpMngwcrqNAg==",
+	},
+	{
+		"DSSF3o0s0f+ElWzj9E/Osxw8hLpk55chkmx0LYN5WiY=",
+		"257 3 15 zPnZ/QwEe7S8C5SPz2OfS5RR40ATk2/rYnE9xHIEijs=",
+		35217,
+		"zXQ0bkYgQTEFyfLyi9QoiY6D8ZdYo4wyUhVioYZXFdT410QPRITQSqJSnzQoSm5poJ7gD7AQR0O7KuI5k2pcBg==",
+	},
+}
+
+// rfc8080RRset is "example.com. 3600 IN MX 10 mail.example.com." in canonical
+// wire form.
+var rfc8080RRset = []byte("\x07example\x03com\x00\x00\x0f\x00\x01\x00\x00\x0e\x10\x00\x14" +
+	"\x00\x0a\x04mail\x07example\x03com\x00")
+
+func rfc8080RRSIG(keyTag uint16) *RRSIG {
+	return &RRSIG{
+		TypeCovered: 15, // MX
+		Labels:      2,
+		OriginalTTL: 3600,
+		Expiration:  1440021600,
+		Inception:   1438207200,
+		KeyTag:      keyTag,
+		SignerName:  []byte("\x07example\x03com\x00"),
+	}
+}
+
+func TestRFC8080(t *testing.T) {
+	now := time.Date(2015, 8, 15, 0, 0, 0, 0, time.UTC)
+
+	for _, ex := range rfc8080Examples {
+		seed, _ := base64.StdEncoding.DecodeString(ex.privateKey)
+		private := NewKeyFromSeed(seed)
+		public := PublicKey(private[32:])
+
+		if got := FormatDNSKEY(public, DNSKEYFlagZone|DNSKEYFlagSEP); got != ex.dnskey {
+			t.Errorf("FormatDNSKEY = %q, want %q", got, ex.dnskey)
+		}
+		dnskey, err := MarshalDNSKEY(public, 257)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := DNSKeyTag(dnskey); got != ex.keyTag {
+			t.Errorf("key tag %d, want %d", got, ex.keyTag)
+		}
+
+		sig := rfc8080RRSIG(ex.keyTag)
+		sig.Signature, _ = base64.StdEncoding.DecodeString(ex.signature)
+		if err := VerifyRRSIG(dnskey, sig, rfc8080RRset, now); err != nil {
+			t.Errorf("key tag %d: %s", ex.keyTag, err)
+		}
+
+		signed := rfc8080RRSIG(ex.keyTag)
+		if err := SignRRSIG(private, signed, rfc8080RRset); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(signed.Signature, sig.Signature) {
+			t.Errorf("key tag %d: SignRRSIG gave %s", ex.keyTag, base64.StdEncoding.EncodeToString(signed.Signature))
+		}
+
+		rdata, err := MarshalRRSIG(sig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed, err := ParseRRSIG(rdata)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := VerifyRRSIG(dnskey, parsed, rfc8080RRset, now); err != nil {
+			t.Errorf("key tag %d: parsed RRSIG: %s", ex.keyTag, err)
+		}
+	}
+}
+
+func TestRRSIGErrors(t *testing.T) {
+	now := time.Date(2015, 8, 15, 0, 0, 0, 0, time.UTC)
+	ex := rfc8080Examples[0]
+	seed, _ := base64.StdEncoding.DecodeString(ex.privateKey)
+	public := PublicKey(NewKeyFromSeed(seed)[32:])
+	dnskey, _ := MarshalDNSKEY(public, 257)
+	good := rfc8080RRSIG(ex.keyTag)
+	good.Signature, _ = base64.StdEncoding.DecodeString(ex.signature)
+
+	if err := VerifyRRSIG(dnskey, good, rfc8080RRset[:len(rfc8080RRset)-1], now); err != ErrInvalidSignature {
+		t.Errorf("other RRset gave %v", err)
+	}
+	for _, when := range []time.Time{time.Unix(1438207199, 0), time.Unix(1440021601, 0)} {
+		if err := VerifyRRSIG(dnskey, good, rfc8080RRset, when); err != ErrRRSIGTime {
+			t.Errorf("at %s: got %v, want ErrRRSIGTime", when, err)
+		}
+	}
+	sig := *good
+	sig.KeyTag++
+	if err := VerifyRRSIG(dnskey, &sig, rfc8080RRset, now); err != ErrRRSIGKey {
+		t.Errorf("other key tag gave %v", err)
+	}
+	notZone, _ := MarshalDNSKEY(public, DNSKEYFlagSEP)
+	sig.KeyTag = DNSKeyTag(notZone)
+	if err := VerifyRRSIG(notZone, &sig, rfc8080RRset, now); err != ErrRRSIGKey {
+		t.Errorf("key without the Zone Key flag gave %v", err)
+	}
+
+	// Inception and expiration use serial number arithmetic, so validity
+	// periods may span the wrap of 32-bit time.
+	_, private, _ := GenerateKey(rand.Reader)
+	wrapKey, _ := MarshalDNSKEY(PublicKey(private[32:]), 256)
+	wrapped := &RRSIG{TypeCovered: 1, Inception: 0xffffff00, Expiration: 0x100, KeyTag: DNSKeyTag(wrapKey), SignerName: []byte{0}}
+	if err := SignRRSIG(private, wrapped, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyRRSIG(wrapKey, wrapped, nil, time.Unix(1<<32, 0)); err != nil {
+		t.Errorf("validity period across the wrap: %s", err)
+	}
+
+	for _, name := range []string{"", "\x07example\x03com", "\x07Example\x03com\x00", "\x07example\x03com\x00\x00", "\xc0\x0c"} {
+		sig := *good
+		sig.SignerName = []byte(name)
+		if err := VerifyRRSIG(dnskey, &sig, rfc8080RRset, now); err == nil {
+			t.Errorf("signer name %q accepted", name)
+		}
+		if _, err := MarshalRRSIG(&sig); err == nil {
+			t.Errorf("MarshalRRSIG accepted signer name %q", name)
+		}
+	}
+
+	rdata, _ := MarshalRRSIG(good)
+	for i := 0; i < len(rdata); i++ {
+		if _, err := ParseRRSIG(rdata[:i]); err == nil {
+			t.Errorf("RRSIG truncated to %d bytes accepted", i)
+		}
+	}
+	for _, bad := range [][]byte{dnskey[:35], append(dnskey[:2:2], 4, 15), append([]byte{1, 1, 3, 13}, public...)} {
+		if _, _, err := ParseDNSKEY(bad); err == nil {
+			t.Errorf("DNSKEY %x accepted", bad)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"crypto"
+	"errors"
+)
+
+// maxDomainLength is the longest domain accepted by SignWithDomain.
+const maxDomainLength = 255
+
+var errDomainLength = errors.New("ed25519: domain longer than 255 bytes")
+
+// SignWithDomain signs message with signer after binding it to domain,
+// so that a signature made for one purpose can't be passed off as a
+// signature for another. The signed data is
+//
+//	byte(len(domain)) || domain || message
+//
+// and is an ordinary Ed25519 message, so any Ed25519 verifier can check the
+// signature given the same framing. domain may contain any bytes, including
+// NUL, but must be at most 255 bytes long.
+//
+// Unlike Ed25519ctx (see Options.Context) this needs no support from the
+// verifier beyond plain Ed25519, but signatures made with SignWithDomain
+// and with Sign are not separated from each other: Sign(priv, m) is also a
+// valid domain signature whenever m itself starts with a valid framing.
+// Keys used with SignWithDomain should not also sign raw messages.
+//
+// signer is usually a PrivateKey, but may be any crypto.Signer with an
+// Ed25519 public key.
+func SignWithDomain(signer crypto.Signer, domain string, message []byte) ([]byte, error) {
+	framed, err := frameDomain(domain, message)
+	if err != nil {
+		return nil, err
+	}
+	return signWith(signer, framed)
+}
+
+// VerifyWithDomain reports whether sig is a valid signature of message in
+// domain by publicKey, as produced by SignWithDomain. It returns false if
+// domain is too long.
+func VerifyWithDomain(publicKey PublicKey, domain string, message, sig []byte) bool {
+	framed, err := frameDomain(domain, message)
+	if err != nil {
+		return false
+	}
+	return Verify(publicKey, framed, sig)
+}
+
+// frameDomain returns byte(len(domain)) || domain || message.
+func frameDomain(domain string, message []byte) ([]byte, error) {
+	if len(domain) > maxDomainLength {
+		return nil, errDomainLength
+	}
+	framed := make([]byte, 0, 1+len(domain)+len(message))
+	framed = append(framed, byte(len(domain)))
+	framed = append(framed, domain...)
+	return append(framed, message...), nil
+}
@@ -0,0 +1,84 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSignWithDomain(t *testing.T) {
+	public, private, _ := GenerateKey(zeroReader{})
+	message := []byte("message")
+
+	sig, err := SignWithDomain(private, "com.example.login", message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyWithDomain(public, "com.example.login", message, sig) {
+		t.Errorf("valid domain signature rejected")
+	}
+	if VerifyWithDomain(public, "com.example.payment", message, sig) {
+		t.Errorf("signature accepted in another domain")
+	}
+	if Verify(public, message, sig) {
+		t.Errorf("domain signature accepted as a raw signature")
+	}
+
+	// The framing is exactly byte(len(domain)) || domain || message.
+	framed := append([]byte{17}, "com.example.login"...)
+	framed = append(framed, message...)
+	if !Verify(public, framed, sig) {
+		t.Errorf("signature does not match the documented framing")
+	}
+
+	// The empty domain and domains containing NUL are allowed.
+	for _, domain := range []string{"", "a\x00b", strings.Repeat("d", 255)} {
+		sig, err := SignWithDomain(private, domain, message)
+		if err != nil {
+			t.Errorf("domain %q: %s", domain, err)
+			continue
+		}
+		if !VerifyWithDomain(public, domain, message, sig) {
+			t.Errorf("domain %q: valid signature rejected", domain)
+		}
+	}
+}
+
+func TestSignWithDomainAmbiguity(t *testing.T) {
+	public, private, _ := GenerateKey(zeroReader{})
+
+	sig1, err := SignWithDomain(private, "ab", []byte("c"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig2, err := SignWithDomain(private, "a", []byte("bc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(sig1, sig2) {
+		t.Errorf(`("ab", "c") and ("a", "bc") have the same signature`)
+	}
+	if VerifyWithDomain(public, "a", []byte("bc"), sig1) || VerifyWithDomain(public, "ab", []byte("c"), sig2) {
+		t.Errorf("signature accepted for a different split of the same bytes")
+	}
+}
+
+func TestSignWithDomainErrors(t *testing.T) {
+	public, private, _ := GenerateKey(zeroReader{})
+	long := strings.Repeat("d", 256)
+
+	if _, err := SignWithDomain(private, long, nil); err == nil {
+		t.Errorf("256-byte domain accepted")
+	}
+	sig := mustSign(t, private, nil)
+	if VerifyWithDomain(public, long, nil, sig) {
+		t.Errorf("VerifyWithDomain accepted a 256-byte domain")
+	}
+	if _, err := SignWithDomain(private[:10], "domain", nil); err != ErrBadPrivateKeyLength {
+		t.Errorf("short private key: got %v, want ErrBadPrivateKeyLength", err)
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/sha512"
+)
+
+// PrivateKeyToX25519 lets one key both sign and take part in key exchanges,
+// which is safe for the two uses here but ties them together: a flaw in one
+// protocol may expose the scalar used by the other. DeriveKeys instead
+// derives an Ed25519 key and an X25519 key from one stored seed with
+// HKDF-SHA-512, under the salt dualKeySalt and a different info label for
+// each, so the keys are independent but only the seed needs to be kept.
+
+// dualKeySalt names the derivation of DeriveKeys and its version.
+const dualKeySalt = "github.com/agl/ed25519 DeriveKeys v1"
+
+const (
+	dualKeySigningLabel = "Ed25519 signing key"
+	dualKeyX25519Label  = "X25519 private key"
+)
+
+// DeriveKeys derives from seed an Ed25519 private key for signing and an
+// X25519 private key for Diffie-Hellman, which are unrelated to each other:
+// the X25519 public key is not the PublicKeyToX25519 conversion of the
+// Ed25519 one. The X25519 key is clamped as by PrivateKeyToX25519. seed must
+// be 32 uniformly random bytes kept secret, and the results are as secret as
+// it is.
+func DeriveKeys(seed [32]byte) (signing PrivateKey, dh [32]byte, err error) {
+	signingSeed, err := hkdf.Key(sha512.New, seed[:], []byte(dualKeySalt), dualKeySigningLabel, SeedSize)
+	if err != nil {
+		return nil, dh, err
+	}
+	defer wipeBytes(signingSeed)
+	dhKey, err := hkdf.Key(sha512.New, seed[:], []byte(dualKeySalt), dualKeyX25519Label, 32)
+	if err != nil {
+		return nil, dh, err
+	}
+	defer wipeBytes(dhKey)
+	copy(dh[:], dhKey)
+	dh[0] &= 248
+	dh[31] &= 127
+	dh[31] |= 64
+	return NewKeyFromSeed(signingSeed), dh, nil
+}
+
+// DerivePublicKeys returns the public keys of the private keys that
+// DeriveKeys derives from seed: the Ed25519 public key of signing and the
+// X25519 public key of dh, for publishing side by side.
+func DerivePublicKeys(seed [32]byte) (signing PublicKey, dh [32]byte, err error) {
+	signingKey, dhKey, err := DeriveKeys(seed)
+	if err != nil {
+		return nil, dh, err
+	}
+	defer wipeBytes(signingKey[:32])
+	defer wipeBytes(dhKey[:])
+	priv, err := ecdh.X25519().NewPrivateKey(dhKey[:])
+	if err != nil {
+		return nil, dh, err
+	}
+	copy(dh[:], priv.PublicKey().Bytes())
+	return append(PublicKey(nil), signingKey[32:]...), dh, nil
+}
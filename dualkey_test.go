@@ -0,0 +1,65 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"encoding/hex"
+	"testing"
+)
+
+func TestDeriveKeys(t *testing.T) {
+	var seed [32]byte
+	for i := range seed {
+		seed[i] = byte(i)
+	}
+	// Computed with Python's hmac module for HKDF and libsodium's
+	// crypto_sign_seed_keypair and crypto_scalarmult_curve25519_base.
+	const (
+		signingSeed = "31632f843dc5d609ee26f4a81ab4e7ec81507643bac26ffb6821430c62498d56"
+		signingPub  = "b7f94d663267ef2dc491258ee299c5202e110c25ae9e438e8f4c1445909938cb"
+		dhPriv      = "603a77d77491b50fdf7532a8ed99cba74c54c4b89923bd1232f614c00e5f8276"
+		dhPub       = "7b0bf4a776d52dfd7f79e7a01d96d751eb4fea817a5e68254b240e0f5ef9f860"
+	)
+
+	signing, dh, err := DeriveKeys(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hex.EncodeToString(signing.Seed()); got != signingSeed {
+		t.Errorf("signing seed %s, want %s", got, signingSeed)
+	}
+	if got := hex.EncodeToString(signing[32:]); got != signingPub {
+		t.Errorf("signing public key %s, want %s", got, signingPub)
+	}
+	if got := hex.EncodeToString(dh[:]); got != dhPriv {
+		t.Errorf("X25519 private key %s, want %s", got, dhPriv)
+	}
+
+	signingPublic, dhPublic, err := DerivePublicKeys(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(signingPublic, signing[32:]) {
+		t.Errorf("DerivePublicKeys gave signing key %x, want %s", signingPublic, signingPub)
+	}
+	if got := hex.EncodeToString(dhPublic[:]); got != dhPub {
+		t.Errorf("X25519 public key %s, want %s", got, dhPub)
+	}
+	priv, _ := ecdh.X25519().NewPrivateKey(dh[:])
+	if !bytes.Equal(priv.PublicKey().Bytes(), dhPublic[:]) {
+		t.Errorf("X25519 public key doesn't match the private key")
+	}
+
+	// The keys are not the conversion of one another.
+	if converted, _ := PublicKeyToX25519(signingPublic); converted == dhPublic {
+		t.Error("X25519 key is the conversion of the signing key")
+	}
+	seed[0] ^= 1
+	if other, _, _ := DeriveKeys(seed); bytes.Equal(other, signing) {
+		t.Error("different seeds gave the same signing key")
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import "testing"
+
+// edgeCases covers the classes of edge-case signatures from "Taming the many
+// EdDSAs" (Chalkias, Garillot and Nikolaenko, SSR 2020): small-order and
+// mixed-order A and R, signatures that only satisfy the cofactored equation,
+// non-canonical encodings of A and R, and S >= L. The first twelve are taken
+// from the ed25519vectors corpus (filippo.io/mostly-harmless/ed25519vectors),
+// one for each class; the last two are the prime-order case with S
+// replaced by S + L and by S with its top three bits set.
+//
+// The expected results pin the behaviour of every verification mode, so any
+// change to what is accepted shows up as a change to this table. Batch
+// verification always agrees with VerifyZIP215.
+var edgeCases = []struct {
+	name                               string
+	A, R, S, M                         string
+	verify, strict, cofactored, zip215 bool
+}{
+	{
+		"small-order A and R",
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		"use ristretto255",
+		false, false, true, true,
+	},
+	{
+		"small-order A, mixed-order R",
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		"36684ea91032ba5b1dbab2d02f4debc74c3327f2b3802e2e4d371aa42b12b56b",
+		"05ba9a796274d80437afa36f1236563f2f3b0aa84cecddc3d20914615ba4fe02",
+		"use ristretto255 5",
+		true, false, true, true,
+	},
+	{
+		"mixed-order A, small-order R",
+		"10eb7c3acfb2bed3e0d6ab89bf5a3d6afddd1176ce4812e38d9fd485058fdb1f",
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		"bf189c9ab4c04e8cc8d1460102a9aa7d8c4fcd20a8acd085289774c218f93103",
+		"use ristretto255 5",
+		true, false, true, true,
+	},
+	{
+		"mixed-order A and R, no residue",
+		"10eb7c3acfb2bed3e0d6ab89bf5a3d6afddd1176ce4812e38d9fd485058fdb1f",
+		"36684ea91032ba5b1dbab2d02f4debc74c3327f2b3802e2e4d371aa42b12b56b",
+		"0f472298eb30eee3b820b5b7890b34c2e989090d8a7a75a1e98a5603f348c405",
+		"use ristretto255 3",
+		true, true, true, true,
+	},
+	{
+		"mixed-order A and R, low order residue",
+		"10eb7c3acfb2bed3e0d6ab89bf5a3d6afddd1176ce4812e38d9fd485058fdb1f",
+		"36684ea91032ba5b1dbab2d02f4debc74c3327f2b3802e2e4d371aa42b12b56b",
+		"f46326ed9059dbe9d56b405e4f0474120d279ef694a23727ad207a27ae80f80b",
+		"use ristretto255 2",
+		false, false, true, true,
+	},
+	{
+		"prime-order A and R",
+		"ef75b20e7540e3dff77404193652ba2bd13df99c1508eee1515e27ae25f28076",
+		"b62cf890de42c413b11b1411c9f01f1c4d77aa87ef182258d1251f69af2a3506",
+		"e765a9b6f121a36646a202ee936550996384c27bf0a6661aed1410a04a657501",
+		"use ristretto255 2",
+		true, true, true, true,
+	},
+	{
+		"small-order A only",
+		"0100000000000000000000000000000000000000000000000000000000000000",
+		"b62cf890de42c413b11b1411c9f01f1c4d77aa87ef182258d1251f69af2a3506",
+		"05ba9a796274d80437afa36f1236563f2f3b0aa84cecddc3d20914615ba4fe02",
+		"use ristretto255 4",
+		true, false, true, true,
+	},
+	{
+		"small-order R only",
+		"ef75b20e7540e3dff77404193652ba2bd13df99c1508eee1515e27ae25f28076",
+		"0100000000000000000000000000000000000000000000000000000000000000",
+		"243f9957780c6701deb70384a9846ba548b8cd3147251baf356e878424465f00",
+		"use ristretto255 25",
+		true, false, true, true,
+	},
+	{
+		"non-canonical small-order R",
+		"ef75b20e7540e3dff77404193652ba2bd13df99c1508eee1515e27ae25f28076",
+		"0100000000000000000000000000000000000000000000000000000000000080",
+		"ec5acaa3711508388c273078fef0efd7a70a54c404587d95aabf56f7ac612300",
+		"use ristretto255 39",
+		false, false, false, true,
+	},
+	{
+		"non-canonical R, mixed-order A",
+		"10eb7c3acfb2bed3e0d6ab89bf5a3d6afddd1176ce4812e38d9fd485058fdb1f",
+		"0100000000000000000000000000000000000000000000000000000000000080",
+		"51bdbb2e023a8f2a6ae3edcd3a204ce99e8630583ddb8ea23ec61c9deac32208",
+		"use ristretto255 13",
+		false, false, false, true,
+	},
+	{
+		"non-canonical small-order A",
+		"0100000000000000000000000000000000000000000000000000000000000080",
+		"b62cf890de42c413b11b1411c9f01f1c4d77aa87ef182258d1251f69af2a3506",
+		"05ba9a796274d80437afa36f1236563f2f3b0aa84cecddc3d20914615ba4fe02",
+		"use ristretto255 3",
+		true, false, true, true,
+	},
+	{
+		"non-canonical A, low order residue",
+		"0100000000000000000000000000000000000000000000000000000000000080",
+		"36684ea91032ba5b1dbab2d02f4debc74c3327f2b3802e2e4d371aa42b12b56b",
+		"05ba9a796274d80437afa36f1236563f2f3b0aa84cecddc3d20914615ba4fe02",
+		"use ristretto255",
+		false, false, true, true,
+	},
+	{
+		"S + L",
+		"ef75b20e7540e3dff77404193652ba2bd13df99c1508eee1515e27ae25f28076",
+		"b62cf890de42c413b11b1411c9f01f1c4d77aa87ef182258d1251f69af2a3506",
+		"d4399f130c85b5be1c3ffa90725f2fae6384c27bf0a6661aed1410a04a657511",
+		"use ristretto255 2",
+		false, false, false, false,
+	},
+	{
+		"S with the high bits set",
+		"ef75b20e7540e3dff77404193652ba2bd13df99c1508eee1515e27ae25f28076",
+		"b62cf890de42c413b11b1411c9f01f1c4d77aa87ef182258d1251f69af2a3506",
+		"e765a9b6f121a36646a202ee936550996384c27bf0a6661aed1410a04a6575e1",
+		"use ristretto255 2",
+		false, false, false, false,
+	}}
+
+func TestEdgeCases(t *testing.T) {
+	if len(edgeCases) != 14 {
+		t.Fatalf("expected 14 edge cases, have %d", len(edgeCases))
+	}
+
+	for _, c := range edgeCases {
+		public := PublicKey(decodeHex(t, c.A))
+		sig := append(decodeHex(t, c.R), decodeHex(t, c.S)...)
+		message := []byte(c.M)
+
+		var batch BatchVerifier
+		batchResult := batch.Add(public, message, sig) == nil && batch.VerifyAll()
+
+		for _, mode := range []struct {
+			name      string
+			got, want bool
+		}{
+			{"Verify", Verify(public, message, sig), c.verify},
+			{"StrictVerify", StrictVerify(public, message, sig), c.strict},
+			{"VerifyCofactored", VerifyCofactored(public, message, sig), c.cofactored},
+			{"VerifyZIP215", VerifyZIP215(public, message, sig), c.zip215},
+			{"BatchVerifier", batchResult, c.zip215},
+		} {
+			if mode.got != mode.want {
+				t.Errorf("%s: %s returned %v, want %v", c.name, mode.name, mode.got, mode.want)
+			}
+		}
+	}
+}
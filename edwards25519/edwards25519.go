@@ -562,7 +562,8 @@ func FeInvert(out, z *FieldElement) {
 	FeMul(out, &t1, &t0) // 254..5,3,1,0
 }
 
-func fePow22523(out, z *FieldElement) {
+// FePow22523 sets out to z^((p-5)/8) = z^(2^252 - 3), for square roots.
+func FePow22523(out, z *FieldElement) {
 	var t0, t1, t2 FieldElement
 	var i int
 
@@ -734,7 +735,7 @@ func (p *ExtendedGroupElement) FromBytes(s *[32]byte) bool {
 	FeMul(&p.X, &p.X, &v)
 	FeMul(&p.X, &p.X, &u) // x = uv^7
 
-	fePow22523(&p.X, &p.X) // x = (uv^7)^((q-5)/8)
+	FePow22523(&p.X, &p.X) // x = (uv^7)^((q-5)/8)
 	FeMul(&p.X, &p.X, &v3)
 	FeMul(&p.X, &p.X, &u) // x = uv^3(uv^7)^((q-5)/8)
 
@@ -880,14 +881,16 @@ func slide(r *[256]int8, a *[32]byte) {
 // and b = b[0]+256*b[1]+...+256^31 b[31].
 // B is the Ed25519 base point (x,4/5) with x positive.
 func GeDoubleScalarMultVartime(r *ProjectiveGroupElement, a *[32]byte, A *ExtendedGroupElement, b *[32]byte) {
-	var aSlide, bSlide [256]int8
 	var Ai [8]CachedGroupElement // A,3A,5A,7A,9A,11A,13A,15A
+	GePrecomputeOddMultiples(&Ai, A)
+	GeDoubleScalarMultPrecomputedVartime(r, a, &Ai, b)
+}
+
+// GePrecomputeOddMultiples sets Ai to A,3A,5A,7A,9A,11A,13A,15A, the table
+// used by the variable-time scalar multiplications.
+func GePrecomputeOddMultiples(Ai *[8]CachedGroupElement, A *ExtendedGroupElement) {
 	var t CompletedGroupElement
 	var u, A2 ExtendedGroupElement
-	var i int
-
-	slide(&aSlide, a)
-	slide(&bSlide, b)
 
 	A.ToCached(&Ai[0])
 	A.Double(&t)
@@ -898,6 +901,19 @@ func GeDoubleScalarMultVartime(r *ProjectiveGroupElement, a *[32]byte, A *Extend
 		t.ToExtended(&u)
 		u.ToCached(&Ai[i+1])
 	}
+}
+
+// GeDoubleScalarMultPrecomputedVartime is like GeDoubleScalarMultVartime but
+// takes the table of odd multiples of A computed by GePrecomputeOddMultiples,
+// which saves recomputing it when A is used repeatedly.
+func GeDoubleScalarMultPrecomputedVartime(r *ProjectiveGroupElement, a *[32]byte, Ai *[8]CachedGroupElement, b *[32]byte) {
+	var aSlide, bSlide [256]int8
+	var t CompletedGroupElement
+	var u ExtendedGroupElement
+	var i int
+
+	slide(&aSlide, a)
+	slide(&bSlide, b)
 
 	r.Zero()
 
@@ -1839,3 +1855,142 @@ func ScalarMult(out *ExtendedGroupElement, k *[32]byte, p *ExtendedGroupElement)
 		}
 	}
 }
+
+// IsIdentity returns true if p is the neutral element of the group. This
+// function is not constant time.
+func (p *ProjectiveGroupElement) IsIdentity() bool {
+	var yMinusZ FieldElement
+	FeSub(&yMinusZ, &p.Y, &p.Z)
+	return FeIsNonZero(&p.X) == 0 && FeIsNonZero(&yMinusZ) == 0
+}
+
+// MultByCofactor sets p = 8*p.
+func (p *ProjectiveGroupElement) MultByCofactor() {
+	var t CompletedGroupElement
+	for i := 0; i < 3; i++ {
+		p.Double(&t)
+		t.ToProjective(p)
+	}
+}
+
+// GeMultiScalarMultVartime sets r = a[0]*A[0] + ... + a[n-1]*A[n-1] + b*B
+// where the scalars are encoded as for GeDoubleScalarMultVartime and B is the
+// Ed25519 base point. It panics if a and A have different lengths.
+//
+// This function is not constant time. It shares the doublings between all
+// the points (Straus' method), so it is considerably faster than computing
+// each product separately.
+func GeMultiScalarMultVartime(r *ProjectiveGroupElement, a [][32]byte, A []ExtendedGroupElement, b *[32]byte) {
+	if len(a) != len(A) {
+		panic("edwards25519: mismatched number of scalars and points")
+	}
+
+	aSlide := make([][256]int8, len(a))
+	Ai := make([][8]CachedGroupElement, len(A)) // A,3A,5A,7A,9A,11A,13A,15A
+	var bSlide [256]int8
+	var t CompletedGroupElement
+	var u ExtendedGroupElement
+
+	for j := range A {
+		slide(&aSlide[j], &a[j])
+		GePrecomputeOddMultiples(&Ai[j], &A[j])
+	}
+	slide(&bSlide, b)
+
+	r.Zero()
+
+	i := 255
+top:
+	for ; i >= 0; i-- {
+		if bSlide[i] != 0 {
+			break
+		}
+		for j := range aSlide {
+			if aSlide[j][i] != 0 {
+				break top
+			}
+		}
+	}
+
+	for ; i >= 0; i-- {
+		r.Double(&t)
+
+		for j := range aSlide {
+			if s := aSlide[j][i]; s > 0 {
+				t.ToExtended(&u)
+				geAdd(&t, &u, &Ai[j][s/2])
+			} else if s < 0 {
+				t.ToExtended(&u)
+				geSub(&t, &u, &Ai[j][(-s)/2])
+			}
+		}
+
+		if bSlide[i] > 0 {
+			t.ToExtended(&u)
+			geMixedAdd(&t, &u, &bi[bSlide[i]/2])
+		} else if bSlide[i] < 0 {
+			t.ToExtended(&u)
+			geMixedSub(&t, &u, &bi[(-bSlide[i])/2])
+		}
+
+		t.ToProjective(r)
+	}
+}
+
+// IsSmallOrder returns true if p is one of the eight points whose order
+// divides the cofactor, i.e. if 8*p is the neutral element. This function is
+// not constant time.
+func (p *ExtendedGroupElement) IsSmallOrder() bool {
+	var q ProjectiveGroupElement
+	p.ToProjective(&q)
+	q.MultByCofactor()
+	return q.IsIdentity()
+}
+
+// IsTorsionFree returns true if p is in the prime-order subgroup, i.e. if
+// l*p is the neutral element, where l is the order of the base point. The
+// neutral element itself is torsion free. This function is not constant time.
+func (p *ExtendedGroupElement) IsTorsionFree() bool {
+	var l [32]byte
+	for i := range order {
+		binary.LittleEndian.PutUint64(l[i*8:], order[i])
+	}
+	var r ExtendedGroupElement
+	ScalarMult(&r, &l, p)
+	var q ProjectiveGroupElement
+	r.ToProjective(&q)
+	return q.IsIdentity()
+}
+
+// IsTorsionFreeVartime is like IsTorsionFree, but computes l*p with
+// GeDoubleScalarMultVartime, which is faster but whose running time depends
+// on p. It must only be used on public points.
+func (p *ExtendedGroupElement) IsTorsionFreeVartime() bool {
+	var l, zero [32]byte
+	for i := range order {
+		binary.LittleEndian.PutUint64(l[i*8:], order[i])
+	}
+	var q ProjectiveGroupElement
+	GeDoubleScalarMultVartime(&q, &l, p, &zero)
+	return q.IsIdentity()
+}
+
+// ScIsCanonical returns 1 if the given scalar is less than the order of the
+// curve, and 0 otherwise. Unlike ScMinimal, it runs in constant time.
+func ScIsCanonical(s *[32]byte) int32 {
+	var l [32]byte
+	for i := range order {
+		binary.LittleEndian.PutUint64(l[i*8:], order[i])
+	}
+
+	// Compare from the most significant byte down: c records whether s < l
+	// at the first differing byte, and n whether every byte so far was equal.
+	c, n := int32(0), int32(1)
+	for i := 31; i >= 0; i-- {
+		x, y := int32(s[i]), int32(l[i])
+		c |= ((x - y) >> 8) & n
+		n &= ((x ^ y) - 1) >> 8
+	}
+
+	return c & 1
+}
@@ -0,0 +1,49 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package edwards25519
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"testing"
+)
+
+func TestScIsCanonical(t *testing.T) {
+	var l, lMinusOne, lPlusOne, max [32]byte
+	for i := range order {
+		binary.LittleEndian.PutUint64(l[i*8:], order[i])
+	}
+	lMinusOne, lPlusOne = l, l
+	lMinusOne[0]--
+	lPlusOne[0]++
+	for i := range max {
+		max[i] = 0xff
+	}
+
+	for _, test := range []struct {
+		name string
+		s    [32]byte
+		want int32
+	}{
+		{"zero", [32]byte{}, 1},
+		{"L-1", lMinusOne, 1},
+		{"L", l, 0},
+		{"L+1", lPlusOne, 0},
+		{"2^256-1", max, 0},
+	} {
+		if got := ScIsCanonical(&test.s); got != test.want {
+			t.Errorf("%s: ScIsCanonical = %d, want %d", test.name, got, test.want)
+		}
+	}
+
+	var s [32]byte
+	for i := 0; i < 1000; i++ {
+		rand.Read(s[:])
+		s[31] &= 0x1f
+		if want := ScMinimal(&s); (ScIsCanonical(&s) == 1) != want {
+			t.Errorf("ScIsCanonical(%x) disagrees with ScMinimal", s)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package edwards25519
+
+import (
+	"crypto/subtle"
+	"errors"
+)
+
+// The birational maps of RFC 7748, Section 4.1, between the Edwards curve
+// and Curve25519, v^2 = u^3 + 486662u^2 + u, are
+//
+//	(u, v) = ((1+y)/(1-y), sqrt(-486664)*u/x)
+//	(x, y) = (sqrt(-486664)*u/v, (u-1)/(u+1))
+//
+// They are undefined at the Edwards points with x = 0, which are the
+// identity, y = 1, where 1-y is zero, and the point of order 2, y = -1,
+// where x is zero; that point corresponds to u = 0. On the Montgomery side
+// they are undefined at u = -1, where u+1 is zero, which is not the
+// u-coordinate of a point of Curve25519 but of its twist. The functions
+// below return ErrExceptionalPoint for these inputs instead of dividing by
+// zero. The other points of small order, of order 4 and 8, are not
+// exceptional and convert like any other point.
+
+// sqrtMinus486664 is the square root of -486664 that maps the base point of
+// Ed25519 to that of X25519 with the v given in RFC 7748, Section 4.1.
+var sqrtMinus486664 = FieldElement{
+	12222970, 8312128, 11511410, -9067497, 15300785, 241793, -25456130, -14121551, 12187136, -3972024,
+}
+
+// ErrExceptionalPoint is returned by EdwardsToMontgomery for the identity and
+// the point of order 2, and by MontgomeryToEdwards for u = 0 and u = -1.
+var ErrExceptionalPoint = errors.New("edwards25519: the birational map is undefined at this point")
+
+var (
+	errInvalidMontgomery = errors.New("edwards25519: invalid Montgomery u-coordinate")
+	errLowOrderResult    = errors.New("edwards25519: scalar multiplication gave the all-zero value")
+)
+
+// EdwardsToMontgomery returns the coordinates u and v, each as the canonical
+// 32-byte little-endian encoding of RFC 7748, of the point of Curve25519 that
+// corresponds to p. It returns ErrExceptionalPoint if p is the identity, for
+// which 1-y is zero, or the point of order 2, for which x is zero and v would
+// be a division by zero; every other point, including those of order 4 and
+// 8, is converted.
+func EdwardsToMontgomery(p *Point) (u, v [32]byte, err error) {
+	// FeIsNonZero reduces its argument, so it gets a copy of X.
+	x := p.p.X
+	if FeIsNonZero(&x) == 0 {
+		return u, v, ErrExceptionalPoint
+	}
+	// With x = X/Z and y = Y/Z, u = (Z+Y)/(Z-Y) and v = c*(Z+Y)*Z/((Z-Y)*X),
+	// which share the inverse of (Z-Y)*X.
+	var zPlusY, zMinusY, inv, fu, fv FieldElement
+	FeAdd(&zPlusY, &p.p.Z, &p.p.Y)
+	FeSub(&zMinusY, &p.p.Z, &p.p.Y)
+	FeMul(&inv, &zMinusY, &p.p.X)
+	FeInvert(&inv, &inv)
+
+	FeMul(&fu, &zPlusY, &p.p.X)
+	FeMul(&fu, &fu, &inv)
+	FeMul(&fv, &zPlusY, &p.p.Z)
+	FeMul(&fv, &fv, &sqrtMinus486664)
+	FeMul(&fv, &fv, &inv)
+	FeToBytes(&u, &fu)
+	FeToBytes(&v, &fv)
+	return u, v, nil
+}
+
+// MontgomeryToEdwards returns the point of the Edwards curve that corresponds
+// to a point of Curve25519 with u-coordinate u, in the canonical encoding of
+// RFC 7748. The u-coordinate determines the point up to the sign of x, which
+// sign selects: it must be 0 or 1, and is the least significant bit of x, as
+// in the sign bit of the Edwards encoding.
+//
+// It returns ErrExceptionalPoint if u is 0, which corresponds to the point of
+// order 2 whose x is 0 and has no sign, or if u is -1, that is p-1, where u+1
+// is zero. It returns other errors if sign is not 0 or 1, if u is not
+// canonical, or if u is not the u-coordinate of a point of Curve25519 but of
+// its twist.
+func MontgomeryToEdwards(u [32]byte, sign int) (*Point, error) {
+	if sign != 0 && sign != 1 {
+		return nil, errors.New("edwards25519: sign must be 0 or 1")
+	}
+	var fu FieldElement
+	var canonical [32]byte
+	FeFromBytes(&fu, &u)
+	FeToBytes(&canonical, &fu)
+	if subtle.ConstantTimeCompare(canonical[:], u[:]) != 1 {
+		return nil, errInvalidMontgomery
+	}
+
+	var one, num, den FieldElement
+	FeOne(&one)
+	FeSub(&num, &fu, &one)
+	FeAdd(&den, &fu, &one)
+	if FeIsNonZero(&fu) == 0 || FeIsNonZero(&den) == 0 {
+		return nil, ErrExceptionalPoint
+	}
+	FeInvert(&den, &den)
+	FeMul(&num, &num, &den)
+
+	var s [32]byte
+	FeToBytes(&s, &num)
+	s[31] |= byte(sign) << 7
+	p, err := new(Point).SetBytes(s[:])
+	if err != nil {
+		return nil, errInvalidMontgomery
+	}
+	return p, nil
+}
+
+// BytesMontgomery returns the canonical encoding of the u-coordinate of the
+// point of Curve25519 that corresponds to v, as used by X25519. Since only u
+// is encoded, v and -v give the same result. Unlike EdwardsToMontgomery it
+// doesn't fail: the identity, where the map is undefined, gives 32 zero
+// bytes, the u-coordinate that X25519 uses for the point at infinity, and so
+// does the point of order 2, whose u is 0. Callers that must tell these apart
+// from other points should use EdwardsToMontgomery.
+func (v *Point) BytesMontgomery() []byte {
+	var zPlusY, zMinusY, fu FieldElement
+	FeAdd(&zPlusY, &v.p.Z, &v.p.Y)
+	FeSub(&zMinusY, &v.p.Z, &v.p.Y)
+	FeInvert(&zMinusY, &zMinusY)
+	FeMul(&fu, &zPlusY, &zMinusY)
+	var u [32]byte
+	FeToBytes(&u, &fu)
+	return u[:]
+}
+
+// MontgomeryOptions configures MontgomeryScalarMult. The zero value selects
+// X25519.
+type MontgomeryOptions struct {
+	// Unclamped, if true, multiplies by all 256 bits of the scalar as given,
+	// for protocols that need raw scalars, instead of clamping it as in
+	// RFC 7748, Section 5.
+	Unclamped bool
+}
+
+// a24 is (486662+2)/4, the constant of the doubling formula of the ladder.
+var a24 = FieldElement{121666, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+
+// feCSwap swaps f and g if b == 1 and leaves them unchanged if b == 0, in
+// constant time.
+func feCSwap(f, g *FieldElement, b int32) {
+	t := *f
+	FeCMove(f, g, b)
+	FeCMove(g, &t, b)
+}
+
+// MontgomeryScalarMult returns the u-coordinate of scalar times the point of
+// Curve25519, or of its twist, with u-coordinate u, computed with the
+// constant-time Montgomery ladder of RFC 7748, Section 5. As there, the most
+// significant bit of u is ignored and non-canonical values of u are accepted.
+// The scalar is clamped unless opts says otherwise; a nil opts selects the
+// defaults.
+//
+// It returns an error if the result is all zeros, as it is when u is the
+// u-coordinate of a point of small order.
+func MontgomeryScalarMult(u, scalar [32]byte, opts *MontgomeryOptions) ([32]byte, error) {
+	var o MontgomeryOptions
+	if opts != nil {
+		o = *opts
+	}
+	bits := 256
+	if !o.Unclamped {
+		scalar[0] &= 248
+		scalar[31] &= 127
+		scalar[31] |= 64
+		bits = 255
+	}
+	u[31] &= 127
+
+	var x1, x2, z2, x3, z3, tmp0, tmp1 FieldElement
+	FeFromBytes(&x1, &u)
+	FeOne(&x2)
+	FeZero(&z2)
+	FeCopy(&x3, &x1)
+	FeOne(&z3)
+
+	swap := int32(0)
+	for pos := bits - 1; pos >= 0; pos-- {
+		b := int32(scalar[pos/8]>>uint(pos&7)) & 1
+		swap ^= b
+		feCSwap(&x2, &x3, swap)
+		feCSwap(&z2, &z3, swap)
+		swap = b
+
+		FeSub(&tmp0, &x3, &z3)
+		FeSub(&tmp1, &x2, &z2)
+		FeAdd(&x2, &x2, &z2)
+		FeAdd(&z2, &x3, &z3)
+		FeMul(&z3, &tmp0, &x2)
+		FeMul(&z2, &z2, &tmp1)
+		FeSquare(&tmp0, &tmp1)
+		FeSquare(&tmp1, &x2)
+		FeAdd(&x3, &z3, &z2)
+		FeSub(&z2, &z3, &z2)
+		FeMul(&x2, &tmp1, &tmp0)
+		FeSub(&tmp1, &tmp1, &tmp0)
+		FeSquare(&z2, &z2)
+		FeMul(&z3, &tmp1, &a24)
+		FeSquare(&x3, &x3)
+		FeAdd(&tmp0, &tmp0, &z3)
+		FeMul(&z3, &x1, &z2)
+		FeMul(&z2, &tmp1, &tmp0)
+	}
+	feCSwap(&x2, &x3, swap)
+	feCSwap(&z2, &z3, swap)
+
+	FeInvert(&z2, &z2)
+	FeMul(&x2, &x2, &z2)
+	var out [32]byte
+	FeToBytes(&out, &x2)
+	if FeIsNonZero(&x2) == 0 {
+		return out, errLowOrderResult
+	}
+	return out, nil
+}
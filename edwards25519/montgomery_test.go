@@ -0,0 +1,315 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package edwards25519
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"golang.org/x/crypto/curve25519"
+)
+
+var bigP = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 255), big.NewInt(19))
+
+// torsionPoints are points of order 2, 4 and 8.
+var torsionPoints = []string{
+	"ecffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f",
+	"0000000000000000000000000000000000000000000000000000000000000000",
+	"26e8958fc2b227b045c3f489f2ef98f0d5dfac05d3c63339b13802886d53fc05",
+	"c7176a703d4dd84fba3c0b760d10670f2a2053fa2c39ccc64ec7fd7792ac037a",
+}
+
+func mustPoint(t *testing.T, s string) *Point {
+	b, _ := hex.DecodeString(s)
+	p, err := new(Point).SetBytes(b)
+	if err != nil {
+		t.Fatalf("%s: %v", s, err)
+	}
+	return p
+}
+
+// leToBig converts a little-endian field element encoding to a big.Int.
+func leToBig(b []byte) *big.Int {
+	be := make([]byte, len(b))
+	for i, c := range b {
+		be[len(b)-1-i] = c
+	}
+	return new(big.Int).SetBytes(be)
+}
+
+// onCurve25519 reports whether v^2 = u^3 + 486662u^2 + u.
+func onCurve25519(u, v []byte) bool {
+	bu, bv := leToBig(u), leToBig(v)
+	lhs := new(big.Int).Mul(bv, bv)
+	rhs := new(big.Int).Mul(bu, bu)
+	rhs.Mul(rhs, new(big.Int).Add(bu, big.NewInt(486662)))
+	rhs.Add(rhs, bu)
+	return lhs.Sub(lhs, rhs).Mod(lhs, bigP).Sign() == 0
+}
+
+func TestMontgomeryBasePoint(t *testing.T) {
+	u, v, err := EdwardsToMontgomery(NewGeneratorPoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The base point of RFC 7748, Section 4.1.
+	wantV, _ := new(big.Int).SetString("14781619447589544791020593568409986887264606134616475288964881837755586237401", 10)
+	if !bytes.Equal(u[:], curve25519.Basepoint) || leToBig(v[:]).Cmp(wantV) != 0 {
+		t.Errorf("got u = %x, v = %x", u, v)
+	}
+	p, err := MontgomeryToEdwards(u, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hex.EncodeToString(p.Bytes()) != basePointHex {
+		t.Errorf("got %x", p.Bytes())
+	}
+}
+
+func TestMontgomeryRoundTrip(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		p := new(Point).ScalarBaseMult(randomScalar(t))
+		if i%2 == 1 {
+			// Include points outside the prime-order subgroup.
+			p.Add(p, mustPoint(t, torsionPoints[i/2%len(torsionPoints)]))
+		}
+		u, v, err := EdwardsToMontgomery(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !onCurve25519(u[:], v[:]) {
+			t.Fatalf("(%x, %x) is not on Curve25519", u, v)
+		}
+		if !bytes.Equal(p.BytesMontgomery(), u[:]) {
+			t.Fatalf("BytesMontgomery gave %x, want %x", p.BytesMontgomery(), u)
+		}
+
+		enc := p.Bytes()
+		q, err := MontgomeryToEdwards(u, int(enc[31]>>7))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if q.Equal(p) != 1 {
+			t.Fatalf("%x: got back %x", enc, q.Bytes())
+		}
+		negated, err := MontgomeryToEdwards(u, int(enc[31]>>7)^1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if negated.Equal(new(Point).Negate(p)) != 1 {
+			t.Fatalf("%x: the other sign gave %x", enc, negated.Bytes())
+		}
+	}
+}
+
+func TestBytesMontgomeryX25519(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		var k [32]byte
+		rand.Read(k[:])
+		k[0] &= 248
+		k[31] &= 127
+		k[31] |= 64
+		var p Point
+		GeScalarMultBase(&p.p, &k)
+		want, err := curve25519.X25519(k[:], curve25519.Basepoint)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := p.BytesMontgomery(); !bytes.Equal(got, want) {
+			t.Fatalf("%x: got %x, want %x", k, got, want)
+		}
+	}
+}
+
+func TestMontgomeryExceptional(t *testing.T) {
+	identity := NewIdentityPoint()
+	minusOne := mustPoint(t, torsionPoints[0])
+	for _, p := range []*Point{identity, minusOne} {
+		before := *p
+		if _, _, err := EdwardsToMontgomery(p); err != ErrExceptionalPoint {
+			t.Errorf("%x: got %v", p.Bytes(), err)
+		}
+		if *p != before {
+			t.Errorf("%x: EdwardsToMontgomery modified its argument", p.Bytes())
+		}
+		if got := p.BytesMontgomery(); !bytes.Equal(got, make([]byte, 32)) {
+			t.Errorf("%x: BytesMontgomery gave %x", p.Bytes(), got)
+		}
+	}
+
+	decode := func(s string) (u [32]byte) {
+		hex.Decode(u[:], []byte(s))
+		return
+	}
+	base := decode("0900000000000000000000000000000000000000000000000000000000000000")
+	for _, tc := range []struct {
+		name string
+		u    [32]byte
+	}{
+		{"zero", [32]byte{}},
+		{"p-1", decode("ecffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f")},
+	} {
+		for sign := 0; sign <= 1; sign++ {
+			if _, err := MontgomeryToEdwards(tc.u, sign); err != ErrExceptionalPoint {
+				t.Errorf("%s, sign %d: got %v", tc.name, sign, err)
+			}
+		}
+	}
+	for _, tc := range []struct {
+		name string
+		u    [32]byte
+		sign int
+	}{
+		{"non-canonical", decode("f6ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f"), 0},
+		{"top bit", decode("0900000000000000000000000000000000000000000000000000000000000080"), 0},
+		{"twist", decode("0200000000000000000000000000000000000000000000000000000000000000"), 0},
+		{"sign 2", base, 2},
+		{"sign -1", base, -1},
+	} {
+		if _, err := MontgomeryToEdwards(tc.u, tc.sign); err == nil {
+			t.Errorf("%s: no error", tc.name)
+		}
+	}
+}
+
+// TestMontgomerySmallOrder converts all eight points of small order: the
+// identity and the point of order 2 are exceptional, and the points of order
+// 4 and 8 must map to points of Curve25519 and back.
+func TestMontgomerySmallOrder(t *testing.T) {
+	// torsionPoints[2] has order 8, so its multiples are all the points of
+	// small order.
+	gen := mustPoint(t, torsionPoints[2])
+	p := NewIdentityPoint()
+	seen := make(map[string]bool)
+	for i := 0; i < 8; i++ {
+		seen[hex.EncodeToString(p.Bytes())] = true
+		u, v, err := EdwardsToMontgomery(p)
+		switch {
+		case i == 0 || i == 4:
+			if err != ErrExceptionalPoint {
+				t.Errorf("%d·T = %x: got %v", i, p.Bytes(), err)
+			}
+		case err != nil:
+			t.Errorf("%d·T = %x: %v", i, p.Bytes(), err)
+		case !onCurve25519(u[:], v[:]):
+			t.Errorf("%d·T = %x: (%x, %x) is not on Curve25519", i, p.Bytes(), u, v)
+		case !bytes.Equal(u[:], p.BytesMontgomery()):
+			t.Errorf("%d·T = %x: BytesMontgomery gave %x, want %x", i, p.Bytes(), p.BytesMontgomery(), u)
+		default:
+			sign := int(p.Bytes()[31] >> 7)
+			back, err := MontgomeryToEdwards(u, sign)
+			if err != nil || back.Equal(p) != 1 {
+				t.Errorf("%d·T = %x: round trip gave %v", i, p.Bytes(), err)
+			}
+		}
+		p = new(Point).Add(p, gen)
+	}
+	if p.Equal(NewIdentityPoint()) != 1 || len(seen) != 8 {
+		t.Fatalf("T doesn't have order 8")
+	}
+}
+
+func decodeArray(t *testing.T, s string) (a [32]byte) {
+	if n, err := hex.Decode(a[:], []byte(s)); err != nil || n != 32 {
+		t.Fatalf("bad hex %q", s)
+	}
+	return a
+}
+
+// TestMontgomeryScalarMultRFC7748 uses the test vectors of RFC 7748,
+// Section 5.2.
+func TestMontgomeryScalarMultRFC7748(t *testing.T) {
+	for _, tc := range []struct{ scalar, u, want string }{
+		{
+			"a546e36bf0527c9d3b16154b82465edd62144c0ac1fc5a18506a2244ba449ac4",
+			"e6db6867583030db3594c1a424b15f7c726624ec26b3353b10a903a6d0ab1c4c",
+			"c3da55379de9c6908e94ea4df28d084f32eccf03491c71f754b4075577a28552",
+		},
+		{
+			"4b66e9d4d1b4673c5ad22691957d6af5c11b6421e0ea01d42ca4169e7918ba0d",
+			"e5210f12786811d3f4b7959d0538ae2c31dbe7106fc03c3efc4cd549c715a493",
+			"95cbde9476e8907d7aade45cb4b873f88b595a68799fa152e6f8f7647aac7957",
+		},
+	} {
+		got, err := MontgomeryScalarMult(decodeArray(t, tc.u), decodeArray(t, tc.scalar), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(got[:]) != tc.want {
+			t.Errorf("%s: got %x, want %s", tc.scalar, got, tc.want)
+		}
+	}
+
+	iterations := map[int]string{
+		1:    "422c8e7a6227d7bca1350b3e2bb7279f7897b87bb6854b783c60e80311ae3079",
+		1000: "684cf59ba83309552800ef566f2f4d3c1c3887c49360e3875f2eb94d99532c51",
+	}
+	k := [32]byte{9}
+	u := k
+	for i := 1; i <= 1000; i++ {
+		if i > 1 && testing.Short() {
+			break
+		}
+		next, err := MontgomeryScalarMult(u, k, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		k, u = next, k
+		if want, ok := iterations[i]; ok && hex.EncodeToString(k[:]) != want {
+			t.Errorf("after %d iterations got %x, want %s", i, k, want)
+		}
+	}
+}
+
+func TestMontgomeryScalarMultX25519(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		var k, u [32]byte
+		rand.Read(k[:])
+		rand.Read(u[:])
+		want, err := curve25519.X25519(k[:], u[:])
+		if err != nil {
+			// A random u of small order is vanishingly unlikely.
+			t.Fatal(err)
+		}
+		got, err := MontgomeryScalarMult(u, k, nil)
+		if err != nil || !bytes.Equal(got[:], want) {
+			t.Fatalf("k = %x, u = %x: got %x, %v, want %x", k, u, got, err, want)
+		}
+	}
+}
+
+func TestMontgomeryScalarMultUnclamped(t *testing.T) {
+	base := [32]byte{9}
+	for i := 0; i < 50; i++ {
+		var k [64]byte
+		rand.Read(k[:32])
+		s, err := NewScalar().SetUniformBytes(k[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := new(Point).ScalarBaseMult(s).BytesMontgomery()
+		got, err := MontgomeryScalarMult(base, [32]byte(k[:32]), &MontgomeryOptions{Unclamped: true})
+		if err != nil || !bytes.Equal(got[:], want) {
+			t.Fatalf("k = %x: got %x, %v, want %x", k[:32], got, err, want)
+		}
+	}
+}
+
+func TestMontgomeryScalarMultLowOrder(t *testing.T) {
+	var k [32]byte
+	rand.Read(k[:])
+	for _, p := range torsionPoints {
+		u := [32]byte(mustPoint(t, p).BytesMontgomery())
+		if got, err := MontgomeryScalarMult(u, k, nil); err == nil {
+			t.Errorf("u = %x gave %x and no error", u, got)
+		}
+	}
+	if _, err := MontgomeryScalarMult([32]byte{9}, [32]byte{}, &MontgomeryOptions{Unclamped: true}); err == nil {
+		t.Error("zero scalar gave no error")
+	}
+}
@@ -0,0 +1,209 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package edwards25519
+
+import (
+	"crypto/subtle"
+	"errors"
+)
+
+// Point is a point of the Edwards curve. It wraps ExtendedGroupElement with
+// a method-based API for protocols built on top of the group. The zero value
+// is NOT valid; use NewIdentityPoint, NewGeneratorPoint or SetBytes.
+//
+// Methods set the receiver to the result and return it, so they can be
+// chained, and the arguments may alias the receiver.
+type Point struct {
+	p ExtendedGroupElement
+}
+
+// NewIdentityPoint returns a new Point set to the neutral element.
+func NewIdentityPoint() *Point {
+	v := new(Point)
+	v.p.Zero()
+	return v
+}
+
+// NewGeneratorPoint returns a new Point set to the Ed25519 base point.
+func NewGeneratorPoint() *Point {
+	return new(Point).ScalarBaseMult(scalarOne())
+}
+
+var errInvalidPointEncoding = errors.New("edwards25519: invalid point encoding")
+
+// SetBytes sets v to the point encoded by x, which must be 32 bytes. Like
+// the decoding in ExtendedGroupElement.FromBytes it accepts non-canonical
+// encodings, where y >= p or where x = 0 and the sign bit is set; use
+// SetCanonicalBytes to reject those. If x is not a valid encoding, SetBytes
+// returns nil and an error and v is unchanged.
+func (v *Point) SetBytes(x []byte) (*Point, error) {
+	if len(x) != 32 {
+		return nil, errInvalidPointEncoding
+	}
+	var s [32]byte
+	copy(s[:], x)
+	var p ExtendedGroupElement
+	if !p.FromBytes(&s) {
+		return nil, errInvalidPointEncoding
+	}
+	v.p = p
+	return v, nil
+}
+
+// SetCanonicalBytes is like SetBytes but only accepts the canonical
+// encoding of a point, which is the one returned by Bytes.
+func (v *Point) SetCanonicalBytes(x []byte) (*Point, error) {
+	var p Point
+	if _, err := p.SetBytes(x); err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare(p.Bytes(), x) != 1 {
+		return nil, errInvalidPointEncoding
+	}
+	*v = p
+	return v, nil
+}
+
+// Bytes returns the canonical 32-byte encoding of v.
+func (v *Point) Bytes() []byte {
+	var s [32]byte
+	v.p.ToBytes(&s)
+	return s[:]
+}
+
+// Set sets v = u and returns v.
+func (v *Point) Set(u *Point) *Point {
+	*v = *u
+	return v
+}
+
+// SetExtendedGroupElement sets v = p and returns v.
+func (v *Point) SetExtendedGroupElement(p *ExtendedGroupElement) *Point {
+	v.p = *p
+	return v
+}
+
+// ExtendedGroupElement returns v for use with the lower-level functions of
+// this package.
+func (v *Point) ExtendedGroupElement() ExtendedGroupElement {
+	return v.p
+}
+
+// Add sets v = p + q and returns v.
+func (v *Point) Add(p, q *Point) *Point {
+	var qCached CachedGroupElement
+	var r CompletedGroupElement
+	q.p.ToCached(&qCached)
+	geAdd(&r, &p.p, &qCached)
+	r.ToExtended(&v.p)
+	return v
+}
+
+// Subtract sets v = p - q and returns v.
+func (v *Point) Subtract(p, q *Point) *Point {
+	var qCached CachedGroupElement
+	var r CompletedGroupElement
+	q.p.ToCached(&qCached)
+	geSub(&r, &p.p, &qCached)
+	r.ToExtended(&v.p)
+	return v
+}
+
+// Negate sets v = -p and returns v.
+func (v *Point) Negate(p *Point) *Point {
+	v.p = p.p
+	FeNeg(&v.p.X, &v.p.X)
+	FeNeg(&v.p.T, &v.p.T)
+	return v
+}
+
+// MultByCofactor sets v = 8 * p and returns v.
+func (v *Point) MultByCofactor(p *Point) *Point {
+	var r CompletedGroupElement
+	v.p = p.p
+	for i := 0; i < 3; i++ {
+		v.p.Double(&r)
+		r.ToExtended(&v.p)
+	}
+	return v
+}
+
+// ScalarBaseMult sets v = x * B, where B is the base point, and returns v.
+// It runs in constant time.
+func (v *Point) ScalarBaseMult(x *Scalar) *Point {
+	GeScalarMultBase(&v.p, &x.s)
+	return v
+}
+
+// ScalarMult sets v = x * p and returns v. It runs in constant time.
+func (v *Point) ScalarMult(x *Scalar, p *Point) *Point {
+	q := p.p
+	ScalarMult(&v.p, &x.s, &q)
+	return v
+}
+
+// VarTimeDoubleScalarBaseMult sets v = a * A + b * B, where B is the base
+// point, and returns v. Its running time depends on the inputs, so it must
+// only be used with public values, as in signature verification.
+func (v *Point) VarTimeDoubleScalarBaseMult(a *Scalar, A *Point, b *Scalar) *Point {
+	var r ProjectiveGroupElement
+	GeMultiScalarMultVartime(&r, [][32]byte{a.s}, []ExtendedGroupElement{A.p}, &b.s)
+	v.setProjective(&r)
+	return v
+}
+
+// VarTimeMultiScalarMult sets v = sum(scalars[i] * points[i]) and returns v.
+// It panics if the slices have different lengths. Like
+// VarTimeDoubleScalarBaseMult, it is not constant time.
+func (v *Point) VarTimeMultiScalarMult(scalars []*Scalar, points []*Point) *Point {
+	if len(scalars) != len(points) {
+		panic("edwards25519: mismatched number of scalars and points")
+	}
+	a := make([][32]byte, len(scalars))
+	A := make([]ExtendedGroupElement, len(points))
+	for i := range scalars {
+		a[i] = scalars[i].s
+		A[i] = points[i].p
+	}
+	var zero [32]byte
+	var r ProjectiveGroupElement
+	GeMultiScalarMultVartime(&r, a, A, &zero)
+	v.setProjective(&r)
+	return v
+}
+
+// setProjective sets v to the point represented by r.
+func (v *Point) setProjective(r *ProjectiveGroupElement) {
+	// (X:Y:Z) -> (XZ:YZ:Z^2:XY)
+	FeMul(&v.p.X, &r.X, &r.Z)
+	FeMul(&v.p.Y, &r.Y, &r.Z)
+	FeSquare(&v.p.Z, &r.Z)
+	FeMul(&v.p.T, &r.X, &r.Y)
+}
+
+// Equal returns 1 if v and u are the same point, and 0 otherwise. It runs
+// in constant time.
+func (v *Point) Equal(u *Point) int {
+	var t1, t2, t3, t4 FieldElement
+	FeMul(&t1, &v.p.X, &u.p.Z)
+	FeMul(&t2, &u.p.X, &v.p.Z)
+	FeMul(&t3, &v.p.Y, &u.p.Z)
+	FeMul(&t4, &u.p.Y, &v.p.Z)
+	FeSub(&t1, &t1, &t2)
+	FeSub(&t3, &t3, &t4)
+	return int(1 &^ (FeIsNonZero(&t1) | FeIsNonZero(&t3)))
+}
+
+// IsSmallOrder reports whether 8 * v is the neutral element. It is not
+// constant time.
+func (v *Point) IsSmallOrder() bool {
+	return v.p.IsSmallOrder()
+}
+
+// IsTorsionFree reports whether v is in the prime-order subgroup generated by
+// the base point. It is not constant time.
+func (v *Point) IsTorsionFree() bool {
+	return v.p.IsTorsionFree()
+}
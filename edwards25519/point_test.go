@@ -0,0 +1,198 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package edwards25519
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"math/big"
+	"testing"
+)
+
+const basePointHex = "5866666666666666666666666666666666666666666666666666666666666666"
+
+var bigL, _ = new(big.Int).SetString("7237005577332262213973186563042994240857116359379907606001950938285454250989", 10)
+
+func randomScalar(t *testing.T) *Scalar {
+	var b [64]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewScalar().SetUniformBytes(b[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// scalarToBig converts a little-endian scalar to a big.Int.
+func scalarToBig(s *Scalar) *big.Int {
+	var be [32]byte
+	for i, b := range s.s {
+		be[31-i] = b
+	}
+	return new(big.Int).SetBytes(be[:])
+}
+
+func TestPointEncoding(t *testing.T) {
+	if got := hex.EncodeToString(NewGeneratorPoint().Bytes()); got != basePointHex {
+		t.Errorf("generator encodes as %s, want %s", got, basePointHex)
+	}
+	identity := make([]byte, 32)
+	identity[0] = 1
+	if got := NewIdentityPoint().Bytes(); !bytes.Equal(got, identity) {
+		t.Errorf("identity encodes as %x", got)
+	}
+
+	for i := 0; i < 16; i++ {
+		p := new(Point).ScalarBaseMult(randomScalar(t))
+		q, err := new(Point).SetCanonicalBytes(p.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if q.Equal(p) != 1 {
+			t.Errorf("point does not round-trip through its encoding")
+		}
+	}
+
+	// y = 2 is not on the curve, and y = 1 with the sign bit set is a
+	// non-canonical encoding of the identity.
+	offCurve := make([]byte, 32)
+	offCurve[0] = 2
+	if _, err := new(Point).SetBytes(offCurve); err == nil {
+		t.Errorf("SetBytes accepted a point that is not on the curve")
+	}
+	negativeZero := append([]byte(nil), identity...)
+	negativeZero[31] |= 0x80
+	if p, err := new(Point).SetBytes(negativeZero); err != nil || p.Equal(NewIdentityPoint()) != 1 {
+		t.Errorf("SetBytes rejected a non-canonical encoding of the identity")
+	}
+	if _, err := new(Point).SetCanonicalBytes(negativeZero); err == nil {
+		t.Errorf("SetCanonicalBytes accepted a non-canonical encoding")
+	}
+	if _, err := new(Point).SetBytes(identity[:31]); err == nil {
+		t.Errorf("SetBytes accepted a short encoding")
+	}
+}
+
+func TestPointArithmetic(t *testing.T) {
+	B := NewGeneratorPoint()
+	for i := 0; i < 8; i++ {
+		a, b := randomScalar(t), randomScalar(t)
+		aB := new(Point).ScalarBaseMult(a)
+		bB := new(Point).ScalarBaseMult(b)
+
+		sum := new(Point).ScalarBaseMult(new(Scalar).Add(a, b))
+		if new(Point).Add(aB, bB).Equal(sum) != 1 {
+			t.Errorf("aB + bB != (a+b)B")
+		}
+		difference := new(Point).ScalarBaseMult(new(Scalar).Subtract(a, b))
+		if new(Point).Subtract(aB, bB).Equal(difference) != 1 {
+			t.Errorf("aB - bB != (a-b)B")
+		}
+		if new(Point).Negate(aB).Equal(new(Point).ScalarBaseMult(new(Scalar).Negate(a))) != 1 {
+			t.Errorf("-(aB) != (-a)B")
+		}
+		product := new(Point).ScalarBaseMult(new(Scalar).Multiply(a, b))
+		if new(Point).ScalarMult(a, bB).Equal(product) != 1 {
+			t.Errorf("a(bB) != (ab)B")
+		}
+		if new(Point).ScalarMult(a, B).Equal(aB) != 1 {
+			t.Errorf("ScalarMult and ScalarBaseMult disagree")
+		}
+		if new(Point).VarTimeDoubleScalarBaseMult(a, bB, b).Equal(new(Point).Add(new(Point).ScalarMult(a, bB), bB)) != 1 {
+			t.Errorf("VarTimeDoubleScalarBaseMult(a, bB, b) != a(bB) + bB")
+		}
+		multi := new(Point).VarTimeMultiScalarMult([]*Scalar{a, b}, []*Point{B, B})
+		if multi.Equal(sum) != 1 {
+			t.Errorf("VarTimeMultiScalarMult disagrees with ScalarBaseMult")
+		}
+		if aB.Equal(bB) != 0 {
+			t.Errorf("different points compare equal")
+		}
+		if aB.IsSmallOrder() {
+			t.Errorf("random multiple of the base point has small order")
+		}
+		if !aB.IsTorsionFree() || !aB.p.IsTorsionFreeVartime() {
+			t.Errorf("random multiple of the base point has torsion")
+		}
+
+		// Aliasing the receiver with the arguments must work.
+		p := new(Point).Set(aB)
+		if p.Add(p, p).Equal(new(Point).ScalarBaseMult(new(Scalar).Add(a, a))) != 1 {
+			t.Errorf("p.Add(p, p) != 2p")
+		}
+	}
+
+	if new(Point).MultByCofactor(B).Equal(new(Point).ScalarBaseMult(&Scalar{s: [32]byte{8}})) != 1 {
+		t.Errorf("MultByCofactor(B) != 8B")
+	}
+	if !NewIdentityPoint().IsSmallOrder() {
+		t.Errorf("identity is not small order")
+	}
+	if !NewIdentityPoint().IsTorsionFree() || !NewIdentityPoint().p.IsTorsionFreeVartime() {
+		t.Errorf("identity has torsion")
+	}
+
+	// A point of order 4, and its sum with B, which has order 4l.
+	T, err := new(Point).SetBytes(append(make([]byte, 31), 0x80))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if T.IsTorsionFree() || new(Point).Add(B, T).IsTorsionFree() {
+		t.Errorf("point with a torsion component is torsion free")
+	}
+	if T.p.IsTorsionFreeVartime() || new(Point).Add(B, T).p.IsTorsionFreeVartime() {
+		t.Errorf("IsTorsionFreeVartime: point with a torsion component is torsion free")
+	}
+}
+
+func TestScalarArithmetic(t *testing.T) {
+	for i := 0; i < 32; i++ {
+		x, y, z := randomScalar(t), randomScalar(t), randomScalar(t)
+		bx, by, bz := scalarToBig(x), scalarToBig(y), scalarToBig(z)
+
+		for _, test := range []struct {
+			name string
+			got  *Scalar
+			want *big.Int
+		}{
+			{"Add", new(Scalar).Add(x, y), new(big.Int).Add(bx, by)},
+			{"Subtract", new(Scalar).Subtract(x, y), new(big.Int).Sub(bx, by)},
+			{"Negate", new(Scalar).Negate(x), new(big.Int).Neg(bx)},
+			{"Multiply", new(Scalar).Multiply(x, y), new(big.Int).Mul(bx, by)},
+			{"MultiplyAdd", new(Scalar).MultiplyAdd(x, y, z), new(big.Int).Add(new(big.Int).Mul(bx, by), bz)},
+			{"Invert", new(Scalar).Invert(x), new(big.Int).ModInverse(bx, bigL)},
+		} {
+			test.want.Mod(test.want, bigL)
+			if scalarToBig(test.got).Cmp(test.want) != 0 {
+				t.Errorf("%s: got %v, want %v", test.name, scalarToBig(test.got), test.want)
+			}
+		}
+
+		if back, err := NewScalar().SetCanonicalBytes(x.Bytes()); err != nil || back.Equal(x) != 1 {
+			t.Errorf("scalar does not round-trip through its encoding")
+		}
+	}
+
+	if got := new(Scalar).Invert(NewScalar()); got.Equal(NewScalar()) != 1 {
+		t.Errorf("Invert(0) = %x, want 0", got.Bytes())
+	}
+
+	l := make([]byte, 32)
+	for i, b := range bigL.Bytes() {
+		l[31-i] = b
+	}
+	if _, err := NewScalar().SetCanonicalBytes(l); err == nil {
+		t.Errorf("SetCanonicalBytes accepted L")
+	}
+	if _, err := NewScalar().SetCanonicalBytes(l[:31]); err == nil {
+		t.Errorf("SetCanonicalBytes accepted a short encoding")
+	}
+	if _, err := NewScalar().SetUniformBytes(l); err == nil {
+		t.Errorf("SetUniformBytes accepted a 32-byte input")
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package edwards25519
+
+import (
+	"crypto/subtle"
+	"errors"
+)
+
+// Scalar is an integer modulo the group order
+// L = 2^252 + 27742317777372353535851937790883648493. It wraps the 32-byte
+// little-endian representation used by the Sc functions of this package;
+// the value is always fully reduced. The zero value is the scalar 0.
+//
+// Methods set the receiver to the result and return it, so they can be
+// chained, and the arguments may alias the receiver. All of them run in
+// constant time.
+type Scalar struct {
+	s [32]byte
+}
+
+// scMinusOne is L - 1 in little-endian form.
+var scMinusOne = [32]byte{
+	0xec, 0xd3, 0xf5, 0x5c, 0x1a, 0x63, 0x12, 0x58, 0xd6, 0x9c, 0xf7, 0xa2, 0xde, 0xf9, 0xde, 0x14, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x10,
+}
+
+func scalarOne() *Scalar {
+	return &Scalar{s: [32]byte{1}}
+}
+
+// NewScalar returns a new Scalar set to 0.
+func NewScalar() *Scalar {
+	return new(Scalar)
+}
+
+// SetCanonicalBytes sets x to the scalar encoded by the 32 bytes of b, which
+// must be less than L. Otherwise it returns nil and an error and x is
+// unchanged.
+func (x *Scalar) SetCanonicalBytes(b []byte) (*Scalar, error) {
+	if len(b) != 32 {
+		return nil, errors.New("edwards25519: invalid scalar length")
+	}
+	var s [32]byte
+	copy(s[:], b)
+	if ScIsCanonical(&s) != 1 {
+		return nil, errors.New("edwards25519: non-canonical scalar encoding")
+	}
+	x.s = s
+	return x, nil
+}
+
+// SetUniformBytes sets x to the 64-byte little-endian value b reduced modulo
+// L. If b is uniformly random, so is x. This is how Ed25519 maps SHA-512
+// outputs to scalars.
+func (x *Scalar) SetUniformBytes(b []byte) (*Scalar, error) {
+	if len(b) != 64 {
+		return nil, errors.New("edwards25519: invalid uniform scalar length")
+	}
+	var wide [64]byte
+	copy(wide[:], b)
+	ScReduce(&x.s, &wide)
+	return x, nil
+}
+
+// Bytes returns the canonical 32-byte little-endian encoding of x.
+func (x *Scalar) Bytes() []byte {
+	b := make([]byte, 32)
+	copy(b, x.s[:])
+	return b
+}
+
+// Set sets x = y and returns x.
+func (x *Scalar) Set(y *Scalar) *Scalar {
+	*x = *y
+	return x
+}
+
+// Add sets x = y + z mod L and returns x.
+func (x *Scalar) Add(y, z *Scalar) *Scalar {
+	one := [32]byte{1}
+	ScMulAdd(&x.s, &one, &y.s, &z.s)
+	return x
+}
+
+// Subtract sets x = y - z mod L and returns x.
+func (x *Scalar) Subtract(y, z *Scalar) *Scalar {
+	ScMulAdd(&x.s, &scMinusOne, &z.s, &y.s)
+	return x
+}
+
+// Negate sets x = -y mod L and returns x.
+func (x *Scalar) Negate(y *Scalar) *Scalar {
+	var zero [32]byte
+	ScMulAdd(&x.s, &scMinusOne, &y.s, &zero)
+	return x
+}
+
+// Multiply sets x = y * z mod L and returns x.
+func (x *Scalar) Multiply(y, z *Scalar) *Scalar {
+	var zero [32]byte
+	ScMulAdd(&x.s, &y.s, &z.s, &zero)
+	return x
+}
+
+// MultiplyAdd sets x = y * z + w mod L and returns x.
+func (x *Scalar) MultiplyAdd(y, z, w *Scalar) *Scalar {
+	ScMulAdd(&x.s, &y.s, &z.s, &w.s)
+	return x
+}
+
+// Invert sets x = 1 / t mod L and returns x. If t is zero, x is set to zero.
+func (x *Scalar) Invert(t *Scalar) *Scalar {
+	// 1/t = t^(L-2), by square-and-multiply over the fixed bits of L-2, so
+	// the running time doesn't depend on t.
+	lMinusTwo := scMinusOne
+	lMinusTwo[0]--
+	r := scalarOne()
+	for i := 252; i >= 0; i-- {
+		r.Multiply(r, r)
+		if lMinusTwo[i/8]>>uint(i%8)&1 == 1 {
+			r.Multiply(r, t)
+		}
+	}
+	x.s = r.s
+	return x
+}
+
+// Equal returns 1 if x and y are equal, and 0 otherwise.
+func (x *Scalar) Equal(y *Scalar) int {
+	return subtle.ConstantTimeCompare(x.s[:], y.s[:])
+}
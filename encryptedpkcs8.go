@@ -0,0 +1,346 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	cryptorand "crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"hash"
+	"io"
+)
+
+// Encrypted private keys use PBES2 from RFC 8018, Section 6.2, with PBKDF2
+// as the key derivation function. This is the format of the "ENCRYPTED
+// PRIVATE KEY" PEM blocks written by "openssl pkcs8 -topk8 -v2 aes-256-cbc".
+
+var (
+	oidPBES2          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHMACWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidAES256CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+	oidAES256GCM      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 46}
+)
+
+// ErrIncorrectPassphrase is returned by ParseEncryptedPKCS8,
+// ParseOpenSSHPrivateKey, ParseMinisignSecretKey, ParseSignifyPrivateKey,
+// DecodePKCS12 and ParsePPK when the key fails to decrypt. With AES-CBC a
+// corrupted ciphertext can't be told apart from a wrong passphrase, so that
+// too is reported with this error.
+var ErrIncorrectPassphrase = errors.New("ed25519: incorrect passphrase")
+
+var errMalformedEncryptedPKCS8 = errors.New("ed25519: malformed encrypted PKCS #8 private key")
+
+// DefaultPBKDF2Iterations is the number of PBKDF2-HMAC-SHA256 iterations used
+// by MarshalEncryptedPKCS8 unless EncryptionOptions says otherwise.
+const DefaultPBKDF2Iterations = 600000
+
+// maxPBKDF2Iterations bounds the work that ParseEncryptedPKCS8 does for an
+// untrusted key.
+const maxPBKDF2Iterations = 10000000
+
+// EncryptionCipher selects the cipher used to encrypt a private key.
+type EncryptionCipher int
+
+const (
+	// AES256CBC is AES-256 in CBC mode with PKCS #7 padding, which
+	// OpenSSL and most other tools can read.
+	AES256CBC EncryptionCipher = iota
+	// AES256GCM is AES-256 in GCM mode, as specified by RFC 5084. It
+	// authenticates the ciphertext, but OpenSSL 3.0 can't read it in
+	// PBES2.
+	AES256GCM
+)
+
+// EncryptionOptions configures MarshalEncryptedPKCS8. The zero value selects
+// the defaults.
+type EncryptionOptions struct {
+	// Cipher is the cipher to use. The default is AES256CBC.
+	Cipher EncryptionCipher
+
+	// Iterations is the PBKDF2-HMAC-SHA256 iteration count. If zero,
+	// DefaultPBKDF2Iterations is used.
+	Iterations int
+
+	// Rand is the source of the salt and IV. If nil, crypto/rand.Reader
+	// is used.
+	Rand io.Reader
+}
+
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                      `asn1:"optional"`
+	PRF            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// gcmParams is GCMParameters from RFC 5084, Section 3.2.
+type gcmParams struct {
+	Nonce  []byte
+	ICVLen int `asn1:"optional,default:12"`
+}
+
+// MarshalEncryptedPKCS8 returns the PKCS #8 encoding of privateKey, as
+// produced by MarshalPKCS8PrivateKey, encrypted with a key derived from
+// passphrase using PBKDF2-HMAC-SHA256 and a random 16-byte salt. opts may
+// be nil.
+func MarshalEncryptedPKCS8(privateKey PrivateKey, passphrase []byte, opts *EncryptionOptions) ([]byte, error) {
+	plaintext, err := MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	defer wipeBytes(plaintext)
+
+	algorithm, ciphertext, err := pbes2Encrypt(plaintext, passphrase, opts)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(encryptedPrivateKeyInfo{Algorithm: algorithm, EncryptedData: ciphertext})
+}
+
+// pbes2Encrypt encrypts plaintext with PBES2 as configured by opts, which may
+// be nil, and returns the PBES2 algorithm identifier and the ciphertext.
+func pbes2Encrypt(plaintext, passphrase []byte, opts *EncryptionOptions) (pkix.AlgorithmIdentifier, []byte, error) {
+	var o EncryptionOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Iterations == 0 {
+		o.Iterations = DefaultPBKDF2Iterations
+	}
+	if o.Iterations < 0 || o.Iterations > maxPBKDF2Iterations {
+		return pkix.AlgorithmIdentifier{}, nil, errors.New("ed25519: bad PBKDF2 iteration count")
+	}
+	if o.Rand == nil {
+		o.Rand = cryptorand.Reader
+	}
+
+	salt := make([]byte, 16)
+	if _, err := io.ReadFull(o.Rand, salt); err != nil {
+		return pkix.AlgorithmIdentifier{}, nil, err
+	}
+	key, err := pbkdf2.Key(sha256.New, string(passphrase), salt, o.Iterations, 32)
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, nil, err
+	}
+	defer wipeBytes(key)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, nil, err
+	}
+
+	var scheme pkix.AlgorithmIdentifier
+	var ciphertext []byte
+	switch o.Cipher {
+	case AES256CBC:
+		iv := make([]byte, aes.BlockSize)
+		if _, err := io.ReadFull(o.Rand, iv); err != nil {
+			return pkix.AlgorithmIdentifier{}, nil, err
+		}
+		padding := aes.BlockSize - len(plaintext)%aes.BlockSize
+		padded := append(append([]byte{}, plaintext...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+		defer wipeBytes(padded)
+		ciphertext = make([]byte, len(padded))
+		cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, padded)
+		scheme, err = newAlgorithmIdentifier(oidAES256CBC, iv)
+	case AES256GCM:
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return pkix.AlgorithmIdentifier{}, nil, err
+		}
+		nonce := make([]byte, aead.NonceSize())
+		if _, err := io.ReadFull(o.Rand, nonce); err != nil {
+			return pkix.AlgorithmIdentifier{}, nil, err
+		}
+		ciphertext = aead.Seal(nil, nonce, plaintext, nil)
+		scheme, err = newAlgorithmIdentifier(oidAES256GCM, gcmParams{Nonce: nonce, ICVLen: aead.Overhead()})
+	default:
+		return pkix.AlgorithmIdentifier{}, nil, errors.New("ed25519: unknown encryption cipher")
+	}
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, nil, err
+	}
+
+	prf := pkix.AlgorithmIdentifier{Algorithm: oidHMACWithSHA256, Parameters: asn1.NullRawValue}
+	kdf, err := newAlgorithmIdentifier(oidPBKDF2, pbkdf2Params{Salt: salt, IterationCount: o.Iterations, PRF: prf})
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, nil, err
+	}
+	pbes2, err := newAlgorithmIdentifier(oidPBES2, pbes2Params{KeyDerivationFunc: kdf, EncryptionScheme: scheme})
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, nil, err
+	}
+	return pbes2, ciphertext, nil
+}
+
+// ParseEncryptedPKCS8 decrypts and parses a private key produced by
+// MarshalEncryptedPKCS8, or by OpenSSL with PBES2, PBKDF2 using
+// HMAC-SHA-1 or HMAC-SHA-256, and AES-256-CBC or AES-256-GCM.
+//
+// It returns ErrIncorrectPassphrase if the key doesn't decrypt to a valid
+// private key, and other errors if der is malformed or uses an unsupported
+// algorithm. Iteration counts above 10,000,000 are rejected, to bound the
+// work done for an untrusted input.
+func ParseEncryptedPKCS8(der, passphrase []byte) (PrivateKey, error) {
+	var info encryptedPrivateKeyInfo
+	if rest, err := asn1.Unmarshal(der, &info); err != nil || len(rest) != 0 {
+		return nil, errMalformedEncryptedPKCS8
+	}
+	plaintext, err := pbes2Decrypt(info.Algorithm, info.EncryptedData, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer wipeBytes(plaintext)
+
+	privateKey, err := ParsePKCS8PrivateKey(plaintext)
+	if err != nil {
+		return nil, ErrIncorrectPassphrase
+	}
+	return privateKey, nil
+}
+
+// pbes2Decrypt decrypts ciphertext, encrypted with the PBES2 algorithm
+// identified by algorithm, and returns the plaintext, which the caller should
+// wipe. A bad padding or authentication tag is ErrIncorrectPassphrase.
+func pbes2Decrypt(algorithm pkix.AlgorithmIdentifier, ciphertext, passphrase []byte) ([]byte, error) {
+	if !algorithm.Algorithm.Equal(oidPBES2) {
+		return nil, errors.New("ed25519: unsupported private key encryption: " + algorithm.Algorithm.String())
+	}
+	var params pbes2Params
+	if err := unmarshalParameters(algorithm, &params); err != nil {
+		return nil, err
+	}
+
+	if !params.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) {
+		return nil, errors.New("ed25519: unsupported key derivation function: " + params.KeyDerivationFunc.Algorithm.String())
+	}
+	var kdf pbkdf2Params
+	if err := unmarshalParameters(params.KeyDerivationFunc, &kdf); err != nil {
+		return nil, err
+	}
+	var prf func() hash.Hash
+	switch {
+	case len(kdf.PRF.Algorithm) == 0, kdf.PRF.Algorithm.Equal(oidHMACWithSHA1):
+		prf = sha1.New
+	case kdf.PRF.Algorithm.Equal(oidHMACWithSHA256):
+		prf = sha256.New
+	default:
+		return nil, errors.New("ed25519: unsupported PBKDF2 PRF: " + kdf.PRF.Algorithm.String())
+	}
+	if kdf.IterationCount <= 0 || kdf.IterationCount > maxPBKDF2Iterations {
+		return nil, errors.New("ed25519: bad PBKDF2 iteration count")
+	}
+	if kdf.KeyLength != 0 && kdf.KeyLength != 32 {
+		return nil, errors.New("ed25519: bad PBKDF2 key length")
+	}
+
+	var open func(block cipher.Block) ([]byte, error)
+	switch scheme := params.EncryptionScheme; {
+	case scheme.Algorithm.Equal(oidAES256CBC):
+		var iv []byte
+		if err := unmarshalParameters(scheme, &iv); err != nil {
+			return nil, err
+		}
+		if len(iv) != aes.BlockSize || len(ciphertext) == 0 || len(ciphertext)%aes.BlockSize != 0 {
+			return nil, errMalformedEncryptedPKCS8
+		}
+		open = func(block cipher.Block) ([]byte, error) {
+			padded := make([]byte, len(ciphertext))
+			cipher.NewCBCDecrypter(block, iv).CryptBlocks(padded, ciphertext)
+			plaintext, ok := unpad(padded)
+			if !ok {
+				wipeBytes(padded)
+				return nil, ErrIncorrectPassphrase
+			}
+			return plaintext, nil
+		}
+	case scheme.Algorithm.Equal(oidAES256GCM):
+		var gcm gcmParams
+		if err := unmarshalParameters(scheme, &gcm); err != nil {
+			return nil, err
+		}
+		if len(gcm.Nonce) != 12 || gcm.ICVLen != 16 {
+			return nil, errors.New("ed25519: unsupported AES-GCM parameters")
+		}
+		open = func(block cipher.Block) ([]byte, error) {
+			aead, err := cipher.NewGCM(block)
+			if err != nil {
+				return nil, err
+			}
+			plaintext, err := aead.Open(nil, gcm.Nonce, ciphertext, nil)
+			if err != nil {
+				return nil, ErrIncorrectPassphrase
+			}
+			return plaintext, nil
+		}
+	default:
+		return nil, errors.New("ed25519: unsupported encryption scheme: " + scheme.Algorithm.String())
+	}
+
+	key, err := pbkdf2.Key(prf, string(passphrase), kdf.Salt, kdf.IterationCount, 32)
+	if err != nil {
+		return nil, err
+	}
+	defer wipeBytes(key)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return open(block)
+}
+
+// newAlgorithmIdentifier returns an AlgorithmIdentifier with the DER
+// encoding of params as its parameters.
+func newAlgorithmIdentifier(oid asn1.ObjectIdentifier, params interface{}) (pkix.AlgorithmIdentifier, error) {
+	der, err := asn1.Marshal(params)
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, err
+	}
+	return pkix.AlgorithmIdentifier{Algorithm: oid, Parameters: asn1.RawValue{FullBytes: der}}, nil
+}
+
+// unmarshalParameters decodes the parameters of algorithm into out.
+func unmarshalParameters(algorithm pkix.AlgorithmIdentifier, out interface{}) error {
+	rest, err := asn1.Unmarshal(algorithm.Parameters.FullBytes, out)
+	if err != nil || len(rest) != 0 {
+		return errMalformedEncryptedPKCS8
+	}
+	return nil
+}
+
+// unpad removes PKCS #7 padding from b. It runs in time that depends only
+// on len(b), since the padding is checked before the key is authenticated.
+func unpad(b []byte) ([]byte, bool) {
+	n := int(b[len(b)-1])
+	good := subtle.ConstantTimeLessOrEq(1, n) & subtle.ConstantTimeLessOrEq(n, aes.BlockSize)
+	for i := 1; i <= aes.BlockSize; i++ {
+		inPadding := subtle.ConstantTimeLessOrEq(i, n)
+		matches := subtle.ConstantTimeByteEq(b[len(b)-i], byte(n))
+		good &= subtle.ConstantTimeSelect(inPadding, matches, 1)
+	}
+	if good != 1 {
+		return nil, false
+	}
+	return b[:len(b)-n], true
+}
@@ -0,0 +1,173 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/asn1"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const testPassphrase = "correct-horse"
+
+func TestEncryptedPKCS8RoundTrip(t *testing.T) {
+	_, private, _ := GenerateKey(rand.Reader)
+	for _, c := range []EncryptionCipher{AES256CBC, AES256GCM} {
+		der, err := MarshalEncryptedPKCS8(private, []byte(testPassphrase), &EncryptionOptions{Cipher: c, Iterations: 1000})
+		if err != nil {
+			t.Fatalf("cipher %d: %v", c, err)
+		}
+		parsed, err := ParseEncryptedPKCS8(der, []byte(testPassphrase))
+		if err != nil {
+			t.Fatalf("cipher %d: %v", c, err)
+		}
+		if !bytes.Equal(parsed, private) {
+			t.Errorf("cipher %d: round trip changed the key", c)
+		}
+		if _, err := ParseEncryptedPKCS8(der, []byte("wrong")); err != ErrIncorrectPassphrase {
+			t.Errorf("cipher %d: wrong passphrase gave %v", c, err)
+		}
+	}
+}
+
+func TestEncryptedPKCS8Salt(t *testing.T) {
+	_, private, _ := GenerateKey(rand.Reader)
+	opts := &EncryptionOptions{Iterations: 1000}
+	a, _ := MarshalEncryptedPKCS8(private, []byte(testPassphrase), opts)
+	b, _ := MarshalEncryptedPKCS8(private, []byte(testPassphrase), opts)
+	if bytes.Equal(a, b) {
+		t.Error("two encryptions of the same key are identical")
+	}
+}
+
+func TestEncryptedPKCS8OpenSSL(t *testing.T) {
+	want := readPEM(t, "testdata/openssl_ed25519.pem", "PRIVATE KEY")
+	wantKey, err := ParsePKCS8PrivateKey(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{
+		"testdata/openssl_ed25519_encrypted.pem",
+		"testdata/openssl_ed25519_encrypted_sha1.pem",
+	} {
+		der := readPEM(t, path, "ENCRYPTED PRIVATE KEY")
+		private, err := ParseEncryptedPKCS8(der, []byte(testPassphrase))
+		if err != nil {
+			t.Errorf("%s: %v", path, err)
+			continue
+		}
+		if !bytes.Equal(private, wantKey) {
+			t.Errorf("%s: decrypted to the wrong key", path)
+		}
+		if _, err := ParseEncryptedPKCS8(der, []byte("correct-horse-battery")); err != ErrIncorrectPassphrase {
+			t.Errorf("%s: wrong passphrase gave %v", path, err)
+		}
+	}
+
+	der := readPEM(t, "testdata/openssl_ed25519_encrypted_scrypt.pem", "ENCRYPTED PRIVATE KEY")
+	_, err = ParseEncryptedPKCS8(der, []byte(testPassphrase))
+	if err == nil || !strings.Contains(err.Error(), "unsupported key derivation function") {
+		t.Errorf("scrypt key gave %v", err)
+	}
+}
+
+func TestEncryptedPKCS8Errors(t *testing.T) {
+	_, private, _ := GenerateKey(rand.Reader)
+	passphrase := []byte(testPassphrase)
+
+	if _, err := MarshalEncryptedPKCS8(private[:10], passphrase, nil); err != ErrBadPrivateKeyLength {
+		t.Errorf("short key gave %v", err)
+	}
+	if _, err := MarshalEncryptedPKCS8(private, passphrase, &EncryptionOptions{Iterations: -1}); err == nil {
+		t.Error("negative iteration count accepted")
+	}
+	if _, err := MarshalEncryptedPKCS8(private, passphrase, &EncryptionOptions{Cipher: 2, Iterations: 1000}); err == nil {
+		t.Error("unknown cipher accepted")
+	}
+	if _, err := MarshalEncryptedPKCS8(private, passphrase, &EncryptionOptions{Rand: failingReader{}}); err == nil {
+		t.Error("failing reader accepted")
+	}
+
+	der, err := MarshalEncryptedPKCS8(private, passphrase, &EncryptionOptions{Iterations: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Structural damage is reported as such, never as a wrong passphrase.
+	for _, corrupt := range [][]byte{
+		nil,
+		der[:len(der)-1],
+		append(append([]byte{}, der...), 0),
+	} {
+		_, err := ParseEncryptedPKCS8(corrupt, passphrase)
+		if err == nil || err == ErrIncorrectPassphrase {
+			t.Errorf("corrupt structure of length %d gave %v", len(corrupt), err)
+		}
+	}
+
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		t.Fatal(err)
+	}
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		t.Fatal(err)
+	}
+	var kdf pbkdf2Params
+	if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdf); err != nil {
+		t.Fatal(err)
+	}
+	remarshal := func(kdf pbkdf2Params, data []byte) []byte {
+		kdfID, err := newAlgorithmIdentifier(oidPBKDF2, kdf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pbes2, err := newAlgorithmIdentifier(oidPBES2, pbes2Params{kdfID, params.EncryptionScheme})
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := asn1.Marshal(encryptedPrivateKeyInfo{pbes2, data})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+
+	// Re-encoding the parsed structure must give back something valid.
+	if _, err := ParseEncryptedPKCS8(remarshal(kdf, info.EncryptedData), passphrase); err != nil {
+		t.Fatalf("re-encoded key: %v", err)
+	}
+
+	huge := kdf
+	huge.IterationCount = maxPBKDF2Iterations + 1
+	if _, err := ParseEncryptedPKCS8(remarshal(huge, info.EncryptedData), passphrase); err == nil || err == ErrIncorrectPassphrase {
+		t.Errorf("huge iteration count gave %v", err)
+	}
+	badLength := kdf
+	badLength.KeyLength = 16
+	if _, err := ParseEncryptedPKCS8(remarshal(badLength, info.EncryptedData), passphrase); err == nil || err == ErrIncorrectPassphrase {
+		t.Errorf("bad key length gave %v", err)
+	}
+	if _, err := ParseEncryptedPKCS8(remarshal(kdf, info.EncryptedData[:len(info.EncryptedData)-1]), passphrase); err == nil || err == ErrIncorrectPassphrase {
+		t.Errorf("partial block gave %v", err)
+	}
+	badPRF := kdf
+	badPRF.PRF.Algorithm = oidEd25519
+	if _, err := ParseEncryptedPKCS8(remarshal(badPRF, info.EncryptedData), passphrase); err == nil || err == ErrIncorrectPassphrase {
+		t.Errorf("unknown PRF gave %v", err)
+	}
+
+	// Damage to the ciphertext is indistinguishable from a wrong
+	// passphrase with CBC.
+	tampered := append([]byte{}, info.EncryptedData...)
+	tampered[len(tampered)-1] ^= 1
+	if _, err := ParseEncryptedPKCS8(remarshal(kdf, tampered), passphrase); !errors.Is(err, ErrIncorrectPassphrase) {
+		t.Errorf("tampered ciphertext gave %v", err)
+	}
+}
@@ -0,0 +1,235 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package envelope implements a small versioned envelope for Ed25519
+// signatures, carrying the signing key's ID and the time of signing along
+// with the payload.
+//
+// An encoded envelope, in version 1, is
+//
+//	version       1 byte, 1
+//	algorithm     1 byte, 1 for Ed25519
+//	key ID        16 bytes, see KeyIDOf
+//	created       8 bytes, seconds since the Unix epoch
+//	payload size  4 bytes
+//	payload       payload size bytes
+//	signature     64 bytes
+//
+// with integers in big-endian order. The signature covers every byte that
+// precedes it, framed as by ed25519.SignWithDomain with the domain
+// "ed25519 envelope", so it can't be confused with a signature made with the
+// same key for another purpose.
+package envelope
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"time"
+
+	"github.com/agl/ed25519"
+)
+
+const (
+	// Version is the envelope version produced by Seal.
+	Version = 1
+
+	// AlgorithmEd25519 identifies plain Ed25519 signatures.
+	AlgorithmEd25519 = 1
+
+	headerSize = 1 + 1 + KeyIDSize + 8 + 4
+	domain     = "ed25519 envelope"
+)
+
+// DefaultMaxSkew is how far in the future the creation time of an envelope
+// may be, to allow for clock differences, if OpenOptions.MaxSkew is zero.
+const DefaultMaxSkew = 5 * time.Minute
+
+var (
+	// ErrUnknownVersion is returned by Open for envelopes with a version
+	// it does not understand.
+	ErrUnknownVersion = errors.New("envelope: unknown version")
+	// ErrUnknownAlgorithm is returned by Open for envelopes with an
+	// unknown algorithm.
+	ErrUnknownAlgorithm = errors.New("envelope: unknown algorithm")
+	// ErrUnknownKey can be returned by a KeyResolver that doesn't know a
+	// key ID. Open also returns it if the resolver returns a nil key.
+	ErrUnknownKey = errors.New("envelope: unknown key ID")
+	// ErrMalformed is returned by Open for envelopes that can't be
+	// decoded.
+	ErrMalformed = errors.New("envelope: malformed envelope")
+	// ErrInvalidSignature is returned by Open if the signature does not
+	// verify.
+	ErrInvalidSignature = errors.New("envelope: invalid signature")
+	// ErrBadTimestamp is returned by Open if the creation time is
+	// rejected by the default time checks.
+	ErrBadTimestamp = errors.New("envelope: creation time out of range")
+)
+
+// KeyIDSize is the size, in bytes, of a KeyID.
+const KeyIDSize = 16
+
+// KeyID identifies the public key that sealed an envelope.
+type KeyID [KeyIDSize]byte
+
+// KeyIDOf returns the KeyID of publicKey, which is the first 16 bytes of its
+// SHA-256 hash.
+func KeyIDOf(publicKey ed25519.PublicKey) KeyID {
+	sum := sha256.Sum256(publicKey)
+	var id KeyID
+	copy(id[:], sum[:])
+	return id
+}
+
+// KeyResolver returns the public key with the given ID, or an error if
+// there is none, such as ErrUnknownKey.
+type KeyResolver func(id KeyID) (ed25519.PublicKey, error)
+
+// Envelope is a decoded envelope.
+type Envelope struct {
+	Version   byte
+	Algorithm byte
+	KeyID     KeyID
+	Created   time.Time
+	Payload   []byte
+	Signature []byte
+}
+
+// SealOptions configures Seal. The zero value selects the defaults.
+type SealOptions struct {
+	// Created is the creation time to record. If zero, time.Now is used.
+	Created time.Time
+}
+
+// Seal signs payload with signer and returns the encoded envelope. signer is
+// usually an ed25519.PrivateKey, but may be any crypto.Signer with an Ed25519
+// public key, as described in the ed25519 package. opts may be nil.
+func Seal(signer crypto.Signer, payload []byte, opts *SealOptions) ([]byte, error) {
+	publicKey, err := ed25519.SignerPublicKey(signer)
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(payload)) > 1<<32-1 {
+		return nil, errors.New("envelope: payload too large")
+	}
+	created := time.Now()
+	if opts != nil && !opts.Created.IsZero() {
+		created = opts.Created
+	}
+
+	out := make([]byte, headerSize, headerSize+len(payload)+ed25519.SignatureSize)
+	out[0] = Version
+	out[1] = AlgorithmEd25519
+	id := KeyIDOf(publicKey)
+	copy(out[2:], id[:])
+	binary.BigEndian.PutUint64(out[2+KeyIDSize:], uint64(created.Unix()))
+	binary.BigEndian.PutUint32(out[2+KeyIDSize+8:], uint32(len(payload)))
+	out = append(out, payload...)
+
+	sig, err := ed25519.SignWithDomain(signer, domain, out)
+	if err != nil {
+		return nil, err
+	}
+	return append(out, sig...), nil
+}
+
+// Parse decodes an envelope without verifying it. Use Open to obtain a
+// payload that can be trusted.
+func Parse(envelope []byte) (*Envelope, error) {
+	if len(envelope) < 1 {
+		return nil, ErrMalformed
+	}
+	if envelope[0] != Version {
+		return nil, ErrUnknownVersion
+	}
+	if len(envelope) < headerSize+ed25519.SignatureSize {
+		return nil, ErrMalformed
+	}
+	if envelope[1] != AlgorithmEd25519 {
+		return nil, ErrUnknownAlgorithm
+	}
+
+	e := &Envelope{Version: envelope[0], Algorithm: envelope[1]}
+	copy(e.KeyID[:], envelope[2:])
+	e.Created = time.Unix(int64(binary.BigEndian.Uint64(envelope[2+KeyIDSize:])), 0)
+	size := binary.BigEndian.Uint32(envelope[2+KeyIDSize+8:])
+	if uint64(len(envelope)) != headerSize+uint64(size)+ed25519.SignatureSize {
+		return nil, ErrMalformed
+	}
+	e.Payload = envelope[headerSize : headerSize+int(size)]
+	e.Signature = envelope[headerSize+int(size):]
+	return e, nil
+}
+
+// OpenOptions configures the checks made by Open. The zero value selects
+// the defaults.
+type OpenOptions struct {
+	// Now returns the current time. If nil, time.Now is used.
+	Now func() time.Time
+
+	// MaxSkew is how far in the future the creation time may be. If
+	// zero, DefaultMaxSkew is used.
+	MaxSkew time.Duration
+
+	// MaxAge, if not zero, is how far in the past the creation time may
+	// be, in addition to MaxSkew.
+	MaxAge time.Duration
+
+	// CheckTime, if not nil, replaces the checks selected by MaxSkew and
+	// MaxAge. It is called with the creation time and the current time,
+	// after the signature has been verified.
+	CheckTime func(created, now time.Time) error
+}
+
+// Open verifies an envelope and returns its payload. The public key is
+// obtained by passing the envelope's key ID to resolve; errors from resolve
+// are returned unchanged. opts may be nil.
+//
+// The payload is only returned if the version and algorithm are known, the
+// signature is valid and the creation time passes the checks in opts. It
+// aliases envelope.
+func Open(resolve KeyResolver, envelope []byte, opts *OpenOptions) ([]byte, error) {
+	e, err := Parse(envelope)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey, err := resolve(e.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	if publicKey == nil || KeyIDOf(publicKey) != e.KeyID {
+		return nil, ErrUnknownKey
+	}
+	signed := envelope[:len(envelope)-ed25519.SignatureSize]
+	if !ed25519.VerifyWithDomain(publicKey, domain, signed, e.Signature) {
+		return nil, ErrInvalidSignature
+	}
+
+	if opts == nil {
+		opts = &OpenOptions{}
+	}
+	now := time.Now()
+	if opts.Now != nil {
+		now = opts.Now()
+	}
+	if opts.CheckTime != nil {
+		if err := opts.CheckTime(e.Created, now); err != nil {
+			return nil, err
+		}
+		return e.Payload, nil
+	}
+	skew := opts.MaxSkew
+	if skew == 0 {
+		skew = DefaultMaxSkew
+	}
+	if e.Created.After(now.Add(skew)) {
+		return nil, ErrBadTimestamp
+	}
+	if opts.MaxAge != 0 && e.Created.Before(now.Add(-opts.MaxAge-skew)) {
+		return nil, ErrBadTimestamp
+	}
+	return e.Payload, nil
+}
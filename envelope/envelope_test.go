@@ -0,0 +1,250 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package envelope
+
+import (
+	"bytes"
+	"crypto"
+	stded25519 "crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/agl/ed25519"
+)
+
+var created = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func newKey(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey, KeyResolver) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolve := func(id KeyID) (ed25519.PublicKey, error) {
+		if id != KeyIDOf(public) {
+			return nil, ErrUnknownKey
+		}
+		return public, nil
+	}
+	return public, private, resolve
+}
+
+func at(now time.Time) *OpenOptions {
+	return &OpenOptions{Now: func() time.Time { return now }}
+}
+
+func TestSealOpen(t *testing.T) {
+	public, private, resolve := newKey(t)
+
+	for _, payload := range [][]byte{nil, []byte("hello"), bytes.Repeat([]byte{0xaa}, 1000)} {
+		env, err := Seal(private, payload, &SealOptions{Created: created})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(env) != headerSize+len(payload)+ed25519.SignatureSize {
+			t.Errorf("envelope is %d bytes", len(env))
+		}
+		got, err := Open(resolve, env, at(created))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, payload) {
+			t.Errorf("Open returned %q, want %q", got, payload)
+		}
+
+		e, err := Parse(env)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.Version != Version || e.Algorithm != AlgorithmEd25519 || e.KeyID != KeyIDOf(public) || !e.Created.Equal(created) {
+			t.Errorf("unexpected header %+v", e)
+		}
+	}
+
+	// With no options the current time is recorded and checked.
+	env, err := Seal(private, []byte("now"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(resolve, env, nil); err != nil {
+		t.Errorf("Open with default options: %s", err)
+	}
+}
+
+func TestOpenTamper(t *testing.T) {
+	_, private, resolve := newKey(t)
+	env, err := Seal(private, []byte("payload"), &SealOptions{Created: created})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range env {
+		tampered := append([]byte(nil), env...)
+		tampered[i] ^= 0x10
+		if _, err := Open(resolve, tampered, at(created)); err == nil {
+			t.Errorf("envelope with byte %d modified accepted", i)
+		}
+	}
+	if _, err := Open(resolve, append(env, 0), at(created)); err != ErrMalformed {
+		t.Errorf("trailing data: got %v, want ErrMalformed", err)
+	}
+	if _, err := Open(resolve, env[:len(env)-1], at(created)); err != ErrMalformed {
+		t.Errorf("truncated envelope: got %v, want ErrMalformed", err)
+	}
+	if _, err := Open(resolve, nil, at(created)); err != ErrMalformed {
+		t.Errorf("empty envelope: got %v, want ErrMalformed", err)
+	}
+
+	// Re-signing a modified header with another key doesn't help: the key
+	// ID no longer matches.
+	_, otherPrivate, _ := newKey(t)
+	forged, err := Seal(otherPrivate, []byte("payload"), &SealOptions{Created: created})
+	if err != nil {
+		t.Fatal(err)
+	}
+	copy(forged[2:], env[2:2+KeyIDSize])
+	if _, err := Open(resolve, forged, at(created)); err != ErrInvalidSignature {
+		t.Errorf("forged envelope: got %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestOpenUnknown(t *testing.T) {
+	_, private, resolve := newKey(t)
+	env, err := Seal(private, []byte("payload"), &SealOptions{Created: created})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v2 := append([]byte(nil), env...)
+	v2[0] = 2
+	if _, err := Open(resolve, v2, at(created)); err != ErrUnknownVersion {
+		t.Errorf("version 2: got %v, want ErrUnknownVersion", err)
+	}
+	// Unknown versions are rejected before their length is checked, as
+	// they may have a different layout.
+	if _, err := Open(resolve, []byte{2}, at(created)); err != ErrUnknownVersion {
+		t.Errorf("short version 2: got %v, want ErrUnknownVersion", err)
+	}
+	alg := append([]byte(nil), env...)
+	alg[1] = 2
+	if _, err := Open(resolve, alg, at(created)); err != ErrUnknownAlgorithm {
+		t.Errorf("algorithm 2: got %v, want ErrUnknownAlgorithm", err)
+	}
+
+	_, _, otherResolve := newKey(t)
+	if _, err := Open(otherResolve, env, at(created)); err != ErrUnknownKey {
+		t.Errorf("unknown key: got %v, want ErrUnknownKey", err)
+	}
+	resolverErr := errors.New("key store unavailable")
+	failing := func(KeyID) (ed25519.PublicKey, error) { return nil, resolverErr }
+	if _, err := Open(failing, env, at(created)); err != resolverErr {
+		t.Errorf("failing resolver: got %v, want %v", err, resolverErr)
+	}
+	nilKey := func(KeyID) (ed25519.PublicKey, error) { return nil, nil }
+	if _, err := Open(nilKey, env, at(created)); err != ErrUnknownKey {
+		t.Errorf("nil key: got %v, want ErrUnknownKey", err)
+	}
+	// A resolver returning the wrong key for an ID is caught.
+	otherPublic, _, _ := newKey(t)
+	wrongKey := func(KeyID) (ed25519.PublicKey, error) { return otherPublic, nil }
+	if _, err := Open(wrongKey, env, at(created)); err != ErrUnknownKey {
+		t.Errorf("wrong key: got %v, want ErrUnknownKey", err)
+	}
+}
+
+func TestOpenTimestamps(t *testing.T) {
+	_, private, resolve := newKey(t)
+	env, err := Seal(private, []byte("payload"), &SealOptions{Created: created})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		name string
+		now  time.Time
+		opts OpenOptions
+		ok   bool
+	}{
+		{"same time", created, OpenOptions{}, true},
+		{"much later, no maximum age", created.Add(1000 * time.Hour), OpenOptions{}, true},
+		{"signer's clock slightly ahead", created.Add(-DefaultMaxSkew), OpenOptions{}, true},
+		{"signer's clock too far ahead", created.Add(-DefaultMaxSkew - time.Second), OpenOptions{}, false},
+		{"custom skew", created.Add(-time.Hour), OpenOptions{MaxSkew: time.Hour}, true},
+		{"custom skew exceeded", created.Add(-time.Hour - time.Second), OpenOptions{MaxSkew: time.Hour}, false},
+		{"within maximum age", created.Add(time.Hour), OpenOptions{MaxAge: time.Hour}, true},
+		{"within maximum age plus skew", created.Add(time.Hour + DefaultMaxSkew), OpenOptions{MaxAge: time.Hour}, true},
+		{"too old", created.Add(time.Hour + DefaultMaxSkew + time.Second), OpenOptions{MaxAge: time.Hour}, false},
+	} {
+		opts := test.opts
+		now := test.now
+		opts.Now = func() time.Time { return now }
+		_, err := Open(resolve, env, &opts)
+		if test.ok && err != nil {
+			t.Errorf("%s: %s", test.name, err)
+		}
+		if !test.ok && err != ErrBadTimestamp {
+			t.Errorf("%s: got %v, want ErrBadTimestamp", test.name, err)
+		}
+	}
+
+	hookErr := errors.New("rejected by hook")
+	var gotCreated, gotNow time.Time
+	opts := at(created.Add(time.Minute))
+	opts.CheckTime = func(created, now time.Time) error {
+		gotCreated, gotNow = created, now
+		return hookErr
+	}
+	if _, err := Open(resolve, env, opts); err != hookErr {
+		t.Errorf("CheckTime hook: got %v, want %v", err, hookErr)
+	}
+	if !gotCreated.Equal(created) || !gotNow.Equal(created.Add(time.Minute)) {
+		t.Errorf("CheckTime called with %v, %v", gotCreated, gotNow)
+	}
+
+	// The hook replaces the default checks.
+	opts = at(created.Add(-time.Hour))
+	opts.CheckTime = func(created, now time.Time) error { return nil }
+	if _, err := Open(resolve, env, opts); err != nil {
+		t.Errorf("CheckTime hook accepting everything: %s", err)
+	}
+}
+
+// failingSigner is a crypto.Signer, like a key held in an HSM, whose Sign
+// method always fails.
+type failingSigner struct {
+	public ed25519.PublicKey
+	calls  int
+}
+
+var errSigner = errors.New("HSM unavailable")
+
+func (s *failingSigner) Public() crypto.PublicKey { return s.public }
+
+func (s *failingSigner) Sign(io.Reader, []byte, crypto.SignerOpts) ([]byte, error) {
+	s.calls++
+	return nil, errSigner
+}
+
+func TestSealSigner(t *testing.T) {
+	public, private, resolve := newKey(t)
+
+	env, err := Seal(stded25519.PrivateKey(private), []byte("payload"), &SealOptions{Created: created})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(resolve, env, at(created)); err != nil {
+		t.Errorf("envelope sealed by a crypto/ed25519 key: %s", err)
+	}
+
+	s := &failingSigner{public: public}
+	if _, err := Seal(s, []byte("payload"), nil); err != errSigner || s.calls != 1 {
+		t.Errorf("failing signer: got %v after %d calls, want %v after 1", err, s.calls, errSigner)
+	}
+	if _, err := Seal(private[:10], []byte("payload"), nil); err != ed25519.ErrBadPrivateKeyLength {
+		t.Errorf("short key: got %v, want ErrBadPrivateKeyLength", err)
+	}
+}
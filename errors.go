@@ -0,0 +1,43 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import "errors"
+
+// The errors returned by the signing and verification functions, which never
+// panic on malformed keys or signatures. Callers can compare against them to
+// tell why an operation failed.
+var (
+	// ErrBadPrivateKeyLength is returned when a private key is not
+	// PrivateKeySize bytes long.
+	ErrBadPrivateKeyLength = errors.New("ed25519: bad private key length")
+
+	// ErrBadPublicKeyLength is returned when a public key is not
+	// PublicKeySize bytes long.
+	ErrBadPublicKeyLength = errors.New("ed25519: bad public key length")
+
+	// ErrBadSignatureLength is returned when a signature is not
+	// SignatureSize bytes long.
+	ErrBadSignatureLength = errors.New("ed25519: bad signature length")
+
+	// ErrInvalidPublicKey is returned when a public key does not encode a
+	// point on the curve, or encodes one that the verification rules in use
+	// do not allow.
+	ErrInvalidPublicKey = errors.New("ed25519: invalid public key")
+
+	// ErrInvalidSignature is returned when a signature is well formed but
+	// is not valid for the message and public key, or when its R or S
+	// component is not a valid encoding.
+	ErrInvalidSignature = errors.New("ed25519: invalid signature")
+
+	// ErrKeyMismatch is returned by PrivateKey.Validate and CheckKeyPair
+	// when a public key is not the one derived from the private key's
+	// seed.
+	ErrKeyMismatch = errors.New("ed25519: public key does not match private key")
+
+	// ErrUnsupportedSigner is returned when a crypto.Signer passed in
+	// place of a PrivateKey does not have an Ed25519 public key.
+	ErrUnsupportedSigner = errors.New("ed25519: signer does not have an Ed25519 public key")
+)
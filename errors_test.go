@@ -0,0 +1,108 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"crypto"
+	"testing"
+)
+
+// offCurve is y = 2, which is not the y coordinate of any point.
+var offCurve = append([]byte{2}, make([]byte, 31)...)
+
+func TestSigningErrors(t *testing.T) {
+	message := []byte("message")
+	for _, l := range []int{0, 32, PrivateKeySize - 1, PrivateKeySize + 1} {
+		private := PrivateKey(make([]byte, l))
+
+		if _, err := Sign(private, message); err != ErrBadPrivateKeyLength {
+			t.Errorf("Sign with %d-byte key: got %v, want ErrBadPrivateKeyLength", l, err)
+		}
+		if _, err := SignWithRand(zeroReader{}, private, message, nil); err != ErrBadPrivateKeyLength {
+			t.Errorf("SignWithRand with %d-byte key: got %v, want ErrBadPrivateKeyLength", l, err)
+		}
+		if _, err := private.Sign(nil, message, crypto.Hash(0)); err != ErrBadPrivateKeyLength {
+			t.Errorf("PrivateKey.Sign with %d-byte key: got %v, want ErrBadPrivateKeyLength", l, err)
+		}
+		if _, err := private.Expand(); err != ErrBadPrivateKeyLength {
+			t.Errorf("Expand with %d-byte key: got %v, want ErrBadPrivateKeyLength", l, err)
+		}
+	}
+	if _, err := Sign(nil, message); err != ErrBadPrivateKeyLength {
+		t.Errorf("Sign with nil key: got %v, want ErrBadPrivateKeyLength", err)
+	}
+}
+
+func TestVerificationErrors(t *testing.T) {
+	public, private, _ := GenerateKey(zeroReader{})
+	message := []byte("message")
+	sig := mustSign(t, private, message)
+
+	tampered := append([]byte(nil), sig...)
+	tampered[0] ^= 1
+	highS := append([]byte(nil), sig...)
+	highS[63] |= 0xe0
+	offCurveR := append(append([]byte(nil), offCurve...), sig[32:]...)
+
+	for _, test := range []struct {
+		name    string
+		public  PublicKey
+		message []byte
+		sig     []byte
+		want    error
+	}{
+		{"valid", public, message, sig, nil},
+		{"nil public key", nil, message, sig, ErrBadPublicKeyLength},
+		{"short public key", public[:31], message, sig, ErrBadPublicKeyLength},
+		{"long public key", append(public, 0), message, sig, ErrBadPublicKeyLength},
+		{"nil signature", public, message, nil, ErrBadSignatureLength},
+		{"short signature", public, message, sig[:63], ErrBadSignatureLength},
+		{"long signature", public, message, append(sig, 0), ErrBadSignatureLength},
+		{"public key not on the curve", offCurve, message, sig, ErrInvalidPublicKey},
+		{"wrong message", public, []byte("other"), sig, ErrInvalidSignature},
+		{"tampered R", public, message, tampered, ErrInvalidSignature},
+		{"R not on the curve", public, message, offCurveR, ErrInvalidSignature},
+		{"non-canonical S", public, message, highS, ErrInvalidSignature},
+	} {
+		if err := CheckSignature(test.public, test.message, test.sig); err != test.want {
+			t.Errorf("%s: CheckSignature returned %v, want %v", test.name, err, test.want)
+		}
+		if got := Verify(test.public, test.message, test.sig); got != (test.want == nil) {
+			t.Errorf("%s: Verify returned %v", test.name, got)
+		}
+	}
+}
+
+func TestBatchAndPrecomputedErrors(t *testing.T) {
+	public, private, _ := GenerateKey(zeroReader{})
+	message := []byte("message")
+	sig := mustSign(t, private, message)
+	highS := append([]byte(nil), sig...)
+	highS[63] |= 0xe0
+
+	var v BatchVerifier
+	for _, test := range []struct {
+		name   string
+		public PublicKey
+		sig    []byte
+		want   error
+	}{
+		{"short public key", public[:31], sig, ErrBadPublicKeyLength},
+		{"public key not on the curve", offCurve, sig, ErrInvalidPublicKey},
+		{"short signature", public, sig[:63], ErrBadSignatureLength},
+		{"non-canonical S", public, highS, ErrInvalidSignature},
+	} {
+		if err := v.Add(test.public, message, test.sig); err != test.want {
+			t.Errorf("%s: Add returned %v, want %v", test.name, err, test.want)
+		}
+	}
+
+	if _, err := NewPrecomputedPublicKey(public[:31]); err != ErrBadPublicKeyLength {
+		t.Errorf("NewPrecomputedPublicKey with a short key returned %v", err)
+	}
+	if _, err := NewPrecomputedPublicKey(offCurve); err != ErrInvalidPublicKey {
+		t.Errorf("NewPrecomputedPublicKey with an invalid key returned %v", err)
+	}
+}
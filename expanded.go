@@ -0,0 +1,116 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"crypto"
+	"errors"
+	"io"
+)
+
+// ExpandedPrivateKey is a private key in the form used internally for
+// signing: the clamped secret scalar and the nonce prefix derived from the
+// seed with SHA-512, together with the public key. Signing with an
+// ExpandedPrivateKey skips that derivation, which is worthwhile when many
+// messages are signed with the same key. The signatures are identical to
+// those made with the PrivateKey it was expanded from.
+//
+// An ExpandedPrivateKey is as sensitive as the seed. Call Wipe once it is no
+// longer needed.
+type ExpandedPrivateKey struct {
+	scalar    [32]byte
+	prefix    [32]byte
+	publicKey [32]byte
+}
+
+// Expand returns the ExpandedPrivateKey for priv. It returns
+// ErrBadPrivateKeyLength if len(priv) is not PrivateKeySize.
+func (priv PrivateKey) Expand() (*ExpandedPrivateKey, error) {
+	if len(priv) != PrivateKeySize {
+		return nil, ErrBadPrivateKeyLength
+	}
+
+	k := new(ExpandedPrivateKey)
+	k.expand(priv)
+	return k, nil
+}
+
+func (k *ExpandedPrivateKey) expand(priv PrivateKey) {
+	k.expandSeed(priv[:32])
+	copy(k.publicKey[:], priv[32:])
+}
+
+// expandSeed derives the scalar and prefix from seed, leaving the public key
+// unset.
+func (k *ExpandedPrivateKey) expandSeed(seed []byte) {
+	st := getSHA512()
+	k.expandSeedWith(st, seed)
+	putSHA512(st)
+}
+
+// expandSeedWith is like expandSeed but uses st, whose hash must be freshly
+// reset and have a 64-byte output, instead of SHA-512. st is wiped
+// afterwards.
+func (k *ExpandedPrivateKey) expandSeedWith(st *hashState, seed []byte) {
+	st.h.Write(seed)
+	st.h.Sum(st.digest[:0])
+
+	copy(k.scalar[:], st.digest[:32])
+	k.scalar[0] &= 248
+	k.scalar[31] &= 63
+	k.scalar[31] |= 64
+	copy(k.prefix[:], st.digest[32:])
+
+	st.wipe()
+}
+
+// Public returns the PublicKey corresponding to k.
+func (k *ExpandedPrivateKey) Public() crypto.PublicKey {
+	publicKey := make([]byte, PublicKeySize)
+	copy(publicKey, k.publicKey[:])
+	return PublicKey(publicKey)
+}
+
+// Sign signs message with k, exactly like PrivateKey.Sign. rand is ignored.
+func (k *ExpandedPrivateKey) Sign(rand io.Reader, message []byte, opts crypto.SignerOpts) (signature []byte, err error) {
+	o, ok := opts.(*Options)
+	if !ok {
+		o = &Options{Hash: opts.HashFunc()}
+	}
+
+	return k.SignWithRand(nil, message, o)
+}
+
+// SignWithRand signs message with k, exactly like the SignWithRand function.
+func (k *ExpandedPrivateKey) SignWithRand(rand io.Reader, message []byte, opts *Options) ([]byte, error) {
+	// A clamped scalar always has bit 254 set.
+	if k.scalar[31] == 0 {
+		return nil, errWipedKey
+	}
+	dom, err := opts.dom(message)
+	if err != nil {
+		return nil, err
+	}
+	if rand == nil {
+		return k.appendSign(make([]byte, 0, SignatureSize), message, dom, nil), nil
+	}
+
+	var noise [32]byte
+	defer wipeBytes(noise[:])
+	if _, err := io.ReadFull(rand, noise[:]); err != nil {
+		return nil, err
+	}
+
+	return k.appendSign(make([]byte, 0, SignatureSize), message, dom, noise[:]), nil
+}
+
+// Wipe overwrites the secret parts of k with zeros. k must not be used to
+// sign afterwards.
+func (k *ExpandedPrivateKey) Wipe() {
+	wipeBytes(k.scalar[:])
+	wipeBytes(k.prefix[:])
+}
+
+var errWipedKey = errors.New("ed25519: signing with a wiped key")
@@ -0,0 +1,97 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha512"
+	"testing"
+)
+
+func TestExpandedPrivateKey(t *testing.T) {
+	for i := 0; i < 8; i++ {
+		public, private, _ := GenerateKey(rand.Reader)
+		expanded, err := private.Expand()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(expanded.Public().(PublicKey), public) {
+			t.Fatalf("Public() does not match the key pair")
+		}
+
+		message := make([]byte, 10*i)
+		rand.Read(message)
+		digest := sha512.Sum512(message)
+
+		for _, mode := range signingModes {
+			msg := message
+			if mode.prehash {
+				msg = digest[:]
+			}
+
+			want, err := SignWithRand(nil, private, msg, mode.opts)
+			if err != nil {
+				t.Fatalf("%s: %s", mode.name, err)
+			}
+			got, err := expanded.SignWithRand(nil, msg, mode.opts)
+			if err != nil {
+				t.Fatalf("%s: %s", mode.name, err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("%s: signature differs from PrivateKey", mode.name)
+			}
+
+			var opts crypto.SignerOpts = crypto.Hash(0)
+			if mode.opts != nil {
+				opts = mode.opts
+			}
+			if got, err := crypto.Signer(expanded).Sign(rand.Reader, msg, opts); err != nil || !bytes.Equal(got, want) {
+				t.Errorf("%s: crypto.Signer signature differs from PrivateKey (error %v)", mode.name, err)
+			}
+
+			// Hedged signatures are equal given the same randomness.
+			want, _ = SignWithRand(constantReader(7), private, msg, mode.opts)
+			got, _ = expanded.SignWithRand(constantReader(7), msg, mode.opts)
+			if !bytes.Equal(got, want) {
+				t.Errorf("%s: hedged signature differs from PrivateKey", mode.name)
+			}
+		}
+	}
+}
+
+func TestExpandedPrivateKeyWipe(t *testing.T) {
+	_, private, _ := GenerateKey(rand.Reader)
+	expanded, err := private.Expand()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expanded.Wipe()
+
+	if expanded.scalar != [32]byte{} || expanded.prefix != [32]byte{} {
+		t.Errorf("Wipe left secret material behind")
+	}
+	if _, err := expanded.SignWithRand(nil, []byte("message"), nil); err == nil {
+		t.Errorf("signed with a wiped key")
+	}
+}
+
+func BenchmarkSigningExpanded(b *testing.B) {
+	_, priv, err := GenerateKey(zeroReader{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	expanded, err := priv.Expand()
+	if err != nil {
+		b.Fatal(err)
+	}
+	message := []byte("Hello, world!")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		expanded.SignWithRand(nil, message, nil)
+	}
+}
@@ -41,14 +41,28 @@ func edwardsToMontgomeryX(outX, y *edwards25519.FieldElement) {
 }
 
 // PublicKeyToCurve25519 converts an Ed25519 public key into the curve25519
-// public key that would be generated from the same private key.
+// public key that would be generated from the same private key. It returns
+// false if publicKey isn't a point of the curve, or if it is the identity,
+// y = 1, or the point of order 2, y = -1, where the map is undefined, as
+// EdwardsToMontgomery of edwards25519 reports with ErrExceptionalPoint; no
+// private key has those public keys. The points of order 4 and 8 are
+// converted.
 func PublicKeyToCurve25519(curve25519Public *[32]byte, publicKey *[32]byte) bool {
 	var A edwards25519.ExtendedGroupElement
 	if !A.FromBytes(publicKey) {
 		return false
 	}
 
-	// A.Z = 1 as a postcondition of FromBytes.
+	// A.Z = 1 as a postcondition of FromBytes, so y = A.Y, and y = ±1 exactly
+	// when 1-y or 1+y is zero.
+	var one, oneMinusY, onePlusY edwards25519.FieldElement
+	edwards25519.FeOne(&one)
+	edwards25519.FeSub(&oneMinusY, &one, &A.Y)
+	edwards25519.FeAdd(&onePlusY, &one, &A.Y)
+	if edwards25519.FeIsNonZero(&oneMinusY) == 0 || edwards25519.FeIsNonZero(&onePlusY) == 0 {
+		return false
+	}
+
 	var x edwards25519.FieldElement
 	edwardsToMontgomeryX(&x, &A.Y)
 	edwards25519.FeToBytes(curve25519Public, &x)
@@ -7,6 +7,7 @@ package extra25519
 import (
 	"bytes"
 	"crypto/rand"
+	"encoding/hex"
 	"testing"
 
 	"github.com/agl/ed25519"
@@ -17,10 +18,10 @@ func TestCurve25519Conversion(t *testing.T) {
 	public, private, _ := ed25519.GenerateKey(rand.Reader)
 
 	var curve25519Public, curve25519Public2, curve25519Private [32]byte
-	PrivateKeyToCurve25519(&curve25519Private, private)
+	PrivateKeyToCurve25519(&curve25519Private, (*[64]byte)(private))
 	curve25519.ScalarBaseMult(&curve25519Public, &curve25519Private)
 
-	if !PublicKeyToCurve25519(&curve25519Public2, public) {
+	if !PublicKeyToCurve25519(&curve25519Public2, (*[32]byte)(public)) {
 		t.Fatalf("PublicKeyToCurve25519 failed")
 	}
 
@@ -29,6 +30,32 @@ func TestCurve25519Conversion(t *testing.T) {
 	}
 }
 
+func TestCurve25519ConversionExceptional(t *testing.T) {
+	var identity, orderTwo [32]byte
+	identity[0] = 1
+	hex.Decode(orderTwo[:], []byte("ecffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f"))
+	for _, publicKey := range [][32]byte{identity, orderTwo} {
+		var out [32]byte
+		if PublicKeyToCurve25519(&out, &publicKey) {
+			t.Errorf("%x converted to %x", publicKey, out)
+		}
+	}
+
+	// The point of order 4 with y = 0 maps to u = 1, and the points of
+	// order 8 to the u-coordinates that X25519 implementations blacklist.
+	for _, tc := range []struct{ ed, u string }{
+		{"0000000000000000000000000000000000000000000000000000000000000000", "0100000000000000000000000000000000000000000000000000000000000000"},
+		{"26e8958fc2b227b045c3f489f2ef98f0d5dfac05d3c63339b13802886d53fc05", "5f9c95bca3508c24b1d0b1559c83ef5b04445cc4581c8e86d8224eddd09f1157"},
+		{"c7176a703d4dd84fba3c0b760d10670f2a2053fa2c39ccc64ec7fd7792ac037a", "e0eb7a7c3b41b8ae1656e3faf19fc46ada098deb9c32b1fd866205165f49b800"},
+	} {
+		var publicKey, out [32]byte
+		hex.Decode(publicKey[:], []byte(tc.ed))
+		if !PublicKeyToCurve25519(&out, &publicKey) || hex.EncodeToString(out[:]) != tc.u {
+			t.Errorf("%s converted to %x, want %s", tc.ed, out, tc.u)
+		}
+	}
+}
+
 func TestElligator(t *testing.T) {
 	var publicKey, publicKey2, publicKey3, representative, privateKey [32]byte
 
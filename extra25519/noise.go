@@ -0,0 +1,148 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package extra25519
+
+import (
+	"crypto/ed25519"
+	"crypto/subtle"
+	"errors"
+	"io"
+
+	"github.com/agl/ed25519/edwards25519"
+)
+
+// The Noise protocol framework is written over a DH function with a fixed
+// key length. DH25519 is its "25519" function, X25519, on key pairs whose
+// private half is an Ed25519 private key: DH converts it with
+// PrivateKeyToCurve25519 and the public half is its curve25519 public key,
+// so a party can run a Noise handshake with the static key of its Ed25519
+// identity and its peers can check the remote static key against
+// PublicKeyToCurve25519 of the Ed25519 public key they expect. Remote keys
+// are curve25519 public keys, the DHLen bytes that Noise sends.
+
+var (
+	// ErrLowOrderKey is returned by DH25519.DH for a remote public key of
+	// small order, whose shared secret is predictable.
+	ErrLowOrderKey = errors.New("extra25519: remote public key has low order")
+
+	errNoiseKeyLength = errors.New("extra25519: bad Noise key length")
+)
+
+// DHKey is a Noise key pair, with the fields of DHKey in
+// github.com/flynn/noise.
+type DHKey struct {
+	// Private is a 64-byte Ed25519 private key.
+	Private []byte
+	// Public is the 32-byte curve25519 public key of Private.
+	Public []byte
+}
+
+// DHFunc is the DH function interface of the Noise framework, with the
+// methods of DHFunc in github.com/flynn/noise, so DH25519 can be used where
+// a value of that interface is needed.
+type DHFunc interface {
+	// GenerateKeypair generates a new key pair using random as a source
+	// of entropy.
+	GenerateKeypair(random io.Reader) (DHKey, error)
+	// DH performs a Diffie-Hellman calculation between the provided
+	// private and public keys and returns the result.
+	DH(privkey, pubkey []byte) ([]byte, error)
+	// DHLen is the number of bytes returned by DH.
+	DHLen() int
+	// DHName is the name of the DH function.
+	DHName() string
+}
+
+// DH25519 is the Noise DH function "25519" on converted Ed25519 keys.
+var DH25519 DHFunc = dh25519{}
+
+type dh25519 struct{}
+
+// NoiseKeypair returns the Noise key pair of the Ed25519 private key
+// privateKey, for use with DH25519.
+func NoiseKeypair(privateKey *[64]byte) DHKey {
+	var curve25519Private, basePoint [32]byte
+	PrivateKeyToCurve25519(&curve25519Private, privateKey)
+	basePoint[0] = 9
+	public, _ := edwards25519.MontgomeryScalarMult(basePoint, curve25519Private, nil)
+	for i := range curve25519Private {
+		curve25519Private[i] = 0
+	}
+	return DHKey{Private: append([]byte(nil), privateKey[:]...), Public: public[:]}
+}
+
+func (dh25519) GenerateKeypair(random io.Reader) (DHKey, error) {
+	var seed [ed25519.SeedSize]byte
+	if _, err := io.ReadFull(random, seed[:]); err != nil {
+		return DHKey{}, err
+	}
+	var privateKey [64]byte
+	copy(privateKey[:], ed25519.NewKeyFromSeed(seed[:]))
+	key := NoiseKeypair(&privateKey)
+	for i := range seed {
+		seed[i] = 0
+		privateKey[i] = 0
+	}
+	return key, nil
+}
+
+// lowOrderKeys are the canonical u-coordinates of the points of order 1, 2,
+// 4 and 8 of Curve25519 and its twist: 0, 1, p-1 and the two of order 8.
+var lowOrderKeys = [][32]byte{
+	{},
+	{1},
+	{0xec, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f},
+	{0xe0, 0xeb, 0x7a, 0x7c, 0x3b, 0x41, 0xb8, 0xae, 0x16, 0x56, 0xe3, 0xfa, 0xf1, 0x9f, 0xc4, 0x6a, 0xda, 0x09, 0x8d, 0xeb, 0x9c, 0x32, 0xb1, 0xfd, 0x86, 0x62, 0x05, 0x16, 0x5f, 0x49, 0xb8, 0x00},
+	{0x5f, 0x9c, 0x95, 0xbc, 0xa3, 0x50, 0x8c, 0x24, 0xb1, 0xd0, 0xb1, 0x55, 0x9c, 0x83, 0xef, 0x5b, 0x04, 0x44, 0x5c, 0xc4, 0x58, 0x1c, 0x8e, 0x86, 0xd8, 0x22, 0x4e, 0xdd, 0xd0, 0x9f, 0x11, 0x57},
+}
+
+// isLowOrder reports whether u, with its top bit ignored and reduced modulo
+// p as X25519 reads it, is the u-coordinate of a point of small order.
+func isLowOrder(u *[32]byte) bool {
+	var x edwards25519.FieldElement
+	var canonical [32]byte
+	in := *u
+	in[31] &= 127
+	edwards25519.FeFromBytes(&x, &in)
+	edwards25519.FeToBytes(&canonical, &x)
+	found := 0
+	for i := range lowOrderKeys {
+		found |= subtle.ConstantTimeCompare(canonical[:], lowOrderKeys[i][:])
+	}
+	return found == 1
+}
+
+// DH returns the X25519 shared secret of the Ed25519 private key privkey,
+// converted with PrivateKeyToCurve25519, and the curve25519 public key
+// pubkey. It returns ErrLowOrderKey if pubkey has small order or the result
+// is all zeros.
+func (dh25519) DH(privkey, pubkey []byte) ([]byte, error) {
+	if len(privkey) != 64 || len(pubkey) != 32 {
+		return nil, errNoiseKeyLength
+	}
+	var privateKey [64]byte
+	var curve25519Private, u [32]byte
+	copy(privateKey[:], privkey)
+	copy(u[:], pubkey)
+	if isLowOrder(&u) {
+		return nil, ErrLowOrderKey
+	}
+	PrivateKeyToCurve25519(&curve25519Private, &privateKey)
+	shared, err := edwards25519.MontgomeryScalarMult(u, curve25519Private, nil)
+	for i := range privateKey {
+		privateKey[i] = 0
+	}
+	for i := range curve25519Private {
+		curve25519Private[i] = 0
+	}
+	if err != nil {
+		return nil, ErrLowOrderKey
+	}
+	return shared[:], nil
+}
+
+func (dh25519) DHLen() int { return 32 }
+
+func (dh25519) DHName() string { return "25519" }
@@ -0,0 +1,388 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package extra25519
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/agl/ed25519"
+	"golang.org/x/crypto/curve25519"
+)
+
+// noiseCipher is the CipherState of the Noise specification with AESGCM.
+type noiseCipher struct {
+	aead cipher.AEAD
+	n    uint64
+}
+
+func newNoiseCipher(k []byte) *noiseCipher {
+	block, err := aes.NewCipher(k)
+	if err != nil {
+		panic(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		panic(err)
+	}
+	return &noiseCipher{aead: aead}
+}
+
+func (c *noiseCipher) nonce() []byte {
+	var nonce [12]byte
+	binary.BigEndian.PutUint64(nonce[4:], c.n)
+	c.n++
+	return nonce[:]
+}
+
+func (c *noiseCipher) encrypt(ad, plaintext []byte) []byte {
+	return c.aead.Seal(nil, c.nonce(), plaintext, ad)
+}
+
+func (c *noiseCipher) decrypt(ad, ciphertext []byte) ([]byte, error) {
+	return c.aead.Open(nil, c.nonce(), ciphertext, ad)
+}
+
+func noiseHKDF(ck, ikm []byte) ([]byte, []byte) {
+	mac := hmac.New(sha256.New, ck)
+	mac.Write(ikm)
+	temp := mac.Sum(nil)
+	mac = hmac.New(sha256.New, temp)
+	mac.Write([]byte{1})
+	out1 := mac.Sum(nil)
+	mac = hmac.New(sha256.New, temp)
+	mac.Write(out1)
+	mac.Write([]byte{2})
+	return out1, mac.Sum(nil)
+}
+
+// noiseXX is one party of a Noise_XX_25519_AESGCM_SHA256 handshake, written
+// against the DHFunc interface with the SymmetricState of the Noise
+// specification:
+//
+//	-> e
+//	<- e, ee, s, es
+//	-> s, se
+type noiseXX struct {
+	dh        DHFunc
+	initiator bool
+	s, e      DHKey
+	rs, re    []byte
+	ck, h     []byte
+	c         *noiseCipher
+}
+
+func newNoiseXX(dh DHFunc, initiator bool, s DHKey, prologue []byte) *noiseXX {
+	h := make([]byte, sha256.Size)
+	copy(h, "Noise_XX_"+dh.DHName()+"_AESGCM_SHA256")
+	x := &noiseXX{dh: dh, initiator: initiator, s: s, ck: h, h: h}
+	x.mixHash(prologue)
+	return x
+}
+
+func (x *noiseXX) mixHash(data []byte) {
+	h := sha256.New()
+	h.Write(x.h)
+	h.Write(data)
+	x.h = h.Sum(nil)
+}
+
+func (x *noiseXX) mixKey(priv, pub []byte) error {
+	shared, err := x.dh.DH(priv, pub)
+	if err != nil {
+		return err
+	}
+	var k []byte
+	x.ck, k = noiseHKDF(x.ck, shared)
+	x.c = newNoiseCipher(k)
+	return nil
+}
+
+func (x *noiseXX) encryptAndHash(plaintext []byte) []byte {
+	out := plaintext
+	if x.c != nil {
+		out = x.c.encrypt(x.h, plaintext)
+	}
+	x.mixHash(out)
+	return out
+}
+
+func (x *noiseXX) decryptAndHash(ciphertext []byte) ([]byte, error) {
+	out := ciphertext
+	if x.c != nil {
+		var err error
+		if out, err = x.c.decrypt(x.h, ciphertext); err != nil {
+			return nil, err
+		}
+	}
+	x.mixHash(ciphertext)
+	return out, nil
+}
+
+// es returns the keys of the DH between the initiator's e and the
+// responder's s, from the point of view of x, and se those of the other
+// mixed one.
+func (x *noiseXX) es() ([]byte, []byte) {
+	if x.initiator {
+		return x.e.Private, x.rs
+	}
+	return x.s.Private, x.re
+}
+
+func (x *noiseXX) se() ([]byte, []byte) {
+	if x.initiator {
+		return x.s.Private, x.re
+	}
+	return x.e.Private, x.rs
+}
+
+func (x *noiseXX) writeMessage(n int, payload []byte) ([]byte, error) {
+	var msg []byte
+	var err error
+	switch n {
+	case 0:
+		if x.e, err = x.dh.GenerateKeypair(rand.Reader); err != nil {
+			return nil, err
+		}
+		msg = append(msg, x.e.Public...)
+		x.mixHash(x.e.Public)
+	case 1:
+		if x.e, err = x.dh.GenerateKeypair(rand.Reader); err != nil {
+			return nil, err
+		}
+		msg = append(msg, x.e.Public...)
+		x.mixHash(x.e.Public)
+		if err := x.mixKey(x.e.Private, x.re); err != nil {
+			return nil, err
+		}
+		msg = append(msg, x.encryptAndHash(x.s.Public)...)
+		if err := x.mixKey(x.es()); err != nil {
+			return nil, err
+		}
+	case 2:
+		msg = append(msg, x.encryptAndHash(x.s.Public)...)
+		if err := x.mixKey(x.se()); err != nil {
+			return nil, err
+		}
+	}
+	return append(msg, x.encryptAndHash(payload)...), nil
+}
+
+func (x *noiseXX) readMessage(n int, msg []byte) ([]byte, error) {
+	dhLen := x.dh.DHLen()
+	readStatic := func() error {
+		size := dhLen + 16
+		if len(msg) < size {
+			return errors.New("short message")
+		}
+		var err error
+		x.rs, err = x.decryptAndHash(msg[:size])
+		msg = msg[size:]
+		return err
+	}
+	if n < 2 {
+		if len(msg) < dhLen {
+			return nil, errors.New("short message")
+		}
+		x.re = append([]byte(nil), msg[:dhLen]...)
+		msg = msg[dhLen:]
+		x.mixHash(x.re)
+	}
+	switch n {
+	case 1:
+		if err := x.mixKey(x.e.Private, x.re); err != nil {
+			return nil, err
+		}
+		if err := readStatic(); err != nil {
+			return nil, err
+		}
+		if err := x.mixKey(x.es()); err != nil {
+			return nil, err
+		}
+	case 2:
+		if err := readStatic(); err != nil {
+			return nil, err
+		}
+		if err := x.mixKey(x.se()); err != nil {
+			return nil, err
+		}
+	}
+	return x.decryptAndHash(msg)
+}
+
+// split returns the cipher states of the transport messages sent by the
+// initiator and by the responder.
+func (x *noiseXX) split() (*noiseCipher, *noiseCipher) {
+	k1, k2 := noiseHKDF(x.ck, nil)
+	return newNoiseCipher(k1[:32]), newNoiseCipher(k2[:32])
+}
+
+func noiseStatic(t *testing.T) (ed25519.PublicKey, DHKey) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var privateKey [64]byte
+	copy(privateKey[:], private)
+	return public, NoiseKeypair(&privateKey)
+}
+
+func TestNoiseXXHandshake(t *testing.T) {
+	alicePublic, aliceStatic := noiseStatic(t)
+	bobPublic, bobStatic := noiseStatic(t)
+	prologue := []byte("extra25519 test")
+	alice := newNoiseXX(DH25519, true, aliceStatic, prologue)
+	bob := newNoiseXX(DH25519, false, bobStatic, prologue)
+
+	payloads := []string{"", "responder payload", "initiator payload"}
+	senders := []*noiseXX{alice, bob, alice}
+	for n, payload := range payloads {
+		sender, receiver := senders[n], bob
+		if sender == bob {
+			receiver = alice
+		}
+		msg, err := sender.writeMessage(n, []byte(payload))
+		if err != nil {
+			t.Fatalf("message %d: %v", n, err)
+		}
+		got, err := receiver.readMessage(n, msg)
+		if err != nil {
+			t.Fatalf("message %d: %v", n, err)
+		}
+		if string(got) != payload {
+			t.Errorf("message %d: got payload %q, want %q", n, got, payload)
+		}
+	}
+	if !bytes.Equal(alice.h, bob.h) {
+		t.Fatal("handshake hashes differ")
+	}
+
+	// Each side authenticates the other's Ed25519 identity by its remote
+	// static key.
+	for _, test := range []struct {
+		rs     []byte
+		public ed25519.PublicKey
+	}{{alice.rs, bobPublic}, {bob.rs, alicePublic}} {
+		var edPublic, want [32]byte
+		copy(edPublic[:], test.public)
+		if !PublicKeyToCurve25519(&want, &edPublic) {
+			t.Fatal("conversion failed")
+		}
+		if !bytes.Equal(test.rs, want[:]) {
+			t.Error("remote static key is not the converted Ed25519 key")
+		}
+	}
+
+	aliceSend, aliceReceive := alice.split()
+	bobReceive, bobSend := bob.split()
+	for i := 0; i < 3; i++ {
+		msg := []byte("transport message from the initiator")
+		got, err := bobReceive.decrypt(nil, aliceSend.encrypt(nil, msg))
+		if err != nil || !bytes.Equal(got, msg) {
+			t.Fatalf("initiator transport message %d: %v", i, err)
+		}
+		msg = []byte("transport message from the responder")
+		got, err = aliceReceive.decrypt(nil, bobSend.encrypt(nil, msg))
+		if err != nil || !bytes.Equal(got, msg) {
+			t.Fatalf("responder transport message %d: %v", i, err)
+		}
+	}
+	if _, err := aliceReceive.decrypt(nil, aliceSend.encrypt(nil, []byte("x"))); err == nil {
+		t.Error("a message decrypted with the key of the other direction")
+	}
+}
+
+func TestNoiseXXMismatchedPrologue(t *testing.T) {
+	_, aliceStatic := noiseStatic(t)
+	_, bobStatic := noiseStatic(t)
+	alice := newNoiseXX(DH25519, true, aliceStatic, []byte("one"))
+	bob := newNoiseXX(DH25519, false, bobStatic, []byte("two"))
+	msg, _ := alice.writeMessage(0, nil)
+	if _, err := bob.readMessage(0, msg); err != nil {
+		t.Fatal(err)
+	}
+	msg, _ = bob.writeMessage(1, nil)
+	if _, err := alice.readMessage(1, msg); err == nil {
+		t.Error("handshake with a different prologue succeeded")
+	}
+}
+
+func TestDH25519(t *testing.T) {
+	if DH25519.DHLen() != 32 || DH25519.DHName() != "25519" {
+		t.Errorf("got DHLen %d and DHName %q", DH25519.DHLen(), DH25519.DHName())
+	}
+	a, err := DH25519.GenerateKeypair(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := DH25519.GenerateKeypair(rand.Reader)
+	ab, err := DH25519.DH(a.Private, b.Public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ba, _ := DH25519.DH(b.Private, a.Public)
+	if !bytes.Equal(ab, ba) {
+		t.Error("shared secrets differ")
+	}
+
+	// The result is plain X25519 of the converted private key.
+	var privateKey [64]byte
+	var curve25519Private [32]byte
+	copy(privateKey[:], a.Private)
+	PrivateKeyToCurve25519(&curve25519Private, &privateKey)
+	if want, _ := curve25519.X25519(curve25519Private[:], curve25519.Basepoint); !bytes.Equal(a.Public, want) {
+		t.Error("public key is not the X25519 public key of the converted private key")
+	}
+	if want, _ := curve25519.X25519(curve25519Private[:], b.Public); !bytes.Equal(ab, want) {
+		t.Error("DH disagrees with X25519")
+	}
+
+	if _, err := DH25519.DH(a.Private[:32], b.Public); err == nil {
+		t.Error("short private key accepted")
+	}
+	if _, err := DH25519.DH(a.Private, b.Public[:31]); err == nil {
+		t.Error("short public key accepted")
+	}
+	if _, err := DH25519.GenerateKeypair(bytes.NewReader(make([]byte, 31))); err == nil {
+		t.Error("short random source accepted")
+	}
+}
+
+func TestDH25519LowOrder(t *testing.T) {
+	a, _ := DH25519.GenerateKeypair(rand.Reader)
+	p := [32]byte{0xed, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f}
+	for i, u := range lowOrderKeys {
+		// u itself, u with the top bit set, and u + p where it fits in 255
+		// bits all read as the same point.
+		variants := [][32]byte{u, u}
+		variants[1][31] |= 0x80
+		var sum [32]byte
+		var carry uint16
+		for j := range sum {
+			carry += uint16(u[j]) + uint16(p[j])
+			sum[j] = byte(carry)
+			carry >>= 8
+		}
+		if sum[31]&0x80 == 0 {
+			variants = append(variants, sum)
+		}
+		for _, v := range variants {
+			if _, err := DH25519.DH(a.Private, v[:]); err != ErrLowOrderKey {
+				t.Errorf("%d: %x: got %v", i, v, err)
+			}
+		}
+		// Every low-order point gives X25519 an all-zero result.
+		if _, err := curve25519.X25519(make([]byte, 32), u[:]); err == nil {
+			t.Errorf("%d: %x is not of low order", i, u)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package extra25519
+
+import (
+	"io"
+
+	"github.com/agl/ed25519/edwards25519"
+)
+
+// An obfuscated handshake, as in obfs4, sends Elligator representatives in
+// place of curve25519 public keys, so that the wire carries bytes that look
+// uniformly random. ScalarBaseMult finds the representative of the public key
+// of about half of all private keys, as a field element whose top bit is
+// always zero; it is filled with a random bit here, which
+// RepresentativeToPublicKey ignores, so representatives interoperate with
+// those of ScalarBaseMult in both directions.
+//
+// The public keys are multiples of the base point, so a party that decodes
+// representatives can still tell them from random strings by checking their
+// order. That is the convention of ScalarBaseMult and its deployments.
+
+// GenerateObfuscatedKeypair reads private keys from rand until one has a
+// public key with an Elligator representative, and returns that private key
+// and representative. About two keys are read on average, each with one more
+// byte for the random top bit of the representative.
+func GenerateObfuscatedKeypair(rand io.Reader) (priv, representative [32]byte, err error) {
+	var publicKey [32]byte
+	var buf [33]byte
+	for {
+		if _, err = io.ReadFull(rand, buf[:]); err != nil {
+			return [32]byte{}, [32]byte{}, err
+		}
+		copy(priv[:], buf[:32])
+		if ScalarBaseMult(&publicKey, &representative, &priv) {
+			representative[31] |= buf[32] & 0x80
+			for i := range buf {
+				buf[i] = 0
+			}
+			return priv, representative, nil
+		}
+	}
+}
+
+// SharedSecretFromRepresentative returns the curve25519 shared secret of priv
+// and the public key that representative, from GenerateObfuscatedKeypair or
+// ScalarBaseMult, represents. It returns an error if the result is all zeros.
+func SharedSecretFromRepresentative(priv, representative [32]byte) ([32]byte, error) {
+	var publicKey [32]byte
+	RepresentativeToPublicKey(&publicKey, &representative)
+	return edwards25519.MontgomeryScalarMult(publicKey, priv, nil)
+}
@@ -0,0 +1,171 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package extra25519
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	"golang.org/x/crypto/curve25519"
+)
+
+// obfuscatedTests are keys from GenerateObfuscatedKeypair for fixed random
+// streams; in the second the first private key has no representative. The
+// public keys are libsodium 1.0.18's crypto_scalarmult_base of the private
+// keys, and were checked to be the images of the representatives under an
+// independent implementation of the Elligator 2 map in Python. The shared
+// secrets are crypto_scalarmult of each private key and the next public key,
+// in a cycle.
+var obfuscatedTests = []struct {
+	random, private, representative, public, shared string
+}{
+	{
+		"5f0b339cf71fb78c45b7ca4314ecfabd77f5a5daad38266ada01d8d82049528322",
+		"5f0b339cf71fb78c45b7ca4314ecfabd77f5a5daad38266ada01d8d820495283",
+		"6bbbbfc7011dceb7538a88d4f7be7456143c585bda36963e62d8db6d64898665",
+		"71869c49fcfc1f6222b0fa760b9c40101f5bc361877fc3bd9574353acc3ada7c",
+		"4f0b0e5bd4040d25138b2952e3f235986d3600efb6b346adac4a85eb1bdd112f",
+	},
+	{
+		"2fedd07b797ce1a2ecfdfc65d95739d138993c6f21ceac20e040e71d586f980137b362c92087890bb464acc89d1908aae8741698dab2ebcdaac15ce72c4aa6b0648d",
+		"b362c92087890bb464acc89d1908aae8741698dab2ebcdaac15ce72c4aa6b064",
+		"a9653882b9daaa286e689aed836e43252298ec1303e68549f225f2be7c443ff0",
+		"2992574881e01fb648cf5e113e90ffb3fa2d7a25fd2ff79b7228d78dc582a042",
+		"933761234c56e6a6fdee9de25337661587b5d182ceb554d2d925b6ee01fa5620",
+	},
+	{
+		"bef6e85d5a03d1c6dbcca56f99ac24e5b86f3cfca361593f770fd58da98cb758fe",
+		"bef6e85d5a03d1c6dbcca56f99ac24e5b86f3cfca361593f770fd58da98cb758",
+		"1cb671b630806d1b05176c522544adfbbfa9d6a68990cd0ed90dfbd422e240f2",
+		"d733d046573461e6923e34812f93470cb171cb337b74ebc72c3cae54e492ec49",
+		"90b6cc351a909e53fc681baea909ae365e34b68150e1ac4c270845b64f89914f",
+	},
+}
+
+func decodeHex32(t *testing.T, s string) [32]byte {
+	t.Helper()
+	var out [32]byte
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != 32 {
+		t.Fatalf("bad hex fixture %q", s)
+	}
+	copy(out[:], b)
+	return out
+}
+
+func TestObfuscatedKeypairVectors(t *testing.T) {
+	for i, test := range obfuscatedTests {
+		random, _ := hex.DecodeString(test.random)
+		priv, representative, err := GenerateObfuscatedKeypair(bytes.NewReader(random))
+		if err != nil {
+			t.Fatalf("%d: %v", i, err)
+		}
+		if got := hex.EncodeToString(priv[:]); got != test.private {
+			t.Errorf("%d: got private key %s, want %s", i, got, test.private)
+		}
+		if got := hex.EncodeToString(representative[:]); got != test.representative {
+			t.Errorf("%d: got representative %s, want %s", i, got, test.representative)
+		}
+
+		// ScalarBaseMult gives the same representative, with the top bit
+		// clear, and both decode to the public key.
+		var publicKey, fromScalarBaseMult, decoded [32]byte
+		if !ScalarBaseMult(&publicKey, &fromScalarBaseMult, &priv) {
+			t.Fatalf("%d: ScalarBaseMult failed", i)
+		}
+		representative[31] &= 0x7f
+		if fromScalarBaseMult != representative {
+			t.Errorf("%d: ScalarBaseMult gave representative %x", i, fromScalarBaseMult)
+		}
+		if got := hex.EncodeToString(publicKey[:]); got != test.public {
+			t.Errorf("%d: got public key %s, want %s", i, got, test.public)
+		}
+		RepresentativeToPublicKey(&decoded, &fromScalarBaseMult)
+		if decoded != publicKey {
+			t.Errorf("%d: representative decodes to %x", i, decoded)
+		}
+
+		next := obfuscatedTests[(i+1)%len(obfuscatedTests)]
+		shared, err := SharedSecretFromRepresentative(priv, decodeHex32(t, next.representative))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(shared[:]); got != test.shared {
+			t.Errorf("%d: got shared secret %s, want %s", i, got, test.shared)
+		}
+	}
+}
+
+func TestObfuscatedHandshake(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		privA, reprA, err := GenerateObfuscatedKeypair(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		privB, reprB, err := GenerateObfuscatedKeypair(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ab, err1 := SharedSecretFromRepresentative(privA, reprB)
+		ba, err2 := SharedSecretFromRepresentative(privB, reprA)
+		if err1 != nil || err2 != nil || ab != ba {
+			t.Fatalf("shared secrets %x, %v and %x, %v", ab, err1, ba, err2)
+		}
+
+		// The representative decodes to the curve25519 public key.
+		publicA, _ := curve25519.X25519(privA[:], curve25519.Basepoint)
+		var decoded [32]byte
+		RepresentativeToPublicKey(&decoded, &reprA)
+		if !bytes.Equal(decoded[:], publicA) {
+			t.Fatalf("representative %x decodes to %x, want %x", reprA, decoded, publicA)
+		}
+	}
+}
+
+// TestRepresentativeDistribution is a smoke test that every bit of the
+// representatives, the top one included, is set about half of the time.
+func TestRepresentativeDistribution(t *testing.T) {
+	n := 2000
+	if testing.Short() {
+		n = 400
+	}
+	var counts [256]int
+	for i := 0; i < n; i++ {
+		_, representative, err := GenerateObfuscatedKeypair(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for bit := range counts {
+			counts[bit] += int(representative[bit/8] >> uint(bit%8) & 1)
+		}
+	}
+	// Six standard deviations, sqrt(n)/2 each, from n/2.
+	var slack int
+	for slack*slack < 9*n {
+		slack++
+	}
+	for bit, c := range counts {
+		if c < n/2-slack || c > n/2+slack {
+			t.Errorf("bit %d set in %d of %d representatives", bit, c, n)
+		}
+	}
+}
+
+func TestGenerateObfuscatedKeypairErrors(t *testing.T) {
+	// Neither of these two private keys has a representative.
+	random, _ := hex.DecodeString("2fedd07b797ce1a2ecfdfc65d95739d138993c6f21ceac20e040e71d586f9801376663014e09bf3a0e36ddb12a130e3af54d05dfd7e48190b7b29f2cc038dd5e3d8f")
+	priv, representative, err := GenerateObfuscatedKeypair(bytes.NewReader(random))
+	if err == nil {
+		t.Fatal("exhausted random source accepted")
+	}
+	if priv != [32]byte{} || representative != [32]byte{} {
+		t.Errorf("error returned %x, %x", priv, representative)
+	}
+	if _, _, err := GenerateObfuscatedKeypair(bytes.NewReader(random[:32])); err == nil {
+		t.Error("short random source accepted")
+	}
+}
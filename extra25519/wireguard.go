@@ -0,0 +1,56 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package extra25519
+
+import (
+	"encoding/base64"
+	"errors"
+)
+
+// WireGuard configurations and the wg tool write curve25519 keys, public and
+// private alike, as the padded standard base64 encoding of their 32 bytes.
+
+// ErrBadWireGuardKey is returned by ParseWireGuardKey when the key is not
+// exactly 44 characters of padded, canonical base64 that encode 32 bytes.
+var ErrBadWireGuardKey = errors.New("extra25519: malformed WireGuard key")
+
+// ErrBadEd25519Key is returned by ConvertedX25519PublicFromEd25519 when the
+// Ed25519 public key is not a valid point.
+var ErrBadEd25519Key = errors.New("extra25519: invalid Ed25519 public key")
+
+// FormatWireGuardKey returns key in the base64 form used by WireGuard, as in
+// "hSDwCYkwp1R0i33ctD73Wg2/Og0mOBr066SpjqqbTmo=".
+func FormatWireGuardKey(key [32]byte) string {
+	return base64.StdEncoding.EncodeToString(key[:])
+}
+
+// ParseWireGuardKey parses a key in the base64 form used by WireGuard. Unlike
+// the decoders of encoding/base64 it accepts only the canonical encoding, so
+// it rejects missing padding, line breaks, the URL alphabet and set unused
+// bits.
+func ParseWireGuardKey(s string) ([32]byte, error) {
+	var key [32]byte
+	if len(s) != 44 {
+		return key, ErrBadWireGuardKey
+	}
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil || len(b) != 32 || base64.StdEncoding.EncodeToString(b) != s {
+		return key, ErrBadWireGuardKey
+	}
+	copy(key[:], b)
+	return key, nil
+}
+
+// ConvertedX25519PublicFromEd25519 converts an Ed25519 public key with
+// PublicKeyToCurve25519 and returns the result as FormatWireGuardKey does,
+// ready to be used as the PublicKey of a WireGuard peer. The matching private
+// key is that of PrivateKeyToCurve25519.
+func ConvertedX25519PublicFromEd25519(publicKey *[32]byte) (string, error) {
+	var curve25519Public [32]byte
+	if !PublicKeyToCurve25519(&curve25519Public, publicKey) {
+		return "", ErrBadEd25519Key
+	}
+	return FormatWireGuardKey(curve25519Public), nil
+}
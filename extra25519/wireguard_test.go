@@ -0,0 +1,101 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package extra25519
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/agl/ed25519"
+	"golang.org/x/crypto/curve25519"
+)
+
+// The private and public keys of Alice from RFC 7748, Section 6.1, in the
+// form printed by "wg genkey" and "wg pubkey".
+const (
+	wireGuardPrivate = "dwdtCnMYpX08FsFyUbJmRd9ML4frwJkqsXf7pR25LCo="
+	wireGuardPublic  = "hSDwCYkwp1R0i33ctD73Wg2/Og0mOBr066SpjqqbTmo="
+)
+
+func TestWireGuardKey(t *testing.T) {
+	private, err := ParseWireGuardKey(wireGuardPrivate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hex.EncodeToString(private[:]) != "77076d0a7318a57d3c16c17251b26645df4c2f87ebc0992ab177fba51db92c2a" {
+		t.Errorf("private key %x", private)
+	}
+	var public [32]byte
+	curve25519.ScalarBaseMult(&public, &private)
+	if got := FormatWireGuardKey(public); got != wireGuardPublic {
+		t.Errorf("public key %s, want %s", got, wireGuardPublic)
+	}
+
+	for i := 0; i < 10; i++ {
+		var key [32]byte
+		rand.Read(key[:])
+		s := FormatWireGuardKey(key)
+		if len(s) != 44 {
+			t.Fatalf("%s is not 44 characters", s)
+		}
+		if back, err := ParseWireGuardKey(s); err != nil || back != key {
+			t.Errorf("%s didn't round-trip: %x, %v", s, back, err)
+		}
+	}
+}
+
+func TestParseWireGuardKeyErrors(t *testing.T) {
+	for _, s := range []string{
+		"",
+		strings.TrimSuffix(wireGuardPublic, "="),
+		wireGuardPublic + "=",
+		" " + wireGuardPublic[1:],
+		wireGuardPublic[:20] + "\n" + wireGuardPublic[21:],
+		strings.Replace(wireGuardPublic, "/", "_", 1),
+		// The last character sets one of the unused bits.
+		wireGuardPublic[:42] + "p=",
+		// 33 bytes in 44 characters.
+		"hSDwCYkwp1R0i33ctD73Wg2/Og0mOBr066SpjqqbTmpp",
+		// 31 bytes, padded to 44 characters.
+		"hSDwCYkwp1R0i33ctD73Wg2/Og0mOBr066SpjqqbTg==",
+	} {
+		if _, err := ParseWireGuardKey(s); err != ErrBadWireGuardKey {
+			t.Errorf("%q gave %v", s, err)
+		}
+	}
+}
+
+func TestConvertedX25519PublicFromEd25519(t *testing.T) {
+	// The key of RFC 8032, Section 7.1, test 1, converted by
+	// crypto_sign_ed25519_pk_to_curve25519 of libsodium.
+	var public [32]byte
+	hex.Decode(public[:], []byte("d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a"))
+	got, err := ConvertedX25519PublicFromEd25519(&public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "2F4H7CKwrYgVN8L0TWYtGhQ8+DDFespDBdhcepD2ti4=" {
+		t.Errorf("got %s", got)
+	}
+
+	// The converted private key, as a WireGuard private key, has the
+	// converted public key.
+	edPublic, edPrivate, _ := ed25519.GenerateKey(rand.Reader)
+	var curve25519Private, curve25519Public [32]byte
+	PrivateKeyToCurve25519(&curve25519Private, (*[64]byte)(edPrivate))
+	curve25519.ScalarBaseMult(&curve25519Public, &curve25519Private)
+	got, err = ConvertedX25519PublicFromEd25519((*[32]byte)(edPublic))
+	if err != nil || got != FormatWireGuardKey(curve25519Public) {
+		t.Errorf("got %s, %v, want %s", got, err, FormatWireGuardKey(curve25519Public))
+	}
+
+	// y = 2 is not the y-coordinate of a point.
+	bad := [32]byte{2}
+	if _, err := ConvertedX25519PublicFromEd25519(&bad); err != ErrBadEd25519Key {
+		t.Errorf("invalid point gave %v", err)
+	}
+}
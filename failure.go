@@ -0,0 +1,109 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import "strconv"
+
+// VerifyFailure says why VerifyDetailed rejected a signature.
+//
+// It is meant for logs and debugging only. Callers must not make security
+// decisions on anything but whether verification succeeded, and should not
+// reveal the reason to whoever supplied the signature: the set of reasons,
+// and which check fires first, may change between versions.
+type VerifyFailure int
+
+const (
+	// FailureNone means that the signature is valid.
+	FailureNone VerifyFailure = iota
+	// FailureBadOptions means that the Options were invalid or
+	// inconsistent with the message, for example an Ed25519ph message
+	// that is not a 64-byte digest.
+	FailureBadOptions
+	// FailurePublicKeyLength means that the public key was not
+	// PublicKeySize bytes long.
+	FailurePublicKeyLength
+	// FailureSignatureLength means that the signature was not
+	// SignatureSize bytes long.
+	FailureSignatureLength
+	// FailureNonCanonicalS means that S was not reduced modulo L.
+	FailureNonCanonicalS
+	// FailurePublicKeyNotOnCurve means that the public key did not decode
+	// to a point.
+	FailurePublicKeyNotOnCurve
+	// FailureNonCanonicalPublicKey means that the public key was a
+	// non-canonical encoding, which only ModeStrict rejects.
+	FailureNonCanonicalPublicKey
+	// FailureSmallOrderPublicKey means that the public key was a point of
+	// small order, which only ModeStrict rejects.
+	FailureSmallOrderPublicKey
+	// FailureRNotOnCurve means that R did not decode to a point. The
+	// default rules compare R as bytes, so they report such signatures
+	// as FailureEquation instead.
+	FailureRNotOnCurve
+	// FailureNonCanonicalR means that R was a non-canonical encoding,
+	// which ModeZIP215 accepts and the default rules report as
+	// FailureEquation.
+	FailureNonCanonicalR
+	// FailureSmallOrderR means that R was a point of small order, which
+	// only ModeStrict rejects.
+	FailureSmallOrderR
+	// FailureEquation means that the signature was well formed but the
+	// verification equation did not hold.
+	FailureEquation
+)
+
+var verifyFailureNames = [...]string{
+	FailureNone:                  "none",
+	FailureBadOptions:            "bad options",
+	FailurePublicKeyLength:       "bad public key length",
+	FailureSignatureLength:       "bad signature length",
+	FailureNonCanonicalS:         "non-canonical S",
+	FailurePublicKeyNotOnCurve:   "public key not on curve",
+	FailureNonCanonicalPublicKey: "non-canonical public key",
+	FailureSmallOrderPublicKey:   "small-order public key",
+	FailureRNotOnCurve:           "R not on curve",
+	FailureNonCanonicalR:         "non-canonical R",
+	FailureSmallOrderR:           "small-order R",
+	FailureEquation:              "verification equation mismatch",
+}
+
+func (f VerifyFailure) String() string {
+	if f >= 0 && int(f) < len(verifyFailureNames) {
+		return verifyFailureNames[f]
+	}
+	return "VerifyFailure(" + strconv.Itoa(int(f)) + ")"
+}
+
+// err returns the error that CheckSignature reports for f.
+func (f VerifyFailure) err() error {
+	switch f {
+	case FailureNone:
+		return nil
+	case FailurePublicKeyLength:
+		return ErrBadPublicKeyLength
+	case FailureSignatureLength:
+		return ErrBadSignatureLength
+	case FailurePublicKeyNotOnCurve, FailureNonCanonicalPublicKey, FailureSmallOrderPublicKey:
+		return ErrInvalidPublicKey
+	}
+	return ErrInvalidSignature
+}
+
+// VerifyDetailed is like VerifyWithOptions but reports why sig was rejected.
+// ok is true, and reason is FailureNone, exactly when VerifyWithOptions would
+// return nil. See VerifyFailure for the limits on how reason may be used.
+func VerifyDetailed(publicKey PublicKey, message, sig []byte, opts *Options) (ok bool, reason VerifyFailure) {
+	rules, err := opts.rules()
+	if err != nil {
+		return false, FailureBadOptions
+	}
+	dom, err := opts.dom(message)
+	if err != nil {
+		return false, FailureBadOptions
+	}
+
+	reason = verifyReason(publicKey, message, sig, dom, rules)
+	return reason == FailureNone, reason
+}
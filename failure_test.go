@@ -0,0 +1,91 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"crypto"
+	"crypto/rand"
+	"testing"
+)
+
+func TestVerifyDetailed(t *testing.T) {
+	public, private, _ := GenerateKey(rand.Reader)
+	message := []byte("message")
+	sig := mustSign(t, private, message)
+
+	withR := func(R []byte) []byte {
+		return append(append([]byte{}, R...), sig[32:]...)
+	}
+	nonCanonical := decodeHex(t, "f0ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f")
+	identity := decodeHex(t, smallOrderEncodings[0])
+	strict := &Options{Mode: ModeStrict}
+	cofactored := &Options{Mode: ModeCofactored}
+
+	for _, test := range []struct {
+		name      string
+		publicKey PublicKey
+		message   []byte
+		sig       []byte
+		opts      *Options
+		want      VerifyFailure
+	}{
+		{"valid", public, message, sig, nil, FailureNone},
+		{"valid, strict", public, message, sig, strict, FailureNone},
+		{"unknown mode", public, message, sig, &Options{Mode: 99}, FailureBadOptions},
+		{"short Ed25519ph digest", public, message, sig, &Options{Hash: crypto.SHA512}, FailureBadOptions},
+		{"short public key", public[:31], message, sig, nil, FailurePublicKeyLength},
+		{"short signature", public, message, sig[:63], nil, FailureSignatureLength},
+		{"S + L", public, message, append(sig[:32:32], addL(sig[32:])...), nil, FailureNonCanonicalS},
+		{"public key off curve", offCurve, message, sig, nil, FailurePublicKeyNotOnCurve},
+		{"non-canonical public key, strict", nonCanonical, message, sig, strict, FailureNonCanonicalPublicKey},
+		{"small-order public key, strict", identity, message, sig, strict, FailureSmallOrderPublicKey},
+		{"R off curve, strict", public, message, withR(offCurve), strict, FailureRNotOnCurve},
+		{"R off curve, cofactored", public, message, withR(offCurve), cofactored, FailureRNotOnCurve},
+		{"R off curve", public, message, withR(offCurve), nil, FailureEquation},
+		{"non-canonical R, strict", public, message, withR(nonCanonical), strict, FailureNonCanonicalR},
+		{"non-canonical R, cofactored", public, message, withR(nonCanonical), cofactored, FailureNonCanonicalR},
+		{"non-canonical R", public, message, withR(nonCanonical), nil, FailureEquation},
+		{"small-order R, strict", public, message, withR(identity), strict, FailureSmallOrderR},
+		{"wrong message", public, []byte("other"), sig, nil, FailureEquation},
+		{"wrong message, cofactored", public, []byte("other"), sig, cofactored, FailureEquation},
+	} {
+		ok, reason := VerifyDetailed(test.publicKey, test.message, test.sig, test.opts)
+		if reason != test.want {
+			t.Errorf("%s: got %v, want %v", test.name, reason, test.want)
+		}
+		if ok != (test.want == FailureNone) {
+			t.Errorf("%s: ok is %v with reason %v", test.name, ok, reason)
+		}
+
+		// The outcome always agrees with VerifyWithOptions.
+		err := VerifyWithOptions(test.publicKey, test.message, test.sig, test.opts)
+		if ok != (err == nil) {
+			t.Errorf("%s: VerifyDetailed returned %v but VerifyWithOptions returned %v", test.name, ok, err)
+		}
+		if test.want != FailureBadOptions && err != reason.err() {
+			t.Errorf("%s: VerifyWithOptions returned %v, want %v", test.name, err, reason.err())
+		}
+	}
+}
+
+func TestVerifyFailureString(t *testing.T) {
+	seen := make(map[string]VerifyFailure)
+	for f := FailureNone; f <= FailureEquation; f++ {
+		s := f.String()
+		if s == "" {
+			t.Errorf("VerifyFailure %d has no name", int(f))
+		}
+		if other, ok := seen[s]; ok {
+			t.Errorf("%d and %d are both named %q", int(other), int(f), s)
+		}
+		seen[s] = f
+	}
+	if s := VerifyFailure(100).String(); s != "VerifyFailure(100)" {
+		t.Errorf("unknown reason is named %q", s)
+	}
+	if s := VerifyFailure(-1).String(); s != "VerifyFailure(-1)" {
+		t.Errorf("negative reason is named %q", s)
+	}
+}
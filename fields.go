@@ -0,0 +1,63 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"crypto"
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+var errFieldLength = errors.New("ed25519: field longer than 2^32-1 bytes")
+
+// SignFields signs a message made of several fields, encoding them so that
+// no two different lists of fields produce the same signed data. Each field
+// is preceded by its length as a 32-bit big-endian integer:
+//
+//	len(fields[0]) || fields[0] || len(fields[1]) || fields[1] || ...
+//
+// The encoding can be decoded in only one way, which binds both the number
+// of fields and where each of them ends: ("ab", "c"), ("a", "bc"), ("abc")
+// and ("abc", "") are all signed differently. The result is an ordinary
+// Ed25519 signature of the encoding. signer is usually a PrivateKey, but may
+// be any crypto.Signer with an Ed25519 public key.
+func SignFields(signer crypto.Signer, fields ...[]byte) ([]byte, error) {
+	message, err := encodeFields(fields)
+	if err != nil {
+		return nil, err
+	}
+	return signWith(signer, message)
+}
+
+// VerifyFields reports whether sig is a valid signature of fields by
+// publicKey, as produced by SignFields.
+func VerifyFields(publicKey PublicKey, sig []byte, fields ...[]byte) bool {
+	message, err := encodeFields(fields)
+	if err != nil {
+		return false
+	}
+	return Verify(publicKey, message, sig)
+}
+
+// encodeFields returns the encoding of fields described for SignFields.
+func encodeFields(fields [][]byte) ([]byte, error) {
+	size := 0
+	for _, f := range fields {
+		if uint64(len(f)) > math.MaxUint32 {
+			return nil, errFieldLength
+		}
+		size += 4 + len(f)
+	}
+
+	out := make([]byte, 0, size)
+	for _, f := range fields {
+		var l [4]byte
+		binary.BigEndian.PutUint32(l[:], uint32(len(f)))
+		out = append(out, l[:]...)
+		out = append(out, f...)
+	}
+	return out, nil
+}
@@ -0,0 +1,89 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSignFields(t *testing.T) {
+	public, private, _ := GenerateKey(zeroReader{})
+
+	sig, err := SignFields(private, []byte("alice"), []byte("transfer"), []byte("100"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyFields(public, sig, []byte("alice"), []byte("transfer"), []byte("100")) {
+		t.Errorf("valid signature rejected")
+	}
+
+	// The signed data is the documented encoding.
+	encoded := []byte("\x00\x00\x00\x05alice\x00\x00\x00\x08transfer\x00\x00\x00\x03100")
+	if !Verify(public, encoded, sig) {
+		t.Errorf("signature does not match the documented encoding")
+	}
+	if Verify(public, []byte("alicetransfer100"), sig) {
+		t.Errorf("signature accepted for the plain concatenation")
+	}
+}
+
+func TestSignFieldsAmbiguity(t *testing.T) {
+	public, private, _ := GenerateKey(zeroReader{})
+
+	// Each of these splits the same concatenated bytes "abc" differently,
+	// or adds empty fields.
+	splits := [][][]byte{
+		{},
+		{[]byte("abc")},
+		{[]byte("ab"), []byte("c")},
+		{[]byte("a"), []byte("bc")},
+		{[]byte("a"), []byte("b"), []byte("c")},
+		{[]byte("abc"), {}},
+		{{}, []byte("abc")},
+		{{}},
+		{{}, {}},
+	}
+
+	sigs := make([][]byte, len(splits))
+	for i, fields := range splits {
+		sig, err := SignFields(private, fields...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sigs[i] = sig
+	}
+
+	for i := range splits {
+		for j := range splits {
+			if i != j && bytes.Equal(sigs[i], sigs[j]) {
+				t.Errorf("splits %q and %q have the same signature", splits[i], splits[j])
+			}
+			if got := VerifyFields(public, sigs[i], splits[j]...); got != (i == j) {
+				t.Errorf("signature of %q: VerifyFields(%q) = %v", splits[i], splits[j], got)
+			}
+		}
+	}
+}
+
+func TestEncodeFields(t *testing.T) {
+	for _, test := range []struct {
+		fields [][]byte
+		want   string
+	}{
+		{nil, ""},
+		{[][]byte{{}}, "\x00\x00\x00\x00"},
+		{[][]byte{[]byte("x"), nil}, "\x00\x00\x00\x01x\x00\x00\x00\x00"},
+		{[][]byte{bytes.Repeat([]byte("y"), 258)}, "\x00\x00\x01\x02" + string(bytes.Repeat([]byte("y"), 258))},
+	} {
+		got, err := encodeFields(test.fields)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != test.want {
+			t.Errorf("encodeFields(%q) = %q, want %q", test.fields, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+)
+
+// The fingerprint formats are part of the API: a key has the same fingerprint
+// in every release, so that logs and pinning configurations stay comparable.
+//
+//   - FingerprintSHA256 is the SHA-256 hash of the 32 bytes of the key, in
+//     lower case hex, 64 characters long. Truncated, it is the first 8 or 16
+//     bytes of that hash, 16 or 32 characters long.
+//   - FingerprintSSH is "SHA256:" followed by the unpadded base64 of the
+//     SHA-256 hash of the SSH wire encoding of the key, as shown by
+//     ssh-keygen -l and returned by SSHFingerprintSHA256.
+
+// FingerprintFormat selects the hash input and encoding of a fingerprint.
+type FingerprintFormat int
+
+const (
+	// FingerprintSHA256 hashes the raw public key and writes the hash in
+	// hex.
+	FingerprintSHA256 FingerprintFormat = iota
+	// FingerprintSSH is the OpenSSH SHA-256 fingerprint.
+	FingerprintSSH
+)
+
+// FingerprintOptions configures Fingerprint. The zero value selects the
+// defaults, the full FingerprintSHA256 form.
+type FingerprintOptions struct {
+	Format FingerprintFormat
+
+	// Truncate, if not zero, keeps only the first Truncate bytes of the
+	// hash, which must be 8 or 16. It is only allowed with
+	// FingerprintSHA256.
+	Truncate int
+}
+
+// Fingerprint returns a short identifier of publicKey in the format selected by
+// opts, which may be nil for the defaults. It returns the empty string if
+// len(publicKey) is not PublicKeySize or if opts is invalid.
+func Fingerprint(publicKey PublicKey, opts *FingerprintOptions) string {
+	var o FingerprintOptions
+	if opts != nil {
+		o = *opts
+	}
+	if len(publicKey) != PublicKeySize {
+		return ""
+	}
+	switch {
+	case o.Format == FingerprintSSH && o.Truncate == 0:
+		return SSHFingerprintSHA256(publicKey)
+	case o.Format == FingerprintSHA256 && (o.Truncate == 0 || o.Truncate == 8 || o.Truncate == 16):
+		sum := sha256.Sum256(publicKey)
+		if o.Truncate == 0 {
+			return hex.EncodeToString(sum[:])
+		}
+		return hex.EncodeToString(sum[:o.Truncate])
+	}
+	return ""
+}
+
+// MatchFingerprint reports whether fp is a fingerprint of publicKey in any of
+// the formats that Fingerprint produces, which it tells apart by their prefix
+// and length. Hex fingerprints may be in upper case.
+func MatchFingerprint(publicKey PublicKey, fp string) bool {
+	var want string
+	switch {
+	case strings.HasPrefix(fp, "SHA256:"):
+		want = Fingerprint(publicKey, &FingerprintOptions{Format: FingerprintSSH})
+	case len(fp) == 2*sha256.Size:
+		want, fp = Fingerprint(publicKey, nil), strings.ToLower(fp)
+	case len(fp) == 2*8 || len(fp) == 2*16:
+		want, fp = Fingerprint(publicKey, &FingerprintOptions{Truncate: len(fp) / 2}), strings.ToLower(fp)
+	}
+	return want != "" && subtle.ConstantTimeCompare([]byte(fp), []byte(want)) == 1
+}
@@ -0,0 +1,63 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFingerprint(t *testing.T) {
+	// The public key of RFC 8032, section 7.1, test 1. The SSH form is the
+	// output of ssh-keygen -lf.
+	public := PublicKey(decodeHex(t, "d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a"))
+	for _, tc := range []struct {
+		opts *FingerprintOptions
+		want string
+	}{
+		{nil, "21fe31dfa154a261626bf854046fd2271b7bed4b6abe45aa58877ef47f9721b9"},
+		{&FingerprintOptions{Truncate: 8}, "21fe31dfa154a261"},
+		{&FingerprintOptions{Truncate: 16}, "21fe31dfa154a261626bf854046fd227"},
+		{&FingerprintOptions{Format: FingerprintSSH}, "SHA256:bbXpuKG6zhzdmnxq256TlqzFBzRl2f6OOg722cYNbU8"},
+	} {
+		got := Fingerprint(public, tc.opts)
+		if got != tc.want {
+			t.Errorf("Fingerprint(%+v) = %s, want %s", tc.opts, got, tc.want)
+		}
+		if !MatchFingerprint(public, tc.want) {
+			t.Errorf("MatchFingerprint(%s) = false", tc.want)
+		}
+		if !strings.HasPrefix(tc.want, "SHA256:") && !MatchFingerprint(public, strings.ToUpper(tc.want)) {
+			t.Errorf("MatchFingerprint(%s) in upper case = false", tc.want)
+		}
+		if MatchFingerprint(public, tc.want[:len(tc.want)-1]) {
+			t.Errorf("MatchFingerprint(%s) matched when truncated", tc.want)
+		}
+		other := []byte(tc.want)
+		other[len(other)-1] ^= 1
+		if MatchFingerprint(public, string(other)) {
+			t.Errorf("MatchFingerprint(%s) matched", other)
+		}
+	}
+
+	for _, opts := range []*FingerprintOptions{
+		{Truncate: 4},
+		{Truncate: 32},
+		{Format: FingerprintSSH, Truncate: 8},
+		{Format: 2},
+	} {
+		if got := Fingerprint(public, opts); got != "" {
+			t.Errorf("Fingerprint(%+v) = %s", opts, got)
+		}
+	}
+	if Fingerprint(public[:31], nil) != "" {
+		t.Error("fingerprint of a key of the wrong length")
+	}
+	for _, fp := range []string{"", "SHA256:", "21fe", "sha256:bbXpuKG6zhzdmnxq256TlqzFBzRl2f6OOg722cYNbU8"} {
+		if MatchFingerprint(public, fp) {
+			t.Errorf("MatchFingerprint(%q) = true", fp)
+		}
+	}
+}
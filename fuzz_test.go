@@ -0,0 +1,89 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"crypto"
+	"testing"
+)
+
+// The fuzz targets below only check that no input causes a panic and that
+// the different entry points agree with each other. Run them with, e.g.,
+//
+//	go test -fuzz FuzzVerify
+
+func FuzzSign(f *testing.F) {
+	_, private, _ := GenerateKey(zeroReader{})
+	f.Add([]byte(private), []byte("message"), uint(0), "")
+	f.Add([]byte(private[:31]), []byte{}, uint(crypto.SHA512), "context")
+	f.Add([]byte{}, []byte(nil), uint(0), "")
+
+	f.Fuzz(func(t *testing.T, private, message []byte, hash uint, context string) {
+		sig, err := Sign(private, message)
+		if (err == nil) != (len(private) == PrivateKeySize) {
+			t.Fatalf("Sign with %d-byte key returned %v", len(private), err)
+		}
+		if err == nil && len(sig) != SignatureSize {
+			t.Fatalf("Sign returned %d-byte signature", len(sig))
+		}
+
+		opts := &Options{Hash: crypto.Hash(hash), Context: context}
+		if sig, err := SignWithRand(zeroReader{}, private, message, opts); err == nil && len(sig) != SignatureSize {
+			t.Fatalf("SignWithRand returned %d-byte signature", len(sig))
+		}
+		if sig, err := PrivateKey(private).Sign(nil, message, opts); err == nil && len(sig) != SignatureSize {
+			t.Fatalf("PrivateKey.Sign returned %d-byte signature", len(sig))
+		}
+		if k, err := PrivateKey(private).Expand(); err == nil {
+			k.SignWithRand(nil, message, opts)
+		}
+	})
+}
+
+func FuzzVerify(f *testing.F) {
+	public, private, _ := GenerateKey(zeroReader{})
+	message := []byte("message")
+	sig := mustSign(f, private, message)
+	f.Add([]byte(public), message, sig)
+	f.Add([]byte(public[:31]), message, sig[:63])
+	f.Add(offCurve, []byte{}, append(offCurve, sig[32:]...))
+	for _, enc := range smallOrderEncodings {
+		f.Add(decodeHex(f, enc), message, append(decodeHex(f, enc), make([]byte, 32)...))
+	}
+
+	f.Fuzz(func(t *testing.T, public, message, sig []byte) {
+		ok := Verify(public, message, sig)
+		if err := CheckSignature(public, message, sig); (err == nil) != ok {
+			t.Fatalf("Verify returned %v but CheckSignature returned %v", ok, err)
+		}
+		cofactored := VerifyCofactored(public, message, sig)
+		zip215 := VerifyZIP215(public, message, sig)
+		strict := StrictVerify(public, message, sig)
+		if ok && !cofactored {
+			t.Fatalf("Verify accepted a signature that VerifyCofactored rejected")
+		}
+		if cofactored && !zip215 {
+			t.Fatalf("VerifyCofactored accepted a signature that VerifyZIP215 rejected")
+		}
+		if strict && !ok {
+			t.Fatalf("StrictVerify accepted a signature that Verify rejected")
+		}
+
+		if p, err := NewPrecomputedPublicKey(public); err == nil {
+			if p.Verify(message, sig) != ok {
+				t.Fatalf("PrecomputedPublicKey.Verify disagrees with Verify")
+			}
+		}
+
+		var v BatchVerifier
+		if err := v.Add(public, message, sig); err == nil {
+			if v.VerifyAll() != zip215 {
+				t.Fatalf("BatchVerifier disagrees with VerifyZIP215")
+			}
+		} else if zip215 {
+			t.Fatalf("BatchVerifier.Add rejected a signature that VerifyZIP215 accepted: %v", err)
+		}
+	})
+}
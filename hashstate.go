@@ -0,0 +1,49 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"crypto/sha512"
+	"hash"
+	"sync"
+)
+
+// hashState is a hash together with buffers for its input and output.
+// Anything passed to the methods of a hash.Hash escapes to the heap, so
+// signing and verification copy their temporaries into a hashState instead,
+// and take SHA-512 hashStates from a pool. With that, neither allocates.
+type hashState struct {
+	h      hash.Hash
+	buf    [64]byte
+	digest [64]byte
+}
+
+var sha512Pool = sync.Pool{
+	New: func() interface{} { return &hashState{h: sha512.New()} },
+}
+
+// getSHA512 returns a reset SHA-512 hashState from the pool. It must be
+// returned with putSHA512.
+func getSHA512() *hashState {
+	st := sha512Pool.Get().(*hashState)
+	st.h.Reset()
+	return st
+}
+
+// putSHA512 wipes st and returns it to the pool.
+func putSHA512(st *hashState) {
+	st.wipe()
+	sha512Pool.Put(st)
+}
+
+// wipe overwrites everything st has absorbed or produced.
+func (st *hashState) wipe() {
+	wipeHash(st.h)
+	wipeBytes(st.buf[:])
+	wipeBytes(st.digest[:])
+}
+
+// zeroBlock is written to hashes by wipeHash. It is never modified.
+var zeroBlock [256]byte
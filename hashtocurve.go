@@ -0,0 +1,168 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"crypto/sha512"
+
+	"github.com/agl/ed25519/edwards25519"
+	"github.com/agl/ed25519/internal/xmd"
+)
+
+// This file implements the edwards25519_XMD:SHA-512_ELL2_NU_ suite of
+// RFC 9380: the message is expanded with expand_message_xmd and SHA-512 to a
+// field element, which Elligator 2 maps to Curve25519 and the rational map of
+// Section 6.8.2 carries to edwards25519, and the cofactor is cleared.
+
+// sqrtMinus486664Even is the square root of -486664 whose sgn0 is 0, the
+// constant c1 of the rational map of RFC 9380, Appendix D.1.
+var sqrtMinus486664Even = edwards25519.FieldElement{
+	-12222970, -8312128, -11511410, 9067497, -15300785, -241793, 25456130, 14121551, -12187136, 3972024,
+}
+
+// twoTo192 is 2^192, for reducing 48-byte big-endian values modulo p.
+var twoTo192 = edwards25519.FieldElement{0, 0, 0, 0, 0, 0, 0, 8192, 0, 0}
+
+// expandMessageXMD implements expand_message_xmd of RFC 9380, Section
+// 5.3.1, with SHA-512.
+func expandMessageXMD(msg, dst []byte, n int) ([]byte, error) {
+	return xmd.Expand(sha512.New, msg, dst, n)
+}
+
+// fePow sets out to z^e, where e is a little-endian exponent. It runs in
+// time that depends on e but not on z.
+func fePow(out, z *edwards25519.FieldElement, e *[32]byte) {
+	var t edwards25519.FieldElement
+	edwards25519.FeOne(&t)
+	for i := 255; i >= 0; i-- {
+		edwards25519.FeSquare(&t, &t)
+		if e[i/8]>>uint(i%8)&1 == 1 {
+			edwards25519.FeMul(&t, &t, z)
+		}
+	}
+	*out = t
+}
+
+// feSqrt sets out to a square root of a and returns 1 if a is a square, and
+// returns 0 otherwise, in constant time.
+func feSqrt(out, a *edwards25519.FieldElement) int32 {
+	// A square root, if there is one, is a^((p+3)/8) or that times
+	// sqrt(-1). (p+3)/8 = 2^252 - 2.
+	var e [32]byte
+	for i := range e {
+		e[i] = 0xff
+	}
+	e[0] = 0xfe
+	e[31] = 0x0f
+
+	var r, check, diff edwards25519.FieldElement
+	fePow(&r, a, &e)
+	edwards25519.FeSquare(&check, &r)
+	edwards25519.FeSub(&diff, &check, a)
+	isRoot := 1 - edwards25519.FeIsNonZero(&diff)
+
+	var rI edwards25519.FieldElement
+	edwards25519.FeMul(&rI, &r, &edwards25519.SqrtM1)
+	edwards25519.FeCMove(&r, &rI, 1-isRoot)
+	edwards25519.FeSquare(&check, &r)
+	edwards25519.FeSub(&diff, &check, a)
+	*out = r
+	return 1 - edwards25519.FeIsNonZero(&diff)
+}
+
+// hashToFieldEdwards25519 implements hash_to_field of RFC 9380, Section
+// 5.2, for one element of GF(2^255 - 19), with L = 48.
+func hashToFieldEdwards25519(msg, dst []byte) (*edwards25519.FieldElement, error) {
+	uniform, err := expandMessageXMD(msg, dst, 48)
+	if err != nil {
+		return nil, err
+	}
+	// The 48 bytes are a big-endian integer: split it into two 192-bit
+	// halves, which FeFromBytes can load, and combine them modulo p.
+	var lo, hi [32]byte
+	for i := 0; i < 24; i++ {
+		hi[i] = uniform[23-i]
+		lo[i] = uniform[47-i]
+	}
+	var u, t edwards25519.FieldElement
+	edwards25519.FeFromBytes(&u, &lo)
+	edwards25519.FeFromBytes(&t, &hi)
+	edwards25519.FeMul(&t, &t, &twoTo192)
+	edwards25519.FeAdd(&u, &u, &t)
+	return &u, nil
+}
+
+// mapToCurveElligator2Edwards25519 implements
+// map_to_curve_elligator2_edwards25519 of RFC 9380, Appendix G.2.2: the
+// Elligator 2 map of Section 6.7.1 with Z = 2 to Curve25519, followed by the
+// rational map to edwards25519. It runs in constant time.
+func mapToCurveElligator2Edwards25519(u *edwards25519.FieldElement) *edwards25519.Point {
+	var one, a, t, x1, x2, gx, y1, y2 edwards25519.FieldElement
+	edwards25519.FeOne(&one)
+	a[0] = 486662
+
+	// x1 = -A / (1 + 2u^2); 1 + 2u^2 is never zero as -1/2 isn't a square.
+	edwards25519.FeSquare2(&t, u)
+	edwards25519.FeAdd(&t, &t, &one)
+	edwards25519.FeInvert(&t, &t)
+	edwards25519.FeMul(&x1, &a, &t)
+	edwards25519.FeNeg(&x1, &x1)
+	edwards25519.FeNeg(&x2, &x1)
+	edwards25519.FeSub(&x2, &x2, &a)
+
+	// g(x) = x^3 + Ax^2 + x, with y1 of sgn0 1 and y2 of sgn0 0.
+	curve := func(gx, x *edwards25519.FieldElement) {
+		edwards25519.FeAdd(gx, x, &a)
+		edwards25519.FeMul(gx, gx, x)
+		edwards25519.FeAdd(gx, gx, &one)
+		edwards25519.FeMul(gx, gx, x)
+	}
+	var minus edwards25519.FieldElement
+	curve(&gx, &x1)
+	isSquare := feSqrt(&y1, &gx)
+	edwards25519.FeNeg(&minus, &y1)
+	edwards25519.FeCMove(&y1, &minus, int32(1^edwards25519.FeIsNegative(&y1)))
+	curve(&gx, &x2)
+	feSqrt(&y2, &gx)
+	edwards25519.FeNeg(&minus, &y2)
+	edwards25519.FeCMove(&y2, &minus, int32(edwards25519.FeIsNegative(&y2)))
+	edwards25519.FeCMove(&x2, &x1, isSquare)
+	edwards25519.FeCMove(&y2, &y1, isSquare)
+
+	// (x, y) = (c1 * s / t, (s - 1) / (s + 1)) with one inversion, or the
+	// identity where the denominator is zero.
+	var xn, xd, yn, yd, inv edwards25519.FieldElement
+	edwards25519.FeMul(&xn, &x2, &sqrtMinus486664Even)
+	xd = y2
+	edwards25519.FeSub(&yn, &x2, &one)
+	edwards25519.FeAdd(&yd, &x2, &one)
+	edwards25519.FeMul(&inv, &xd, &yd)
+	exceptional := 1 - edwards25519.FeIsNonZero(&inv)
+	edwards25519.FeInvert(&inv, &inv)
+
+	var p edwards25519.ExtendedGroupElement
+	edwards25519.FeMul(&p.X, &xn, &yd)
+	edwards25519.FeMul(&p.X, &p.X, &inv)
+	edwards25519.FeMul(&p.Y, &yn, &xd)
+	edwards25519.FeMul(&p.Y, &p.Y, &inv)
+	var zero edwards25519.FieldElement
+	edwards25519.FeCMove(&p.X, &zero, exceptional)
+	edwards25519.FeCMove(&p.Y, &one, exceptional)
+	edwards25519.FeOne(&p.Z)
+	edwards25519.FeMul(&p.T, &p.X, &p.Y)
+	return new(edwards25519.Point).SetExtendedGroupElement(&p)
+}
+
+// encodeToCurveEdwards25519 implements encode_to_curve of RFC 9380, Section
+// 3, for the suite edwards25519_XMD:SHA-512_ELL2_NU_ with the domain
+// separation tag dst. The result has prime order.
+func encodeToCurveEdwards25519(msg, dst []byte) (*edwards25519.Point, error) {
+	u, err := hashToFieldEdwards25519(msg, dst)
+	if err != nil {
+		return nil, err
+	}
+	P := mapToCurveElligator2Edwards25519(u)
+	return P.MultByCofactor(P), nil
+}
@@ -0,0 +1,41 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import "crypto/hpke"
+
+// HPKE, RFC 9180, encrypts to the public key of a KEM. With DHKEM(X25519,
+// HKDF-SHA256) that key can be the X25519 key converted from an Ed25519
+// identity, so anyone who knows the Ed25519 public key can encrypt to its
+// holder without a second key pair being published. The HPKE private key is
+// the converted scalar of PrivateKeyToX25519: a key derived from the seed with
+// a separate label would have a public key that senders can't compute.
+// Domain separation comes from HPKE itself, whose key schedule binds the
+// suite and the info string into every secret, so its keys are unrelated to
+// those derived from SharedSecret with the same key pair.
+
+// HPKEPublicKey returns the DHKEM(X25519, HKDF-SHA256) public key of the
+// Ed25519 public key publicKey, converted as by PublicKeyToX25519, for use
+// with hpke.NewSender and hpke.Seal. It returns the errors of
+// PublicKeyToX25519.
+func HPKEPublicKey(publicKey PublicKey) (hpke.PublicKey, error) {
+	pub, err := publicKey.ToECDH()
+	if err != nil {
+		return nil, err
+	}
+	return hpke.NewDHKEMPublicKey(pub)
+}
+
+// HPKEPrivateKey returns the DHKEM(X25519, HKDF-SHA256) private key of the
+// Ed25519 private key privateKey, converted as by PrivateKeyToX25519, for use
+// with hpke.NewRecipient and hpke.Open. Its public key is the result of
+// HPKEPublicKey for the public key of privateKey.
+func HPKEPrivateKey(privateKey PrivateKey) (hpke.PrivateKey, error) {
+	priv, err := privateKey.ToECDH()
+	if err != nil {
+		return nil, err
+	}
+	return hpke.NewDHKEMPrivateKey(priv)
+}
@@ -0,0 +1,132 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/hpke"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+)
+
+func TestHPKERoundTrip(t *testing.T) {
+	public, private, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, err := HPKEPublicKey(public[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	sk, err := HPKEPrivateKey(private[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pk.KEM().ID() != 0x0020 || sk.KEM().ID() != 0x0020 {
+		t.Fatalf("KEM IDs %#x and %#x, want 0x20", pk.KEM().ID(), sk.KEM().ID())
+	}
+	if !bytes.Equal(sk.PublicKey().Bytes(), pk.Bytes()) {
+		t.Fatalf("private key has public key %x, want %x", sk.PublicKey().Bytes(), pk.Bytes())
+	}
+	u, _ := PublicKeyToX25519(public[:])
+	if !bytes.Equal(pk.Bytes(), u[:]) {
+		t.Errorf("public key %x, want the X25519 key %x", pk.Bytes(), u)
+	}
+
+	kdf, aead := hpke.HKDFSHA256(), hpke.ChaCha20Poly1305()
+	info := []byte("ed25519 HPKE test")
+	message := []byte("attack at dawn")
+	ciphertext, err := hpke.Seal(pk, kdf, aead, info, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opened, err := hpke.Open(sk, kdf, aead, info, ciphertext)
+	if err != nil || !bytes.Equal(opened, message) {
+		t.Fatalf("Open gave %q, %v", opened, err)
+	}
+	if _, err := hpke.Open(sk, kdf, aead, []byte("other"), ciphertext); err == nil {
+		t.Error("ciphertext opened with another info string")
+	}
+
+	// Another identity can't open it.
+	_, other, _ := GenerateKey(rand.Reader)
+	otherKey, err := HPKEPrivateKey(other[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := hpke.Open(otherKey, kdf, aead, info, ciphertext); err == nil {
+		t.Error("ciphertext opened with another private key")
+	}
+}
+
+func TestHPKEKeyErrors(t *testing.T) {
+	for _, enc := range smallOrderEncodings {
+		if _, err := HPKEPublicKey(decodeHex(t, enc)); err == nil {
+			t.Errorf("small-order %s accepted", enc)
+		}
+	}
+	if _, err := HPKEPublicKey(make([]byte, 31)); err != ErrBadPublicKeyLength {
+		t.Errorf("short public key gave %v", err)
+	}
+	if _, err := HPKEPrivateKey(make([]byte, 32)); err != ErrBadPrivateKeyLength {
+		t.Errorf("short private key gave %v", err)
+	}
+}
+
+// TestHPKERFC9180 checks the KEM that the converted keys are loaded into
+// against RFC 9180, Appendix A.1, the base mode of DHKEM(X25519, HKDF-SHA256),
+// HKDF-SHA256 and AES-128-GCM.
+func TestHPKERFC9180(t *testing.T) {
+	kem := hpke.DHKEM(ecdh.X25519())
+	ikmE := decodeHex(t, "7268600d403fce431561aef583ee1613527cff655c1343f29812e66706df3234")
+	ikmR := decodeHex(t, "6db9df30aa07dd42ee5e8181afdb977e538f5e1fec8a06223f33f7013e525037")
+	skRm := decodeHex(t, "4612c550263fc8ad58375df3f557aac531d26850903e55a9f23f21d8534e8ac8")
+	pkRm := "3948cfe0ad1ddb695d780e59077195da6c56506b027329794ab02bca80815c4d"
+	enc := decodeHex(t, "37fda3567bdbd628e88668c3c8d7e97d1d1253b6d4ea6d44c150f741f1bf4431")
+	info := decodeHex(t, "4f6465206f6e2061204772656369616e2055726e")
+
+	skR, err := kem.DeriveKeyPair(ikmR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hex.EncodeToString(skR.PublicKey().Bytes()); got != pkRm {
+		t.Errorf("DeriveKeyPair(ikmR) gave the public key %s", got)
+	}
+	skE, err := kem.DeriveKeyPair(ikmE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := skE.PublicKey().Bytes(); !bytes.Equal(got, enc) {
+		t.Errorf("DeriveKeyPair(ikmE) gave the public key %x", got)
+	}
+
+	// skRm loaded as HPKEPrivateKey loads a converted scalar.
+	priv, err := ecdh.X25519().NewPrivateKey(skRm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sk, err := hpke.NewDHKEMPrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hex.EncodeToString(sk.PublicKey().Bytes()); got != pkRm {
+		t.Errorf("got pkRm %s, want %s", got, pkRm)
+	}
+	r, err := hpke.NewRecipient(enc, sk, hpke.HKDFSHA256(), hpke.AES128GCM(), info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := r.Open(
+		decodeHex(t, "436f756e742d30"),
+		decodeHex(t, "f938558b5d72f1a23810b4be2ab4f84331acc02fc97babc53a52ae8218a355a96d8770ac83d07bea87e13c512a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(plaintext) != "Beauty is truth, truth beauty" {
+		t.Errorf("got plaintext %q", plaintext)
+	}
+}
@@ -0,0 +1,33 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package blake2b
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestSum(t *testing.T) {
+	// RFC 7693, Appendix A, and hashes computed with Python's hashlib.
+	sum512 := Sum512([]byte("abc"))
+	if got := hex.EncodeToString(sum512[:]); got != "ba80a53f981c4d0d6a2797b69f12f6e94c212f14685ac4b74b12bb6fdbffa2d17d87c5392aab792dc252d5de4533cc9518d38aa8dbf1925ab92386edd4009923" {
+		t.Errorf("BLAKE2b-512(abc) = %s", got)
+	}
+	sum256 := Sum256(nil)
+	if got := hex.EncodeToString(sum256[:]); got != "0e5751c026e543b2e8ab2eb06099daa1d1e5df47778f7787faab45cdf12fe3a8" {
+		t.Errorf("BLAKE2b-256() = %s", got)
+	}
+
+	h, err := New512(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 1000; i++ {
+		h.Write([]byte("abc"))
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != "c8927a5469131bcab3b310f9b4443b3fbe16b920768d599981a5276b197c9ee14080682b8c42bf7eb1dcaed0a4802f1e7b26b5392736ff7acec2c8bb37dd37af" {
+		t.Errorf("BLAKE2b-512(abc * 1000) = %s", got)
+	}
+}
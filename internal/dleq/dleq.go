@@ -0,0 +1,49 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package dleq implements the arithmetic of Chaum-Pedersen proofs that
+// log_G(A) = log_H(B), shared by the DLEQ proofs of package zkp over
+// edwards25519 and the VOPRF proofs of package oprf over ristretto255. The
+// Fiat-Shamir challenge, and so the transcript and its domain separation, is
+// left to the caller.
+//
+// The prover of x picks a random r, commits to t2 = rG and t3 = rH, and
+// answers the challenge c = challenge(t2, t3) with s = r - cx. The verifier
+// recomputes t2 = sG + cA and t3 = sH + cB and checks that they give c.
+package dleq
+
+import "github.com/agl/ed25519/edwards25519"
+
+// Element is a group element type T, such as edwards25519.Point or
+// ristretto255.Element, through its pointer type.
+type Element[T any] interface {
+	*T
+	Add(p, q *T) *T
+	ScalarMult(x *edwards25519.Scalar, p *T) *T
+}
+
+// Prove returns the proof (c, s) that log_G(xG) = log_H(xH) = x, with the
+// secret nonce r and the challenge function of the caller.
+func Prove[T any, E Element[T]](x, r *edwards25519.Scalar, G, H E, challenge func(t2, t3 E) *edwards25519.Scalar) (c, s *edwards25519.Scalar) {
+	t2, t3 := E(new(T)), E(new(T))
+	t2.ScalarMult(r, G)
+	t3.ScalarMult(r, H)
+	c = challenge(t2, t3)
+	// s = r - c * x
+	s = edwards25519.NewScalar().Multiply(c, x)
+	s.Subtract(r, s)
+	return c, s
+}
+
+// Verify reports whether (c, s) proves that log_G(A) = log_H(B), with the
+// challenge function that the prover used.
+func Verify[T any, E Element[T]](G, H, A, B E, c, s *edwards25519.Scalar, challenge func(t2, t3 E) *edwards25519.Scalar) bool {
+	// t2 = sG + cA, t3 = sH + cB
+	t2, t3 := E(new(T)), E(new(T))
+	t2.ScalarMult(s, G)
+	t2.Add(t2, E(new(T)).ScalarMult(c, A))
+	t3.ScalarMult(s, H)
+	t3.Add(t3, E(new(T)).ScalarMult(c, B))
+	return challenge(t2, t3).Equal(c) == 1
+}
@@ -0,0 +1,88 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package merlin implements the Merlin transcripts of the merlin Rust crate,
+// version 1.0, on a minimal STROBE-128, for the Fiat-Shamir challenges of
+// schnorrkel. A transcript absorbs labelled messages in order and squeezes
+// challenges that depend on all of them.
+package merlin
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// Transcript is a Merlin transcript. Copy it with Clone, not by assignment.
+type Transcript struct {
+	s *strobe
+}
+
+// New returns a transcript for the protocol with the domain separation label
+// label.
+func New(label string) *Transcript {
+	t := &Transcript{s: newStrobe([]byte("Merlin v1.0"))}
+	t.AppendMessage([]byte("dom-sep"), []byte(label))
+	return t
+}
+
+// Clone returns an independent copy of t.
+func (t *Transcript) Clone() *Transcript {
+	s := *t.s
+	return &Transcript{s: &s}
+}
+
+func encodeLength(n int) []byte {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], uint32(n))
+	return b[:]
+}
+
+// AppendMessage appends message to t under label.
+func (t *Transcript) AppendMessage(label, message []byte) {
+	t.s.metaAD(label, false)
+	t.s.metaAD(encodeLength(len(message)), true)
+	t.s.ad(message, false)
+}
+
+// AppendUint64 appends x to t under label, as eight little-endian bytes.
+func (t *Transcript) AppendUint64(label []byte, x uint64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], x)
+	t.AppendMessage(label, b[:])
+}
+
+// ChallengeBytes fills dest with challenge bytes under label.
+func (t *Transcript) ChallengeBytes(label, dest []byte) {
+	t.s.metaAD(label, false)
+	t.s.metaAD(encodeLength(len(dest)), true)
+	t.s.prf(dest, false)
+}
+
+// WitnessBytes fills dest with secret bytes from the transcript RNG of the
+// merlin crate, keyed with the transcript so far, each of the witnesses under
+// label, and 32 bytes from rand. t itself is unchanged.
+func (t *Transcript) WitnessBytes(label, dest []byte, witnesses [][]byte, rand io.Reader) error {
+	s := *t.s
+	for _, w := range witnesses {
+		s.metaAD(label, false)
+		s.metaAD(encodeLength(len(w)), true)
+		s.key(w, false)
+	}
+	var random [32]byte
+	if _, err := io.ReadFull(rand, random[:]); err != nil {
+		return err
+	}
+	s.metaAD([]byte("rng"), false)
+	s.key(random[:], false)
+	for i := range random {
+		random[i] = 0
+	}
+
+	s.metaAD(encodeLength(len(dest)), false)
+	s.prf(dest, false)
+	for i := range s.state {
+		s.state[i] = 0
+	}
+	return nil
+}
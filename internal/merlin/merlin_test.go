@@ -0,0 +1,21 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package merlin
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// TestSimpleTranscript is the equivalence_simple test of the merlin crate.
+func TestSimpleTranscript(t *testing.T) {
+	tr := New("test protocol")
+	tr.AppendMessage([]byte("some label"), []byte("some data"))
+	var challenge [32]byte
+	tr.ChallengeBytes([]byte("challenge"), challenge[:])
+	if got := hex.EncodeToString(challenge[:]); got != "d5a21972d0d5fe320c0d263fac7fffb8145aa640af6e9bca177c03c7efcf0615" {
+		t.Errorf("got challenge %s", got)
+	}
+}
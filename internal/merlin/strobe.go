@@ -0,0 +1,127 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package merlin
+
+import "encoding/binary"
+
+// strobe is the subset of STROBE-128 that Merlin uses, as in the strobe.rs of
+// the merlin crate: the AD, meta-AD, PRF and KEY operations, without
+// transport or ratcheting.
+type strobe struct {
+	state    [200]byte
+	pos      byte
+	posBegin byte
+	curFlags byte
+}
+
+// strobeR is the rate of STROBE-128, in bytes, less the two bytes of padding.
+const strobeR = 166
+
+const (
+	flagI = 1 << iota
+	flagA
+	flagC
+	flagT
+	flagM
+	flagK
+)
+
+func newStrobe(protocolLabel []byte) *strobe {
+	s := new(strobe)
+	copy(s.state[:], []byte{1, strobeR + 2, 1, 0, 1, 96})
+	copy(s.state[6:], "STROBEv1.0.2")
+	s.keccak()
+	s.metaAD(protocolLabel, false)
+	return s
+}
+
+func (s *strobe) keccak() {
+	var a [25]uint64
+	for i := range a {
+		a[i] = binary.LittleEndian.Uint64(s.state[8*i:])
+	}
+	keccakF1600(&a)
+	for i := range a {
+		binary.LittleEndian.PutUint64(s.state[8*i:], a[i])
+	}
+}
+
+func (s *strobe) runF() {
+	s.state[s.pos] ^= s.posBegin
+	s.state[s.pos+1] ^= 0x04
+	s.state[strobeR+1] ^= 0x80
+	s.keccak()
+	s.pos = 0
+	s.posBegin = 0
+}
+
+func (s *strobe) absorb(data []byte) {
+	for _, b := range data {
+		s.state[s.pos] ^= b
+		s.pos++
+		if s.pos == strobeR {
+			s.runF()
+		}
+	}
+}
+
+func (s *strobe) overwrite(data []byte) {
+	for _, b := range data {
+		s.state[s.pos] = b
+		s.pos++
+		if s.pos == strobeR {
+			s.runF()
+		}
+	}
+}
+
+func (s *strobe) squeeze(data []byte) {
+	for i := range data {
+		data[i] = s.state[s.pos]
+		s.state[s.pos] = 0
+		s.pos++
+		if s.pos == strobeR {
+			s.runF()
+		}
+	}
+}
+
+// beginOp starts an operation with flags, or continues the current one if
+// more is set, in which case flags must be those of the current operation.
+func (s *strobe) beginOp(flags byte, more bool) {
+	if more {
+		if s.curFlags != flags {
+			panic("merlin: continued STROBE operation with different flags")
+		}
+		return
+	}
+	oldBegin := s.posBegin
+	s.posBegin = s.pos + 1
+	s.curFlags = flags
+	s.absorb([]byte{oldBegin, flags})
+	if flags&(flagC|flagK) != 0 && s.pos != 0 {
+		s.runF()
+	}
+}
+
+func (s *strobe) metaAD(data []byte, more bool) {
+	s.beginOp(flagM|flagA, more)
+	s.absorb(data)
+}
+
+func (s *strobe) ad(data []byte, more bool) {
+	s.beginOp(flagA, more)
+	s.absorb(data)
+}
+
+func (s *strobe) prf(data []byte, more bool) {
+	s.beginOp(flagI|flagA|flagC, more)
+	s.squeeze(data)
+}
+
+func (s *strobe) key(data []byte, more bool) {
+	s.beginOp(flagA|flagC, more)
+	s.overwrite(data)
+}
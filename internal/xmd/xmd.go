@@ -0,0 +1,50 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package xmd implements expand_message_xmd of RFC 9380, Section 5.3.1, which
+// expands a message and a domain separation tag to uniform bytes for hashing
+// to curves, groups and scalars.
+package xmd
+
+import (
+	"errors"
+	"hash"
+)
+
+var errTooLong = errors.New("xmd: expand_message_xmd output or tag too long")
+
+// Expand returns n bytes of expand_message_xmd of msg with the domain
+// separation tag dst and the hash function h. It returns an error if n or
+// dst is too long for the construction: tags over 255 bytes, which RFC 9380
+// hashes down first, are not supported.
+func Expand(h func() hash.Hash, msg, dst []byte, n int) ([]byte, error) {
+	H := h()
+	size := H.Size()
+	ell := (n + size - 1) / size
+	if ell > 255 || n > 65535 || len(dst) > 255 {
+		return nil, errTooLong
+	}
+	dstPrime := append(append([]byte(nil), dst...), byte(len(dst)))
+
+	H.Write(make([]byte, H.BlockSize()))
+	H.Write(msg)
+	H.Write([]byte{byte(n >> 8), byte(n), 0})
+	H.Write(dstPrime)
+	b0 := H.Sum(nil)
+
+	out := make([]byte, 0, ell*size)
+	bi := make([]byte, size)
+	for i := 1; i <= ell; i++ {
+		for j := range bi {
+			bi[j] ^= b0[j]
+		}
+		H.Reset()
+		H.Write(bi)
+		H.Write([]byte{byte(i)})
+		H.Write(dstPrime)
+		bi = H.Sum(bi[:0])
+		out = append(out, bi...)
+	}
+	return out[:n], nil
+}
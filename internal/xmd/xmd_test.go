@@ -0,0 +1,43 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xmd
+
+import (
+	"crypto/sha512"
+	"encoding/hex"
+	"testing"
+)
+
+// TestExpandRFC9380 checks outputs of expand_message_xmd with SHA-512 from
+// RFC 9380, Appendix K.3.
+func TestExpandRFC9380(t *testing.T) {
+	dst := []byte("QUUX-V01-CS02-with-expander-SHA512-256")
+	for _, test := range []struct {
+		msg, out string
+	}{
+		{"", "6b9a7312411d92f921c6f68ca0b6380730a1a4d982c507211a90964c394179ba"},
+		{"abc", "0da749f12fbe5483eb066a5f595055679b976e93abe9be6f0f6318bce7aca8dc"},
+	} {
+		out, err := Expand(sha512.New, []byte(test.msg), dst, 32)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(out); got != test.out {
+			t.Errorf("%q: got %s, want %s", test.msg, got, test.out)
+		}
+	}
+}
+
+func TestExpandLimits(t *testing.T) {
+	if _, err := Expand(sha512.New, nil, make([]byte, 256), 32); err == nil {
+		t.Error("256-byte tag accepted")
+	}
+	if _, err := Expand(sha512.New, nil, []byte("dst"), 255*sha512.Size+1); err == nil {
+		t.Error("output over 255 blocks accepted")
+	}
+	if _, err := Expand(sha512.New, nil, []byte("dst"), 255*sha512.Size); err != nil {
+		t.Errorf("output of 255 blocks rejected: %v", err)
+	}
+}
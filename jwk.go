@@ -0,0 +1,141 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+// JWKOptions holds the optional members of a JSON Web Key.
+type JWKOptions struct {
+	KeyID string // "kid"
+	Use   string // "use", usually "sig"
+
+	// Algorithm is the "alg" member. If set, it must be "EdDSA", the JWS
+	// algorithm of RFC 8037.
+	Algorithm string
+}
+
+// jwk is an Ed25519 key in the format of RFC 8037, Section 2. The order of
+// the fields is the order in which MarshalJWK writes them.
+type jwk struct {
+	KeyType string  `json:"kty"`
+	Curve   string  `json:"crv"`
+	X       string  `json:"x"`
+	D       *string `json:"d,omitempty"`
+	KeyID   string  `json:"kid,omitempty"`
+	Use     string  `json:"use,omitempty"`
+	Alg     string  `json:"alg,omitempty"`
+}
+
+// MarshalJWK returns key, a PublicKey or a PrivateKey, as a JSON Web Key with
+// "kty" "OKP" and "crv" "Ed25519", as defined by RFC 8037. The public key is
+// in the "x" member and, for a private key, the seed is in "d". opts may be
+// nil.
+//
+// The output has no white space and its members are always in the same
+// order, so equal keys and options give equal bytes.
+func MarshalJWK(key interface{}, opts *JWKOptions) ([]byte, error) {
+	var k jwk
+	switch key := key.(type) {
+	case PublicKey:
+		if len(key) != PublicKeySize {
+			return nil, ErrBadPublicKeyLength
+		}
+		k.X = base64.RawURLEncoding.EncodeToString(key)
+	case PrivateKey:
+		if len(key) != PrivateKeySize {
+			return nil, ErrBadPrivateKeyLength
+		}
+		k.X = base64.RawURLEncoding.EncodeToString(key[32:])
+		d := base64.RawURLEncoding.EncodeToString(key[:32])
+		k.D = &d
+	default:
+		return nil, errors.New("ed25519: JWK key must be a PublicKey or a PrivateKey")
+	}
+	k.KeyType, k.Curve = "OKP", "Ed25519"
+
+	if opts != nil {
+		if opts.Algorithm != "" && opts.Algorithm != "EdDSA" {
+			return nil, errors.New("ed25519: JWK algorithm must be EdDSA")
+		}
+		k.KeyID, k.Use, k.Alg = opts.KeyID, opts.Use, opts.Algorithm
+	}
+	return json.Marshal(&k)
+}
+
+// ParseJWK parses an Ed25519 JSON Web Key as defined by RFC 8037 and returns
+// the key, a PrivateKey if the JWK has a "d" member and otherwise a
+// PublicKey, together with its optional members. Other members are ignored.
+//
+// "kty" must be "OKP", "crv" must be "Ed25519" and "alg", if present, must be
+// "EdDSA". "x" and "d" must be unpadded base64url, as RFC 7515 requires, with
+// no white space or unused bits set, and decode to 32 bytes. For a private
+// key, "x" must be the public key of "d" or the error is ErrKeyMismatch.
+func ParseJWK(data []byte) (key interface{}, opts *JWKOptions, err error) {
+	var k jwk
+	if err := json.Unmarshal(data, &k); err != nil {
+		return nil, nil, errors.New("ed25519: malformed JWK: " + err.Error())
+	}
+	if k.KeyType != "OKP" {
+		return nil, nil, errors.New("ed25519: JWK key type is not OKP")
+	}
+	if k.Curve != "Ed25519" {
+		return nil, nil, errors.New("ed25519: JWK curve is not Ed25519")
+	}
+	if k.Alg != "" && k.Alg != "EdDSA" {
+		return nil, nil, errors.New("ed25519: JWK algorithm is not EdDSA")
+	}
+	opts = &JWKOptions{KeyID: k.KeyID, Use: k.Use, Algorithm: k.Alg}
+
+	x, err := decodeJWKMember(k.X, "x")
+	if err != nil {
+		return nil, nil, err
+	}
+	publicKey := PublicKey(x)
+	if k.D == nil {
+		return publicKey, opts, nil
+	}
+
+	seed, err := decodeJWKMember(*k.D, "d")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer wipeBytes(seed)
+	privateKey := NewKeyFromSeed(seed)
+	if !publicKey.Equal(privateKey.Public()) {
+		wipeBytes(privateKey)
+		return nil, nil, ErrKeyMismatch
+	}
+	return privateKey, opts, nil
+}
+
+// JWKThumbprint returns the RFC 7638 thumbprint of publicKey: the unpadded
+// base64url encoding of the SHA-256 hash of its required JWK members in
+// lexical order, {"crv":"Ed25519","kty":"OKP","x":"..."}. It returns the
+// empty string if len(publicKey) is not PublicKeySize.
+func JWKThumbprint(publicKey PublicKey) string {
+	if len(publicKey) != PublicKeySize {
+		return ""
+	}
+	h := sha256.New()
+	h.Write([]byte(`{"crv":"Ed25519","kty":"OKP","x":"`))
+	h.Write([]byte(base64.RawURLEncoding.EncodeToString(publicKey)))
+	h.Write([]byte(`"}`))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
+// decodeJWKMember decodes the base64url member name of a JWK, which must hold
+// 32 bytes.
+func decodeJWKMember(s, name string) ([]byte, error) {
+	b, ok := decodeBase64URL(s)
+	if !ok || len(b) != 32 {
+		return nil, errors.New("ed25519: JWK member " + name + " is not the base64url encoding of 32 bytes")
+	}
+	return b, nil
+}
@@ -0,0 +1,141 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// The key of RFC 8037, Appendix A.1 and A.2.
+const (
+	rfc8037PrivateJWK = `{"kty":"OKP","crv":"Ed25519",
+   "d":"nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A",
+   "x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}`
+	rfc8037PublicJWK = `{"kty":"OKP","crv":"Ed25519",
+   "x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}`
+	rfc8037Seed      = "9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60"
+	rfc8037PublicKey = "d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a"
+)
+
+func TestJWKRFC8037(t *testing.T) {
+	key, opts, err := ParseJWK([]byte(rfc8037PrivateJWK))
+	if err != nil {
+		t.Fatal(err)
+	}
+	private, ok := key.(PrivateKey)
+	if !ok {
+		t.Fatalf("got a %T", key)
+	}
+	if !bytes.Equal(private.Seed(), decodeHex(t, rfc8037Seed)) || !bytes.Equal(private[32:], decodeHex(t, rfc8037PublicKey)) {
+		t.Errorf("got key %x", private)
+	}
+	if *opts != (JWKOptions{}) {
+		t.Errorf("got options %+v", opts)
+	}
+	out, err := MarshalJWK(private, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `{"kty":"OKP","crv":"Ed25519","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo","d":"nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A"}`
+	if string(out) != want {
+		t.Errorf("re-encoded as %s, want %s", out, want)
+	}
+
+	key, _, err = ParseJWK([]byte(rfc8037PublicJWK))
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, ok := key.(PublicKey)
+	if !ok || !bytes.Equal(public, decodeHex(t, rfc8037PublicKey)) {
+		t.Errorf("got %T %x", key, key)
+	}
+	out, err = MarshalJWK(public, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `{"kty":"OKP","crv":"Ed25519","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}` {
+		t.Errorf("re-encoded as %s", out)
+	}
+
+	// RFC 8037, Appendix A.3.
+	if got := JWKThumbprint(public); got != "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k" {
+		t.Errorf("thumbprint %s", got)
+	}
+	if JWKThumbprint(public[:31]) != "" {
+		t.Error("thumbprint of a short key")
+	}
+}
+
+func TestJWKOptions(t *testing.T) {
+	public, private, _ := GenerateKey(rand.Reader)
+	opts := &JWKOptions{KeyID: "key-1", Use: "sig", Algorithm: "EdDSA"}
+	for _, key := range []interface{}{public, private} {
+		out, err := MarshalJWK(key, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed, gotOpts, err := ParseJWK(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(parsed, key) || *gotOpts != *opts {
+			t.Errorf("%T: round trip gave %x %+v", key, parsed, gotOpts)
+		}
+		if !strings.HasSuffix(string(out), `"kid":"key-1","use":"sig","alg":"EdDSA"}`) {
+			t.Errorf("%T: %s", key, out)
+		}
+	}
+
+	if _, err := MarshalJWK(public, &JWKOptions{Algorithm: "ES256"}); err == nil {
+		t.Error("ES256 accepted")
+	}
+	if _, err := MarshalJWK(public[:31], nil); err != ErrBadPublicKeyLength {
+		t.Errorf("short public key gave %v", err)
+	}
+	if _, err := MarshalJWK(private[:63], nil); err != ErrBadPrivateKeyLength {
+		t.Errorf("short private key gave %v", err)
+	}
+	if _, err := MarshalJWK([]byte(public), nil); err == nil {
+		t.Error("[]byte accepted")
+	}
+}
+
+func TestJWKErrors(t *testing.T) {
+	const (
+		x = `"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"`
+		d = `"nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A"`
+	)
+	for _, tc := range []struct {
+		name, jwk string
+		want      error
+	}{
+		{"not JSON", `{`, nil},
+		{"array", `[]`, nil},
+		{"EC", `{"kty":"EC","crv":"Ed25519","x":` + x + `}`, nil},
+		{"no kty", `{"crv":"Ed25519","x":` + x + `}`, nil},
+		{"X25519", `{"kty":"OKP","crv":"X25519","x":` + x + `}`, nil},
+		{"Ed448", `{"kty":"OKP","crv":"Ed448","x":` + x + `}`, nil},
+		{"alg", `{"kty":"OKP","crv":"Ed25519","alg":"ES256","x":` + x + `}`, nil},
+		{"no x", `{"kty":"OKP","crv":"Ed25519"}`, nil},
+		{"x number", `{"kty":"OKP","crv":"Ed25519","x":1}`, nil},
+		{"padded x", `{"kty":"OKP","crv":"Ed25519","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo="}`, nil},
+		{"standard base64", `{"kty":"OKP","crv":"Ed25519","x":"11qYAYKxCrfVS/7TyWQHOg7hcvPapiMlrwIaaPcHURo"}`, nil},
+		{"line break", `{"kty":"OKP","crv":"Ed25519","x":"11qYAYKxCrfVS_7TyWQHOg7h\ncvPapiMlrwIaaPcHURo"}`, nil},
+		{"unused bits", `{"kty":"OKP","crv":"Ed25519","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURp"}`, nil},
+		{"short x", `{"kty":"OKP","crv":"Ed25519","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcH"}`, nil},
+		{"short d", `{"kty":"OKP","crv":"Ed25519","x":` + x + `,"d":"nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDus"}`, nil},
+		{"empty d", `{"kty":"OKP","crv":"Ed25519","x":` + x + `,"d":""}`, nil},
+		{"mismatch", `{"kty":"OKP","crv":"Ed25519","x":"AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA","d":` + d + `}`, ErrKeyMismatch},
+	} {
+		key, _, err := ParseJWK([]byte(tc.jwk))
+		if err == nil || (tc.want != nil && err != tc.want) {
+			t.Errorf("%s: got %v, %v", tc.name, key, err)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+)
+
+var (
+	// ErrJWSAlgorithm is returned by VerifyJWS when the "alg" header
+	// parameter is not "EdDSA". Other algorithms are refused rather than
+	// being dispatched on, so a token can't pick how it is checked.
+	ErrJWSAlgorithm = errors.New("ed25519: JWS algorithm is not EdDSA")
+
+	// ErrJWSCritical is returned by VerifyJWS when the header has a "crit"
+	// parameter. No extensions are understood, so any critical one must
+	// be rejected as required by RFC 7515, Section 4.1.11.
+	ErrJWSCritical = errors.New("ed25519: JWS has critical header parameters")
+
+	errBadJWS = errors.New("ed25519: malformed JWS")
+)
+
+// SignJWS returns a JSON Web Signature of payload by signer in the compact
+// serialization of RFC 7515, using the EdDSA algorithm of RFC 8037. header
+// holds the protected header parameters, such as "kid" or "typ", and may be
+// nil. Its "alg" is set to "EdDSA"; it is an error for header to name another
+// algorithm or to have a "crit" parameter, since VerifyJWS would reject it.
+//
+// The header is encoded with encoding/json, which sorts its members.
+func SignJWS(signer crypto.Signer, header map[string]interface{}, payload []byte) (string, error) {
+	protected := map[string]interface{}{"alg": "EdDSA"}
+	for name, value := range header {
+		switch name {
+		case "alg":
+			if value != "EdDSA" {
+				return "", ErrJWSAlgorithm
+			}
+		case "crit":
+			return "", ErrJWSCritical
+		default:
+			protected[name] = value
+		}
+	}
+	encodedHeader, err := json.Marshal(protected)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(encodedHeader) + "." +
+		base64.RawURLEncoding.EncodeToString(payload)
+	sig, err := signWith(signer, []byte(signingInput))
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// VerifyJWS checks that token is a JSON Web Signature in the compact
+// serialization by publicKey and returns its payload.
+//
+// The protected header must be a JSON object whose "alg" is "EdDSA", and
+// must have no "crit" parameter and no repeated names; there is no
+// unprotected header in the compact serialization. Each part must be
+// unpadded base64url, with no white space or unused bits set. The errors are
+// ErrJWSAlgorithm, ErrJWSCritical, ErrInvalidSignature, or others if token is
+// malformed.
+func VerifyJWS(publicKey PublicKey, token string) ([]byte, error) {
+	_, payload, err := verifyJWS(publicKey, token)
+	return payload, err
+}
+
+// verifyJWS is VerifyJWS that also returns the protected header.
+func verifyJWS(publicKey PublicKey, token string) (map[string]json.RawMessage, []byte, error) {
+	if len(publicKey) != PublicKeySize {
+		return nil, nil, ErrBadPublicKeyLength
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, nil, errBadJWS
+	}
+	encodedHeader, ok1 := decodeBase64URL(parts[0])
+	payload, ok2 := decodeBase64URL(parts[1])
+	sig, ok3 := decodeBase64URL(parts[2])
+	if !ok1 || !ok2 || !ok3 {
+		return nil, nil, errBadJWS
+	}
+
+	header, err := parseJOSEHeader(encodedHeader)
+	if err != nil {
+		return nil, nil, err
+	}
+	var alg string
+	if err := json.Unmarshal(header["alg"], &alg); err != nil || alg != "EdDSA" {
+		return nil, nil, ErrJWSAlgorithm
+	}
+	if _, ok := header["crit"]; ok {
+		return nil, nil, ErrJWSCritical
+	}
+
+	signingInput := token[:len(parts[0])+1+len(parts[1])]
+	if err := CheckSignature(publicKey, []byte(signingInput), sig); err != nil {
+		return nil, nil, ErrInvalidSignature
+	}
+	return header, payload, nil
+}
+
+// parseJOSEHeader decodes a JSON object, keeping the values undecoded. Unlike
+// encoding/json it matches names exactly and rejects repeated names, so that
+// two parsers can't disagree about which "alg" a header has.
+func parseJOSEHeader(b []byte) (map[string]json.RawMessage, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	if t, err := dec.Token(); err != nil || t != json.Delim('{') {
+		return nil, errBadJWS
+	}
+	header := make(map[string]json.RawMessage)
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return nil, errBadJWS
+		}
+		name := t.(string)
+		if _, ok := header[name]; ok {
+			return nil, errors.New("ed25519: JWS header repeats " + name)
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, errBadJWS
+		}
+		header[name] = value
+	}
+	if t, err := dec.Token(); err != nil || t != json.Delim('}') {
+		return nil, errBadJWS
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errBadJWS
+	}
+	return header, nil
+}
+
+// decodeBase64URL decodes unpadded base64url. Unlike the decoders of
+// encoding/base64 it accepts only the canonical encoding, so it rejects
+// padding, line breaks and set unused bits.
+func decodeBase64URL(s string) ([]byte, bool) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || base64.RawURLEncoding.EncodeToString(b) != s {
+		return nil, false
+	}
+	return b, true
+}
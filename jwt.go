@@ -0,0 +1,214 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"crypto"
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrJWTExpired is returned by VerifyJWT when the "exp" claim of the
+	// token is not after the current time.
+	ErrJWTExpired = errors.New("ed25519: JWT has expired")
+
+	// ErrJWTNotValidYet is returned by VerifyJWT when the "nbf" or "iat"
+	// claim of the token is after the current time.
+	ErrJWTNotValidYet = errors.New("ed25519: JWT is not valid yet")
+
+	// ErrJWTIssuer is returned by VerifyJWT when the "iss" claim of the
+	// token is not the expected issuer.
+	ErrJWTIssuer = errors.New("ed25519: JWT is from another issuer")
+
+	// ErrJWTAudience is returned by VerifyJWT when the token has an "aud"
+	// claim that doesn't name the expected audience.
+	ErrJWTAudience = errors.New("ed25519: JWT is for another audience")
+
+	// ErrJWTType is returned by VerifyJWT when the "typ" header parameter
+	// of the token is present and not "JWT", so that other kinds of JWS
+	// signed with the same key aren't taken for a JWT.
+	ErrJWTType = errors.New("ed25519: JWS is not a JWT")
+)
+
+var errBadJWT = errors.New("ed25519: malformed JWT claims")
+
+// JWTOptions configures SignJWT. The zero value selects the defaults.
+type JWTOptions struct {
+	// KeyID, if not empty, is set as the "kid" header parameter.
+	KeyID string
+}
+
+// JWTValidation configures the checks of VerifyJWT. The zero value checks
+// only the times in the token against the system clock.
+type JWTValidation struct {
+	// Issuer, if not empty, must be the "iss" claim.
+	Issuer string
+
+	// Audience, if not empty, must be the "aud" claim or one of its
+	// elements. If it is empty, tokens with an "aud" claim are rejected, as
+	// required by RFC 7519, Section 4.1.3.
+	Audience string
+
+	// Now returns the current time. If nil, time.Now is used.
+	Now func() time.Time
+
+	// Leeway is the clock skew allowed when checking "exp", "nbf" and
+	// "iat".
+	Leeway time.Duration
+}
+
+// SignJWT returns a JSON Web Token with the given claims signed by signer
+// with the EdDSA algorithm, as a JWS in the compact serialization with the
+// "typ" header parameter "JWT".
+//
+// The claims are encoded with encoding/json, except that time.Time values of
+// "exp", "nbf" and "iat" are encoded as NumericDate, in seconds since the
+// Unix epoch.
+func SignJWT(signer crypto.Signer, claims map[string]interface{}, opts *JWTOptions) (string, error) {
+	var o JWTOptions
+	if opts != nil {
+		o = *opts
+	}
+	header := map[string]interface{}{"typ": "JWT"}
+	if o.KeyID != "" {
+		header["kid"] = o.KeyID
+	}
+
+	encoded := make(map[string]interface{}, len(claims))
+	for name, value := range claims {
+		if t, ok := value.(time.Time); ok && isJWTTimeClaim(name) {
+			value = t.Unix()
+		}
+		encoded[name] = value
+	}
+	payload, err := json.Marshal(encoded)
+	if err != nil {
+		return "", err
+	}
+	return SignJWS(signer, header, payload)
+}
+
+// VerifyJWT checks that token is a JSON Web Token signed by publicKey with the
+// EdDSA algorithm, as for VerifyJWS, and returns its claims decoded with
+// encoding/json. The algorithm is fixed: a token naming any other, including
+// "none", is rejected with ErrJWSAlgorithm before anything else is looked at.
+//
+// The "exp", "nbf" and "iat" claims are checked if present, and must be
+// numbers. The claims must be a JSON object with no repeated names. A nil v
+// is the same as the zero JWTValidation. The errors are ErrJWTExpired,
+// ErrJWTNotValidYet, ErrJWTIssuer, ErrJWTAudience, ErrJWTType, those of
+// VerifyJWS, or others if the claims are malformed.
+func VerifyJWT(publicKey PublicKey, token string, v *JWTValidation) (map[string]interface{}, error) {
+	var o JWTValidation
+	if v != nil {
+		o = *v
+	}
+	header, payload, err := verifyJWS(publicKey, token)
+	if err != nil {
+		return nil, err
+	}
+	if typ, ok := header["typ"]; ok {
+		var s string
+		if err := json.Unmarshal(typ, &s); err != nil || !strings.EqualFold(s, "JWT") {
+			return nil, ErrJWTType
+		}
+	}
+
+	raw, err := parseJOSEHeader(payload)
+	if err != nil {
+		return nil, errBadJWT
+	}
+
+	now := time.Now
+	if o.Now != nil {
+		now = o.Now
+	}
+	t := now()
+	if exp, ok := raw["exp"]; ok {
+		at, err := parseNumericDate(exp)
+		if err != nil {
+			return nil, err
+		}
+		if !t.Before(at.Add(o.Leeway)) {
+			return nil, ErrJWTExpired
+		}
+	}
+	for _, name := range []string{"nbf", "iat"} {
+		if b, ok := raw[name]; ok {
+			at, err := parseNumericDate(b)
+			if err != nil {
+				return nil, err
+			}
+			if t.Add(o.Leeway).Before(at) {
+				return nil, ErrJWTNotValidYet
+			}
+		}
+	}
+
+	if o.Issuer != "" {
+		var iss string
+		if err := json.Unmarshal(raw["iss"], &iss); err != nil || iss != o.Issuer {
+			return nil, ErrJWTIssuer
+		}
+	}
+	if aud, ok := raw["aud"]; ok {
+		if !jwtAudienceHas(aud, o.Audience) {
+			return nil, ErrJWTAudience
+		}
+	} else if o.Audience != "" {
+		return nil, ErrJWTAudience
+	}
+
+	claims := make(map[string]interface{}, len(raw))
+	for name, b := range raw {
+		var value interface{}
+		if err := json.Unmarshal(b, &value); err != nil {
+			return nil, errBadJWT
+		}
+		claims[name] = value
+	}
+	return claims, nil
+}
+
+func isJWTTimeClaim(name string) bool {
+	return name == "exp" || name == "nbf" || name == "iat"
+}
+
+// parseNumericDate decodes a NumericDate of RFC 7519, a JSON number of
+// seconds since the Unix epoch that may have a fraction.
+func parseNumericDate(b json.RawMessage) (time.Time, error) {
+	var f float64
+	if string(b) == "null" || json.Unmarshal(b, &f) != nil || math.Abs(f) > 1<<53 {
+		return time.Time{}, errBadJWT
+	}
+	sec, frac := math.Modf(f)
+	return time.Unix(int64(sec), int64(frac*1e9)), nil
+}
+
+// jwtAudienceHas reports whether the "aud" claim aud, a string or an array of
+// strings, has the non-empty audience.
+func jwtAudienceHas(aud json.RawMessage, audience string) bool {
+	if audience == "" {
+		return false
+	}
+	var one string
+	if json.Unmarshal(aud, &one) == nil {
+		return one == audience
+	}
+	var many []string
+	if json.Unmarshal(aud, &many) != nil {
+		return false
+	}
+	for _, a := range many {
+		if a == audience {
+			return true
+		}
+	}
+	return false
+}
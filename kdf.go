@@ -0,0 +1,81 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/hkdf"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+)
+
+// DeriveKey turns a Diffie-Hellman output into keys with HKDF-SHA-512. The
+// salt is the fixed string kdfSalt, which names the scheme and its version,
+// and the info is
+//
+//	uint16(len(label)) || label || context
+//
+// with the length big-endian, so that no label is a prefix of another and
+// the context can't be mistaken for part of the label. A later scheme would
+// change kdfSalt, so keys of different versions are unrelated.
+
+// kdfSalt names the labeling scheme of DeriveKey.
+const kdfSalt = "github.com/agl/ed25519 DeriveKey v1"
+
+// maxDerivedKeyLength is the most that HKDF-SHA-512 can expand to.
+const maxDerivedKeyLength = 255 * sha512.Size
+
+var (
+	errKDFSecret = errors.New("ed25519: empty or all-zero shared secret")
+	errKDFLabel  = errors.New("ed25519: DeriveKey label is empty or longer than 65535 bytes")
+	errKDFLength = errors.New("ed25519: DeriveKey length out of range")
+)
+
+// DeriveKey derives length bytes of key material from sharedSecret, such as
+// the result of SharedSecret, for the purpose named by label, with context
+// binding the key to a session, for example the public keys of both parties
+// and a transcript hash. Different labels or contexts give independent keys.
+//
+// label must not be empty nor longer than 65535 bytes, and length must be
+// between 1 and 255*64. An empty or all-zero sharedSecret, which is what
+// X25519 gives for a peer of small order, is rejected.
+func DeriveKey(sharedSecret []byte, label string, context []byte, length int) ([]byte, error) {
+	if len(sharedSecret) == 0 || subtle.ConstantTimeCompare(sharedSecret, make([]byte, len(sharedSecret))) == 1 {
+		return nil, errKDFSecret
+	}
+	if len(label) == 0 || len(label) > 65535 {
+		return nil, errKDFLabel
+	}
+	if length < 1 || length > maxDerivedKeyLength {
+		return nil, errKDFLength
+	}
+	info := binary.BigEndian.AppendUint16(nil, uint16(len(label)))
+	info = append(info, label...)
+	info = append(info, context...)
+	return hkdf.Key(sha512.New, sharedSecret, []byte(kdfSalt), string(info), length)
+}
+
+// DeriveSharedKey derives length bytes of key material for label from the
+// SharedSecret of privateKey and peerPublicKey, with the errors of
+// SharedSecret and DeriveKey. The context of DeriveKey is the two Ed25519
+// public keys, the smaller first so that both parties agree on it, followed
+// by context; the raw shared secret never leaves this function.
+func DeriveSharedKey(privateKey PrivateKey, peerPublicKey PublicKey, label string, context []byte, length int) ([]byte, error) {
+	shared, err := SharedSecret(privateKey, peerPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	defer wipeBytes(shared[:])
+	own, peer := []byte(privateKey[32:]), []byte(peerPublicKey)
+	if bytes.Compare(own, peer) > 0 {
+		own, peer = peer, own
+	}
+	keys := make([]byte, 0, 2*PublicKeySize+len(context))
+	keys = append(keys, own...)
+	keys = append(keys, peer...)
+	return DeriveKey(shared[:], label, append(keys, context...), length)
+}
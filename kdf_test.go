@@ -0,0 +1,119 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// kdfTests pin the outputs of DeriveKey for the X25519 shared secret of
+// RFC 7748, Section 6.1. They were also computed by a separate HKDF-SHA-512
+// in Python, from the framing documented in kdf.go.
+var kdfTests = []struct {
+	label, context string
+	length         int
+	key            string
+}{
+	{"encryption", "", 32, "ff1c019e5dba30a82f04cdae07897cc4ba5c5e0a9e2a09f4b0c223034f8ea12c"},
+	{"encryption", "session 1", 32, "428192b227f3525fc5f487fa751aca2f676e9af7c8cf7bd22538dae3a95fbb2d"},
+	{"authentication", "session 1", 64, "e768bbf8c5686861e1f19fc2bd549669fe05a23deef2ac516da26e7165be992c0836d5919d82bdbc101e455672efa76e1de3e2601e5063ea0f08b2e62080522a"},
+	{"x", "", 100, "341bd39fe8ac3aa7c339b5c41262cd73a029d241466ac439b504cab2cf89ea55470df58c55fa095decc88ca37e245ce1122394ec4a9bb400866b73bf1924f61dd0327a5ef321a2692f66f72ee5d4acf1a076d7771dda5e7c1890d0151d3c600d58bbc5eb"},
+}
+
+const kdfTestSecret = "4a5d9d5ba4ce2de1728e3bf480350f25e07e21c947d19e3376f09b3c1e161742"
+
+func TestDeriveKeyVectors(t *testing.T) {
+	secret, _ := hex.DecodeString(kdfTestSecret)
+	for _, test := range kdfTests {
+		key, err := DeriveKey(secret, test.label, []byte(test.context), test.length)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(key); got != test.key {
+			t.Errorf("%q, %q: got %s, want %s", test.label, test.context, got, test.key)
+		}
+	}
+}
+
+func TestDeriveKeyFraming(t *testing.T) {
+	secret, _ := hex.DecodeString(kdfTestSecret)
+	// Moving bytes between the label and the context changes the key.
+	a, _ := DeriveKey(secret, "ab", []byte("c"), 32)
+	b, _ := DeriveKey(secret, "a", []byte("bc"), 32)
+	if bytes.Equal(a, b) {
+		t.Error("the label and context are not separated")
+	}
+	// A shorter output is a prefix of a longer one, as in HKDF.
+	long, _ := DeriveKey(secret, "ab", []byte("c"), 64)
+	if !bytes.Equal(long[:32], a) {
+		t.Error("the 32-byte key is not a prefix of the 64-byte one")
+	}
+}
+
+func TestDeriveKeyErrors(t *testing.T) {
+	secret, _ := hex.DecodeString(kdfTestSecret)
+	for _, test := range []struct {
+		name   string
+		secret []byte
+		label  string
+		length int
+	}{
+		{"empty secret", nil, "label", 32},
+		{"all-zero secret", make([]byte, 32), "label", 32},
+		{"empty label", secret, "", 32},
+		{"long label", secret, string(make([]byte, 65536)), 32},
+		{"zero length", secret, "label", 0},
+		{"long output", secret, "label", 255*64 + 1},
+	} {
+		if _, err := DeriveKey(test.secret, test.label, nil, test.length); err == nil {
+			t.Errorf("%s accepted", test.name)
+		}
+	}
+	if _, err := DeriveKey(secret, string(make([]byte, 65535)), nil, 255*64); err != nil {
+		t.Errorf("longest label and output rejected: %v", err)
+	}
+}
+
+func TestDeriveSharedKey(t *testing.T) {
+	seedA, _ := hex.DecodeString("9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60")
+	seedB, _ := hex.DecodeString("4ccd089b28ff96da9db6c346ec114e0f5b8a319f35aba624da8cf6ed4fb8a6fb")
+	a, b := NewKeyFromSeed(seedA), NewKeyFromSeed(seedB)
+	publicA, publicB := a.Public().(PublicKey), b.Public().(PublicKey)
+
+	keyA, err := DeriveSharedKey(a, publicB, "handshake", []byte("ctx"), 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyB, err := DeriveSharedKey(b, publicA, "handshake", []byte("ctx"), 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(keyA, keyB) {
+		t.Fatal("the two parties derived different keys")
+	}
+	if got, want := hex.EncodeToString(keyA), "aed598270872f8ca17832dad27d3d53a347667d33d4d1fd6bb3f1376e7e7d8b7"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	// The same as DeriveKey of SharedSecret with the sorted public keys.
+	shared, _ := SharedSecret(a, publicB)
+	context := append(append(append([]byte(nil), publicB...), publicA...), "ctx"...)
+	if direct, _ := DeriveKey(shared[:], "handshake", context, 32); !bytes.Equal(direct, keyA) {
+		t.Error("DeriveSharedKey disagrees with DeriveKey")
+	}
+
+	_, c, _ := GenerateKey(nil)
+	if keyC, _ := DeriveSharedKey(c, publicB, "handshake", []byte("ctx"), 32); bytes.Equal(keyC, keyA) {
+		t.Error("a third party derived the same key")
+	}
+	if _, err := DeriveSharedKey(a, make(PublicKey, PublicKeySize), "handshake", nil, 32); err == nil {
+		t.Error("small-order peer accepted")
+	}
+	if _, err := DeriveSharedKey(a, publicB, "", nil, 32); err == nil {
+		t.Error("empty label accepted")
+	}
+}
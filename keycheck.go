@@ -0,0 +1,157 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"crypto/subtle"
+	"errors"
+
+	"github.com/agl/ed25519/edwards25519"
+)
+
+// Validate checks that priv is internally consistent: that the public key
+// stored in its second half is well formed and is the one derived from its
+// seed. A private key whose public half has been corrupted still produces
+// signatures, but they fail to verify under the public key it claims to have,
+// so keys loaded from storage are worth checking.
+//
+// It returns ErrBadPrivateKeyLength, ErrInvalidPublicKey if the public half
+// is not a canonical encoding of a point of large order, ErrKeyMismatch if it
+// is a different key, or nil.
+func (priv PrivateKey) Validate() error {
+	if len(priv) != PrivateKeySize {
+		return ErrBadPrivateKeyLength
+	}
+	return checkKeyPair(PublicKey(priv[32:]), priv)
+}
+
+// CheckKeyPair checks that publicKey, which has been stored separately, is the
+// public key of privateKey, and that privateKey is itself consistent as
+// checked by Validate. The errors are as for Validate, plus
+// ErrBadPublicKeyLength.
+func CheckKeyPair(publicKey PublicKey, privateKey PrivateKey) error {
+	if len(publicKey) != PublicKeySize {
+		return ErrBadPublicKeyLength
+	}
+	if err := privateKey.Validate(); err != nil {
+		return err
+	}
+	return checkKeyPair(publicKey, privateKey)
+}
+
+// checkKeyPair checks that publicKey is canonical, of large order and derived
+// from the seed of privateKey. Both arguments must have the right length.
+func checkKeyPair(publicKey PublicKey, privateKey PrivateKey) error {
+	if err := checkPublicKey(publicKey); err != nil {
+		return err
+	}
+
+	derived := NewKeyFromSeed(privateKey[:32])
+	defer wipeBytes(derived)
+	if subtle.ConstantTimeCompare(derived[32:], publicKey) != 1 {
+		return ErrKeyMismatch
+	}
+	return nil
+}
+
+// checkPublicKey returns ErrInvalidPublicKey unless publicKey, which must be
+// PublicKeySize bytes long, is the canonical encoding of a point of large
+// order.
+func checkPublicKey(publicKey PublicKey) error {
+	var A edwards25519.ExtendedGroupElement
+	var publicKeyBytes [32]byte
+	copy(publicKeyBytes[:], publicKey)
+	if !A.FromBytes(&publicKeyBytes) || !isCanonicalEncoding(&A, &publicKeyBytes) || A.IsSmallOrder() {
+		return ErrInvalidPublicKey
+	}
+	return nil
+}
+
+// KeyValidationOptions configures ParsePublicKey. The zero value selects the
+// defaults, which are the rules that StrictVerify applies to public keys.
+type KeyValidationOptions struct {
+	// AllowNonCanonical accepts encodings of a point other than the one
+	// Bytes would produce, where y >= p or where x = 0 and the sign bit is
+	// set. ZIP-215 accepts them.
+	AllowNonCanonical bool
+
+	// AllowSmallOrder accepts the eight points of small order, for which
+	// signatures can be forged without the private key. With
+	// RequirePrimeOrder, only the identity, the one of them in the
+	// prime-order subgroup, is accepted.
+	AllowSmallOrder bool
+
+	// RequirePrimeOrder rejects points of large order that have a
+	// small-order component, that is points outside the subgroup
+	// generated by the base point. Keys made by GenerateKey never have
+	// one, but RFC 8032 verification doesn't reject them.
+	RequirePrimeOrder bool
+}
+
+var (
+	// ErrNonCanonicalKey is returned by ParsePublicKey for a
+	// non-canonical encoding of a point.
+	ErrNonCanonicalKey = errors.New("ed25519: non-canonical public key encoding")
+
+	// ErrSmallOrderKey is returned by ParsePublicKey for a point of small
+	// order.
+	ErrSmallOrderKey = errors.New("ed25519: public key of small order")
+
+	// ErrMixedOrderKey is returned by ParsePublicKey, if
+	// KeyValidationOptions.RequirePrimeOrder is set, for a point with a
+	// small-order component, which includes the small-order points other
+	// than the identity when AllowSmallOrder is also set.
+	ErrMixedOrderKey = errors.New("ed25519: public key is not in the prime-order subgroup")
+)
+
+// ParsePublicKey checks that b, which comes from an untrusted source, is a
+// public key allowed by opts and returns a copy of it. opts may be nil for the
+// defaults, which reject non-canonical encodings and points of small order.
+//
+// It returns ErrBadPublicKeyLength, ErrInvalidPublicKey if b is not the
+// encoding of a point, or ErrNonCanonicalKey, ErrSmallOrderKey or
+// ErrMixedOrderKey, checked in that order.
+func ParsePublicKey(b []byte, opts *KeyValidationOptions) (PublicKey, error) {
+	var A edwards25519.ExtendedGroupElement
+	if err := decodePublicKey(&A, b, opts, false); err != nil {
+		return nil, err
+	}
+	return append(PublicKey(nil), b...), nil
+}
+
+// decodePublicKey decodes b into A and checks it as ParsePublicKey does. If
+// vartime is set, the check for RequirePrimeOrder uses IsTorsionFreeVartime,
+// which is only suitable for public keys.
+func decodePublicKey(A *edwards25519.ExtendedGroupElement, b []byte, opts *KeyValidationOptions, vartime bool) error {
+	var o KeyValidationOptions
+	if opts != nil {
+		o = *opts
+	}
+	if len(b) != PublicKeySize {
+		return ErrBadPublicKeyLength
+	}
+
+	var publicKeyBytes [32]byte
+	copy(publicKeyBytes[:], b)
+	if !A.FromBytes(&publicKeyBytes) {
+		return ErrInvalidPublicKey
+	}
+	if !o.AllowNonCanonical && !isCanonicalEncoding(A, &publicKeyBytes) {
+		return ErrNonCanonicalKey
+	}
+	if !o.AllowSmallOrder && A.IsSmallOrder() {
+		return ErrSmallOrderKey
+	}
+	if o.RequirePrimeOrder {
+		torsionFree := A.IsTorsionFree
+		if vartime {
+			torsionFree = A.IsTorsionFreeVartime
+		}
+		if !torsionFree() {
+			return ErrMixedOrderKey
+		}
+	}
+	return nil
+}
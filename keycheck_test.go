@@ -0,0 +1,183 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	public, private, _ := GenerateKey(rand.Reader)
+	if err := private.Validate(); err != nil {
+		t.Fatalf("Validate of a fresh key: %s", err)
+	}
+	if err := CheckKeyPair(public, private); err != nil {
+		t.Fatalf("CheckKeyPair of a fresh key: %s", err)
+	}
+
+	for i := 0; i < PrivateKeySize; i++ {
+		for _, bit := range []byte{0x01, 0x80} {
+			corrupted := append(PrivateKey(nil), private...)
+			corrupted[i] ^= bit
+			err := corrupted.Validate()
+			// A flipped public key bit usually leaves a point on the curve,
+			// but not always.
+			if err != ErrKeyMismatch && !(i >= 32 && err == ErrInvalidPublicKey) {
+				t.Errorf("Validate with byte %d ^ %#x: got %v", i, bit, err)
+			}
+			if err := CheckKeyPair(public, corrupted); err == nil {
+				t.Errorf("CheckKeyPair with private byte %d ^ %#x succeeded", i, bit)
+			}
+		}
+	}
+
+	for i := 0; i < PublicKeySize; i++ {
+		corrupted := append(PublicKey(nil), public...)
+		corrupted[i] ^= 0x01
+		if err := CheckKeyPair(corrupted, private); err != ErrKeyMismatch && err != ErrInvalidPublicKey {
+			t.Errorf("CheckKeyPair with public byte %d ^ 1: got %v", i, err)
+		}
+	}
+
+	// A copy of the other half of a different key pair doesn't match either.
+	otherPublic, _, _ := GenerateKey(rand.Reader)
+	if err := CheckKeyPair(otherPublic, private); err != ErrKeyMismatch {
+		t.Errorf("CheckKeyPair with another public key: got %v, want ErrKeyMismatch", err)
+	}
+	swapped := append(private[:32:32], otherPublic...)
+	if err := swapped.Validate(); err != ErrKeyMismatch {
+		t.Errorf("Validate with another public key: got %v, want ErrKeyMismatch", err)
+	}
+}
+
+func TestValidateInvalidPublicKeys(t *testing.T) {
+	_, private, _ := GenerateKey(rand.Reader)
+
+	invalid := append([]string{
+		// y = 3 + p, a non-canonical encoding of a point of large order.
+		"f0ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f",
+	}, smallOrderEncodings...)
+	for _, encoding := range invalid {
+		publicKey := decodeHex(t, encoding)
+		corrupted := append(private[:32:32], publicKey...)
+		if err := corrupted.Validate(); err != ErrInvalidPublicKey {
+			t.Errorf("Validate with public half %s: got %v, want ErrInvalidPublicKey", encoding, err)
+		}
+		if err := CheckKeyPair(publicKey, private); err != ErrInvalidPublicKey {
+			t.Errorf("CheckKeyPair with %s: got %v, want ErrInvalidPublicKey", encoding, err)
+		}
+	}
+
+	corrupted := append(private[:32:32], offCurve...)
+	if err := corrupted.Validate(); err != ErrInvalidPublicKey {
+		t.Errorf("Validate with an off-curve public half: got %v, want ErrInvalidPublicKey", err)
+	}
+}
+
+func TestValidateLengths(t *testing.T) {
+	public, private, _ := GenerateKey(rand.Reader)
+
+	if err := private[:PrivateKeySize-1].Validate(); err != ErrBadPrivateKeyLength {
+		t.Errorf("Validate of a short key: got %v, want ErrBadPrivateKeyLength", err)
+	}
+	if err := PrivateKey(nil).Validate(); err != ErrBadPrivateKeyLength {
+		t.Errorf("Validate of a nil key: got %v, want ErrBadPrivateKeyLength", err)
+	}
+	if err := CheckKeyPair(public[:PublicKeySize-1], private); err != ErrBadPublicKeyLength {
+		t.Errorf("CheckKeyPair with a short public key: got %v, want ErrBadPublicKeyLength", err)
+	}
+	if err := CheckKeyPair(public, private.Seed()); err != ErrBadPrivateKeyLength {
+		t.Errorf("CheckKeyPair with a seed: got %v, want ErrBadPrivateKeyLength", err)
+	}
+}
+
+func TestParsePublicKey(t *testing.T) {
+	var (
+		strict     = &KeyValidationOptions{}
+		lax        = &KeyValidationOptions{AllowNonCanonical: true}
+		permissive = &KeyValidationOptions{AllowNonCanonical: true, AllowSmallOrder: true}
+		primeOrder = &KeyValidationOptions{RequirePrimeOrder: true}
+		// Only the identity is both of small order and in the prime-order
+		// subgroup.
+		smallPrime = &KeyValidationOptions{AllowSmallOrder: true, RequirePrimeOrder: true}
+	)
+
+	type result struct{ strict, lax, permissive, primeOrder, smallPrime error }
+	var (
+		valid        = result{nil, nil, nil, nil, nil}
+		mixedOrder   = result{nil, nil, nil, ErrMixedOrderKey, ErrMixedOrderKey}
+		identity     = result{ErrSmallOrderKey, ErrSmallOrderKey, nil, ErrSmallOrderKey, nil}
+		smallOrder   = result{ErrSmallOrderKey, ErrSmallOrderKey, nil, ErrSmallOrderKey, ErrMixedOrderKey}
+		nonCanonical = result{ErrNonCanonicalKey, ErrSmallOrderKey, nil, ErrNonCanonicalKey, ErrNonCanonicalKey}
+	)
+	tests := []struct {
+		encoding string
+		want     result
+	}{
+		// The public keys of edgeCases that aren't in smallOrderEncodings.
+		{"ef75b20e7540e3dff77404193652ba2bd13df99c1508eee1515e27ae25f28076", valid},
+		{"10eb7c3acfb2bed3e0d6ab89bf5a3d6afddd1176ce4812e38d9fd485058fdb1f", mixedOrder},
+		// y = 3 + p, a non-canonical encoding of a point of large order.
+		{"f0ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f", result{ErrNonCanonicalKey, nil, nil, ErrNonCanonicalKey, ErrNonCanonicalKey}},
+	}
+	for i, encoding := range smallOrderEncodings {
+		want := smallOrder
+		if i == 0 {
+			want = identity
+		} else if i >= 8 {
+			want = nonCanonical
+		}
+		tests = append(tests, struct {
+			encoding string
+			want     result
+		}{encoding, want})
+	}
+
+	for _, tt := range tests {
+		b := decodeHex(t, tt.encoding)
+		for _, c := range []struct {
+			name string
+			opts *KeyValidationOptions
+			want error
+		}{
+			{"default", nil, tt.want.strict},
+			{"strict", strict, tt.want.strict},
+			{"AllowNonCanonical", lax, tt.want.lax},
+			{"AllowSmallOrder", permissive, tt.want.permissive},
+			{"RequirePrimeOrder", primeOrder, tt.want.primeOrder},
+			{"AllowSmallOrder and RequirePrimeOrder", smallPrime, tt.want.smallPrime},
+		} {
+			got, err := ParsePublicKey(b, c.opts)
+			if err != c.want {
+				t.Errorf("%s with %s: got %v, want %v", tt.encoding, c.name, err, c.want)
+			}
+			if err == nil && !bytes.Equal(got, b) {
+				t.Errorf("%s with %s: returned %x", tt.encoding, c.name, got)
+			}
+		}
+	}
+
+	// ParsePublicKey and checkPublicKey agree with the defaults.
+	for _, tt := range tests {
+		b := decodeHex(t, tt.encoding)
+		_, err := ParsePublicKey(b, nil)
+		if (err == nil) != (checkPublicKey(b) == nil) {
+			t.Errorf("%s: ParsePublicKey gave %v, checkPublicKey %v", tt.encoding, err, checkPublicKey(b))
+		}
+	}
+
+	public, _, _ := GenerateKey(rand.Reader)
+	if _, err := ParsePublicKey(public, primeOrder); err != nil {
+		t.Errorf("generated key: %s", err)
+	}
+	if _, err := ParsePublicKey(offCurve, permissive); err != ErrInvalidPublicKey {
+		t.Errorf("off-curve key gave %v", err)
+	}
+	if _, err := ParsePublicKey(public[:31], nil); err != ErrBadPublicKeyLength {
+		t.Errorf("short key gave %v", err)
+	}
+}
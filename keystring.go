@@ -0,0 +1,193 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// KeyEncoding is a text encoding of keys and signatures for ParseKey. Each
+// one has a single accepted form: ParseKey never falls back to a related
+// encoding, so a string that parses is exactly the one the encoding writes.
+type KeyEncoding int
+
+const (
+	// HexLower is lower case hexadecimal, as from hex.EncodeToString.
+	HexLower KeyEncoding = iota + 1
+
+	// Base64Std is the standard base64 of RFC 4648, with padding.
+	Base64Std
+
+	// Base64URL is the URL and file name safe base64 of RFC 4648, with
+	// padding.
+	Base64URL
+
+	// Base64RawURL is the URL and file name safe base64 without padding,
+	// as used by JOSE.
+	Base64RawURL
+)
+
+// KeyKind is what a string parsed by ParseKey or ParsePrefixedKey holds,
+// which sets the expected length.
+type KeyKind int
+
+const (
+	// KindSeed is a SeedSize-byte private key seed.
+	KindSeed KeyKind = iota + 1
+
+	// KindPrivateKey64 is a PrivateKeySize-byte private key, the seed
+	// followed by the public key.
+	KindPrivateKey64
+
+	// KindPublicKey is a PublicKeySize-byte public key.
+	KindPublicKey
+
+	// KindSignature is a SignatureSize-byte signature.
+	KindSignature
+)
+
+var (
+	// ErrBadKeyEncoding is wrapped by the error returned by ParseKey when
+	// a string is not in the canonical form of its encoding. The error
+	// says what is wrong and where.
+	ErrBadKeyEncoding = errors.New("ed25519: bad key string encoding")
+
+	// ErrBadSeedLength is returned when a seed is not SeedSize bytes long.
+	ErrBadSeedLength = errors.New("ed25519: bad seed length")
+)
+
+var errBadKeyKind = errors.New("ed25519: unknown key kind")
+
+// ParseKey decodes s, a key or signature of the given kind in the given
+// encoding, and returns its bytes.
+//
+// An error that wraps ErrBadKeyEncoding is returned if s is not the exact
+// output of the encoding: for example upper case or an odd number of hex
+// digits, characters of the other base64 alphabet, missing or unexpected
+// padding, white space or non-zero unused bits. If s decodes to the wrong
+// number of bytes the error wraps ErrBadSeedLength, ErrBadPrivateKeyLength,
+// ErrBadPublicKeyLength or ErrBadSignatureLength. A KindPrivateKey64 key must
+// have the public key of its seed or the error is ErrKeyMismatch. Public keys
+// and signatures are only checked for length; Verify checks the rest.
+func ParseKey(s string, encoding KeyEncoding, kind KeyKind) ([]byte, error) {
+	size, lengthErr := keyKindSize(kind)
+	if size == 0 {
+		return nil, errBadKeyKind
+	}
+	b, err := decodeKeyString(s, encoding)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) != size {
+		wipeBytes(b)
+		return nil, fmt.Errorf("%w: decoded to %d bytes, want %d", lengthErr, len(b), size)
+	}
+	if kind == KindPrivateKey64 {
+		if err := checkKeyPair(PublicKey(b[32:]), PrivateKey(b)); err != nil {
+			wipeBytes(b)
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// keyKindSize returns the length of a key of the given kind and the error
+// for a key of another length, or zero if kind is unknown.
+func keyKindSize(kind KeyKind) (int, error) {
+	switch kind {
+	case KindSeed:
+		return SeedSize, ErrBadSeedLength
+	case KindPrivateKey64:
+		return PrivateKeySize, ErrBadPrivateKeyLength
+	case KindPublicKey:
+		return PublicKeySize, ErrBadPublicKeyLength
+	case KindSignature:
+		return SignatureSize, ErrBadSignatureLength
+	}
+	return 0, errBadKeyKind
+}
+
+// decodeKeyString decodes s, which must be in the canonical form of
+// encoding.
+func decodeKeyString(s string, encoding KeyEncoding) ([]byte, error) {
+	badEncoding := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%w: "+format, append([]interface{}{ErrBadKeyEncoding}, args...)...)
+	}
+	if s == "" {
+		return nil, badEncoding("empty string")
+	}
+
+	var enc *base64.Encoding
+	alphabet, other, otherName := base64URLAlphabet, base64StdAlphabet, "standard"
+	switch encoding {
+	case HexLower:
+		for i := 0; i < len(s); i++ {
+			switch c := s[i]; {
+			case '0' <= c && c <= '9', 'a' <= c && c <= 'f':
+			case 'A' <= c && c <= 'F':
+				return nil, badEncoding("upper case hex digit %q at offset %d", c, i)
+			default:
+				return nil, badEncoding("invalid hex character %q at offset %d", c, i)
+			}
+		}
+		if len(s)%2 != 0 {
+			return nil, badEncoding("odd number of hex digits")
+		}
+		return hex.DecodeString(s)
+	case Base64Std:
+		enc = base64.StdEncoding
+		alphabet, other, otherName = base64StdAlphabet, base64URLAlphabet, "URL-safe"
+	case Base64URL:
+		enc = base64.URLEncoding
+	case Base64RawURL:
+		enc = base64.RawURLEncoding
+	default:
+		return nil, errors.New("ed25519: unknown key encoding")
+	}
+	padded := encoding != Base64RawURL
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '=' || strings.IndexByte(alphabet, c) >= 0 {
+			continue
+		}
+		if strings.IndexByte(other, c) >= 0 {
+			return nil, badEncoding("%q at offset %d is from the %s base64 alphabet", c, i, otherName)
+		}
+		return nil, badEncoding("invalid base64 character %q at offset %d", c, i)
+	}
+	data := s
+	if i := strings.IndexByte(s, '='); i >= 0 {
+		if !padded {
+			return nil, badEncoding("padding at offset %d in unpadded base64", i)
+		}
+		data = s[:i]
+		if j := strings.IndexFunc(s[i:], func(r rune) bool { return r != '=' }); j >= 0 {
+			return nil, badEncoding("data after padding at offset %d", i+j)
+		}
+	}
+	if len(data)%4 == 1 {
+		return nil, badEncoding("truncated base64")
+	}
+	if padded {
+		if want := (4 - len(data)%4) % 4; len(s)-len(data) != want {
+			return nil, badEncoding("%d padding characters, want %d", len(s)-len(data), want)
+		}
+	}
+	b, err := enc.Strict().DecodeString(s)
+	if err != nil {
+		return nil, badEncoding("non-zero unused bits in the last character")
+	}
+	return b, nil
+}
+
+const (
+	base64StdAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+	base64URLAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"
+)
@@ -0,0 +1,184 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+var keyEncodings = []struct {
+	name     string
+	encoding KeyEncoding
+	encode   func([]byte) string
+}{
+	{"HexLower", HexLower, hex.EncodeToString},
+	{"Base64Std", Base64Std, base64.StdEncoding.EncodeToString},
+	{"Base64URL", Base64URL, base64.URLEncoding.EncodeToString},
+	{"Base64RawURL", Base64RawURL, base64.RawURLEncoding.EncodeToString},
+}
+
+// keyKindValues returns a valid value of every kind, each starting with a
+// byte that gives letters in hex.
+func keyKindValues(t *testing.T) map[KeyKind][]byte {
+	publicKey, privateKey, _ := GenerateKey(rand.Reader)
+	for publicKey[0] < 0xa0 {
+		publicKey, privateKey, _ = GenerateKey(rand.Reader)
+	}
+	sig, err := Sign(privateKey, []byte("message"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	seed := append([]byte{0xab}, privateKey[1:32]...)
+	sig[0] = 0xcd
+	return map[KeyKind][]byte{
+		KindSeed:         seed,
+		KindPrivateKey64: privateKey,
+		KindPublicKey:    publicKey,
+		KindSignature:    sig,
+	}
+}
+
+var keyKindLengthErrors = map[KeyKind]error{
+	KindSeed:         ErrBadSeedLength,
+	KindPrivateKey64: ErrBadPrivateKeyLength,
+	KindPublicKey:    ErrBadPublicKeyLength,
+	KindSignature:    ErrBadSignatureLength,
+}
+
+func TestParseKey(t *testing.T) {
+	for kind, value := range keyKindValues(t) {
+		for _, enc := range keyEncodings {
+			s := enc.encode(value)
+			got, err := ParseKey(s, enc.encoding, kind)
+			if err != nil || !bytes.Equal(got, value) {
+				t.Errorf("%s kind %d: ParseKey(%s) = %x, %v", enc.name, kind, s, got, err)
+			}
+		}
+	}
+}
+
+// lastDataIndex returns the offset of the last base64 character of s that
+// isn't padding.
+func lastDataIndex(s string) int {
+	return len(strings.TrimRight(s, "=")) - 1
+}
+
+// setUnusedBits returns s with the lowest bit of its last data character set,
+// which is one of the bits that the encoding leaves unused for the lengths of
+// keys and signatures.
+func setUnusedBits(s, alphabet string) string {
+	i := lastDataIndex(s)
+	c := alphabet[strings.IndexByte(alphabet, s[i])|1]
+	return s[:i] + string(c) + s[i+1:]
+}
+
+func TestParseKeyErrors(t *testing.T) {
+	type malformation struct {
+		name      string
+		mangle    func(value []byte, s string) string
+		wantKind  bool   // the error is the length error of the kind
+		wantError string // otherwise, the error wraps ErrBadKeyEncoding and contains this
+	}
+	common := []malformation{
+		{"empty", func([]byte, string) string { return "" }, false, "empty string"},
+		{"leading space", func(_ []byte, s string) string { return " " + s }, false, "' ' at offset 0"},
+		{"trailing newline", func(_ []byte, s string) string { return s + "\n" }, false, "'\\n' at offset"},
+		{"inner line break", func(_ []byte, s string) string { return s[:8] + "\r\n" + s[8:] }, false, "'\\r' at offset 8"},
+		{"byte short", nil, true, ""},
+		{"byte long", nil, true, ""},
+	}
+	specific := map[KeyEncoding][]malformation{
+		HexLower: {
+			{"upper case", func(_ []byte, s string) string { return strings.ToUpper(s) }, false, "upper case hex digit"},
+			{"odd length", func(_ []byte, s string) string { return s[:len(s)-1] }, false, "odd number of hex digits"},
+			{"non-hex letter", func(_ []byte, s string) string { return "g" + s[1:] }, false, "invalid hex character 'g' at offset 0"},
+			{"0x prefix", func(_ []byte, s string) string { return "0x" + s }, false, "invalid hex character 'x' at offset 1"},
+		},
+		Base64Std: {
+			{"URL-safe character", func(_ []byte, s string) string { return "-" + s[1:] }, false, "from the URL-safe base64 alphabet"},
+			{"missing padding", func(_ []byte, s string) string { return strings.TrimRight(s, "=") }, false, "padding characters, want"},
+			{"extra padding", func(_ []byte, s string) string { return s + "=" }, false, "padding characters, want"},
+			{"data after padding", func(_ []byte, s string) string { return s + "A" }, false, "data after padding"},
+			{"unused bits", func(_ []byte, s string) string { return setUnusedBits(s, base64StdAlphabet) }, false, "non-zero unused bits"},
+			{"truncated", func(_ []byte, s string) string { return strings.TrimRight(s, "=")[:lastDataIndex(s)] }, false, ""},
+		},
+		Base64URL: {
+			{"standard character", func(_ []byte, s string) string { return "+" + s[1:] }, false, "from the standard base64 alphabet"},
+			{"missing padding", func(_ []byte, s string) string { return strings.TrimRight(s, "=") }, false, "padding characters, want"},
+			{"unused bits", func(_ []byte, s string) string { return setUnusedBits(s, base64URLAlphabet) }, false, "non-zero unused bits"},
+		},
+		Base64RawURL: {
+			{"standard character", func(_ []byte, s string) string { return s[:4] + "/" + s[5:] }, false, "'/' at offset 4 is from the standard"},
+			{"padding", func(value []byte, _ string) string { return base64.URLEncoding.EncodeToString(value) }, false, "padding at offset"},
+			{"unused bits", func(_ []byte, s string) string { return setUnusedBits(s, base64URLAlphabet) }, false, "non-zero unused bits"},
+			{"one character too many", func(_ []byte, s string) string { return s + "AAAA"[:5-len(s)%4] }, false, "truncated base64"},
+		},
+	}
+
+	for kind, value := range keyKindValues(t) {
+		for _, enc := range keyEncodings {
+			for _, m := range append(append([]malformation(nil), common...), specific[enc.encoding]...) {
+				var s string
+				switch {
+				case m.name == "byte short":
+					s = enc.encode(value[:len(value)-1])
+				case m.name == "byte long":
+					s = enc.encode(append(append([]byte(nil), value...), 0))
+				default:
+					s = m.mangle(value, enc.encode(value))
+				}
+				_, err := ParseKey(s, enc.encoding, kind)
+				switch {
+				case err == nil:
+					t.Errorf("%s kind %d %s: %q accepted", enc.name, kind, m.name, s)
+				case m.wantKind:
+					if !errors.Is(err, keyKindLengthErrors[kind]) {
+						t.Errorf("%s kind %d %s: got %v, want %v", enc.name, kind, m.name, err, keyKindLengthErrors[kind])
+					}
+				case !errors.Is(err, ErrBadKeyEncoding) || !strings.Contains(err.Error(), m.wantError):
+					t.Errorf("%s kind %d %s: got %v, want %q", enc.name, kind, m.name, err, m.wantError)
+				}
+			}
+		}
+	}
+}
+
+func TestParseKeyKinds(t *testing.T) {
+	values := keyKindValues(t)
+
+	// A private key is checked against its seed. The other kinds of the
+	// same length parse as themselves.
+	mismatched := append([]byte(nil), values[KindPrivateKey64]...)
+	mismatched[0] ^= 1
+	if _, err := ParseKey(hex.EncodeToString(mismatched), HexLower, KindPrivateKey64); err != ErrKeyMismatch {
+		t.Errorf("mismatched private key gave %v", err)
+	}
+	if _, err := ParseKey(hex.EncodeToString(mismatched), HexLower, KindSignature); err != nil {
+		t.Errorf("64 arbitrary bytes as a signature gave %v", err)
+	}
+
+	// A seed is the wrong length for the 64-byte kinds, and the other way
+	// round, and the error names the kind that was asked for.
+	seedHex := hex.EncodeToString(values[KindSeed])
+	if _, err := ParseKey(seedHex, HexLower, KindSignature); !errors.Is(err, ErrBadSignatureLength) || !strings.Contains(err.Error(), "32 bytes, want 64") {
+		t.Errorf("seed as a signature gave %v", err)
+	}
+	if _, err := ParseKey(hex.EncodeToString(values[KindSignature]), HexLower, KindPublicKey); !errors.Is(err, ErrBadPublicKeyLength) {
+		t.Errorf("signature as a public key gave %v", err)
+	}
+
+	if _, err := ParseKey(seedHex, HexLower, 0); err == nil {
+		t.Error("unknown kind accepted")
+	}
+	if _, err := ParseKey(seedHex, 0, KindSeed); err == nil || errors.Is(err, ErrBadKeyEncoding) {
+		t.Errorf("unknown encoding gave %v", err)
+	}
+}
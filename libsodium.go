@@ -0,0 +1,58 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+// The secret key of libsodium's crypto_sign is the 32-byte seed followed by the
+// public key, the same layout as PrivateKey. libsodium reads the public half
+// back without checking it, so a blob from elsewhere can carry a public key
+// that its signatures won't verify under.
+
+// libsodiumSecretKeySize is crypto_sign_SECRETKEYBYTES.
+const libsodiumSecretKeySize = 64
+
+// FromLibsodiumSecretKey returns the PrivateKey of a libsodium crypto_sign
+// secret key. It returns ErrKeyMismatch if the public half is not the public
+// key of the seed.
+func FromLibsodiumSecretKey(sk []byte) (PrivateKey, error) {
+	if len(sk) != libsodiumSecretKeySize {
+		return nil, ErrBadPrivateKeyLength
+	}
+	if err := checkKeyPair(PublicKey(sk[32:]), PrivateKey(sk)); err != nil {
+		return nil, err
+	}
+	return append(PrivateKey(nil), sk...), nil
+}
+
+// ToLibsodiumSecretKey returns privateKey as a libsodium crypto_sign secret
+// key. It returns the zero array if len(privateKey) is not PrivateKeySize.
+func ToLibsodiumSecretKey(privateKey PrivateKey) [libsodiumSecretKeySize]byte {
+	var sk [libsodiumSecretKeySize]byte
+	if len(privateKey) == PrivateKeySize {
+		copy(sk[:], privateKey)
+	}
+	return sk
+}
+
+// LibsodiumSecretKeyToSeed returns the seed of a libsodium crypto_sign secret
+// key, like crypto_sign_ed25519_sk_to_seed.
+func LibsodiumSecretKeyToSeed(sk []byte) ([]byte, error) {
+	if len(sk) != libsodiumSecretKeySize {
+		return nil, ErrBadPrivateKeyLength
+	}
+	return append([]byte(nil), sk[:32]...), nil
+}
+
+// LibsodiumSecretKeyToPublicKey returns the public key of a libsodium
+// crypto_sign secret key, like crypto_sign_ed25519_sk_to_pk. Unlike that
+// function, it derives the key from the seed and returns ErrKeyMismatch if
+// the public half of sk is different.
+func LibsodiumSecretKeyToPublicKey(sk []byte) (PublicKey, error) {
+	privateKey, err := FromLibsodiumSecretKey(sk)
+	if err != nil {
+		return nil, err
+	}
+	defer wipeBytes(privateKey)
+	return append(PublicKey(nil), privateKey[32:]...), nil
+}
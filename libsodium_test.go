@@ -0,0 +1,104 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+)
+
+// libsodiumTests are keys from crypto_sign_keypair and signatures of
+// libsodiumMessage from crypto_sign_detached, made with libsodium 1.0.18.
+var libsodiumTests = []struct {
+	sk, pk, sig string
+}{
+	{
+		"bf22219bbe21f0acda17ac29f55217c9ade843c8e59acbc9a1537d750622ed5e9754bb3b69db8091cf11d6175fe7e7c55f15f6adde6f8fd9aae936bbb9a4a3b2",
+		"9754bb3b69db8091cf11d6175fe7e7c55f15f6adde6f8fd9aae936bbb9a4a3b2",
+		"1075afaa63e092051678c2d6221934bb9486e0e07b7e93dcdb979c1a7a3f82d4870673d3c3be6b5168142b6e32b66f2132b113a226c8008444dd589a0ba59303",
+	},
+	{
+		"a4f6cc1ded9dba0536b1ad83deda102ccb89f191148be4bf9d75690483c6e4cda06c41258268c3f3ea13218f8bb4a569dfd70d7715752edac0cdfe2a05bf4bb0",
+		"a06c41258268c3f3ea13218f8bb4a569dfd70d7715752edac0cdfe2a05bf4bb0",
+		"21300dc55bd55f2aa9b93542926a38ce2d4776286a357bdb3b07db0ee08b225c524ad676952cd71a658b5a97f66b9f262cdc550e5849d64bb6724e327b4cfa06",
+	},
+	{
+		"9ee2f48009f083f6a8af3cc42a538dad1c08ac624eef120e2599485b456954d8fa0a44d0d6b9a39dd5a7f15f18fe5ab9bf6e368e0b51264a5d65059191023e80",
+		"fa0a44d0d6b9a39dd5a7f15f18fe5ab9bf6e368e0b51264a5d65059191023e80",
+		"dca59e9ab888cf9dd6bd360cbcc2f2831e5042568dd3ce7b0b407a75bc7e475f018d334961ccef87d2c534dd614699acb270ec34e1d1762253365653370f5d03",
+	},
+}
+
+const libsodiumMessage = "libsodium fixture"
+
+func TestLibsodiumSecretKey(t *testing.T) {
+	for _, test := range libsodiumTests {
+		sk, _ := hex.DecodeString(test.sk)
+		pk, _ := hex.DecodeString(test.pk)
+		sig, _ := hex.DecodeString(test.sig)
+
+		privateKey, err := FromLibsodiumSecretKey(sk)
+		if err != nil {
+			t.Errorf("%s: %s", test.pk, err)
+			continue
+		}
+		if got, err := Sign(privateKey, []byte(libsodiumMessage)); err != nil || !bytes.Equal(got, sig) {
+			t.Errorf("%s: signature doesn't match crypto_sign_detached", test.pk)
+		}
+		if got := ToLibsodiumSecretKey(privateKey); !bytes.Equal(got[:], sk) {
+			t.Errorf("%s: ToLibsodiumSecretKey = %x", test.pk, got)
+		}
+
+		if seed, err := LibsodiumSecretKeyToSeed(sk); err != nil || !bytes.Equal(seed, sk[:32]) {
+			t.Errorf("%s: LibsodiumSecretKeyToSeed = %x, %v", test.pk, seed, err)
+		} else if !bytes.Equal(NewKeyFromSeed(seed), privateKey) {
+			t.Errorf("%s: seed doesn't give the key", test.pk)
+		}
+		if got, err := LibsodiumSecretKeyToPublicKey(sk); err != nil || !bytes.Equal(got, pk) {
+			t.Errorf("%s: LibsodiumSecretKeyToPublicKey = %x, %v", test.pk, got, err)
+		}
+
+		// FromLibsodiumSecretKey doesn't keep a reference to sk.
+		sk[0] ^= 1
+		if privateKey[0] == sk[0] {
+			t.Errorf("%s: private key aliases the secret key", test.pk)
+		}
+	}
+}
+
+func TestLibsodiumSecretKeyErrors(t *testing.T) {
+	sk, _ := hex.DecodeString(libsodiumTests[0].sk)
+
+	// A public half with one bit flipped, and one that is another key.
+	tampered := append([]byte(nil), sk...)
+	tampered[40] ^= 0x10
+	other, _, _ := GenerateKey(rand.Reader)
+	swapped := append(append([]byte(nil), sk[:32]...), other...)
+	for _, bad := range [][]byte{tampered, swapped} {
+		if _, err := FromLibsodiumSecretKey(bad); err == nil {
+			t.Errorf("tampered secret key %x accepted", bad)
+		}
+		if _, err := LibsodiumSecretKeyToPublicKey(bad); err == nil {
+			t.Errorf("public key of tampered secret key %x accepted", bad)
+		}
+	}
+	if _, err := FromLibsodiumSecretKey(swapped); err != ErrKeyMismatch {
+		t.Errorf("secret key with another public key gave %v", err)
+	}
+
+	for _, bad := range [][]byte{nil, sk[:32], append(sk, 0)} {
+		if _, err := FromLibsodiumSecretKey(bad); err != ErrBadPrivateKeyLength {
+			t.Errorf("%d-byte secret key gave %v", len(bad), err)
+		}
+		if _, err := LibsodiumSecretKeyToSeed(bad); err != ErrBadPrivateKeyLength {
+			t.Errorf("seed of %d-byte secret key gave %v", len(bad), err)
+		}
+	}
+	if got := ToLibsodiumSecretKey(PrivateKey(sk[:32])); got != [64]byte{} {
+		t.Error("ToLibsodiumSecretKey of a seed isn't zero")
+	}
+}
@@ -0,0 +1,430 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto"
+	cryptorand "crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/agl/ed25519/internal/blake2b"
+	"github.com/agl/ed25519/internal/scrypt"
+)
+
+// minisign files are two lines, an untrusted comment and the base64 of a
+// binary structure, with two more lines in signatures for the trusted comment
+// and the global signature that covers it. The formats are those of the
+// minisign tool, which stores a random 8-byte key ID next to every key and
+// signature. Secret keys are encrypted by XORing them with the output of
+// scrypt, with the parameters picked from an opslimit and a memlimit as
+// libsodium's crypto_pwhash_scryptsalsa208sha256 does.
+
+const (
+	minisignUntrustedPrefix = "untrusted comment: "
+	minisignTrustedPrefix   = "trusted comment: "
+
+	minisignAlgorithm       = "Ed"
+	minisignHashedAlgorithm = "ED"
+	minisignKDFAlgorithm    = "Sc"
+	minisignChecksumAlgo    = "B2"
+
+	minisignPublicKeySize = 2 + 8 + PublicKeySize
+	minisignSignatureSize = 2 + 8 + SignatureSize
+	minisignSecretKeySize = 2 + 2 + 2 + 32 + 8 + 8 + 8 + PrivateKeySize + 32
+)
+
+const (
+	// DefaultMinisignOpsLimit and DefaultMinisignMemLimit are the scrypt
+	// limits used by MarshalMinisignSecretKey if none are given. They match
+	// minisign, and need a second or two and 1 GiB of memory to derive the
+	// key.
+	DefaultMinisignOpsLimit = 33554432
+	DefaultMinisignMemLimit = 1073741824
+
+	// maxMinisignOpsLimit and maxMinisignMemory bound the work that
+	// ParseMinisignSecretKey does for an untrusted key.
+	maxMinisignOpsLimit = 1 << 32
+	maxMinisignMemory   = 1 << 30
+)
+
+// ErrMinisignKeyID is returned by VerifyMinisign when the signature was made
+// by a key with another key ID.
+var ErrMinisignKeyID = errors.New("ed25519: minisign signature is by a different key")
+
+var errBadMinisign = errors.New("ed25519: malformed minisign file")
+
+// MinisignKeyID is the identifier that minisign stores next to a key and in
+// every signature made with it. It is usually chosen at random when the key is
+// generated.
+type MinisignKeyID [8]byte
+
+// String returns the key ID as minisign prints it: the bytes as a
+// little-endian integer, in upper-case hexadecimal.
+func (id MinisignKeyID) String() string {
+	var b [8]byte
+	for i := range id {
+		b[i] = id[len(id)-1-i]
+	}
+	return strings.ToUpper(hex.EncodeToString(b[:]))
+}
+
+// MinisignSecretKeyOptions configures MarshalMinisignSecretKey. The zero value
+// selects the defaults.
+type MinisignSecretKeyOptions struct {
+	// OpsLimit and MemLimit are the scrypt limits from which the scrypt
+	// parameters are picked. If zero, DefaultMinisignOpsLimit and
+	// DefaultMinisignMemLimit are used.
+	OpsLimit, MemLimit uint64
+
+	// UntrustedComment is the comment on the first line. If empty, the
+	// comment written by minisign is used.
+	UntrustedComment string
+
+	// Rand is the source of the salt. If nil, crypto/rand.Reader is used.
+	Rand io.Reader
+}
+
+// MinisignSignOptions configures SignMinisign. The zero value selects the
+// defaults.
+type MinisignSignOptions struct {
+	// UntrustedComment is the comment on the first line, which isn't
+	// signed. If empty, the comment written by minisign is used.
+	UntrustedComment string
+
+	// TrustedComment is the comment that the global signature covers. If
+	// empty, it is "timestamp:" followed by the current Unix time, as
+	// written by minisign.
+	TrustedComment string
+
+	// Legacy selects the "Ed" algorithm, which signs the message itself,
+	// instead of the default "ED", which signs its BLAKE2b-512 hash.
+	Legacy bool
+}
+
+// MarshalMinisignPublicKey returns publicKey with keyID in the format of a
+// minisign public key file.
+func MarshalMinisignPublicKey(publicKey PublicKey, keyID MinisignKeyID) ([]byte, error) {
+	if len(publicKey) != PublicKeySize {
+		return nil, ErrBadPublicKeyLength
+	}
+	b := make([]byte, 0, minisignPublicKeySize)
+	b = append(b, minisignAlgorithm...)
+	b = append(b, keyID[:]...)
+	b = append(b, publicKey...)
+	return appendMinisignLines(nil, "minisign public key "+keyID.String(), b)
+}
+
+// ParseMinisignPublicKey parses a minisign public key file, or just the base64
+// line of one as given to "minisign -P".
+func ParseMinisignPublicKey(text []byte) (PublicKey, MinisignKeyID, error) {
+	var keyID MinisignKeyID
+	lines := minisignLines(text)
+	if len(lines) == 2 && strings.HasPrefix(lines[0], minisignUntrustedPrefix) {
+		lines = lines[1:]
+	}
+	if len(lines) != 1 {
+		return nil, keyID, errBadMinisign
+	}
+	b, err := base64.StdEncoding.Strict().DecodeString(lines[0])
+	if err != nil || len(b) != minisignPublicKeySize {
+		return nil, keyID, errBadMinisign
+	}
+	if string(b[:2]) != minisignAlgorithm {
+		return nil, keyID, errors.New("ed25519: unsupported minisign algorithm")
+	}
+	copy(keyID[:], b[2:10])
+	return PublicKey(b[10:]), keyID, nil
+}
+
+// MarshalMinisignSecretKey returns privateKey with keyID in the format of a
+// minisign secret key file. If password is not empty the key is encrypted
+// under a key derived from it by scrypt with a random salt, with the
+// parameters picked from the limits in opts, which may be nil. If password is
+// empty the key is stored unencrypted, as by "minisign -G -W".
+func MarshalMinisignSecretKey(privateKey PrivateKey, keyID MinisignKeyID, password []byte, opts *MinisignSecretKeyOptions) ([]byte, error) {
+	if len(privateKey) != PrivateKeySize {
+		return nil, ErrBadPrivateKeyLength
+	}
+	var o MinisignSecretKeyOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.OpsLimit == 0 {
+		o.OpsLimit = DefaultMinisignOpsLimit
+	}
+	if o.MemLimit == 0 {
+		o.MemLimit = DefaultMinisignMemLimit
+	}
+	if o.UntrustedComment == "" {
+		o.UntrustedComment = "minisign encrypted secret key"
+	}
+	if o.Rand == nil {
+		o.Rand = cryptorand.Reader
+	}
+
+	b := make([]byte, minisignSecretKeySize)
+	defer wipeBytes(b)
+	copy(b, minisignAlgorithm)
+	copy(b[4:], minisignChecksumAlgo)
+	salt, limits, keynumSK := b[6:38], b[38:54], b[54:]
+	copy(keynumSK, keyID[:])
+	copy(keynumSK[8:], privateKey)
+	chk := minisignChecksum(keyID, privateKey)
+	copy(keynumSK[8+PrivateKeySize:], chk[:])
+
+	if len(password) > 0 {
+		copy(b[2:], minisignKDFAlgorithm)
+		if _, err := io.ReadFull(o.Rand, salt); err != nil {
+			return nil, err
+		}
+		binary.LittleEndian.PutUint64(limits, o.OpsLimit)
+		binary.LittleEndian.PutUint64(limits[8:], o.MemLimit)
+		if err := minisignCrypt(keynumSK, password, salt, o.OpsLimit, o.MemLimit); err != nil {
+			return nil, err
+		}
+	}
+	return appendMinisignLines(nil, o.UntrustedComment, b)
+}
+
+// ParseMinisignSecretKey parses a minisign secret key file and decrypts it
+// with password, which is ignored if the key isn't encrypted.
+//
+// It returns ErrPassphraseRequired if the key is encrypted and password is
+// empty, ErrIncorrectPassphrase if the checksum of the decrypted key doesn't
+// match, and ErrKeyMismatch if the public key in it is not that of the seed.
+func ParseMinisignSecretKey(text, password []byte) (PrivateKey, MinisignKeyID, error) {
+	var keyID MinisignKeyID
+	lines := minisignLines(text)
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], minisignUntrustedPrefix) {
+		return nil, keyID, errBadMinisign
+	}
+	b, err := base64.StdEncoding.Strict().DecodeString(lines[1])
+	if err != nil || len(b) != minisignSecretKeySize {
+		return nil, keyID, errBadMinisign
+	}
+	defer wipeBytes(b)
+	if string(b[:2]) != minisignAlgorithm || string(b[4:6]) != minisignChecksumAlgo {
+		return nil, keyID, errors.New("ed25519: unsupported minisign algorithm")
+	}
+	salt, limits, keynumSK := b[6:38], b[38:54], b[54:]
+
+	switch string(b[2:4]) {
+	case "\x00\x00":
+	case minisignKDFAlgorithm:
+		if len(password) == 0 {
+			return nil, keyID, ErrPassphraseRequired
+		}
+		opsLimit := binary.LittleEndian.Uint64(limits)
+		memLimit := binary.LittleEndian.Uint64(limits[8:])
+		if err := minisignCrypt(keynumSK, password, salt, opsLimit, memLimit); err != nil {
+			return nil, keyID, err
+		}
+	default:
+		return nil, keyID, errors.New("ed25519: unsupported minisign key derivation function")
+	}
+
+	copy(keyID[:], keynumSK)
+	privateKey := keynumSK[8 : 8+PrivateKeySize]
+	chk := minisignChecksum(keyID, privateKey)
+	if subtle.ConstantTimeCompare(chk[:], keynumSK[8+PrivateKeySize:]) != 1 {
+		return nil, keyID, ErrIncorrectPassphrase
+	}
+	if err := checkKeyPair(PublicKey(privateKey[32:]), PrivateKey(privateKey)); err != nil {
+		return nil, keyID, err
+	}
+	return append(PrivateKey(nil), privateKey...), keyID, nil
+}
+
+// SignMinisign signs message with signer, whose key ID is keyID, and returns
+// the signature in the format of a minisign signature file. opts may be nil
+// for the defaults. signer is called twice: once for the message and once for
+// the global signature over that signature and the trusted comment.
+func SignMinisign(signer crypto.Signer, keyID MinisignKeyID, message []byte, opts *MinisignSignOptions) ([]byte, error) {
+	var o MinisignSignOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.UntrustedComment == "" {
+		o.UntrustedComment = "signature from minisign secret key"
+	}
+	if o.TrustedComment == "" {
+		o.TrustedComment = "timestamp:" + strconv.FormatInt(time.Now().Unix(), 10)
+	}
+	if strings.ContainsAny(o.TrustedComment, "\r\n") {
+		return nil, errors.New("ed25519: minisign comment contains a line break")
+	}
+
+	algorithm := minisignHashedAlgorithm
+	if o.Legacy {
+		algorithm = minisignAlgorithm
+	} else {
+		hash := blake2b.Sum512(message)
+		message = hash[:]
+	}
+	sig, err := signWith(signer, message)
+	if err != nil {
+		return nil, err
+	}
+	globalSig, err := signWith(signer, append(sig[:SignatureSize:SignatureSize], o.TrustedComment...))
+	if err != nil {
+		return nil, err
+	}
+
+	b := make([]byte, 0, minisignSignatureSize)
+	b = append(b, algorithm...)
+	b = append(b, keyID[:]...)
+	b = append(b, sig...)
+	out, err := appendMinisignLines(nil, o.UntrustedComment, b)
+	if err != nil {
+		return nil, err
+	}
+	out = append(out, minisignTrustedPrefix+o.TrustedComment+"\n"...)
+	out = append(out, base64.StdEncoding.EncodeToString(globalSig)+"\n"...)
+	return out, nil
+}
+
+// VerifyMinisign checks that sigText, a minisign signature file as produced by
+// SignMinisign or "minisign -S", is a valid signature of message by the key in
+// publicKeyText, a minisign public key file, and returns the trusted comment.
+// Both the legacy and the hashed algorithms are accepted.
+//
+// It returns ErrMinisignKeyID if the key IDs of the signature and the public
+// key differ, ErrInvalidSignature if either the signature or the global
+// signature doesn't verify, and other errors if the files are malformed.
+func VerifyMinisign(publicKeyText, sigText, message []byte) (trustedComment string, err error) {
+	publicKey, keyID, err := ParseMinisignPublicKey(publicKeyText)
+	if err != nil {
+		return "", err
+	}
+
+	lines := minisignLines(sigText)
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], minisignUntrustedPrefix) || !strings.HasPrefix(lines[2], minisignTrustedPrefix) {
+		return "", errBadMinisign
+	}
+	b, err := base64.StdEncoding.Strict().DecodeString(lines[1])
+	if err != nil || len(b) != minisignSignatureSize {
+		return "", errBadMinisign
+	}
+	globalSig, err := base64.StdEncoding.Strict().DecodeString(lines[3])
+	if err != nil || len(globalSig) != SignatureSize {
+		return "", errBadMinisign
+	}
+
+	switch string(b[:2]) {
+	case minisignAlgorithm:
+	case minisignHashedAlgorithm:
+		hash := blake2b.Sum512(message)
+		message = hash[:]
+	default:
+		return "", errors.New("ed25519: unsupported minisign algorithm")
+	}
+	if !bytes.Equal(b[2:10], keyID[:]) {
+		return "", ErrMinisignKeyID
+	}
+	sig := b[10:minisignSignatureSize:minisignSignatureSize]
+	if err := CheckSignature(publicKey, message, sig); err != nil {
+		return "", err
+	}
+
+	trustedComment = lines[2][len(minisignTrustedPrefix):]
+	if err := CheckSignature(publicKey, append(sig, trustedComment...), globalSig); err != nil {
+		return "", err
+	}
+	return trustedComment, nil
+}
+
+// minisignLines splits text into lines without their line endings, ignoring
+// a final line break.
+func minisignLines(text []byte) []string {
+	s := strings.TrimSuffix(strings.TrimSuffix(string(text), "\n"), "\r")
+	lines := strings.Split(s, "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSuffix(lines[i], "\r")
+	}
+	return lines
+}
+
+// appendMinisignLines appends the untrusted comment line and the base64 of b
+// to dst.
+func appendMinisignLines(dst []byte, comment string, b []byte) ([]byte, error) {
+	if strings.ContainsAny(comment, "\r\n") {
+		return nil, errors.New("ed25519: minisign comment contains a line break")
+	}
+	dst = append(dst, minisignUntrustedPrefix+comment+"\n"...)
+	dst = append(dst, base64.StdEncoding.EncodeToString(b)+"\n"...)
+	return dst, nil
+}
+
+// minisignChecksum returns the checksum of a secret key: the BLAKE2b-256 hash
+// of the algorithm, the key ID and the key.
+func minisignChecksum(keyID MinisignKeyID, privateKey []byte) [32]byte {
+	h, _ := blake2b.New256(nil)
+	h.Write([]byte(minisignAlgorithm))
+	h.Write(keyID[:])
+	h.Write(privateKey)
+	var chk [32]byte
+	h.Sum(chk[:0])
+	return chk
+}
+
+// minisignCrypt encrypts or decrypts b in place by XORing it with the scrypt
+// output for password and salt, with the parameters picked from opsLimit and
+// memLimit.
+func minisignCrypt(b, password, salt []byte, opsLimit, memLimit uint64) error {
+	if opsLimit > maxMinisignOpsLimit {
+		return errors.New("ed25519: minisign scrypt limits too large")
+	}
+	N, r, p := minisignScryptParams(opsLimit, memLimit)
+	if p == 0 || 128*uint64(r)*N > maxMinisignMemory {
+		return errors.New("ed25519: minisign scrypt limits too large")
+	}
+	stream, err := scrypt.Key(password, salt, int(N), r, p, len(b))
+	if err != nil {
+		return err
+	}
+	subtle.XORBytes(b, b, stream)
+	wipeBytes(stream)
+	return nil
+}
+
+// minisignScryptParams picks the scrypt parameters for the given limits, as
+// pickparams in libsodium does.
+func minisignScryptParams(opsLimit, memLimit uint64) (N uint64, r, p int) {
+	if opsLimit < 32768 {
+		opsLimit = 32768
+	}
+	r = 8
+	var maxN uint64
+	if opsLimit < memLimit/32 {
+		p = 1
+		maxN = opsLimit / (uint64(r) * 4)
+	} else {
+		maxN = memLimit / (uint64(r) * 128)
+	}
+	logN := 1
+	for ; logN < 63; logN++ {
+		if uint64(1)<<logN > maxN/2 {
+			break
+		}
+	}
+	N = uint64(1) << logN
+	if p == 0 {
+		maxrp := (opsLimit / 4) / N
+		if maxrp > 0x3fffffff {
+			maxrp = 0x3fffffff
+		}
+		p = int(maxrp) / r
+	}
+	return N, r, p
+}
@@ -0,0 +1,242 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+// smallMinisignLimits give N = 1024, r = 8 and p = 1, which keeps the tests
+// fast.
+var smallMinisignLimits = &MinisignSecretKeyOptions{OpsLimit: 32768, MemLimit: 16777216}
+
+func TestMinisignKeyID(t *testing.T) {
+	// The key of the author of minisign, as printed in its documentation.
+	public, keyID, err := ParseMinisignPublicKey([]byte("RWQf6LRCGA9i53mlYecO4IzT51TGPpvWucNSCh1CBM0QTaLn73Y7GFO3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := keyID.String(); got != "E7620F1842B4E81F" {
+		t.Errorf("key ID %s, want E7620F1842B4E81F", got)
+	}
+	if len(public) != PublicKeySize {
+		t.Errorf("public key of %d bytes", len(public))
+	}
+}
+
+func TestMinisignFixtures(t *testing.T) {
+	pubText := readTestdata(t, "minisign.pub")
+	message := readTestdata(t, "minisign_message.txt")
+
+	tests := []struct {
+		file           string
+		trustedComment string
+	}{
+		{"minisign_message.txt.minisig", "timestamp:1760400000\tfile:minisign_message.txt\thashed"},
+		{"minisign_message.txt.legacy.minisig", "timestamp:1760400000\tfile:minisign_message.txt"},
+	}
+	for _, test := range tests {
+		sigText := readTestdata(t, test.file)
+		comment, err := VerifyMinisign(pubText, sigText, message)
+		if err != nil {
+			t.Errorf("%s: %s", test.file, err)
+			continue
+		}
+		if comment != test.trustedComment {
+			t.Errorf("%s: trusted comment %q, want %q", test.file, comment, test.trustedComment)
+		}
+		if _, err := VerifyMinisign(pubText, sigText, append(message, '!')); err != ErrInvalidSignature {
+			t.Errorf("%s: other message gave %v", test.file, err)
+		}
+
+		forged := bytes.Replace(sigText, []byte("timestamp:1760400000"), []byte("timestamp:1760400001"), 1)
+		if _, err := VerifyMinisign(pubText, forged, message); err != ErrInvalidSignature {
+			t.Errorf("%s: changed trusted comment gave %v", test.file, err)
+		}
+		crlf := bytes.ReplaceAll(sigText, []byte("\n"), []byte("\r\n"))
+		if _, err := VerifyMinisign(pubText, crlf, message); err != nil {
+			t.Errorf("%s with CRLF line endings: %s", test.file, err)
+		}
+	}
+
+	private, keyID, err := ParseMinisignSecretKey(readTestdata(t, "minisign.key"), []byte(testPassphrase))
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, pubKeyID, err := ParseMinisignPublicKey(pubText)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keyID != pubKeyID || !bytes.Equal(private[32:], public) {
+		t.Error("secret key doesn't match the public key")
+	}
+	if got, err := MarshalMinisignPublicKey(public, keyID); err != nil || !bytes.Equal(got, pubText) {
+		t.Errorf("MarshalMinisignPublicKey = %q, %v, want %q", got, err, pubText)
+	}
+
+	// Signing is deterministic, so the fixtures can be reproduced exactly.
+	for _, test := range []struct {
+		file   string
+		legacy bool
+	}{
+		{"minisign_message.txt.minisig", false},
+		{"minisign_message.txt.legacy.minisig", true},
+	} {
+		want := readTestdata(t, test.file)
+		lines := minisignLines(want)
+		opts := &MinisignSignOptions{TrustedComment: lines[2][len(minisignTrustedPrefix):], Legacy: test.legacy}
+		got, err := SignMinisign(private, keyID, message, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("SignMinisign gave\n%s\nwant\n%s", got, want)
+		}
+	}
+}
+
+func TestMinisignSecretKeyRoundTrip(t *testing.T) {
+	_, private, _ := GenerateKey(rand.Reader)
+	keyID := MinisignKeyID{1, 2, 3, 4, 5, 6, 7, 8}
+
+	for _, password := range []string{testPassphrase, ""} {
+		text, err := MarshalMinisignSecretKey(private, keyID, []byte(password), smallMinisignLimits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, gotKeyID, err := ParseMinisignSecretKey(text, []byte(password))
+		if err != nil {
+			t.Errorf("password %q: %s", password, err)
+			continue
+		}
+		if !bytes.Equal(got, private) || gotKeyID != keyID {
+			t.Errorf("password %q: key didn't round-trip", password)
+		}
+
+		if password == "" {
+			continue
+		}
+		if _, _, err := ParseMinisignSecretKey(text, nil); err != ErrPassphraseRequired {
+			t.Errorf("no password gave %v", err)
+		}
+		if _, _, err := ParseMinisignSecretKey(text, []byte("wrong")); err != ErrIncorrectPassphrase {
+			t.Errorf("wrong password gave %v", err)
+		}
+	}
+}
+
+func TestMinisignSignVerify(t *testing.T) {
+	public, private, _ := GenerateKey(rand.Reader)
+	keyID := MinisignKeyID{1, 2, 3, 4, 5, 6, 7, 8}
+	pubText, err := MarshalMinisignPublicKey(public, keyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := []byte("hello, world")
+
+	sigText, err := SignMinisign(private, keyID, message, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	comment, err := VerifyMinisign(pubText, sigText, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(comment, "timestamp:") {
+		t.Errorf("default trusted comment %q", comment)
+	}
+	if b, _ := base64.StdEncoding.DecodeString(minisignLines(sigText)[1]); string(b[:2]) != "ED" {
+		t.Error("signature doesn't use the hashed algorithm by default")
+	}
+
+	otherText, _ := MarshalMinisignPublicKey(public, MinisignKeyID{8, 7, 6, 5, 4, 3, 2, 1})
+	if _, err := VerifyMinisign(otherText, sigText, message); err != ErrMinisignKeyID {
+		t.Errorf("other key ID gave %v", err)
+	}
+	other, _, _ := GenerateKey(rand.Reader)
+	otherText, _ = MarshalMinisignPublicKey(other, keyID)
+	if _, err := VerifyMinisign(otherText, sigText, message); err != ErrInvalidSignature {
+		t.Errorf("other key gave %v", err)
+	}
+
+	if _, err := SignMinisign(private, keyID, message, &MinisignSignOptions{TrustedComment: "a\nb"}); err == nil {
+		t.Error("trusted comment with a line break accepted")
+	}
+	if _, err := SignMinisign(private, keyID, message, &MinisignSignOptions{UntrustedComment: "a\nb"}); err == nil {
+		t.Error("untrusted comment with a line break accepted")
+	}
+}
+
+func TestMinisignErrors(t *testing.T) {
+	pubText := readTestdata(t, "minisign.pub")
+	sigText := readTestdata(t, "minisign_message.txt.minisig")
+	message := readTestdata(t, "minisign_message.txt")
+	lines := minisignLines(sigText)
+
+	badSigs := []string{
+		"",
+		lines[0] + "\n" + lines[1] + "\n",
+		strings.Join([]string{lines[0], lines[1], "comment: " + lines[2], lines[3]}, "\n"),
+		strings.Join([]string{lines[0], lines[1][:len(lines[1])-4], lines[2], lines[3]}, "\n"),
+		strings.Join([]string{lines[0], lines[1], lines[2], lines[3][4:]}, "\n"),
+		strings.Join([]string{lines[0], "RX" + lines[1][2:], lines[2], lines[3]}, "\n"),
+		string(sigText) + "\n",
+	}
+	for _, bad := range badSigs {
+		if _, err := VerifyMinisign(pubText, []byte(bad), message); err == nil {
+			t.Errorf("malformed signature %q accepted", bad)
+		}
+	}
+
+	pubLines := minisignLines(pubText)
+	b, _ := base64.StdEncoding.DecodeString(pubLines[1])
+	b[0] = 'X'
+	for _, bad := range []string{"", pubLines[1][1:], base64.StdEncoding.EncodeToString(b), "comment\n" + pubLines[1]} {
+		if _, _, err := ParseMinisignPublicKey([]byte(bad)); err == nil {
+			t.Errorf("malformed public key %q accepted", bad)
+		}
+	}
+
+	// A key whose public half doesn't match its seed, with a valid checksum.
+	_, private, _ := GenerateKey(rand.Reader)
+	other, _, _ := GenerateKey(rand.Reader)
+	mismatched := append(PrivateKey(nil), private...)
+	copy(mismatched[32:], other)
+	text, err := MarshalMinisignSecretKey(mismatched, MinisignKeyID{}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ParseMinisignSecretKey(text, nil); err != ErrKeyMismatch {
+		t.Errorf("mismatched key gave %v", err)
+	}
+
+	huge := &MinisignSecretKeyOptions{OpsLimit: 1 << 40, MemLimit: 1 << 40}
+	if _, err := MarshalMinisignSecretKey(private, MinisignKeyID{}, []byte(testPassphrase), huge); err == nil {
+		t.Error("huge scrypt limits accepted")
+	}
+}
+
+func TestMinisignScryptParams(t *testing.T) {
+	tests := []struct {
+		opsLimit, memLimit uint64
+		N                  uint64
+		r, p               int
+	}{
+		{DefaultMinisignOpsLimit, DefaultMinisignMemLimit, 1 << 20, 8, 1},
+		{32768, 16777216, 1 << 10, 8, 1},
+		{0, 16777216, 1 << 10, 8, 1},
+		{1 << 24, 1 << 20, 1 << 10, 8, 512},
+	}
+	for _, test := range tests {
+		N, r, p := minisignScryptParams(test.opsLimit, test.memLimit)
+		if N != test.N || r != test.r || p != test.p {
+			t.Errorf("minisignScryptParams(%d, %d) = %d, %d, %d, want %d, %d, %d", test.opsLimit, test.memLimit, N, r, p, test.N, test.r, test.p)
+		}
+	}
+}
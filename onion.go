@@ -0,0 +1,83 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"crypto/sha3"
+	"errors"
+	"strings"
+)
+
+// A Tor v3 onion address is the lower case base32 of the identity key of the
+// onion service, a 2-byte checksum and the version, 3, followed by ".onion",
+// as specified in rend-spec-v3, section 6. The checksum is the start of
+// SHA3-256(".onion checksum" || key || version).
+
+const (
+	onionVersion = 3
+	onionSuffix  = ".onion"
+	onionSize    = PublicKeySize + 2 + 1
+)
+
+var (
+	// ErrOnionChecksum is returned by ParseOnionAddress when the checksum
+	// of an onion address doesn't match, as happens with a mistyped
+	// address.
+	ErrOnionChecksum = errors.New("ed25519: onion address checksum mismatch")
+
+	// ErrOnionVersion is returned by ParseOnionAddress for an address of a
+	// version other than 3.
+	ErrOnionVersion = errors.New("ed25519: unsupported onion address version")
+)
+
+var errBadOnion = errors.New("ed25519: malformed onion address")
+
+// OnionAddressFromPublicKey returns the Tor v3 onion address, ending in
+// ".onion", of the onion service with identity key publicKey.
+func OnionAddressFromPublicKey(publicKey PublicKey) (string, error) {
+	if len(publicKey) != PublicKeySize {
+		return "", ErrBadPublicKeyLength
+	}
+	b := make([]byte, 0, onionSize)
+	b = append(b, publicKey...)
+	b = append(b, onionChecksum(publicKey, onionVersion)...)
+	b = append(b, onionVersion)
+	return strings.ToLower(base32NoPadding.EncodeToString(b)) + onionSuffix, nil
+}
+
+// ParseOnionAddress returns the identity key of a Tor v3 onion address, with
+// or without the ".onion" suffix. The address must be in lower case and must
+// not have subdomains. It returns ErrOnionVersion for an address of another
+// version, ErrOnionChecksum if the checksum doesn't match, and
+// ErrInvalidPublicKey if the key is not a valid point.
+func ParseOnionAddress(address string) (PublicKey, error) {
+	encoded := strings.TrimSuffix(address, onionSuffix)
+	if len(encoded) != base32NoPadding.EncodedLen(onionSize) || strings.ToLower(encoded) != encoded {
+		return nil, errBadOnion
+	}
+	b, err := base32NoPadding.DecodeString(strings.ToUpper(encoded))
+	if err != nil || len(b) != onionSize {
+		return nil, errBadOnion
+	}
+	publicKey, checksum, version := PublicKey(b[:PublicKeySize]), b[PublicKeySize:onionSize-1], b[onionSize-1]
+	if version != onionVersion {
+		return nil, ErrOnionVersion
+	}
+	if string(checksum) != string(onionChecksum(publicKey, version)) {
+		return nil, ErrOnionChecksum
+	}
+	if err := checkPublicKey(publicKey); err != nil {
+		return nil, err
+	}
+	return publicKey, nil
+}
+
+func onionChecksum(publicKey PublicKey, version byte) []byte {
+	h := sha3.New256()
+	h.Write([]byte(".onion checksum"))
+	h.Write(publicKey)
+	h.Write([]byte{version})
+	return h.Sum(nil)[:2]
+}
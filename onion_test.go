@@ -0,0 +1,88 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+var onionTests = []struct {
+	publicKey, address string
+}{
+	// The key of test 1 of RFC 8032, as in test_build_address of Tor.
+	{"d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a", "25njqamcweflpvkl73j4szahhihoc4xt3ktcgjnpaingr5yhkenl5sid.onion"},
+	// The example address of rend-spec-v3.
+	{"79bcc625184b05194975c28b66b66b0469f7f6556fb1ac3189a79b40dda32f1f", "pg6mmjiyjmcrsslvykfwnntlaru7p5svn6y2ymmju6nubxndf4pscryd.onion"},
+}
+
+func TestOnionAddress(t *testing.T) {
+	for _, test := range onionTests {
+		publicKey, _ := hex.DecodeString(test.publicKey)
+		if got, err := OnionAddressFromPublicKey(publicKey); err != nil || got != test.address {
+			t.Errorf("OnionAddressFromPublicKey(%s) = %s, %v, want %s", test.publicKey, got, err, test.address)
+		}
+		for _, address := range []string{test.address, strings.TrimSuffix(test.address, ".onion")} {
+			if got, err := ParseOnionAddress(address); err != nil || !bytes.Equal(got, publicKey) {
+				t.Errorf("ParseOnionAddress(%s) = %x, %v", address, got, err)
+			}
+		}
+	}
+
+	publicKey, _, _ := GenerateKey(rand.Reader)
+	address, _ := OnionAddressFromPublicKey(publicKey)
+	if got, err := ParseOnionAddress(address); err != nil || !bytes.Equal(got, publicKey) {
+		t.Errorf("ParseOnionAddress(%s) = %x, %v", address, got, err)
+	}
+	if _, err := OnionAddressFromPublicKey(publicKey[:31]); err != ErrBadPublicKeyLength {
+		t.Errorf("short key gave %v", err)
+	}
+}
+
+func TestOnionAddressErrors(t *testing.T) {
+	address := onionTests[1].address
+	publicKey, _ := hex.DecodeString(onionTests[1].publicKey)
+
+	// The first character only changes the key.
+	if _, err := ParseOnionAddress("q" + address[1:]); err != ErrOnionChecksum {
+		t.Errorf("mistyped address gave %v", err)
+	}
+
+	// An address with a correct checksum for version 2.
+	b := append(append([]byte(nil), publicKey...), onionChecksum(publicKey, 2)...)
+	b = append(b, 2)
+	v2 := strings.ToLower(base32NoPadding.EncodeToString(b)) + ".onion"
+	if _, err := ParseOnionAddress(v2); err != ErrOnionVersion {
+		t.Errorf("version 2 address gave %v", err)
+	}
+
+	// A small order point, with a valid checksum.
+	small := make(PublicKey, PublicKeySize)
+	small[0] = 1
+	smallAddress, _ := OnionAddressFromPublicKey(small)
+	if _, err := ParseOnionAddress(smallAddress); err != ErrInvalidPublicKey {
+		t.Errorf("identity point gave %v", err)
+	}
+
+	for _, bad := range []string{
+		"",
+		".onion",
+		strings.ToUpper(address[:56]) + ".onion",
+		"www." + address,
+		address[1:],
+		"a" + address,
+		address + ".onion",
+		address[:20] + "1" + address[21:],
+		// A v2 address.
+		"expyuzz4wqqyqhjn.onion",
+	} {
+		if _, err := ParseOnionAddress(bad); err != errBadOnion {
+			t.Errorf("ParseOnionAddress(%q) gave %v", bad, err)
+		}
+	}
+}
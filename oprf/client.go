@@ -0,0 +1,109 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package oprf
+
+import (
+	"io"
+
+	"github.com/agl/ed25519/edwards25519"
+	"github.com/agl/ed25519/ristretto255"
+)
+
+// Client is the client of the protocol, which learns the outputs.
+type Client struct {
+	mode Mode
+	pk   *ristretto255.Element
+}
+
+// NewClient returns a client of the base OPRF mode.
+func NewClient() *Client {
+	return &Client{mode: ModeOPRF}
+}
+
+// NewVerifiableClient returns a client of the VOPRF mode, which verifies the
+// evaluations of the server with the public key pk.
+func NewVerifiableClient(pk *PublicKey) *Client {
+	return &Client{mode: ModeVOPRF, pk: new(ristretto255.Element).Set(pk.e)}
+}
+
+// Blinded is the state of the client for one input between Blind and
+// Finalize. It holds the secret blind, and must not be sent to the server.
+type Blinded struct {
+	input   []byte
+	blind   *edwards25519.Scalar
+	element *ristretto255.Element
+}
+
+// Element returns the serialized blinded element to send to the server.
+func (b *Blinded) Element() []byte {
+	return b.element.Bytes()
+}
+
+// Blind blinds input with a blind from rand. If rand is nil,
+// crypto/rand.Reader will be used. It returns ErrInvalidInput if input
+// hashes to the identity.
+func (c *Client) Blind(input []byte, rand io.Reader) (*Blinded, error) {
+	blind, err := randomScalar(rand)
+	if err != nil {
+		return nil, err
+	}
+	return c.blind(input, blind)
+}
+
+func (c *Client) blind(input []byte, blind *edwards25519.Scalar) (*Blinded, error) {
+	p := hashToGroup(c.mode, input)
+	if p.Equal(identity) == 1 {
+		return nil, ErrInvalidInput
+	}
+	return &Blinded{
+		input:   append([]byte(nil), input...),
+		blind:   blind,
+		element: new(ristretto255.Element).ScalarMult(blind, p),
+	}, nil
+}
+
+// Finalize returns the output for the input of b from the evaluated element
+// of the server. A verifiable client also checks the proof, and returns
+// ErrVerify if it doesn't verify; the base mode ignores it.
+func (c *Client) Finalize(b *Blinded, evaluatedElement, proof []byte) ([]byte, error) {
+	outputs, err := c.FinalizeBatch([]*Blinded{b}, [][]byte{evaluatedElement}, proof)
+	if err != nil {
+		return nil, err
+	}
+	return outputs[0], nil
+}
+
+// FinalizeBatch is Finalize for the inputs of bs, whose evaluated elements
+// were returned in order by one BlindEvaluateBatch, with one proof.
+func (c *Client) FinalizeBatch(bs []*Blinded, evaluatedElements [][]byte, proof []byte) ([][]byte, error) {
+	if len(bs) != len(evaluatedElements) {
+		return nil, errBatchSizes
+	}
+	evaluated := make([]*ristretto255.Element, len(bs))
+	for i, b := range evaluatedElements {
+		e, err := deserializeElement(b)
+		if err != nil {
+			return nil, err
+		}
+		evaluated[i] = e
+	}
+	if c.mode == ModeVOPRF {
+		blinded := make([]*ristretto255.Element, len(bs))
+		for i, b := range bs {
+			blinded[i] = b.element
+		}
+		if !verifyProof(c.mode, c.pk, blinded, evaluated, proof) {
+			return nil, ErrVerify
+		}
+	}
+
+	outputs := make([][]byte, len(bs))
+	for i, b := range bs {
+		inverse := edwards25519.NewScalar().Invert(b.blind)
+		n := new(ristretto255.Element).ScalarMult(inverse, evaluated[i])
+		outputs[i] = finalizeHash(b.input, n)
+	}
+	return outputs, nil
+}
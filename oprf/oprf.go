@@ -0,0 +1,237 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package oprf implements the oblivious pseudorandom functions of RFC 9497
+// with the ristretto255-SHA512 suite, in the base OPRF mode and the
+// verifiable VOPRF mode.
+//
+// A client blinds its input and sends the blinded element to the server,
+// which evaluates it under its private key without learning the input. The
+// client unblinds the result and hashes it to the output, which the server
+// can compute itself from the input with Evaluate. In the verifiable mode the
+// server also proves, with a DLEQ proof, that it used the private key of a
+// public key known to the client.
+package oprf
+
+import (
+	cryptorand "crypto/rand"
+	"crypto/sha512"
+	"errors"
+	"io"
+
+	"github.com/agl/ed25519/edwards25519"
+	"github.com/agl/ed25519/internal/xmd"
+	"github.com/agl/ed25519/ristretto255"
+)
+
+// Mode is an RFC 9497 protocol variant.
+type Mode byte
+
+const (
+	// ModeOPRF is the base mode, modeOPRF.
+	ModeOPRF Mode = 0x00
+	// ModeVOPRF is the verifiable mode, modeVOPRF, in which the server
+	// proves its evaluations.
+	ModeVOPRF Mode = 0x01
+)
+
+const (
+	// ElementSize is the size, in bytes, of serialized blinded and evaluated
+	// elements, and of public keys.
+	ElementSize = 32
+	// ScalarSize is the size, in bytes, of serialized private keys.
+	ScalarSize = 32
+	// ProofSize is the size, in bytes, of a serialized VOPRF proof.
+	ProofSize = 64
+	// OutputSize is the size, in bytes, of an OPRF output.
+	OutputSize = sha512.Size
+)
+
+var (
+	// ErrInvalidInput is returned for an input that hashes to the identity.
+	// This happens with negligible probability.
+	ErrInvalidInput = errors.New("oprf: input hashes to the identity element")
+	// ErrVerify is returned by Finalize when the proof of a VOPRF server
+	// doesn't verify.
+	ErrVerify = errors.New("oprf: proof verification failed")
+	// ErrDeserialize is returned for an element or scalar that is malformed,
+	// or that is the identity element or zero.
+	ErrDeserialize = errors.New("oprf: invalid serialized element or scalar")
+	// ErrDeriveKeyPair is returned by DeriveKeyPair in the negligibly likely
+	// case that no key can be derived.
+	ErrDeriveKeyPair = errors.New("oprf: key pair derivation failed")
+
+	errMode       = errors.New("oprf: unsupported mode")
+	errBatchSizes = errors.New("oprf: mismatched batch sizes")
+)
+
+// contextString returns the contextString of RFC 9497, Section 3.1, for the
+// ristretto255-SHA512 suite in mode.
+func contextString(mode Mode) []byte {
+	return append([]byte{'O', 'P', 'R', 'F', 'V', '1', '-', byte(mode), '-'}, "ristretto255-SHA512"...)
+}
+
+func checkMode(mode Mode) error {
+	if mode != ModeOPRF && mode != ModeVOPRF {
+		return errMode
+	}
+	return nil
+}
+
+// hashToGroup implements HashToGroup, hash_to_ristretto255 of RFC 9380 with
+// the DST "HashToGroup-" || contextString.
+func hashToGroup(mode Mode, msg []byte) *ristretto255.Element {
+	uniform, err := xmd.Expand(sha512.New, msg, append([]byte("HashToGroup-"), contextString(mode)...), 64)
+	if err != nil {
+		panic("oprf: internal error: " + err.Error())
+	}
+	e, _ := new(ristretto255.Element).SetUniformBytes(uniform)
+	return e
+}
+
+// hashToScalar implements HashToScalar, with the DST "HashToScalar-" ||
+// contextString unless dst is given.
+func hashToScalar(mode Mode, msg, dst []byte) *edwards25519.Scalar {
+	if dst == nil {
+		dst = append([]byte("HashToScalar-"), contextString(mode)...)
+	}
+	uniform, err := xmd.Expand(sha512.New, msg, dst, 64)
+	if err != nil {
+		panic("oprf: internal error: " + err.Error())
+	}
+	s, _ := edwards25519.NewScalar().SetUniformBytes(uniform)
+	return s
+}
+
+// randomScalar returns a uniformly random non-zero scalar from rand, or from
+// crypto/rand.Reader if rand is nil.
+func randomScalar(rand io.Reader) (*edwards25519.Scalar, error) {
+	if rand == nil {
+		rand = cryptorand.Reader
+	}
+	var b [64]byte
+	zero := edwards25519.NewScalar()
+	for {
+		if _, err := io.ReadFull(rand, b[:]); err != nil {
+			return nil, err
+		}
+		s, _ := edwards25519.NewScalar().SetUniformBytes(b[:])
+		if s.Equal(zero) != 1 {
+			return s, nil
+		}
+	}
+}
+
+var identity = ristretto255.NewIdentityElement()
+
+// deserializeElement implements DeserializeElement, which rejects the
+// identity.
+func deserializeElement(b []byte) (*ristretto255.Element, error) {
+	e, err := new(ristretto255.Element).SetCanonicalBytes(b)
+	if err != nil || e.Equal(identity) == 1 {
+		return nil, ErrDeserialize
+	}
+	return e, nil
+}
+
+// lengthPrefixed appends each of parts to b, after its length as two
+// big-endian bytes, as the I2OSP(len(x), 2) || x of RFC 9497.
+func lengthPrefixed(b []byte, parts ...[]byte) []byte {
+	for _, p := range parts {
+		b = append(b, byte(len(p)>>8), byte(len(p)))
+		b = append(b, p...)
+	}
+	return b
+}
+
+// finalizeHash returns the OPRF output of input with the unblinded element
+// n, Hash(len || input || len || n || "Finalize").
+func finalizeHash(input []byte, n *ristretto255.Element) []byte {
+	h := sha512.Sum512(append(lengthPrefixed(nil, input, n.Bytes()), "Finalize"...))
+	return h[:]
+}
+
+// PrivateKey is the private key of a server, with its public key.
+type PrivateKey struct {
+	k  *edwards25519.Scalar
+	pk *ristretto255.Element
+}
+
+func newPrivateKey(k *edwards25519.Scalar) *PrivateKey {
+	return &PrivateKey{k: k, pk: new(ristretto255.Element).ScalarBaseMult(k)}
+}
+
+// GenerateKey generates a private key using entropy from rand. If rand is
+// nil, crypto/rand.Reader will be used.
+func GenerateKey(rand io.Reader) (*PrivateKey, error) {
+	k, err := randomScalar(rand)
+	if err != nil {
+		return nil, err
+	}
+	return newPrivateKey(k), nil
+}
+
+// DeriveKeyPair derives a private key for mode from seed and the public info
+// string info, as in RFC 9497, Section 3.2.1. The seed should be 32 uniformly
+// random bytes; the key is only as secret as the seed.
+func DeriveKeyPair(mode Mode, seed, info []byte) (*PrivateKey, error) {
+	if err := checkMode(mode); err != nil {
+		return nil, err
+	}
+	if len(info) > 65535 {
+		return nil, ErrDeriveKeyPair
+	}
+	dst := append([]byte("DeriveKeyPair"), contextString(mode)...)
+	deriveInput := lengthPrefixed(append([]byte(nil), seed...), info)
+	zero := edwards25519.NewScalar()
+	for counter := 0; counter <= 255; counter++ {
+		k := hashToScalar(mode, append(deriveInput, byte(counter)), dst)
+		if k.Equal(zero) != 1 {
+			return newPrivateKey(k), nil
+		}
+	}
+	return nil, ErrDeriveKeyPair
+}
+
+// NewPrivateKey returns the private key serialized as b by Bytes. It returns
+// ErrDeserialize if b is not the canonical encoding of a non-zero scalar.
+func NewPrivateKey(b []byte) (*PrivateKey, error) {
+	k, err := edwards25519.NewScalar().SetCanonicalBytes(b)
+	if err != nil || k.Equal(edwards25519.NewScalar()) == 1 {
+		return nil, ErrDeserialize
+	}
+	return newPrivateKey(k), nil
+}
+
+// Bytes returns the ScalarSize-byte serialization of k.
+func (k *PrivateKey) Bytes() []byte {
+	return k.k.Bytes()
+}
+
+// Public returns the public key of k.
+func (k *PrivateKey) Public() *PublicKey {
+	return &PublicKey{e: new(ristretto255.Element).Set(k.pk)}
+}
+
+// PublicKey is the public key of a server, which VOPRF clients verify its
+// evaluations against.
+type PublicKey struct {
+	e *ristretto255.Element
+}
+
+// NewPublicKey returns the public key serialized as b by Bytes. It returns
+// ErrDeserialize if b is not the encoding of an element other than the
+// identity.
+func NewPublicKey(b []byte) (*PublicKey, error) {
+	e, err := deserializeElement(b)
+	if err != nil {
+		return nil, err
+	}
+	return &PublicKey{e: e}, nil
+}
+
+// Bytes returns the ElementSize-byte serialization of pk.
+func (pk *PublicKey) Bytes() []byte {
+	return pk.e.Bytes()
+}
@@ -0,0 +1,369 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package oprf
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/agl/ed25519/edwards25519"
+	"github.com/agl/ed25519/internal/dleq"
+	"github.com/agl/ed25519/ristretto255"
+)
+
+// The test vectors of the ristretto255-SHA512 suite, from RFC 9497,
+// Appendix A.1. Every test derives its key from the same seed and info.
+const (
+	vectorSeed = "a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3"
+	vectorInfo = "74657374206b6579"
+)
+
+type vectorTest struct {
+	inputs, blinds, blinded, evaluated, outputs []string
+	proof, proofRandom                          string
+}
+
+var oprfTests = struct {
+	sk    string
+	tests []vectorTest
+}{
+	"5ebcea5ee37023ccb9fc2d2019f9d7737be85591ae8652ffa9ef0f4d37063b0e",
+	[]vectorTest{
+		{
+			inputs:    []string{"00"},
+			blinds:    []string{"64d37aed22a27f5191de1c1d69fadb899d8862b58eb4220029e036ec4c1f6706"},
+			blinded:   []string{"609a0ae68c15a3cf6903766461307e5c8bb2f95e7e6550e1ffa2dc99e412803c"},
+			evaluated: []string{"7ec6578ae5120958eb2db1745758ff379e77cb64fe77b0b2d8cc917ea0869c7e"},
+			outputs:   []string{"527759c3d9366f277d8c6020418d96bb393ba2afb20ff90df23fb7708264e2f3ab9135e3bd69955851de4b1f9fe8a0973396719b7912ba9ee8aa7d0b5e24bcf6"},
+		},
+		{
+			inputs:    []string{"5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a"},
+			blinds:    []string{"64d37aed22a27f5191de1c1d69fadb899d8862b58eb4220029e036ec4c1f6706"},
+			blinded:   []string{"da27ef466870f5f15296299850aa088629945a17d1f5b7f5ff043f76b3c06418"},
+			evaluated: []string{"b4cbf5a4f1eeda5a63ce7b77c7d23f461db3fcab0dd28e4e17cecb5c90d02c25"},
+			outputs:   []string{"f4a74c9c592497375e796aa837e907b1a045d34306a749db9f34221f7e750cb4f2a6413a6bf6fa5e19ba6348eb673934a722a7ede2e7621306d18951e7cf2c73"},
+		},
+	},
+}
+
+var voprfTests = struct {
+	sk, pk string
+	tests  []vectorTest
+}{
+	"e6f73f344b79b379f1a0dd37e07ff62e38d9f71345ce62ae3a9bc60b04ccd909",
+	"c803e2cc6b05fc15064549b5920659ca4a77b2cca6f04f6b357009335476ad4e",
+	[]vectorTest{
+		{
+			inputs:      []string{"00"},
+			blinds:      []string{"64d37aed22a27f5191de1c1d69fadb899d8862b58eb4220029e036ec4c1f6706"},
+			blinded:     []string{"863f330cc1a1259ed5a5998a23acfd37fb4351a793a5b3c090b642ddc439b945"},
+			evaluated:   []string{"aa8fa048764d5623868679402ff6108d2521884fa138cd7f9c7669a9a014267e"},
+			proof:       "ddef93772692e535d1a53903db24367355cc2cc78de93b3be5a8ffcc6985dd066d4346421d17bf5117a2a1ff0fcb2a759f58a539dfbe857a40bce4cf49ec600d",
+			proofRandom: "222a5e897cf59db8145db8d16e597e8facb80ae7d4e26d9881aa6f61d645fc0e",
+			outputs:     []string{"b58cfbe118e0cb94d79b5fd6a6dafb98764dff49c14e1770b566e42402da1a7da4d8527693914139caee5bd03903af43a491351d23b430948dd50cde10d32b3c"},
+		},
+		{
+			inputs:      []string{"5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a"},
+			blinds:      []string{"64d37aed22a27f5191de1c1d69fadb899d8862b58eb4220029e036ec4c1f6706"},
+			blinded:     []string{"cc0b2a350101881d8a4cba4c80241d74fb7dcbfde4a61fde2f91443c2bf9ef0c"},
+			evaluated:   []string{"60a59a57208d48aca71e9e850d22674b611f752bed48b36f7a91b372bd7ad468"},
+			proof:       "401a0da6264f8cf45bb2f5264bc31e109155600babb3cd4e5af7d181a2c9dc0a67154fabf031fd936051dec80b0b6ae29c9503493dde7393b722eafdf5a50b02",
+			proofRandom: "222a5e897cf59db8145db8d16e597e8facb80ae7d4e26d9881aa6f61d645fc0e",
+			outputs:     []string{"8a9a2f3c7f085b65933594309041fc1898d42d0858e59f90814ae90571a6df60356f4610bf816f27afdd84f47719e480906d27ecd994985890e5f539e7ea74b6"},
+		},
+		{
+			inputs: []string{"00", "5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a"},
+			blinds: []string{
+				"64d37aed22a27f5191de1c1d69fadb899d8862b58eb4220029e036ec4c1f6706",
+				"222a5e897cf59db8145db8d16e597e8facb80ae7d4e26d9881aa6f61d645fc0e",
+			},
+			blinded: []string{
+				"863f330cc1a1259ed5a5998a23acfd37fb4351a793a5b3c090b642ddc439b945",
+				"90a0145ea9da29254c3a56be4fe185465ebb3bf2a1801f7124bbbadac751e654",
+			},
+			evaluated: []string{
+				"aa8fa048764d5623868679402ff6108d2521884fa138cd7f9c7669a9a014267e",
+				"cc5ac221950a49ceaa73c8db41b82c20372a4c8d63e5dded2db920b7eee36a2a",
+			},
+			proof:       "cc203910175d786927eeb44ea847328047892ddf8590e723c37205cb74600b0a5ab5337c8eb4ceae0494c2cf89529dcf94572ed267473d567aeed6ab873dee08",
+			proofRandom: "419c4f4f5052c53c45f3da494d2b67b220d02118e0857cdbcf037f9ea84bbe0c",
+			outputs: []string{
+				"b58cfbe118e0cb94d79b5fd6a6dafb98764dff49c14e1770b566e42402da1a7da4d8527693914139caee5bd03903af43a491351d23b430948dd50cde10d32b3c",
+				"8a9a2f3c7f085b65933594309041fc1898d42d0858e59f90814ae90571a6df60356f4610bf816f27afdd84f47719e480906d27ecd994985890e5f539e7ea74b6",
+			},
+		},
+	},
+}
+
+func decodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func decodeScalar(t *testing.T, s string) *edwards25519.Scalar {
+	t.Helper()
+	x, err := edwards25519.NewScalar().SetCanonicalBytes(decodeHex(t, s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return x
+}
+
+func deriveVectorKey(t *testing.T, mode Mode) *PrivateKey {
+	t.Helper()
+	key, err := DeriveKeyPair(mode, decodeHex(t, vectorSeed), decodeHex(t, vectorInfo))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestDeriveKeyPair(t *testing.T) {
+	if got := hex.EncodeToString(deriveVectorKey(t, ModeOPRF).Bytes()); got != oprfTests.sk {
+		t.Errorf("OPRF: got private key %s, want %s", got, oprfTests.sk)
+	}
+	key := deriveVectorKey(t, ModeVOPRF)
+	if got := hex.EncodeToString(key.Bytes()); got != voprfTests.sk {
+		t.Errorf("VOPRF: got private key %s, want %s", got, voprfTests.sk)
+	}
+	if got := hex.EncodeToString(key.Public().Bytes()); got != voprfTests.pk {
+		t.Errorf("VOPRF: got public key %s, want %s", got, voprfTests.pk)
+	}
+	if _, err := DeriveKeyPair(Mode(2), nil, nil); err == nil {
+		t.Error("unknown mode accepted")
+	}
+}
+
+// runVector runs test between client and server, and checks every message
+// and output against it.
+func runVector(t *testing.T, i int, client *Client, server *Server, test vectorTest) {
+	var bs []*Blinded
+	var blinded [][]byte
+	for j, input := range test.inputs {
+		b, err := client.blind(decodeHex(t, input), decodeScalar(t, test.blinds[j]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(b.Element()); got != test.blinded[j] {
+			t.Errorf("%d: got blinded element %s, want %s", i, got, test.blinded[j])
+		}
+		bs = append(bs, b)
+		blinded = append(blinded, b.Element())
+	}
+
+	var r *edwards25519.Scalar
+	if test.proofRandom != "" {
+		r = decodeScalar(t, test.proofRandom)
+	}
+	evaluated, proof, err := server.blindEvaluate(blinded, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for j := range evaluated {
+		if got := hex.EncodeToString(evaluated[j]); got != test.evaluated[j] {
+			t.Errorf("%d: got evaluated element %s, want %s", i, got, test.evaluated[j])
+		}
+	}
+	if got := hex.EncodeToString(proof); got != test.proof {
+		t.Errorf("%d: got proof %s, want %s", i, got, test.proof)
+	}
+
+	outputs, err := client.FinalizeBatch(bs, evaluated, proof)
+	if err != nil {
+		t.Fatalf("%d: %v", i, err)
+	}
+	for j, input := range test.inputs {
+		if got := hex.EncodeToString(outputs[j]); got != test.outputs[j] {
+			t.Errorf("%d: got output %s, want %s", i, got, test.outputs[j])
+		}
+		direct, err := server.Evaluate(decodeHex(t, input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(direct, outputs[j]) {
+			t.Errorf("%d: Evaluate disagrees with Finalize", i)
+		}
+	}
+}
+
+func TestOPRFVectors(t *testing.T) {
+	server := NewServer(deriveVectorKey(t, ModeOPRF))
+	for i, test := range oprfTests.tests {
+		runVector(t, i, NewClient(), server, test)
+	}
+}
+
+func TestVOPRFVectors(t *testing.T) {
+	pk, err := NewPublicKey(decodeHex(t, voprfTests.pk))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := NewVerifiableServer(deriveVectorKey(t, ModeVOPRF))
+	for i, test := range voprfTests.tests {
+		runVector(t, i, NewVerifiableClient(pk), server, test)
+	}
+}
+
+func TestVOPRFRejectsBadProofs(t *testing.T) {
+	key, err := GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := NewVerifiableServer(key)
+	client := NewVerifiableClient(key.Public())
+	b1, _ := client.Blind([]byte("first input"), nil)
+	b2, _ := client.Blind([]byte("second input"), nil)
+	bs := []*Blinded{b1, b2}
+	evaluated, proof, err := server.BlindEvaluateBatch([][]byte{b1.Element(), b2.Element()}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.FinalizeBatch(bs, evaluated, proof); err != nil {
+		t.Fatalf("valid proof rejected: %v", err)
+	}
+
+	for _, i := range []int{0, 31, 32, 63} {
+		bad := append([]byte(nil), proof...)
+		bad[i] ^= 1
+		if _, err := client.FinalizeBatch(bs, evaluated, bad); err != ErrVerify {
+			t.Errorf("proof with byte %d altered: got %v", i, err)
+		}
+	}
+	if _, err := client.FinalizeBatch(bs, evaluated, proof[:ProofSize-1]); err != ErrVerify {
+		t.Errorf("short proof: got %v", err)
+	}
+	if _, err := client.FinalizeBatch(bs, evaluated, nil); err != ErrVerify {
+		t.Errorf("missing proof: got %v", err)
+	}
+
+	// A server evaluating with another key can't prove it used key.
+	other, _ := GenerateKey(nil)
+	otherEvaluated, otherProof, _ := NewVerifiableServer(other).BlindEvaluateBatch([][]byte{b1.Element(), b2.Element()}, nil)
+	if _, err := client.FinalizeBatch(bs, otherEvaluated, otherProof); err != ErrVerify {
+		t.Errorf("evaluation under another key: got %v", err)
+	}
+	if _, err := client.FinalizeBatch(bs, otherEvaluated, proof); err != ErrVerify {
+		t.Errorf("evaluation under another key with the proof of key: got %v", err)
+	}
+
+	// The proof covers the batch in order.
+	swapped := [][]byte{evaluated[1], evaluated[0]}
+	if _, err := client.FinalizeBatch(bs, swapped, proof); err != ErrVerify {
+		t.Errorf("swapped evaluations: got %v", err)
+	}
+	if _, err := client.Finalize(b1, evaluated[0], proof); err != ErrVerify {
+		t.Errorf("part of a batch: got %v", err)
+	}
+}
+
+// TestProofIsDLEQ checks a VOPRF proof against package internal/dleq
+// directly: it is a Chaum-Pedersen proof that log_G(B) = log_M(Z), with the
+// challenge of RFC 9497.
+func TestProofIsDLEQ(t *testing.T) {
+	key, _ := GenerateKey(nil)
+	var C, D []*ristretto255.Element
+	for _, input := range []string{"first input", "second input"} {
+		c := hashToGroup(ModeVOPRF, []byte(input))
+		C = append(C, c)
+		D = append(D, new(ristretto255.Element).ScalarMult(key.k, c))
+	}
+	r := hashToScalar(ModeVOPRF, []byte("proof randomness"), nil)
+	proof := generateProof(ModeVOPRF, key, C, D, r)
+
+	M, Z := computeComposites(ModeVOPRF, nil, key.pk, C, D)
+	ch := func(t2, t3 *ristretto255.Element) *edwards25519.Scalar {
+		return challenge(ModeVOPRF, key.pk, M, Z, t2, t3)
+	}
+	c, s := dleq.Prove(key.k, r, ristretto255.NewGeneratorElement(), M, ch)
+	if want := append(c.Bytes(), s.Bytes()...); !bytes.Equal(proof, want) {
+		t.Fatalf("generateProof gave %x, dleq.Prove %x", proof, want)
+	}
+	if !dleq.Verify(ristretto255.NewGeneratorElement(), M, key.pk, Z, c, s, ch) {
+		t.Error("dleq.Verify rejected the proof")
+	}
+	if !verifyProof(ModeVOPRF, key.pk, C, D, proof) {
+		t.Error("verifyProof rejected the proof")
+	}
+}
+
+func TestBadElements(t *testing.T) {
+	key, _ := GenerateKey(nil)
+	identity := make([]byte, ElementSize)
+	invalid := bytes.Repeat([]byte{0xff}, ElementSize)
+	for _, server := range []*Server{NewServer(key), NewVerifiableServer(key)} {
+		for _, b := range [][]byte{identity, invalid, identity[:31]} {
+			if _, _, err := server.BlindEvaluate(b, nil); err != ErrDeserialize {
+				t.Errorf("BlindEvaluate(%x): got %v", b, err)
+			}
+		}
+	}
+
+	client := NewClient()
+	b, _ := client.Blind([]byte("input"), nil)
+	for _, e := range [][]byte{identity, invalid} {
+		if _, err := client.Finalize(b, e, nil); err != ErrDeserialize {
+			t.Errorf("Finalize(%x): got %v", e, err)
+		}
+	}
+	if _, err := client.FinalizeBatch([]*Blinded{b}, nil, nil); err != errBatchSizes {
+		t.Errorf("mismatched batch: got %v", err)
+	}
+
+	if _, err := NewPublicKey(identity); err != ErrDeserialize {
+		t.Errorf("identity public key: got %v", err)
+	}
+	if _, err := NewPrivateKey(make([]byte, ScalarSize)); err != ErrDeserialize {
+		t.Errorf("zero private key: got %v", err)
+	}
+	if _, err := NewPrivateKey(invalid); err != ErrDeserialize {
+		t.Errorf("non-canonical private key: got %v", err)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	key, err := GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := NewPrivateKey(key.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := []byte("test input")
+	for _, mode := range []Mode{ModeOPRF, ModeVOPRF} {
+		client, server := NewClient(), NewServer(decoded)
+		if mode == ModeVOPRF {
+			client, server = NewVerifiableClient(key.Public()), NewVerifiableServer(decoded)
+		}
+		b, err := client.Blind(input, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		evaluated, proof, err := server.BlindEvaluate(b.Element(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		output, err := client.Finalize(b, evaluated, proof)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(output) != OutputSize {
+			t.Errorf("mode %d: output is %d bytes", mode, len(output))
+		}
+		direct, _ := server.Evaluate(input)
+		if !bytes.Equal(output, direct) {
+			t.Errorf("mode %d: Finalize disagrees with Evaluate", mode)
+		}
+		other, _ := client.Blind(input, nil)
+		if bytes.Equal(other.Element(), b.Element()) {
+			t.Errorf("mode %d: two blindings of the input are equal", mode)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package oprf
+
+import (
+	"crypto/sha512"
+
+	"github.com/agl/ed25519/edwards25519"
+	"github.com/agl/ed25519/internal/dleq"
+	"github.com/agl/ed25519/ristretto255"
+)
+
+// The VOPRF proof is the discrete log equality proof of RFC 9497, Section
+// 2.2: that the private key k of the public key B = kA, where A is the
+// generator, also takes each blinded element C[i] to the evaluated element
+// D[i]. The pairs are first combined into one, M = sum(d[i] * C[i]) and
+// Z = sum(d[i] * D[i]) = kM, with weights d[i] that are hashed from all of
+// them, so one proof covers a whole batch. The proof itself is computed by
+// package internal/dleq, as are the DLEQ proofs of package zkp.
+
+// computeComposites returns M and Z for the batch C, D of the public key B.
+// If k is not nil, Z is computed as kM, as the server can.
+func computeComposites(mode Mode, k *edwards25519.Scalar, B *ristretto255.Element, C, D []*ristretto255.Element) (M, Z *ristretto255.Element) {
+	seedDST := append([]byte("Seed-"), contextString(mode)...)
+	seed := sha512.Sum512(lengthPrefixed(nil, B.Bytes(), seedDST))
+
+	M = ristretto255.NewIdentityElement()
+	Z = ristretto255.NewIdentityElement()
+	for i := range C {
+		transcript := lengthPrefixed(nil, seed[:])
+		transcript = append(transcript, byte(i>>8), byte(i))
+		transcript = lengthPrefixed(transcript, C[i].Bytes(), D[i].Bytes())
+		transcript = append(transcript, "Composite"...)
+		di := hashToScalar(mode, transcript, nil)
+		M.Add(M, new(ristretto255.Element).ScalarMult(di, C[i]))
+		if k == nil {
+			Z.Add(Z, new(ristretto255.Element).ScalarMult(di, D[i]))
+		}
+	}
+	if k != nil {
+		Z.ScalarMult(k, M)
+	}
+	return M, Z
+}
+
+// challenge returns the challenge scalar c of a proof with the commitments
+// t2 = rA and t3 = rM.
+func challenge(mode Mode, B, M, Z, t2, t3 *ristretto255.Element) *edwards25519.Scalar {
+	transcript := lengthPrefixed(nil, B.Bytes(), M.Bytes(), Z.Bytes(), t2.Bytes(), t3.Bytes())
+	return hashToScalar(mode, append(transcript, "Challenge"...), nil)
+}
+
+// generateProof implements GenerateProof with the generator as A, the
+// public key of k as B, and the proof randomness r.
+func generateProof(mode Mode, k *PrivateKey, C, D []*ristretto255.Element, r *edwards25519.Scalar) []byte {
+	M, Z := computeComposites(mode, k.k, k.pk, C, D)
+	c, s := dleq.Prove(k.k, r, ristretto255.NewGeneratorElement(), M, func(t2, t3 *ristretto255.Element) *edwards25519.Scalar {
+		return challenge(mode, k.pk, M, Z, t2, t3)
+	})
+	return append(c.Bytes(), s.Bytes()...)
+}
+
+// verifyProof implements VerifyProof with the generator as A and the public
+// key B.
+func verifyProof(mode Mode, B *ristretto255.Element, C, D []*ristretto255.Element, proof []byte) bool {
+	if len(proof) != ProofSize {
+		return false
+	}
+	c, err := edwards25519.NewScalar().SetCanonicalBytes(proof[:32])
+	if err != nil {
+		return false
+	}
+	s, err := edwards25519.NewScalar().SetCanonicalBytes(proof[32:])
+	if err != nil {
+		return false
+	}
+	M, Z := computeComposites(mode, nil, B, C, D)
+	return dleq.Verify(ristretto255.NewGeneratorElement(), M, B, Z, c, s, func(t2, t3 *ristretto255.Element) *edwards25519.Scalar {
+		return challenge(mode, B, M, Z, t2, t3)
+	})
+}
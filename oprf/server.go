@@ -0,0 +1,83 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package oprf
+
+import (
+	"io"
+
+	"github.com/agl/ed25519/edwards25519"
+	"github.com/agl/ed25519/ristretto255"
+)
+
+// Server is the server of the protocol, which holds the private key.
+type Server struct {
+	mode Mode
+	key  *PrivateKey
+}
+
+// NewServer returns a server of the base OPRF mode with the private key key.
+func NewServer(key *PrivateKey) *Server {
+	return &Server{mode: ModeOPRF, key: key}
+}
+
+// NewVerifiableServer returns a server of the VOPRF mode with the private
+// key key, whose clients know key.Public().
+func NewVerifiableServer(key *PrivateKey) *Server {
+	return &Server{mode: ModeVOPRF, key: key}
+}
+
+// BlindEvaluate evaluates the blinded element of a client. In the VOPRF
+// mode it also returns a proof, made with randomness from rand, or from
+// crypto/rand.Reader if rand is nil; in the base mode proof is nil and rand
+// is unused.
+func (s *Server) BlindEvaluate(blindedElement []byte, rand io.Reader) (evaluatedElement, proof []byte, err error) {
+	evaluated, proof, err := s.BlindEvaluateBatch([][]byte{blindedElement}, rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	return evaluated[0], proof, nil
+}
+
+// BlindEvaluateBatch is BlindEvaluate for several blinded elements of one
+// client, with a single proof for all of them.
+func (s *Server) BlindEvaluateBatch(blindedElements [][]byte, rand io.Reader) (evaluatedElements [][]byte, proof []byte, err error) {
+	var r *edwards25519.Scalar
+	if s.mode == ModeVOPRF {
+		if r, err = randomScalar(rand); err != nil {
+			return nil, nil, err
+		}
+	}
+	return s.blindEvaluate(blindedElements, r)
+}
+
+func (s *Server) blindEvaluate(blindedElements [][]byte, r *edwards25519.Scalar) ([][]byte, []byte, error) {
+	blinded := make([]*ristretto255.Element, len(blindedElements))
+	evaluated := make([]*ristretto255.Element, len(blindedElements))
+	evaluatedElements := make([][]byte, len(blindedElements))
+	for i, b := range blindedElements {
+		e, err := deserializeElement(b)
+		if err != nil {
+			return nil, nil, err
+		}
+		blinded[i] = e
+		evaluated[i] = new(ristretto255.Element).ScalarMult(s.key.k, e)
+		evaluatedElements[i] = evaluated[i].Bytes()
+	}
+	if s.mode != ModeVOPRF {
+		return evaluatedElements, nil, nil
+	}
+	return evaluatedElements, generateProof(s.mode, s.key, blinded, evaluated, r), nil
+}
+
+// Evaluate returns the output for input directly, as a client would get it
+// from this server. It returns ErrInvalidInput if input hashes to the
+// identity.
+func (s *Server) Evaluate(input []byte) ([]byte, error) {
+	p := hashToGroup(s.mode, input)
+	if p.Equal(identity) == 1 {
+		return nil, ErrInvalidInput
+	}
+	return finalizeHash(input, new(ristretto255.Element).ScalarMult(s.key.k, p)), nil
+}
@@ -0,0 +1,137 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"crypto"
+	"errors"
+	"strconv"
+)
+
+// Options can be used with PrivateKey.Sign, SignWithRand and
+// VerifyWithOptions to select Ed25519 variants.
+type Options struct {
+	// Hash can be zero for regular Ed25519, or crypto.SHA512 for Ed25519ph.
+	//
+	// It can also be crypto.BLAKE2b_512, if AllowNonStandardPrehash is set,
+	// for the variant of Ed25519ph used by some existing systems in which
+	// the message is prehashed with BLAKE2b-512 instead of SHA-512.
+	Hash crypto.Hash
+
+	// Context, if not empty, selects Ed25519ctx or provides the context
+	// string for Ed25519ph. It can be at most 255 bytes in length.
+	Context string
+
+	// Mode selects the verification rules used by VerifyWithOptions. It
+	// is ignored when signing.
+	Mode VerifyMode
+
+	// AllowNonStandardPrehash enables Hash to be crypto.BLAKE2b_512. This
+	// variant is not part of RFC 8032 and should only be used to
+	// interoperate with systems that require it. Only the prehash
+	// changes: the message passed to Sign or VerifyWithOptions is the
+	// 64-byte BLAKE2b-512 digest, and the nonce and challenge are still
+	// computed with SHA-512 exactly as for Ed25519ph, with the same dom2
+	// prefix. A signature of a BLAKE2b digest is therefore also a valid
+	// Ed25519ph signature of those 64 bytes taken as a SHA-512 digest;
+	// what they hash is up to the protocol to fix.
+	AllowNonStandardPrehash bool
+}
+
+// VerifyMode selects one of the sets of verification rules implemented by
+// this package.
+type VerifyMode int
+
+const (
+	// ModeDefault applies the rules of Verify, which are also those of
+	// crypto/ed25519.
+	ModeDefault VerifyMode = iota
+	// ModeCofactored applies the rules of VerifyCofactored.
+	ModeCofactored
+	// ModeZIP215 applies the rules of VerifyZIP215.
+	ModeZIP215
+	// ModeStrict applies the rules of StrictVerify.
+	ModeStrict
+)
+
+// rules returns the verification rules selected by o, which may be nil.
+func (o *Options) rules() (verifyRules, error) {
+	if o == nil {
+		return verifyRules{}, nil
+	}
+
+	switch o.Mode {
+	case ModeDefault:
+		return verifyRules{}, nil
+	case ModeCofactored:
+		return verifyRules{cofactored: true}, nil
+	case ModeZIP215:
+		return verifyRules{cofactored: true, nonCanonicalR: true}, nil
+	case ModeStrict:
+		return verifyRules{strictPoints: true}, nil
+	}
+
+	return verifyRules{}, errors.New("ed25519: unknown verification mode")
+}
+
+// HashFunc returns o.Hash.
+func (o *Options) HashFunc() crypto.Hash { return o.Hash }
+
+// domPrefix is the prefix of dom2(F, C) from RFC 8032, Section 2.
+const domPrefix = "SigEd25519 no Ed25519 collisions"
+
+// dom returns the dom2 prefix selected by opts, which may be nil, and checks
+// that message is consistent with it. The prefix is nil for regular Ed25519.
+func (o *Options) dom(message []byte) ([]byte, error) {
+	if o == nil {
+		return nil, nil
+	}
+
+	switch {
+	case o.Hash == crypto.BLAKE2b_512 && !o.AllowNonStandardPrehash:
+		return nil, errors.New("ed25519: BLAKE2b-512 prehash is non-standard and must be enabled with AllowNonStandardPrehash")
+	case o.Hash == crypto.SHA512 || o.Hash == crypto.BLAKE2b_512: // Ed25519ph
+		if l := len(message); l != o.Hash.Size() {
+			return nil, errors.New("ed25519: bad Ed25519ph message hash length: " + strconv.Itoa(l))
+		}
+		return dom2(1, o.Context)
+	case o.Hash == crypto.Hash(0) && o.Context != "": // Ed25519ctx
+		return dom2(0, o.Context)
+	case o.Hash == crypto.Hash(0): // Ed25519
+		return nil, nil
+	}
+
+	return nil, errors.New("ed25519: expected opts.Hash zero (unhashed message, for standard Ed25519) or SHA-512 (for Ed25519ph)")
+}
+
+// dom2 returns dom2(phflag, context) from RFC 8032, Section 2:
+//
+//	"SigEd25519 no Ed25519 collisions" || octet(phflag) || octet(len(context)) || context
+//
+// It is the only place the prefix is built. phflag is 1 for Ed25519ph and 0
+// for Ed25519ctx, which RFC 8032, Section 8.3, says must not be used with an
+// empty context, so that is rejected, as are contexts longer than 255 bytes.
+func dom2(phflag byte, context string) ([]byte, error) {
+	var variant string
+	switch phflag {
+	case 0:
+		variant = "Ed25519ctx"
+		if context == "" {
+			return nil, errors.New("ed25519: Ed25519ctx requires a non-empty context")
+		}
+	case 1:
+		variant = "Ed25519ph"
+	default:
+		return nil, errors.New("ed25519: bad dom2 flag: " + strconv.Itoa(int(phflag)))
+	}
+	if l := len(context); l > 255 {
+		return nil, errors.New("ed25519: bad " + variant + " context length: " + strconv.Itoa(l))
+	}
+
+	out := make([]byte, 0, len(domPrefix)+2+len(context))
+	out = append(out, domPrefix...)
+	out = append(out, phflag, byte(len(context)))
+	return append(out, context...), nil
+}
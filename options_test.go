@@ -0,0 +1,128 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto"
+	"strings"
+	"testing"
+)
+
+// rfcDom2 builds dom2(x, y) literally as in the pseudocode of RFC 8032,
+// Section 2: "SigEd25519 no Ed25519 collisions" || octet(x) || octet(OLEN(y))
+// || y.
+func rfcDom2(x byte, y string) []byte {
+	var out bytes.Buffer
+	out.WriteString("SigEd25519 no Ed25519 collisions")
+	out.WriteByte(x)
+	out.WriteByte(byte(len(y)))
+	out.WriteString(y)
+	return out.Bytes()
+}
+
+func TestDom2(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		phflag  byte
+		context string
+		ok      bool
+	}{
+		{"ph, empty context", 1, "", true},
+		{"ph, 1-byte context", 1, "x", true},
+		{"ph, 255-byte context", 1, strings.Repeat("p", 255), true},
+		{"ph, 256-byte context", 1, strings.Repeat("p", 256), false},
+		{"ctx, empty context", 0, "", false},
+		{"ctx, 1-byte context", 0, "x", true},
+		{"ctx, 255-byte context", 0, strings.Repeat("c", 255), true},
+		{"ctx, 256-byte context", 0, strings.Repeat("c", 256), false},
+		{"ctx, NUL context", 0, "\x00", true},
+		{"bad flag", 2, "x", false},
+	} {
+		got, err := dom2(test.phflag, test.context)
+		if !test.ok {
+			if err == nil {
+				t.Errorf("%s: dom2 succeeded", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		want := rfcDom2(test.phflag, test.context)
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: got %x, want %x", test.name, got, want)
+		}
+		if len(got) != 34+len(test.context) {
+			t.Errorf("%s: dom2 is %d bytes", test.name, len(got))
+		}
+	}
+}
+
+// TestOptionsDom checks the prefix that each combination of options selects,
+// which is what ends up hashed into signatures.
+func TestOptionsDom(t *testing.T) {
+	digest := make([]byte, 64)
+	long := strings.Repeat("a", 255)
+
+	for _, test := range []struct {
+		name    string
+		opts    *Options
+		message []byte
+		want    []byte
+	}{
+		{"nil", nil, nil, nil},
+		{"Ed25519", &Options{}, nil, nil},
+		{"Ed25519ctx", &Options{Context: "foo"}, nil, rfcDom2(0, "foo")},
+		{"Ed25519ctx, 255 bytes", &Options{Context: long}, nil, rfcDom2(0, long)},
+		{"Ed25519ph", &Options{Hash: crypto.SHA512}, digest, rfcDom2(1, "")},
+		{"Ed25519ph with context", &Options{Hash: crypto.SHA512, Context: "bar"}, digest, rfcDom2(1, "bar")},
+		{"Ed25519ph, 255 bytes", &Options{Hash: crypto.SHA512, Context: long}, digest, rfcDom2(1, long)},
+	} {
+		got, err := test.opts.dom(test.message)
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		if !bytes.Equal(got, test.want) {
+			t.Errorf("%s: got %x, want %x", test.name, got, test.want)
+		}
+	}
+
+	for _, test := range []struct {
+		name    string
+		opts    *Options
+		message []byte
+	}{
+		{"Ed25519ctx, 256 bytes", &Options{Context: long + "a"}, nil},
+		{"Ed25519ph, 256 bytes", &Options{Hash: crypto.SHA512, Context: long + "a"}, digest},
+		{"Ed25519ph, short digest", &Options{Hash: crypto.SHA512}, digest[:63]},
+		{"SHA-256", &Options{Hash: crypto.SHA256}, digest[:32]},
+	} {
+		if _, err := test.opts.dom(test.message); err == nil {
+			t.Errorf("%s: dom succeeded", test.name)
+		}
+	}
+}
+
+// TestDom2Signature checks the prefix through a signature, against the
+// Ed25519ctx vector with context "foo" from RFC 8032, Section 7.2.
+func TestDom2Signature(t *testing.T) {
+	private := NewKeyFromSeed(decodeHex(t, "0305334e381af78f141cb666f6199f57bc3495335a256a95bd2a55bf546663f6"))
+	message := decodeHex(t, "f726936d19c800494e3fdaff20b276a8")
+	want := decodeHex(t, "55a4cc2f70a54e04288c5f4cd1e45a7bb520b36292911876cada7323198dd87a8b36950b95130022907a7fb7c4e9b2d5f6cca685a587b4b21f4b888e4e7edb0d")
+
+	dom, err := dom2(0, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := sign(private, message, dom, nil); !bytes.Equal(got, want) {
+		t.Errorf("got signature %x, want %x", got, want)
+	}
+	if got := sign(private, message, rfcDom2(0, "foo"), nil); !bytes.Equal(got, want) {
+		t.Errorf("RFC pseudocode prefix: got signature %x, want %x", got, want)
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"encoding/base32"
+	"errors"
+	"strings"
+)
+
+// A libp2p peer ID is a multihash of the protobuf encoding of the peer's
+// public key, a message with the key type, 1 for Ed25519, in field 1 and the
+// key in field 2. Keys whose encoding is at most 42 bytes long, like Ed25519
+// keys, use the identity multihash, so the peer ID contains the key itself.
+// The usual text form is the base58 of the multihash, which for Ed25519 starts
+// with "12D3KooW". The other one is a CIDv1 with the libp2p-key multicodec,
+// 0x72, in multibase base32.
+
+// peerIDPrefix is the identity multihash header, code 0 and length 36,
+// followed by the protobuf header of an Ed25519 key.
+var peerIDPrefix = []byte{0x00, 0x24, 0x08, 0x01, 0x12, 0x20}
+
+// peerIDCIDPrefix is the CID version, 1, and the libp2p-key multicodec.
+var peerIDCIDPrefix = []byte{0x01, 0x72}
+
+var base32NoPadding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+var (
+	// ErrBadPeerID is returned by ParsePeerID when a string is not a
+	// libp2p peer ID with an Ed25519 key in it.
+	ErrBadPeerID = errors.New("ed25519: malformed libp2p peer ID")
+
+	// ErrPeerIDHashed is returned by ParsePeerID for a peer ID that holds
+	// a SHA-256 hash of the public key, from which the key can't be
+	// recovered. Such peer IDs, starting with "Qm", are used for keys
+	// larger than Ed25519 keys.
+	ErrPeerIDHashed = errors.New("ed25519: libp2p peer ID doesn't contain the public key")
+)
+
+// PeerIDFromPublicKey returns the libp2p peer ID of publicKey in base58, as
+// printed by libp2p implementations.
+func PeerIDFromPublicKey(publicKey PublicKey) (string, error) {
+	if len(publicKey) != PublicKeySize {
+		return "", ErrBadPublicKeyLength
+	}
+	return encodeBase58(peerIDMultihash(publicKey)), nil
+}
+
+// PeerIDCIDFromPublicKey returns the libp2p peer ID of publicKey as a CIDv1
+// in multibase base32, which starts with "bafzaa".
+func PeerIDCIDFromPublicKey(publicKey PublicKey) (string, error) {
+	if len(publicKey) != PublicKeySize {
+		return "", ErrBadPublicKeyLength
+	}
+	cid := append(append([]byte(nil), peerIDCIDPrefix...), peerIDMultihash(publicKey)...)
+	return "b" + strings.ToLower(base32NoPadding.EncodeToString(cid)), nil
+}
+
+// ParsePeerID returns the Ed25519 public key in a libp2p peer ID, in either
+// the base58 or the CIDv1 form. It returns ErrPeerIDHashed if the peer ID is
+// a hash of the key, and ErrBadPeerID if it is malformed, uses another
+// multibase than lower case base32 for a CID, or is for another key type.
+func ParsePeerID(id string) (PublicKey, error) {
+	var multihash []byte
+	if encoded, ok := strings.CutPrefix(id, "b"); ok {
+		// No base58 multihash starts with 'b': it would be a first byte
+		// of 33 or more, but multihash codes of one byte are smaller.
+		cid, err := base32NoPadding.DecodeString(strings.ToUpper(encoded))
+		if err != nil || strings.ToLower(base32NoPadding.EncodeToString(cid)) != encoded {
+			return nil, ErrBadPeerID
+		}
+		if len(cid) < 2 || string(cid[:2]) != string(peerIDCIDPrefix) {
+			return nil, ErrBadPeerID
+		}
+		multihash = cid[2:]
+	} else {
+		var ok bool
+		if multihash, ok = decodeBase58(id); !ok {
+			return nil, ErrBadPeerID
+		}
+	}
+
+	if len(multihash) == 34 && multihash[0] == 0x12 && multihash[1] == 0x20 {
+		return nil, ErrPeerIDHashed
+	}
+	if len(multihash) != len(peerIDPrefix)+PublicKeySize || string(multihash[:len(peerIDPrefix)]) != string(peerIDPrefix) {
+		return nil, ErrBadPeerID
+	}
+	return PublicKey(multihash[len(peerIDPrefix):]), nil
+}
+
+// peerIDMultihash returns the identity multihash of the protobuf encoding of
+// publicKey.
+func peerIDMultihash(publicKey PublicKey) []byte {
+	return append(append([]byte(nil), peerIDPrefix...), publicKey...)
+}
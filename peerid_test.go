@@ -0,0 +1,89 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+// peerIDTests are Ed25519 peer IDs in both forms. The first key is that of the
+// peer ID specification's example seed.
+var peerIDTests = []struct {
+	publicKey, base58, cid string
+}{
+	{"1ed1e8fae2c4a144b8be8fd4b47bf3d3b34b871c3cacf6010f0e42d474fce27e", "12D3KooWBtg3aaRMjxwedh83aGiUkwSxDwUZkzuJcfaqUmo7R3pq", "bafzaajaiaejcahwr5d5ofrfbis4l5d6uwr57hu5tjodrypfm6yaq6dsc2r2pzyt6"},
+	{"94b6062d9dc5044338d25d5bdff0a3fe67ae790308193ecf3b1ea098f85739c9", "12D3KooWKpsVSU2sCHVkMKRZJHUQMspLEy1MpZmQWeLtU3JD4BVr", "bafzaajaiaejcbffwaywz3rieim4nexk337ykh7thvz4qgcazh3htwhvatd4foooj"},
+	{"517c608497bfd671433a094c4e8cd1c6611c324475e891fd89f809266f973cc5", "12D3KooWFJTC68wFrVVHFi3tEkaEDNcGfzTSUkrwtsZ1ye2AgAur", "bafzaajaiaejcaul4mccjpp6wofbtuckmj2gndrtbdqzei5pish6yt6ajezxzopgf"},
+	{"99a7502c92ff0357db04c69a036383148cee84c003dd5240378a032f6d543b37", "12D3KooWLAAX4pM7ibq5ubrms7pJ973FFadiL5sx89UYGsZGmj7c", "bafzaajaiaejcbgnhkawjf7ydk7nqjru2anrygfem52cmaa65kjadpcqdf5wviozx"},
+}
+
+func TestPeerID(t *testing.T) {
+	for _, test := range peerIDTests {
+		publicKey, _ := hex.DecodeString(test.publicKey)
+		if got, err := PeerIDFromPublicKey(publicKey); err != nil || got != test.base58 {
+			t.Errorf("PeerIDFromPublicKey(%s) = %s, %v, want %s", test.publicKey, got, err, test.base58)
+		}
+		if got, err := PeerIDCIDFromPublicKey(publicKey); err != nil || got != test.cid {
+			t.Errorf("PeerIDCIDFromPublicKey(%s) = %s, %v, want %s", test.publicKey, got, err, test.cid)
+		}
+		for _, id := range []string{test.base58, test.cid} {
+			if got, err := ParsePeerID(id); err != nil || !bytes.Equal(got, publicKey) {
+				t.Errorf("ParsePeerID(%s) = %x, %v", id, got, err)
+			}
+		}
+	}
+
+	// The example peer ID of the specification.
+	got, err := ParsePeerID("12D3KooWD3eckifWpRn9wQpMG9R9hX3sD158z7EqHWmweQAJU5SA")
+	if err != nil || hex.EncodeToString(got) != "2ffa35a99d3a3cfbb17bb7c1dc5561b18a8dcca4df38dc613ea859c37eb1336b" {
+		t.Errorf("ParsePeerID of the specification example = %x, %v", got, err)
+	}
+
+	if _, err := PeerIDFromPublicKey(make([]byte, 31)); err != ErrBadPublicKeyLength {
+		t.Errorf("short key gave %v", err)
+	}
+	if _, err := PeerIDCIDFromPublicKey(make([]byte, 33)); err != ErrBadPublicKeyLength {
+		t.Errorf("long key gave %v", err)
+	}
+}
+
+func TestPeerIDErrors(t *testing.T) {
+	// A peer ID of a larger key holds a SHA-256 multihash of it.
+	sum := sha256.Sum256([]byte("an RSA public key"))
+	hashed := encodeBase58(append([]byte{0x12, 0x20}, sum[:]...))
+	if _, err := ParsePeerID(hashed); err != ErrPeerIDHashed {
+		t.Errorf("hashed peer ID %s gave %v", hashed, err)
+	}
+
+	test := peerIDTests[0]
+	multihash, _ := decodeBase58(test.base58)
+	secp256k1 := append([]byte(nil), multihash...)
+	secp256k1[3] = 2
+	long := append(append([]byte(nil), multihash...), 0)
+	long[1]++
+	for _, bad := range []string{
+		"",
+		"b",
+		test.base58[:len(test.base58)-1],
+		test.base58 + "1",
+		"1" + test.base58,
+		"12D3KooWBtg3aaRMjxwedh83aGiUkwSxDwUZkzuJcfaqUmo7R3p0",
+		encodeBase58(secp256k1),
+		encodeBase58(long),
+		test.cid[:len(test.cid)-1],
+		"B" + test.cid[1:],
+		"bAFZAA" + test.cid[6:],
+		"z" + test.base58,
+		// A CIDv1 with the dag-pb codec.
+		"bafybeigdyrzt5sfp7udm7hu76uh7y26nf3efuylqabf3oclgtqy55fbzdi",
+	} {
+		if _, err := ParsePeerID(bad); err != ErrBadPeerID {
+			t.Errorf("ParsePeerID(%q) gave %v", bad, err)
+		}
+	}
+}
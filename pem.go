@@ -0,0 +1,110 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+// The PEM block types of RFC 7468 for PKCS #8 private keys and PKIX public
+// keys.
+const (
+	privateKeyPEMType = "PRIVATE KEY"
+	publicKeyPEMType  = "PUBLIC KEY"
+)
+
+// The errors returned by the PEM decoding functions. A block that can't be
+// parsed is reported with an error that wraps ErrBadPEMContents and
+// includes the reason.
+var (
+	// ErrNotPEM is returned when the input contains no PEM block at all.
+	ErrNotPEM = errors.New("ed25519: no PEM block found")
+
+	// ErrPEMBlockType is returned when the input contains PEM blocks, but
+	// none of the expected type.
+	ErrPEMBlockType = errors.New("ed25519: no PEM block of the expected type")
+
+	// ErrBadPEMContents is wrapped by the error returned when the DER
+	// inside a PEM block of the expected type is not a valid key.
+	ErrBadPEMContents = errors.New("ed25519: bad DER in PEM block")
+)
+
+// EncodePrivateKeyPEM returns privateKey as a "PRIVATE KEY" PEM block
+// containing its PKCS #8 encoding, the format written by
+// "openssl genpkey -algorithm ed25519".
+func EncodePrivateKeyPEM(privateKey PrivateKey) ([]byte, error) {
+	der, err := MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: privateKeyPEMType, Bytes: der}), nil
+}
+
+// DecodePrivateKeyPEM parses the first "PRIVATE KEY" PEM block in data with
+// ParsePKCS8PrivateKey.
+//
+// Text before, between and after PEM blocks is ignored, as are blocks of
+// other types, so a file may include comments or other material. Only the
+// first "PRIVATE KEY" block is considered, even if it is invalid and another
+// one follows; if that matters, split the file first.
+func DecodePrivateKeyPEM(data []byte) (PrivateKey, error) {
+	der, err := findPEMBlock(data, privateKeyPEMType)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, err := ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadPEMContents, err)
+	}
+	return privateKey, nil
+}
+
+// EncodePublicKeyPEM returns publicKey as a "PUBLIC KEY" PEM block
+// containing its PKIX encoding, the format written by "openssl pkey -pubout".
+func EncodePublicKeyPEM(publicKey PublicKey) ([]byte, error) {
+	der, err := MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: publicKeyPEMType, Bytes: der}), nil
+}
+
+// DecodePublicKeyPEM parses the first "PUBLIC KEY" PEM block in data with
+// ParsePKIXPublicKey, without requiring a canonical key. The handling of
+// surrounding text and other blocks is as for DecodePrivateKeyPEM.
+func DecodePublicKeyPEM(data []byte) (PublicKey, error) {
+	der, err := findPEMBlock(data, publicKeyPEMType)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := ParsePKIXPublicKey(der, false)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadPEMContents, err)
+	}
+	return publicKey, nil
+}
+
+// findPEMBlock returns the contents of the first PEM block of type
+// blockType in data.
+func findPEMBlock(data []byte, blockType string) ([]byte, error) {
+	found := false
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		found = true
+		if block.Type == blockType {
+			return block.Bytes, nil
+		}
+	}
+	if !found {
+		return nil, ErrNotPEM
+	}
+	return nil, ErrPEMBlockType
+}
@@ -0,0 +1,620 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"strings"
+	"time"
+)
+
+// OpenPGP Ed25519 keys are v4 public key packets with the EdDSA algorithm, 22,
+// as specified by RFC 9580 for the "EdDSALegacy" algorithm that GnuPG uses:
+// the curve OID followed by the point as an MPI with a 0x40 prefix. An EdDSA
+// signature signs the digest of the message and of the signature's hashed
+// fields, and stores R and S as two MPIs, with their leading zero bytes
+// removed.
+
+const (
+	pgpTagSignature = 2
+	pgpTagPublicKey = 6
+	pgpTagUserID    = 13
+	pgpTagSubkey    = 14
+
+	pgpAlgorithmEdDSA = 22
+
+	pgpSigBinary        = 0x00
+	pgpSigText          = 0x01
+	pgpSigPositiveCert  = 0x13
+	pgpSubCreationTime  = 2
+	pgpSubIssuer        = 16
+	pgpSubKeyFlags      = 27
+	pgpSubIssuerFpr     = 33
+	pgpKeyFlagsCertSign = 0x03
+
+	pgpMaxArmorLine = 76
+)
+
+// pgpEd25519OID is the DER encoding, without the tag and length, of the
+// OID 1.3.6.1.4.1.11591.15.1.
+var pgpEd25519OID = []byte{0x2b, 0x06, 0x01, 0x04, 0x01, 0xda, 0x47, 0x0f, 0x01}
+
+// ErrPGPKeyID is returned by VerifyPGPSignature when the signature names an
+// issuer other than the key.
+var ErrPGPKeyID = errors.New("ed25519: OpenPGP signature is by a different key")
+
+var errBadPGP = errors.New("ed25519: malformed OpenPGP data")
+
+// PGPPublicKey is an Ed25519 OpenPGP v4 public key.
+type PGPPublicKey struct {
+	// CreationTime is part of the key's fingerprint, so it must be kept
+	// to identify the key.
+	CreationTime time.Time
+	PublicKey    PublicKey
+}
+
+// PGPSignOptions configures SignPGP. The zero value selects the defaults.
+type PGPSignOptions struct {
+	// Time is the signature creation time. If zero, the current time is
+	// used.
+	Time time.Time
+
+	// Text makes a signature of a text document, for which line endings are
+	// converted to CRLF before hashing, instead of a binary one.
+	Text bool
+}
+
+// ParsePGPPublicKey parses the first packet of data, which may be ASCII
+// armored, as an Ed25519 OpenPGP public key or public subkey. An exported
+// key, with its user IDs and signatures after the primary key, gives the
+// primary key. The self-signatures are not checked.
+func ParsePGPPublicKey(data []byte) (*PGPPublicKey, error) {
+	data, err := pgpDearmor(data)
+	if err != nil {
+		return nil, err
+	}
+	tag, body, _, ok := readPGPPacket(data)
+	if !ok {
+		return nil, errBadPGP
+	}
+	if tag != pgpTagPublicKey && tag != pgpTagSubkey {
+		return nil, errors.New("ed25519: OpenPGP data doesn't start with a public key")
+	}
+	return parsePGPPublicKeyBody(body)
+}
+
+// parsePGPPublicKeyBody parses the body of a public key packet.
+func parsePGPPublicKeyBody(b []byte) (*PGPPublicKey, error) {
+	if len(b) < 7 {
+		return nil, errBadPGP
+	}
+	if b[0] != 4 {
+		return nil, errors.New("ed25519: unsupported OpenPGP key version")
+	}
+	if b[5] != pgpAlgorithmEdDSA {
+		return nil, errors.New("ed25519: unsupported OpenPGP public key algorithm")
+	}
+	created := binary.BigEndian.Uint32(b[1:])
+	oidLen := int(b[6])
+	if len(b) < 7+oidLen {
+		return nil, errBadPGP
+	}
+	if !bytes.Equal(b[7:7+oidLen], pgpEd25519OID) {
+		return nil, errors.New("ed25519: unsupported OpenPGP curve")
+	}
+	// The point is 263 bits long because of its 0x40 prefix.
+	point, rest, ok := readPGPMPI(b[7+oidLen:])
+	if !ok || len(rest) != 0 || binary.BigEndian.Uint16(b[7+oidLen:]) != 263 || len(point) != 1+PublicKeySize || point[0] != 0x40 {
+		return nil, errBadPGP
+	}
+	return &PGPPublicKey{
+		CreationTime: time.Unix(int64(created), 0),
+		PublicKey:    append(PublicKey(nil), point[1:]...),
+	}, nil
+}
+
+// Fingerprint returns the v4 fingerprint of k, the SHA-1 hash of its
+// public key packet.
+func (k *PGPPublicKey) Fingerprint() []byte {
+	h := sha1.New()
+	k.hashKey(h)
+	return h.Sum(nil)
+}
+
+// KeyID returns the key ID of k, the last 8 bytes of its fingerprint.
+func (k *PGPPublicKey) KeyID() uint64 {
+	return binary.BigEndian.Uint64(k.Fingerprint()[12:])
+}
+
+// body returns the body of the public key packet of k, or false if the
+// key or the time can't be encoded.
+func (k *PGPPublicKey) body() ([]byte, bool) {
+	created, ok := pgpTime(k.CreationTime)
+	if len(k.PublicKey) != PublicKeySize || !ok {
+		return nil, false
+	}
+	b := []byte{4, 0, 0, 0, 0, pgpAlgorithmEdDSA, byte(len(pgpEd25519OID))}
+	binary.BigEndian.PutUint32(b[1:], created)
+	b = append(b, pgpEd25519OID...)
+	b = appendPGPMPI(b, append([]byte{0x40}, k.PublicKey...))
+	return b, true
+}
+
+// hashKey writes the public key packet of k to h in the form that
+// fingerprints and certifications hash.
+func (k *PGPPublicKey) hashKey(h hash.Hash) {
+	body, _ := k.body()
+	h.Write([]byte{0x99, byte(len(body) >> 8), byte(len(body))})
+	h.Write(body)
+}
+
+// SignPGPKey returns a transferable OpenPGP public key, as written by "gpg
+// --export", for the key of signer created at created: the public key packet,
+// a user ID packet with userID, and a positive certification by the key
+// itself that binds them and allows the key to sign.
+func SignPGPKey(signer crypto.Signer, userID string, created time.Time) ([]byte, error) {
+	publicKey, err := SignerPublicKey(signer)
+	if err != nil {
+		return nil, err
+	}
+	key := &PGPPublicKey{CreationTime: created, PublicKey: publicKey}
+	body, ok := key.body()
+	if !ok {
+		return nil, errors.New("ed25519: OpenPGP key creation time out of range")
+	}
+
+	hashed := appendPGPSubpacket(nil, pgpSubKeyFlags, []byte{pgpKeyFlagsCertSign})
+	sig, err := signPGP(signer, key, pgpSigPositiveCert, created, hashed, func(h hash.Hash) {
+		key.hashKey(h)
+		var prefix [5]byte
+		prefix[0] = 0xb4
+		binary.BigEndian.PutUint32(prefix[1:], uint32(len(userID)))
+		h.Write(prefix[:])
+		h.Write([]byte(userID))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := appendPGPPacket(nil, pgpTagPublicKey, body)
+	out = appendPGPPacket(out, pgpTagUserID, []byte(userID))
+	return appendPGPPacket(out, pgpTagSignature, sig), nil
+}
+
+// SignPGP signs message with signer, whose OpenPGP key was created at
+// keyCreated, and returns a v4 signature packet, as written by "gpg
+// --detach-sign". The hash is SHA-512. opts may be nil for the defaults.
+func SignPGP(signer crypto.Signer, keyCreated time.Time, message []byte, opts *PGPSignOptions) ([]byte, error) {
+	var o PGPSignOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Time.IsZero() {
+		o.Time = time.Now()
+	}
+	publicKey, err := SignerPublicKey(signer)
+	if err != nil {
+		return nil, err
+	}
+	key := &PGPPublicKey{CreationTime: keyCreated, PublicKey: publicKey}
+	if _, ok := key.body(); !ok {
+		return nil, errors.New("ed25519: OpenPGP key creation time out of range")
+	}
+
+	sigType := byte(pgpSigBinary)
+	if o.Text {
+		sigType = pgpSigText
+	}
+	sig, err := signPGP(signer, key, sigType, o.Time, nil, func(h hash.Hash) {
+		hashPGPMessage(h, sigType, message)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return appendPGPPacket(nil, pgpTagSignature, sig), nil
+}
+
+// signPGP returns the body of a signature packet of sigType by signer, whose
+// key is key, with the creation time, the issuer fingerprint and the extra
+// hashed subpackets. hashData writes the signed data to the hash.
+func signPGP(signer crypto.Signer, key *PGPPublicKey, sigType byte, t time.Time, extra []byte, hashData func(hash.Hash)) ([]byte, error) {
+	created, ok := pgpTime(t)
+	if !ok {
+		return nil, errors.New("ed25519: OpenPGP signature time out of range")
+	}
+	var createdBytes [4]byte
+	binary.BigEndian.PutUint32(createdBytes[:], created)
+	fingerprint := key.Fingerprint()
+
+	hashed := appendPGPSubpacket(nil, pgpSubIssuerFpr, append([]byte{4}, fingerprint...))
+	hashed = appendPGPSubpacket(hashed, pgpSubCreationTime, createdBytes[:])
+	hashed = append(hashed, extra...)
+	unhashed := appendPGPSubpacket(nil, pgpSubIssuer, fingerprint[12:])
+
+	b := []byte{4, sigType, pgpAlgorithmEdDSA, byte(pgpHashSHA512), byte(len(hashed) >> 8), byte(len(hashed))}
+	b = append(b, hashed...)
+	h := sha512.New()
+	hashData(h)
+	hashPGPTrailer(h, b)
+	digest := h.Sum(nil)
+
+	sig, err := signWith(signer, digest)
+	if err != nil {
+		return nil, err
+	}
+	b = append(b, byte(len(unhashed)>>8), byte(len(unhashed)))
+	b = append(b, unhashed...)
+	b = append(b, digest[:2]...)
+	b = appendPGPMPI(b, bytes.TrimLeft(sig[:32], "\x00"))
+	b = appendPGPMPI(b, bytes.TrimLeft(sig[32:], "\x00"))
+	return b, nil
+}
+
+// The OpenPGP hash algorithm IDs that VerifyPGPSignature accepts.
+const (
+	pgpHashSHA256 = 8
+	pgpHashSHA384 = 9
+	pgpHashSHA512 = 10
+	pgpHashSHA224 = 11
+)
+
+// VerifyPGPSignature checks that sigPacket is a valid OpenPGP signature of
+// message by the key in keyPacket. Both may be ASCII armored. keyPacket is
+// parsed by ParsePGPPublicKey, so an exported key may be given to check a
+// signature by its primary key. sigPacket must be a v4 signature of a binary
+// or a text document with one of the SHA-2 hashes, as made by "gpg
+// --detach-sign" or SignPGP.
+//
+// If the signature names its issuer, by fingerprint or by key ID, it must be
+// the key, or the error is ErrPGPKeyID. Signature expiration and other
+// subpackets are not interpreted, except that unknown subpackets marked as
+// critical make the signature invalid. It returns ErrInvalidSignature if the
+// signature doesn't verify, and other errors if either packet is malformed or
+// unsupported.
+func VerifyPGPSignature(keyPacket, sigPacket, message []byte) error {
+	key, err := ParsePGPPublicKey(keyPacket)
+	if err != nil {
+		return err
+	}
+	data, err := pgpDearmor(sigPacket)
+	if err != nil {
+		return err
+	}
+	tag, b, rest, ok := readPGPPacket(data)
+	if !ok || len(rest) != 0 {
+		return errBadPGP
+	}
+	if tag != pgpTagSignature {
+		return errors.New("ed25519: OpenPGP data is not a signature")
+	}
+
+	if len(b) < 6 {
+		return errBadPGP
+	}
+	if b[0] != 4 {
+		return errors.New("ed25519: unsupported OpenPGP signature version")
+	}
+	sigType, algorithm, hashAlgorithm := b[1], b[2], b[3]
+	if sigType != pgpSigBinary && sigType != pgpSigText {
+		return errors.New("ed25519: unsupported OpenPGP signature type")
+	}
+	if algorithm != pgpAlgorithmEdDSA {
+		return errors.New("ed25519: unsupported OpenPGP signature algorithm")
+	}
+	var h hash.Hash
+	switch hashAlgorithm {
+	case pgpHashSHA256:
+		h = sha256.New()
+	case pgpHashSHA384:
+		h = sha512.New384()
+	case pgpHashSHA512:
+		h = sha512.New()
+	case pgpHashSHA224:
+		h = sha256.New224()
+	default:
+		return errors.New("ed25519: unsupported OpenPGP hash algorithm")
+	}
+
+	hashedLen := int(binary.BigEndian.Uint16(b[4:]))
+	if len(b) < 6+hashedLen+2 {
+		return errBadPGP
+	}
+	trailer, hashed := b[:6+hashedLen], b[6:6+hashedLen]
+	unhashedLen := int(binary.BigEndian.Uint16(b[6+hashedLen:]))
+	b = b[6+hashedLen+2:]
+	if len(b) < unhashedLen+2 {
+		return errBadPGP
+	}
+	unhashed, quickCheck := b[:unhashedLen], b[unhashedLen:unhashedLen+2]
+	r, b, ok1 := readPGPMPI(b[unhashedLen+2:])
+	s, b, ok2 := readPGPMPI(b)
+	if !ok1 || !ok2 || len(b) != 0 || len(r) > 32 || len(s) > 32 {
+		return errBadPGP
+	}
+
+	hasCreationTime := false
+	issuerOK := true
+	for i, area := range [][]byte{hashed, unhashed} {
+		err := forEachPGPSubpacket(area, func(typ byte, critical bool, data []byte) error {
+			switch typ {
+			case pgpSubCreationTime:
+				if i == 0 && len(data) == 4 {
+					hasCreationTime = true
+				}
+			case pgpSubIssuer:
+				if len(data) != 8 {
+					return errBadPGP
+				}
+				issuerOK = issuerOK && binary.BigEndian.Uint64(data) == key.KeyID()
+			case pgpSubIssuerFpr:
+				if len(data) == 21 && data[0] == 4 {
+					issuerOK = issuerOK && bytes.Equal(data[1:], key.Fingerprint())
+				}
+			default:
+				if critical {
+					return errors.New("ed25519: unknown critical OpenPGP subpacket")
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if !hasCreationTime {
+		return errBadPGP
+	}
+	if !issuerOK {
+		return ErrPGPKeyID
+	}
+
+	hashPGPMessage(h, sigType, message)
+	hashPGPTrailer(h, trailer)
+	digest := h.Sum(nil)
+	if !bytes.Equal(digest[:2], quickCheck) {
+		return ErrInvalidSignature
+	}
+	sig := make([]byte, SignatureSize)
+	copy(sig[32-len(r):], r)
+	copy(sig[64-len(s):], s)
+	return CheckSignature(key.PublicKey, digest, sig)
+}
+
+// hashPGPMessage writes message to h, with its line endings converted to CRLF
+// if the signature is of a text document.
+func hashPGPMessage(h hash.Hash, sigType byte, message []byte) {
+	if sigType != pgpSigText {
+		h.Write(message)
+		return
+	}
+	for len(message) > 0 {
+		i := bytes.IndexByte(message, '\n')
+		if i < 0 {
+			h.Write(message)
+			return
+		}
+		h.Write(bytes.TrimSuffix(message[:i], []byte("\r")))
+		h.Write([]byte("\r\n"))
+		message = message[i+1:]
+	}
+}
+
+// hashPGPTrailer writes the hashed part of a v4 signature, hashed, to h,
+// followed by the final trailer.
+func hashPGPTrailer(h hash.Hash, hashed []byte) {
+	h.Write(hashed)
+	var trailer [6]byte
+	trailer[0], trailer[1] = 4, 0xff
+	binary.BigEndian.PutUint32(trailer[2:], uint32(len(hashed)))
+	h.Write(trailer[:])
+}
+
+// pgpTime returns t as an OpenPGP timestamp, or false if it doesn't fit.
+func pgpTime(t time.Time) (uint32, bool) {
+	u := t.Unix()
+	return uint32(u), u >= 0 && u <= 0xffffffff
+}
+
+// readPGPPacket splits the first packet off b, in either the old or the new
+// format. Indeterminate and partial body lengths are not supported.
+func readPGPPacket(b []byte) (tag byte, body, rest []byte, ok bool) {
+	if len(b) < 2 || b[0]&0x80 == 0 {
+		return 0, nil, nil, false
+	}
+	var length int
+	if b[0]&0x40 == 0 {
+		tag = (b[0] >> 2) & 0x0f
+		switch b[0] & 3 {
+		case 0:
+			length, b = int(b[1]), b[2:]
+		case 1:
+			if len(b) < 3 {
+				return 0, nil, nil, false
+			}
+			length, b = int(binary.BigEndian.Uint16(b[1:])), b[3:]
+		case 2:
+			if len(b) < 5 {
+				return 0, nil, nil, false
+			}
+			length, b = int(binary.BigEndian.Uint32(b[1:])), b[5:]
+		default:
+			return 0, nil, nil, false
+		}
+	} else {
+		tag = b[0] & 0x3f
+		if b[1] >= 224 && b[1] < 255 {
+			return 0, nil, nil, false
+		}
+		length, b, ok = readPGPLength(b[1:])
+		if !ok {
+			return 0, nil, nil, false
+		}
+	}
+	if length < 0 || len(b) < length {
+		return 0, nil, nil, false
+	}
+	return tag, b[:length], b[length:], true
+}
+
+// readPGPLength reads a new format packet length or a subpacket length.
+func readPGPLength(b []byte) (length int, rest []byte, ok bool) {
+	switch {
+	case len(b) < 1:
+		return 0, nil, false
+	case b[0] < 192:
+		return int(b[0]), b[1:], true
+	case b[0] < 255:
+		if len(b) < 2 {
+			return 0, nil, false
+		}
+		return (int(b[0])-192)<<8 + int(b[1]) + 192, b[2:], true
+	default:
+		if len(b) < 5 {
+			return 0, nil, false
+		}
+		l := binary.BigEndian.Uint32(b[1:])
+		return int(l), b[5:], l <= 1<<30
+	}
+}
+
+// appendPGPLength appends the new format encoding of length to b.
+func appendPGPLength(b []byte, length int) []byte {
+	switch {
+	case length < 192:
+		return append(b, byte(length))
+	case length < 8384:
+		length -= 192
+		return append(b, byte(length>>8)+192, byte(length))
+	default:
+		return append(b, 255, byte(length>>24), byte(length>>16), byte(length>>8), byte(length))
+	}
+}
+
+// appendPGPPacket appends a new format packet with tag and body to b.
+func appendPGPPacket(b []byte, tag byte, body []byte) []byte {
+	b = append(b, 0xc0|tag)
+	b = appendPGPLength(b, len(body))
+	return append(b, body...)
+}
+
+// appendPGPSubpacket appends a non-critical subpacket to b.
+func appendPGPSubpacket(b []byte, typ byte, data []byte) []byte {
+	b = appendPGPLength(b, 1+len(data))
+	b = append(b, typ)
+	return append(b, data...)
+}
+
+// forEachPGPSubpacket calls f for every subpacket in area, stopping at the
+// first error.
+func forEachPGPSubpacket(area []byte, f func(typ byte, critical bool, data []byte) error) error {
+	for len(area) > 0 {
+		length, rest, ok := readPGPLength(area)
+		if !ok || length < 1 || len(rest) < length {
+			return errBadPGP
+		}
+		if err := f(rest[0]&0x7f, rest[0]&0x80 != 0, rest[1:length]); err != nil {
+			return err
+		}
+		area = rest[length:]
+	}
+	return nil
+}
+
+// readPGPMPI reads an MPI and returns its big-endian bytes.
+func readPGPMPI(b []byte) (value, rest []byte, ok bool) {
+	if len(b) < 2 {
+		return nil, nil, false
+	}
+	bits := int(binary.BigEndian.Uint16(b))
+	n := (bits + 7) / 8
+	if len(b) < 2+n {
+		return nil, nil, false
+	}
+	return b[2 : 2+n], b[2+n:], true
+}
+
+// appendPGPMPI appends value, which must have no leading zero bytes, as an MPI.
+func appendPGPMPI(b, value []byte) []byte {
+	bits := 8 * len(value)
+	if len(value) > 0 {
+		for top := value[0]; top&0x80 == 0; top <<= 1 {
+			bits--
+		}
+	}
+	return append(append(b, byte(bits>>8), byte(bits)), value...)
+}
+
+// pgpDearmor returns the contents of the first ASCII armored block in data if
+// there is one, after checking its CRC-24 if present, and data itself
+// otherwise.
+func pgpDearmor(data []byte) ([]byte, error) {
+	text := strings.TrimLeft(string(data), " \t\r\n")
+	if !strings.HasPrefix(text, "-----BEGIN PGP ") {
+		return data, nil
+	}
+	lines := strings.Split(text, "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], " \t\r")
+	}
+
+	// Skip the armor headers, which end at the first empty line.
+	i := 1
+	for i < len(lines) && lines[i] != "" {
+		if !strings.Contains(lines[i], ": ") {
+			return nil, errBadPGP
+		}
+		i++
+	}
+	var encoded strings.Builder
+	checksum := ""
+	for i++; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case strings.HasPrefix(line, "-----END PGP "):
+			b, err := base64.StdEncoding.DecodeString(encoded.String())
+			if err != nil {
+				return nil, errBadPGP
+			}
+			if checksum != "" {
+				sum, err := base64.StdEncoding.DecodeString(checksum)
+				crc := crc24(b)
+				if err != nil || len(sum) != 3 || !bytes.Equal(sum, []byte{byte(crc >> 16), byte(crc >> 8), byte(crc)}) {
+					return nil, errors.New("ed25519: OpenPGP armor checksum mismatch")
+				}
+			}
+			return b, nil
+		case checksum != "" || len(line) > pgpMaxArmorLine:
+			return nil, errBadPGP
+		case strings.HasPrefix(line, "="):
+			checksum = line[1:]
+		default:
+			encoded.WriteString(line)
+		}
+	}
+	return nil, errBadPGP
+}
+
+// crc24 returns the CRC-24 of b used by OpenPGP armor.
+func crc24(b []byte) uint32 {
+	crc := uint32(0xb704ce)
+	for _, c := range b {
+		crc ^= uint32(c) << 16
+		for i := 0; i < 8; i++ {
+			crc <<= 1
+			if crc&0x1000000 != 0 {
+				crc ^= 0x1864cfb
+			}
+		}
+	}
+	return crc & 0xffffff
+}
@@ -0,0 +1,213 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPGPGnuPG(t *testing.T) {
+	message := readTestdata(t, "pgp_message.txt")
+
+	for _, keyFile := range []string{"pgp_ed25519.gpg", "pgp_ed25519.asc"} {
+		keyData := readTestdata(t, keyFile)
+		key, err := ParsePGPPublicKey(keyData)
+		if err != nil {
+			t.Fatalf("%s: %s", keyFile, err)
+		}
+		if got := strings.ToUpper(hex.EncodeToString(key.Fingerprint())); got != "40C515CB5F6E9D71F90A478D6199B3541B96DB85" {
+			t.Errorf("%s: fingerprint %s", keyFile, got)
+		}
+		if got := key.KeyID(); got != 0x6199B3541B96DB85 {
+			t.Errorf("%s: key ID %X", keyFile, got)
+		}
+		if !key.CreationTime.Equal(time.Unix(1791979200, 0)) {
+			t.Errorf("%s: creation time %s", keyFile, key.CreationTime)
+		}
+
+		for _, sigFile := range []string{"pgp_message.txt.sig", "pgp_message.txt.text.sig", "pgp_message.txt.asc"} {
+			sig := readTestdata(t, sigFile)
+			if err := VerifyPGPSignature(keyData, sig, message); err != nil {
+				t.Errorf("%s with %s: %s", sigFile, keyFile, err)
+			}
+			if err := VerifyPGPSignature(keyData, sig, append(message, '\n')); err != ErrInvalidSignature {
+				t.Errorf("%s with another message gave %v", sigFile, err)
+			}
+		}
+	}
+
+	// The text signature is over the message with CRLF line endings.
+	crlf := bytes.ReplaceAll(bytes.ReplaceAll(message, []byte("\r\n"), []byte("\n")), []byte("\n"), []byte("\r\n"))
+	if err := VerifyPGPSignature(readTestdata(t, "pgp_ed25519.gpg"), readTestdata(t, "pgp_message.txt.text.sig"), crlf); err != nil {
+		t.Errorf("text signature with CRLF line endings: %s", err)
+	}
+}
+
+// TestPGPGnuPGCertification checks the self-signature of the key exported by
+// GnuPG, whose R is a 255-bit MPI, with the same hashing as SignPGPKey.
+func TestPGPGnuPGCertification(t *testing.T) {
+	data := readTestdata(t, "pgp_ed25519.gpg")
+	_, keyBody, rest, ok1 := readPGPPacket(data)
+	_, userID, rest, ok2 := readPGPPacket(rest)
+	_, sig, _, ok3 := readPGPPacket(rest)
+	if !ok1 || !ok2 || !ok3 {
+		t.Fatal("can't split the exported key")
+	}
+	key, err := parsePGPPublicKeyBody(keyBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig[1] != pgpSigPositiveCert || sig[3] != pgpHashSHA256 {
+		t.Fatalf("unexpected self-signature type %#x or hash %d", sig[1], sig[3])
+	}
+
+	h := sha256.New()
+	key.hashKey(h)
+	h.Write([]byte{0xb4, 0, 0, 0, byte(len(userID))})
+	h.Write(userID)
+	hashedLen := int(binary.BigEndian.Uint16(sig[4:]))
+	hashPGPTrailer(h, sig[:6+hashedLen])
+	digest := h.Sum(nil)
+
+	b := sig[6+hashedLen:]
+	b = b[2+int(binary.BigEndian.Uint16(b)):]
+	if !bytes.Equal(b[:2], digest[:2]) {
+		t.Fatal("quick check of the digest doesn't match")
+	}
+	if bits := binary.BigEndian.Uint16(b[2:]); bits != 255 {
+		t.Errorf("R is %d bits, want 255", bits)
+	}
+	r, b, _ := readPGPMPI(b[2:])
+	s, _, _ := readPGPMPI(b)
+	full := make([]byte, SignatureSize)
+	copy(full[32-len(r):], r)
+	copy(full[64-len(s):], s)
+	if err := CheckSignature(key.PublicKey, digest, full); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestPGPGoFixtures checks a key and a signature written by SignPGPKey and
+// SignPGP, which "gpg --import" and "gpg --verify" accept.
+func TestPGPGoFixtures(t *testing.T) {
+	if err := VerifyPGPSignature(readTestdata(t, "pgp_go.gpg"), readTestdata(t, "pgp_message.txt.go.sig"), readTestdata(t, "pgp_message.txt")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPGPSignVerify(t *testing.T) {
+	public, private, _ := GenerateKey(rand.Reader)
+	created := time.Unix(1700000000, 0)
+	keyData, err := SignPGPKey(private, "Alice <alice@example.org>", created)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ParsePGPPublicKey(keyData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(key.PublicKey, public) || !key.CreationTime.Equal(created) {
+		t.Error("key didn't round-trip")
+	}
+
+	message := []byte("line one\nline two\n")
+	for _, text := range []bool{false, true} {
+		sig, err := SignPGP(private, created, message, &PGPSignOptions{Text: text})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := VerifyPGPSignature(keyData, sig, message); err != nil {
+			t.Errorf("text %v: %s", text, err)
+		}
+		err = VerifyPGPSignature(keyData, sig, []byte("line one\r\nline two\r\n"))
+		if text && err != nil {
+			t.Errorf("text signature with CRLF line endings: %s", err)
+		} else if !text && err != ErrInvalidSignature {
+			t.Errorf("binary signature with CRLF line endings gave %v", err)
+		}
+
+		// The same key with another creation time has another fingerprint.
+		otherKey, _ := SignPGPKey(private, "Alice <alice@example.org>", created.Add(time.Second))
+		if err := VerifyPGPSignature(otherKey, sig, message); err != ErrPGPKeyID {
+			t.Errorf("key with another fingerprint gave %v", err)
+		}
+	}
+
+	if _, err := SignPGP(private, time.Unix(-1, 0), message, nil); err == nil {
+		t.Error("creation time before 1970 accepted")
+	}
+	if _, err := SignPGP(private, created, message, &PGPSignOptions{Time: time.Unix(1<<32, 0)}); err == nil {
+		t.Error("signature time after 2106 accepted")
+	}
+}
+
+func TestPGPErrors(t *testing.T) {
+	keyData := readTestdata(t, "pgp_ed25519.gpg")
+	sig := readTestdata(t, "pgp_message.txt.sig")
+	message := readTestdata(t, "pgp_message.txt")
+
+	// Truncated packets must be rejected without panicking.
+	for i := 0; i < len(sig); i++ {
+		if err := VerifyPGPSignature(keyData, sig[:i], message); err == nil {
+			t.Errorf("signature truncated to %d bytes accepted", i)
+		}
+	}
+	for i := 0; i < 53; i++ {
+		if _, err := ParsePGPPublicKey(keyData[:i]); err == nil {
+			t.Errorf("key truncated to %d bytes accepted", i)
+		}
+	}
+
+	// The bit count of the last MPI claims more bytes than there are.
+	bad := append([]byte(nil), sig...)
+	bad[len(bad)-34] = 0x02
+	if err := VerifyPGPSignature(keyData, bad, message); err == nil {
+		t.Error("MPI longer than the packet accepted")
+	}
+	// The point MPI of the key isn't 263 bits.
+	bad = append([]byte(nil), keyData...)
+	bad[19] = 0x02
+	if _, err := ParsePGPPublicKey(bad); err == nil {
+		t.Error("key with a bad MPI accepted")
+	}
+	// The user ID packet that follows the key.
+	if _, err := ParsePGPPublicKey(keyData[53:]); err == nil {
+		t.Error("user ID accepted as a key")
+	}
+
+	// Mark the creation time subpacket, at offset 32, as critical: it is
+	// understood so the signature still fails only because it changed.
+	bad = append([]byte(nil), sig...)
+	if bad[32] != pgpSubCreationTime {
+		t.Fatalf("unexpected subpacket %d", bad[32])
+	}
+	bad[32] |= 0x80
+	if err := VerifyPGPSignature(keyData, bad, message); err != ErrInvalidSignature {
+		t.Errorf("critical creation time gave %v", err)
+	}
+	// An unknown critical subpacket makes the signature invalid.
+	bad[32] = 0x80 | 99
+	if err := VerifyPGPSignature(keyData, bad, message); err == nil || err == ErrInvalidSignature {
+		t.Errorf("unknown critical subpacket gave %v", err)
+	}
+
+	armored := string(readTestdata(t, "pgp_message.txt.asc"))
+	for _, bad := range []string{
+		strings.Replace(armored, "=idNi", "=idNj", 1),
+		strings.Replace(armored, "-----END PGP SIGNATURE-----", "", 1),
+		strings.Replace(armored, "\n\n", "\nnot a header\n", 1),
+	} {
+		if err := VerifyPGPSignature(keyData, []byte(bad), message); err == nil {
+			t.Errorf("bad armor accepted:\n%s", bad)
+		}
+	}
+}
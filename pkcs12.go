@@ -0,0 +1,451 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/hmac"
+	cryptorand "crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"hash"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// A PKCS #12 file, from RFC 7292, is a PFX holding an AuthenticatedSafe, a
+// sequence of ContentInfos that are either plain or encrypted. Each holds a
+// SafeContents, a sequence of bags, of which the ones used here are the
+// pkcs8ShroudedKeyBag, an encrypted PKCS #8 key, and the certBag. The whole
+// AuthenticatedSafe is protected by an HMAC keyed with the KDF of RFC 7292,
+// Appendix B, applied to the password as a NUL-terminated BMPString. This is
+// the layout that OpenSSL 3 writes by default: the certificates and the key
+// are both encrypted with PBES2, and the MAC uses SHA-256.
+
+var (
+	oidData          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidEncryptedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 6}
+
+	oidKeyBag              = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 1}
+	oidPKCS8ShroudedKeyBag = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 2}
+	oidCertBag             = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 3}
+	oidX509Certificate     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 22, 1}
+	oidLocalKeyID          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 21}
+
+	oidSHA1   = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidSHA256 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+)
+
+var errMalformedPKCS12 = errors.New("ed25519: malformed PKCS #12 file")
+
+type pfxPDU struct {
+	Version  int
+	AuthSafe contentInfo
+	MACData  macData `asn1:"optional"`
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"optional"`
+}
+
+type encryptedData struct {
+	Version              int
+	EncryptedContentInfo encryptedContentInfo
+}
+
+type encryptedContentInfo struct {
+	ContentType                asn1.ObjectIdentifier
+	ContentEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedContent           []byte `asn1:"tag:0,optional"`
+}
+
+type safeBag struct {
+	ID         asn1.ObjectIdentifier
+	Value      asn1.RawValue
+	Attributes []asn1.RawValue `asn1:"set,optional"`
+}
+
+type certBag struct {
+	ID   asn1.ObjectIdentifier
+	Data []byte `asn1:"tag:0,explicit"`
+}
+
+type pkcs12Attribute struct {
+	ID     asn1.ObjectIdentifier
+	Values [][]byte `asn1:"set"`
+}
+
+type macData struct {
+	MAC        digestInfo
+	MACSalt    []byte
+	Iterations int `asn1:"optional,default:1"`
+}
+
+type digestInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	Digest    []byte
+}
+
+// EncodePKCS12 returns a PKCS #12 file holding privateKey and the DER
+// certificates in certs, which may be empty. By convention certs[0] is the
+// certificate of privateKey and the rest is its chain. The key and the
+// certificates are encrypted with PBES2 under passphrase, and the file is
+// authenticated with HMAC-SHA-256, both using opts.Iterations, which defaults
+// to DefaultPBKDF2Iterations. opts may be nil.
+//
+// passphrase must be valid UTF-8, since PKCS #12 turns it into a BMPString
+// for the MAC. The result can be read by "openssl pkcs12" and DecodePKCS12.
+func EncodePKCS12(privateKey PrivateKey, certs [][]byte, passphrase []byte, opts *EncryptionOptions) ([]byte, error) {
+	bmpPassphrase, err := bmpString(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	var o EncryptionOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Iterations == 0 {
+		o.Iterations = DefaultPBKDF2Iterations
+	}
+	if o.Rand == nil {
+		o.Rand = cryptorand.Reader
+	}
+
+	shrouded, err := MarshalEncryptedPKCS8(privateKey, passphrase, &o)
+	if err != nil {
+		return nil, err
+	}
+	keyBag := safeBag{ID: oidPKCS8ShroudedKeyBag, Value: explicitTag0(shrouded)}
+
+	var authSafe []contentInfo
+	if len(certs) > 0 {
+		// The localKeyID attribute ties the key to its certificate.
+		// Like OpenSSL, use the SHA-1 hash of the certificate.
+		sum := sha1.Sum(certs[0])
+		attr, err := asn1.Marshal(pkcs12Attribute{ID: oidLocalKeyID, Values: [][]byte{sum[:]}})
+		if err != nil {
+			return nil, err
+		}
+		keyBag.Attributes = []asn1.RawValue{{FullBytes: attr}}
+
+		var bags []safeBag
+		for i, cert := range certs {
+			value, err := asn1.Marshal(certBag{ID: oidX509Certificate, Data: cert})
+			if err != nil {
+				return nil, err
+			}
+			bag := safeBag{ID: oidCertBag, Value: explicitTag0(value)}
+			if i == 0 {
+				bag.Attributes = keyBag.Attributes
+			}
+			bags = append(bags, bag)
+		}
+		safeContents, err := asn1.Marshal(bags)
+		if err != nil {
+			return nil, err
+		}
+		algorithm, ciphertext, err := pbes2Encrypt(safeContents, passphrase, &o)
+		if err != nil {
+			return nil, err
+		}
+		content, err := asn1.Marshal(encryptedData{
+			EncryptedContentInfo: encryptedContentInfo{
+				ContentType:                oidData,
+				ContentEncryptionAlgorithm: algorithm,
+				EncryptedContent:           ciphertext,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		authSafe = append(authSafe, contentInfo{ContentType: oidEncryptedData, Content: explicitTag0(content)})
+	}
+
+	safeContents, err := asn1.Marshal([]safeBag{keyBag})
+	if err != nil {
+		return nil, err
+	}
+	data, err := marshalDataContentInfo(safeContents)
+	if err != nil {
+		return nil, err
+	}
+	authSafe = append(authSafe, data)
+	authSafeDER, err := asn1.Marshal(authSafe)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, 16)
+	if _, err := io.ReadFull(o.Rand, salt); err != nil {
+		return nil, err
+	}
+	mac := pkcs12MAC(sha256.New, authSafeDER, bmpPassphrase, salt, o.Iterations)
+	pfx := pfxPDU{
+		Version: 3,
+		MACData: macData{
+			MAC: digestInfo{
+				Algorithm: pkix.AlgorithmIdentifier{Algorithm: oidSHA256, Parameters: asn1.NullRawValue},
+				Digest:    mac,
+			},
+			MACSalt:    salt,
+			Iterations: o.Iterations,
+		},
+	}
+	if pfx.AuthSafe, err = marshalDataContentInfo(authSafeDER); err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(pfx)
+}
+
+// DecodePKCS12 decrypts and parses a PKCS #12 file with one Ed25519 private
+// key, as written by EncodePKCS12 or by OpenSSL 3, and returns the key and the
+// DER certificates in the file. The certificate of the key, if there is one,
+// comes first and the others follow in the order of the file.
+//
+// The MAC may use HMAC-SHA-1 or HMAC-SHA-256, and the bags must be encrypted
+// with PBES2, as for ParseEncryptedPKCS8; the legacy PKCS #12 ciphers such as
+// RC2 and 3DES are not supported. It returns ErrIncorrectPassphrase if the MAC
+// doesn't match or the contents don't decrypt.
+func DecodePKCS12(der, passphrase []byte) (PrivateKey, [][]byte, error) {
+	var pfx pfxPDU
+	if rest, err := asn1.Unmarshal(der, &pfx); err != nil || len(rest) != 0 {
+		return nil, nil, errMalformedPKCS12
+	}
+	if pfx.Version != 3 {
+		return nil, nil, errors.New("ed25519: unsupported PKCS #12 version")
+	}
+	authSafeDER, err := unmarshalDataContentInfo(pfx.AuthSafe)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(pfx.MACData.MAC.Algorithm.Algorithm) != 0 {
+		if err := checkPKCS12MAC(&pfx.MACData, authSafeDER, passphrase); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var authSafe []contentInfo
+	if rest, err := asn1.Unmarshal(authSafeDER, &authSafe); err != nil || len(rest) != 0 {
+		return nil, nil, errMalformedPKCS12
+	}
+	var privateKey PrivateKey
+	var certs [][]byte
+	for _, ci := range authSafe {
+		var safeContents []byte
+		switch {
+		case ci.ContentType.Equal(oidData):
+			if safeContents, err = unmarshalDataContentInfo(ci); err != nil {
+				return nil, nil, err
+			}
+		case ci.ContentType.Equal(oidEncryptedData):
+			var ed encryptedData
+			if err := unmarshalExplicitTag0(ci.Content, &ed); err != nil {
+				return nil, nil, errMalformedPKCS12
+			}
+			if !ed.EncryptedContentInfo.ContentType.Equal(oidData) {
+				return nil, nil, errMalformedPKCS12
+			}
+			safeContents, err = pbes2Decrypt(ed.EncryptedContentInfo.ContentEncryptionAlgorithm, ed.EncryptedContentInfo.EncryptedContent, passphrase)
+			if err != nil {
+				return nil, nil, err
+			}
+		default:
+			return nil, nil, errors.New("ed25519: unsupported PKCS #12 content type " + ci.ContentType.String())
+		}
+
+		var bags []safeBag
+		if rest, err := asn1.Unmarshal(safeContents, &bags); err != nil || len(rest) != 0 {
+			return nil, nil, errMalformedPKCS12
+		}
+		for _, bag := range bags {
+			if bag.Value.Class != asn1.ClassContextSpecific || bag.Value.Tag != 0 || !bag.Value.IsCompound {
+				return nil, nil, errMalformedPKCS12
+			}
+			var key PrivateKey
+			switch {
+			case bag.ID.Equal(oidPKCS8ShroudedKeyBag):
+				key, err = ParseEncryptedPKCS8(bag.Value.Bytes, passphrase)
+			case bag.ID.Equal(oidKeyBag):
+				key, err = ParsePKCS8PrivateKey(bag.Value.Bytes)
+			case bag.ID.Equal(oidCertBag):
+				var cb certBag
+				if err := unmarshalExplicitTag0(bag.Value, &cb); err != nil {
+					return nil, nil, errMalformedPKCS12
+				}
+				if !cb.ID.Equal(oidX509Certificate) {
+					return nil, nil, errors.New("ed25519: unsupported PKCS #12 certificate type " + cb.ID.String())
+				}
+				certs = append(certs, cb.Data)
+				continue
+			default:
+				// CRLs and secrets are of no use here.
+				continue
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+			if privateKey != nil {
+				return nil, nil, errors.New("ed25519: PKCS #12 file has more than one private key")
+			}
+			privateKey = key
+		}
+	}
+	if privateKey == nil {
+		return nil, nil, errors.New("ed25519: PKCS #12 file has no private key")
+	}
+
+	for i, cert := range certs {
+		if publicKey, err := ParseCertificatePublicKey(cert); err == nil && bytes.Equal(publicKey, privateKey[32:]) {
+			copy(certs[1:i+1], certs[:i])
+			certs[0] = cert
+			break
+		}
+	}
+	return privateKey, certs, nil
+}
+
+// marshalDataContentInfo returns an id-data ContentInfo holding content.
+func marshalDataContentInfo(content []byte) (contentInfo, error) {
+	octets, err := asn1.Marshal(content)
+	if err != nil {
+		return contentInfo{}, err
+	}
+	return contentInfo{ContentType: oidData, Content: explicitTag0(octets)}, nil
+}
+
+// unmarshalDataContentInfo returns the content of an id-data ContentInfo.
+func unmarshalDataContentInfo(ci contentInfo) ([]byte, error) {
+	if !ci.ContentType.Equal(oidData) {
+		return nil, errors.New("ed25519: unsupported PKCS #12 content type " + ci.ContentType.String())
+	}
+	var content []byte
+	if err := unmarshalExplicitTag0(ci.Content, &content); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// explicitTag0 returns the DER element der wrapped in an explicit [0] tag.
+func explicitTag0(der []byte) asn1.RawValue {
+	return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: der}
+}
+
+// unmarshalExplicitTag0 decodes the element wrapped in the explicit [0] tag
+// v into out.
+func unmarshalExplicitTag0(v asn1.RawValue, out interface{}) error {
+	if v.Class != asn1.ClassContextSpecific || v.Tag != 0 || !v.IsCompound {
+		return errMalformedPKCS12
+	}
+	if rest, err := asn1.Unmarshal(v.Bytes, out); err != nil || len(rest) != 0 {
+		return errMalformedPKCS12
+	}
+	return nil
+}
+
+// checkPKCS12MAC checks the MAC of authSafe. OpenSSL computes the MAC of a
+// file with an empty password over either an empty BMPString or none at all,
+// so both are tried.
+func checkPKCS12MAC(md *macData, authSafe, passphrase []byte) error {
+	var h func() hash.Hash
+	switch algorithm := md.MAC.Algorithm.Algorithm; {
+	case algorithm.Equal(oidSHA1):
+		h = sha1.New
+	case algorithm.Equal(oidSHA256):
+		h = sha256.New
+	default:
+		return errors.New("ed25519: unsupported PKCS #12 MAC algorithm " + md.MAC.Algorithm.Algorithm.String())
+	}
+	if md.Iterations <= 0 || md.Iterations > maxPBKDF2Iterations {
+		return errors.New("ed25519: bad PKCS #12 MAC iteration count")
+	}
+	bmpPassphrase, err := bmpString(passphrase)
+	if err != nil {
+		return err
+	}
+	if hmac.Equal(pkcs12MAC(h, authSafe, bmpPassphrase, md.MACSalt, md.Iterations), md.MAC.Digest) {
+		return nil
+	}
+	if len(passphrase) == 0 && hmac.Equal(pkcs12MAC(h, authSafe, nil, md.MACSalt, md.Iterations), md.MAC.Digest) {
+		return nil
+	}
+	return ErrIncorrectPassphrase
+}
+
+// pkcs12MAC returns the HMAC of message with a key derived from the BMPString
+// password, as specified by RFC 7292, Appendix B.4.
+func pkcs12MAC(h func() hash.Hash, message, password, salt []byte, iterations int) []byte {
+	key := pkcs12KDF(h, password, salt, 3, iterations, h().Size())
+	defer wipeBytes(key)
+	mac := hmac.New(h, key)
+	mac.Write(message)
+	return mac.Sum(nil)
+}
+
+// pkcs12KDF derives size bytes from password and salt with the key derivation
+// function of RFC 7292, Appendix B.2. id is 1 for keys, 2 for IVs and 3 for
+// MAC keys.
+func pkcs12KDF(h func() hash.Hash, password, salt []byte, id byte, iterations, size int) []byte {
+	d := h()
+	u, v := d.Size(), d.BlockSize()
+
+	// I is the salt and then the password, each repeated to a multiple of
+	// v bytes.
+	var I []byte
+	for _, s := range [][]byte{salt, password} {
+		n := v * ((len(s) + v - 1) / v)
+		for i := 0; i < n; i++ {
+			I = append(I, s[i%len(s)])
+		}
+	}
+	defer wipeBytes(I)
+
+	D := bytes.Repeat([]byte{id}, v)
+	var out []byte
+	for len(out) < size {
+		d.Reset()
+		d.Write(D)
+		d.Write(I)
+		A := d.Sum(nil)
+		for i := 1; i < iterations; i++ {
+			d.Reset()
+			d.Write(A)
+			A = d.Sum(A[:0])
+		}
+		out = append(out, A...)
+		if len(out) >= size {
+			break
+		}
+
+		// Treat each v-byte block of I as a big-endian integer and add
+		// B + 1 to it, where B is A repeated to v bytes.
+		for j := 0; j < len(I); j += v {
+			carry := 1
+			for k := v - 1; k >= 0; k-- {
+				carry += int(I[j+k]) + int(A[k%u])
+				I[j+k] = byte(carry)
+				carry >>= 8
+			}
+		}
+	}
+	return out[:size]
+}
+
+// bmpString returns s, which must be UTF-8, as a NUL-terminated big-endian
+// UTF-16 string, as PKCS #12 encodes passwords.
+func bmpString(s []byte) ([]byte, error) {
+	if !utf8.Valid(s) {
+		return nil, errors.New("ed25519: PKCS #12 passphrase is not valid UTF-8")
+	}
+	var b []byte
+	for _, r := range utf16.Encode([]rune(string(s))) {
+		b = append(b, byte(r>>8), byte(r))
+	}
+	return append(b, 0, 0), nil
+}
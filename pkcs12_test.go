@@ -0,0 +1,136 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/asn1"
+	"testing"
+)
+
+func TestPKCS12OpenSSL(t *testing.T) {
+	want, err := ParsePKCS8PrivateKey(readPEM(t, "testdata/openssl_ed25519.pem", "PRIVATE KEY"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := readPEM(t, "testdata/openssl_ed25519_cert.pem", "CERTIFICATE")
+
+	// openssl pkcs12 -export, with the certificate and a CA certificate, and
+	// with -nocerts and either -macalg sha1 or an empty password.
+	for _, tc := range []struct {
+		name, passphrase string
+		certs            int
+	}{
+		{"openssl_ed25519.p12", testPassphrase, 2},
+		{"openssl_ed25519_sha1mac.p12", testPassphrase, 0},
+		{"openssl_ed25519_nopass.p12", "", 0},
+	} {
+		der := readTestdata(t, tc.name)
+		private, certs, err := DecodePKCS12(der, []byte(tc.passphrase))
+		if err != nil {
+			t.Errorf("%s: %s", tc.name, err)
+			continue
+		}
+		if !bytes.Equal(private, want) {
+			t.Errorf("%s: wrong private key", tc.name)
+		}
+		if len(certs) != tc.certs {
+			t.Errorf("%s: %d certificates, want %d", tc.name, len(certs), tc.certs)
+		} else if len(certs) > 0 && !bytes.Equal(certs[0], cert) {
+			t.Errorf("%s: first certificate is not that of the key", tc.name)
+		}
+		if _, _, err := DecodePKCS12(der, []byte("wrong")); err != ErrIncorrectPassphrase {
+			t.Errorf("%s: wrong passphrase gave %v", tc.name, err)
+		}
+	}
+}
+
+func TestPKCS12RoundTrip(t *testing.T) {
+	_, private, _ := GenerateKey(rand.Reader)
+	leaf, err := CreateSelfSignedCert(private, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca := readPEM(t, "testdata/openssl_ed25519_cert.pem", "CERTIFICATE")
+	opts := &EncryptionOptions{Iterations: 1000}
+
+	for _, tc := range []struct {
+		name       string
+		certs      [][]byte
+		passphrase string
+	}{
+		{"no certificates", nil, testPassphrase},
+		{"chain", [][]byte{leaf, ca}, testPassphrase},
+		{"empty passphrase", [][]byte{leaf}, ""},
+		{"non-ASCII passphrase", [][]byte{leaf}, "pässwörd 🔑"},
+	} {
+		der, err := EncodePKCS12(private, tc.certs, []byte(tc.passphrase), opts)
+		if err != nil {
+			t.Fatalf("%s: %s", tc.name, err)
+		}
+		got, certs, err := DecodePKCS12(der, []byte(tc.passphrase))
+		if err != nil {
+			t.Errorf("%s: %s", tc.name, err)
+			continue
+		}
+		if !bytes.Equal(got, private) {
+			t.Errorf("%s: private key didn't round-trip", tc.name)
+		}
+		if len(certs) != len(tc.certs) {
+			t.Errorf("%s: %d certificates, want %d", tc.name, len(certs), len(tc.certs))
+			continue
+		}
+		for i := range certs {
+			if !bytes.Equal(certs[i], tc.certs[i]) {
+				t.Errorf("%s: certificate %d didn't round-trip", tc.name, i)
+			}
+		}
+	}
+
+	// The certificate of the key comes first even if it wasn't.
+	der, err := EncodePKCS12(private, [][]byte{ca, leaf}, []byte(testPassphrase), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, certs, err := DecodePKCS12(der, []byte(testPassphrase)); err != nil || len(certs) != 2 || !bytes.Equal(certs[0], leaf) || !bytes.Equal(certs[1], ca) {
+		t.Errorf("certificates not reordered: %v", err)
+	}
+}
+
+func TestPKCS12Errors(t *testing.T) {
+	_, private, _ := GenerateKey(rand.Reader)
+	opts := &EncryptionOptions{Iterations: 1000}
+	if _, err := EncodePKCS12(private[:63], nil, []byte(testPassphrase), opts); err != ErrBadPrivateKeyLength {
+		t.Errorf("short key gave %v", err)
+	}
+	if _, err := EncodePKCS12(private, nil, []byte{0xff}, opts); err == nil {
+		t.Error("passphrase that isn't UTF-8 accepted")
+	}
+
+	der, err := EncodePKCS12(private, nil, []byte(testPassphrase), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, bad := range [][]byte{nil, der[:len(der)-1], append(der, 0)} {
+		if _, _, err := DecodePKCS12(bad, []byte(testPassphrase)); err == nil {
+			t.Errorf("%d-byte file accepted", len(bad))
+		}
+	}
+	// Changing a byte of the contents, well inside the file, breaks the MAC.
+	bad := append([]byte(nil), der...)
+	bad[len(bad)/2] ^= 1
+	if _, _, err := DecodePKCS12(bad, []byte(testPassphrase)); err == nil {
+		t.Error("corrupted file accepted")
+	}
+	// An empty SafeContents, which has no key.
+	data, _ := marshalDataContentInfo([]byte{0x30, 0})
+	authSafe, _ := asn1.Marshal([]contentInfo{data})
+	pfx, _ := marshalDataContentInfo(authSafe)
+	noKey, _ := asn1.Marshal(pfxPDU{Version: 3, AuthSafe: pfx})
+	if _, _, err := DecodePKCS12(noKey, nil); err == nil {
+		t.Error("file without a key accepted")
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+)
+
+// oidEd25519 is id-Ed25519 from RFC 8410, Section 3.
+var oidEd25519 = asn1.ObjectIdentifier{1, 3, 101, 112}
+
+// oneAsymmetricKey is the PKCS #8 private key structure, OneAsymmetricKey
+// from RFC 5958, Section 2. Version 0 ends at PrivateKey; version 1 (v2) may
+// add the public key.
+type oneAsymmetricKey struct {
+	Version    int
+	Algorithm  pkix.AlgorithmIdentifier
+	PrivateKey []byte
+	Attributes asn1.RawValue  `asn1:"optional,tag:0"`
+	PublicKey  asn1.BitString `asn1:"optional,tag:1"`
+}
+
+// MarshalPKCS8PrivateKey returns the PKCS #8, ASN.1 DER encoding of
+// privateKey as specified by RFC 8410, Section 7: a version 0
+// OneAsymmetricKey with the id-Ed25519 algorithm whose privateKey is the
+// seed, wrapped in an OCTET STRING. This is the encoding produced by
+// crypto/x509 and by OpenSSL, usually found in "PRIVATE KEY" PEM blocks.
+func MarshalPKCS8PrivateKey(privateKey PrivateKey) ([]byte, error) {
+	if len(privateKey) != PrivateKeySize {
+		return nil, ErrBadPrivateKeyLength
+	}
+
+	curvePrivateKey, err := asn1.Marshal(privateKey[:SeedSize])
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(oneAsymmetricKey{
+		Algorithm:  pkix.AlgorithmIdentifier{Algorithm: oidEd25519},
+		PrivateKey: curvePrivateKey,
+	})
+}
+
+// ParsePKCS8PrivateKey parses an Ed25519 private key in PKCS #8, ASN.1 DER
+// form, as produced by MarshalPKCS8PrivateKey.
+//
+// Both versions of RFC 5958 are accepted. Attributes are ignored, but if the
+// optional public key is present it must be the one derived from the seed,
+// or ParsePKCS8PrivateKey returns ErrKeyMismatch. Keys for other algorithms,
+// and algorithm identifiers with parameters, which RFC 8410 forbids, are
+// rejected.
+func ParsePKCS8PrivateKey(der []byte) (PrivateKey, error) {
+	var key oneAsymmetricKey
+	rest, err := asn1.Unmarshal(der, &key)
+	if err != nil {
+		return nil, errors.New("ed25519: malformed PKCS #8 private key: " + err.Error())
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("ed25519: trailing data after PKCS #8 private key")
+	}
+	if key.Version != 0 && key.Version != 1 {
+		return nil, errors.New("ed25519: unsupported PKCS #8 version")
+	}
+	if err := checkAlgorithm(key.Algorithm); err != nil {
+		return nil, err
+	}
+
+	var seed []byte
+	rest, err = asn1.Unmarshal(key.PrivateKey, &seed)
+	if err != nil || len(rest) != 0 {
+		return nil, errors.New("ed25519: malformed CurvePrivateKey in PKCS #8 private key")
+	}
+	if len(seed) != SeedSize {
+		return nil, errors.New("ed25519: bad seed length in PKCS #8 private key")
+	}
+
+	privateKey := NewKeyFromSeed(seed)
+	if key.PublicKey.BitLength != 0 {
+		if key.PublicKey.BitLength != 8*PublicKeySize || !bytes.Equal(key.PublicKey.Bytes, privateKey[SeedSize:]) {
+			return nil, ErrKeyMismatch
+		}
+	}
+	return privateKey, nil
+}
+
+// checkAlgorithm checks that algorithm is id-Ed25519 with absent parameters,
+// as RFC 8410, Section 3, requires.
+func checkAlgorithm(algorithm pkix.AlgorithmIdentifier) error {
+	if !algorithm.Algorithm.Equal(oidEd25519) {
+		return errors.New("ed25519: not an Ed25519 key: algorithm " + algorithm.Algorithm.String())
+	}
+	if len(algorithm.Parameters.FullBytes) != 0 {
+		return errors.New("ed25519: Ed25519 algorithm identifier has parameters")
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	stded25519 "crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"os"
+	"testing"
+)
+
+func readPEM(t *testing.T, path, blockType string) []byte {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != blockType {
+		t.Fatalf("%s: no %s PEM block", path, blockType)
+	}
+	return block.Bytes
+}
+
+func readTestdata(t *testing.T, name string) []byte {
+	data, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestPKCS8RoundTrip(t *testing.T) {
+	_, private, _ := GenerateKey(rand.Reader)
+	der, err := MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParsePKCS8PrivateKey(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(parsed, private) {
+		t.Error("round trip changed the key")
+	}
+
+	// crypto/x509 produces and accepts the same encoding.
+	stdDER, err := x509.MarshalPKCS8PrivateKey(stded25519.PrivateKey(private))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(der, stdDER) {
+		t.Errorf("encoding differs from crypto/x509:\n%x\n%x", der, stdDER)
+	}
+	stdKey, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !private.Equal(stdKey) {
+		t.Error("crypto/x509 parsed a different key")
+	}
+
+	if _, err := MarshalPKCS8PrivateKey(private[:32]); err != ErrBadPrivateKeyLength {
+		t.Errorf("short key: got %v, want ErrBadPrivateKeyLength", err)
+	}
+}
+
+// TestPKCS8OpenSSL parses a key generated by "openssl genpkey -algorithm
+// ed25519", whose public half "openssl pkey -text" printed.
+func TestPKCS8OpenSSL(t *testing.T) {
+	der := readPEM(t, "testdata/openssl_ed25519.pem", "PRIVATE KEY")
+	private, err := ParsePKCS8PrivateKey(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := decodeHex(t, "2d5692cef3c3d206b521592043356859916af86bd273135a150dfef86e969a30")
+	if !bytes.Equal(private[32:], want) {
+		t.Errorf("public key is %x, want %x", private[32:], want)
+	}
+
+	again, err := MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again, der) {
+		t.Error("re-encoding differs from OpenSSL's")
+	}
+}
+
+// TestPKCS8Version2 parses the example of RFC 8410, Section 10.3, a version 2
+// key with an attribute and the public key.
+func TestPKCS8Version2(t *testing.T) {
+	der := readPEM(t, "testdata/rfc8410_private_v2.pem", "PRIVATE KEY")
+	private, err := ParsePKCS8PrivateKey(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seed := decodeHex(t, "d4ee72dbf913584ad5b6d8f1f769f8ad3afe7c28cbf1d4fbe097a88f44755842"); !bytes.Equal(private.Seed(), seed) {
+		t.Errorf("seed is %x, want %x", private.Seed(), seed)
+	}
+
+	// Corrupting the embedded public key is detected. It is the last 32
+	// bytes of the encoding.
+	corrupted := append([]byte{}, der...)
+	corrupted[len(corrupted)-1] ^= 1
+	if _, err := ParsePKCS8PrivateKey(corrupted); err != ErrKeyMismatch {
+		t.Errorf("corrupted public key: got %v, want ErrKeyMismatch", err)
+	}
+}
+
+func TestPKCS8Errors(t *testing.T) {
+	_, private, _ := GenerateKey(rand.Reader)
+	der, _ := MarshalPKCS8PrivateKey(private)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ecDER, _ := x509.MarshalPKCS8PrivateKey(ecKey)
+
+	wrappedSeed, _ := asn1.Marshal(private[:32])
+	marshal := func(key oneAsymmetricKey) []byte {
+		if key.Algorithm.Algorithm == nil {
+			key.Algorithm.Algorithm = oidEd25519
+		}
+		out, err := asn1.Marshal(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+	longSeed, _ := asn1.Marshal(append(private[:32:32], 0))
+	null, _ := asn1.Marshal(asn1.NullRawValue)
+
+	for _, test := range []struct {
+		name string
+		der  []byte
+	}{
+		{"empty", nil},
+		{"truncated", der[:len(der)-1]},
+		{"trailing data", append(der, 0)},
+		{"ECDSA key", ecDER},
+		{"algorithm parameters", marshal(oneAsymmetricKey{
+			Algorithm:  pkix.AlgorithmIdentifier{Algorithm: oidEd25519, Parameters: asn1.RawValue{FullBytes: null}},
+			PrivateKey: wrappedSeed,
+		})},
+		{"33-byte seed", marshal(oneAsymmetricKey{PrivateKey: longSeed})},
+		{"unwrapped seed", marshal(oneAsymmetricKey{PrivateKey: private[:32]})},
+		{"version 3", marshal(oneAsymmetricKey{Version: 2, PrivateKey: wrappedSeed})},
+		{"short public key", marshal(oneAsymmetricKey{
+			Version:    1,
+			PrivateKey: wrappedSeed,
+			PublicKey:  asn1.BitString{Bytes: private[32:63], BitLength: 248},
+		})},
+	} {
+		if _, err := ParsePKCS8PrivateKey(test.der); err == nil {
+			t.Errorf("%s: ParsePKCS8PrivateKey succeeded", test.name)
+		}
+	}
+
+	// The constructed encodings are otherwise valid.
+	withPublic := marshal(oneAsymmetricKey{
+		Version:    1,
+		PrivateKey: wrappedSeed,
+		PublicKey:  asn1.BitString{Bytes: private[32:], BitLength: 256},
+	})
+	if parsed, err := ParsePKCS8PrivateKey(withPublic); err != nil || !bytes.Equal(parsed, private) {
+		t.Errorf("version 2 key with public key: got %x, %v", parsed, err)
+	}
+	if !bytes.Equal(marshal(oneAsymmetricKey{PrivateKey: wrappedSeed}), der) {
+		t.Error("test encoding differs from MarshalPKCS8PrivateKey")
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+)
+
+// subjectPublicKeyInfo is the SubjectPublicKeyInfo structure from RFC 5280,
+// Section 4.1.
+type subjectPublicKeyInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+// MarshalPKIXPublicKey returns the PKIX, ASN.1 DER encoding of publicKey as
+// specified by RFC 8410, Section 4: a SubjectPublicKeyInfo with the
+// id-Ed25519 algorithm, no parameters and the 32-byte key as the BIT STRING.
+// This is the encoding produced by crypto/x509 and by OpenSSL, usually found
+// in "PUBLIC KEY" PEM blocks.
+func MarshalPKIXPublicKey(publicKey PublicKey) ([]byte, error) {
+	if len(publicKey) != PublicKeySize {
+		return nil, ErrBadPublicKeyLength
+	}
+	return asn1.Marshal(subjectPublicKeyInfo{
+		Algorithm: pkix.AlgorithmIdentifier{Algorithm: oidEd25519},
+		PublicKey: asn1.BitString{Bytes: publicKey, BitLength: 8 * PublicKeySize},
+	})
+}
+
+// ParsePKIXPublicKey parses an Ed25519 public key in PKIX, ASN.1 DER form, as
+// produced by MarshalPKIXPublicKey. Keys for other algorithms, algorithm
+// identifiers with parameters, which RFC 8410 forbids, and keys that are not
+// PublicKeySize bytes long are rejected.
+//
+// If requireCanonical is true the key must also be the canonical encoding of
+// a point of large order, as for StrictVerify, or ParsePKIXPublicKey returns
+// ErrInvalidPublicKey. Otherwise, like crypto/x509, it doesn't look at the
+// key bytes, and invalid keys are only detected when verifying.
+func ParsePKIXPublicKey(der []byte, requireCanonical bool) (PublicKey, error) {
+	var spki subjectPublicKeyInfo
+	rest, err := asn1.Unmarshal(der, &spki)
+	if err != nil {
+		return nil, errors.New("ed25519: malformed PKIX public key: " + err.Error())
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("ed25519: trailing data after PKIX public key")
+	}
+	if err := checkAlgorithm(spki.Algorithm); err != nil {
+		return nil, err
+	}
+	if spki.PublicKey.BitLength != 8*PublicKeySize || len(spki.PublicKey.Bytes) != PublicKeySize {
+		return nil, ErrBadPublicKeyLength
+	}
+
+	publicKey := make(PublicKey, PublicKeySize)
+	copy(publicKey, spki.PublicKey.Bytes)
+	if requireCanonical {
+		if err := checkPublicKey(publicKey); err != nil {
+			return nil, err
+		}
+	}
+	return publicKey, nil
+}
@@ -0,0 +1,147 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	stded25519 "crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"testing"
+)
+
+func TestPKIXRoundTrip(t *testing.T) {
+	public, _, _ := GenerateKey(rand.Reader)
+	der, err := MarshalPKIXPublicKey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, requireCanonical := range []bool{false, true} {
+		parsed, err := ParsePKIXPublicKey(der, requireCanonical)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(parsed, public) {
+			t.Error("round trip changed the key")
+		}
+	}
+
+	// The encoding is the one crypto/x509 uses in both directions.
+	stdDER, err := x509.MarshalPKIXPublicKey(stded25519.PublicKey(public))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(der, stdDER) {
+		t.Errorf("encoding differs from crypto/x509:\n%x\n%x", der, stdDER)
+	}
+	stdKey, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !public.Equal(stdKey) {
+		t.Error("crypto/x509 parsed a different key")
+	}
+
+	if _, err := MarshalPKIXPublicKey(public[:31]); err != ErrBadPublicKeyLength {
+		t.Errorf("short key: got %v, want ErrBadPublicKeyLength", err)
+	}
+}
+
+// TestPKIXOpenSSL parses the public key of testdata/openssl_ed25519.pem, as
+// written by "openssl pkey -pubout".
+func TestPKIXOpenSSL(t *testing.T) {
+	der := readPEM(t, "testdata/openssl_ed25519_pub.pem", "PUBLIC KEY")
+	public, err := ParsePKIXPublicKey(der, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	private, err := ParsePKCS8PrivateKey(readPEM(t, "testdata/openssl_ed25519.pem", "PRIVATE KEY"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckKeyPair(public, private); err != nil {
+		t.Errorf("OpenSSL key pair: %s", err)
+	}
+
+	again, err := MarshalPKIXPublicKey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again, der) {
+		t.Error("re-encoding differs from OpenSSL's")
+	}
+	stdKey, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !public.Equal(stdKey) {
+		t.Error("crypto/x509 parsed a different key")
+	}
+}
+
+func TestPKIXErrors(t *testing.T) {
+	public, _, _ := GenerateKey(rand.Reader)
+	der, _ := MarshalPKIXPublicKey(public)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ecDER, _ := x509.MarshalPKIXPublicKey(&ecKey.PublicKey)
+	null, _ := asn1.Marshal(asn1.NullRawValue)
+
+	marshal := func(spki subjectPublicKeyInfo) []byte {
+		if spki.Algorithm.Algorithm == nil {
+			spki.Algorithm.Algorithm = oidEd25519
+		}
+		out, err := asn1.Marshal(spki)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+	if !bytes.Equal(marshal(subjectPublicKeyInfo{PublicKey: asn1.BitString{Bytes: public, BitLength: 256}}), der) {
+		t.Fatal("test encoding differs from MarshalPKIXPublicKey")
+	}
+
+	for _, test := range []struct {
+		name string
+		der  []byte
+	}{
+		{"empty", nil},
+		{"truncated", der[:len(der)-1]},
+		{"trailing data", append(der, 0)},
+		{"ECDSA key", ecDER},
+		{"NULL parameters", marshal(subjectPublicKeyInfo{
+			Algorithm: pkix.AlgorithmIdentifier{Algorithm: oidEd25519, Parameters: asn1.RawValue{FullBytes: null}},
+			PublicKey: asn1.BitString{Bytes: public, BitLength: 256},
+		})},
+		{"31-byte key", marshal(subjectPublicKeyInfo{PublicKey: asn1.BitString{Bytes: public[:31], BitLength: 248}})},
+		{"33-byte key", marshal(subjectPublicKeyInfo{PublicKey: asn1.BitString{Bytes: append(public, 0), BitLength: 264}})},
+		{"partial byte", marshal(subjectPublicKeyInfo{PublicKey: asn1.BitString{Bytes: public, BitLength: 255}})},
+	} {
+		for _, requireCanonical := range []bool{false, true} {
+			if _, err := ParsePKIXPublicKey(test.der, requireCanonical); err == nil {
+				t.Errorf("%s: ParsePKIXPublicKey succeeded", test.name)
+			}
+		}
+	}
+
+	// Invalid points are only rejected on request.
+	for _, encoding := range []string{
+		smallOrderEncodings[0],
+		"f0ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f",
+		"0200000000000000000000000000000000000000000000000000000000000000",
+	} {
+		key := decodeHex(t, encoding)
+		der := marshal(subjectPublicKeyInfo{PublicKey: asn1.BitString{Bytes: key, BitLength: 256}})
+		if parsed, err := ParsePKIXPublicKey(der, false); err != nil || !bytes.Equal(parsed, key) {
+			t.Errorf("%s: got %x, %v", encoding, parsed, err)
+		}
+		if _, err := ParsePKIXPublicKey(der, true); err != ErrInvalidPublicKey {
+			t.Errorf("%s with requireCanonical: got %v, want ErrInvalidPublicKey", encoding, err)
+		}
+	}
+}
@@ -0,0 +1,332 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	cryptorand "crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/agl/ed25519/internal/argon2"
+)
+
+// PuTTY key files, version 3, are a sequence of "Name: value" header lines
+// with the public and private key blobs in base64, 64 characters per line.
+// The public blob is the SSH wire encoding of the key and the private blob the
+// 32-byte seed as an SSH string. An encrypted private blob is padded to the
+// AES block size with the start of its SHA-1 hash and encrypted with
+// AES-256-CBC. Argon2 derives the AES key, the IV and the key of an
+// HMAC-SHA-256 over the headers and both blobs; unencrypted files use the same
+// MAC with an empty key.
+
+const (
+	ppkHeader   = "PuTTY-User-Key-File-3"
+	ppkLineSize = 64
+
+	// The defaults of MarshalPPK. The memory and parallelism are those of
+	// puttygen, which picks the passes to take a tenth of a second.
+	defaultPPKArgon2Memory      = 8192
+	defaultPPKArgon2Passes      = 21
+	defaultPPKArgon2Parallelism = 1
+
+	// maxPPKArgon2Memory, in KiB, and maxPPKArgon2Passes bound the work
+	// that ParsePPK does for an untrusted key.
+	maxPPKArgon2Memory = 256 * 1024
+	maxPPKArgon2Passes = 1000
+)
+
+// ErrPPKMAC is returned by ParsePPK when the MAC of an unencrypted key file
+// doesn't match, because the file has been modified. For encrypted files that
+// can't be told apart from a wrong passphrase, so ErrIncorrectPassphrase is
+// returned instead.
+var ErrPPKMAC = errors.New("ed25519: PuTTY key file MAC mismatch")
+
+var errBadPPK = errors.New("ed25519: malformed PuTTY key file")
+
+// PPKArgon2Params configures the Argon2id key derivation of MarshalPPK. The
+// zero value selects the defaults: 8192 KiB of memory, 21 passes and a
+// parallelism of 1.
+type PPKArgon2Params struct {
+	// Memory is the memory size in KiB.
+	Memory uint32
+
+	// Passes is the number of passes over the memory.
+	Passes uint32
+
+	// Parallelism is the number of lanes.
+	Parallelism uint8
+
+	// Rand is the source of the salt. If nil, crypto/rand.Reader is used.
+	Rand io.Reader
+}
+
+// MarshalPPK returns privateKey in the format of a PuTTY version 3 key file.
+// If passphrase is not empty the key is encrypted with a key derived from it
+// by Argon2id with params, which may be nil for the defaults. If passphrase is
+// empty the key is stored unencrypted and params is ignored. comment must not
+// contain a line break.
+func MarshalPPK(privateKey PrivateKey, comment string, passphrase []byte, params *PPKArgon2Params) ([]byte, error) {
+	if len(privateKey) != PrivateKeySize {
+		return nil, ErrBadPrivateKeyLength
+	}
+	if strings.ContainsAny(comment, "\r\n") {
+		return nil, errors.New("ed25519: bad PuTTY key comment")
+	}
+	var p PPKArgon2Params
+	if params != nil {
+		p = *params
+	}
+	if p.Memory == 0 {
+		p.Memory = defaultPPKArgon2Memory
+	}
+	if p.Passes == 0 {
+		p.Passes = defaultPPKArgon2Passes
+	}
+	if p.Parallelism == 0 {
+		p.Parallelism = defaultPPKArgon2Parallelism
+	}
+	if p.Rand == nil {
+		p.Rand = cryptorand.Reader
+	}
+	if p.Memory < 8*uint32(p.Parallelism) || p.Memory > maxPPKArgon2Memory || p.Passes > maxPPKArgon2Passes {
+		return nil, errors.New("ed25519: bad Argon2 parameters")
+	}
+
+	publicBlob := sshPublicKeyBlob(PublicKey(privateKey[32:]))
+	privateBlob := appendSSHString(nil, privateKey[:SeedSize])
+	defer wipeBytes(privateBlob)
+
+	encryption := "none"
+	var kdfHeaders string
+	var macKey []byte
+	var block cipher.Block
+	var iv []byte
+	if len(passphrase) > 0 {
+		encryption = "aes256-cbc"
+		salt := make([]byte, 16)
+		if _, err := io.ReadFull(p.Rand, salt); err != nil {
+			return nil, err
+		}
+		kdfHeaders = "Key-Derivation: Argon2id\n" +
+			"Argon2-Memory: " + strconv.FormatUint(uint64(p.Memory), 10) + "\n" +
+			"Argon2-Passes: " + strconv.FormatUint(uint64(p.Passes), 10) + "\n" +
+			"Argon2-Parallelism: " + strconv.Itoa(int(p.Parallelism)) + "\n" +
+			"Argon2-Salt: " + hex.EncodeToString(salt) + "\n"
+		keys := argon2.IDKey(passphrase, salt, p.Passes, p.Memory, p.Parallelism, 32+aes.BlockSize+32)
+		defer wipeBytes(keys)
+		var err error
+		if block, err = aes.NewCipher(keys[:32]); err != nil {
+			return nil, err
+		}
+		iv, macKey = keys[32:32+aes.BlockSize], keys[32+aes.BlockSize:]
+
+		sum := sha1.Sum(privateBlob)
+		privateBlob = append(privateBlob, sum[:(aes.BlockSize-len(privateBlob)%aes.BlockSize)%aes.BlockSize]...)
+	}
+
+	mac := ppkMAC(macKey, encryption, comment, publicBlob, privateBlob)
+	if block != nil {
+		cipher.NewCBCEncrypter(block, iv).CryptBlocks(privateBlob, privateBlob)
+	}
+
+	var b strings.Builder
+	b.WriteString(ppkHeader + ": " + sshKeyType + "\n")
+	b.WriteString("Encryption: " + encryption + "\n")
+	b.WriteString("Comment: " + comment + "\n")
+	writePPKBlob(&b, "Public-Lines", publicBlob)
+	b.WriteString(kdfHeaders)
+	writePPKBlob(&b, "Private-Lines", privateBlob)
+	b.WriteString("Private-MAC: " + hex.EncodeToString(mac) + "\n")
+	return []byte(b.String()), nil
+}
+
+// ParsePPK parses a PuTTY version 3 key file for an ssh-ed25519 key and
+// decrypts it with passphrase, which is ignored if the key isn't encrypted.
+// Only Argon2id and Argon2i key derivation are supported, and not the
+// version 2 format.
+//
+// The MAC is checked before the key is decrypted further or parsed. ParsePPK
+// returns ErrPassphraseRequired if the key is encrypted and passphrase is
+// empty, ErrIncorrectPassphrase if the MAC of an encrypted file doesn't
+// match, ErrPPKMAC if that of an unencrypted file doesn't, and ErrKeyMismatch
+// if the public key is not that of the private key.
+func ParsePPK(data, passphrase []byte) (privateKey PrivateKey, comment string, err error) {
+	r := ppkReader{data: string(data)}
+	if r.field(ppkHeader) != sshKeyType {
+		if r.err == nil && strings.HasPrefix(string(data), "PuTTY-User-Key-File-") {
+			return nil, "", errors.New("ed25519: unsupported PuTTY key file version or key type")
+		}
+		return nil, "", errBadPPK
+	}
+	encryption := r.field("Encryption")
+	comment = r.field("Comment")
+	publicBlob := r.blob("Public-Lines")
+
+	var macKey []byte
+	var block cipher.Block
+	var iv []byte
+	switch encryption {
+	case "none":
+	case "aes256-cbc":
+		kdf := r.field("Key-Derivation")
+		memory := r.number("Argon2-Memory", maxPPKArgon2Memory)
+		passes := r.number("Argon2-Passes", maxPPKArgon2Passes)
+		parallelism := r.number("Argon2-Parallelism", 255)
+		salt, err := hex.DecodeString(r.field("Argon2-Salt"))
+		if r.err != nil || err != nil || passes == 0 || parallelism == 0 || memory < 8*parallelism {
+			return nil, "", errBadPPK
+		}
+		if len(passphrase) == 0 {
+			return nil, "", ErrPassphraseRequired
+		}
+		var keys []byte
+		switch kdf {
+		case "Argon2id":
+			keys = argon2.IDKey(passphrase, salt, passes, memory, uint8(parallelism), 32+aes.BlockSize+32)
+		case "Argon2i":
+			keys = argon2.Key(passphrase, salt, passes, memory, uint8(parallelism), 32+aes.BlockSize+32)
+		default:
+			return nil, "", errors.New("ed25519: unsupported PuTTY key derivation " + kdf)
+		}
+		defer wipeBytes(keys)
+		if block, err = aes.NewCipher(keys[:32]); err != nil {
+			return nil, "", err
+		}
+		iv, macKey = keys[32:32+aes.BlockSize], keys[32+aes.BlockSize:]
+	default:
+		if r.err == nil {
+			return nil, "", errors.New("ed25519: unsupported PuTTY key encryption " + encryption)
+		}
+	}
+	privateBlob := r.blob("Private-Lines")
+	mac, err := hex.DecodeString(r.field("Private-MAC"))
+	if r.err != nil || err != nil || r.data != "" {
+		return nil, "", errBadPPK
+	}
+	defer wipeBytes(privateBlob)
+
+	if block != nil {
+		if len(privateBlob)%aes.BlockSize != 0 {
+			return nil, "", errBadPPK
+		}
+		cipher.NewCBCDecrypter(block, iv).CryptBlocks(privateBlob, privateBlob)
+	}
+	if !hmac.Equal(ppkMAC(macKey, encryption, comment, publicBlob, privateBlob), mac) {
+		if block != nil {
+			return nil, "", ErrIncorrectPassphrase
+		}
+		return nil, "", ErrPPKMAC
+	}
+
+	publicKey, err := parseSSHPublicKeyBlob(publicBlob)
+	if err != nil {
+		return nil, "", err
+	}
+	seed, rest, ok := readSSHString(privateBlob)
+	if !ok || len(seed) != SeedSize || (block == nil && len(rest) != 0) {
+		return nil, "", errBadPPK
+	}
+	privateKey = NewKeyFromSeed(seed)
+	if err := checkKeyPair(publicKey, privateKey); err != nil {
+		wipeBytes(privateKey)
+		return nil, "", err
+	}
+	return privateKey, comment, nil
+}
+
+// ppkMAC returns the HMAC-SHA-256 with key that authenticates a PuTTY key
+// file, over the key type, the encryption, the comment, the public blob and
+// the unencrypted, padded private blob, each as an SSH string.
+func ppkMAC(key []byte, encryption, comment string, publicBlob, privateBlob []byte) []byte {
+	var b []byte
+	b = appendSSHString(b, []byte(sshKeyType))
+	b = appendSSHString(b, []byte(encryption))
+	b = appendSSHString(b, []byte(comment))
+	b = appendSSHString(b, publicBlob)
+	b = appendSSHString(b, privateBlob)
+	defer wipeBytes(b)
+	mac := hmac.New(sha256.New, key)
+	mac.Write(b)
+	return mac.Sum(nil)
+}
+
+// writePPKBlob writes the header name, with the number of lines, and blob in
+// base64 split into lines.
+func writePPKBlob(b *strings.Builder, name string, blob []byte) {
+	s := base64.StdEncoding.EncodeToString(blob)
+	b.WriteString(name + ": " + strconv.Itoa((len(s)+ppkLineSize-1)/ppkLineSize) + "\n")
+	for len(s) > 0 {
+		n := min(len(s), ppkLineSize)
+		b.WriteString(s[:n] + "\n")
+		s = s[n:]
+	}
+}
+
+// ppkReader reads the lines of a PuTTY key file in order. After the first
+// error it returns zero values and leaves err set.
+type ppkReader struct {
+	data string
+	err  error
+}
+
+// line returns the next line, which may end with CRLF or LF.
+func (r *ppkReader) line() string {
+	if r.err != nil {
+		return ""
+	}
+	line, rest, ok := strings.Cut(r.data, "\n")
+	if !ok {
+		r.err = errBadPPK
+		return ""
+	}
+	r.data = rest
+	return strings.TrimSuffix(line, "\r")
+}
+
+// field returns the value of the next line, which must be the header name.
+func (r *ppkReader) field(name string) string {
+	value, ok := strings.CutPrefix(r.line(), name+": ")
+	if !ok {
+		r.err = errBadPPK
+		return ""
+	}
+	return value
+}
+
+// number returns the value of the next line, the header name, as a decimal
+// number of at most limit.
+func (r *ppkReader) number(name string, limit uint32) uint32 {
+	n, err := strconv.ParseUint(r.field(name), 10, 32)
+	if err != nil || n > uint64(limit) {
+		r.err = errBadPPK
+		return 0
+	}
+	return uint32(n)
+}
+
+// blob reads the header name, with the line count, and the base64 lines that
+// follow it.
+func (r *ppkReader) blob(name string) []byte {
+	n := r.number(name, 1024)
+	var s bytes.Buffer
+	for i := uint32(0); i < n; i++ {
+		s.WriteString(r.line())
+	}
+	b, err := base64.StdEncoding.Strict().DecodeString(s.String())
+	if r.err == nil && err != nil {
+		r.err = errBadPPK
+	}
+	return b
+}
@@ -0,0 +1,131 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"strings"
+	"testing"
+)
+
+func TestPPKFixtures(t *testing.T) {
+	seed := sha256.Sum256([]byte("putty test key"))
+	want := NewKeyFromSeed(seed[:])
+
+	// putty_ed25519_encrypted.ppk has CRLF line endings.
+	for _, tc := range []struct {
+		name, passphrase string
+	}{
+		{"putty_ed25519.ppk", ""},
+		{"putty_ed25519_encrypted.ppk", testPassphrase},
+	} {
+		data := readTestdata(t, tc.name)
+		private, comment, err := ParsePPK(data, []byte(tc.passphrase))
+		if err != nil {
+			t.Fatalf("%s: %s", tc.name, err)
+		}
+		if !bytes.Equal(private, want) || comment != "eddsa-key-20261014" {
+			t.Errorf("%s: got key %x, comment %q", tc.name, private, comment)
+		}
+
+		if tc.passphrase == "" {
+			if got, err := MarshalPPK(private, comment, nil, nil); err != nil || !bytes.Equal(got, data) {
+				t.Errorf("MarshalPPK = %q, %v, want %q", got, err, data)
+			}
+			continue
+		}
+		if _, _, err := ParsePPK(data, nil); err != ErrPassphraseRequired {
+			t.Errorf("%s: no passphrase gave %v", tc.name, err)
+		}
+		if _, _, err := ParsePPK(data, []byte("wrong")); err != ErrIncorrectPassphrase {
+			t.Errorf("%s: wrong passphrase gave %v", tc.name, err)
+		}
+	}
+}
+
+func TestPPKRoundTrip(t *testing.T) {
+	_, private, _ := GenerateKey(rand.Reader)
+	params := &PPKArgon2Params{Memory: 64, Passes: 1}
+	for _, passphrase := range []string{testPassphrase, ""} {
+		data, err := MarshalPPK(private, "test key", []byte(passphrase), params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, comment, err := ParsePPK(data, []byte(passphrase))
+		if err != nil {
+			t.Errorf("passphrase %q: %s", passphrase, err)
+			continue
+		}
+		if !bytes.Equal(got, private) || comment != "test key" {
+			t.Errorf("passphrase %q: key didn't round-trip", passphrase)
+		}
+	}
+
+	if _, err := MarshalPPK(private, "a\nb", nil, nil); err == nil {
+		t.Error("comment with a line break accepted")
+	}
+	if _, err := MarshalPPK(private[:63], "", nil, nil); err != ErrBadPrivateKeyLength {
+		t.Errorf("short key gave %v", err)
+	}
+	if _, err := MarshalPPK(private, "", []byte(testPassphrase), &PPKArgon2Params{Memory: maxPPKArgon2Memory + 1}); err == nil {
+		t.Error("too much memory accepted")
+	}
+}
+
+func TestPPKTampering(t *testing.T) {
+	plain := string(readTestdata(t, "putty_ed25519.ppk"))
+	encrypted := string(readTestdata(t, "putty_ed25519_encrypted.ppk"))
+
+	// The MAC covers the comment, the encryption and both blobs.
+	for _, tc := range []struct {
+		name string
+		data string
+		want error
+	}{
+		{"comment", strings.Replace(plain, "Comment: eddsa", "Comment: EdDSA", 1), ErrPPKMAC},
+		{"public key", strings.Replace(plain, "AAAAINyZ", "AAAAINyY", 1), ErrPPKMAC},
+		{"private key", strings.Replace(plain, "AAAAIETC", "AAAAIETD", 1), ErrPPKMAC},
+		{"MAC", strings.Replace(plain, "Private-MAC: 0440", "Private-MAC: 0441", 1), ErrPPKMAC},
+		{"encrypted comment", strings.Replace(encrypted, "Comment: eddsa", "Comment: EdDSA", 1), ErrIncorrectPassphrase},
+		{"encrypted private key", strings.Replace(encrypted, "FN6y", "FN6z", 1), ErrIncorrectPassphrase},
+	} {
+		if tc.data == plain || tc.data == encrypted {
+			t.Fatalf("%s: replacement didn't apply", tc.name)
+		}
+		if _, _, err := ParsePPK([]byte(tc.data), []byte(testPassphrase)); err != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.name, err, tc.want)
+		}
+	}
+
+	// A key whose public half doesn't match its seed, with a valid MAC.
+	_, private, _ := GenerateKey(rand.Reader)
+	other, _, _ := GenerateKey(rand.Reader)
+	mismatched := append(private[:32:32], other...)
+	data, err := MarshalPPK(mismatched, "", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ParsePPK(data, nil); err != ErrKeyMismatch {
+		t.Errorf("mismatched key gave %v", err)
+	}
+
+	for _, bad := range []string{
+		"",
+		plain[:len(plain)-1],
+		plain + "\n",
+		strings.Replace(plain, "File-3", "File-2", 1),
+		strings.Replace(plain, "ssh-ed25519", "ssh-rsa", 1),
+		strings.Replace(plain, "Public-Lines: 2", "Public-Lines: 3", 1),
+		strings.Replace(plain, "Encryption: none", "Encryption: aes128-cbc", 1),
+		strings.Replace(encrypted, "Argon2id", "Argon2d", 1),
+		strings.Replace(encrypted, "Argon2-Memory: 8192", "Argon2-Memory: 1000000000", 1),
+	} {
+		if _, _, err := ParsePPK([]byte(bad), []byte(testPassphrase)); err == nil {
+			t.Errorf("malformed file accepted:\n%s", bad)
+		}
+	}
+}
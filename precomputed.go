@@ -0,0 +1,95 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"crypto/subtle"
+
+	"github.com/agl/ed25519/edwards25519"
+)
+
+// PrecomputedPublicKey is a public key that has been decoded and validated
+// once, together with the table of multiples of -A used by verification.
+// Verifying many signatures with a PrecomputedPublicKey is faster than
+// calling Verify with the same PublicKey each time.
+//
+// A PrecomputedPublicKey is safe for concurrent use.
+type PrecomputedPublicKey struct {
+	publicKey [32]byte
+	A         edwards25519.ExtendedGroupElement
+	minusAi   [8]edwards25519.CachedGroupElement // -A,-3A,-5A,...,-15A
+}
+
+// NewPrecomputedPublicKey decodes publicKey and precomputes the values needed
+// to verify signatures with it. It returns an error if publicKey is not the
+// canonical encoding of a point, or if the point has small order: no
+// signature made with such a key proves anything. The error is
+// ErrBadPublicKeyLength or ErrInvalidPublicKey.
+func NewPrecomputedPublicKey(publicKey PublicKey) (*PrecomputedPublicKey, error) {
+	if len(publicKey) != PublicKeySize {
+		return nil, ErrBadPublicKeyLength
+	}
+
+	p := new(PrecomputedPublicKey)
+	copy(p.publicKey[:], publicKey)
+	if !p.A.FromBytes(&p.publicKey) || !isCanonicalEncoding(&p.A, &p.publicKey) || p.A.IsSmallOrder() {
+		return nil, ErrInvalidPublicKey
+	}
+
+	minusA := p.A
+	edwards25519.FeNeg(&minusA.X, &minusA.X)
+	edwards25519.FeNeg(&minusA.T, &minusA.T)
+	edwards25519.GePrecomputeOddMultiples(&p.minusAi, &minusA)
+
+	return p, nil
+}
+
+// PublicKey returns the encoded public key.
+func (p *PrecomputedPublicKey) PublicKey() PublicKey {
+	publicKey := make([]byte, PublicKeySize)
+	copy(publicKey, p.publicKey[:])
+	return publicKey
+}
+
+// Verify reports whether sig is a valid signature of message by p, applying
+// exactly the same rules as the Verify function.
+func (p *PrecomputedPublicKey) Verify(message, sig []byte) bool {
+	if len(sig) != SignatureSize {
+		return false
+	}
+
+	var s [32]byte
+	copy(s[:], sig[32:])
+	if edwards25519.ScIsCanonical(&s) != 1 {
+		return false
+	}
+
+	var k [32]byte
+	computeK(&k, nil, sig[:32], p.publicKey[:], message)
+
+	var R edwards25519.ProjectiveGroupElement
+	edwards25519.GeDoubleScalarMultPrecomputedVartime(&R, &k, &p.minusAi, &s)
+
+	var checkR [32]byte
+	R.ToBytes(&checkR)
+	return subtle.ConstantTimeCompare(sig[:32], checkR[:]) == 1
+}
+
+// VerifyBatch reports whether every sig[i] is a valid signature of
+// messages[i] by p, using batch verification as BatchVerifier does. It
+// panics if messages and sigs have different lengths.
+func (p *PrecomputedPublicKey) VerifyBatch(messages, sigs [][]byte) bool {
+	if len(messages) != len(sigs) {
+		panic("ed25519: mismatched number of messages and signatures")
+	}
+
+	v := NewBatchVerifier(nil)
+	for i := range sigs {
+		if err := v.AddPrecomputed(p, messages[i], sigs[i]); err != nil {
+			return false
+		}
+	}
+	return v.VerifyAll()
+}
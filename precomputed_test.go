@@ -0,0 +1,166 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+// checkPrecomputed checks that p.Verify agrees with Verify on the given
+// inputs.
+func checkPrecomputed(t *testing.T, p *PrecomputedPublicKey, message, sig []byte, desc string) {
+	t.Helper()
+	want := Verify(p.PublicKey(), message, sig)
+	if got := p.Verify(message, sig); got != want {
+		t.Errorf("%s: PrecomputedPublicKey.Verify returned %v but Verify returned %v", desc, got, want)
+	}
+}
+
+func TestPrecomputedPublicKey(t *testing.T) {
+	for _, m := range newSignedMessages(t, 16) {
+		p, err := NewPrecomputedPublicKey(m.public)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(p.PublicKey(), m.public) {
+			t.Errorf("PublicKey() = %x, want %x", p.PublicKey(), m.public)
+		}
+		if !p.Verify(m.message, m.sig) {
+			t.Errorf("valid signature rejected")
+		}
+
+		checkPrecomputed(t, p, []byte("tampered"), m.sig, "wrong message")
+		checkPrecomputed(t, p, m.message, m.sig[:SignatureSize-1], "short signature")
+		malleated := append(append([]byte(nil), m.sig[:32]...), addL(m.sig[32:])...)
+		checkPrecomputed(t, p, m.message, malleated, "S + L")
+		for i := 0; i < 8*SignatureSize; i += 7 {
+			sig := append([]byte(nil), m.sig...)
+			sig[i/8] ^= 1 << uint(i%8)
+			checkPrecomputed(t, p, m.message, sig, "bit flip")
+		}
+		random := make([]byte, SignatureSize)
+		rand.Read(random)
+		checkPrecomputed(t, p, m.message, random, "random signature")
+	}
+}
+
+func TestPrecomputedPublicKeyEdgeCases(t *testing.T) {
+	for i, v := range mixedOrderVectors {
+		p, err := NewPrecomputedPublicKey(decodeHex(t, v.A))
+		if err != nil {
+			t.Fatalf("#%d: %s", i, err)
+		}
+		sig := append(decodeHex(t, v.R), decodeHex(t, v.S)...)
+		checkPrecomputed(t, p, []byte(v.M), sig, "mixed order")
+
+		// Small-order R values with S = 0, including non-canonical
+		// encodings, under a key that isn't small order.
+		for _, R := range smallOrderEncodings {
+			sig := append(decodeHex(t, R), make([]byte, 32)...)
+			checkPrecomputed(t, p, []byte(v.M), sig, "small-order R "+R)
+		}
+	}
+}
+
+func TestPrecomputedPublicKeyInvalid(t *testing.T) {
+	for _, enc := range smallOrderEncodings {
+		if _, err := NewPrecomputedPublicKey(decodeHex(t, enc)); err == nil {
+			t.Errorf("small-order or non-canonical key %s accepted", enc)
+		}
+	}
+
+	// The values p, p+1, ..., p+18 fit in 255 bits and are non-canonical
+	// encodings of y = 0, ..., 18. Some of them decode to points that
+	// aren't small order, but all must be rejected.
+	for y := 0; y < 19; y++ {
+		enc := make([]byte, 32)
+		enc[0] = 0xed + byte(y)
+		for i := 1; i < 31; i++ {
+			enc[i] = 0xff
+		}
+		enc[31] = 0x7f
+		if _, err := NewPrecomputedPublicKey(enc); err == nil {
+			t.Errorf("non-canonical key %x accepted", enc)
+		}
+	}
+
+	pub, _, err := GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewPrecomputedPublicKey(pub[:31]); err == nil {
+		t.Errorf("short key accepted")
+	}
+}
+
+func TestPrecomputedPublicKeyBatch(t *testing.T) {
+	pub, priv, err := GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewPrecomputedPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var messages, sigs [][]byte
+	for i := 0; i < 16; i++ {
+		message := []byte{byte(i)}
+		messages = append(messages, message)
+		sigs = append(sigs, mustSign(t, priv, message))
+	}
+	if !p.VerifyBatch(messages, sigs) {
+		t.Errorf("valid batch rejected")
+	}
+
+	sigs[3] = sigs[4]
+	if p.VerifyBatch(messages, sigs) {
+		t.Errorf("batch with a bad signature accepted")
+	}
+	sigs[3] = sigs[3][:10]
+	if p.VerifyBatch(messages, sigs) {
+		t.Errorf("batch with a malformed signature accepted")
+	}
+}
+
+// BenchmarkVerificationPrecomputed and BenchmarkVerificationRepeated verify
+// 100 signatures under the same key, with and without precomputation.
+func BenchmarkVerificationPrecomputed(b *testing.B) {
+	pub, messages, sigs := newRepeatedKeyBenchmark(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p, _ := NewPrecomputedPublicKey(pub)
+		for j := range sigs {
+			p.Verify(messages[j], sigs[j])
+		}
+	}
+}
+
+func BenchmarkVerificationRepeated(b *testing.B) {
+	pub, messages, sigs := newRepeatedKeyBenchmark(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := range sigs {
+			Verify(pub, messages[j], sigs[j])
+		}
+	}
+}
+
+func newRepeatedKeyBenchmark(b *testing.B) (PublicKey, [][]byte, [][]byte) {
+	var zero zeroReader
+	pub, priv, err := GenerateKey(zero)
+	if err != nil {
+		b.Fatal(err)
+	}
+	var messages, sigs [][]byte
+	for i := 0; i < 100; i++ {
+		message := []byte{byte(i)}
+		messages = append(messages, message)
+		sigs = append(sigs, mustSign(b, priv, message))
+	}
+	return pub, messages, sigs
+}
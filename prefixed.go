@@ -0,0 +1,81 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"errors"
+	"strings"
+)
+
+// NEAR and other chains write keys and signatures as the key type, a colon and
+// the base58 of the bytes, as in "ed25519:DzvCTxRFk4Sc3Y9S29L9be72TYHzcRtku4qSgWt8ttAu".
+// Private keys are the 64 bytes of the seed followed by the public key, the
+// layout of PrivateKey, and not an expanded key.
+
+const prefixedKeyPrefix = "ed25519:"
+
+var (
+	// ErrPrefixedKeyType is returned by ParsePrefixedKey when a string
+	// doesn't start with "ed25519:", for example because it is a key of
+	// another type such as "secp256k1:".
+	ErrPrefixedKeyType = errors.New("ed25519: key string doesn't start with \"ed25519:\"")
+
+	// ErrPrefixedKeyLength is returned by ParsePrefixedKey when a string is
+	// well formed but doesn't hold the number of bytes of the kind asked
+	// for.
+	ErrPrefixedKeyLength = errors.New("ed25519: key string has the wrong length for its kind")
+)
+
+// FormatPrefixedKey returns b, a key or signature of the given kind, as an
+// "ed25519:" string. kind must be KindPublicKey, KindPrivateKey64 or
+// KindSignature; private keys and signatures are both 64 bytes long, so the
+// kind can't be told from the string. It returns ErrBadPublicKeyLength,
+// ErrBadPrivateKeyLength or ErrBadSignatureLength if b has the wrong length
+// for kind.
+func FormatPrefixedKey(kind KeyKind, b []byte) (string, error) {
+	if size, err := prefixedKeySize(kind); size == 0 || len(b) != size {
+		return "", err
+	}
+	return prefixedKeyPrefix + encodeBase58(b), nil
+}
+
+// ParsePrefixedKey returns the bytes of s, an "ed25519:" string of a key or a
+// signature of the given kind. A private key must have the public key of its
+// seed or the error is ErrKeyMismatch. Public keys and signatures are only
+// checked for length; Verify checks the rest.
+func ParsePrefixedKey(kind KeyKind, s string) ([]byte, error) {
+	size, lengthErr := prefixedKeySize(kind)
+	if size == 0 {
+		return nil, lengthErr
+	}
+	encoded, ok := strings.CutPrefix(s, prefixedKeyPrefix)
+	if !ok {
+		return nil, ErrPrefixedKeyType
+	}
+	b, ok := decodeBase58(encoded)
+	if !ok || len(encoded) == 0 {
+		return nil, errors.New("ed25519: malformed base58 in key string")
+	}
+	if len(b) != size {
+		wipeBytes(b)
+		return nil, ErrPrefixedKeyLength
+	}
+	if kind == KindPrivateKey64 {
+		if err := checkKeyPair(PublicKey(b[32:]), PrivateKey(b)); err != nil {
+			wipeBytes(b)
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// prefixedKeySize is like keyKindSize, but without seeds, which have no
+// "ed25519:" form.
+func prefixedKeySize(kind KeyKind) (int, error) {
+	if kind == KindSeed {
+		return 0, errBadKeyKind
+	}
+	return keyKindSize(kind)
+}
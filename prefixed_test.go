@@ -0,0 +1,114 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+const (
+	prefixedPublicKey  = "ed25519:DzvCTxRFk4Sc3Y9S29L9be72TYHzcRtku4qSgWt8ttAu"
+	prefixedPrivateKey = "ed25519:xdzRN6AGzJwDFrJG2yPcbsDaf5mJ3cqTDEfMwEZSyV74o4gQDb9gPpznqzMqcKVX4g7oK3K8EqrvTzW5YcLd2kZ"
+	prefixedSignature  = "ed25519:wcCHP6K1CF4V53cNofgqBkksDvLL7j9wCvA6gLAd2QifDYx7Jz2ECxNjFgWJkbaM8LjdVWhJPyxEtodXQK9UppH"
+	prefixedMessage    = "hello, NEAR"
+)
+
+func TestPrefixedKey(t *testing.T) {
+	publicKey, err := ParsePrefixedKey(KindPublicKey, prefixedPublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateKey, err := ParsePrefixedKey(KindPrivateKey64, prefixedPrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := ParsePrefixedKey(KindSignature, prefixedSignature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(privateKey[32:], publicKey) {
+		t.Error("private key doesn't match the public key")
+	}
+	if !Verify(publicKey, []byte(prefixedMessage), sig) {
+		t.Error("signature doesn't verify")
+	}
+
+	for _, test := range []struct {
+		kind KeyKind
+		b    []byte
+		want string
+	}{
+		{KindPublicKey, publicKey, prefixedPublicKey},
+		{KindPrivateKey64, privateKey, prefixedPrivateKey},
+		{KindSignature, sig, prefixedSignature},
+	} {
+		if got, err := FormatPrefixedKey(test.kind, test.b); err != nil || got != test.want {
+			t.Errorf("FormatPrefixedKey(%d) = %s, %v, want %s", test.kind, got, err, test.want)
+		}
+	}
+
+	// A leading zero byte is a leading '1' in base58.
+	zero := append(PublicKey{0}, publicKey[1:]...)
+	s, _ := FormatPrefixedKey(KindPublicKey, zero)
+	if s != "ed25519:1XWhNNyFgtpD2wPqkvyooFuWfFEM5EnCfK4Xt14ncaM" {
+		t.Errorf("key with a leading zero is %s", s)
+	}
+	if got, err := ParsePrefixedKey(KindPublicKey, s); err != nil || !bytes.Equal(got, zero) {
+		t.Errorf("ParsePrefixedKey(%s) = %x, %v", s, got, err)
+	}
+}
+
+func TestPrefixedKeyErrors(t *testing.T) {
+	for _, test := range []struct {
+		kind KeyKind
+		s    string
+		err  error
+	}{
+		{KindPublicKey, prefixedPublicKey[len("ed25519:"):], ErrPrefixedKeyType},
+		{KindPublicKey, "secp256k1:" + prefixedPublicKey[len("ed25519:"):], ErrPrefixedKeyType},
+		{KindPublicKey, "ED25519:" + prefixedPublicKey[len("ed25519:"):], ErrPrefixedKeyType},
+		{KindPublicKey, " " + prefixedPublicKey, ErrPrefixedKeyType},
+		{KindPublicKey, prefixedPrivateKey, ErrPrefixedKeyLength},
+		{KindPublicKey, prefixedPublicKey[:len(prefixedPublicKey)-4], ErrPrefixedKeyLength},
+		{KindPublicKey, "ed25519:1" + prefixedPublicKey[len("ed25519:"):], ErrPrefixedKeyLength},
+		{KindPrivateKey64, prefixedPublicKey, ErrPrefixedKeyLength},
+		{KindSignature, prefixedPublicKey, ErrPrefixedKeyLength},
+		{KindSignature, prefixedSignature + "1", ErrPrefixedKeyLength},
+	} {
+		if _, err := ParsePrefixedKey(test.kind, test.s); err != test.err {
+			t.Errorf("ParsePrefixedKey(%d, %q) gave %v, want %v", test.kind, test.s, err, test.err)
+		}
+	}
+
+	for _, bad := range []string{"ed25519:", "ed25519:0" + prefixedPublicKey[9:], "ed25519:" + prefixedPublicKey[8:40] + "l" + prefixedPublicKey[41:], prefixedPublicKey + " "} {
+		_, err := ParsePrefixedKey(KindPublicKey, bad)
+		if err == nil || err == ErrPrefixedKeyType || err == ErrPrefixedKeyLength {
+			t.Errorf("ParsePrefixedKey(%q) gave %v", bad, err)
+		}
+	}
+
+	// A private key whose public half is another key.
+	_, private, _ := GenerateKey(rand.Reader)
+	other, _, _ := GenerateKey(rand.Reader)
+	mismatched := append([]byte(nil), private[:32]...)
+	s, _ := FormatPrefixedKey(KindPrivateKey64, append(mismatched, other...))
+	if _, err := ParsePrefixedKey(KindPrivateKey64, s); err != ErrKeyMismatch {
+		t.Errorf("mismatched private key gave %v", err)
+	}
+
+	if _, err := FormatPrefixedKey(KindSignature, make([]byte, 63)); err != ErrBadSignatureLength {
+		t.Errorf("short signature gave %v", err)
+	}
+	if _, err := FormatPrefixedKey(0, make([]byte, 32)); err == nil {
+		t.Error("unknown kind accepted by FormatPrefixedKey")
+	}
+	for _, kind := range []KeyKind{KindSeed, 5} {
+		if _, err := ParsePrefixedKey(kind, prefixedPublicKey); err == nil || err == ErrPrefixedKeyLength {
+			t.Errorf("kind %d gave %v", kind, err)
+		}
+	}
+}
@@ -0,0 +1,371 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package ristretto255 implements the ristretto255 prime-order group of
+// RFC 9496, on the edwards25519 package. Each element is a coset of the
+// points of order 4, and is encoded as one canonical 32-byte string, so
+// protocols built on the group needn't worry about the cofactor of the curve.
+package ristretto255
+
+import (
+	"crypto/subtle"
+	"errors"
+
+	"github.com/agl/ed25519/edwards25519"
+)
+
+var (
+	errInvalidEncoding = errors.New("ristretto255: invalid element encoding")
+	errUniformLength   = errors.New("ristretto255: invalid uniform bytes length")
+)
+
+// d is the constant of the Edwards curve, -121665/121666.
+var d = edwards25519.FieldElement{
+	56195235, 13857412, 51736253, 6949390, 114729, 24766616, 60832955, 30306712, 48412415, 21499315,
+}
+
+// invSqrtAMinusD is 1/sqrt(a - d), the INVSQRT_A_MINUS_D of RFC 9496, where
+// a = -1.
+var invSqrtAMinusD = edwards25519.FieldElement{
+	6111466, 4156064, 39310137, 12243467, 41204824, 120896, 20826367, 26493656, 6093567, 31568420,
+}
+
+// sqrtADMinusOne is sqrt(a*d - 1), the SQRT_AD_MINUS_ONE of RFC 9496.
+var sqrtADMinusOne = edwards25519.FieldElement{
+	24849947, 33400850, 43495378, 6347714, 46036536, 32887293, 41837720, 18186727, 66238516, 14525638,
+}
+
+// oneMinusDSq is 1 - d^2, the ONE_MINUS_D_SQ of RFC 9496.
+var oneMinusDSq = edwards25519.FieldElement{
+	6275446, 16937061, 44170319, 29780721, 11667076, 7397348, 39186143, 1766194, 42675006, 672202,
+}
+
+// dMinusOneSq is (d - 1)^2, the D_MINUS_ONE_SQ of RFC 9496.
+var dMinusOneSq = edwards25519.FieldElement{
+	15551776, 22456977, 53683765, 23429360, 55212328, 10178283, 40474537, 4729243, 61826754, 23438029,
+}
+
+// Element is an element of the ristretto255 group. The zero value is NOT
+// valid; use NewIdentityElement, NewGeneratorElement or SetCanonicalBytes.
+//
+// Methods set the receiver to the result and return it, so they can be
+// chained, and the arguments may alias the receiver.
+type Element struct {
+	p edwards25519.Point
+}
+
+// NewIdentityElement returns a new Element set to the identity.
+func NewIdentityElement() *Element {
+	e := new(Element)
+	e.p.Set(edwards25519.NewIdentityPoint())
+	return e
+}
+
+// NewGeneratorElement returns a new Element set to the generator, the class
+// of the Ed25519 base point.
+func NewGeneratorElement() *Element {
+	e := new(Element)
+	e.p.Set(edwards25519.NewGeneratorPoint())
+	return e
+}
+
+// Set sets e = x and returns e.
+func (e *Element) Set(x *Element) *Element {
+	*e = *x
+	return e
+}
+
+// FeToBytes, and so FeIsNegative and FeIsNonZero, reduce their argument in
+// place, to limbs that are too wide for the group law, so the helpers below
+// only ever pass them copies.
+
+// feIsNegative returns 1 if f is negative, that is odd, and 0 otherwise.
+func feIsNegative(f *edwards25519.FieldElement) int32 {
+	t := *f
+	return int32(edwards25519.FeIsNegative(&t))
+}
+
+// feIsZero returns 1 if f is zero, and 0 otherwise.
+func feIsZero(f *edwards25519.FieldElement) int32 {
+	t := *f
+	return 1 - edwards25519.FeIsNonZero(&t)
+}
+
+// feEqual returns 1 if a == b, and 0 otherwise.
+func feEqual(a, b *edwards25519.FieldElement) int32 {
+	var t edwards25519.FieldElement
+	edwards25519.FeSub(&t, a, b)
+	return feIsZero(&t)
+}
+
+// feAbs sets out to whichever of x and -x is non-negative.
+func feAbs(out, x *edwards25519.FieldElement) {
+	var neg edwards25519.FieldElement
+	edwards25519.FeNeg(&neg, x)
+	isNegative := feIsNegative(x)
+	*out = *x
+	edwards25519.FeCMove(out, &neg, isNegative)
+}
+
+// sqrtRatioM1 implements SQRT_RATIO_M1 of RFC 9496, Section 4.2: it sets out
+// to the non-negative square root of u/v and returns 1 if there is one,
+// and otherwise sets out to the non-negative square root of sqrt(-1)*u/v and
+// returns 0. If u is zero, out is zero and the result is 1; if only v is
+// zero, out is zero and the result is 0.
+func sqrtRatioM1(out, u, v *edwards25519.FieldElement) int32 {
+	var v3, v7, r, check, t edwards25519.FieldElement
+	edwards25519.FeSquare(&v3, v)
+	edwards25519.FeMul(&v3, &v3, v)
+	edwards25519.FeSquare(&v7, &v3)
+	edwards25519.FeMul(&v7, &v7, v)
+
+	// r = (u * v^3) * (u * v^7)^((p-5)/8)
+	edwards25519.FeMul(&t, u, &v7)
+	edwards25519.FePow22523(&t, &t)
+	edwards25519.FeMul(&r, u, &v3)
+	edwards25519.FeMul(&r, &r, &t)
+
+	edwards25519.FeSquare(&check, &r)
+	edwards25519.FeMul(&check, &check, v)
+	var minusU, minusUI edwards25519.FieldElement
+	edwards25519.FeNeg(&minusU, u)
+	edwards25519.FeMul(&minusUI, &minusU, &edwards25519.SqrtM1)
+	correct := feEqual(&check, u)
+	flipped := feEqual(&check, &minusU)
+	flippedI := feEqual(&check, &minusUI)
+
+	var rI edwards25519.FieldElement
+	edwards25519.FeMul(&rI, &r, &edwards25519.SqrtM1)
+	edwards25519.FeCMove(&r, &rI, flipped|flippedI)
+	feAbs(out, &r)
+	return correct | flipped
+}
+
+// SetCanonicalBytes sets e to the element encoded by x, which must be the 32
+// bytes returned by Bytes for some element, as decoded in RFC 9496, Section
+// 4.3.1. Otherwise it returns nil and an error and e is unchanged.
+func (e *Element) SetCanonicalBytes(x []byte) (*Element, error) {
+	if len(x) != 32 {
+		return nil, errInvalidEncoding
+	}
+	var buf, check [32]byte
+	copy(buf[:], x)
+	var s, t edwards25519.FieldElement
+	edwards25519.FeFromBytes(&s, &buf)
+	t = s
+	edwards25519.FeToBytes(&check, &t)
+	// s must be below p, with the top bit clear, and non-negative.
+	if subtle.ConstantTimeCompare(check[:], buf[:]) != 1 || check[0]&1 == 1 {
+		return nil, errInvalidEncoding
+	}
+
+	var one, ss, u1, u2, u2Sqr, v edwards25519.FieldElement
+	edwards25519.FeOne(&one)
+	edwards25519.FeSquare(&ss, &s)
+	edwards25519.FeSub(&u1, &one, &ss)
+	edwards25519.FeAdd(&u2, &one, &ss)
+	edwards25519.FeSquare(&u2Sqr, &u2)
+
+	// v = -(d * u1^2) - u2^2
+	edwards25519.FeSquare(&v, &u1)
+	edwards25519.FeMul(&v, &v, &d)
+	edwards25519.FeNeg(&v, &v)
+	edwards25519.FeSub(&v, &v, &u2Sqr)
+
+	var invSqrt, denX, denY edwards25519.FieldElement
+	edwards25519.FeMul(&t, &v, &u2Sqr)
+	wasSquare := sqrtRatioM1(&invSqrt, &one, &t)
+	edwards25519.FeMul(&denX, &invSqrt, &u2)
+	edwards25519.FeMul(&denY, &invSqrt, &denX)
+	edwards25519.FeMul(&denY, &denY, &v)
+
+	var p edwards25519.ExtendedGroupElement
+	edwards25519.FeAdd(&p.X, &s, &s)
+	edwards25519.FeMul(&p.X, &p.X, &denX)
+	feAbs(&p.X, &p.X)
+	edwards25519.FeMul(&p.Y, &u1, &denY)
+	edwards25519.FeOne(&p.Z)
+	edwards25519.FeMul(&p.T, &p.X, &p.Y)
+
+	if wasSquare == 0 || feIsNegative(&p.T) == 1 || feIsZero(&p.Y) == 1 {
+		return nil, errInvalidEncoding
+	}
+	e.p.SetExtendedGroupElement(&p)
+	return e, nil
+}
+
+// SetUniformBytes sets e to the element derived from the 64 bytes of x by
+// the one-way map of RFC 9496, Section 4.3.4, and returns e. If x is
+// uniformly random, so is e, and no discrete logarithm of e is known; this is
+// the map that hash_to_ristretto255 of RFC 9380 applies to its
+// expand_message_xmd output. It returns nil and an error if len(x) is not 64.
+func (e *Element) SetUniformBytes(x []byte) (*Element, error) {
+	if len(x) != 64 {
+		return nil, errUniformLength
+	}
+	var b [32]byte
+	var t edwards25519.FieldElement
+	copy(b[:], x[:32])
+	edwards25519.FeFromBytes(&t, &b)
+	var p1, p2 edwards25519.Point
+	mapToPoint(&p1, &t)
+	copy(b[:], x[32:])
+	edwards25519.FeFromBytes(&t, &b)
+	mapToPoint(&p2, &t)
+	e.p.Add(&p1, &p2)
+	return e, nil
+}
+
+// mapToPoint sets p to MAP(t) of RFC 9496, Section 4.3.4.
+func mapToPoint(p *edwards25519.Point, t *edwards25519.FieldElement) {
+	var one, minusOne, r, u, v, s, tmp edwards25519.FieldElement
+	edwards25519.FeOne(&one)
+	edwards25519.FeNeg(&minusOne, &one)
+
+	// r = SQRT_M1 * t^2, u = (r + 1) * ONE_MINUS_D_SQ
+	edwards25519.FeSquare(&r, t)
+	edwards25519.FeMul(&r, &r, &edwards25519.SqrtM1)
+	edwards25519.FeAdd(&u, &r, &one)
+	edwards25519.FeMul(&u, &u, &oneMinusDSq)
+
+	// v = (-1 - r*d) * (r + d)
+	edwards25519.FeMul(&v, &r, &d)
+	edwards25519.FeSub(&v, &minusOne, &v)
+	edwards25519.FeAdd(&tmp, &r, &d)
+	edwards25519.FeMul(&v, &v, &tmp)
+
+	wasSquare := sqrtRatioM1(&s, &u, &v)
+	var sPrime edwards25519.FieldElement
+	edwards25519.FeMul(&sPrime, &s, t)
+	feAbs(&sPrime, &sPrime)
+	edwards25519.FeNeg(&sPrime, &sPrime)
+	edwards25519.FeCMove(&s, &sPrime, 1-wasSquare)
+	c := minusOne
+	edwards25519.FeCMove(&c, &r, 1-wasSquare)
+
+	// N = c * (r - 1) * D_MINUS_ONE_SQ - v
+	var n edwards25519.FieldElement
+	edwards25519.FeSub(&n, &r, &one)
+	edwards25519.FeMul(&n, &n, &c)
+	edwards25519.FeMul(&n, &n, &dMinusOneSq)
+	edwards25519.FeSub(&n, &n, &v)
+
+	var w0, w1, w2, w3, sSq edwards25519.FieldElement
+	edwards25519.FeMul(&w0, &s, &v)
+	edwards25519.FeAdd(&w0, &w0, &w0)
+	edwards25519.FeMul(&w1, &n, &sqrtADMinusOne)
+	edwards25519.FeSquare(&sSq, &s)
+	edwards25519.FeSub(&w2, &one, &sSq)
+	edwards25519.FeAdd(&w3, &one, &sSq)
+
+	var q edwards25519.ExtendedGroupElement
+	edwards25519.FeMul(&q.X, &w0, &w3)
+	edwards25519.FeMul(&q.Y, &w2, &w1)
+	edwards25519.FeMul(&q.Z, &w1, &w3)
+	edwards25519.FeMul(&q.T, &w0, &w2)
+	p.SetExtendedGroupElement(&q)
+}
+
+// Bytes returns the canonical 32-byte encoding of e, as in RFC 9496,
+// Section 4.3.2.
+func (e *Element) Bytes() []byte {
+	p := e.p.ExtendedGroupElement()
+	var u1, u2, t, invSqrt, one edwards25519.FieldElement
+	edwards25519.FeOne(&one)
+
+	// u1 = (z0 + y0) * (z0 - y0), u2 = x0 * y0
+	edwards25519.FeAdd(&u1, &p.Z, &p.Y)
+	edwards25519.FeSub(&t, &p.Z, &p.Y)
+	edwards25519.FeMul(&u1, &u1, &t)
+	edwards25519.FeMul(&u2, &p.X, &p.Y)
+
+	edwards25519.FeSquare(&t, &u2)
+	edwards25519.FeMul(&t, &t, &u1)
+	sqrtRatioM1(&invSqrt, &one, &t)
+
+	var den1, den2, zInv edwards25519.FieldElement
+	edwards25519.FeMul(&den1, &invSqrt, &u1)
+	edwards25519.FeMul(&den2, &invSqrt, &u2)
+	edwards25519.FeMul(&zInv, &den1, &den2)
+	edwards25519.FeMul(&zInv, &zInv, &p.T)
+
+	var ix0, iy0, enchantedDenominator edwards25519.FieldElement
+	edwards25519.FeMul(&ix0, &p.X, &edwards25519.SqrtM1)
+	edwards25519.FeMul(&iy0, &p.Y, &edwards25519.SqrtM1)
+	edwards25519.FeMul(&enchantedDenominator, &den1, &invSqrtAMinusD)
+
+	var tZInv edwards25519.FieldElement
+	edwards25519.FeMul(&tZInv, &p.T, &zInv)
+	rotate := feIsNegative(&tZInv)
+
+	x, y, denInv := p.X, p.Y, den2
+	edwards25519.FeCMove(&x, &iy0, rotate)
+	edwards25519.FeCMove(&y, &ix0, rotate)
+	edwards25519.FeCMove(&denInv, &enchantedDenominator, rotate)
+
+	edwards25519.FeMul(&t, &x, &zInv)
+	var minusY edwards25519.FieldElement
+	edwards25519.FeNeg(&minusY, &y)
+	edwards25519.FeCMove(&y, &minusY, feIsNegative(&t))
+
+	var s edwards25519.FieldElement
+	edwards25519.FeSub(&s, &p.Z, &y)
+	edwards25519.FeMul(&s, &s, &denInv)
+	feAbs(&s, &s)
+
+	var out [32]byte
+	edwards25519.FeToBytes(&out, &s)
+	return out[:]
+}
+
+// Equal returns 1 if e and x are the same element, and 0 otherwise, as in
+// RFC 9496, Section 4.5. It runs in constant time.
+func (e *Element) Equal(x *Element) int {
+	p, q := e.p.ExtendedGroupElement(), x.p.ExtendedGroupElement()
+	var x1y2, y1x2, y1y2, x1x2 edwards25519.FieldElement
+	edwards25519.FeMul(&x1y2, &p.X, &q.Y)
+	edwards25519.FeMul(&y1x2, &p.Y, &q.X)
+	edwards25519.FeMul(&y1y2, &p.Y, &q.Y)
+	edwards25519.FeMul(&x1x2, &p.X, &q.X)
+	return int(feEqual(&x1y2, &y1x2) | feEqual(&y1y2, &x1x2))
+}
+
+// Add sets e = p + q and returns e.
+func (e *Element) Add(p, q *Element) *Element {
+	e.p.Add(&p.p, &q.p)
+	return e
+}
+
+// Subtract sets e = p - q and returns e.
+func (e *Element) Subtract(p, q *Element) *Element {
+	e.p.Subtract(&p.p, &q.p)
+	return e
+}
+
+// Negate sets e = -p and returns e.
+func (e *Element) Negate(p *Element) *Element {
+	e.p.Negate(&p.p)
+	return e
+}
+
+// ScalarBaseMult sets e = x * G, where G is the generator, and returns e. It
+// runs in constant time.
+func (e *Element) ScalarBaseMult(x *edwards25519.Scalar) *Element {
+	e.p.ScalarBaseMult(x)
+	return e
+}
+
+// ScalarMult sets e = x * p and returns e. It runs in constant time.
+func (e *Element) ScalarMult(x *edwards25519.Scalar, p *Element) *Element {
+	e.p.ScalarMult(x, &p.p)
+	return e
+}
+
+// VarTimeDoubleScalarBaseMult sets e = a * A + b * G, where G is the
+// generator, and returns e. Its running time depends on the inputs, so it
+// must only be used with public values, as in signature verification.
+func (e *Element) VarTimeDoubleScalarBaseMult(a *edwards25519.Scalar, A *Element, b *edwards25519.Scalar) *Element {
+	e.p.VarTimeDoubleScalarBaseMult(a, &A.p, b)
+	return e
+}
@@ -0,0 +1,126 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"crypto/ecdh"
+	cryptorand "crypto/rand"
+	"errors"
+	"io"
+
+	"github.com/agl/ed25519/internal/blake2b"
+	"github.com/agl/ed25519/internal/secretbox"
+)
+
+// A sealed box of libsodium, crypto_box_seal, is a crypto_box from a fresh
+// ephemeral key pair to the recipient: the ephemeral public key, followed by
+// the box of the plaintext under the nonce BLAKE2b-192(ephemeral public key ||
+// recipient public key). The sender can't open it afterwards and the recipient
+// learns nothing about who sent it. Here the recipient's X25519 key is the
+// one converted from an Ed25519 identity, as libsodium's
+// crypto_sign_ed25519_pk_to_curve25519 converts it.
+
+// SealedBoxOverhead is the number of bytes that SealAnonymous adds to a
+// plaintext, crypto_box_SEALBYTES.
+const SealedBoxOverhead = 32 + secretbox.Overhead
+
+// ErrBoxAuthentication is returned when a box doesn't open: it is too short,
+// was altered, or wasn't made for the key that tried to open it.
+var ErrBoxAuthentication = errors.New("ed25519: box failed to authenticate")
+
+// boxKey returns the key of crypto_box_beforenm, HSalsa20 of the X25519 shared
+// secret of priv and pub. It fails if the shared secret is all zeros, as
+// libsodium does.
+func boxKey(priv *ecdh.PrivateKey, pub *ecdh.PublicKey) (*[32]byte, error) {
+	shared, err := priv.ECDH(pub)
+	if err != nil {
+		return nil, ErrSmallOrderKey
+	}
+	defer wipeBytes(shared)
+	var key [32]byte
+	var zero [16]byte
+	secretbox.HSalsa20(&key, &zero, (*[32]byte)(shared), &secretbox.Sigma)
+	return &key, nil
+}
+
+// sealedBoxNonce returns the nonce of a sealed box from the ephemeral public
+// key epk to the recipient public key pk.
+func sealedBoxNonce(epk, pk []byte) *[24]byte {
+	h, _ := blake2b.New(24, nil)
+	h.Write(epk)
+	h.Write(pk)
+	var nonce [24]byte
+	h.Sum(nonce[:0])
+	return &nonce
+}
+
+// SealAnonymous encrypts plaintext to the holder of the Ed25519 public key
+// publicKey, as crypto_box_seal of libsodium does to its converted X25519 key,
+// with an ephemeral key pair made from rand. If rand is nil, crypto/rand.Reader
+// will be used. The result is SealedBoxOverhead bytes longer than plaintext,
+// and opens with OpenAnonymous or libsodium's crypto_box_seal_open.
+//
+// publicKey is converted as by PublicKeyToX25519, with its errors.
+func SealAnonymous(publicKey PublicKey, plaintext []byte, rand io.Reader) ([]byte, error) {
+	u, err := PublicKeyToX25519(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	pk, err := ecdh.X25519().NewPublicKey(u[:])
+	if err != nil {
+		return nil, err
+	}
+	if rand == nil {
+		rand = cryptorand.Reader
+	}
+	var seed [32]byte
+	if _, err := io.ReadFull(rand, seed[:]); err != nil {
+		return nil, err
+	}
+	esk, err := ecdh.X25519().NewPrivateKey(seed[:])
+	wipeBytes(seed[:])
+	if err != nil {
+		return nil, err
+	}
+	key, err := boxKey(esk, pk)
+	if err != nil {
+		return nil, err
+	}
+	defer wipeBytes(key[:])
+
+	epk := esk.PublicKey().Bytes()
+	box := make([]byte, 0, len(plaintext)+SealedBoxOverhead)
+	box = append(box, epk...)
+	return secretbox.Seal(box, plaintext, sealedBoxNonce(epk, u[:]), key), nil
+}
+
+// OpenAnonymous decrypts box, made by SealAnonymous or libsodium's
+// crypto_box_seal for the public key of privateKey. It returns
+// ErrBoxAuthentication if box doesn't open.
+func OpenAnonymous(privateKey PrivateKey, box []byte) ([]byte, error) {
+	if len(box) < SealedBoxOverhead {
+		return nil, ErrBoxAuthentication
+	}
+	sk, err := privateKey.ToECDH()
+	if err != nil {
+		return nil, err
+	}
+	epk, err := ecdh.X25519().NewPublicKey(box[:32])
+	if err != nil {
+		return nil, ErrBoxAuthentication
+	}
+	key, err := boxKey(sk, epk)
+	if err != nil {
+		return nil, ErrBoxAuthentication
+	}
+	defer wipeBytes(key[:])
+
+	nonce := sealedBoxNonce(box[:32], sk.PublicKey().Bytes())
+	plaintext, ok := secretbox.Open(nil, box[32:], nonce, key)
+	if !ok {
+		return nil, ErrBoxAuthentication
+	}
+	return plaintext, nil
+}
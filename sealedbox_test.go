@@ -0,0 +1,124 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+// sealedBoxTests are boxes made by crypto_box_seal of libsodium 1.0.18 for the
+// X25519 keys that crypto_sign_ed25519_pk_to_curve25519 converts from the
+// public keys of libsodiumTests.
+var sealedBoxTests = []struct {
+	key            int
+	plaintext, box string
+}{
+	{
+		0,
+		"",
+		"7b1a52fdc2af3f56044664fb5c2522e2368cb4ddae960e6bd45168a965ff5a02efdc1f94aba28e41f846c03c5b2e75e5",
+	},
+	{
+		0,
+		"6c6962736f6469756d207365616c656420626f782066697874757265",
+		"f2bd13b598e5ee76b45f10337887b9610e81a6374c295d2576e4213595d201363cc2b321a2b61562be4f37fce6217100c6d790dcad553cb349c7e4436dfe58f3f675fe50a9673ef3f3e749a1",
+	},
+	{
+		1,
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f202122232425262728292a2b2c2d2e2f303132333435363738393a3b3c3d3e3f404142434445464748494a4b4c4d4e4f505152535455565758595a5b5c5d5e5f60616263",
+		"66c33ded1b4a9ed28234fcec8876f9730ebf72dbf21a9fbce9f98345d15aff438a30d581cc2ccf216ecf0587cd18a0e75cbc6873295ed919904141845b1d6432ea46008c97b679c8582b55fe1e4c6f662bbffb572cc6617cd2f3be66721c331459c9fb12b443625067345875fe0cd3fa55b3f81d903da97afccafc5b0da51a493a6154bc69ff2b1e9e1fc98c09b6a56e6ce5907d",
+	},
+}
+
+func TestOpenAnonymousLibsodium(t *testing.T) {
+	for i, test := range sealedBoxTests {
+		priv := PrivateKey(decodeHex(t, libsodiumTests[test.key].sk))
+		box := decodeHex(t, test.box)
+		plaintext, err := OpenAnonymous(priv, box)
+		if err != nil {
+			t.Errorf("%d: %v", i, err)
+			continue
+		}
+		if !bytes.Equal(plaintext, decodeHex(t, test.plaintext)) {
+			t.Errorf("%d: got %x, want %s", i, plaintext, test.plaintext)
+		}
+
+		for j := range box {
+			bad := append([]byte(nil), box...)
+			bad[j] ^= 0x20
+			if _, err := OpenAnonymous(priv, bad); err != ErrBoxAuthentication {
+				t.Errorf("%d: box with byte %d changed gave %v", i, j, err)
+			}
+		}
+		if _, err := OpenAnonymous(priv, box[:len(box)-1]); err != ErrBoxAuthentication {
+			t.Errorf("%d: truncated box gave %v", i, err)
+		}
+		other := PrivateKey(decodeHex(t, libsodiumTests[2].sk))
+		if _, err := OpenAnonymous(other, box); err != ErrBoxAuthentication {
+			t.Errorf("%d: box opened by another key gave %v", i, err)
+		}
+	}
+}
+
+func TestSealAnonymous(t *testing.T) {
+	public, private, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []int{0, 1, 31, 32, 33, 100, 1000} {
+		plaintext := make([]byte, n)
+		rand.Read(plaintext)
+		box, err := SealAnonymous(public, plaintext, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(box) != n+SealedBoxOverhead {
+			t.Errorf("%d bytes sealed to %d", n, len(box))
+		}
+		opened, err := OpenAnonymous(private, box)
+		if err != nil || !bytes.Equal(opened, plaintext) {
+			t.Errorf("%d bytes opened to %x, %v", n, opened, err)
+		}
+	}
+
+	// Each box has a fresh ephemeral key.
+	a, _ := SealAnonymous(public, []byte("message"), rand.Reader)
+	b, _ := SealAnonymous(public, []byte("message"), rand.Reader)
+	if bytes.Equal(a, b) {
+		t.Error("two boxes of the same message are equal")
+	}
+
+	// A nil rand uses crypto/rand.Reader.
+	box, err := SealAnonymous(public, []byte("message"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opened, err := OpenAnonymous(private, box); err != nil || string(opened) != "message" {
+		t.Errorf("box sealed with nil rand opened to %q, %v", opened, err)
+	}
+}
+
+func TestSealAnonymousErrors(t *testing.T) {
+	for _, enc := range smallOrderEncodings {
+		if _, err := SealAnonymous(decodeHex(t, enc), nil, rand.Reader); err == nil {
+			t.Errorf("small-order %s accepted", enc)
+		}
+	}
+	public := decodeHex(t, libsodiumTests[0].pk)
+	if _, err := SealAnonymous(public, nil, bytes.NewReader(make([]byte, 31))); err == nil {
+		t.Error("short random source accepted")
+	}
+
+	// An ephemeral key of small order makes the shared secret zero.
+	priv := PrivateKey(decodeHex(t, libsodiumTests[0].sk))
+	if _, err := OpenAnonymous(priv, make([]byte, SealedBoxOverhead)); err != ErrBoxAuthentication {
+		t.Errorf("zero ephemeral key gave %v", err)
+	}
+	if _, err := OpenAnonymous(priv[:32], decodeHex(t, sealedBoxTests[0].box)); err != ErrBadPrivateKeyLength {
+		t.Errorf("short private key gave %v", err)
+	}
+}
@@ -0,0 +1,344 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+// This code is a port of the public domain, “ref10” implementation of ed25519
+// from SUPERCOP.
+
+import (
+	"crypto"
+	stded25519 "crypto/ed25519"
+	cryptorand "crypto/rand"
+	"crypto/subtle"
+	"hash"
+	"io"
+	"strconv"
+
+	"github.com/agl/ed25519/edwards25519"
+)
+
+const (
+	// PublicKeySize is the size, in bytes, of public keys as used in this package.
+	PublicKeySize = 32
+	// PrivateKeySize is the size, in bytes, of private keys as used in this package.
+	PrivateKeySize = 64
+	// SignatureSize is the size, in bytes, of signatures generated and verified by this package.
+	SignatureSize = 64
+	// SeedSize is the size, in bytes, of private key seeds. These are the
+	// private key representations used by RFC 8032.
+	SeedSize = 32
+)
+
+// PublicKey is the type of Ed25519 public keys.
+type PublicKey []byte
+
+// Equal reports whether x is the same public key as pub. x may be a PublicKey
+// from this package or from crypto/ed25519. Note that the Equal method of
+// crypto/ed25519 only accepts its own type, so comparisons in the other
+// direction need a conversion.
+func (pub PublicKey) Equal(x crypto.PublicKey) bool {
+	var other []byte
+	switch x := x.(type) {
+	case PublicKey:
+		other = x
+	case stded25519.PublicKey:
+		other = x
+	default:
+		return false
+	}
+	return subtle.ConstantTimeCompare(pub, other) == 1
+}
+
+// PrivateKey is the type of Ed25519 private keys. It is the seed followed by
+// the public key, and it implements crypto.Signer.
+type PrivateKey []byte
+
+// Public returns the PublicKey corresponding to priv. It will panic if
+// len(priv) is not PrivateKeySize, where Sign returns ErrBadPrivateKeyLength.
+func (priv PrivateKey) Public() crypto.PublicKey {
+	if l := len(priv); l != PrivateKeySize {
+		panic("ed25519: bad private key length: " + strconv.Itoa(l))
+	}
+	publicKey := make([]byte, PublicKeySize)
+	copy(publicKey, priv[32:])
+	return PublicKey(publicKey)
+}
+
+// Equal reports whether x is the same private key as priv. x may be a
+// PrivateKey from this package or from crypto/ed25519. The comparison is
+// constant time.
+func (priv PrivateKey) Equal(x crypto.PrivateKey) bool {
+	var other []byte
+	switch x := x.(type) {
+	case PrivateKey:
+		other = x
+	case stded25519.PrivateKey:
+		other = x
+	default:
+		return false
+	}
+	return subtle.ConstantTimeCompare(priv, other) == 1
+}
+
+// Seed returns the private key seed corresponding to priv. It is provided for
+// interoperability with RFC 8032. RFC 8032's private keys correspond to seeds
+// in this package.
+func (priv PrivateKey) Seed() []byte {
+	seed := make([]byte, SeedSize)
+	copy(seed, priv[:32])
+	return seed
+}
+
+// Sign signs the given message with priv. rand is ignored and the signature
+// is deterministic; use SignWithRand for hedged signatures.
+//
+// If opts.HashFunc() is crypto.SHA512, the pre-hashed variant Ed25519ph is
+// used and message is expected to be a SHA-512 hash, otherwise opts.HashFunc()
+// must be crypto.Hash(0) and the message must not be hashed, as Ed25519
+// performs two passes over messages to be signed.
+//
+// A *Options can be used as opts, or crypto.Hash(0) or crypto.SHA512 directly
+// to select plain Ed25519 or Ed25519ph, respectively.
+func (priv PrivateKey) Sign(rand io.Reader, message []byte, opts crypto.SignerOpts) (signature []byte, err error) {
+	o, ok := opts.(*Options)
+	if !ok {
+		o = &Options{Hash: opts.HashFunc()}
+	}
+
+	return SignWithRand(nil, priv, message, o)
+}
+
+// GenerateKey generates a public/private key pair using entropy from rand.
+// If rand is nil, crypto/rand.Reader will be used.
+//
+// Exactly SeedSize bytes are read from rand, across as many calls to Read as
+// it takes. If rand fails before then, GenerateKey returns the error, or
+// io.ErrUnexpectedEOF if rand simply ran out, and no key: the partial seed is
+// never padded or used.
+func GenerateKey(rand io.Reader) (PublicKey, PrivateKey, error) {
+	if rand == nil {
+		rand = cryptorand.Reader
+	}
+
+	var seed [SeedSize]byte
+	defer wipeBytes(seed[:])
+	if _, err := io.ReadFull(rand, seed[:]); err != nil {
+		return nil, nil, err
+	}
+
+	privateKey := NewKeyFromSeed(seed[:])
+	publicKey := make([]byte, PublicKeySize)
+	copy(publicKey, privateKey[32:])
+
+	return publicKey, privateKey, nil
+}
+
+// NewKeyFromSeed calculates a private key from a seed. It will panic if
+// len(seed) is not SeedSize. This function is provided for interoperability
+// with RFC 8032. RFC 8032's private keys correspond to seeds in this
+// package.
+func NewKeyFromSeed(seed []byte) PrivateKey {
+	if l := len(seed); l != SeedSize {
+		panic("ed25519: bad seed length: " + strconv.Itoa(l))
+	}
+
+	st := getSHA512()
+	defer putSHA512(st)
+	return newKeyFromSeed(st, seed)
+}
+
+// newKeyFromSeed calculates a private key from seed, which must be SeedSize
+// bytes long, using the hash of st to expand it.
+func newKeyFromSeed(st *hashState, seed []byte) PrivateKey {
+	var k ExpandedPrivateKey
+	k.expandSeedWith(st, seed)
+	defer k.Wipe()
+
+	var A edwards25519.ExtendedGroupElement
+	edwards25519.GeScalarMultBase(&A, &k.scalar)
+	var publicKeyBytes [32]byte
+	A.ToBytes(&publicKeyBytes)
+	wipeExtended(&A)
+
+	privateKey := make([]byte, PrivateKeySize)
+	copy(privateKey, seed)
+	copy(privateKey[32:], publicKeyBytes[:])
+
+	return privateKey
+}
+
+// Sign signs the message with privateKey and returns a signature. It returns
+// ErrBadPrivateKeyLength if len(privateKey) is not PrivateKeySize.
+func Sign(privateKey PrivateKey, message []byte) ([]byte, error) {
+	if len(privateKey) != PrivateKeySize {
+		return nil, ErrBadPrivateKeyLength
+	}
+
+	return sign(privateKey, message, nil, nil), nil
+}
+
+// AppendSign appends the signature of message by privateKey to dst and
+// returns the extended slice. opts selects the Ed25519 variant as for
+// SignWithRand, and may be nil for regular Ed25519. The signature is
+// deterministic.
+//
+// AppendSign allocates nothing when dst has room for SignatureSize more
+// bytes and opts is nil or selects regular Ed25519. It returns
+// ErrBadPrivateKeyLength, or an error from opts, and dst unchanged if it
+// can't sign.
+func AppendSign(dst []byte, privateKey PrivateKey, message []byte, opts *Options) ([]byte, error) {
+	if len(privateKey) != PrivateKeySize {
+		return dst, ErrBadPrivateKeyLength
+	}
+	dom, err := opts.dom(message)
+	if err != nil {
+		return dst, err
+	}
+
+	var k ExpandedPrivateKey
+	k.expand(privateKey)
+	defer k.Wipe()
+
+	return k.appendSign(dst, message, dom, nil), nil
+}
+
+// SignHedged is like Sign but mixes 32 bytes read from rand into the nonce.
+// It is equivalent to SignWithRand(rand, privateKey, message, nil).
+func SignHedged(rand io.Reader, privateKey PrivateKey, message []byte) ([]byte, error) {
+	return SignWithRand(rand, privateKey, message, nil)
+}
+
+// SignWithRand signs message with privateKey using the Ed25519 variant
+// selected by opts, which may be nil for regular Ed25519.
+//
+// If rand is nil the signature is the deterministic one specified by RFC
+// 8032. Otherwise 32 bytes Z are read from rand and the nonce is derived as
+// SHA-512(Z || dom2 || prefix || message) instead of
+// SHA-512(dom2 || prefix || message). This hedges against fault attacks,
+// where an attacker that can induce an error while the same message is signed
+// twice may be able to recover the key from the two signatures. The result is
+// still an ordinary signature that Verify accepts, but it is no longer
+// deterministic. libsodium built with ED25519_NONDETERMINISTIC derives its
+// nonce differently, so its signatures don't match these for the same Z.
+//
+// If rand returns an error or fewer than 32 bytes, SignWithRand returns an
+// error rather than falling back to deterministic signing. If privateKey has
+// the wrong length the error is ErrBadPrivateKeyLength.
+func SignWithRand(rand io.Reader, privateKey PrivateKey, message []byte, opts *Options) ([]byte, error) {
+	if len(privateKey) != PrivateKeySize {
+		return nil, ErrBadPrivateKeyLength
+	}
+
+	var k ExpandedPrivateKey
+	k.expand(privateKey)
+	defer k.Wipe()
+
+	return k.SignWithRand(rand, message, opts)
+}
+
+// sign computes the signature of message by privateKey. dom is the dom2
+// prefix, which is empty for regular Ed25519. If noise is not nil it is hashed
+// first when deriving the nonce.
+func sign(privateKey PrivateKey, message, dom, noise []byte) []byte {
+	var k ExpandedPrivateKey
+	k.expand(privateKey)
+	defer k.Wipe()
+
+	return k.appendSign(make([]byte, 0, SignatureSize), message, dom, noise)
+}
+
+// appendSign appends the signature of message by k to dst. The other
+// arguments are as for the sign function.
+func (k *ExpandedPrivateKey) appendSign(dst, message, dom, noise []byte) []byte {
+	st := getSHA512()
+	defer putSHA512(st)
+	return k.appendSignWith(st, dst, message, dom, noise)
+}
+
+// appendSignWith is like appendSign but uses st, whose hash must be freshly
+// reset and have a 64-byte output, instead of SHA-512 for both the nonce and
+// the challenge. st is wiped afterwards.
+//
+// The nonce r and the scalar are secret, so every operation on them below
+// uses the constant-time primitives of the edwards25519 package: ScReduce,
+// GeScalarMultBase and ScMulAdd never branch on, or index memory with, their
+// inputs. Only R, k and S, which end up in or are derived from the public
+// signature, are handled by ordinary code. The secret intermediate values are
+// wiped before returning.
+//
+// Values on the stack would escape to the heap if written to the hash
+// directly, so the noise, the prefix, R and A are copied into st.buf first.
+func (k *ExpandedPrivateKey) appendSignWith(st *hashState, dst, message, dom, noise []byte) []byte {
+	defer st.wipe()
+	h := st.h
+
+	// Secret: r = SHA-512(noise || dom || prefix || message) mod L and
+	// R = [r]B.
+	var r [32]byte
+	defer wipeBytes(r[:])
+	h.Write(st.buf[:copy(st.buf[:], noise)])
+	h.Write(dom)
+	h.Write(st.buf[:copy(st.buf[:], k.prefix[:])])
+	h.Write(message)
+	h.Sum(st.digest[:0])
+	edwards25519.ScReduce(&r, &st.digest)
+	wipeBytes(st.digest[:])
+	wipeBytes(st.buf[:])
+
+	var R edwards25519.ExtendedGroupElement
+	edwards25519.GeScalarMultBase(&R, &r)
+	var encodedR [32]byte
+	R.ToBytes(&encodedR)
+	wipeExtended(&R)
+
+	// Public: the challenge k = SHA-512(dom || R || A || message) mod L.
+	var hram [32]byte
+	h.Reset()
+	copy(st.buf[:32], encodedR[:])
+	copy(st.buf[32:], k.publicKey[:])
+	computeKWith(st, &hram, dom, st.buf[:32], st.buf[32:], message)
+
+	// Secret: S = k * s + r mod L.
+	var s [32]byte
+	edwards25519.ScMulAdd(&s, &hram, &k.scalar, &r)
+
+	dst = append(dst, encodedR[:]...)
+	dst = append(dst, s[:]...)
+	wipeBytes(s[:])
+
+	return dst
+}
+
+// wipeBytes overwrites b with zeros.
+func wipeBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// wipeExtended overwrites p with zeros.
+func wipeExtended(p *edwards25519.ExtendedGroupElement) {
+	*p = edwards25519.ExtendedGroupElement{}
+}
+
+// wipeHash overwrites the state of a hash, usually SHA-512, that has absorbed
+// secret data. Resetting alone restores the chaining value but leaves the
+// buffered partial block in place, so a full block of zeros is written first,
+// a byte at a time at the end so that it lands in the buffer rather than
+// being processed directly from the argument. This is best effort: the hash
+// package gives no guarantees about its internal state, and Sum works on a
+// copy that lives on the stack.
+func wipeHash(h hash.Hash) {
+	zeros := zeroBlock[:]
+	if n := h.BlockSize(); n > len(zeros) {
+		zeros = make([]byte, n)
+	} else {
+		zeros = zeros[:n]
+	}
+	h.Reset()
+	h.Write(zeros[:len(zeros)-1])
+	h.Write(zeros[:1])
+	h.Reset()
+}
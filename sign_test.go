@@ -0,0 +1,659 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto"
+	stded25519 "crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+func mustSign(t testing.TB, private PrivateKey, message []byte) []byte {
+	sig, err := Sign(private, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sig
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(buf []byte) (int, error) {
+	for i := range buf {
+		buf[i] = 0
+	}
+	return len(buf), nil
+}
+
+func TestSignVerify(t *testing.T) {
+	var zero zeroReader
+	public, private, _ := GenerateKey(zero)
+
+	message := []byte("test message")
+	sig := mustSign(t, private, message)
+	if !Verify(public, message, sig) {
+		t.Errorf("valid signature rejected")
+	}
+
+	wrongMessage := []byte("wrong message")
+	if Verify(public, wrongMessage, sig) {
+		t.Errorf("signature of different message accepted")
+	}
+}
+
+func TestCryptoSigner(t *testing.T) {
+	var zero zeroReader
+	public, private, _ := GenerateKey(zero)
+
+	signer := crypto.Signer(private)
+
+	publicInterface := signer.Public()
+	public2, ok := publicInterface.(PublicKey)
+	if !ok {
+		t.Fatalf("expected PublicKey from Public() but got %T", publicInterface)
+	}
+
+	if !bytes.Equal(public, public2) {
+		t.Errorf("public keys do not match: original:%x vs Public():%x", public, public2)
+	}
+
+	message := []byte("message")
+	var noHash crypto.Hash
+	signature, err := signer.Sign(zero, message, noHash)
+	if err != nil {
+		t.Fatalf("error from Sign(): %s", err)
+	}
+
+	if !Verify(public, message, signature) {
+		t.Errorf("Verify failed on signature from Sign()")
+	}
+
+	if _, err := signer.Sign(zero, message, crypto.SHA256); err == nil {
+		t.Errorf("Sign accepted a pre-hashed message")
+	}
+}
+
+func TestRFC8032Vectors(t *testing.T) {
+	vectors := []struct {
+		seed, public, message, sig string
+	}{
+		{
+			"9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60",
+			"d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a",
+			"",
+			"e5564300c360ac729086e2cc806e828a84877f1eb8e5d974d873e065224901555fb8821590a33bacc61e39701cf9b46bd25bf5f0595bbe24655141438e7a100b",
+		},
+		{
+			"4ccd089b28ff96da9db6c346ec114e0f5b8a319f35aba624da8cf6ed4fb8a6fb",
+			"3d4017c3e843895a92b70aa74d1b7ebc9c982ccf2ec4968cc0cd55f12af4660c",
+			"72",
+			"92a009a9f0d4cab8720e820b5f642540a2b27b5416503f8fb3762223ebdb69da085ac1e43e15996e458f3613d0f11d8c387b2eaeb4302aeeb00d291612bb0c00",
+		},
+	}
+
+	for i, v := range vectors {
+		seed, _ := hex.DecodeString(v.seed)
+		public, _ := hex.DecodeString(v.public)
+		message, _ := hex.DecodeString(v.message)
+		expected, _ := hex.DecodeString(v.sig)
+
+		private := NewKeyFromSeed(seed)
+		if !bytes.Equal(private[32:], public) {
+			t.Errorf("#%d: bad public key: got %x", i, private[32:])
+		}
+		if sig := mustSign(t, private, message); !bytes.Equal(sig, expected) {
+			t.Errorf("#%d: bad signature: got %x", i, sig)
+		}
+		if !Verify(public, message, expected) {
+			t.Errorf("#%d: signature failed to verify", i)
+		}
+	}
+}
+
+func TestStdlibInterop(t *testing.T) {
+	for i := 0; i < 16; i++ {
+		public, private, err := GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		message := make([]byte, i*7)
+		rand.Read(message)
+
+		sig := mustSign(t, private, message)
+		if !stded25519.Verify(stded25519.PublicKey(public), message, sig) {
+			t.Errorf("crypto/ed25519 rejected our signature")
+		}
+		if stdSig := stded25519.Sign(stded25519.PrivateKey(private), message); !bytes.Equal(sig, stdSig) {
+			t.Errorf("signatures differ from crypto/ed25519: %x vs %x", sig, stdSig)
+		}
+	}
+}
+
+func TestMalformedInputs(t *testing.T) {
+	public, private, _ := GenerateKey(zeroReader{})
+	message := []byte("message")
+	sig := mustSign(t, private, message)
+
+	if Verify(public[:31], message, sig) {
+		t.Errorf("short public key accepted")
+	}
+	if Verify(public, message, sig[:63]) {
+		t.Errorf("short signature accepted")
+	}
+
+	highS := append([]byte(nil), sig...)
+	highS[63] |= 0xe0
+	if Verify(public, message, highS) {
+		t.Errorf("signature with high bits set in S accepted")
+	}
+}
+
+func BenchmarkKeyGeneration(b *testing.B) {
+	var zero zeroReader
+	for i := 0; i < b.N; i++ {
+		if _, _, err := GenerateKey(zero); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSigning(b *testing.B) {
+	var zero zeroReader
+	_, priv, err := GenerateKey(zero)
+	if err != nil {
+		b.Fatal(err)
+	}
+	message := []byte("Hello, world!")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Sign(priv, message)
+	}
+}
+
+func BenchmarkAppendSign(b *testing.B) {
+	_, priv, err := GenerateKey(zeroReader{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	message := []byte("Hello, world!")
+	sig := make([]byte, 0, SignatureSize)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		AppendSign(sig[:0], priv, message, nil)
+	}
+}
+
+func BenchmarkVerification(b *testing.B) {
+	var zero zeroReader
+	pub, priv, err := GenerateKey(zero)
+	if err != nil {
+		b.Fatal(err)
+	}
+	message := []byte("Hello, world!")
+	signature := mustSign(b, priv, message)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Verify(pub, message, signature)
+	}
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("read failed")
+}
+
+func TestSignHedged(t *testing.T) {
+	public, private, _ := GenerateKey(rand.Reader)
+	message := []byte("fault attacks")
+
+	sig1, err := SignHedged(rand.Reader, private, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig2, err := SignHedged(rand.Reader, private, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(sig1, sig2) {
+		t.Errorf("hedged signatures of the same message are identical")
+	}
+	if bytes.Equal(sig1, mustSign(t, private, message)) {
+		t.Errorf("hedged signature equals the deterministic one")
+	}
+	for _, sig := range [][]byte{sig1, sig2} {
+		if !Verify(public, message, sig) {
+			t.Errorf("hedged signature rejected by Verify")
+		}
+		if !stded25519.Verify(stded25519.PublicKey(public), message, sig) {
+			t.Errorf("hedged signature rejected by crypto/ed25519")
+		}
+	}
+
+	sig, err := SignHedged(nil, private, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(sig, mustSign(t, private, message)) {
+		t.Errorf("SignHedged with a nil reader is not deterministic")
+	}
+
+	if _, err := SignHedged(failingReader{}, private, message); err == nil {
+		t.Errorf("SignHedged ignored a failing reader")
+	}
+}
+
+type constantReader byte
+
+func (c constantReader) Read(buf []byte) (int, error) {
+	for i := range buf {
+		buf[i] = byte(c)
+	}
+	return len(buf), nil
+}
+
+type shortReader struct{ n int }
+
+func (r *shortReader) Read(buf []byte) (int, error) {
+	if r.n == 0 {
+		return 0, io.EOF
+	}
+	if len(buf) > r.n {
+		buf = buf[:r.n]
+	}
+	r.n -= len(buf)
+	return len(buf), nil
+}
+
+// signingModes are the Ed25519 variants, together with the equivalent
+// crypto/ed25519 options.
+var signingModes = []struct {
+	name    string
+	opts    *Options
+	stdOpts *stded25519.Options
+	prehash bool
+}{
+	{"Ed25519", nil, &stded25519.Options{}, false},
+	{"Ed25519ctx", &Options{Context: "foo"}, &stded25519.Options{Context: "foo"}, false},
+	{"Ed25519ph", &Options{Hash: crypto.SHA512}, &stded25519.Options{Hash: crypto.SHA512}, true},
+	{"Ed25519ph with context", &Options{Hash: crypto.SHA512, Context: "bar"}, &stded25519.Options{Hash: crypto.SHA512, Context: "bar"}, true},
+}
+
+func TestSignWithRand(t *testing.T) {
+	public, private, _ := GenerateKey(rand.Reader)
+	message := []byte("SignWithRand")
+	digest := sha512.Sum512(message)
+
+	for _, mode := range signingModes {
+		msg := message
+		if mode.prehash {
+			msg = digest[:]
+		}
+
+		sig, err := SignWithRand(nil, private, msg, mode.opts)
+		if err != nil {
+			t.Fatalf("%s: %s", mode.name, err)
+		}
+		stdSig, err := stded25519.PrivateKey(private).Sign(nil, msg, mode.stdOpts)
+		if err != nil {
+			t.Fatalf("%s: %s", mode.name, err)
+		}
+		if !bytes.Equal(sig, stdSig) {
+			t.Errorf("%s: deterministic signature differs from crypto/ed25519", mode.name)
+		}
+
+		hedged, err := SignWithRand(rand.Reader, private, msg, mode.opts)
+		if err != nil {
+			t.Fatalf("%s: %s", mode.name, err)
+		}
+		if bytes.Equal(hedged, sig) {
+			t.Errorf("%s: hedged signature equals the deterministic one", mode.name)
+		}
+		if err := stded25519.VerifyWithOptions(stded25519.PublicKey(public), msg, hedged, mode.stdOpts); err != nil {
+			t.Errorf("%s: crypto/ed25519 rejected hedged signature: %s", mode.name, err)
+		}
+	}
+}
+
+func TestSignWithRandFixedReader(t *testing.T) {
+	seed := decodeHex(t, "9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60")
+	private := NewKeyFromSeed(seed)
+	message := []byte("hedged")
+
+	sig, err := SignWithRand(constantReader(0x42), private, message, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// This is SignWithRand's own output, pinned to catch changes to the
+	// nonce derivation.
+	const expected = "aa2f78079c0d0d4b132ced979874c3a8e0449265458aff93394de32338503f16" +
+		"cd027c618cb19c80f4d22957dd52fbdf5190ae0de6af31f65423a1084454f20b"
+	if hex.EncodeToString(sig) != expected {
+		t.Errorf("hedged signature changed: got %x", sig)
+	}
+	if !Verify(private.Public().(PublicKey), message, sig) {
+		t.Errorf("hedged signature rejected")
+	}
+}
+
+func TestSignWithRandBadReaders(t *testing.T) {
+	_, private, _ := GenerateKey(rand.Reader)
+	message := []byte("message")
+
+	if _, err := SignWithRand(failingReader{}, private, message, nil); err == nil {
+		t.Errorf("failing reader accepted")
+	}
+	if _, err := SignWithRand(&shortReader{31}, private, message, nil); err == nil {
+		t.Errorf("short reader accepted")
+	}
+	// A reader that returns the bytes one at a time is fine.
+	if _, err := SignWithRand(iotest.OneByteReader(rand.Reader), private, message, nil); err != nil {
+		t.Errorf("one byte reader rejected: %s", err)
+	}
+}
+
+func TestSignWithRandBadOptions(t *testing.T) {
+	_, private, _ := GenerateKey(rand.Reader)
+	long := string(make([]byte, 256))
+
+	for _, test := range []struct {
+		name    string
+		message []byte
+		opts    *Options
+	}{
+		{"unsupported hash", make([]byte, 32), &Options{Hash: crypto.SHA256}},
+		{"short digest", make([]byte, 63), &Options{Hash: crypto.SHA512}},
+		{"long Ed25519ph context", make([]byte, 64), &Options{Hash: crypto.SHA512, Context: long}},
+		{"long Ed25519ctx context", nil, &Options{Context: long}},
+	} {
+		if _, err := SignWithRand(nil, private, test.message, test.opts); err == nil {
+			t.Errorf("%s: no error", test.name)
+		}
+	}
+}
+
+func TestWipeHash(t *testing.T) {
+	h := sha512.New()
+	h.Write(bytes.Repeat([]byte{0xaa}, 200))
+	wipeHash(h)
+
+	empty := sha512.Sum512(nil)
+	if got := h.Sum(nil); !bytes.Equal(got, empty[:]) {
+		t.Errorf("wiped hash is not in its initial state")
+	}
+}
+
+func TestEqual(t *testing.T) {
+	public, private, _ := GenerateKey(rand.Reader)
+	otherPublic, otherPrivate, _ := GenerateKey(rand.Reader)
+
+	if !public.Equal(public) {
+		t.Errorf("public key is not equal to itself")
+	}
+	if !public.Equal(private.Public()) {
+		t.Errorf("private.Public() is not equal to public")
+	}
+	if !public.Equal(stded25519.PublicKey(public)) {
+		t.Errorf("public key is not equal to the crypto/ed25519 key")
+	}
+	if public.Equal(otherPublic) || public.Equal(stded25519.PublicKey(otherPublic)) {
+		t.Errorf("different public keys are equal")
+	}
+	if public.Equal(public[:31]) || public.Equal(PublicKey(nil)) || public.Equal(nil) {
+		t.Errorf("public key is equal to a truncated or missing key")
+	}
+	if public.Equal([]byte(public)) || public.Equal(&public) {
+		t.Errorf("public key is equal to a value of another type")
+	}
+	if !PublicKey(nil).Equal(PublicKey(nil)) {
+		t.Errorf("nil public key is not equal to itself")
+	}
+
+	if !private.Equal(private) {
+		t.Errorf("private key is not equal to itself")
+	}
+	if !private.Equal(stded25519.PrivateKey(private)) {
+		t.Errorf("private key is not equal to the crypto/ed25519 key")
+	}
+	if private.Equal(otherPrivate) || private.Equal(stded25519.PrivateKey(otherPrivate)) {
+		t.Errorf("different private keys are equal")
+	}
+	if private.Equal(private[:63]) || private.Equal(PrivateKey(nil)) || private.Equal(nil) {
+		t.Errorf("private key is equal to a truncated or missing key")
+	}
+	if private.Equal(public) || private.Equal([]byte(private)) {
+		t.Errorf("private key is equal to a value of another type")
+	}
+}
+
+func TestGenerateKeyReaders(t *testing.T) {
+	stream := make([]byte, 64)
+	for i := range stream {
+		stream[i] = byte(i)
+	}
+	midStream := func() io.Reader {
+		return io.MultiReader(bytes.NewReader(stream[:10]), failingReader{})
+	}
+
+	for _, test := range []struct {
+		name string
+		r    io.Reader
+		ok   bool
+	}{
+		{"full", bytes.NewReader(stream), true},
+		{"exact", bytes.NewReader(stream[:SeedSize]), true},
+		{"one byte at a time", iotest.OneByteReader(bytes.NewReader(stream)), true},
+		{"half reads", iotest.HalfReader(bytes.NewReader(stream)), true},
+		{"error with last data", iotest.DataErrReader(bytes.NewReader(stream[:SeedSize])), true},
+		{"timeout after first read", iotest.TimeoutReader(iotest.OneByteReader(bytes.NewReader(stream))), false},
+		{"short", bytes.NewReader(stream[:SeedSize-1]), false},
+		{"short, one byte at a time", iotest.OneByteReader(bytes.NewReader(stream[:SeedSize-1])), false},
+		{"empty", bytes.NewReader(nil), false},
+		{"failing", failingReader{}, false},
+		{"error mid-stream", midStream(), false},
+		{"error mid-stream, one byte at a time", iotest.OneByteReader(midStream()), false},
+	} {
+		public, private, err := GenerateKey(test.r)
+		if !test.ok {
+			if err == nil {
+				t.Errorf("%s: GenerateKey succeeded", test.name)
+			}
+			if public != nil || private != nil {
+				t.Errorf("%s: GenerateKey returned a key with error %v", test.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		if !bytes.Equal(private.Seed(), stream[:SeedSize]) {
+			t.Errorf("%s: seed is %x, want the first %d bytes read", test.name, private.Seed(), SeedSize)
+		}
+		if !bytes.Equal(public, private[32:]) || !Verify(public, stream, mustSign(t, private, stream)) {
+			t.Errorf("%s: inconsistent key pair", test.name)
+		}
+	}
+
+	if _, _, err := GenerateKey(bytes.NewReader(stream[:SeedSize-1])); err != io.ErrUnexpectedEOF {
+		t.Errorf("short reader: got %v, want io.ErrUnexpectedEOF", err)
+	}
+	if _, _, err := GenerateKey(midStream()); err == nil || err.Error() != "read failed" {
+		t.Errorf("failing reader: got %v, want the reader's error", err)
+	}
+}
+
+func TestGenerateKeyReadsSeedSize(t *testing.T) {
+	r := bytes.NewReader(make([]byte, 100))
+	if _, _, err := GenerateKey(r); err != nil {
+		t.Fatal(err)
+	}
+	if n := r.Len(); n != 100-SeedSize {
+		t.Errorf("GenerateKey consumed %d bytes, want %d", 100-n, SeedSize)
+	}
+}
+
+// TestGenerateKeyPinned pins the key generated from the bytes 0, 1, ..., 31 so
+// that the derivation can't change unnoticed. The public key was checked with
+// OpenSSL.
+func TestGenerateKeyPinned(t *testing.T) {
+	seed := make([]byte, SeedSize)
+	for i := range seed {
+		seed[i] = byte(i)
+	}
+	public, private, err := GenerateKey(bytes.NewReader(seed))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const want = "03a107bff3ce10be1d70dd18e74bc09967e4d6309ba50d5f1ddc8664125531b8"
+	if got := hex.EncodeToString(public); got != want {
+		t.Errorf("public key is %s, want %s", got, want)
+	}
+	if got := hex.EncodeToString(private); got != hex.EncodeToString(seed)+want {
+		t.Errorf("private key is %s, want seed || public key", got)
+	}
+}
+
+// TestBLAKE2bPrehash checks the non-standard BLAKE2b-512 variant of
+// Ed25519ph against signatures computed with an independent implementation
+// of RFC 8032, with the digest BLAKE2b-512("abc") from RFC 7693, Appendix A,
+// as the prehashed message.
+func TestBLAKE2bPrehash(t *testing.T) {
+	seed := make([]byte, SeedSize)
+	for i := range seed {
+		seed[i] = byte(i)
+	}
+	private := NewKeyFromSeed(seed)
+	public := private.Public().(PublicKey)
+	digest := decodeHex(t, "ba80a53f981c4d0d6a2797b69f12f6e94c212f14685ac4b74b12bb6fdbffa2d17d87c5392aab792dc252d5de4533cc9518d38aa8dbf1925ab92386edd4009923")
+
+	for _, test := range []struct {
+		context, sig string
+	}{
+		{"", "dbcdb45be14bee262c2d7edfa06325c9dba1aca5b5c672fb14f1eebbf0821b0117bc6aa4d2fe58c1292f604c864293049daead64974a9ee1f9ce71a28c310c06"},
+		{"foo", "36f8913465045ee83d54605a9c9e4536972df8ca07cdb4a31b7e1daf1f12b31ef1182820e1319e7825958de6c4a100817b9bc7194d3e8f713963694abea65b09"},
+	} {
+		opts := &Options{Hash: crypto.BLAKE2b_512, Context: test.context, AllowNonStandardPrehash: true}
+		sig, err := private.Sign(nil, digest, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(sig); got != test.sig {
+			t.Errorf("context %q: got signature %s, want %s", test.context, got, test.sig)
+		}
+		if err := VerifyWithOptions(public, digest, sig, opts); err != nil {
+			t.Errorf("context %q: %s", test.context, err)
+		}
+
+		// The signature is that of Ed25519ph over the same 64 bytes.
+		phOpts := &Options{Hash: crypto.SHA512, Context: test.context}
+		if err := VerifyWithOptions(public, digest, sig, phOpts); err != nil {
+			t.Errorf("context %q: not an Ed25519ph signature: %s", test.context, err)
+		}
+		if err := VerifyWithOptions(public, digest, sig, &Options{Hash: crypto.BLAKE2b_512, Context: test.context, Mode: ModeStrict}); err == nil {
+			t.Errorf("context %q: BLAKE2b prehash accepted by strict verification without being enabled", test.context)
+		}
+	}
+
+	// Without AllowNonStandardPrehash the variant is rejected everywhere.
+	for _, opts := range []crypto.SignerOpts{crypto.BLAKE2b_512, &Options{Hash: crypto.BLAKE2b_512}} {
+		if _, err := private.Sign(nil, digest, opts); err == nil {
+			t.Errorf("Sign with %v succeeded without AllowNonStandardPrehash", opts)
+		}
+	}
+	if _, err := SignWithRand(rand.Reader, private, digest, &Options{Hash: crypto.BLAKE2b_512}); err == nil {
+		t.Error("SignWithRand succeeded without AllowNonStandardPrehash")
+	}
+	if err := VerifyWithOptions(public, digest, make([]byte, SignatureSize), &Options{Hash: crypto.BLAKE2b_512}); err == nil || err == ErrInvalidSignature {
+		t.Errorf("VerifyWithOptions without AllowNonStandardPrehash: got %v, want an options error", err)
+	}
+
+	// The option doesn't enable other hashes, and the digest length is checked.
+	enabled := &Options{Hash: crypto.BLAKE2b_256, AllowNonStandardPrehash: true}
+	if _, err := private.Sign(nil, digest[:32], enabled); err == nil {
+		t.Error("Sign with BLAKE2b-256 succeeded")
+	}
+	enabled.Hash = crypto.BLAKE2b_512
+	if _, err := private.Sign(nil, digest[:32], enabled); err == nil {
+		t.Error("Sign with a 32-byte BLAKE2b-512 digest succeeded")
+	}
+}
+
+func TestAppendSign(t *testing.T) {
+	public, private, _ := GenerateKey(rand.Reader)
+	message := []byte("message")
+
+	prefix := []byte("prefix")
+	out, err := AppendSign(prefix, private, message, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out[:len(prefix)], prefix) || !bytes.Equal(out[len(prefix):], mustSign(t, private, message)) {
+		t.Errorf("AppendSign returned %x", out)
+	}
+
+	for _, mode := range signingModes {
+		msg := message
+		if mode.prehash {
+			h := sha512.Sum512(message)
+			msg = h[:]
+		}
+		sig, err := AppendSign(nil, private, msg, mode.opts)
+		if err != nil {
+			t.Errorf("%s: %s", mode.name, err)
+			continue
+		}
+		if err := VerifyWithOptions(public, msg, sig, mode.opts); err != nil {
+			t.Errorf("%s: %s", mode.name, err)
+		}
+	}
+
+	if out, err := AppendSign(prefix, private[:10], message, nil); err != ErrBadPrivateKeyLength || !bytes.Equal(out, prefix) {
+		t.Errorf("short key: got %x, %v", out, err)
+	}
+	if out, err := AppendSign(prefix, private, message, &Options{Hash: crypto.SHA512}); err == nil || !bytes.Equal(out, prefix) {
+		t.Errorf("bad options: got %x, %v", out, err)
+	}
+}
+
+func TestAllocations(t *testing.T) {
+	public, private, _ := GenerateKey(rand.Reader)
+	message := []byte("Hello, world!")
+	sig := mustSign(t, private, message)
+	buf := make([]byte, 0, SignatureSize)
+
+	if allocs := testing.AllocsPerRun(100, func() {
+		if !Verify(public, message, sig) {
+			t.Fatal("valid signature rejected")
+		}
+	}); allocs > 0 {
+		t.Errorf("Verify allocates %v times, want 0", allocs)
+	}
+	if allocs := testing.AllocsPerRun(100, func() {
+		if _, err := AppendSign(buf, private, message, nil); err != nil {
+			t.Fatal(err)
+		}
+	}); allocs > 0 {
+		t.Errorf("AppendSign allocates %v times, want 0", allocs)
+	}
+	if allocs := testing.AllocsPerRun(100, func() {
+		if _, err := Sign(private, message); err != nil {
+			t.Fatal(err)
+		}
+	}); allocs > 1 {
+		t.Errorf("Sign allocates %v times, want 1", allocs)
+	}
+}
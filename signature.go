@@ -0,0 +1,52 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import "github.com/agl/ed25519/edwards25519"
+
+// Signature is a decoded signature: the point R and the scalar S from the
+// equation [S]B = R + [k]A. It lets protocols that build on Ed25519 work with
+// the two halves directly.
+type Signature struct {
+	r edwards25519.Point
+	s edwards25519.Scalar
+}
+
+// ParseSignature decodes the SignatureSize bytes of sig. It returns
+// ErrBadSignatureLength if sig has the wrong length, and ErrInvalidSignature
+// if R is not the canonical encoding of a point or if S is not canonical,
+// that is if S >= L. Signatures rejected by ParseSignature are also rejected
+// by Verify, although VerifyZIP215 accepts some with non-canonical R.
+func ParseSignature(sig []byte) (Signature, error) {
+	if len(sig) != SignatureSize {
+		return Signature{}, ErrBadSignatureLength
+	}
+
+	var s Signature
+	if _, err := s.r.SetCanonicalBytes(sig[:32]); err != nil {
+		return Signature{}, ErrInvalidSignature
+	}
+	if _, err := s.s.SetCanonicalBytes(sig[32:]); err != nil {
+		return Signature{}, ErrInvalidSignature
+	}
+	return s, nil
+}
+
+// R returns the commitment point R.
+func (sig *Signature) R() edwards25519.Point {
+	return sig.r
+}
+
+// S returns the response scalar S.
+func (sig *Signature) S() edwards25519.Scalar {
+	return sig.s
+}
+
+// Bytes returns the SignatureSize-byte encoding of sig, R followed by S.
+func (sig *Signature) Bytes() []byte {
+	out := make([]byte, 0, SignatureSize)
+	out = append(out, sig.r.Bytes()...)
+	return append(out, sig.s.Bytes()...)
+}
@@ -0,0 +1,95 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/agl/ed25519/edwards25519"
+)
+
+func TestParseSignature(t *testing.T) {
+	for _, m := range newSignedMessages(t, 8) {
+		sig, err := ParseSignature(m.sig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(sig.Bytes(), m.sig) {
+			t.Errorf("Bytes() = %x, want %x", sig.Bytes(), m.sig)
+		}
+
+		R, S := sig.R(), sig.S()
+		if !bytes.Equal(R.Bytes(), m.sig[:32]) || !bytes.Equal(S.Bytes(), m.sig[32:]) {
+			t.Errorf("R and S do not match the signature")
+		}
+
+		// Check the verification equation [S]B = R + [k]A with the
+		// decoded values.
+		A, err := new(edwards25519.Point).SetBytes(m.public)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var k [32]byte
+		computeK(&k, nil, m.sig[:32], m.public, m.message)
+		kScalar, err := edwards25519.NewScalar().SetCanonicalBytes(k[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		lhs := new(edwards25519.Point).ScalarBaseMult(&S)
+		rhs := new(edwards25519.Point).ScalarMult(kScalar, A)
+		rhs.Add(rhs, &R)
+		if lhs.Equal(rhs) != 1 {
+			t.Errorf("[S]B != R + [k]A")
+		}
+	}
+}
+
+func TestParseSignatureRejects(t *testing.T) {
+	m := newSignedMessages(t, 1)[0]
+
+	for _, l := range []int{0, 32, SignatureSize - 1, SignatureSize + 1} {
+		sig := make([]byte, l)
+		copy(sig, m.sig)
+		if _, err := ParseSignature(sig); err != ErrBadSignatureLength {
+			t.Errorf("%d-byte signature: got %v, want ErrBadSignatureLength", l, err)
+		}
+	}
+
+	highS := append([]byte(nil), m.sig...)
+	highS[63] |= 0xe0
+	offCurveR := append(append([]byte(nil), offCurve...), m.sig[32:]...)
+	nonCanonicalR := append(decodeHex(t, smallOrderEncodings[8]), m.sig[32:]...)
+	for name, sig := range map[string][]byte{
+		"S + L":           append(append([]byte(nil), m.sig[:32]...), addL(m.sig[32:])...),
+		"high bits in S":  highS,
+		"R off the curve": offCurveR,
+		"non-canonical R": nonCanonicalR,
+	} {
+		if _, err := ParseSignature(sig); err != ErrInvalidSignature {
+			t.Errorf("%s: got %v, want ErrInvalidSignature", name, err)
+		}
+		if Verify(m.public, m.message, sig) {
+			t.Errorf("%s: Verify accepted a signature that ParseSignature rejected", name)
+		}
+	}
+
+	// A mutated signature that still parses must re-encode to exactly the
+	// mutated bytes, and must not verify.
+	for i := 0; i < 8*SignatureSize; i++ {
+		sig := append([]byte(nil), m.sig...)
+		sig[i/8] ^= 1 << uint(i%8)
+		parsed, err := ParseSignature(sig)
+		if err != nil {
+			continue
+		}
+		if !bytes.Equal(parsed.Bytes(), sig) {
+			t.Errorf("bit %d: Bytes() = %x, want %x", i, parsed.Bytes(), sig)
+		}
+		if Verify(m.public, m.message, sig) {
+			t.Errorf("bit %d: mutated signature verified", i)
+		}
+	}
+}
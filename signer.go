@@ -0,0 +1,77 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"crypto"
+	stded25519 "crypto/ed25519"
+	cryptorand "crypto/rand"
+)
+
+// The higher-level signing functions of this package, such as SignWithDomain
+// and SignChunked, take a crypto.Signer rather than a PrivateKey so that they
+// also work with keys that are held elsewhere, for example in an HSM or a key
+// management service, and only expose a sign operation. Such a signer must
+// return an Ed25519 public key, either a PublicKey or a crypto/ed25519 one,
+// from Public, and must accept crypto.Hash(0) as the options to Sign and
+// produce a plain Ed25519 signature of the message it is given. A PrivateKey
+// and a crypto/ed25519 PrivateKey both qualify.
+
+// SignerPublicKey returns the Ed25519 public key of signer. It returns
+// ErrBadPrivateKeyLength if signer is a private key of the wrong length, and
+// ErrUnsupportedSigner if signer is nil or its public key is not an Ed25519
+// key.
+func SignerPublicKey(signer crypto.Signer) (PublicKey, error) {
+	var public crypto.PublicKey
+	switch s := signer.(type) {
+	case nil:
+		return nil, ErrUnsupportedSigner
+	case PrivateKey:
+		if len(s) != PrivateKeySize {
+			return nil, ErrBadPrivateKeyLength
+		}
+		public = s.Public()
+	case stded25519.PrivateKey:
+		if len(s) != PrivateKeySize {
+			return nil, ErrBadPrivateKeyLength
+		}
+		public = s.Public()
+	default:
+		public = signer.Public()
+	}
+
+	var publicKey []byte
+	switch p := public.(type) {
+	case PublicKey:
+		publicKey = p
+	case stded25519.PublicKey:
+		publicKey = p
+	default:
+		return nil, ErrUnsupportedSigner
+	}
+	if len(publicKey) != PublicKeySize {
+		return nil, ErrUnsupportedSigner
+	}
+	return PublicKey(publicKey), nil
+}
+
+// signWith returns a plain Ed25519 signature of message by signer. Errors
+// from signer are returned unchanged.
+func signWith(signer crypto.Signer, message []byte) ([]byte, error) {
+	if privateKey, ok := signer.(PrivateKey); ok {
+		return Sign(privateKey, message)
+	}
+	if _, err := SignerPublicKey(signer); err != nil {
+		return nil, err
+	}
+	sig, err := signer.Sign(cryptorand.Reader, message, crypto.Hash(0))
+	if err != nil {
+		return nil, err
+	}
+	if len(sig) != SignatureSize {
+		return nil, ErrBadSignatureLength
+	}
+	return sig, nil
+}
@@ -0,0 +1,275 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	stded25519 "crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSigner stands in for a key held in an HSM. It counts the signatures it
+// makes and returns err, if set, instead of signing.
+type fakeSigner struct {
+	private PrivateKey
+	public  crypto.PublicKey // if nil, private.Public() is used
+	calls   int
+	err     error
+	mangle  func([]byte) []byte
+}
+
+func (s *fakeSigner) Public() crypto.PublicKey {
+	if s.public != nil {
+		return s.public
+	}
+	return s.private.Public()
+}
+
+func (s *fakeSigner) Sign(rand io.Reader, message []byte, opts crypto.SignerOpts) ([]byte, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	if opts.HashFunc() != crypto.Hash(0) {
+		return nil, errors.New("fakeSigner: unexpected hash")
+	}
+	sig, err := s.private.Sign(rand, message, opts)
+	if err == nil && s.mangle != nil {
+		sig = s.mangle(sig)
+	}
+	return sig, err
+}
+
+// signerFunctions are the functions that accept a crypto.Signer, each paired
+// with a check of its output.
+var signerFunctions = []struct {
+	name   string
+	sign   func(crypto.Signer) (interface{}, error)
+	verify func(PublicKey, interface{}) bool
+}{
+	{
+		"SignWithDomain",
+		func(s crypto.Signer) (interface{}, error) { return SignWithDomain(s, "test", []byte("message")) },
+		func(pub PublicKey, sig interface{}) bool {
+			return VerifyWithDomain(pub, "test", []byte("message"), sig.([]byte))
+		},
+	},
+	{
+		"SignFields",
+		func(s crypto.Signer) (interface{}, error) { return SignFields(s, []byte("a"), []byte("b")) },
+		func(pub PublicKey, sig interface{}) bool {
+			return VerifyFields(pub, sig.([]byte), []byte("a"), []byte("b"))
+		},
+	},
+	{
+		"SignCombined",
+		func(s crypto.Signer) (interface{}, error) { return SignCombined(s, []byte("message")) },
+		func(pub PublicKey, sm interface{}) bool {
+			_, err := OpenCombined(pub, sm.([]byte))
+			return err == nil
+		},
+	},
+	{
+		"SignChunked",
+		func(s crypto.Signer) (interface{}, error) {
+			m, sig, err := SignChunked(s, bytes.NewReader(make([]byte, 100)), 16)
+			if err != nil {
+				return nil, err
+			}
+			return [2]interface{}{m, sig}, nil
+		},
+		func(pub PublicKey, v interface{}) bool {
+			pair := v.([2]interface{})
+			return VerifyManifest(pub, pair[0].(*Manifest), pair[1].([]byte)) == nil
+		},
+	},
+	{
+		"SignSSHSig",
+		func(s crypto.Signer) (interface{}, error) {
+			return SignSSHSig(s, "file", strings.NewReader("message"))
+		},
+		func(pub PublicKey, armored interface{}) bool {
+			return VerifySSHSig(pub, "file", strings.NewReader("message"), armored.([]byte)) == nil
+		},
+	},
+	{
+		"SignSSHCertificate",
+		func(s crypto.Signer) (interface{}, error) {
+			cert := &SSHCertificate{Key: make(PublicKey, PublicKeySize), CertType: SSHUserCert, ValidBefore: SSHCertTimeInfinity}
+			return cert, SignSSHCertificate(cert, s)
+		},
+		func(pub PublicKey, cert interface{}) bool {
+			return VerifySSHCertificate(cert.(*SSHCertificate), pub, SSHUserCert, "", time.Now()) == nil
+		},
+	},
+	{
+		"SignJWS",
+		func(s crypto.Signer) (interface{}, error) { return SignJWS(s, nil, []byte("message")) },
+		func(pub PublicKey, token interface{}) bool {
+			_, err := VerifyJWS(pub, token.(string))
+			return err == nil
+		},
+	},
+	{
+		"SignCOSESign1",
+		func(s crypto.Signer) (interface{}, error) { return SignCOSESign1(s, nil, nil, []byte("message"), nil) },
+		func(pub PublicKey, message interface{}) bool {
+			_, err := VerifyCOSESign1(pub, message.([]byte), nil)
+			return err == nil
+		},
+	},
+	{
+		"SignMinisign",
+		func(s crypto.Signer) (interface{}, error) {
+			return SignMinisign(s, MinisignKeyID{}, []byte("message"), nil)
+		},
+		func(pub PublicKey, sig interface{}) bool {
+			pubText, _ := MarshalMinisignPublicKey(pub, MinisignKeyID{})
+			_, err := VerifyMinisign(pubText, sig.([]byte), []byte("message"))
+			return err == nil
+		},
+	},
+	{
+		"SignSignify",
+		func(s crypto.Signer) (interface{}, error) {
+			return SignSignify(s, SignifyKeyID{}, []byte("message"), nil)
+		},
+		func(pub PublicKey, sig interface{}) bool {
+			pubText, _ := MarshalSignifyPublicKey(pub, SignifyKeyID{}, "")
+			return VerifySignify(pubText, sig.([]byte), []byte("message")) == nil
+		},
+	},
+	{
+		"SignPGP",
+		func(s crypto.Signer) (interface{}, error) {
+			return SignPGP(s, time.Unix(0, 0), []byte("message"), nil)
+		},
+		func(pub PublicKey, sig interface{}) bool {
+			body, _ := (&PGPPublicKey{CreationTime: time.Unix(0, 0), PublicKey: pub}).body()
+			key := appendPGPPacket(nil, pgpTagPublicKey, body)
+			return VerifyPGPSignature(key, sig.([]byte), []byte("message")) == nil
+		},
+	},
+	{
+		"SignRRSIG",
+		func(s crypto.Signer) (interface{}, error) {
+			sig := &RRSIG{TypeCovered: 1, Expiration: 1, SignerName: []byte{0}}
+			return sig, SignRRSIG(s, sig, []byte("rrset"))
+		},
+		func(pub PublicKey, sig interface{}) bool {
+			rrsig := sig.(*RRSIG)
+			return CheckSignature(pub, append(rrsig.signedFields(), "rrset"...), rrsig.Signature) == nil
+		},
+	},
+	{
+		"CreateSelfSignedCert",
+		func(s crypto.Signer) (interface{}, error) { return CreateSelfSignedCert(s, nil) },
+		func(pub PublicKey, der interface{}) bool {
+			cert, err := x509.ParseCertificate(der.([]byte))
+			return err == nil && cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil &&
+				bytes.Equal(cert.PublicKey.(stded25519.PublicKey), pub)
+		},
+	},
+	{
+		"CreateCertificateRequest",
+		func(s crypto.Signer) (interface{}, error) { return CreateCertificateRequest(s, nil) },
+		func(pub PublicKey, der interface{}) bool {
+			csr, err := x509.ParseCertificateRequest(der.([]byte))
+			return err == nil && csr.CheckSignature() == nil && bytes.Equal(csr.PublicKey.(stded25519.PublicKey), pub)
+		},
+	},
+}
+
+// signerCalls is the number of signatures that the functions which need more
+// than one make.
+var signerCalls = map[string]int{
+	"SignMinisign": 2,
+}
+
+func TestSigner(t *testing.T) {
+	public, private, _ := GenerateKey(rand.Reader)
+
+	for _, f := range signerFunctions {
+		s := &fakeSigner{private: private}
+		out, err := f.sign(s)
+		if err != nil {
+			t.Errorf("%s: %s", f.name, err)
+			continue
+		}
+		if want := max(signerCalls[f.name], 1); s.calls != want {
+			t.Errorf("%s: signer called %d times, want %d", f.name, s.calls, want)
+		}
+		if !f.verify(public, out) {
+			t.Errorf("%s: result doesn't verify", f.name)
+		}
+
+		// A crypto/ed25519 key implements crypto.Signer too.
+		out, err = f.sign(stded25519.PrivateKey(private))
+		if err != nil {
+			t.Errorf("%s with a crypto/ed25519 key: %s", f.name, err)
+		} else if !f.verify(public, out) {
+			t.Errorf("%s with a crypto/ed25519 key: result doesn't verify", f.name)
+		}
+		s = &fakeSigner{private: private, public: stded25519.PublicKey(public)}
+		if _, err := f.sign(s); err != nil {
+			t.Errorf("%s with a crypto/ed25519 public key: %s", f.name, err)
+		}
+	}
+}
+
+func TestSignerErrors(t *testing.T) {
+	_, private, _ := GenerateKey(rand.Reader)
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	injected := errors.New("HSM unavailable")
+
+	for _, f := range signerFunctions {
+		s := &fakeSigner{private: private, err: injected}
+		if _, err := f.sign(s); err != injected {
+			t.Errorf("%s with a failing signer: got %v, want %v", f.name, err, injected)
+		}
+		if s.calls != 1 {
+			t.Errorf("%s: failing signer called %d times, want once", f.name, s.calls)
+		}
+
+		s = &fakeSigner{private: private, mangle: func(sig []byte) []byte { return sig[:SignatureSize-1] }}
+		if _, err := f.sign(s); err != ErrBadSignatureLength {
+			t.Errorf("%s with a short signature: got %v, want ErrBadSignatureLength", f.name, err)
+		}
+
+		for _, public := range []crypto.PublicKey{&ecdsaKey.PublicKey, PublicKey(make([]byte, 31)), []byte(private[32:])} {
+			s = &fakeSigner{private: private, public: public}
+			if _, err := f.sign(s); err != ErrUnsupportedSigner {
+				t.Errorf("%s with public key %T: got %v, want ErrUnsupportedSigner", f.name, public, err)
+			}
+			if s.calls != 0 {
+				t.Errorf("%s with public key %T: signer called", f.name, public)
+			}
+		}
+		if _, err := f.sign(ecdsaKey); err != ErrUnsupportedSigner {
+			t.Errorf("%s with an ECDSA key: got %v, want ErrUnsupportedSigner", f.name, err)
+		}
+		if _, err := f.sign(nil); err != ErrUnsupportedSigner {
+			t.Errorf("%s with a nil signer: got %v, want ErrUnsupportedSigner", f.name, err)
+		}
+		if _, err := f.sign(private[:PrivateKeySize-1]); err != ErrBadPrivateKeyLength {
+			t.Errorf("%s with a short key: got %v, want ErrBadPrivateKeyLength", f.name, err)
+		}
+		if _, err := f.sign(stded25519.PrivateKey(private[:PrivateKeySize-1])); err != ErrBadPrivateKeyLength {
+			t.Errorf("%s with a short crypto/ed25519 key: got %v, want ErrBadPrivateKeyLength", f.name, err)
+		}
+	}
+}
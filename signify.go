@@ -0,0 +1,317 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto"
+	cryptorand "crypto/rand"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/agl/ed25519/internal/bcryptpbkdf"
+)
+
+// signify(1) files are an untrusted comment line followed by the base64 of a
+// binary structure that starts with the algorithm, "Ed", and, except in secret
+// keys, a random 8-byte key number. Secret keys are encrypted by XORing them
+// with the output of bcrypt_pbkdf, and carry the first 8 bytes of the SHA-512
+// hash of the plaintext key as a checksum. An embedded signature is a
+// signature file followed by the message itself.
+
+const (
+	signifyCommentPrefix = "untrusted comment: "
+	signifyAlgorithm     = "Ed"
+	signifyKDFAlgorithm  = "BK"
+
+	signifyPublicKeySize  = 2 + 8 + PublicKeySize
+	signifySignatureSize  = 2 + 8 + SignatureSize
+	signifySecretKeySize  = 2 + 2 + 4 + 16 + 8 + 8 + PrivateKeySize
+	signifyMaxCommentSize = 1024
+)
+
+const (
+	// DefaultSignifyRounds is the bcrypt_pbkdf rounds count used by
+	// MarshalSignifyPrivateKey if none is given. It matches signify.
+	DefaultSignifyRounds = 42
+
+	// maxSignifyRounds bounds the work that ParseSignifyPrivateKey does for
+	// an untrusted key.
+	maxSignifyRounds = 10000
+)
+
+// ErrSignifyKeyID is returned by VerifySignify and VerifySignifyEmbedded
+// when the signature was made by a key with another key number.
+var ErrSignifyKeyID = errors.New("ed25519: signify signature is by a different key")
+
+var errBadSignify = errors.New("ed25519: malformed signify file")
+
+// SignifyKeyID is the random key number that signify stores next to a key and
+// in every signature made with it.
+type SignifyKeyID [8]byte
+
+// SignifySignOptions configures SignSignify. The zero value selects the
+// defaults.
+type SignifySignOptions struct {
+	// Comment is the untrusted comment, which isn't signed. If empty,
+	// "signature from signify secret key" is used.
+	Comment string
+
+	// Embed appends the message to the signature, as "signify -S -e"
+	// does. The result is then checked with VerifySignifyEmbedded.
+	Embed bool
+}
+
+// MarshalSignifyPublicKey returns publicKey with keyID in the format of a
+// signify public key file. If comment is empty, "signify public key" is used.
+func MarshalSignifyPublicKey(publicKey PublicKey, keyID SignifyKeyID, comment string) ([]byte, error) {
+	if len(publicKey) != PublicKeySize {
+		return nil, ErrBadPublicKeyLength
+	}
+	if comment == "" {
+		comment = "signify public key"
+	}
+	b := make([]byte, 0, signifyPublicKeySize)
+	b = append(b, signifyAlgorithm...)
+	b = append(b, keyID[:]...)
+	b = append(b, publicKey...)
+	return appendSignifyFile(nil, comment, b)
+}
+
+// ParseSignifyPublicKey parses a signify public key file.
+func ParseSignifyPublicKey(text []byte) (PublicKey, SignifyKeyID, error) {
+	var keyID SignifyKeyID
+	b, rest, err := parseSignifyFile(text, signifyPublicKeySize)
+	if err != nil {
+		return nil, keyID, err
+	}
+	if len(rest) != 0 {
+		return nil, keyID, errBadSignify
+	}
+	copy(keyID[:], b[2:10])
+	return PublicKey(b[10:]), keyID, nil
+}
+
+// MarshalSignifyPrivateKey returns privateKey with keyID in the format of a
+// signify secret key file. If passphrase is not empty the key is encrypted
+// under a key derived from it by bcrypt_pbkdf with rounds rounds and a random
+// salt; rounds may be zero for DefaultSignifyRounds. If passphrase is empty
+// the key is stored unencrypted, as by "signify -G -n", and rounds is ignored.
+// If comment is empty, "signify secret key" is used.
+func MarshalSignifyPrivateKey(privateKey PrivateKey, keyID SignifyKeyID, comment string, passphrase []byte, rounds int) ([]byte, error) {
+	if len(privateKey) != PrivateKeySize {
+		return nil, ErrBadPrivateKeyLength
+	}
+	if rounds == 0 {
+		rounds = DefaultSignifyRounds
+	}
+	if rounds < 0 || rounds > maxSignifyRounds {
+		return nil, errors.New("ed25519: bad bcrypt_pbkdf rounds count")
+	}
+	if len(passphrase) == 0 {
+		rounds = 0
+	}
+	if comment == "" {
+		comment = "signify secret key"
+	}
+
+	b := make([]byte, signifySecretKeySize)
+	defer wipeBytes(b)
+	copy(b, signifyAlgorithm)
+	copy(b[2:], signifyKDFAlgorithm)
+	binary.BigEndian.PutUint32(b[4:], uint32(rounds))
+	salt, checksum, key := b[8:24], b[24:32], b[40:]
+	copy(b[32:], keyID[:])
+	copy(key, privateKey)
+	sum := sha512.Sum512(privateKey)
+	copy(checksum, sum[:])
+
+	if rounds > 0 {
+		if _, err := io.ReadFull(cryptorand.Reader, salt); err != nil {
+			return nil, err
+		}
+		if err := signifyCrypt(key, passphrase, salt, rounds); err != nil {
+			return nil, err
+		}
+	}
+	return appendSignifyFile(nil, comment, b)
+}
+
+// ParseSignifyPrivateKey parses a signify secret key file and decrypts it with
+// passphrase, which is ignored if the key isn't encrypted.
+//
+// It returns ErrPassphraseRequired if the key is encrypted and passphrase is
+// empty, ErrIncorrectPassphrase if the checksum of the decrypted key doesn't
+// match, and ErrKeyMismatch if the public key in it is not that of the seed.
+func ParseSignifyPrivateKey(text, passphrase []byte) (PrivateKey, SignifyKeyID, error) {
+	var keyID SignifyKeyID
+	b, rest, err := parseSignifyFile(text, signifySecretKeySize)
+	if err != nil {
+		return nil, keyID, err
+	}
+	defer wipeBytes(b)
+	if len(rest) != 0 {
+		return nil, keyID, errBadSignify
+	}
+	if string(b[2:4]) != signifyKDFAlgorithm {
+		return nil, keyID, errors.New("ed25519: unsupported signify key derivation function")
+	}
+	rounds := binary.BigEndian.Uint32(b[4:])
+	salt, checksum, key := b[8:24], b[24:32], b[40:]
+	copy(keyID[:], b[32:40])
+
+	if rounds > 0 {
+		if len(passphrase) == 0 {
+			return nil, keyID, ErrPassphraseRequired
+		}
+		if rounds > maxSignifyRounds {
+			return nil, keyID, errors.New("ed25519: bad bcrypt_pbkdf rounds count")
+		}
+		if err := signifyCrypt(key, passphrase, salt, int(rounds)); err != nil {
+			return nil, keyID, err
+		}
+	}
+	sum := sha512.Sum512(key)
+	if subtle.ConstantTimeCompare(sum[:8], checksum) != 1 {
+		return nil, keyID, ErrIncorrectPassphrase
+	}
+	if err := checkKeyPair(PublicKey(key[32:]), PrivateKey(key)); err != nil {
+		return nil, keyID, err
+	}
+	return append(PrivateKey(nil), key...), keyID, nil
+}
+
+// SignSignify signs message with signer, whose key number is keyID, and
+// returns the signature in the format of a signify signature file, followed by
+// message if opts.Embed is set. opts may be nil for the defaults.
+func SignSignify(signer crypto.Signer, keyID SignifyKeyID, message []byte, opts *SignifySignOptions) ([]byte, error) {
+	var o SignifySignOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Comment == "" {
+		o.Comment = "signature from signify secret key"
+	}
+	sig, err := signWith(signer, message)
+	if err != nil {
+		return nil, err
+	}
+
+	b := make([]byte, 0, signifySignatureSize)
+	b = append(b, signifyAlgorithm...)
+	b = append(b, keyID[:]...)
+	b = append(b, sig...)
+	out, err := appendSignifyFile(nil, o.Comment, b)
+	if err != nil {
+		return nil, err
+	}
+	if o.Embed {
+		out = append(out, message...)
+	}
+	return out, nil
+}
+
+// VerifySignify checks that sigText, a signify signature file as produced by
+// SignSignify or "signify -S", is a valid signature of message by the key in
+// publicKeyText, a signify public key file.
+//
+// It returns ErrSignifyKeyID if the key numbers of the signature and the
+// public key differ, ErrInvalidSignature if the signature doesn't verify, and
+// other errors if the files are malformed.
+func VerifySignify(publicKeyText, sigText, message []byte) error {
+	rest, err := verifySignify(publicKeyText, sigText, message, false)
+	if err == nil && len(rest) != 0 {
+		return errBadSignify
+	}
+	return err
+}
+
+// VerifySignifyEmbedded is like VerifySignify but for a signature with the
+// message embedded after it, as produced by "signify -S -e" and used for the
+// SHA256.sig files of OpenBSD releases. It returns the message if the
+// signature is valid.
+func VerifySignifyEmbedded(publicKeyText, signed []byte) (message []byte, err error) {
+	return verifySignify(publicKeyText, signed, nil, true)
+}
+
+// verifySignify parses sigText and checks the signature in it, over the rest
+// of sigText if embedded is true and of message otherwise. It returns what
+// follows the signature.
+func verifySignify(publicKeyText, sigText, message []byte, embedded bool) ([]byte, error) {
+	publicKey, keyID, err := ParseSignifyPublicKey(publicKeyText)
+	if err != nil {
+		return nil, err
+	}
+	b, rest, err := parseSignifyFile(sigText, signifySignatureSize)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(b[2:10], keyID[:]) {
+		return nil, ErrSignifyKeyID
+	}
+	if embedded {
+		message = rest
+	}
+	if err := CheckSignature(publicKey, message, b[10:]); err != nil {
+		return nil, err
+	}
+	return rest, nil
+}
+
+// parseSignifyFile parses the comment line and the base64 line at the start of
+// text and returns the decoded structure, which must be size bytes long and
+// use the Ed25519 algorithm, and what follows it.
+func parseSignifyFile(text []byte, size int) (b, rest []byte, err error) {
+	comment, text, ok1 := cutSignifyLine(text)
+	line, rest, ok2 := cutSignifyLine(text)
+	if !ok1 || !ok2 || !strings.HasPrefix(comment, signifyCommentPrefix) || len(comment) >= signifyMaxCommentSize {
+		return nil, nil, errBadSignify
+	}
+	b, err = base64.StdEncoding.Strict().DecodeString(line)
+	if err != nil || len(b) != size {
+		return nil, nil, errBadSignify
+	}
+	if string(b[:2]) != signifyAlgorithm {
+		return nil, nil, errors.New("ed25519: unsupported signify algorithm")
+	}
+	return b, rest, nil
+}
+
+// cutSignifyLine returns the first line of text, which must end with a line
+// feed, without its line ending, and the text after it.
+func cutSignifyLine(text []byte) (line string, rest []byte, ok bool) {
+	i := bytes.IndexByte(text, '\n')
+	if i < 0 {
+		return "", nil, false
+	}
+	return strings.TrimSuffix(string(text[:i]), "\r"), text[i+1:], true
+}
+
+// appendSignifyFile appends the comment line and the base64 of b to dst.
+func appendSignifyFile(dst []byte, comment string, b []byte) ([]byte, error) {
+	if strings.ContainsAny(comment, "\r\n") || len(signifyCommentPrefix+comment) >= signifyMaxCommentSize {
+		return nil, errors.New("ed25519: bad signify comment")
+	}
+	dst = append(dst, signifyCommentPrefix+comment+"\n"...)
+	dst = append(dst, base64.StdEncoding.EncodeToString(b)+"\n"...)
+	return dst, nil
+}
+
+// signifyCrypt encrypts or decrypts the secret key in key in place by XORing
+// it with the bcrypt_pbkdf output for passphrase and salt.
+func signifyCrypt(key, passphrase, salt []byte, rounds int) error {
+	stream, err := bcryptpbkdf.Key(passphrase, salt, rounds, len(key))
+	if err != nil {
+		return err
+	}
+	subtle.XORBytes(key, key, stream)
+	wipeBytes(stream)
+	return nil
+}
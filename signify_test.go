@@ -0,0 +1,194 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestSignifyFixtures(t *testing.T) {
+	pubText := readTestdata(t, "signify.pub")
+	message := readTestdata(t, "signify_message.txt")
+	sigText := readTestdata(t, "signify_message.txt.sig")
+
+	if err := VerifySignify(pubText, sigText, message); err != nil {
+		t.Error(err)
+	}
+	if err := VerifySignify(pubText, sigText, append(message, '!')); err != ErrInvalidSignature {
+		t.Errorf("other message gave %v", err)
+	}
+
+	// The embedded variant, as used for the SHA256.sig files of releases.
+	listing, err := VerifySignifyEmbedded(pubText, readTestdata(t, "signify_SHA256.sig"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"signify.pub", "signify_message.txt"} {
+		sum := sha256.Sum256(readTestdata(t, name))
+		line := "SHA256 (" + name + ") = " + hex.EncodeToString(sum[:]) + "\n"
+		if !strings.Contains(string(listing), line) {
+			t.Errorf("embedded message doesn't contain %q", line)
+		}
+	}
+	if err := VerifySignify(pubText, readTestdata(t, "signify_SHA256.sig"), listing); err == nil {
+		t.Error("VerifySignify accepted an embedded signature")
+	}
+
+	private, keyID, err := ParseSignifyPrivateKey(readTestdata(t, "signify.sec"), []byte(testPassphrase))
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, pubKeyID, err := ParseSignifyPublicKey(pubText)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keyID != pubKeyID || !bytes.Equal(private[32:], public) {
+		t.Error("secret key doesn't match the public key")
+	}
+	if got, err := MarshalSignifyPublicKey(public, keyID, ""); err != nil || !bytes.Equal(got, pubText) {
+		t.Errorf("MarshalSignifyPublicKey = %q, %v, want %q", got, err, pubText)
+	}
+
+	got, err := SignSignify(private, keyID, message, &SignifySignOptions{Comment: "verify with signify.pub"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, sigText) {
+		t.Errorf("SignSignify gave\n%s\nwant\n%s", got, sigText)
+	}
+	got, err = SignSignify(private, keyID, listing, &SignifySignOptions{Comment: "verify with signify.pub", Embed: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := readTestdata(t, "signify_SHA256.sig"); !bytes.Equal(got, want) {
+		t.Errorf("embedded SignSignify gave\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestSignifyPrivateKeyRoundTrip(t *testing.T) {
+	_, private, _ := GenerateKey(rand.Reader)
+	keyID := SignifyKeyID{1, 2, 3, 4, 5, 6, 7, 8}
+
+	for _, passphrase := range []string{testPassphrase, ""} {
+		text, err := MarshalSignifyPrivateKey(private, keyID, "", []byte(passphrase), 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, gotKeyID, err := ParseSignifyPrivateKey(text, []byte(passphrase))
+		if err != nil {
+			t.Errorf("passphrase %q: %s", passphrase, err)
+			continue
+		}
+		if !bytes.Equal(got, private) || gotKeyID != keyID {
+			t.Errorf("passphrase %q: key didn't round-trip", passphrase)
+		}
+
+		if passphrase == "" {
+			continue
+		}
+		if _, _, err := ParseSignifyPrivateKey(text, nil); err != ErrPassphraseRequired {
+			t.Errorf("no passphrase gave %v", err)
+		}
+		if _, _, err := ParseSignifyPrivateKey(text, []byte("wrong")); err != ErrIncorrectPassphrase {
+			t.Errorf("wrong passphrase gave %v", err)
+		}
+	}
+
+	for _, rounds := range []int{-1, maxSignifyRounds + 1} {
+		if _, err := MarshalSignifyPrivateKey(private, keyID, "", []byte(testPassphrase), rounds); err == nil {
+			t.Errorf("%d rounds accepted", rounds)
+		}
+	}
+}
+
+func TestSignifySignVerify(t *testing.T) {
+	public, private, _ := GenerateKey(rand.Reader)
+	keyID := SignifyKeyID{1, 2, 3, 4, 5, 6, 7, 8}
+	pubText, err := MarshalSignifyPublicKey(public, keyID, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := []byte("hello, world\n")
+
+	sigText, err := SignSignify(private, keyID, message, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifySignify(pubText, sigText, message); err != nil {
+		t.Error(err)
+	}
+
+	otherText, _ := MarshalSignifyPublicKey(public, SignifyKeyID{8, 7, 6, 5, 4, 3, 2, 1}, "")
+	if err := VerifySignify(otherText, sigText, message); err != ErrSignifyKeyID {
+		t.Errorf("other key number gave %v", err)
+	}
+	other, _, _ := GenerateKey(rand.Reader)
+	otherText, _ = MarshalSignifyPublicKey(other, keyID, "")
+	if err := VerifySignify(otherText, sigText, message); err != ErrInvalidSignature {
+		t.Errorf("other key gave %v", err)
+	}
+
+	signed, err := SignSignify(private, keyID, message, &SignifySignOptions{Embed: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := VerifySignifyEmbedded(pubText, signed); err != nil || !bytes.Equal(got, message) {
+		t.Errorf("VerifySignifyEmbedded = %q, %v", got, err)
+	}
+	signed[len(signed)-2] ^= 1
+	if _, err := VerifySignifyEmbedded(pubText, signed); err != ErrInvalidSignature {
+		t.Errorf("changed embedded message gave %v", err)
+	}
+
+	if _, err := SignSignify(private, keyID, message, &SignifySignOptions{Comment: "a\nb"}); err == nil {
+		t.Error("comment with a line break accepted")
+	}
+	if _, err := SignSignify(private, keyID, message, &SignifySignOptions{Comment: strings.Repeat("a", signifyMaxCommentSize)}); err == nil {
+		t.Error("long comment accepted")
+	}
+}
+
+func TestSignifyErrors(t *testing.T) {
+	pubText := readTestdata(t, "signify.pub")
+	message := readTestdata(t, "signify_message.txt")
+	sigText := string(readTestdata(t, "signify_message.txt.sig"))
+	comment, line, _ := strings.Cut(sigText, "\n")
+
+	badSigs := []string{
+		"",
+		comment + "\n",
+		comment + "\n" + strings.TrimSuffix(line, "\n"),
+		"comment: x\n" + line,
+		comment + "\n" + line[4:],
+		comment + "\n" + "RX" + line[2:],
+		sigText + "\n",
+	}
+	for _, bad := range badSigs {
+		if err := VerifySignify(pubText, []byte(bad), message); err == nil {
+			t.Errorf("malformed signature %q accepted", bad)
+		}
+	}
+	if err := VerifySignify([]byte(sigText), []byte(sigText), message); err == nil {
+		t.Error("signature accepted as a public key")
+	}
+
+	// A key whose public half doesn't match its seed, with a valid checksum.
+	_, private, _ := GenerateKey(rand.Reader)
+	other, _, _ := GenerateKey(rand.Reader)
+	mismatched := append(PrivateKey(nil), private...)
+	copy(mismatched[32:], other)
+	text, err := MarshalSignifyPrivateKey(mismatched, SignifyKeyID{}, "", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ParseSignifyPrivateKey(text, nil); err != ErrKeyMismatch {
+		t.Errorf("mismatched key gave %v", err)
+	}
+}
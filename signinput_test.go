@@ -0,0 +1,106 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"strings"
+	"testing"
+)
+
+// signInputFile holds test cases in the format of
+// https://ed25519.cr.yp.to/python/sign.input: one per line, with the
+// private key, public key, message and signature (followed by the message)
+// in hex, separated by colons. The copy here is the 128-case selection of
+// the full 1024-line file that is also shipped with crypto/ed25519; the full
+// file can be gzipped and dropped in its place without changing the test.
+const signInputFile = "testdata/sign.input.gz"
+
+// signInputShortStride is the fraction of the cases that are run in -short
+// mode.
+const signInputShortStride = 8
+
+func TestSignInput(t *testing.T) {
+	f, err := os.Open(signInputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	r, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	lineNo, ran := 0, 0
+	for scanner.Scan() {
+		lineNo++
+		if testing.Short() && lineNo%signInputShortStride != 1 {
+			continue
+		}
+		ran++
+
+		parts := strings.Split(scanner.Text(), ":")
+		if len(parts) != 5 {
+			t.Fatalf("line %d: bad number of parts", lineNo)
+		}
+		privateBytes := decodeHex(t, parts[0])
+		public := PublicKey(decodeHex(t, parts[1]))
+		message := decodeHex(t, parts[2])
+		sigAndMessage := decodeHex(t, parts[3])
+		if len(privateBytes) != PrivateKeySize || len(public) != PublicKeySize || len(sigAndMessage) != SignatureSize+len(message) {
+			t.Fatalf("line %d: bad lengths", lineNo)
+		}
+		want := sigAndMessage[:SignatureSize]
+
+		private := NewKeyFromSeed(privateBytes[:SeedSize])
+		if !bytes.Equal(private, privateBytes) {
+			t.Errorf("line %d: derived private key %x, want %x", lineNo, private, privateBytes)
+			continue
+		}
+		sig, err := Sign(private, message)
+		if err != nil {
+			t.Fatalf("line %d: %s", lineNo, err)
+		}
+		if !bytes.Equal(sig, want) {
+			t.Errorf("line %d: got signature %x, want %x", lineNo, sig, want)
+		}
+
+		// The fourth field is in the crypto_sign format.
+		if combined, err := SignCombined(private, message); err != nil || !bytes.Equal(combined, sigAndMessage) {
+			t.Errorf("line %d: SignCombined = %x, %v", lineNo, combined, err)
+		}
+		if opened, err := OpenCombined(public, sigAndMessage); err != nil || !bytes.Equal(opened, message) {
+			t.Errorf("line %d: OpenCombined = %x, %v", lineNo, opened, err)
+		}
+
+		if err := CheckSignature(public, message, want); err != nil {
+			t.Errorf("line %d: CheckSignature: %s", lineNo, err)
+		}
+		if !StrictVerify(public, message, want) || !VerifyZIP215(public, message, want) {
+			t.Errorf("line %d: signature rejected in strict or ZIP-215 mode", lineNo)
+		}
+		p, err := NewPrecomputedPublicKey(public)
+		if err != nil || !p.Verify(message, want) {
+			t.Errorf("line %d: precomputed verification failed: %v", lineNo, err)
+		}
+
+		wrongMessage := append([]byte{0}, message...)
+		if Verify(public, wrongMessage, want) {
+			t.Errorf("line %d: signature verified for the wrong message", lineNo)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if ran == 0 {
+		t.Fatal("no test cases found")
+	}
+}
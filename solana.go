@@ -0,0 +1,78 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+)
+
+// Solana keypair files, as written by solana-keygen, hold a JSON array of the
+// 64 bytes of the seed followed by the public key. The address of an account
+// is the base58 of its public key.
+
+var errBadSolanaKeypair = errors.New("ed25519: malformed Solana keypair")
+
+// LoadSolanaKeypair parses a Solana keypair file. It returns ErrKeyMismatch if
+// the public key in it is not that of the seed.
+func LoadSolanaKeypair(data []byte) (PrivateKey, error) {
+	var ints []int
+	if err := json.Unmarshal(data, &ints); err != nil || len(ints) != PrivateKeySize {
+		return nil, errBadSolanaKeypair
+	}
+	privateKey := make(PrivateKey, PrivateKeySize)
+	for i, n := range ints {
+		if n < 0 || n > 255 {
+			wipeBytes(privateKey)
+			return nil, errBadSolanaKeypair
+		}
+		privateKey[i] = byte(n)
+		ints[i] = 0
+	}
+	if err := checkKeyPair(PublicKey(privateKey[32:]), privateKey); err != nil {
+		wipeBytes(privateKey)
+		return nil, err
+	}
+	return privateKey, nil
+}
+
+// MarshalSolanaKeypair returns privateKey in the format of a Solana keypair
+// file. The output has no white space, like that of solana-keygen.
+func MarshalSolanaKeypair(privateKey PrivateKey) ([]byte, error) {
+	if len(privateKey) != PrivateKeySize {
+		return nil, ErrBadPrivateKeyLength
+	}
+	b := make([]byte, 0, 4*PrivateKeySize+1)
+	for i, c := range privateKey {
+		if i == 0 {
+			b = append(b, '[')
+		} else {
+			b = append(b, ',')
+		}
+		b = strconv.AppendUint(b, uint64(c), 10)
+	}
+	return append(b, ']'), nil
+}
+
+// SolanaAddress returns the Solana address of publicKey, its base58 encoding.
+// It returns the empty string if len(publicKey) is not PublicKeySize.
+func SolanaAddress(publicKey PublicKey) string {
+	if len(publicKey) != PublicKeySize {
+		return ""
+	}
+	return encodeBase58(publicKey)
+}
+
+// ParseSolanaAddress returns the public key of a Solana address. The key is
+// not checked to be a valid point: program derived addresses are
+// deliberately off the curve.
+func ParseSolanaAddress(address string) (PublicKey, error) {
+	b, ok := decodeBase58(address)
+	if !ok || len(b) != PublicKeySize {
+		return nil, errors.New("ed25519: malformed Solana address")
+	}
+	return PublicKey(b), nil
+}
@@ -0,0 +1,116 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/rand"
+	"strings"
+	"testing"
+)
+
+func TestSolanaKeypairFixture(t *testing.T) {
+	data := readTestdata(t, "solana_keypair.json")
+	privateKey, err := LoadSolanaKeypair(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey := privateKey.Public().(PublicKey)
+	if got := SolanaAddress(publicKey); got != "F4ryEBkiPHvw5iikReYSnzgH8fzusYS5t6bM8eHisuda" {
+		t.Errorf("address %s", got)
+	}
+	if got, err := MarshalSolanaKeypair(privateKey); err != nil || !bytes.Equal(got, data) {
+		t.Errorf("MarshalSolanaKeypair = %s, %v, want %s", got, err, data)
+	}
+
+	// solana_transfer.tx is a legacy transaction with one signature, by the
+	// fee payer, over the message that follows it. The fee payer is the
+	// first account key, after the 3-byte header and the key count.
+	tx := readTestdata(t, "solana_transfer.tx")
+	if tx[0] != 1 {
+		t.Fatalf("transaction has %d signatures", tx[0])
+	}
+	sig, message := tx[1:1+SignatureSize], tx[1+SignatureSize:]
+	if payer := PublicKey(message[4 : 4+PublicKeySize]); !bytes.Equal(payer, publicKey) {
+		t.Fatalf("fee payer %s", SolanaAddress(payer))
+	}
+	if !Verify(publicKey, message, sig) {
+		t.Error("transaction signature doesn't verify")
+	}
+	if got, err := Sign(privateKey, message); err != nil || !bytes.Equal(got, sig) {
+		t.Error("signature of the transaction message differs")
+	}
+
+	recipient, err := ParseSolanaAddress("7tark5iZaRrMfGKtKy1aqpGuRgoxbE6ec7Z5Qa4Jc5xr")
+	if err != nil || !bytes.Equal(recipient, message[4+PublicKeySize:4+2*PublicKeySize]) {
+		t.Errorf("ParseSolanaAddress = %x, %v", recipient, err)
+	}
+}
+
+func TestSolanaKeypairRoundTrip(t *testing.T) {
+	publicKey, privateKey, _ := GenerateKey(rand.Reader)
+	data, err := MarshalSolanaKeypair(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadSolanaKeypair(data)
+	if err != nil || !bytes.Equal(got, privateKey) {
+		t.Fatalf("LoadSolanaKeypair = %x, %v", got, err)
+	}
+	if address := SolanaAddress(publicKey); address == "" {
+		t.Error("no address")
+	} else if got, err := ParseSolanaAddress(address); err != nil || !bytes.Equal(got, publicKey) {
+		t.Errorf("ParseSolanaAddress(%s) = %x, %v", address, got, err)
+	}
+
+	// Spaces and line breaks, as in hand-edited files, are accepted.
+	spaced := strings.ReplaceAll(string(data), ",", ", ")
+	if _, err := LoadSolanaKeypair([]byte(" " + spaced + "\n")); err != nil {
+		t.Errorf("keypair with spaces: %s", err)
+	}
+
+	if _, err := MarshalSolanaKeypair(privateKey[:32]); err != ErrBadPrivateKeyLength {
+		t.Errorf("seed gave %v", err)
+	}
+	if SolanaAddress(publicKey[:31]) != "" {
+		t.Error("address of a short key")
+	}
+}
+
+func TestSolanaKeypairErrors(t *testing.T) {
+	data := string(readTestdata(t, "solana_keypair.json"))
+	first, _, _ := strings.Cut(data[1:], ",")
+	_, private, _ := GenerateKey(rand.Reader)
+	other, _, _ := GenerateKey(rand.Reader)
+	mismatched := append(PrivateKey(nil), private...)
+	copy(mismatched[32:], other)
+	mismatchedData, _ := MarshalSolanaKeypair(mismatched)
+
+	for _, bad := range []string{
+		"",
+		"[]",
+		data[:len(data)-1],
+		strings.Replace(data, "]", ",0]", 1),
+		strings.Replace(data, "["+first+",", "[", 1),
+		strings.Replace(data, "["+first, "[256", 1),
+		strings.Replace(data, "["+first, "[-1", 1),
+		strings.Replace(data, "["+first, "[1.5", 1),
+		strings.Replace(data, "["+first, "[\"1\"", 1),
+		`"` + strings.Repeat("A", 86) + `"`,
+	} {
+		if _, err := LoadSolanaKeypair([]byte(bad)); err != errBadSolanaKeypair {
+			t.Errorf("LoadSolanaKeypair(%q) gave %v", bad, err)
+		}
+	}
+	if _, err := LoadSolanaKeypair(mismatchedData); err != ErrKeyMismatch {
+		t.Errorf("mismatched keypair gave %v", err)
+	}
+
+	for _, bad := range []string{"", "F4ryEBkiPHvw5iikReYSnzgH8fzusYS5t6bM8", "F4ryEBkiPHvw5iikReYSnzgH8fzusYS5t6bM8eHisuda1", "0" + "4ryEBkiPHvw5iikReYSnzgH8fzusYS5t6bM8eHisuda"} {
+		if _, err := ParseSolanaAddress(bad); err == nil {
+			t.Errorf("ParseSolanaAddress(%q) accepted", bad)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"crypto/hkdf"
+	"crypto/hmac"
+	cryptorand "crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+
+	"github.com/agl/ed25519/edwards25519"
+)
+
+// SPAKE2, RFC 9382, lets two parties who share a password agree on a strong
+// key by each sending one point: A sends pA = xG + wM and B sends
+// pB = yG + wN, where w is the password scalar, and both compute
+// K = h*x*y*G after removing the blinding of the other's point. An attacker
+// who doesn't know the password gets one guess per run and nothing to test
+// guesses against offline. The protocol follows the
+// SPAKE2-edwards25519-SHA256-HKDF-HMAC suite, with the M and N of RFC 9382,
+// Section 6, which the RFC generates from the seeds
+// "edwards25519 point generation seed (M)" and "(N)" so that no one knows
+// their discrete logarithms.
+
+var (
+	spake2M = mustDecodePoint("d048032c6ea0b6d697ddc2e86bda85a33adac920f1bf18e1b0c6d166a5cecdaf")
+	spake2N = mustDecodePoint("d3bfb518f44f3430f29d0c92af503865a1ed3281dc69b35dd868ba85f886c4ab")
+)
+
+func mustDecodePoint(s string) *edwards25519.Point {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	p, err := new(edwards25519.Point).SetCanonicalBytes(b)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+const (
+	// SPAKE2MessageSize is the size, in bytes, of the message of Start.
+	SPAKE2MessageSize = 32
+	// SPAKE2KeySize is the size, in bytes, of the shared key of Finish.
+	SPAKE2KeySize = 16
+	// SPAKE2ConfirmationSize is the size, in bytes, of a confirmation MAC.
+	SPAKE2ConfirmationSize = sha256.Size
+)
+
+var (
+	// ErrSPAKE2Confirmation is returned by VerifyConfirmation when the MAC
+	// of the peer is wrong: the passwords or identities differ, or someone
+	// tampered with the messages.
+	ErrSPAKE2Confirmation = errors.New("ed25519: SPAKE2 key confirmation failed")
+
+	errSPAKE2Message = errors.New("ed25519: invalid SPAKE2 message")
+	errSPAKE2State   = errors.New("ed25519: SPAKE2 method called out of order")
+)
+
+// SPAKE2 is one side of a SPAKE2 exchange. Each side calls Start and sends
+// its message, then calls Finish with the message of the other and sends the
+// confirmation MAC, then calls VerifyConfirmation with the MAC of the other.
+// The key returned by Finish must not be used before VerifyConfirmation
+// succeeds. A SPAKE2 is for a single exchange.
+type SPAKE2 struct {
+	isA          bool
+	peerIdentity []byte
+	// rand is the source of the secret scalar, crypto/rand.Reader if nil.
+	rand io.Reader
+
+	identity      []byte
+	w, x          *edwards25519.Scalar
+	message       []byte
+	peerMAC       []byte
+	started, done bool
+}
+
+// NewSPAKE2A returns the side A of an exchange with the party B, identified
+// by peerIdentity.
+func NewSPAKE2A(peerIdentity []byte) *SPAKE2 {
+	return &SPAKE2{isA: true, peerIdentity: append([]byte(nil), peerIdentity...)}
+}
+
+// NewSPAKE2B returns the side B of an exchange with the party A, identified
+// by peerIdentity.
+func NewSPAKE2B(peerIdentity []byte) *SPAKE2 {
+	return &SPAKE2{peerIdentity: append([]byte(nil), peerIdentity...)}
+}
+
+// spake2PasswordScalar returns w, the password reduced to a scalar through
+// SHA-512. A password that is stored should first go through a
+// memory-hard function such as Argon2id.
+func spake2PasswordScalar(password []byte) *edwards25519.Scalar {
+	h := sha512.Sum512(password)
+	w, _ := edwards25519.NewScalar().SetUniformBytes(h[:])
+	return w
+}
+
+// Start begins the exchange with the shared password and the identity of
+// this side, and returns the SPAKE2MessageSize-byte message to send to the
+// peer.
+func (s *SPAKE2) Start(password, identity []byte) ([]byte, error) {
+	if s.started {
+		return nil, errSPAKE2State
+	}
+	rand := s.rand
+	if rand == nil {
+		rand = cryptorand.Reader
+	}
+	var b [64]byte
+	if _, err := io.ReadFull(rand, b[:]); err != nil {
+		return nil, err
+	}
+	s.x, _ = edwards25519.NewScalar().SetUniformBytes(b[:])
+	s.w = spake2PasswordScalar(password)
+	s.identity = append([]byte(nil), identity...)
+
+	blinding := spake2N
+	if s.isA {
+		blinding = spake2M
+	}
+	// xG + wM for A, yG + wN for B.
+	p := new(edwards25519.Point).ScalarMult(s.w, blinding)
+	p.Add(p, new(edwards25519.Point).ScalarBaseMult(s.x))
+	s.message = p.Bytes()
+	s.started = true
+	return append([]byte(nil), s.message...), nil
+}
+
+// Finish completes the exchange with the message of the peer, and returns
+// the SPAKE2KeySize-byte shared key and the confirmation MAC to send to the
+// peer. The key is not confirmed until VerifyConfirmation succeeds.
+func (s *SPAKE2) Finish(peerMessage []byte) (key, confirmation []byte, err error) {
+	if !s.started || s.done {
+		return nil, nil, errSPAKE2State
+	}
+	if len(peerMessage) != SPAKE2MessageSize {
+		return nil, nil, errSPAKE2Message
+	}
+	peer, err := new(edwards25519.Point).SetCanonicalBytes(peerMessage)
+	if err != nil {
+		return nil, nil, errSPAKE2Message
+	}
+
+	// K = h * x * (pB - wN) for A, h * y * (pA - wM) for B.
+	unblinding := spake2M
+	if s.isA {
+		unblinding = spake2N
+	}
+	K := new(edwards25519.Point).ScalarMult(s.w, unblinding)
+	K.Subtract(peer, K)
+	K.ScalarMult(s.x, K)
+	K.MultByCofactor(K)
+	if K.Equal(edwards25519.NewIdentityPoint()) == 1 {
+		return nil, nil, errSPAKE2Message
+	}
+	s.done = true
+
+	idA, idB, pA, pB := s.identity, s.peerIdentity, s.message, peerMessage
+	if !s.isA {
+		idA, idB, pA, pB = idB, idA, pB, pA
+	}
+	tt := spake2Transcript(idA, idB, pA, pB, K.Bytes(), s.w.Bytes())
+	// The scalars are not needed past the transcript.
+	*s.x = edwards25519.Scalar{}
+	*s.w = edwards25519.Scalar{}
+	ke, kcA, kcB, err := spake2Keys(tt)
+	if err != nil {
+		return nil, nil, err
+	}
+	mine, theirs := kcA, kcB
+	if !s.isA {
+		mine, theirs = kcB, kcA
+	}
+
+	s.peerMAC = spake2MAC(theirs, tt)
+	return ke, spake2MAC(mine, tt), nil
+}
+
+// spake2Transcript returns the transcript TT of RFC 9382, Section 4: each of
+// parts after its length as eight little-endian bytes.
+func spake2Transcript(parts ...[]byte) []byte {
+	var tt []byte
+	for _, part := range parts {
+		tt = binary.LittleEndian.AppendUint64(tt, uint64(len(part)))
+		tt = append(tt, part...)
+	}
+	return tt
+}
+
+// spake2Keys derives from the transcript tt the shared key Ke and the
+// confirmation keys KcA and KcB: Ke || Ka = SHA-256(tt) and
+// KcA || KcB = HKDF(Ka, nil, "ConfirmationKeys").
+func spake2Keys(tt []byte) (ke, kcA, kcB []byte, err error) {
+	h := sha256.Sum256(tt)
+	kc, err := hkdf.Key(sha256.New, h[16:], nil, "ConfirmationKeys", 2*sha256.Size)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return append([]byte(nil), h[:16]...), kc[:sha256.Size], kc[sha256.Size:], nil
+}
+
+func spake2MAC(key, tt []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(tt)
+	return mac.Sum(nil)
+}
+
+// VerifyConfirmation checks the confirmation MAC of the peer, and returns
+// ErrSPAKE2Confirmation if it is wrong, in which case the key of Finish must
+// be discarded.
+func (s *SPAKE2) VerifyConfirmation(peerConfirmation []byte) error {
+	if !s.done {
+		return errSPAKE2State
+	}
+	if !hmac.Equal(peerConfirmation, s.peerMAC) {
+		return ErrSPAKE2Confirmation
+	}
+	return nil
+}
@@ -0,0 +1,222 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/agl/ed25519/edwards25519"
+)
+
+// spake2GeneratePoint runs the point generation of RFC 9382, Appendix A, for
+// edwards25519: the first iterated SHA-256 hash of seed that decodes to a
+// point of prime order other than the identity.
+func spake2GeneratePoint(t *testing.T, seed string) *edwards25519.Point {
+	h := []byte(seed)
+	for i := 1; i < 1000; i++ {
+		d := sha256.Sum256(h)
+		h = d[:]
+		p, err := new(edwards25519.Point).SetBytes(h)
+		if err == nil && p.IsTorsionFree() && !p.IsSmallOrder() {
+			return p
+		}
+	}
+	t.Fatalf("no point for %q", seed)
+	return nil
+}
+
+func TestSPAKE2Constants(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		p    *edwards25519.Point
+	}{{"M", spake2M}, {"N", spake2N}} {
+		want := spake2GeneratePoint(t, "edwards25519 point generation seed ("+test.name+")")
+		if test.p.Equal(want) != 1 {
+			t.Errorf("%s is not the point of RFC 9382", test.name)
+		}
+	}
+}
+
+// spake2Run runs an exchange between a and b, and returns the keys of both
+// sides and whether each side accepted the confirmation of the other.
+func spake2Run(t *testing.T, a, b *SPAKE2, passwordA, passwordB []byte) (keyA, keyB []byte, okA, okB bool) {
+	t.Helper()
+	msgA, err := a.Start(passwordA, []byte("alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgB, err := b.Start(passwordB, []byte("bob"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyA, confA, err := a.Finish(msgB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyB, confB, err := b.Finish(msgA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keyA, keyB, a.VerifyConfirmation(confB) == nil, b.VerifyConfirmation(confA) == nil
+}
+
+func TestSPAKE2(t *testing.T) {
+	password := []byte("123456")
+	keyA, keyB, okA, okB := spake2Run(t, NewSPAKE2A([]byte("bob")), NewSPAKE2B([]byte("alice")), password, password)
+	if !okA || !okB {
+		t.Fatal("confirmation failed with the same password")
+	}
+	if !bytes.Equal(keyA, keyB) || len(keyA) != SPAKE2KeySize {
+		t.Fatalf("keys differ: %x, %x", keyA, keyB)
+	}
+	again, _, _, _ := spake2Run(t, NewSPAKE2A([]byte("bob")), NewSPAKE2B([]byte("alice")), password, password)
+	if bytes.Equal(again, keyA) {
+		t.Error("two exchanges gave the same key")
+	}
+
+	keyA, keyB, okA, okB = spake2Run(t, NewSPAKE2A([]byte("bob")), NewSPAKE2B([]byte("alice")), password, []byte("123457"))
+	if okA || okB {
+		t.Error("confirmation succeeded with different passwords")
+	}
+	if bytes.Equal(keyA, keyB) {
+		t.Error("different passwords gave the same key")
+	}
+
+	// A thinks it talks to carol.
+	_, _, okA, okB = spake2Run(t, NewSPAKE2A([]byte("carol")), NewSPAKE2B([]byte("alice")), password, password)
+	if okA || okB {
+		t.Error("confirmation succeeded with different identities")
+	}
+
+	// Two sides A can't complete an exchange.
+	_, _, okA, okB = spake2Run(t, NewSPAKE2A([]byte("bob")), NewSPAKE2A([]byte("alice")), password, password)
+	if okA || okB {
+		t.Error("confirmation succeeded between two sides A")
+	}
+}
+
+func TestSPAKE2Reflection(t *testing.T) {
+	a := NewSPAKE2A([]byte("bob"))
+	msg, err := a.Start([]byte("password"), []byte("alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, conf, err := a.Finish(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.VerifyConfirmation(conf) == nil {
+		t.Error("reflected confirmation accepted")
+	}
+}
+
+// TestSPAKE2Vector pins an exchange with fixed secret scalars, laid out as the
+// test vectors of RFC 9382, Appendix B. The values were computed with a
+// Python implementation of the steps of RFC 9382, independent of this
+// package, which reproduces M, N, pA, pB and K of the RFC's P-256 vector.
+func TestSPAKE2Vector(t *testing.T) {
+	a, b := NewSPAKE2A([]byte("server")), NewSPAKE2B([]byte("client"))
+	a.rand = bytes.NewReader(bytes.Repeat([]byte{0x01}, 64))
+	b.rand = bytes.NewReader(bytes.Repeat([]byte{0x02}, 64))
+	password := []byte("password")
+	msgA, _ := a.Start(password, []byte("client"))
+	msgB, _ := b.Start(password, []byte("server"))
+	w, x, y := a.w.Bytes(), a.x.Bytes(), b.x.Bytes()
+	keyA, confA, err := a.Finish(msgB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyB, confB, err := b.Finish(msgA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(keyA, keyB) {
+		t.Fatal("keys differ")
+	}
+
+	const K = "e2a2e56c1d088d9a575613ca22cc0470a8205d41b58e33cca37576431a15299c"
+	tt := spake2Transcript([]byte("client"), []byte("server"), msgA, msgB, decodeHex(t, K), w)
+	ke, kcA, kcB, err := spake2Keys(tt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name string
+		got  []byte
+		want string
+	}{
+		{"w", w, "d7e8eb2c6c8881bf8bbc0979663511bae02d7c6cc4eb80f3f315000a915d5604"},
+		{"x", x, "4fe2a684e0e6c5e370ca0d89f5e2cb0da1e2ecd4028fa2d395fbca4e33f25805"},
+		{"pA", msgA, "f306455a620b9526fd6afeb69b7685feb4cb7fc6c97c63deb9fcd981687f1909"},
+		{"y", y, "9ec44d09c1cd8bc7e1941b12ebc5971b42c5d9a9051e45a72bf7959d66e4b10a"},
+		{"pB", msgB, "cedb388d84407ee303c5c5cd9dfab69cd5fdda996cbd519a0df270e3c020a013"},
+		{"TT", tt, "0600000000000000636c69656e7406000000000000007365727665722000000000000000f306455a620b9526fd6afeb69b7685feb4cb7fc6c97c63deb9fcd981687f19092000000000000000cedb388d84407ee303c5c5cd9dfab69cd5fdda996cbd519a0df270e3c020a0132000000000000000e2a2e56c1d088d9a575613ca22cc0470a8205d41b58e33cca37576431a15299c2000000000000000d7e8eb2c6c8881bf8bbc0979663511bae02d7c6cc4eb80f3f315000a915d5604"},
+		{"Ke", ke, "5533c2426ecb83b92ab094383b5ccf51"},
+		{"key", keyA, "5533c2426ecb83b92ab094383b5ccf51"},
+		{"KcA", kcA, "7e4100ea5642da562ad97f824189cfb9e2324edc3b209128421651a90b85f2e7"},
+		{"KcB", kcB, "f4379b0af8f1ab075c4c73a168ab52760237d6d4d00409cbb13878d11014ff20"},
+		{"MAC(A)", confA, "eae87ee7e9e4001c1751fa1865d6e9e938cdbc01aceda3d2e3d1968b39f5f220"},
+		{"MAC(B)", confB, "01a17a88ac4bd523bd2af4344a5232c14a3ca418cd9180aca20f49bde43292c2"},
+	} {
+		if got := hex.EncodeToString(test.got); got != test.want {
+			t.Errorf("%s: got %s, want %s", test.name, got, test.want)
+		}
+	}
+
+	// Finish wipes the secret scalars.
+	zero := edwards25519.NewScalar()
+	for _, s := range []*edwards25519.Scalar{a.x, a.w, b.x, b.w} {
+		if s.Equal(zero) != 1 {
+			t.Error("scalar not wiped by Finish")
+		}
+	}
+}
+
+func TestSPAKE2Errors(t *testing.T) {
+	a := NewSPAKE2A([]byte("bob"))
+	if _, _, err := a.Finish(make([]byte, SPAKE2MessageSize)); err == nil {
+		t.Error("Finish before Start accepted")
+	}
+	if err := a.VerifyConfirmation(nil); err == nil {
+		t.Error("VerifyConfirmation before Finish accepted")
+	}
+	if _, err := a.Start([]byte("password"), []byte("alice")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Start([]byte("password"), []byte("alice")); err == nil {
+		t.Error("second Start accepted")
+	}
+
+	identity := edwards25519.NewIdentityPoint().Bytes()
+	offCurve, _ := hex.DecodeString("0200000000000000000000000000000000000000000000000000000000000000")
+	for _, msg := range [][]byte{offCurve, identity[:31], append(identity, 0)} {
+		if _, _, err := a.Finish(msg); err == nil {
+			t.Errorf("message %x accepted", msg)
+		}
+	}
+
+	// A message of wM or wN unblinds to the identity, which would make K
+	// known to the attacker.
+	b := NewSPAKE2B([]byte("alice"))
+	b.Start([]byte("password"), []byte("bob"))
+	w := spake2PasswordScalar([]byte("password"))
+	if _, _, err := b.Finish(new(edwards25519.Point).ScalarMult(w, spake2M).Bytes()); err == nil {
+		t.Error("message wM accepted")
+	}
+	if _, _, err := a.Finish(new(edwards25519.Point).ScalarMult(w, spake2N).Bytes()); err == nil {
+		t.Error("message wN accepted")
+	}
+	if _, err := NewSPAKE2A(nil).Start(nil, nil); err != nil {
+		t.Errorf("empty password and identities rejected: %v", err)
+	}
+	c := NewSPAKE2A(nil)
+	c.rand = bytes.NewReader(make([]byte, 63))
+	if _, err := c.Start(nil, nil); err == nil {
+		t.Error("short random source accepted")
+	}
+}
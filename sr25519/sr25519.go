@@ -0,0 +1,221 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package sr25519 implements the Schnorr signatures over ristretto255 of the
+// schnorrkel Rust crate, as Substrate-based chains use them. Keys are
+// expanded from 32-byte mini secret keys in schnorrkel's Ed25519 mode, which
+// is the one Substrate uses, and signatures are computed over a merlin
+// transcript of a signing context and the message.
+package sr25519
+
+import (
+	cryptorand "crypto/rand"
+	"crypto/sha512"
+	"errors"
+	"io"
+	"strconv"
+
+	"github.com/agl/ed25519/edwards25519"
+	"github.com/agl/ed25519/internal/merlin"
+	"github.com/agl/ed25519/ristretto255"
+)
+
+const (
+	// PublicKeySize is the size, in bytes, of public keys as used in this
+	// package, compressed ristretto255 elements.
+	PublicKeySize = 32
+	// PrivateKeySize is the size, in bytes, of private keys as used in this
+	// package.
+	PrivateKeySize = 64
+	// SignatureSize is the size, in bytes, of signatures generated and
+	// verified by this package.
+	SignatureSize = 64
+	// SeedSize is the size, in bytes, of private key seeds, the mini secret
+	// keys of schnorrkel.
+	SeedSize = 32
+)
+
+// SubstrateContext is the signing context of Substrate, under which its
+// sr25519 signatures are made.
+const SubstrateContext = "substrate"
+
+// ErrBadPrivateKeyLength is returned by Sign for a private key that is not
+// PrivateKeySize bytes long.
+var ErrBadPrivateKeyLength = errors.New("sr25519: bad private key length")
+
+// PublicKey is the type of sr25519 public keys.
+type PublicKey []byte
+
+// PrivateKey is the type of sr25519 private keys. It is the seed followed by
+// the public key, as in the ed25519 package.
+type PrivateKey []byte
+
+// Public returns the PublicKey corresponding to priv.
+func (priv PrivateKey) Public() PublicKey {
+	publicKey := make([]byte, PublicKeySize)
+	copy(publicKey, priv[32:])
+	return publicKey
+}
+
+// Seed returns the mini secret key corresponding to priv.
+func (priv PrivateKey) Seed() []byte {
+	seed := make([]byte, SeedSize)
+	copy(seed, priv[:32])
+	return seed
+}
+
+// GenerateKey generates a public/private key pair using entropy from rand.
+// If rand is nil, crypto/rand.Reader will be used.
+func GenerateKey(rand io.Reader) (PublicKey, PrivateKey, error) {
+	if rand == nil {
+		rand = cryptorand.Reader
+	}
+	var seed [SeedSize]byte
+	if _, err := io.ReadFull(rand, seed[:]); err != nil {
+		return nil, nil, err
+	}
+	privateKey := NewKeyFromSeed(seed[:])
+	wipe(seed[:])
+	return privateKey.Public(), privateKey, nil
+}
+
+// NewKeyFromSeed calculates a private key from a mini secret key, as
+// schnorrkel's MiniSecretKey::expand_to_keypair does in Ed25519 mode. It
+// will panic if len(seed) is not SeedSize.
+func NewKeyFromSeed(seed []byte) PrivateKey {
+	if l := len(seed); l != SeedSize {
+		panic("sr25519: bad seed length: " + strconv.Itoa(l))
+	}
+	key, nonce := expand(seed)
+	defer wipe(nonce[:])
+	A := new(ristretto255.Element).ScalarBaseMult(key)
+	key.Set(edwards25519.NewScalar())
+
+	privateKey := make([]byte, PrivateKeySize)
+	copy(privateKey, seed)
+	copy(privateKey[32:], A.Bytes())
+	return privateKey
+}
+
+// expand returns the secret scalar and the signing nonce seed of the mini
+// secret key seed. As in Ed25519, the first half of SHA-512(seed) is clamped,
+// but it is then divided by the cofactor, because ristretto255 has prime
+// order; the result is below 2^252, so it needs no reduction.
+func expand(seed []byte) (*edwards25519.Scalar, [32]byte) {
+	h := sha512.Sum512(seed)
+	defer wipe(h[:])
+	h[0] &= 248
+	h[31] &= 63
+	h[31] |= 64
+
+	var key [32]byte
+	for i := 0; i < 31; i++ {
+		key[i] = h[i]>>3 | h[i+1]<<5
+	}
+	key[31] = h[31] >> 3
+	defer wipe(key[:])
+
+	s, err := edwards25519.NewScalar().SetCanonicalBytes(key[:])
+	if err != nil {
+		panic("sr25519: internal error: expanded key is not canonical")
+	}
+	var nonce [32]byte
+	copy(nonce[:], h[32:])
+	return s, nonce
+}
+
+// transcript returns the transcript of a signature of message by the public
+// key A under the signing context context: schnorrkel's
+// SigningContext::new(context).bytes(message), with A committed to it.
+func transcript(context, message, A []byte) *merlin.Transcript {
+	t := merlin.New("SigningContext")
+	t.AppendMessage(nil, context)
+	t.AppendMessage([]byte("sign-bytes"), message)
+	t.AppendMessage([]byte("proto-name"), []byte("Schnorr-sig"))
+	t.AppendMessage([]byte("sign:pk"), A)
+	return t
+}
+
+// challenge commits the commitment R to t, a transcript from transcript, and
+// returns the challenge scalar of the signature.
+func challenge(t *merlin.Transcript, R []byte) *edwards25519.Scalar {
+	t.AppendMessage([]byte("sign:R"), R)
+	var buf [64]byte
+	t.ChallengeBytes([]byte("sign:c"), buf[:])
+	k, _ := edwards25519.NewScalar().SetUniformBytes(buf[:])
+	return k
+}
+
+// Sign signs message under the signing context context, SubstrateContext for
+// Substrate, with privateKey, and returns a signature in the format of
+// schnorrkel. The commitment nonce is derived from the transcript, the
+// private key and 32 bytes from rand, as in schnorrkel, so signatures are
+// randomized but don't depend on rand for their security. If rand is nil,
+// crypto/rand.Reader will be used. Sign returns ErrBadPrivateKeyLength if
+// len(privateKey) is not PrivateKeySize, or an error from rand.
+func Sign(privateKey PrivateKey, context, message []byte, rand io.Reader) ([]byte, error) {
+	if len(privateKey) != PrivateKeySize {
+		return nil, ErrBadPrivateKeyLength
+	}
+	if rand == nil {
+		rand = cryptorand.Reader
+	}
+	key, nonce := expand(privateKey[:32])
+	defer key.Set(edwards25519.NewScalar())
+	defer wipe(nonce[:])
+
+	t := transcript(context, message, privateKey[32:])
+	var buf [64]byte
+	if err := t.WitnessBytes([]byte("signing"), buf[:], [][]byte{nonce[:]}, rand); err != nil {
+		return nil, err
+	}
+	r, _ := edwards25519.NewScalar().SetUniformBytes(buf[:])
+	wipe(buf[:])
+	defer r.Set(edwards25519.NewScalar())
+	R := new(ristretto255.Element).ScalarBaseMult(r).Bytes()
+
+	k := challenge(t, R)
+	s := edwards25519.NewScalar().MultiplyAdd(k, key, r)
+
+	signature := make([]byte, SignatureSize)
+	copy(signature, R)
+	copy(signature[32:], s.Bytes())
+	// The top bit of s, which is always clear, marks the signature as a
+	// schnorrkel one, as opposed to one of its pre-release versions.
+	signature[63] |= 0x80
+	return signature, nil
+}
+
+// Verify reports whether sig is a valid signature of message under the
+// signing context context by publicKey, as schnorrkel verifies it. It returns
+// false for a public key that is not a canonical ristretto255 encoding and
+// for a signature without the schnorrkel marker bit or with a non-canonical
+// s.
+func Verify(publicKey PublicKey, context, message, sig []byte) bool {
+	if len(publicKey) != PublicKeySize || len(sig) != SignatureSize || sig[63]&0x80 == 0 {
+		return false
+	}
+	A, err := new(ristretto255.Element).SetCanonicalBytes(publicKey)
+	if err != nil {
+		return false
+	}
+	var sBytes [32]byte
+	copy(sBytes[:], sig[32:])
+	sBytes[31] &= 0x7f
+	s, err := edwards25519.NewScalar().SetCanonicalBytes(sBytes[:])
+	if err != nil {
+		return false
+	}
+
+	k := challenge(transcript(context, message, publicKey), sig[:32])
+	minusA := new(ristretto255.Element).Negate(A)
+	R := new(ristretto255.Element).VarTimeDoubleScalarBaseMult(k, minusA, s)
+	return string(R.Bytes()) == string(sig[:32])
+}
+
+func wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,199 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sr25519
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+// keyTests are mini secret keys and their public keys in Substrate: those of
+// the development accounts Alice and Bob, and that of the seed of the first
+// test of RFC 8032, from the sr25519 tests of Substrate's sp-core.
+var keyTests = []struct {
+	seed, public string
+}{
+	{
+		"e5be9a5092b81bca64be81d212e7f2f9eba183bb7a90954f7b76361f6edb5c0a",
+		"d43593c715fdd31c61141abd04a99fd6822c8558854ccde39a5684e7a56da27d",
+	},
+	{
+		"398f0c28f98885e046333d4a41c19cee4c37368a9832c6502f6cfd182e2aef89",
+		"8eaf04151687736326c9fea17e25fc5287613693c912909cb226aa4794f26a48",
+	},
+	{
+		"9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60",
+		"44a996beb1eef7bdcab976ab6d2ca26104834164ecf28fb375600576fcc6eb0f",
+	},
+}
+
+// signatureTests are signatures by the keys of keyTests, with 32 fixed bytes
+// in place of the randomness of the signer. They are the output of Sign, and
+// only guard against changes to the transcript: they don't come from
+// schnorrkel, and nothing here shows that schnorrkel accepts them.
+var signatureTests = []struct {
+	key                         int
+	context, message, rand, sig string
+}{
+	{
+		0, SubstrateContext, "",
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		"5c3d21f3e193907dd380092eecf731fa9581d0a5f162c0285abaa8b233ab3d5c37b314e44ff7e15e60553d729b2e3681c77af514cda325a56a7eede010824386",
+	},
+	{
+		1, SubstrateContext, "hello, world",
+		"0102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f20",
+		"068addfa629edf48083a148c28a6c27aae08f70bf2dccac34aa26e9521b08d4b2c969837d90a12bd5e6894b94ae26be99e76817a972b5cd7d8353b90648ef580",
+	},
+	{
+		2, "good", "test message",
+		"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+		"8e7d3049ff2e2d534028c7052b8bc5c61d93c2653413c8e24158e68fecae495ee58d1b8aceb495cd5a8f21c9154bc940877d5fcfcc4c02d18021caba6cd37a80",
+	},
+}
+
+func decodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestNewKeyFromSeed(t *testing.T) {
+	for i, test := range keyTests {
+		priv := NewKeyFromSeed(decodeHex(t, test.seed))
+		if got := hex.EncodeToString(priv.Public()); got != test.public {
+			t.Errorf("%d: got public key %s, want %s", i, got, test.public)
+		}
+		if got := hex.EncodeToString(priv.Seed()); got != test.seed {
+			t.Errorf("%d: got seed %s", i, got)
+		}
+	}
+}
+
+func TestSignatureVectors(t *testing.T) {
+	for i, test := range signatureTests {
+		priv := NewKeyFromSeed(decodeHex(t, keyTests[test.key].seed))
+		context, message := []byte(test.context), []byte(test.message)
+		want := decodeHex(t, test.sig)
+		if !Verify(priv.Public(), context, message, want) {
+			t.Errorf("%d: signature does not verify", i)
+		}
+		sig, err := Sign(priv, context, message, bytes.NewReader(decodeHex(t, test.rand)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(sig, want) {
+			t.Errorf("%d: got signature %x, want %x", i, sig, want)
+		}
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	public, private, err := GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	context, message := []byte(SubstrateContext), []byte("test message")
+	sig, err := Sign(private, context, message, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Verify(public, context, message, sig) {
+		t.Fatal("valid signature rejected")
+	}
+	if other, _ := Sign(private, context, message, nil); bytes.Equal(other, sig) {
+		t.Error("two signatures of the same message are equal")
+	}
+
+	if Verify(public, context, []byte("wrong message"), sig) {
+		t.Error("signature of a different message accepted")
+	}
+	if Verify(public, []byte("other"), message, sig) {
+		t.Error("signature under a different context accepted")
+	}
+	otherPublic, _, _ := GenerateKey(nil)
+	if Verify(otherPublic, context, message, sig) {
+		t.Error("signature accepted for a different key")
+	}
+	for _, i := range []int{0, 31, 32, 62} {
+		bad := append([]byte(nil), sig...)
+		bad[i] ^= 1
+		if Verify(public, context, message, bad) {
+			t.Errorf("signature with byte %d altered accepted", i)
+		}
+	}
+	if Verify(public, context, message, sig[:SignatureSize-1]) {
+		t.Error("short signature accepted")
+	}
+	if Verify(public[:PublicKeySize-1], context, message, sig) {
+		t.Error("short public key accepted")
+	}
+}
+
+func TestVerifyEncodings(t *testing.T) {
+	test := signatureTests[0]
+	public := NewKeyFromSeed(decodeHex(t, keyTests[test.key].seed)).Public()
+	sig := decodeHex(t, test.sig)
+	context, message := []byte(test.context), []byte(test.message)
+
+	// Without the marker bit the signature is of the pre-release format.
+	unmarked := append([]byte(nil), sig...)
+	unmarked[63] &= 0x7f
+	if Verify(public, context, message, unmarked) {
+		t.Error("signature without the marker bit accepted")
+	}
+
+	// s + L is the same scalar, but not canonical.
+	l := []byte{0xed, 0xd3, 0xf5, 0x5c, 0x1a, 0x63, 0x12, 0x58, 0xd6, 0x9c, 0xf7, 0xa2, 0xde, 0xf9, 0xde, 0x14, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x10}
+	malleable := append([]byte(nil), unmarked...)
+	var carry int
+	for i := range l {
+		carry += int(malleable[32+i]) + int(l[i])
+		malleable[32+i] = byte(carry)
+		carry >>= 8
+	}
+	malleable[63] |= 0x80
+	if Verify(public, context, message, malleable) {
+		t.Error("signature with a non-canonical s accepted")
+	}
+
+	// The public key with a point of order 4 added has the same element,
+	// but the encoding 0x01 || 0... is not canonical.
+	badPublic := make([]byte, PublicKeySize)
+	badPublic[0] = 1
+	if Verify(badPublic, context, message, sig) {
+		t.Error("non-canonical public key accepted")
+	}
+}
+
+func TestSignErrors(t *testing.T) {
+	_, private, err := GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Sign(private[:PrivateKeySize-1], nil, nil, nil); err != ErrBadPrivateKeyLength {
+		t.Errorf("short private key: got %v", err)
+	}
+	if _, err := Sign(private, nil, nil, bytes.NewReader(make([]byte, 31))); err == nil {
+		t.Error("exhausted random source accepted")
+	}
+	if _, _, err := GenerateKey(bytes.NewReader(make([]byte, SeedSize-1))); err == nil {
+		t.Error("GenerateKey accepted a short random source")
+	}
+	if _, err := Sign(private, nil, nil, errorReader{}); !errors.Is(err, errRead) {
+		t.Errorf("got %v, want the error of the random source", err)
+	}
+}
+
+var errRead = errors.New("read failed")
+
+type errorReader struct{}
+
+func (errorReader) Read([]byte) (int, error) { return 0, errRead }
@@ -0,0 +1,169 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// sshKeyType is the name of Ed25519 keys in the SSH protocol, from RFC 8709.
+const sshKeyType = "ssh-ed25519"
+
+// ErrBadOpenSSHPublicKey is returned by ParseOpenSSHPublicKey when a line is
+// not an Ed25519 public key in the OpenSSH authorized_keys format.
+var ErrBadOpenSSHPublicKey = errors.New("ed25519: malformed OpenSSH public key")
+
+// MarshalOpenSSHPublicKey returns publicKey as a line of an OpenSSH
+// authorized_keys file, "ssh-ed25519 <base64 of the wire encoding> comment",
+// without a trailing newline. The comment is omitted if it is empty. It
+// returns the empty string if len(publicKey) is not PublicKeySize.
+func MarshalOpenSSHPublicKey(publicKey PublicKey, comment string) string {
+	if len(publicKey) != PublicKeySize {
+		return ""
+	}
+	line := sshKeyType + " " + base64.StdEncoding.EncodeToString(sshPublicKeyBlob(publicKey))
+	if comment != "" {
+		line += " " + comment
+	}
+	return line
+}
+
+// ParseOpenSSHPublicKey parses an Ed25519 public key in the format of a line
+// of an OpenSSH authorized_keys file or of a .pub file written by ssh-keygen.
+// The line may start with options, such as `no-pty,command="..."`, which are
+// skipped, and fields may be separated by any amount of white space. The
+// comment, possibly empty, is everything after the key with the surrounding
+// white space removed.
+//
+// The key type before the base64 blob and the one encoded inside it must
+// both be ssh-ed25519, and the blob must hold exactly a 32-byte key. Other
+// key types, comments, blank lines and malformed blobs give
+// ErrBadOpenSSHPublicKey.
+func ParseOpenSSHPublicKey(line string) (publicKey PublicKey, comment string, err error) {
+	rest := strings.TrimSpace(line)
+	if rest == "" || rest[0] == '#' {
+		return nil, "", ErrBadOpenSSHPublicKey
+	}
+
+	keyType, rest := nextSSHField(rest)
+	if keyType != sshKeyType {
+		// The first field was the options.
+		keyType, rest = nextSSHField(rest)
+		if keyType != sshKeyType {
+			return nil, "", ErrBadOpenSSHPublicKey
+		}
+	}
+
+	encoded, rest := nextSSHField(rest)
+	blob, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, "", ErrBadOpenSSHPublicKey
+	}
+	publicKey, err = parseSSHPublicKeyBlob(blob)
+	if err != nil {
+		return nil, "", err
+	}
+	return publicKey, strings.TrimSpace(rest), nil
+}
+
+// SSHFingerprintSHA256 returns the fingerprint of publicKey as shown by
+// OpenSSH, "SHA256:" followed by the unpadded base64 of the SHA-256 hash of
+// the SSH wire encoding of the key. It returns the empty string if
+// len(publicKey) is not PublicKeySize.
+func SSHFingerprintSHA256(publicKey PublicKey) string {
+	if len(publicKey) != PublicKeySize {
+		return ""
+	}
+	sum := sha256.Sum256(sshPublicKeyBlob(publicKey))
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+}
+
+// SSHFingerprintLegacyMD5 returns the fingerprint of publicKey in the form
+// used by OpenSSH before version 6.8, the MD5 hash of the SSH wire encoding of
+// the key as colon-separated hex bytes. ssh-keygen -E md5 shows it with an
+// "MD5:" prefix, which is not included. It returns the empty string if
+// len(publicKey) is not PublicKeySize.
+func SSHFingerprintLegacyMD5(publicKey PublicKey) string {
+	if len(publicKey) != PublicKeySize {
+		return ""
+	}
+	sum := md5.Sum(sshPublicKeyBlob(publicKey))
+	hexSum := hex.EncodeToString(sum[:])
+	var b strings.Builder
+	for i := 0; i < len(hexSum); i += 2 {
+		if i > 0 {
+			b.WriteByte(':')
+		}
+		b.WriteString(hexSum[i : i+2])
+	}
+	return b.String()
+}
+
+// nextSSHField splits the first white-space separated field from s, which
+// must not start with white space. Double-quoted sections, in which a
+// backslash escapes the next character, may contain white space, as in the
+// options of an authorized_keys line.
+func nextSSHField(s string) (field, rest string) {
+	inQuote := false
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case inQuote && c == '\\' && i+1 < len(s):
+			i++
+		case c == '"':
+			inQuote = !inQuote
+		case !inQuote && (c == ' ' || c == '\t'):
+			return s[:i], strings.TrimLeft(s[i:], " \t")
+		}
+	}
+	return s, ""
+}
+
+// sshPublicKeyBlob returns the SSH wire encoding of publicKey, as specified by
+// RFC 8709, Section 4: the key type and the key, each as an SSH string.
+func sshPublicKeyBlob(publicKey PublicKey) []byte {
+	var b []byte
+	b = appendSSHString(b, []byte(sshKeyType))
+	b = appendSSHString(b, publicKey)
+	return b
+}
+
+// parseSSHPublicKeyBlob parses the output of sshPublicKeyBlob.
+func parseSSHPublicKeyBlob(blob []byte) (PublicKey, error) {
+	keyType, rest, ok := readSSHString(blob)
+	if !ok || string(keyType) != sshKeyType {
+		return nil, ErrBadOpenSSHPublicKey
+	}
+	key, rest, ok := readSSHString(rest)
+	if !ok || len(key) != PublicKeySize || len(rest) != 0 {
+		return nil, ErrBadOpenSSHPublicKey
+	}
+	return PublicKey(append([]byte(nil), key...)), nil
+}
+
+// appendSSHString appends s to b as an SSH string, a uint32 big-endian length
+// followed by the bytes, from RFC 4251, Section 5.
+func appendSSHString(b, s []byte) []byte {
+	b = binary.BigEndian.AppendUint32(b, uint32(len(s)))
+	return append(b, s...)
+}
+
+// readSSHString reads an SSH string from the start of b. It reports false if b
+// is too short.
+func readSSHString(b []byte) (s, rest []byte, ok bool) {
+	if len(b) < 4 {
+		return nil, nil, false
+	}
+	n := binary.BigEndian.Uint32(b)
+	if uint64(n) > uint64(len(b)-4) {
+		return nil, nil, false
+	}
+	return b[4 : 4+n], b[4+n:], true
+}
@@ -0,0 +1,165 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"strings"
+	"testing"
+)
+
+const (
+	// sshKeygenPublicKey is the key in testdata/ssh_ed25519.pub.
+	sshKeygenPublicKey = "02149a3d7d39e70ac3ae1e818f5863e14b4dd088d8a0cc0d76612da9520e8814"
+	// xcryptoPublicKey is the key written by golang.org/x/crypto/ssh in
+	// testdata/authorized_keys.
+	xcryptoPublicKey = "4fd099ccd47d7893dfe9ec24414ecb0d9b5420232aad30d91c465be33cbe65c4"
+	bobPublicKey     = "1491fd7b4a1cb79da30c95faad3ca8dced0f992bc7c7a7fb7e0518df8b6ecd12"
+)
+
+func TestOpenSSHPublicKeyRoundTrip(t *testing.T) {
+	public, _, _ := GenerateKey(rand.Reader)
+	for _, comment := range []string{"", "user@host", "a comment with spaces"} {
+		line := MarshalOpenSSHPublicKey(public, comment)
+		parsed, gotComment, err := ParseOpenSSHPublicKey(line)
+		if err != nil {
+			t.Fatalf("%q: %v", line, err)
+		}
+		if !bytes.Equal(parsed, public) || gotComment != comment {
+			t.Errorf("%q: got %x %q", line, parsed, gotComment)
+		}
+	}
+	if line := MarshalOpenSSHPublicKey(public[:31], ""); line != "" {
+		t.Errorf("short key gave %q", line)
+	}
+}
+
+func TestOpenSSHPublicKeySSHKeygen(t *testing.T) {
+	data, err := os.ReadFile("testdata/ssh_ed25519.pub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, comment, err := ParseOpenSSHPublicKey(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hex.EncodeToString(public) != sshKeygenPublicKey || comment != "alice@example.com" {
+		t.Errorf("got %x %q", public, comment)
+	}
+	if got := MarshalOpenSSHPublicKey(public, comment) + "\n"; got != string(data) {
+		t.Errorf("re-encoded as %q, want %q", got, data)
+	}
+}
+
+func TestOpenSSHAuthorizedKeys(t *testing.T) {
+	data, err := os.ReadFile("testdata/authorized_keys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		key, comment string
+	}{
+		{sshKeygenPublicKey, "alice@example.com"},
+		{xcryptoPublicKey, ""},
+		{sshKeygenPublicKey, "alice@example.com"},
+		{bobPublicKey, "bob at  example.com"},
+	}
+	var got int
+	for _, line := range strings.Split(string(data), "\n") {
+		public, comment, err := ParseOpenSSHPublicKey(line)
+		if trimmed := strings.TrimSpace(line); trimmed == "" || trimmed[0] == '#' {
+			if err == nil {
+				t.Errorf("%q: parsed", line)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", line, err)
+			continue
+		}
+		if got < len(want) && (hex.EncodeToString(public) != want[got].key || comment != want[got].comment) {
+			t.Errorf("%q: got %x %q", line, public, comment)
+		}
+		got++
+	}
+	if got != len(want) {
+		t.Errorf("parsed %d keys, want %d", got, len(want))
+	}
+
+	// The x/crypto/ssh line must also be reproduced exactly.
+	public, _ := hex.DecodeString(xcryptoPublicKey)
+	if line := MarshalOpenSSHPublicKey(public, ""); !strings.Contains(string(data), "\n"+line+"\n") {
+		t.Errorf("%q does not match the x/crypto/ssh encoding", line)
+	}
+}
+
+func TestOpenSSHPublicKeyErrors(t *testing.T) {
+	public, _, _ := GenerateKey(rand.Reader)
+	blob := sshPublicKeyBlob(public)
+	encode := func(b []byte) string {
+		return "ssh-ed25519 " + base64.StdEncoding.EncodeToString(b)
+	}
+	rsaBlob := appendSSHString(appendSSHString(nil, []byte("ssh-rsa")), public)
+
+	for _, line := range []string{
+		"",
+		"ssh-ed25519",
+		"ssh-ed25519 !!!!",
+		"ecdsa-sha2-nistp256 " + base64.StdEncoding.EncodeToString(blob),
+		`command="ssh-ed25519" ` + "ssh-rsa " + base64.StdEncoding.EncodeToString(blob),
+		encode(rsaBlob),
+		encode(blob[:len(blob)-1]),
+		encode(blob[:10]),
+		encode(append(append([]byte{}, blob...), 0)),
+		encode(appendSSHString(appendSSHString(nil, []byte(sshKeyType)), public[:31])),
+		encode([]byte{0xff, 0xff, 0xff, 0xff}),
+	} {
+		if _, _, err := ParseOpenSSHPublicKey(line); err != ErrBadOpenSSHPublicKey {
+			t.Errorf("%q: got %v", line, err)
+		}
+	}
+}
+
+func TestSSHFingerprint(t *testing.T) {
+	// The expected values are the output of ssh-keygen -lf and
+	// ssh-keygen -E md5 -lf.
+	for _, tc := range []struct {
+		path, sha256, md5 string
+	}{
+		{
+			"testdata/ssh_ed25519.pub",
+			"SHA256:/N/CRHPnJj+fxC2DQMrc83Ix3LRWio6ImRZsIyc9AK8",
+			"ce:da:77:ff:1f:14:c9:b5:fd:48:b7:7f:5c:ce:81:82",
+		},
+		{
+			"testdata/ssh_ca.pub",
+			"SHA256:aZk4gM2u56Agzf5vl7Wt4tOkzlN5bEia1HYbBn23XvI",
+			"0a:d0:c8:f8:8b:6d:34:ca:0f:5a:98:01:64:9b:76:ee",
+		},
+	} {
+		data, err := os.ReadFile(tc.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		public, _, err := ParseOpenSSHPublicKey(string(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := SSHFingerprintSHA256(public); got != tc.sha256 {
+			t.Errorf("%s: SHA-256 fingerprint %s, want %s", tc.path, got, tc.sha256)
+		}
+		if got := SSHFingerprintLegacyMD5(public); got != tc.md5 {
+			t.Errorf("%s: MD5 fingerprint %s, want %s", tc.path, got, tc.md5)
+		}
+	}
+
+	if SSHFingerprintSHA256(make([]byte, 31)) != "" || SSHFingerprintLegacyMD5(make([]byte, 33)) != "" {
+		t.Error("fingerprint of a key of the wrong length")
+	}
+}
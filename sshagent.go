@@ -0,0 +1,170 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"crypto"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+)
+
+// The SSH agent protocol, described in draft-miller-ssh-agent, exchanges
+// messages of a uint32 length, a type byte and a payload. Only two requests
+// are needed here: listing the identities and signing with one of them. An
+// Ed25519 signature comes back in the SSH wire form, the key type and the
+// 64-byte signature as two SSH strings, as in RFC 8709, Section 6.
+
+const (
+	sshAgentFailure           = 5
+	sshAgentRequestIdentities = 11
+	sshAgentIdentitiesAnswer  = 12
+	sshAgentSignRequest       = 13
+	sshAgentSignResponse      = 14
+
+	// sshAgentMaxMessageSize is the limit of the OpenSSH agent.
+	sshAgentMaxMessageSize = 256 * 1024
+)
+
+// ErrSSHAgentRefused is returned when the SSH agent answers a request with a
+// failure, for example because the key was removed or because the user
+// declined to confirm its use.
+var ErrSSHAgentRefused = errors.New("ed25519: SSH agent refused the request")
+
+var errBadSSHAgentMessage = errors.New("ed25519: malformed SSH agent message")
+
+// SSHAgent is a client of an SSH agent, such as ssh-agent(1), that gives its
+// Ed25519 keys as crypto.Signer values for the functions of this package.
+// It is safe for concurrent use: requests are sent one at a time.
+type SSHAgent struct {
+	mu   sync.Mutex
+	conn io.ReadWriter
+}
+
+// NewSSHAgent returns a client of the agent at the other end of conn,
+// usually a connection to the Unix socket named by $SSH_AUTH_SOCK.
+func NewSSHAgent(conn io.ReadWriter) *SSHAgent {
+	return &SSHAgent{conn: conn}
+}
+
+// SSHAgentSigner is an Ed25519 key held by an SSH agent. Its Sign method asks
+// the agent for a signature.
+type SSHAgentSigner struct {
+	agent     *SSHAgent
+	publicKey PublicKey
+
+	// Comment is the comment of the key in the agent, usually the file
+	// name it was added from.
+	Comment string
+}
+
+// Signers returns the Ed25519 keys of the agent, in the order it lists them.
+// Keys of other types, and certificates, are skipped.
+func (a *SSHAgent) Signers() ([]*SSHAgentSigner, error) {
+	reply, err := a.call([]byte{sshAgentRequestIdentities})
+	if err != nil {
+		return nil, err
+	}
+	if reply[0] != sshAgentIdentitiesAnswer || len(reply) < 5 {
+		return nil, errBadSSHAgentMessage
+	}
+	n := binary.BigEndian.Uint32(reply[1:])
+	rest := reply[5:]
+	var signers []*SSHAgentSigner
+	for i := uint32(0); i < n; i++ {
+		var blob, comment []byte
+		var ok1, ok2 bool
+		blob, rest, ok1 = readSSHString(rest)
+		comment, rest, ok2 = readSSHString(rest)
+		if !ok1 || !ok2 {
+			return nil, errBadSSHAgentMessage
+		}
+		publicKey, err := parseSSHPublicKeyBlob(blob)
+		if err != nil {
+			continue
+		}
+		signers = append(signers, &SSHAgentSigner{agent: a, publicKey: publicKey, Comment: string(comment)})
+	}
+	if len(rest) != 0 {
+		return nil, errBadSSHAgentMessage
+	}
+	return signers, nil
+}
+
+// Public returns the PublicKey of s.
+func (s *SSHAgentSigner) Public() crypto.PublicKey {
+	return s.publicKey
+}
+
+// Sign asks the agent to sign message and returns the plain 64-byte
+// signature, which is checked to be valid. opts must be crypto.Hash(0) or
+// Options for plain Ed25519: agents don't implement Ed25519ph or Ed25519ctx.
+// It returns ErrSSHAgentRefused if the
+// agent doesn't sign, and ErrInvalidSignature if it returns a signature that
+// is not by this key.
+func (s *SSHAgentSigner) Sign(_ io.Reader, message []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if o, ok := opts.(*Options); opts.HashFunc() != 0 || ok && o.Context != "" {
+		return nil, errors.New("ed25519: SSH agents only make plain Ed25519 signatures")
+	}
+	req := []byte{sshAgentSignRequest}
+	req = appendSSHString(req, sshPublicKeyBlob(s.publicKey))
+	req = appendSSHString(req, message)
+	req = binary.BigEndian.AppendUint32(req, 0)
+	reply, err := s.agent.call(req)
+	if err != nil {
+		return nil, err
+	}
+	if reply[0] != sshAgentSignResponse {
+		return nil, errBadSSHAgentMessage
+	}
+	blob, rest, ok := readSSHString(reply[1:])
+	if !ok || len(rest) != 0 {
+		return nil, errBadSSHAgentMessage
+	}
+	keyType, rest, ok1 := readSSHString(blob)
+	sig, rest, ok2 := readSSHString(rest)
+	if !ok1 || !ok2 || len(rest) != 0 || string(keyType) != sshKeyType {
+		return nil, errBadSSHAgentMessage
+	}
+	if len(sig) != SignatureSize {
+		return nil, ErrBadSignatureLength
+	}
+	if err := CheckSignature(s.publicKey, message, sig); err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), sig...), nil
+}
+
+// call sends the request message req and returns the reply, which is at
+// least one byte long. A failure reply gives ErrSSHAgentRefused.
+func (a *SSHAgent) call(req []byte) ([]byte, error) {
+	if len(req) > sshAgentMaxMessageSize {
+		return nil, errors.New("ed25519: message too long for the SSH agent")
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	msg := binary.BigEndian.AppendUint32(make([]byte, 0, 4+len(req)), uint32(len(req)))
+	if _, err := a.conn.Write(append(msg, req...)); err != nil {
+		return nil, err
+	}
+	var header [4]byte
+	if _, err := io.ReadFull(a.conn, header[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(header[:])
+	if n == 0 || n > sshAgentMaxMessageSize {
+		return nil, errBadSSHAgentMessage
+	}
+	reply := make([]byte, n)
+	if _, err := io.ReadFull(a.conn, reply); err != nil {
+		return nil, err
+	}
+	if reply[0] == sshAgentFailure {
+		return nil, ErrSSHAgentRefused
+	}
+	return reply, nil
+}
@@ -0,0 +1,175 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+)
+
+// fakeSSHAgent serves the identity and signing requests of the SSH agent
+// protocol with keys, and an RSA-looking identity that clients must skip. If
+// refuse is set it fails every signing request, and wrongKey makes it sign
+// with another key.
+type fakeSSHAgent struct {
+	keys     []PrivateKey
+	refuse   bool
+	wrongKey PrivateKey
+}
+
+func (a *fakeSSHAgent) serve(conn io.ReadWriteCloser) {
+	defer conn.Close()
+	for {
+		var header [4]byte
+		if _, err := io.ReadFull(conn, header[:]); err != nil {
+			return
+		}
+		req := make([]byte, binary.BigEndian.Uint32(header[:]))
+		if _, err := io.ReadFull(conn, req); err != nil {
+			return
+		}
+		reply := a.handle(req)
+		conn.Write(appendSSHString(nil, reply))
+	}
+}
+
+func (a *fakeSSHAgent) handle(req []byte) []byte {
+	switch req[0] {
+	case sshAgentRequestIdentities:
+		reply := binary.BigEndian.AppendUint32([]byte{sshAgentIdentitiesAnswer}, uint32(len(a.keys)+1))
+		rsa := appendSSHString(appendSSHString(appendSSHString(nil, []byte("ssh-rsa")), []byte{1, 0, 1}), []byte{0xc5})
+		reply = appendSSHString(appendSSHString(reply, rsa), []byte("rsa key"))
+		for i, key := range a.keys {
+			reply = appendSSHString(reply, sshPublicKeyBlob(key.Public().(PublicKey)))
+			reply = appendSSHString(reply, []byte("key"+string(rune('0'+i))))
+		}
+		return reply
+	case sshAgentSignRequest:
+		blob, rest, _ := readSSHString(req[1:])
+		data, _, _ := readSSHString(rest)
+		for _, key := range a.keys {
+			if a.refuse || !bytes.Equal(blob, sshPublicKeyBlob(key.Public().(PublicKey))) {
+				continue
+			}
+			if a.wrongKey != nil {
+				key = a.wrongKey
+			}
+			sig, _ := Sign(key, data)
+			sigBlob := appendSSHString(appendSSHString(nil, []byte(sshKeyType)), sig)
+			return appendSSHString([]byte{sshAgentSignResponse}, sigBlob)
+		}
+	}
+	return []byte{sshAgentFailure}
+}
+
+func newFakeSSHAgent(t *testing.T, a *fakeSSHAgent) *SSHAgent {
+	client, server := net.Pipe()
+	go a.serve(server)
+	t.Cleanup(func() { client.Close() })
+	return NewSSHAgent(client)
+}
+
+func TestSSHAgent(t *testing.T) {
+	_, key0, _ := GenerateKey(rand.Reader)
+	_, key1, _ := GenerateKey(rand.Reader)
+	agent := newFakeSSHAgent(t, &fakeSSHAgent{keys: []PrivateKey{key0, key1}})
+
+	signers, err := agent.Signers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signers) != 2 {
+		t.Fatalf("%d signers, want 2", len(signers))
+	}
+	for i, key := range []PrivateKey{key0, key1} {
+		s := signers[i]
+		if !bytes.Equal(s.Public().(PublicKey), key[32:]) || s.Comment != "key"+string(rune('0'+i)) {
+			t.Errorf("signer %d is %x %q", i, s.Public(), s.Comment)
+		}
+		message := []byte("message")
+		sig, err := s.Sign(rand.Reader, message, crypto.Hash(0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want, _ := Sign(key, message); !bytes.Equal(sig, want) {
+			t.Errorf("signer %d: signature differs from the key's", i)
+		}
+	}
+
+	// The signers work with the higher-level functions.
+	armored, err := SignSSHSig(signers[1], "file", strings.NewReader("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifySSHSig(key1.Public().(PublicKey), "file", strings.NewReader("hello"), armored); err != nil {
+		t.Error(err)
+	}
+
+	for _, opts := range []crypto.SignerOpts{crypto.SHA512, &Options{Context: "ctx"}} {
+		if _, err := signers[0].Sign(rand.Reader, []byte("message"), opts); err == nil {
+			t.Errorf("signing with options %v accepted", opts)
+		}
+	}
+	if _, err := signers[0].Sign(rand.Reader, make([]byte, sshAgentMaxMessageSize), crypto.Hash(0)); err == nil {
+		t.Error("oversized message accepted")
+	}
+}
+
+func TestSSHAgentErrors(t *testing.T) {
+	_, key, _ := GenerateKey(rand.Reader)
+
+	refusing := &fakeSSHAgent{keys: []PrivateKey{key}, refuse: true}
+	signers, err := newFakeSSHAgent(t, refusing).Signers()
+	if err != nil || len(signers) != 1 {
+		t.Fatalf("Signers = %v, %v", signers, err)
+	}
+	if _, err := signers[0].Sign(rand.Reader, []byte("message"), crypto.Hash(0)); err != ErrSSHAgentRefused {
+		t.Errorf("refused request gave %v", err)
+	}
+	if _, err := SignSSHSig(signers[0], "file", strings.NewReader("hello")); err != ErrSSHAgentRefused {
+		t.Errorf("SignSSHSig with a refusing agent gave %v", err)
+	}
+
+	_, other, _ := GenerateKey(rand.Reader)
+	lying := &fakeSSHAgent{keys: []PrivateKey{key}, wrongKey: other}
+	signers, _ = newFakeSSHAgent(t, lying).Signers()
+	if _, err := signers[0].Sign(rand.Reader, []byte("message"), crypto.Hash(0)); err != ErrInvalidSignature {
+		t.Errorf("signature by another key gave %v", err)
+	}
+
+	// A key the agent doesn't have.
+	_, absent, _ := GenerateKey(rand.Reader)
+	agent := newFakeSSHAgent(t, &fakeSSHAgent{keys: []PrivateKey{key}})
+	s := &SSHAgentSigner{agent: agent, publicKey: absent.Public().(PublicKey)}
+	if _, err := s.Sign(rand.Reader, []byte("message"), crypto.Hash(0)); err != ErrSSHAgentRefused {
+		t.Errorf("absent key gave %v", err)
+	}
+
+	// Malformed replies, and an agent that went away.
+	for _, reply := range [][]byte{
+		{0, 0, 0, 0},
+		{0, 0, 0, 1, sshAgentSignResponse},
+		{0, 0, 0, 5, sshAgentIdentitiesAnswer, 0, 0, 0, 1},
+		{0xff, 0, 0, 0},
+		{0, 0, 0, 2},
+	} {
+		client, server := net.Pipe()
+		go func() {
+			io.ReadFull(server, make([]byte, 5))
+			server.Write(reply)
+			server.Close()
+		}()
+		if _, err := NewSSHAgent(client).Signers(); err == nil || err == ErrSSHAgentRefused {
+			t.Errorf("reply %x gave %v", reply, err)
+		}
+		client.Close()
+	}
+}
@@ -0,0 +1,373 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"crypto"
+	cryptorand "crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SSH certificates are described in the PROTOCOL.certkeys file of the OpenSSH
+// sources. Only certificates for Ed25519 keys signed by Ed25519 CAs are
+// supported.
+
+const sshCertType = "ssh-ed25519-cert-v01@openssh.com"
+
+// Certificate types, for SSHCertificate.CertType.
+const (
+	SSHUserCert = 1
+	SSHHostCert = 2
+)
+
+// SSHCertTimeInfinity is the ValidBefore of a certificate that never expires.
+const SSHCertTimeInfinity = 1<<64 - 1
+
+var (
+	// ErrBadSSHCertificate is returned by ParseSSHCertificate when a line
+	// is not an Ed25519 SSH certificate signed by an Ed25519 CA.
+	ErrBadSSHCertificate = errors.New("ed25519: malformed SSH certificate")
+
+	// ErrSSHCertificateCA is returned by VerifySSHCertificate when the
+	// certificate is signed by a different CA.
+	ErrSSHCertificateCA = errors.New("ed25519: SSH certificate is signed by a different CA")
+
+	// ErrSSHCertificateType is returned by VerifySSHCertificate when a user
+	// certificate is presented as a host certificate or vice versa.
+	ErrSSHCertificateType = errors.New("ed25519: wrong SSH certificate type")
+
+	// ErrSSHCertificateTime is returned by VerifySSHCertificate when the
+	// time is outside the validity period of the certificate.
+	ErrSSHCertificateTime = errors.New("ed25519: SSH certificate is not valid at this time")
+
+	// ErrSSHCertificatePrincipal is returned by VerifySSHCertificate when
+	// the principal is not listed in the certificate.
+	ErrSSHCertificatePrincipal = errors.New("ed25519: principal is not listed in SSH certificate")
+)
+
+// SSHCertificate is an OpenSSH certificate for an Ed25519 key.
+type SSHCertificate struct {
+	// Nonce is random data that makes the signed data unpredictable. If
+	// it is empty, SignSSHCertificate fills in 32 random bytes.
+	Nonce []byte
+
+	// Key is the certified public key.
+	Key PublicKey
+
+	Serial   uint64
+	CertType uint32 // SSHUserCert or SSHHostCert
+	KeyID    string
+
+	// ValidPrincipals are the user names, for a user certificate, or the
+	// host names, for a host certificate, that the certificate is valid
+	// for.
+	ValidPrincipals []string
+
+	// ValidAfter and ValidBefore are the validity period of the
+	// certificate, in seconds since the Unix epoch. ValidBefore is
+	// exclusive.
+	ValidAfter  uint64
+	ValidBefore uint64
+
+	// CriticalOptions and Extensions map option names to their values,
+	// which are empty for flags such as "permit-pty". They are encoded in
+	// lexical order of their names.
+	CriticalOptions map[string]string
+	Extensions      map[string]string
+
+	Reserved []byte
+
+	// SignatureKey and Signature are set by SignSSHCertificate.
+	SignatureKey PublicKey
+	Signature    []byte
+}
+
+// SignSSHCertificate signs cert with the CA key ca, setting its
+// SignatureKey, Signature and, if it is empty, Nonce.
+func SignSSHCertificate(cert *SSHCertificate, ca crypto.Signer) error {
+	if len(cert.Key) != PublicKeySize {
+		return ErrBadPublicKeyLength
+	}
+	caPublicKey, err := SignerPublicKey(ca)
+	if err != nil {
+		return err
+	}
+	if len(cert.Nonce) == 0 {
+		nonce := make([]byte, 32)
+		if _, err := io.ReadFull(cryptorand.Reader, nonce); err != nil {
+			return err
+		}
+		cert.Nonce = nonce
+	}
+
+	cert.SignatureKey = caPublicKey
+	sig, err := signWith(ca, cert.appendSigned(nil))
+	if err != nil {
+		cert.SignatureKey = nil
+		return err
+	}
+	cert.Signature = sig
+	return nil
+}
+
+// VerifySSHCertificate checks that cert is signed by ca, is of type certType,
+// is valid at time now and, unless principal is empty, lists principal among
+// its ValidPrincipals. Note that a certificate without principals matches no
+// principal, unlike in some OpenSSH configurations.
+//
+// The critical options are not interpreted. Callers must reject
+// certificates with critical options that they don't enforce.
+func VerifySSHCertificate(cert *SSHCertificate, ca PublicKey, certType uint32, principal string, now time.Time) error {
+	if len(ca) != PublicKeySize {
+		return ErrBadPublicKeyLength
+	}
+	if len(cert.Key) != PublicKeySize {
+		return ErrBadPublicKeyLength
+	}
+	if !ca.Equal(cert.SignatureKey) {
+		return ErrSSHCertificateCA
+	}
+	if err := CheckSignature(ca, cert.appendSigned(nil), cert.Signature); err != nil {
+		return err
+	}
+	if cert.CertType != certType {
+		return ErrSSHCertificateType
+	}
+
+	var t uint64
+	if unix := now.Unix(); unix > 0 {
+		t = uint64(unix)
+	}
+	if t < cert.ValidAfter || t >= cert.ValidBefore {
+		return ErrSSHCertificateTime
+	}
+
+	if principal != "" {
+		found := false
+		for _, p := range cert.ValidPrincipals {
+			if p == principal {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return ErrSSHCertificatePrincipal
+		}
+	}
+	return nil
+}
+
+// MarshalSSHCertificate returns cert as a line of the form written by
+// "ssh-keygen -s" to a -cert.pub file, "ssh-ed25519-cert-v01@openssh.com
+// <base64> comment", without a trailing newline. The comment is omitted if
+// it is empty. cert must have been signed.
+func MarshalSSHCertificate(cert *SSHCertificate, comment string) (string, error) {
+	if len(cert.Key) != PublicKeySize || len(cert.SignatureKey) != PublicKeySize {
+		return "", ErrBadPublicKeyLength
+	}
+	if len(cert.Signature) != SignatureSize {
+		return "", ErrBadSignatureLength
+	}
+	b := cert.appendSigned(nil)
+	b = appendSSHString(b, appendSSHString(appendSSHString(nil, []byte(sshKeyType)), cert.Signature))
+
+	line := sshCertType + " " + base64.StdEncoding.EncodeToString(b)
+	if comment != "" {
+		line += " " + comment
+	}
+	return line, nil
+}
+
+// ParseSSHCertificate parses a certificate in the format written by
+// MarshalSSHCertificate or "ssh-keygen -s". It checks only the encoding; use
+// VerifySSHCertificate to check the signature and the other fields.
+func ParseSSHCertificate(line string) (cert *SSHCertificate, comment string, err error) {
+	keyType, rest := nextSSHField(strings.TrimSpace(line))
+	if keyType != sshCertType {
+		return nil, "", ErrBadSSHCertificate
+	}
+	encoded, rest := nextSSHField(rest)
+	b, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, "", ErrBadSSHCertificate
+	}
+	cert, err = parseSSHCertificate(b)
+	if err != nil {
+		return nil, "", err
+	}
+	return cert, strings.TrimSpace(rest), nil
+}
+
+// parseSSHCertificate parses the wire encoding of a certificate.
+func parseSSHCertificate(b []byte) (*SSHCertificate, error) {
+	var cert SSHCertificate
+	r := sshReader{b: b}
+	keyType := r.string()
+	cert.Nonce = r.bytes()
+	cert.Key = PublicKey(r.bytes())
+	cert.Serial = r.uint64()
+	cert.CertType = r.uint32()
+	cert.KeyID = string(r.string())
+	principals := r.string()
+	cert.ValidAfter = r.uint64()
+	cert.ValidBefore = r.uint64()
+	criticalOptions := r.string()
+	extensions := r.string()
+	cert.Reserved = r.bytes()
+	signatureKey := r.string()
+	signature := r.string()
+	if r.bad || len(r.b) != 0 || string(keyType) != sshCertType || len(cert.Key) != PublicKeySize {
+		return nil, ErrBadSSHCertificate
+	}
+
+	pr := sshReader{b: principals}
+	for !pr.bad && len(pr.b) > 0 {
+		cert.ValidPrincipals = append(cert.ValidPrincipals, string(pr.string()))
+	}
+	var ok1, ok2 bool
+	cert.CriticalOptions, ok1 = parseSSHCertOptions(criticalOptions)
+	cert.Extensions, ok2 = parseSSHCertOptions(extensions)
+	if pr.bad || !ok1 || !ok2 {
+		return nil, ErrBadSSHCertificate
+	}
+
+	var err error
+	if cert.SignatureKey, err = parseSSHPublicKeyBlob(signatureKey); err != nil {
+		return nil, ErrBadSSHCertificate
+	}
+	sr := sshReader{b: signature}
+	sigType := sr.string()
+	cert.Signature = sr.bytes()
+	if sr.bad || len(sr.b) != 0 || string(sigType) != sshKeyType || len(cert.Signature) != SignatureSize {
+		return nil, ErrBadSSHCertificate
+	}
+	return &cert, nil
+}
+
+// appendSigned appends the part of the certificate that is signed, which is
+// all of it but the signature, to b.
+func (cert *SSHCertificate) appendSigned(b []byte) []byte {
+	var principals []byte
+	for _, p := range cert.ValidPrincipals {
+		principals = appendSSHString(principals, []byte(p))
+	}
+
+	b = appendSSHString(b, []byte(sshCertType))
+	b = appendSSHString(b, cert.Nonce)
+	b = appendSSHString(b, cert.Key)
+	b = binary.BigEndian.AppendUint64(b, cert.Serial)
+	b = binary.BigEndian.AppendUint32(b, cert.CertType)
+	b = appendSSHString(b, []byte(cert.KeyID))
+	b = appendSSHString(b, principals)
+	b = binary.BigEndian.AppendUint64(b, cert.ValidAfter)
+	b = binary.BigEndian.AppendUint64(b, cert.ValidBefore)
+	b = appendSSHString(b, marshalSSHCertOptions(cert.CriticalOptions))
+	b = appendSSHString(b, marshalSSHCertOptions(cert.Extensions))
+	b = appendSSHString(b, cert.Reserved)
+	b = appendSSHString(b, sshPublicKeyBlob(cert.SignatureKey))
+	return b
+}
+
+// marshalSSHCertOptions encodes options in lexical order of their names. A
+// value is itself wrapped in an SSH string, unless it is empty.
+func marshalSSHCertOptions(options map[string]string) []byte {
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b []byte
+	for _, name := range names {
+		b = appendSSHString(b, []byte(name))
+		if value := options[name]; value == "" {
+			b = appendSSHString(b, nil)
+		} else {
+			b = appendSSHString(b, appendSSHString(nil, []byte(value)))
+		}
+	}
+	return b
+}
+
+// parseSSHCertOptions parses the output of marshalSSHCertOptions. It reports
+// false if the names are not unique and in lexical order.
+func parseSSHCertOptions(b []byte) (map[string]string, bool) {
+	if len(b) == 0 {
+		return nil, true
+	}
+	options := make(map[string]string)
+	r := sshReader{b: b}
+	var last string
+	for !r.bad && len(r.b) > 0 {
+		name := string(r.string())
+		data := r.string()
+		if len(options) > 0 && name <= last {
+			return nil, false
+		}
+		last = name
+		if len(data) == 0 {
+			options[name] = ""
+			continue
+		}
+		value, rest, ok := readSSHString(data)
+		if !ok || len(rest) != 0 {
+			return nil, false
+		}
+		options[name] = string(value)
+	}
+	return options, !r.bad
+}
+
+// sshReader reads SSH wire encoded fields from b. Once a field doesn't fit,
+// bad is set and every read returns a zero value.
+type sshReader struct {
+	b   []byte
+	bad bool
+}
+
+// string reads an SSH string, which aliases r.b.
+func (r *sshReader) string() []byte {
+	s, rest, ok := readSSHString(r.b)
+	if r.bad || !ok {
+		r.bad = true
+		return nil
+	}
+	r.b = rest
+	return s
+}
+
+// bytes reads an SSH string into a new slice, or nil if it is empty.
+func (r *sshReader) bytes() []byte {
+	if s := r.string(); len(s) > 0 {
+		return append([]byte(nil), s...)
+	}
+	return nil
+}
+
+func (r *sshReader) uint32() uint32 {
+	if r.bad || len(r.b) < 4 {
+		r.bad = true
+		return 0
+	}
+	v := binary.BigEndian.Uint32(r.b)
+	r.b = r.b[4:]
+	return v
+}
+
+func (r *sshReader) uint64() uint64 {
+	if r.bad || len(r.b) < 8 {
+		r.bad = true
+		return 0
+	}
+	v := binary.BigEndian.Uint64(r.b)
+	r.b = r.b[8:]
+	return v
+}
@@ -0,0 +1,259 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readSSHCertificate(t *testing.T, path string) *SSHCertificate {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, comment, err := ParseSSHCertificate(string(data))
+	if err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+	if comment != "alice@example.com" {
+		t.Errorf("%s: comment %q", path, comment)
+	}
+	return cert
+}
+
+func readSSHCA(t *testing.T) PrivateKey {
+	data, err := os.ReadFile("testdata/ssh_ca")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := ParseOpenSSHPrivateKey(data, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ca
+}
+
+// goCertificate is the certificate in testdata/ssh_ed25519-go-cert.pub,
+// before signing.
+func goCertificate(t *testing.T) *SSHCertificate {
+	data, err := os.ReadFile("testdata/ssh_ed25519.pub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, _, err := ParseOpenSSHPublicKey(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &SSHCertificate{
+		Nonce:           bytes.Repeat([]byte{0x5a}, 32),
+		Key:             public,
+		Serial:          1001,
+		CertType:        SSHUserCert,
+		KeyID:           "issued-by-go",
+		ValidPrincipals: []string{"alice"},
+		ValidAfter:      1767225600, // 2026-01-01T00:00:00Z
+		ValidBefore:     1798761600, // 2027-01-01T00:00:00Z
+		CriticalOptions: map[string]string{"force-command": "/usr/bin/true"},
+		Extensions:      map[string]string{"permit-pty": "", "permit-X11-forwarding": ""},
+	}
+}
+
+func TestSSHCertificateSSHKeygen(t *testing.T) {
+	ca := PublicKey(readSSHCA(t)[32:])
+	inValidity := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	user := readSSHCertificate(t, "testdata/ssh_ed25519-user-cert.pub")
+	if err := VerifySSHCertificate(user, ca, SSHUserCert, "admin", inValidity); err != nil {
+		t.Fatal(err)
+	}
+	if user.Serial != 42 || user.KeyID != "alice-cert" ||
+		!reflect.DeepEqual(user.ValidPrincipals, []string{"alice", "admin"}) {
+		t.Errorf("user certificate: %+v", user)
+	}
+	wantOptions := map[string]string{"force-command": "/bin/date", "source-address": "10.0.0.0/8"}
+	if !reflect.DeepEqual(user.CriticalOptions, wantOptions) {
+		t.Errorf("critical options %v, want %v", user.CriticalOptions, wantOptions)
+	}
+	if len(user.Extensions) != 5 || user.Extensions["permit-pty"] != "" {
+		t.Errorf("extensions %v", user.Extensions)
+	}
+
+	host := readSSHCertificate(t, "testdata/ssh_ed25519-host-cert.pub")
+	if err := VerifySSHCertificate(host, ca, SSHHostCert, "host.example.com", time.Unix(0, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if host.ValidAfter != 0 || host.ValidBefore != SSHCertTimeInfinity {
+		t.Errorf("host certificate valid from %d to %d", host.ValidAfter, host.ValidBefore)
+	}
+
+	// Re-encoding must give back the ssh-keygen output.
+	for _, path := range []string{"testdata/ssh_ed25519-user-cert.pub", "testdata/ssh_ed25519-host-cert.pub"} {
+		data, _ := os.ReadFile(path)
+		cert, comment, _ := ParseSSHCertificate(string(data))
+		line, err := MarshalSSHCertificate(cert, comment)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if line+"\n" != string(data) {
+			t.Errorf("%s: re-encoded as %q", path, line)
+		}
+	}
+}
+
+func TestSSHCertificateGo(t *testing.T) {
+	ca := readSSHCA(t)
+	cert := goCertificate(t)
+	if err := SignSSHCertificate(cert, ca); err != nil {
+		t.Fatal(err)
+	}
+	line, err := MarshalSSHCertificate(cert, "alice@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile("testdata/ssh_ed25519-go-cert.pub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line+"\n" != string(want) {
+		t.Errorf("got\n%s\nwant\n%s", line, want)
+	}
+
+	// testdata/ssh_ed25519-go-cert.txt is the output of ssh-keygen -L for
+	// the certificate.
+	listing, err := os.ReadFile("testdata/ssh_ed25519-go-cert.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Type: " + sshCertType + " user certificate",
+		fmt.Sprintf("Key ID: %q", cert.KeyID),
+		fmt.Sprintf("Serial: %d", cert.Serial),
+		"Valid: from 2026-01-01T00:00:00 to 2027-01-01T00:00:00",
+		"force-command /usr/bin/true",
+		"permit-X11-forwarding",
+	} {
+		if !strings.Contains(string(listing), want) {
+			t.Errorf("ssh-keygen -L output lacks %q", want)
+		}
+	}
+}
+
+func TestSSHCertificateVerify(t *testing.T) {
+	_, ca, _ := GenerateKey(rand.Reader)
+	otherCA, _, _ := GenerateKey(rand.Reader)
+	cert := goCertificate(t)
+	cert.Nonce = nil
+	if err := SignSSHCertificate(cert, ca); err != nil {
+		t.Fatal(err)
+	}
+	if len(cert.Nonce) != 32 {
+		t.Errorf("nonce of %d bytes", len(cert.Nonce))
+	}
+
+	caPublic := PublicKey(ca[32:])
+	start := time.Unix(int64(cert.ValidAfter), 0)
+	end := time.Unix(int64(cert.ValidBefore), 0)
+	for _, tc := range []struct {
+		name      string
+		ca        PublicKey
+		certType  uint32
+		principal string
+		now       time.Time
+		want      error
+	}{
+		{"valid", caPublic, SSHUserCert, "alice", start, nil},
+		{"any principal", caPublic, SSHUserCert, "", end.Add(-time.Second), nil},
+		{"other CA", otherCA, SSHUserCert, "alice", start, ErrSSHCertificateCA},
+		{"host", caPublic, SSHHostCert, "alice", start, ErrSSHCertificateType},
+		{"early", caPublic, SSHUserCert, "alice", start.Add(-time.Second), ErrSSHCertificateTime},
+		{"expired", caPublic, SSHUserCert, "alice", end, ErrSSHCertificateTime},
+		{"before 1970", caPublic, SSHUserCert, "alice", time.Unix(-1, 0), ErrSSHCertificateTime},
+		{"principal", caPublic, SSHUserCert, "bob", start, ErrSSHCertificatePrincipal},
+	} {
+		if err := VerifySSHCertificate(cert, tc.ca, tc.certType, tc.principal, tc.now); err != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.name, err, tc.want)
+		}
+	}
+
+	// Changing any signed field invalidates the signature.
+	for name, mutate := range map[string]func(c *SSHCertificate){
+		"serial":     func(c *SSHCertificate) { c.Serial++ },
+		"principals": func(c *SSHCertificate) { c.ValidPrincipals = append(c.ValidPrincipals, "root") },
+		"expiry":     func(c *SSHCertificate) { c.ValidBefore = SSHCertTimeInfinity },
+		"options":    func(c *SSHCertificate) { c.CriticalOptions = nil },
+		"extensions": func(c *SSHCertificate) { c.Extensions["permit-port-forwarding"] = "" },
+		"nonce":      func(c *SSHCertificate) { c.Nonce = c.Nonce[1:] },
+	} {
+		c := *cert
+		c.Extensions = map[string]string{}
+		for k, v := range cert.Extensions {
+			c.Extensions[k] = v
+		}
+		mutate(&c)
+		if err := VerifySSHCertificate(&c, caPublic, SSHUserCert, "", start); err != ErrInvalidSignature {
+			t.Errorf("%s: got %v", name, err)
+		}
+	}
+}
+
+func TestSSHCertificateErrors(t *testing.T) {
+	data, err := os.ReadFile("testdata/ssh_ed25519-user-cert.pub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := strings.Fields(string(data))
+	blob, _ := base64.StdEncoding.DecodeString(fields[1])
+	encode := func(b []byte) string {
+		return sshCertType + " " + base64.StdEncoding.EncodeToString(b)
+	}
+	cert, _, _ := ParseSSHCertificate(string(data))
+
+	unsorted := *cert
+	unsorted.CriticalOptions = nil
+	unsortedBlob := unsorted.appendSigned(nil)
+	i := bytes.Index(unsortedBlob, []byte("\x00\x00\x00\x00\x00\x00\x00\x82"))
+	if i < 0 {
+		t.Fatal("extensions not found")
+	}
+	// Swap the first two extensions, which are flags.
+	ext := unsortedBlob[i+8 : i+8+0x82]
+	first := 4 + 21 + 4
+	swapped := append(append([]byte{}, ext[first:first+4+23+4]...), ext[:first]...)
+	swapped = append(swapped, ext[first+4+23+4:]...)
+	copy(ext, swapped)
+	unsortedBlob = appendSSHString(unsortedBlob, appendSSHString(appendSSHString(nil, []byte(sshKeyType)), cert.Signature))
+
+	for name, line := range map[string]string{
+		"empty":      "",
+		"key type":   "ssh-ed25519 " + fields[1],
+		"base64":     sshCertType + " !!!!",
+		"truncated":  encode(blob[:len(blob)-1]),
+		"trailing":   encode(append(append([]byte{}, blob...), 0)),
+		"inner type": encode(bytes.Replace(blob, []byte(sshCertType), []byte("ssh-rsa-cert-v01@openssh.com...."), 1)),
+		"CA type":    encode(bytes.Replace(blob, []byte("\x00\x00\x00\x33\x00\x00\x00\x0bssh-ed25519"), []byte("\x00\x00\x00\x33\x00\x00\x00\x0bssh-ed25518"), 1)),
+		"short":      encode(blob[:100]),
+		"unsorted":   encode(unsortedBlob),
+	} {
+		if _, _, err := ParseSSHCertificate(line); err != ErrBadSSHCertificate {
+			t.Errorf("%s: got %v", name, err)
+		}
+	}
+
+	if _, err := MarshalSSHCertificate(&SSHCertificate{}, ""); err == nil {
+		t.Error("unsigned certificate marshaled")
+	}
+	_, ca, _ := GenerateKey(rand.Reader)
+	if err := SignSSHCertificate(&SSHCertificate{Key: cert.Key[:31]}, ca); err != ErrBadPublicKeyLength {
+		t.Errorf("short key gave %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+)
+
+// An SSHFP record, from RFC 4255, publishes the fingerprint of an SSH host
+// key in DNS. Ed25519 is algorithm 4, from RFC 7479, and SHA-256 is
+// fingerprint type 2, from RFC 6594; the hash is over the SSH wire encoding
+// of the key, as for SSHFingerprintSHA256.
+
+const (
+	sshfpAlgorithmEd25519 = "4"
+	sshfpTypeSHA256       = "2"
+)
+
+// SSHFPRecord returns the presentation form of the RDATA of the SHA-256
+// SSHFP record of publicKey, as in "4 2 fcdfc244…3d00af", which is what
+// "ssh-keygen -r" prints after the owner name, class and type.
+func SSHFPRecord(publicKey PublicKey) (string, error) {
+	if len(publicKey) != PublicKeySize {
+		return "", ErrBadPublicKeyLength
+	}
+	sum := sha256.Sum256(sshPublicKeyBlob(publicKey))
+	return sshfpAlgorithmEd25519 + " " + sshfpTypeSHA256 + " " + hex.EncodeToString(sum[:]), nil
+}
+
+// VerifySSHFP reports whether record is the SHA-256 SSHFP record of
+// publicKey. record is the presentation form of the RDATA, optionally
+// preceded by the owner name, TTL, class and type as in a zone file line.
+// Fields may be separated by any white space, the fingerprint may be split
+// into several fields and be in upper case, and the type may be in any case.
+// SHA-1 records, of fingerprint type 1, don't match.
+func VerifySSHFP(publicKey PublicKey, record string) bool {
+	fields := strings.Fields(record)
+	for i, f := range fields {
+		if strings.EqualFold(f, "SSHFP") {
+			fields = fields[i+1:]
+			break
+		}
+	}
+	if len(fields) < 3 || fields[0] != sshfpAlgorithmEd25519 || fields[1] != sshfpTypeSHA256 {
+		return false
+	}
+	want, err := SSHFPRecord(publicKey)
+	if err != nil {
+		return false
+	}
+	got := strings.ToLower(strings.Join(fields[2:], ""))
+	return subtle.ConstantTimeCompare([]byte(got), []byte(want[len("4 2 "):])) == 1
+}
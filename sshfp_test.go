@@ -0,0 +1,70 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"os"
+	"testing"
+)
+
+// TestSSHFPKeygen checks the SHA-256 records printed by
+// "ssh-keygen -r host.example -f" for the fixture keys.
+func TestSSHFPKeygen(t *testing.T) {
+	for _, tc := range []struct{ file, want string }{
+		{"testdata/ssh_ed25519.pub", "4 2 fcdfc24473e7263f9fc42d8340cadcf37231dcb4568a8e8899166c23273d00af"},
+		{"testdata/ssh_ca.pub", "4 2 69993880cdaee7a020cdfe6f97b5ade2d3a4ce53796c489ad4761b067db75ef2"},
+	} {
+		line, err := os.ReadFile(tc.file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		publicKey, _, err := ParseOpenSSHPublicKey(string(line))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := SSHFPRecord(publicKey)
+		if err != nil || got != tc.want {
+			t.Errorf("%s: got %q, %v, want %q", tc.file, got, err, tc.want)
+		}
+		if !VerifySSHFP(publicKey, "host.example IN SSHFP "+tc.want) {
+			t.Errorf("%s: ssh-keygen line doesn't verify", tc.file)
+		}
+	}
+}
+
+func TestVerifySSHFP(t *testing.T) {
+	publicKey := PublicKey(decodeHex(t, sshKeygenPublicKey))
+	for _, record := range []string{
+		"4 2 fcdfc24473e7263f9fc42d8340cadcf37231dcb4568a8e8899166c23273d00af",
+		"4 2 FCDFC24473E7263F9FC42D8340CADCF37231DCB4568A8E8899166C23273D00AF",
+		"  4\t2   fcdfc24473e7263f9fc42d8340cadcf3 7231dcb4568a8e8899166c23273d00af\n",
+		"host.example. 3600 in sshfp 4 2 fcdfc24473e7263f9fc42d8340cadcf37231dcb4568a8e8899166c23273d00af",
+	} {
+		if !VerifySSHFP(publicKey, record) {
+			t.Errorf("%q didn't verify", record)
+		}
+	}
+	for _, record := range []string{
+		"",
+		"4 2",
+		"4 1 d21176304889b5d9df12cca2f471ee1550539c38",
+		"1 2 fcdfc24473e7263f9fc42d8340cadcf37231dcb4568a8e8899166c23273d00af",
+		"4 02 fcdfc24473e7263f9fc42d8340cadcf37231dcb4568a8e8899166c23273d00af",
+		"4 2 fcdfc24473e7263f9fc42d8340cadcf37231dcb4568a8e8899166c23273d00",
+		"4 2 fcdfc24473e7263f9fc42d8340cadcf37231dcb4568a8e8899166c23273d00ae",
+		"4 2 fcdfc24473e7263f9fc42d8340cadcf37231dcb4568a8e8899166c23273d00af00",
+	} {
+		if VerifySSHFP(publicKey, record) {
+			t.Errorf("%q verified", record)
+		}
+	}
+
+	if _, err := SSHFPRecord(publicKey[:31]); err != ErrBadPublicKeyLength {
+		t.Errorf("short key gave %v", err)
+	}
+	if VerifySSHFP(publicKey[:31], "4 2 fcdfc24473e7263f9fc42d8340cadcf37231dcb4568a8e8899166c23273d00af") {
+		t.Error("short key verified")
+	}
+}
@@ -0,0 +1,235 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	cryptorand "crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"io"
+
+	"github.com/agl/ed25519/internal/bcryptpbkdf"
+)
+
+// OpenSSH private keys use the openssh-key-v1 format described in the
+// PROTOCOL.key file of the OpenSSH sources, in an "OPENSSH PRIVATE KEY" PEM
+// block. Encrypted keys derive the AES-256-CTR key and IV from the passphrase
+// with bcrypt_pbkdf, as ssh-keygen does by default.
+
+const sshPrivateKeyMagic = "openssh-key-v1\x00"
+
+const (
+	// DefaultOpenSSHRounds is the bcrypt_pbkdf rounds count used by
+	// MarshalOpenSSHPrivateKey if none is given. It matches ssh-keygen.
+	DefaultOpenSSHRounds = 16
+
+	// maxOpenSSHRounds bounds the work that ParseOpenSSHPrivateKey does for
+	// an untrusted key. Each round takes a few tens of milliseconds.
+	maxOpenSSHRounds = 10000
+)
+
+// ErrPassphraseRequired is returned by ParseOpenSSHPrivateKey,
+// ParseMinisignSecretKey, ParseSignifyPrivateKey and ParsePPK when the key is
+// encrypted and the passphrase is empty.
+var ErrPassphraseRequired = errors.New("ed25519: private key is encrypted")
+
+var errBadOpenSSHPrivateKey = errors.New("ed25519: malformed OpenSSH private key")
+
+// MarshalOpenSSHPrivateKey returns privateKey as an "OPENSSH PRIVATE KEY" PEM
+// block in the openssh-key-v1 format written by ssh-keygen, with the given
+// comment. If passphrase is not empty the key is encrypted with AES-256-CTR
+// under a key derived from it by bcrypt_pbkdf with rounds rounds and a random
+// salt; rounds may be zero for DefaultOpenSSHRounds. If passphrase is empty
+// the key is stored unencrypted and rounds is ignored.
+func MarshalOpenSSHPrivateKey(privateKey PrivateKey, comment string, passphrase []byte, rounds int) ([]byte, error) {
+	if len(privateKey) != PrivateKeySize {
+		return nil, ErrBadPrivateKeyLength
+	}
+	if rounds == 0 {
+		rounds = DefaultOpenSSHRounds
+	}
+	if rounds < 0 || rounds > maxOpenSSHRounds {
+		return nil, errors.New("ed25519: bad bcrypt_pbkdf rounds count")
+	}
+	publicKey := PublicKey(privateKey[32:])
+
+	var check [4]byte
+	if _, err := io.ReadFull(cryptorand.Reader, check[:]); err != nil {
+		return nil, err
+	}
+	var private []byte
+	defer func() { wipeBytes(private) }()
+	private = append(private, check[:]...)
+	private = append(private, check[:]...)
+	private = appendSSHString(private, []byte(sshKeyType))
+	private = appendSSHString(private, publicKey)
+	private = appendSSHString(private, privateKey)
+	private = appendSSHString(private, []byte(comment))
+
+	cipherName, kdfName, kdfOptions, blockSize := "none", "none", []byte(nil), 8
+	var stream cipher.Stream
+	if len(passphrase) > 0 {
+		salt := make([]byte, 16)
+		if _, err := io.ReadFull(cryptorand.Reader, salt); err != nil {
+			return nil, err
+		}
+		var err error
+		stream, err = openSSHStream(passphrase, salt, rounds)
+		if err != nil {
+			return nil, err
+		}
+		cipherName, kdfName, blockSize = "aes256-ctr", "bcrypt", aes.BlockSize
+		kdfOptions = appendSSHString(nil, salt)
+		kdfOptions = binary.BigEndian.AppendUint32(kdfOptions, uint32(rounds))
+	}
+	for i := 1; len(private)%blockSize != 0; i++ {
+		private = append(private, byte(i))
+	}
+	if stream != nil {
+		stream.XORKeyStream(private, private)
+	}
+
+	b := []byte(sshPrivateKeyMagic)
+	b = appendSSHString(b, []byte(cipherName))
+	b = appendSSHString(b, []byte(kdfName))
+	b = appendSSHString(b, kdfOptions)
+	b = binary.BigEndian.AppendUint32(b, 1)
+	b = appendSSHString(b, sshPublicKeyBlob(publicKey))
+	b = appendSSHString(b, private)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "OPENSSH PRIVATE KEY", Bytes: b}), nil
+}
+
+// ParseOpenSSHPrivateKey parses the first "OPENSSH PRIVATE KEY" PEM block in
+// data, as written by ssh-keygen or MarshalOpenSSHPrivateKey, and returns the
+// Ed25519 private key it holds. Encrypted keys must use bcrypt_pbkdf and
+// AES-256-CTR, which is what ssh-keygen writes by default, and at most 10,000
+// rounds.
+//
+// It returns ErrPassphraseRequired if the key is encrypted and passphrase is
+// empty, ErrIncorrectPassphrase if the key fails to decrypt, and
+// ErrKeyMismatch if the public key stored with the private key or inside it
+// isn't the one derived from the seed. The passphrase is ignored if the key is
+// not encrypted.
+func ParseOpenSSHPrivateKey(data []byte, passphrase []byte) (PrivateKey, error) {
+	b, err := findPEMBlock(data, "OPENSSH PRIVATE KEY")
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(b, []byte(sshPrivateKeyMagic)) {
+		return nil, errBadOpenSSHPrivateKey
+	}
+	b = b[len(sshPrivateKeyMagic):]
+
+	cipherName, b, ok1 := readSSHString(b)
+	kdfName, b, ok2 := readSSHString(b)
+	kdfOptions, b, ok3 := readSSHString(b)
+	if !ok1 || !ok2 || !ok3 || len(b) < 4 {
+		return nil, errBadOpenSSHPrivateKey
+	}
+	if n := binary.BigEndian.Uint32(b); n != 1 {
+		return nil, errors.New("ed25519: OpenSSH private key file holds more than one key")
+	}
+	publicBlob, b, ok1 := readSSHString(b[4:])
+	encrypted, b, ok2 := readSSHString(b)
+	if !ok1 || !ok2 || len(b) != 0 {
+		return nil, errBadOpenSSHPrivateKey
+	}
+	publicKey, err := parseSSHPublicKeyBlob(publicBlob)
+	if err != nil {
+		return nil, errors.New("ed25519: OpenSSH private key is not an Ed25519 key")
+	}
+
+	blockSize := 8
+	private := append([]byte(nil), encrypted...)
+	defer wipeBytes(private)
+	switch {
+	case string(cipherName) == "none" && string(kdfName) == "none":
+		if len(kdfOptions) != 0 {
+			return nil, errBadOpenSSHPrivateKey
+		}
+	case string(cipherName) == "aes256-ctr" && string(kdfName) == "bcrypt":
+		salt, rest, ok := readSSHString(kdfOptions)
+		if !ok || len(rest) != 4 {
+			return nil, errBadOpenSSHPrivateKey
+		}
+		rounds := binary.BigEndian.Uint32(rest)
+		if rounds == 0 || rounds > maxOpenSSHRounds {
+			return nil, errors.New("ed25519: bad bcrypt_pbkdf rounds count")
+		}
+		if len(passphrase) == 0 {
+			return nil, ErrPassphraseRequired
+		}
+		stream, err := openSSHStream(passphrase, salt, int(rounds))
+		if err != nil {
+			return nil, errBadOpenSSHPrivateKey
+		}
+		stream.XORKeyStream(private, private)
+		blockSize = aes.BlockSize
+	case string(kdfName) != "none" && string(kdfName) != "bcrypt":
+		return nil, errors.New("ed25519: unsupported OpenSSH key derivation function: " + string(kdfName))
+	default:
+		return nil, errors.New("ed25519: unsupported OpenSSH cipher: " + string(cipherName))
+	}
+	if len(private) < 8 || len(private)%blockSize != 0 {
+		return nil, errBadOpenSSHPrivateKey
+	}
+
+	// The two check values match only if the key was decrypted correctly.
+	if subtle.ConstantTimeCompare(private[0:4], private[4:8]) != 1 {
+		if blockSize == 8 {
+			return nil, errBadOpenSSHPrivateKey
+		}
+		return nil, ErrIncorrectPassphrase
+	}
+
+	keyType, rest, ok1 := readSSHString(private[8:])
+	innerPublic, rest, ok2 := readSSHString(rest)
+	innerPrivate, rest, ok3 := readSSHString(rest)
+	_, padding, ok4 := readSSHString(rest)
+	if !ok1 || !ok2 || !ok3 || !ok4 || string(keyType) != sshKeyType ||
+		len(innerPublic) != PublicKeySize || len(innerPrivate) != PrivateKeySize {
+		return nil, errBadOpenSSHPrivateKey
+	}
+	if len(padding) >= blockSize {
+		return nil, errBadOpenSSHPrivateKey
+	}
+	for i, p := range padding {
+		if p != byte(i+1) {
+			return nil, errBadOpenSSHPrivateKey
+		}
+	}
+
+	privateKey := PrivateKey(append([]byte(nil), innerPrivate...))
+	if err := CheckKeyPair(publicKey, privateKey); err != nil {
+		wipeBytes(privateKey)
+		return nil, err
+	}
+	if !bytes.Equal(innerPublic, publicKey) {
+		wipeBytes(privateKey)
+		return nil, ErrKeyMismatch
+	}
+	return privateKey, nil
+}
+
+// openSSHStream returns the AES-256-CTR stream that encrypts the private
+// section of an openssh-key-v1 key.
+func openSSHStream(passphrase, salt []byte, rounds int) (cipher.Stream, error) {
+	keyIV, err := bcryptpbkdf.Key(passphrase, salt, rounds, 32+aes.BlockSize)
+	if err != nil {
+		return nil, err
+	}
+	defer wipeBytes(keyIV)
+	block, err := aes.NewCipher(keyIV[:32])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewCTR(block, keyIV[32:]), nil
+}
@@ -0,0 +1,141 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/pem"
+	"os"
+	"testing"
+)
+
+func TestOpenSSHPrivateKeyRoundTrip(t *testing.T) {
+	_, private, _ := GenerateKey(rand.Reader)
+	for _, passphrase := range []string{"", testPassphrase} {
+		data, err := MarshalOpenSSHPrivateKey(private, "user@host", []byte(passphrase), 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed, err := ParseOpenSSHPrivateKey(data, []byte(passphrase))
+		if err != nil {
+			t.Fatalf("passphrase %q: %v", passphrase, err)
+		}
+		if !bytes.Equal(parsed, private) {
+			t.Errorf("passphrase %q: round trip changed the key", passphrase)
+		}
+	}
+}
+
+func TestOpenSSHPrivateKeySSHKeygen(t *testing.T) {
+	want, _ := hex.DecodeString(sshKeygenPublicKey)
+	data, err := os.ReadFile("testdata/ssh_ed25519")
+	if err != nil {
+		t.Fatal(err)
+	}
+	private, err := ParseOpenSSHPrivateKey(data, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(private[32:], want) {
+		t.Errorf("got public key %x, want %x", private[32:], want)
+	}
+
+	want, _ = hex.DecodeString(bobPublicKey)
+	data, err = os.ReadFile("testdata/ssh_ed25519_encrypted")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseOpenSSHPrivateKey(data, nil); err != ErrPassphraseRequired {
+		t.Errorf("no passphrase gave %v", err)
+	}
+	if _, err := ParseOpenSSHPrivateKey(data, []byte("wrong")); err != ErrIncorrectPassphrase {
+		t.Errorf("wrong passphrase gave %v", err)
+	}
+	private, err = ParseOpenSSHPrivateKey(data, []byte(testPassphrase))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(private[32:], want) {
+		t.Errorf("got public key %x, want %x", private[32:], want)
+	}
+}
+
+// openSSHSections returns the outer fields of an unencrypted key written by
+// MarshalOpenSSHPrivateKey. marshal reassembles them into a PEM block.
+func openSSHSections(t *testing.T, data []byte) (publicBlob, private []byte, marshal func(publicBlob, private []byte) []byte) {
+	block, _ := pem.Decode(data)
+	b := block.Bytes[len(sshPrivateKeyMagic):]
+	var ok bool
+	for i := 0; i < 3; i++ {
+		if _, b, ok = readSSHString(b); !ok {
+			t.Fatal("bad header")
+		}
+	}
+	header := block.Bytes[:len(block.Bytes)-len(b)]
+	publicBlob, b, _ = readSSHString(b[4:])
+	private, _, _ = readSSHString(b)
+	return publicBlob, private, func(publicBlob, private []byte) []byte {
+		out := append([]byte{}, header...)
+		out = binary.BigEndian.AppendUint32(out, 1)
+		out = appendSSHString(out, publicBlob)
+		out = appendSSHString(out, private)
+		return pem.EncodeToMemory(&pem.Block{Type: "OPENSSH PRIVATE KEY", Bytes: out})
+	}
+}
+
+func TestOpenSSHPrivateKeyErrors(t *testing.T) {
+	_, private, _ := GenerateKey(rand.Reader)
+	other, _, _ := GenerateKey(rand.Reader)
+
+	if _, err := MarshalOpenSSHPrivateKey(private[:10], "", nil, 0); err != ErrBadPrivateKeyLength {
+		t.Errorf("short key gave %v", err)
+	}
+	if _, err := MarshalOpenSSHPrivateKey(private, "", []byte("x"), -1); err == nil {
+		t.Error("negative rounds accepted")
+	}
+
+	data, err := MarshalOpenSSHPrivateKey(private, "comment", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicBlob, section, marshal := openSSHSections(t, data)
+	if _, err := ParseOpenSSHPrivateKey(marshal(publicBlob, section), nil); err != nil {
+		t.Fatalf("reassembled key: %v", err)
+	}
+
+	if _, err := ParseOpenSSHPrivateKey(marshal(sshPublicKeyBlob(other), section), nil); err != ErrKeyMismatch {
+		t.Errorf("mismatched outer public key gave %v", err)
+	}
+
+	// Replace the public key inside the private section.
+	inner := append([]byte{}, section...)
+	copy(inner[bytes.Index(inner, private[32:]):], other)
+	if _, err := ParseOpenSSHPrivateKey(marshal(publicBlob, inner), nil); err != ErrKeyMismatch {
+		t.Errorf("mismatched inner public key gave %v", err)
+	}
+
+	badCheck := append([]byte{}, section...)
+	badCheck[0] ^= 1
+	badPadding := append([]byte{}, section...)
+	badPadding[len(badPadding)-1] ^= 0x80
+	for name, data := range map[string][]byte{
+		"check values": marshal(publicBlob, badCheck),
+		"padding":      marshal(publicBlob, badPadding),
+		"truncated":    marshal(publicBlob, section[:len(section)-8]),
+		"rsa key":      marshal(appendSSHString(appendSSHString(nil, []byte("ssh-rsa")), other), section),
+		"magic":        pem.EncodeToMemory(&pem.Block{Type: "OPENSSH PRIVATE KEY", Bytes: []byte("openssh-key-v2\x00")}),
+	} {
+		if _, err := ParseOpenSSHPrivateKey(data, nil); err == nil || err == ErrIncorrectPassphrase {
+			t.Errorf("%s: got %v", name, err)
+		}
+	}
+
+	if _, err := ParseOpenSSHPrivateKey([]byte("not PEM"), nil); err != ErrNotPEM {
+		t.Errorf("non-PEM input gave %v", err)
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"io"
+)
+
+// SSH signatures are the format of "ssh-keygen -Y sign", described in the
+// PROTOCOL.sshsig file of the OpenSSH sources. They are used, among other
+// things, to sign git commits.
+
+const (
+	sshSigMagic     = "SSHSIG"
+	sshSigVersion   = 1
+	sshSigPEMType   = "SSH SIGNATURE"
+	sshSigLineWidth = 70
+)
+
+var (
+	// ErrSSHSigNamespace is returned by VerifySSHSig when the signature was
+	// made for a different namespace.
+	ErrSSHSigNamespace = errors.New("ed25519: SSH signature namespace mismatch")
+
+	// ErrSSHSigPublicKey is returned by VerifySSHSig when the signature was
+	// made by a different key.
+	ErrSSHSigPublicKey = errors.New("ed25519: SSH signature is by a different key")
+
+	errBadSSHSig = errors.New("ed25519: malformed SSH signature")
+)
+
+// SignSSHSig signs the contents of message with signer for use in namespace,
+// such as "git" or "file", and returns the armored signature, a "BEGIN SSH
+// SIGNATURE" block, as written by "ssh-keygen -Y sign -n namespace". The
+// message is hashed with SHA-512. Ed25519 signatures are deterministic, so
+// the result is byte for byte what ssh-keygen produces with the same key.
+//
+// namespace must not be empty, so that signatures for one purpose can't be
+// passed off as signatures for another.
+func SignSSHSig(signer crypto.Signer, namespace string, message io.Reader) ([]byte, error) {
+	if namespace == "" {
+		return nil, errors.New("ed25519: empty SSH signature namespace")
+	}
+	publicKey, err := SignerPublicKey(signer)
+	if err != nil {
+		return nil, err
+	}
+
+	signed, err := sshSigSignedData(namespace, "sha512", message)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := signWith(signer, signed)
+	if err != nil {
+		return nil, err
+	}
+
+	b := []byte(sshSigMagic)
+	b = binary.BigEndian.AppendUint32(b, sshSigVersion)
+	b = appendSSHString(b, sshPublicKeyBlob(publicKey))
+	b = appendSSHString(b, []byte(namespace))
+	b = appendSSHString(b, nil)
+	b = appendSSHString(b, []byte("sha512"))
+	b = appendSSHString(b, appendSSHString(appendSSHString(nil, []byte(sshKeyType)), sig))
+
+	encoded := base64.StdEncoding.EncodeToString(b)
+	var out bytes.Buffer
+	out.WriteString("-----BEGIN " + sshSigPEMType + "-----\n")
+	for len(encoded) > 0 {
+		n := min(len(encoded), sshSigLineWidth)
+		out.WriteString(encoded[:n])
+		out.WriteByte('\n')
+		encoded = encoded[n:]
+	}
+	out.WriteString("-----END " + sshSigPEMType + "-----\n")
+	return out.Bytes(), nil
+}
+
+// VerifySSHSig checks that armored, as produced by SignSSHSig or "ssh-keygen
+// -Y sign", is a valid signature of the contents of message by publicKey for
+// namespace. Signatures over SHA-256 or SHA-512 message hashes are accepted.
+//
+// It returns ErrSSHSigPublicKey if the signature is by another key,
+// ErrSSHSigNamespace if it is for another namespace, ErrInvalidSignature if
+// it doesn't verify, and other errors if armored is malformed or uses another
+// key type.
+func VerifySSHSig(publicKey PublicKey, namespace string, message io.Reader, armored []byte) error {
+	if len(publicKey) != PublicKeySize {
+		return ErrBadPublicKeyLength
+	}
+	b, err := findPEMBlock(armored, sshSigPEMType)
+	if err != nil {
+		return err
+	}
+
+	if !bytes.HasPrefix(b, []byte(sshSigMagic)) || len(b) < len(sshSigMagic)+4 {
+		return errBadSSHSig
+	}
+	b = b[len(sshSigMagic):]
+	if binary.BigEndian.Uint32(b) != sshSigVersion {
+		return errors.New("ed25519: unsupported SSH signature version")
+	}
+	publicBlob, b, ok1 := readSSHString(b[4:])
+	sigNamespace, b, ok2 := readSSHString(b)
+	_, b, ok3 := readSSHString(b) // reserved
+	hashName, b, ok4 := readSSHString(b)
+	sigBlob, b, ok5 := readSSHString(b)
+	if !ok1 || !ok2 || !ok3 || !ok4 || !ok5 || len(b) != 0 {
+		return errBadSSHSig
+	}
+
+	sigPublicKey, err := parseSSHPublicKeyBlob(publicBlob)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(sigPublicKey, publicKey) {
+		return ErrSSHSigPublicKey
+	}
+	if string(sigNamespace) != namespace {
+		return ErrSSHSigNamespace
+	}
+	sigType, sig, ok1 := readSSHString(sigBlob)
+	sig, rest, ok2 := readSSHString(sig)
+	if !ok1 || !ok2 || len(rest) != 0 || string(sigType) != sshKeyType {
+		return errBadSSHSig
+	}
+
+	signed, err := sshSigSignedData(namespace, string(hashName), message)
+	if err != nil {
+		return err
+	}
+	return CheckSignature(publicKey, signed, sig)
+}
+
+// sshSigSignedData returns the blob that is actually signed: the magic, the
+// namespace, an empty reserved field, the hash algorithm and the hash of
+// message.
+func sshSigSignedData(namespace, hashName string, message io.Reader) ([]byte, error) {
+	var h hash.Hash
+	switch hashName {
+	case "sha512":
+		h = sha512.New()
+	case "sha256":
+		h = sha256.New()
+	default:
+		return nil, errors.New("ed25519: unsupported SSH signature hash: " + hashName)
+	}
+	if _, err := io.Copy(h, message); err != nil {
+		return nil, err
+	}
+
+	b := []byte(sshSigMagic)
+	b = appendSSHString(b, []byte(namespace))
+	b = appendSSHString(b, nil)
+	b = appendSSHString(b, []byte(hashName))
+	b = appendSSHString(b, h.Sum(nil))
+	return b, nil
+}
@@ -0,0 +1,160 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"strings"
+	"testing"
+)
+
+func readSSHKeygenKey(t *testing.T) PrivateKey {
+	data, err := os.ReadFile("testdata/ssh_ed25519")
+	if err != nil {
+		t.Fatal(err)
+	}
+	private, err := ParseOpenSSHPrivateKey(data, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return private
+}
+
+func TestSSHSigRoundTrip(t *testing.T) {
+	public, private, _ := GenerateKey(rand.Reader)
+	other, _, _ := GenerateKey(rand.Reader)
+	message := []byte("hello, world")
+
+	armored, err := SignSSHSig(private, "file", bytes.NewReader(message))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifySSHSig(public, "file", bytes.NewReader(message), armored); err != nil {
+		t.Error(err)
+	}
+	if err := VerifySSHSig(public, "git", bytes.NewReader(message), armored); err != ErrSSHSigNamespace {
+		t.Errorf("other namespace gave %v", err)
+	}
+	if err := VerifySSHSig(other, "file", bytes.NewReader(message), armored); err != ErrSSHSigPublicKey {
+		t.Errorf("other key gave %v", err)
+	}
+	if err := VerifySSHSig(public, "file", strings.NewReader("hello, world!"), armored); err != ErrInvalidSignature {
+		t.Errorf("other message gave %v", err)
+	}
+
+	if _, err := SignSSHSig(private, "", bytes.NewReader(message)); err == nil {
+		t.Error("empty namespace accepted")
+	}
+}
+
+func TestSSHSigSSHKeygen(t *testing.T) {
+	private := readSSHKeygenKey(t)
+	public := PublicKey(private[32:])
+	message, err := os.ReadFile("testdata/sshsig_message.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct{ path, namespace string }{
+		// Written by ssh-keygen -Y sign, with the default SHA-512 and
+		// with -O hashalg=sha256.
+		{"testdata/sshsig_message.txt.git.sig", "git"},
+		{"testdata/sshsig_message.txt.sha256.sig", "git"},
+		// Written by SignSSHSig and accepted by ssh-keygen -Y verify.
+		{"testdata/sshsig_message.txt.file.sig", "file"},
+	} {
+		armored, err := os.ReadFile(tc.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := VerifySSHSig(public, tc.namespace, bytes.NewReader(message), armored); err != nil {
+			t.Errorf("%s: %v", tc.path, err)
+		}
+		other := "file"
+		if tc.namespace == "file" {
+			other = "git"
+		}
+		if err := VerifySSHSig(public, other, bytes.NewReader(message), armored); err != ErrSSHSigNamespace {
+			t.Errorf("%s: namespace %q gave %v", tc.path, other, err)
+		}
+	}
+
+	// Signing is deterministic, so SignSSHSig must reproduce the SHA-512
+	// signatures exactly.
+	for _, tc := range []struct{ path, namespace string }{
+		{"testdata/sshsig_message.txt.git.sig", "git"},
+		{"testdata/sshsig_message.txt.file.sig", "file"},
+	} {
+		want, _ := os.ReadFile(tc.path)
+		got, err := SignSSHSig(private, tc.namespace, bytes.NewReader(message))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: SignSSHSig wrote\n%s\nwant\n%s", tc.path, got, want)
+		}
+	}
+}
+
+func TestSSHSigErrors(t *testing.T) {
+	private := readSSHKeygenKey(t)
+	public := PublicKey(private[32:])
+	message := []byte("message")
+	armored, err := SignSSHSig(private, "file", bytes.NewReader(message))
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(armored)
+	rearmor := func(b []byte) []byte {
+		return pem.EncodeToMemory(&pem.Block{Type: "SSH SIGNATURE", Bytes: b})
+	}
+	if err := VerifySSHSig(public, "file", bytes.NewReader(message), rearmor(block.Bytes)); err != nil {
+		t.Fatalf("re-armored signature: %v", err)
+	}
+
+	badVersion := append([]byte{}, block.Bytes...)
+	badVersion[9] = 2
+	badHash := bytes.Replace(block.Bytes, []byte("sha512"), []byte("sha384"), 1)
+	badSigType := bytes.Replace(block.Bytes, []byte("\x00\x00\x00\x53\x00\x00\x00\x0bssh-ed25519"), []byte("\x00\x00\x00\x53\x00\x00\x00\x0bssh-ed25518"), 1)
+	if bytes.Equal(badSigType, block.Bytes) {
+		t.Fatal("signature blob not found")
+	}
+	rsaKey := bytes.Replace(block.Bytes, []byte("ssh-ed25519"), []byte("ssh-ed25518"), 1)
+	tampered := append([]byte{}, block.Bytes...)
+	tampered[len(tampered)-1] ^= 1
+
+	for name, tc := range map[string]struct {
+		armored []byte
+		want    error
+	}{
+		"tampered":   {rearmor(tampered), ErrInvalidSignature},
+		"not armor":  {[]byte(base64.StdEncoding.EncodeToString(block.Bytes)), ErrNotPEM},
+		"PEM type":   {pem.EncodeToMemory(&pem.Block{Type: "SIGNATURE", Bytes: block.Bytes}), ErrPEMBlockType},
+		"truncated":  {rearmor(block.Bytes[:len(block.Bytes)-1]), nil},
+		"trailing":   {rearmor(append(append([]byte{}, block.Bytes...), 0)), nil},
+		"magic":      {rearmor(append([]byte("SSHSIH"), block.Bytes[6:]...)), nil},
+		"short":      {rearmor([]byte("SSHSIG")), nil},
+		"version":    {rearmor(badVersion), nil},
+		"hash":       {rearmor(badHash), nil},
+		"signature":  {rearmor(badSigType), nil},
+		"public key": {rearmor(rsaKey), ErrBadOpenSSHPublicKey},
+	} {
+		err := VerifySSHSig(public, "file", bytes.NewReader(message), tc.armored)
+		if err == nil || (tc.want != nil && err != tc.want) {
+			t.Errorf("%s: got %v, want %v", name, err, tc.want)
+		}
+	}
+
+	if err := VerifySSHSig(public[:31], "file", bytes.NewReader(message), armored); err != ErrBadPublicKeyLength {
+		t.Errorf("short public key gave %v", err)
+	}
+	if _, err := SignSSHSig(private, "file", failingReader{}); err == nil {
+		t.Error("failing message reader accepted")
+	}
+}
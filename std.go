@@ -0,0 +1,54 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import stded25519 "crypto/ed25519"
+
+// The keys of crypto/ed25519 have the same layout as those of this package, so
+// the conversions below only check the length and copy the bytes: a key
+// converted back and forth is unchanged and shares no memory with the
+// original.
+
+// FromStdPublicKey returns a copy of a crypto/ed25519 public key. If
+// requireCanonical is true the key must also be the canonical encoding of a
+// point of large order, as for StrictVerify, or the error is
+// ErrInvalidPublicKey.
+func FromStdPublicKey(publicKey stded25519.PublicKey, requireCanonical bool) (PublicKey, error) {
+	if len(publicKey) != PublicKeySize {
+		return nil, ErrBadPublicKeyLength
+	}
+	if requireCanonical {
+		if err := checkPublicKey(PublicKey(publicKey)); err != nil {
+			return nil, err
+		}
+	}
+	return append(PublicKey(nil), publicKey...), nil
+}
+
+// FromStdPrivateKey returns a copy of a crypto/ed25519 private key. It doesn't
+// check that the public half matches the seed; Validate does.
+func FromStdPrivateKey(privateKey stded25519.PrivateKey) (PrivateKey, error) {
+	if len(privateKey) != PrivateKeySize {
+		return nil, ErrBadPrivateKeyLength
+	}
+	return append(PrivateKey(nil), privateKey...), nil
+}
+
+// ToStdPublicKey returns a copy of publicKey as a crypto/ed25519 public key.
+func ToStdPublicKey(publicKey PublicKey) (stded25519.PublicKey, error) {
+	if len(publicKey) != PublicKeySize {
+		return nil, ErrBadPublicKeyLength
+	}
+	return append(stded25519.PublicKey(nil), publicKey...), nil
+}
+
+// ToStdPrivateKey returns a copy of privateKey as a crypto/ed25519 private
+// key.
+func ToStdPrivateKey(privateKey PrivateKey) (stded25519.PrivateKey, error) {
+	if len(privateKey) != PrivateKeySize {
+		return nil, ErrBadPrivateKeyLength
+	}
+	return append(stded25519.PrivateKey(nil), privateKey...), nil
+}
@@ -0,0 +1,91 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	stded25519 "crypto/ed25519"
+	"crypto/rand"
+	"testing"
+)
+
+func TestStdConversions(t *testing.T) {
+	message := []byte("message")
+
+	// A key of this package signs for crypto/ed25519.
+	public, private, _ := GenerateKey(rand.Reader)
+	stdPublic, err := ToStdPublicKey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdPrivate, err := ToStdPrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, _ := Sign(private, message)
+	if !stded25519.Verify(stdPublic, message, sig) {
+		t.Error("crypto/ed25519 rejects our signature")
+	}
+	if !bytes.Equal(stded25519.Sign(stdPrivate, message), sig) {
+		t.Error("crypto/ed25519 signature with the converted key differs")
+	}
+
+	// And the other way round.
+	stdPublic, stdPrivate, _ = stded25519.GenerateKey(rand.Reader)
+	for _, requireCanonical := range []bool{false, true} {
+		got, err := FromStdPublicKey(stdPublic, requireCanonical)
+		if err != nil || !bytes.Equal(got, stdPublic) {
+			t.Errorf("FromStdPublicKey(%v) = %x, %v", requireCanonical, got, err)
+		}
+	}
+	public, _ = FromStdPublicKey(stdPublic, true)
+	private, err = FromStdPrivateKey(stdPrivate)
+	if err != nil || !bytes.Equal(private, stdPrivate) {
+		t.Fatalf("FromStdPrivateKey = %x, %v", private, err)
+	}
+	if !Verify(public, message, stded25519.Sign(stdPrivate, message)) {
+		t.Error("crypto/ed25519 signature doesn't verify")
+	}
+	if err := private.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	// The results are copies.
+	public[0] ^= 1
+	private[0] ^= 1
+	if public[0] == stdPublic[0] || private[0] == stdPrivate[0] {
+		t.Error("converted key shares memory with the original")
+	}
+	back, _ := ToStdPrivateKey(private)
+	private[1] ^= 1
+	if back[1] == private[1] {
+		t.Error("ToStdPrivateKey result shares memory with the original")
+	}
+}
+
+func TestStdConversionsErrors(t *testing.T) {
+	for _, enc := range append([]string{"f0ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f"}, smallOrderEncodings...) {
+		key := stded25519.PublicKey(decodeHex(t, enc))
+		if _, err := FromStdPublicKey(key, true); err != ErrInvalidPublicKey {
+			t.Errorf("strict FromStdPublicKey(%s) gave %v", enc, err)
+		}
+		if got, err := FromStdPublicKey(key, false); err != nil || !bytes.Equal(got, key) {
+			t.Errorf("FromStdPublicKey(%s) = %x, %v", enc, got, err)
+		}
+	}
+
+	if _, err := FromStdPublicKey(make(stded25519.PublicKey, 31), false); err != ErrBadPublicKeyLength {
+		t.Errorf("short public key gave %v", err)
+	}
+	if _, err := FromStdPrivateKey(stded25519.NewKeyFromSeed(make([]byte, 32))[:32]); err != ErrBadPrivateKeyLength {
+		t.Errorf("seed as a private key gave %v", err)
+	}
+	if _, err := ToStdPublicKey(nil); err != ErrBadPublicKeyLength {
+		t.Errorf("nil public key gave %v", err)
+	}
+	if _, err := ToStdPrivateKey(make(PrivateKey, 65)); err != ErrBadPrivateKeyLength {
+		t.Errorf("long private key gave %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// A Stellar strkey, from SEP-23, is the base32 of a version byte, the
+// payload and the CRC16-XModem of both in little-endian order. Ed25519 public
+// keys have version byte 6<<3 and seeds 18<<3, which give the leading 'G'
+// and 'S'. With a 32-byte payload the encoding is exactly 56 characters and
+// has no padding.
+
+const (
+	strkeyVersionPublic = 6 << 3
+	strkeyVersionSeed   = 18 << 3
+
+	strkeySize = 1 + 32 + 2
+)
+
+var (
+	// ErrStrkeyVersion is returned by DecodeStrkeyPublic and
+	// DecodeStrkeySeed when a strkey is well formed but of another type,
+	// such as a seed passed to DecodeStrkeyPublic.
+	ErrStrkeyVersion = errors.New("ed25519: strkey has the wrong version byte")
+
+	// ErrStrkeyChecksum is returned by DecodeStrkeyPublic and
+	// DecodeStrkeySeed when the checksum of a strkey doesn't match.
+	ErrStrkeyChecksum = errors.New("ed25519: strkey checksum mismatch")
+)
+
+var errBadStrkey = errors.New("ed25519: malformed strkey")
+
+// EncodeStrkeyPublic returns publicKey as a Stellar account ID, a strkey
+// starting with 'G'. It returns the empty string if len(publicKey) is not
+// PublicKeySize.
+func EncodeStrkeyPublic(publicKey PublicKey) string {
+	if len(publicKey) != PublicKeySize {
+		return ""
+	}
+	return encodeStrkey(strkeyVersionPublic, publicKey)
+}
+
+// EncodeStrkeySeed returns the seed of privateKey as a Stellar secret seed, a
+// strkey starting with 'S'. It returns the empty string if len(privateKey) is
+// not PrivateKeySize.
+func EncodeStrkeySeed(privateKey PrivateKey) string {
+	if len(privateKey) != PrivateKeySize {
+		return ""
+	}
+	return encodeStrkey(strkeyVersionSeed, privateKey[:32])
+}
+
+// DecodeStrkeyPublic returns the public key in a Stellar account ID. The key
+// is not checked to be a valid point.
+func DecodeStrkeyPublic(s string) (PublicKey, error) {
+	b, err := decodeStrkey(strkeyVersionPublic, s)
+	if err != nil {
+		return nil, err
+	}
+	return PublicKey(b), nil
+}
+
+// DecodeStrkeySeed returns the private key of a Stellar secret seed.
+func DecodeStrkeySeed(s string) (PrivateKey, error) {
+	b, err := decodeStrkey(strkeyVersionSeed, s)
+	if err != nil {
+		return nil, err
+	}
+	defer wipeBytes(b)
+	return NewKeyFromSeed(b), nil
+}
+
+func encodeStrkey(version byte, payload []byte) string {
+	b := make([]byte, 1, strkeySize)
+	b[0] = version
+	b = append(b, payload...)
+	b = binary.LittleEndian.AppendUint16(b, crc16XModem(b))
+	s := base32NoPadding.EncodeToString(b)
+	wipeBytes(b)
+	return s
+}
+
+// decodeStrkey returns the 32-byte payload of s, which must have the given
+// version byte. Only the canonical form, in upper case without padding or
+// white space, is accepted.
+func decodeStrkey(version byte, s string) ([]byte, error) {
+	if len(s) != base32NoPadding.EncodedLen(strkeySize) {
+		return nil, errBadStrkey
+	}
+	b, err := base32NoPadding.DecodeString(s)
+	if err != nil || len(b) != strkeySize {
+		return nil, errBadStrkey
+	}
+	defer wipeBytes(b)
+	if binary.LittleEndian.Uint16(b[strkeySize-2:]) != crc16XModem(b[:strkeySize-2]) {
+		return nil, ErrStrkeyChecksum
+	}
+	if b[0] != version {
+		return nil, ErrStrkeyVersion
+	}
+	return append([]byte(nil), b[1:strkeySize-2]...), nil
+}
+
+// crc16XModem returns the CRC-16 of b with polynomial 0x1021 and initial
+// value 0, as used by XMODEM.
+func crc16XModem(b []byte) uint16 {
+	var crc uint16
+	for _, c := range b {
+		crc ^= uint16(c) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
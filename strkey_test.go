@@ -0,0 +1,91 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestStrkey(t *testing.T) {
+	// The account ID and seed vectors of SEP-23.
+	const (
+		account = "GA7QYNF7SOWQ3GLR2BGMZEHXAVIRZA4KVWLTJJFC7MGXUA74P7UJVSGZ"
+		seed    = "SBU2RRGLXH3E5CQHTD3ODLDF2BWDCYUSSBLLZ5GNW7JXHDIYKXZWHOKR"
+	)
+	publicKey, err := DecodeStrkeyPublic(account)
+	if err != nil || hex.EncodeToString(publicKey) != "3f0c34bf93ad0d9971d04ccc90f705511c838aad9734a4a2fb0d7a03fc7fe89a" {
+		t.Errorf("DecodeStrkeyPublic(%s) = %x, %v", account, publicKey, err)
+	}
+	if got := EncodeStrkeyPublic(publicKey); got != account {
+		t.Errorf("EncodeStrkeyPublic = %s, want %s", got, account)
+	}
+	privateKey, err := DecodeStrkeySeed(seed)
+	if err != nil || hex.EncodeToString(privateKey[:32]) != "69a8c4cbb9f64e8a0798f6e1ac65d06c3162929056bcf4cdb7d3738d1855f363" {
+		t.Errorf("DecodeStrkeySeed(%s) = %x, %v", seed, privateKey, err)
+	}
+	if got := EncodeStrkeySeed(privateKey); got != seed {
+		t.Errorf("EncodeStrkeySeed = %s, want %s", got, seed)
+	}
+
+	// The keypair of the Keypair.fromSecret test of the Stellar SDK.
+	privateKey, err = DecodeStrkeySeed("SDJHRQF4GCMIIKAAAQ6IHY42X73FQFLHUULAPSKKD4DFDM7UXWWCRHBE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := EncodeStrkeyPublic(privateKey.Public().(PublicKey)); got != "GCZHXL5HXQX5ABDM26LHYRCQZ5OJFHLOPLZX47WEBP3V2PF5AVFK2A5D" {
+		t.Errorf("account ID of the seed is %s", got)
+	}
+}
+
+func TestStrkeyRoundTrip(t *testing.T) {
+	publicKey, privateKey, _ := GenerateKey(rand.Reader)
+	account := EncodeStrkeyPublic(publicKey)
+	if got, err := DecodeStrkeyPublic(account); err != nil || !bytes.Equal(got, publicKey) {
+		t.Errorf("DecodeStrkeyPublic(%s) = %x, %v", account, got, err)
+	}
+	seed := EncodeStrkeySeed(privateKey)
+	if got, err := DecodeStrkeySeed(seed); err != nil || !bytes.Equal(got, privateKey) {
+		t.Errorf("DecodeStrkeySeed(%s) didn't round-trip: %v", seed, err)
+	}
+
+	if _, err := DecodeStrkeyPublic(seed); err != ErrStrkeyVersion {
+		t.Errorf("seed as an account ID gave %v", err)
+	}
+	if _, err := DecodeStrkeySeed(account); err != ErrStrkeyVersion {
+		t.Errorf("account ID as a seed gave %v", err)
+	}
+
+	if EncodeStrkeyPublic(publicKey[:31]) != "" || EncodeStrkeySeed(privateKey[:32]) != "" {
+		t.Error("strkey of a key of the wrong length")
+	}
+}
+
+func TestStrkeyErrors(t *testing.T) {
+	const account = "GA7QYNF7SOWQ3GLR2BGMZEHXAVIRZA4KVWLTJJFC7MGXUA74P7UJVSGZ"
+
+	// The last character only changes the checksum.
+	if _, err := DecodeStrkeyPublic(account[:55] + "Y"); err != ErrStrkeyChecksum {
+		t.Errorf("bad checksum gave %v", err)
+	}
+	for _, bad := range []string{
+		"",
+		account[:55],
+		account + "A",
+		account[:48] + "========",
+		strings.ToLower(account),
+		account[:20] + "\n" + account[21:],
+		account[:20] + "1" + account[21:],
+		// A muxed account, with a 40-byte payload.
+		"MA7QYNF7SOWQ3GLR2BGMZEHXAVIRZA4KVWLTJJFC7MGXUA74P7UJUAAAAAAAAAAAACJUQ",
+	} {
+		if _, err := DecodeStrkeyPublic(bad); err != errBadStrkey {
+			t.Errorf("DecodeStrkeyPublic(%q) gave %v", bad, err)
+		}
+	}
+}
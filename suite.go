@@ -0,0 +1,92 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	cryptorand "crypto/rand"
+	"errors"
+	"hash"
+	"io"
+)
+
+// A Suite is a variant of Ed25519 in which SHA-512 is replaced by another
+// hash with a 64-byte output, such as SHAKE256 with 64 bytes of output, for
+// the seed expansion, the nonce and the challenge alike.
+//
+// WARNING: these variants are not Ed25519. Their keys and signatures are
+// incompatible with RFC 8032 and with every other function in this package:
+// a public key derived by a Suite is not the one NewKeyFromSeed derives from
+// the same seed, PrivateKey.Validate rejects its private keys, and Verify
+// rejects its signatures. They exist only to interoperate with systems that
+// have adopted such a variant, and offer whatever security the replacement
+// hash provides. Nothing checks that the hash is collision resistant or even
+// deterministic. Use the package-level functions unless a protocol requires
+// otherwise.
+type Suite struct {
+	newHash func() hash.Hash
+}
+
+// NewCustomSuite returns a Suite that uses newHash wherever Ed25519 uses
+// SHA-512. Every hash returned by newHash must be freshly reset and have a
+// Size of 64 bytes. NewCustomSuite(sha512.New) yields ordinary Ed25519.
+func NewCustomSuite(newHash func() hash.Hash) (*Suite, error) {
+	if newHash == nil {
+		return nil, errors.New("ed25519: nil hash constructor")
+	}
+	if newHash().Size() != 64 {
+		return nil, errors.New("ed25519: custom suite hash must have a 64-byte output")
+	}
+	return &Suite{newHash: newHash}, nil
+}
+
+// GenerateKey is like the GenerateKey function but derives the key with s.
+func (s *Suite) GenerateKey(rand io.Reader) (PublicKey, PrivateKey, error) {
+	if rand == nil {
+		rand = cryptorand.Reader
+	}
+
+	var seed [SeedSize]byte
+	defer wipeBytes(seed[:])
+	if _, err := io.ReadFull(rand, seed[:]); err != nil {
+		return nil, nil, err
+	}
+
+	privateKey := newKeyFromSeed(&hashState{h: s.newHash()}, seed[:])
+	publicKey := make([]byte, PublicKeySize)
+	copy(publicKey, privateKey[32:])
+
+	return publicKey, privateKey, nil
+}
+
+// NewKeyFromSeed is like the NewKeyFromSeed function but derives the key
+// with s. It returns an error, rather than panicking, if len(seed) is not
+// SeedSize.
+func (s *Suite) NewKeyFromSeed(seed []byte) (PrivateKey, error) {
+	if len(seed) != SeedSize {
+		return nil, errors.New("ed25519: bad seed length")
+	}
+	return newKeyFromSeed(&hashState{h: s.newHash()}, seed), nil
+}
+
+// Sign signs message with privateKey, which must have been derived by s, and
+// is otherwise like the Sign function.
+func (s *Suite) Sign(privateKey PrivateKey, message []byte) ([]byte, error) {
+	if len(privateKey) != PrivateKeySize {
+		return nil, ErrBadPrivateKeyLength
+	}
+
+	var k ExpandedPrivateKey
+	k.expandSeedWith(&hashState{h: s.newHash()}, privateKey[:32])
+	copy(k.publicKey[:], privateKey[32:])
+	defer k.Wipe()
+
+	return k.appendSignWith(&hashState{h: s.newHash()}, make([]byte, 0, SignatureSize), message, nil, nil), nil
+}
+
+// Verify reports whether sig is a valid signature of message by publicKey
+// under s. Apart from the hash, the rules are those of the Verify function.
+func (s *Suite) Verify(publicKey PublicKey, message, sig []byte) bool {
+	return verify(publicKey, message, sig, nil, verifyRules{newHash: s.newHash}) == nil
+}
@@ -0,0 +1,148 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha3"
+	"crypto/sha512"
+	"encoding/hex"
+	"hash"
+	"testing"
+)
+
+// shake256 adapts SHAKE256 with 64 bytes of output to hash.Hash.
+type shake256 struct{ buf []byte }
+
+func newSHAKE256() hash.Hash { return new(shake256) }
+
+func (h *shake256) Write(p []byte) (int, error) {
+	h.buf = append(h.buf, p...)
+	return len(p), nil
+}
+
+func (h *shake256) Sum(b []byte) []byte {
+	return append(b, sha3.SumSHAKE256(h.buf, 64)...)
+}
+
+func (h *shake256) Reset()         { h.buf = h.buf[:0] }
+func (h *shake256) Size() int      { return 64 }
+func (h *shake256) BlockSize() int { return 136 }
+
+func TestCustomSuite(t *testing.T) {
+	suite, err := NewCustomSuite(newSHAKE256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	public, private, err := suite.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := []byte("test message")
+	sig, err := suite.Sign(private, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !suite.Verify(public, message, sig) {
+		t.Error("valid signature rejected")
+	}
+	if suite.Verify(public, []byte("wrong message"), sig) {
+		t.Error("signature of a different message accepted")
+	}
+
+	// The variant is separate from Ed25519 in both directions.
+	if Verify(public, message, sig) {
+		t.Error("Verify accepted a SHAKE256 signature")
+	}
+	if suite.Verify(public, message, mustSign(t, private, message)) {
+		t.Error("SHAKE256 suite accepted an Ed25519 signature")
+	}
+	if bytes.Equal(NewKeyFromSeed(private.Seed()), private) {
+		t.Error("SHAKE256 suite derived the Ed25519 key")
+	}
+}
+
+// TestCustomSuiteVector pins the SHAKE256 variant against an independent
+// implementation, for the seed 0, 1, ..., 31 and the message "abc".
+func TestCustomSuiteVector(t *testing.T) {
+	suite, err := NewCustomSuite(newSHAKE256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seed := make([]byte, SeedSize)
+	for i := range seed {
+		seed[i] = byte(i)
+	}
+	private, err := suite.NewKeyFromSeed(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := suite.Sign(private, []byte("abc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const wantPublic = "133392a88290a096085a826ee4d15b87a844feea2ca240310fbec1d1ad288326"
+	const wantSig = "e4a09fac1709a71beb2c86aefbfc1e30ceeb7e13bcd2e53afd59021d41997afa9f2db0308df3e9d1b3bbb81e0e1d9427d29ee1c776ef66625069ad5eb215320b"
+	if got := hex.EncodeToString(private[32:]); got != wantPublic {
+		t.Errorf("public key is %s, want %s", got, wantPublic)
+	}
+	if got := hex.EncodeToString(sig); got != wantSig {
+		t.Errorf("signature is %s, want %s", got, wantSig)
+	}
+}
+
+func TestCustomSuiteSHA512(t *testing.T) {
+	suite, err := NewCustomSuite(sha512.New)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	public, private, _ := GenerateKey(rand.Reader)
+	derived, err := suite.NewKeyFromSeed(private.Seed())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(derived, private) {
+		t.Error("SHA-512 suite derived a different key")
+	}
+	message := []byte("test message")
+	sig, err := suite.Sign(private, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(sig, mustSign(t, private, message)) {
+		t.Error("SHA-512 suite made a different signature")
+	}
+	if !Verify(public, message, sig) || !suite.Verify(public, message, sig) {
+		t.Error("SHA-512 suite signature rejected")
+	}
+}
+
+func TestCustomSuiteErrors(t *testing.T) {
+	if _, err := NewCustomSuite(nil); err == nil {
+		t.Error("NewCustomSuite(nil) succeeded")
+	}
+	if _, err := NewCustomSuite(sha256.New); err == nil {
+		t.Error("NewCustomSuite accepted a 32-byte hash")
+	}
+
+	suite, _ := NewCustomSuite(newSHAKE256)
+	if _, err := suite.NewKeyFromSeed(make([]byte, SeedSize-1)); err == nil {
+		t.Error("NewKeyFromSeed accepted a short seed")
+	}
+	if _, err := suite.Sign(make([]byte, PrivateKeySize-1), nil); err != ErrBadPrivateKeyLength {
+		t.Errorf("Sign with a short key: got %v, want ErrBadPrivateKeyLength", err)
+	}
+	if suite.Verify(make([]byte, PublicKeySize-1), nil, make([]byte, SignatureSize)) {
+		t.Error("Verify accepted a short public key")
+	}
+	if _, _, err := suite.GenerateKey(&shortReader{10}); err == nil {
+		t.Error("GenerateKey succeeded with a short reader")
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"errors"
+
+	"github.com/agl/ed25519/internal/blake2b"
+)
+
+// Tezos writes keys, signatures and addresses in base58check with a prefix
+// that selects the leading characters of the text. A tz1 address is the
+// BLAKE2b-160 hash of the public key.
+
+// tezosPrefixes are the prefixes of the Tezos base58check strings for
+// Ed25519, with the length of the payload that follows them.
+var tezosPrefixes = map[string]struct {
+	prefix []byte
+	size   int
+}{
+	"tz1":    {[]byte{6, 161, 159}, 20},
+	"edpk":   {[]byte{13, 15, 37, 217}, PublicKeySize},
+	"edsk":   {[]byte{13, 15, 58, 7}, 32},
+	"edsk64": {[]byte{43, 246, 78, 7}, PrivateKeySize},
+	"edsig":  {[]byte{9, 245, 205, 134, 18}, SignatureSize},
+}
+
+var errBadTezos = errors.New("ed25519: malformed Tezos string")
+
+// TezosAddressFromPublicKey returns the tz1 address of publicKey.
+func TezosAddressFromPublicKey(publicKey PublicKey) (string, error) {
+	if len(publicKey) != PublicKeySize {
+		return "", ErrBadPublicKeyLength
+	}
+	h, _ := blake2b.New(20, nil)
+	h.Write(publicKey)
+	return encodeTezos("tz1", h.Sum(nil)), nil
+}
+
+// ParseTezosAddress returns the 20-byte public key hash in a tz1 address.
+func ParseTezosAddress(address string) ([]byte, error) {
+	return decodeTezos("tz1", address)
+}
+
+// FormatEdpk returns publicKey as a Tezos "edpk" string.
+func FormatEdpk(publicKey PublicKey) (string, error) {
+	if len(publicKey) != PublicKeySize {
+		return "", ErrBadPublicKeyLength
+	}
+	return encodeTezos("edpk", publicKey), nil
+}
+
+// ParseEdpk parses a Tezos "edpk" public key string. The key is not checked
+// to be a valid point.
+func ParseEdpk(s string) (PublicKey, error) {
+	b, err := decodeTezos("edpk", s)
+	if err != nil {
+		return nil, err
+	}
+	return PublicKey(b), nil
+}
+
+// FormatEdsk returns the seed of privateKey as a Tezos "edsk" string, of 54
+// characters, as octez-client stores unencrypted keys.
+func FormatEdsk(privateKey PrivateKey) (string, error) {
+	if len(privateKey) != PrivateKeySize {
+		return "", ErrBadPrivateKeyLength
+	}
+	return encodeTezos("edsk", privateKey[:32]), nil
+}
+
+// ParseEdsk parses a Tezos "edsk" secret key string, either the 54-character
+// form of the seed or the 98-character form of the seed and the public key.
+// In the latter the public key must be that of the seed or the error is
+// ErrKeyMismatch. Encrypted "edesk" keys are not supported.
+func ParseEdsk(s string) (PrivateKey, error) {
+	if len(s) > 54 {
+		b, err := decodeTezos("edsk64", s)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkKeyPair(PublicKey(b[32:]), PrivateKey(b)); err != nil {
+			wipeBytes(b)
+			return nil, err
+		}
+		return PrivateKey(b), nil
+	}
+	b, err := decodeTezos("edsk", s)
+	if err != nil {
+		return nil, err
+	}
+	defer wipeBytes(b)
+	return NewKeyFromSeed(b), nil
+}
+
+// FormatEdsig returns sig as a Tezos "edsig" string.
+func FormatEdsig(sig []byte) (string, error) {
+	if len(sig) != SignatureSize {
+		return "", ErrBadSignatureLength
+	}
+	return encodeTezos("edsig", sig), nil
+}
+
+// ParseEdsig parses a Tezos "edsig" signature string.
+func ParseEdsig(s string) ([]byte, error) {
+	return decodeTezos("edsig", s)
+}
+
+func encodeTezos(kind string, payload []byte) string {
+	return encodeBase58Check(tezosPrefixes[kind].prefix, payload)
+}
+
+// decodeTezos returns the payload of s, a base58check string of the given
+// kind. It returns ErrBase58Checksum if the checksum doesn't match.
+func decodeTezos(kind, s string) ([]byte, error) {
+	p := tezosPrefixes[kind]
+	b, err := decodeBase58Check(s)
+	if err == errBadBase58Check {
+		return nil, errBadTezos
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(b) != len(p.prefix)+p.size || string(b[:len(p.prefix)]) != string(p.prefix) {
+		wipeBytes(b)
+		return nil, errBadTezos
+	}
+	return b[len(p.prefix):], nil
+}
@@ -0,0 +1,138 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+// tezosTests are the alice and bob accounts of the flextesa sandbox, as
+// listed in its documentation.
+var tezosTests = []struct {
+	edsk, edpk, tz1 string
+}{
+	{"edsk3QoqBuvdamxouPhin7swCvkQNgq4jP5KZPbwWNnwdZpSpJiEbq", "edpkvGfYw3LyB1UcCahKQk4rF2tvbMUk8GFiTuMjL75uGXrpvKXhjn", "tz1VSUr8wwNhLAzempoch5d6hLRiTh8Cjcjb"},
+	{"edsk3RFfvaFaxbHx8BMtEW1rKQcPtDML3LXjNqMNLCzC3wLC1bWbAt", "edpkurPsQ8eUApnLUJ9ZPDvu98E8VNj4KtJa1aZr16Cr5ow5VHKnz4", "tz1aSkwEot3L2kmUvcoxzjMomb9mvBNuzFK6"},
+}
+
+func TestTezos(t *testing.T) {
+	for _, test := range tezosTests {
+		privateKey, err := ParseEdsk(test.edsk)
+		if err != nil {
+			t.Errorf("%s: %s", test.edsk, err)
+			continue
+		}
+		publicKey, err := ParseEdpk(test.edpk)
+		if err != nil {
+			t.Errorf("%s: %s", test.edpk, err)
+			continue
+		}
+		if !bytes.Equal(privateKey[32:], publicKey) {
+			t.Errorf("%s: public key doesn't match %s", test.edsk, test.edpk)
+		}
+		if got, err := TezosAddressFromPublicKey(publicKey); err != nil || got != test.tz1 {
+			t.Errorf("TezosAddressFromPublicKey(%s) = %s, %v, want %s", test.edpk, got, err, test.tz1)
+		}
+		if got, err := FormatEdsk(privateKey); err != nil || got != test.edsk {
+			t.Errorf("FormatEdsk = %s, %v, want %s", got, err, test.edsk)
+		}
+		if got, err := FormatEdpk(publicKey); err != nil || got != test.edpk {
+			t.Errorf("FormatEdpk = %s, %v, want %s", got, err, test.edpk)
+		}
+		if hash, err := ParseTezosAddress(test.tz1); err != nil || len(hash) != 20 {
+			t.Errorf("ParseTezosAddress(%s) = %x, %v", test.tz1, hash, err)
+		}
+	}
+
+	// The 98-character form of alice's secret key, which also holds the
+	// public key.
+	privateKey, err := ParseEdsk("edskRpm2mUhvoUjHjXgMoDRxMKhtKfww1ixmWiHCWhHuMEEbGzdnz8Ks4vgarKDtxok7HmrEo1JzkXkdkvyw7Rtw6BNtSd7MJ7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := FormatEdsk(privateKey); got != tezosTests[0].edsk {
+		t.Errorf("long secret key is %s", got)
+	}
+	sig, err := ParseEdsig("edsigty7t5tSGB38FFZ2MXhuNN4XzXMwFy7jK4dFstFRbwGDfH9UriXgZexavqjnspvZJVtCERe5MLXdxwAxu9VUxMxQosxr5M8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Verify(privateKey.Public().(PublicKey), []byte("tezos fixture"), sig) {
+		t.Error("edsig doesn't verify")
+	}
+}
+
+func TestTezosRoundTrip(t *testing.T) {
+	publicKey, privateKey, _ := GenerateKey(rand.Reader)
+	sig, _ := Sign(privateKey, []byte("message"))
+	edsig, err := FormatEdsig(sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := ParseEdsig(edsig); err != nil || !bytes.Equal(got, sig) {
+		t.Errorf("ParseEdsig(%s) = %x, %v", edsig, got, err)
+	}
+	edpk, _ := FormatEdpk(publicKey)
+	if got, err := ParseEdpk(edpk); err != nil || !bytes.Equal(got, publicKey) {
+		t.Errorf("ParseEdpk(%s) = %x, %v", edpk, got, err)
+	}
+
+	if _, err := FormatEdsig(sig[:63]); err != ErrBadSignatureLength {
+		t.Errorf("short signature gave %v", err)
+	}
+	if _, err := FormatEdsk(privateKey[:32]); err != ErrBadPrivateKeyLength {
+		t.Errorf("seed gave %v", err)
+	}
+	if _, err := TezosAddressFromPublicKey(publicKey[:31]); err != ErrBadPublicKeyLength {
+		t.Errorf("short key gave %v", err)
+	}
+}
+
+func TestTezosErrors(t *testing.T) {
+	test := tezosTests[0]
+
+	// Changing any character must be caught by the checksum, or make the
+	// string malformed.
+	for i := 4; i < len(test.edpk); i++ {
+		c := byte('1')
+		if test.edpk[i] == c {
+			c = '2'
+		}
+		bad := test.edpk[:i] + string(c) + test.edpk[i+1:]
+		if _, err := ParseEdpk(bad); err == nil {
+			t.Errorf("ParseEdpk(%s) accepted", bad)
+		}
+	}
+	if _, err := ParseEdpk(test.edpk[:len(test.edpk)-1] + "k"); err != ErrBase58Checksum {
+		t.Errorf("corrupted checksum gave %v", err)
+	}
+
+	// Valid base58check strings of the wrong kind.
+	for _, bad := range []struct {
+		s     string
+		parse func(string) error
+	}{
+		{test.edsk, func(s string) error { _, err := ParseEdpk(s); return err }},
+		{test.tz1, func(s string) error { _, err := ParseEdpk(s); return err }},
+		{test.edpk, func(s string) error { _, err := ParseEdsk(s); return err }},
+		{test.edpk, func(s string) error { _, err := ParseEdsig(s); return err }},
+		{test.edpk, func(s string) error { _, err := ParseTezosAddress(s); return err }},
+		{"", func(s string) error { _, err := ParseEdpk(s); return err }},
+	} {
+		if err := bad.parse(bad.s); err != errBadTezos {
+			t.Errorf("%s gave %v", bad.s, err)
+		}
+	}
+
+	// A 64-byte secret key whose public half is another key.
+	_, private, _ := GenerateKey(rand.Reader)
+	other, _, _ := GenerateKey(rand.Reader)
+	mismatched := encodeTezos("edsk64", append(append([]byte(nil), private[:32]...), other...))
+	if _, err := ParseEdsk(mismatched); err != ErrKeyMismatch {
+		t.Errorf("mismatched secret key gave %v", err)
+	}
+}
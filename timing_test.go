@@ -0,0 +1,98 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build timing
+// +build timing
+
+package ed25519
+
+// This file contains a timing smoke test for the signing path. It is noisy
+// and slow, so it only runs with
+//
+//	go test -tags timing -run TestSigningTiming
+//
+// It can only catch gross leaks, such as a variable-time scalar
+// multiplication; passing it is not evidence that signing is constant time.
+
+import (
+	"crypto/rand"
+	"math"
+	mathrand "math/rand"
+	"sort"
+	"testing"
+	"time"
+)
+
+const (
+	timingSamples = 20000
+	// timingThreshold is the largest acceptable Welch t statistic. dudect
+	// considers values above 10 to be a definite leak.
+	timingThreshold = 10
+)
+
+// TestSigningTiming compares the time taken to sign with a fixed key against
+// the time taken with random keys, following the dudect methodology: the two
+// classes are interleaved at random, the slowest samples are discarded and
+// Welch's t-test is applied to the rest.
+func TestSigningTiming(t *testing.T) {
+	fixed := NewKeyFromSeed(make([]byte, SeedSize))
+	random := make([]PrivateKey, timingSamples)
+	classes := make([]bool, timingSamples)
+	for i := range random {
+		_, priv, err := GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		random[i] = priv
+		classes[i] = mathrand.Intn(2) == 1
+	}
+	message := make([]byte, 64)
+
+	var fixedTimes, randomTimes []float64
+	for i := range random {
+		priv := random[i]
+		if classes[i] {
+			priv = fixed
+		}
+		start := time.Now()
+		Sign(priv, message)
+		elapsed := float64(time.Since(start))
+		if classes[i] {
+			fixedTimes = append(fixedTimes, elapsed)
+		} else {
+			randomTimes = append(randomTimes, elapsed)
+		}
+	}
+
+	tStat := welchT(crop(fixedTimes), crop(randomTimes))
+	t.Logf("t = %.2f over %d samples", tStat, timingSamples)
+	if math.Abs(tStat) > timingThreshold {
+		t.Errorf("signing time depends on the key: t = %.2f", tStat)
+	}
+}
+
+// crop discards the slowest 10% of samples, which are dominated by
+// interrupts and garbage collection.
+func crop(samples []float64) []float64 {
+	sort.Float64s(samples)
+	return samples[:len(samples)*9/10]
+}
+
+func welchT(a, b []float64) float64 {
+	meanA, varA := meanVariance(a)
+	meanB, varB := meanVariance(b)
+	return (meanA - meanB) / math.Sqrt(varA/float64(len(a))+varB/float64(len(b)))
+}
+
+func meanVariance(samples []float64) (mean, variance float64) {
+	for _, x := range samples {
+		mean += x
+	}
+	mean /= float64(len(samples))
+	for _, x := range samples {
+		variance += (x - mean) * (x - mean)
+	}
+	variance /= float64(len(samples) - 1)
+	return mean, variance
+}
@@ -0,0 +1,46 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"crypto"
+	stded25519 "crypto/ed25519"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+)
+
+// TLSCertificate returns a tls.Certificate with the chain certDER, leaf
+// first, and signer as the private key. Like crypto/x509, crypto/tls only
+// signs with an Ed25519 key whose public key is a crypto/ed25519 one, so
+// signer is wrapped as for CreateSelfSignedCert; every CertificateVerify
+// message is then signed by signer itself. The leaf is parsed into Leaf and
+// must have the public key of signer, or the error is ErrKeyMismatch.
+func TLSCertificate(certDER [][]byte, signer crypto.Signer) (tls.Certificate, error) {
+	if len(certDER) == 0 {
+		return tls.Certificate{}, errors.New("ed25519: no certificates")
+	}
+	s, err := newX509Signer(signer)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	leaf, err := x509.ParseCertificate(certDER[0])
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	leafKey, ok := leaf.PublicKey.(stded25519.PublicKey)
+	if !ok {
+		return tls.Certificate{}, errors.New("ed25519: leaf certificate doesn't have an Ed25519 public key")
+	}
+	if subtle.ConstantTimeCompare(leafKey, s.public) != 1 {
+		return tls.Certificate{}, ErrKeyMismatch
+	}
+	return tls.Certificate{
+		Certificate: certDER,
+		PrivateKey:  s,
+		Leaf:        leaf,
+	}, nil
+}
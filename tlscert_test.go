@@ -0,0 +1,113 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"net"
+	"testing"
+)
+
+// TestTLSCertificateHandshake runs a TLS 1.3 handshake with a server whose key
+// is a fakeSigner, which must sign the CertificateVerify message.
+func TestTLSCertificateHandshake(t *testing.T) {
+	_, caKey, _ := GenerateKey(rand.Reader)
+	caDER, err := CreateSelfSignedCert(caKey, &CertificateOptions{Subject: pkix.Name{CommonName: "Test CA"}, IsCA: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, _ := x509.ParseCertificate(caDER)
+
+	// The leaf is issued by the CA with crypto/x509 directly.
+	_, serverKey, _ := GenerateKey(rand.Reader)
+	leafDER, err := CreateSelfSignedCert(serverKey, &CertificateOptions{DNSNames: []string{"server.test"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	template, _ := x509.ParseCertificate(leafDER)
+	leafDER, err = x509.CreateCertificate(rand.Reader, template, ca, template.PublicKey, mustX509Signer(t, caKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer := &fakeSigner{private: serverKey}
+	cert, err := TLSCertificate([][]byte{leafDER, caDER}, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+
+	serverConn, clientConn := net.Pipe()
+	serverErr := make(chan error, 1)
+	go func() {
+		server := tls.Server(serverConn, &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS13,
+		})
+		defer server.Close()
+		if err := server.Handshake(); err != nil {
+			serverErr <- err
+			return
+		}
+		_, err := server.Write([]byte("hello"))
+		serverErr <- err
+	}()
+
+	client := tls.Client(clientConn, &tls.Config{
+		RootCAs:    roots,
+		ServerName: "server.test",
+		MinVersion: tls.VersionTLS13,
+	})
+	defer client.Close()
+	if err := client.Handshake(); err != nil {
+		t.Fatal(err)
+	}
+	got := make([]byte, 5)
+	if _, err := io.ReadFull(client, got); err != nil || string(got) != "hello" {
+		t.Errorf("read %q, %v", got, err)
+	}
+	if err := <-serverErr; err != nil {
+		t.Fatal(err)
+	}
+
+	state := client.ConnectionState()
+	if state.Version != tls.VersionTLS13 || len(state.PeerCertificates) != 2 {
+		t.Errorf("version %#x with %d certificates", state.Version, len(state.PeerCertificates))
+	}
+	if signer.calls != 1 {
+		t.Errorf("signer called %d times, want once for CertificateVerify", signer.calls)
+	}
+}
+
+func TestTLSCertificateErrors(t *testing.T) {
+	_, private, _ := GenerateKey(rand.Reader)
+	_, other, _ := GenerateKey(rand.Reader)
+	der, err := CreateSelfSignedCert(private, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := TLSCertificate([][]byte{der}, private)
+	if err != nil || cert.Leaf == nil {
+		t.Errorf("TLSCertificate = %v, %v", cert.Leaf, err)
+	}
+	if _, err := TLSCertificate([][]byte{der}, other); err != ErrKeyMismatch {
+		t.Errorf("other key gave %v", err)
+	}
+	if _, err := TLSCertificate(nil, private); err == nil {
+		t.Error("empty chain accepted")
+	}
+	if _, err := TLSCertificate([][]byte{der[:len(der)-1]}, private); err == nil {
+		t.Error("truncated certificate accepted")
+	}
+	if _, err := TLSCertificate([][]byte{der}, &fakeSigner{private: private, public: PublicKey(make([]byte, 31))}); err != ErrUnsupportedSigner {
+		t.Errorf("signer with a bad public key gave %v", err)
+	}
+}
@@ -0,0 +1,250 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"crypto/subtle"
+	"hash"
+
+	"github.com/agl/ed25519/edwards25519"
+)
+
+// verifyRules selects between the variants of the Ed25519 verification
+// equation and encoding rules that are in use. The zero value gives the rules
+// used by Verify.
+type verifyRules struct {
+	// cofactored selects the equation [8][S]B = [8]R + [8][k]A instead of
+	// [S]B = R + [k]A. The two differ only for signatures where R or A have
+	// a small-order component.
+	cofactored bool
+
+	// nonCanonicalR accepts any R that decodes to a point, including
+	// encodings with y >= p or with the sign bit set when x = 0, and hashes
+	// R exactly as it was encoded.
+	nonCanonicalR bool
+
+	// strictPoints rejects non-canonical encodings of A and R and points
+	// of small order.
+	strictPoints bool
+
+	// newHash, if not nil, replaces SHA-512 for computing the challenge.
+	// It is only set by a Suite.
+	newHash func() hash.Hash
+}
+
+var scOne = [32]byte{1}
+
+// Verify reports whether sig is a valid signature of message by publicKey. It
+// returns false, rather than panicking, if publicKey or sig have the wrong
+// length. Use CheckSignature to find out why a signature was rejected.
+//
+// Verify uses the cofactorless equation [S]B = R + [k]A and requires R to be
+// encoded exactly as the signer would have encoded it. It rejects S >= L, as
+// do all the verification functions in this package, so a valid signature
+// cannot be turned into a second valid signature by adding L to S.
+func Verify(publicKey PublicKey, message, sig []byte) bool {
+	return verify(publicKey, message, sig, nil, verifyRules{}) == nil
+}
+
+// CheckSignature is like Verify but returns nil if sig is valid and otherwise
+// an error saying why it is not: ErrBadPublicKeyLength,
+// ErrBadSignatureLength, ErrInvalidPublicKey or ErrInvalidSignature.
+func CheckSignature(publicKey PublicKey, message, sig []byte) error {
+	return verify(publicKey, message, sig, nil, verifyRules{})
+}
+
+// VerifyCofactored is like Verify but uses the cofactored equation
+// [8][S]B = [8]R + [8][k]A permitted by RFC 8032, which is the equation that
+// batch verification checks. It accepts every signature that Verify accepts,
+// and additionally some where R or publicKey have a small-order component.
+// The encoding rules are the same as for Verify.
+func VerifyCofactored(publicKey PublicKey, message, sig []byte) bool {
+	return verify(publicKey, message, sig, nil, verifyRules{cofactored: true}) == nil
+}
+
+// VerifyZIP215 is like Verify but implements the precise rules of ZIP-215,
+// which Zcash adopted so that single and batch verification agree on every
+// input:
+//
+//   - publicKey and R may be any encoding of a point on the curve, including
+//     non-canonical ones, and are hashed exactly as encoded;
+//   - S must be canonical, that is S < L, as in every mode;
+//   - the cofactored equation [8][S]B = [8]R + [8][k]A must hold.
+//
+// BatchVerifier applies the same rules. See
+// https://zips.z.cash/zip-0215.
+func VerifyZIP215(publicKey PublicKey, message, sig []byte) bool {
+	return verify(publicKey, message, sig, nil, verifyRules{
+		cofactored:    true,
+		nonCanonicalR: true,
+	}) == nil
+}
+
+// StrictVerify is like Verify but accepts the narrowest set of signatures,
+// matching libsodium when built without ED25519_COMPAT. In addition to the
+// checks made by Verify it rejects
+//
+//   - non-canonical encodings of publicKey or R;
+//   - publicKey or R of small order.
+//
+// It uses the cofactorless equation, so it also rejects every signature with a
+// low order residue. Signatures where A or R merely have a small-order
+// component are accepted if the cofactorless equation holds.
+func StrictVerify(publicKey PublicKey, message, sig []byte) bool {
+	return verify(publicKey, message, sig, nil, verifyRules{
+		strictPoints: true,
+	}) == nil
+}
+
+// VerifyWithOptions reports whether sig is a valid signature of message by
+// publicKey, returning nil if it is and an error otherwise. With a nil opts
+// or the zero Options it is equivalent to CheckSignature.
+//
+// opts.Hash and opts.Context select the variant exactly as for
+// crypto/ed25519.VerifyWithOptions: if opts.Hash is crypto.SHA512, the
+// pre-hashed variant Ed25519ph is used and message is expected to be a
+// SHA-512 hash, and otherwise opts.Hash must be crypto.Hash(0) and a
+// non-empty opts.Context selects Ed25519ctx. In addition opts.Mode selects
+// the verification rules, which are those of Verify by default.
+//
+// Unlike crypto/ed25519, VerifyWithOptions does not panic if publicKey has
+// the wrong length, but returns ErrBadPublicKeyLength. Invalid signatures
+// are reported with the errors listed for CheckSignature.
+func VerifyWithOptions(publicKey PublicKey, message, sig []byte, opts *Options) error {
+	rules, err := opts.rules()
+	if err != nil {
+		return err
+	}
+	dom, err := opts.dom(message)
+	if err != nil {
+		return err
+	}
+
+	return verify(publicKey, message, sig, dom, rules)
+}
+
+// isCanonicalEncoding reports whether s is the canonical encoding of p, which
+// must have been decoded from it.
+func isCanonicalEncoding(p *edwards25519.ExtendedGroupElement, s *[32]byte) bool {
+	var check [32]byte
+	p.ToBytes(&check)
+	return subtle.ConstantTimeCompare(s[:], check[:]) == 1
+}
+
+// computeK sets k = SHA-512(dom || R || A || message) mod L, where dom is the
+// dom2 prefix, which is empty for regular Ed25519.
+func computeK(k *[32]byte, dom, R, A, message []byte) {
+	st := getSHA512()
+	computeKWith(st, k, dom, R, A, message)
+	putSHA512(st)
+}
+
+// computeKWith is like computeK but uses st, whose hash must be freshly reset
+// and have a 64-byte output, usually SHA-512. R and A must not be on the
+// stack, or they escape; they may alias st.buf.
+func computeKWith(st *hashState, k *[32]byte, dom, R, A, message []byte) {
+	st.h.Write(dom)
+	st.h.Write(R)
+	st.h.Write(A)
+	st.h.Write(message)
+	st.h.Sum(st.digest[:0])
+	edwards25519.ScReduce(k, &st.digest)
+}
+
+// verify checks sig under rules, returning nil if it is valid. dom is the
+// dom2 prefix, which is empty for regular Ed25519.
+func verify(publicKey PublicKey, message, sig, dom []byte, rules verifyRules) error {
+	return verifyReason(publicKey, message, sig, dom, rules).err()
+}
+
+// verifyReason is like verify but returns the reason sig was rejected, or
+// FailureNone if it is valid.
+func verifyReason(publicKey PublicKey, message, sig, dom []byte, rules verifyRules) VerifyFailure {
+	if len(publicKey) != PublicKeySize {
+		return FailurePublicKeyLength
+	}
+	if len(sig) != SignatureSize {
+		return FailureSignatureLength
+	}
+
+	var s [32]byte
+	copy(s[:], sig[32:])
+	if edwards25519.ScIsCanonical(&s) != 1 {
+		return FailureNonCanonicalS
+	}
+
+	var A edwards25519.ExtendedGroupElement
+	var publicKeyBytes [32]byte
+	copy(publicKeyBytes[:], publicKey)
+	if !A.FromBytes(&publicKeyBytes) {
+		return FailurePublicKeyNotOnCurve
+	}
+	if rules.strictPoints {
+		if !isCanonicalEncoding(&A, &publicKeyBytes) {
+			return FailureNonCanonicalPublicKey
+		}
+		if A.IsSmallOrder() {
+			return FailureSmallOrderPublicKey
+		}
+
+		var R edwards25519.ExtendedGroupElement
+		var RBytes [32]byte
+		copy(RBytes[:], sig[:32])
+		if !R.FromBytes(&RBytes) {
+			return FailureRNotOnCurve
+		}
+		if !isCanonicalEncoding(&R, &RBytes) {
+			return FailureNonCanonicalR
+		}
+		if R.IsSmallOrder() {
+			return FailureSmallOrderR
+		}
+	}
+	edwards25519.FeNeg(&A.X, &A.X)
+	edwards25519.FeNeg(&A.T, &A.T)
+
+	var hReduced [32]byte
+	if rules.newHash != nil {
+		computeKWith(&hashState{h: rules.newHash()}, &hReduced, dom, sig[:32], publicKey, message)
+	} else {
+		computeK(&hReduced, dom, sig[:32], publicKey, message)
+	}
+
+	if !rules.cofactored {
+		var R edwards25519.ProjectiveGroupElement
+		edwards25519.GeDoubleScalarMultVartime(&R, &hReduced, &A, &s)
+
+		var checkR [32]byte
+		R.ToBytes(&checkR)
+		if subtle.ConstantTimeCompare(sig[:32], checkR[:]) != 1 {
+			return FailureEquation
+		}
+		return FailureNone
+	}
+
+	var R edwards25519.ExtendedGroupElement
+	var RBytes [32]byte
+	copy(RBytes[:], sig[:32])
+	if !R.FromBytes(&RBytes) {
+		return FailureRNotOnCurve
+	}
+	if !rules.nonCanonicalR && !isCanonicalEncoding(&R, &RBytes) {
+		return FailureNonCanonicalR
+	}
+	edwards25519.FeNeg(&R.X, &R.X)
+	edwards25519.FeNeg(&R.T, &R.T)
+
+	// check = [S]B - [k]A - R, which must be in the small-order subgroup.
+	var check edwards25519.ProjectiveGroupElement
+	edwards25519.GeMultiScalarMultVartime(&check,
+		[][32]byte{hReduced, scOne},
+		[]edwards25519.ExtendedGroupElement{A, R}, &s)
+	check.MultByCofactor()
+
+	if !check.IsIdentity() {
+		return FailureEquation
+	}
+	return FailureNone
+}
@@ -0,0 +1,479 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"crypto"
+	stded25519 "crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+)
+
+// mixedOrderVectors are signatures where R and A have small-order components
+// such that [S]B = R + [k]A does not hold but [8][S]B = [8]R + [8][k]A does.
+// They are taken from the ed25519vectors corpus
+// (filippo.io/mostly-harmless/ed25519vectors), which exercises the same
+// LowOrderComponent/LowOrderResidue classes as the "Taming the many EdDSAs"
+// paper.
+var mixedOrderVectors = []struct {
+	A, R, S, M string
+}{
+	{
+		"10eb7c3acfb2bed3e0d6ab89bf5a3d6afddd1176ce4812e38d9fd485058fdb1f",
+		"36684ea91032ba5b1dbab2d02f4debc74c3327f2b3802e2e4d371aa42b12b56b",
+		"f46326ed9059dbe9d56b405e4f0474120d279ef694a23727ad207a27ae80f80b",
+		"use ristretto255 2",
+	},
+	{
+		"10eb7c3acfb2bed3e0d6ab89bf5a3d6afddd1176ce4812e38d9fd485058fdb1f",
+		"b62cf890de42c413b11b1411c9f01f1c4d77aa87ef182258d1251f69af2a3506",
+		"3e3f17582928fdeaf7749f91e0fe9cc5235651dba7954469815347e695be3b00",
+		"use ristretto255 3",
+	},
+	{
+		"10eb7c3acfb2bed3e0d6ab89bf5a3d6afddd1176ce4812e38d9fd485058fdb1f",
+		"37d3076f21bd3bec4ee4ebee360fe0e3b288557810e7dda72edae09650d5caf9",
+		"e4903a982a72c37d6d4a94dfd8a4c2b0f9ad04af4bc2968a12d98eb2d6498f0c",
+		"use ristretto255",
+	},
+}
+
+func decodeHex(t testing.TB, s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestVerifyCofactored(t *testing.T) {
+	for i, v := range mixedOrderVectors {
+		public := PublicKey(decodeHex(t, v.A))
+		sig := append(decodeHex(t, v.R), decodeHex(t, v.S)...)
+		message := []byte(v.M)
+
+		if Verify(public, message, sig) {
+			t.Errorf("#%d: cofactorless verification accepted a low order residue", i)
+		}
+		if !VerifyCofactored(public, message, sig) {
+			t.Errorf("#%d: cofactored verification rejected a low order residue", i)
+		}
+
+		var batch BatchVerifier
+		if err := batch.Add(public, message, sig); err != nil {
+			t.Fatalf("#%d: Add: %s", i, err)
+		}
+		if !batch.VerifyAll() {
+			t.Errorf("#%d: batch verification disagrees with cofactored verification", i)
+		}
+	}
+}
+
+func TestVerifyCofactoredRegularSignatures(t *testing.T) {
+	for _, m := range newSignedMessages(t, 8) {
+		if !VerifyCofactored(m.public, m.message, m.sig) {
+			t.Errorf("valid signature rejected")
+		}
+		if VerifyCofactored(m.public, []byte("tampered"), m.sig) {
+			t.Errorf("signature of different message accepted")
+		}
+	}
+}
+
+// smallOrderEncodings are the fourteen encodings, canonical and not, of the
+// eight points of small order, as listed by the ed25519-zebra tests. Every
+// combination of them as A and R, with S = 0 and the message "Zcash", forms
+// the 196 cases of the ZIP-215 conformance set that ed25519-zebra publishes,
+// all of which ZIP-215 accepts.
+var smallOrderEncodings = []string{
+	"0100000000000000000000000000000000000000000000000000000000000000",
+	"ecffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f",
+	"0000000000000000000000000000000000000000000000000000000000000080",
+	"0000000000000000000000000000000000000000000000000000000000000000",
+	"c7176a703d4dd84fba3c0b760d10670f2a2053fa2c39ccc64ec7fd7792ac037a",
+	"c7176a703d4dd84fba3c0b760d10670f2a2053fa2c39ccc64ec7fd7792ac03fa",
+	"26e8958fc2b227b045c3f489f2ef98f0d5dfac05d3c63339b13802886d53fc05",
+	"26e8958fc2b227b045c3f489f2ef98f0d5dfac05d3c63339b13802886d53fc85",
+	// Non-canonical encodings.
+	"0100000000000000000000000000000000000000000000000000000000000080",
+	"ecffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+	"eeffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f",
+	"eeffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+	"edffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f",
+	"edffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+}
+
+func TestZIP215(t *testing.T) {
+	message := []byte("Zcash")
+	var batch BatchVerifier
+
+	for i, A := range smallOrderEncodings {
+		for j, R := range smallOrderEncodings {
+			public := PublicKey(decodeHex(t, A))
+			sig := append(decodeHex(t, R), make([]byte, 32)...)
+
+			if !VerifyZIP215(public, message, sig) {
+				t.Errorf("A #%d, R #%d: rejected", i, j)
+			}
+			if err := batch.Add(public, message, sig); err != nil {
+				t.Errorf("A #%d, R #%d: batch rejected encoding: %s", i, j, err)
+			}
+		}
+	}
+
+	if batch.Len() != 196 {
+		t.Fatalf("expected 196 cases, got %d", batch.Len())
+	}
+	if !batch.VerifyAll() {
+		t.Errorf("batch verification disagrees with VerifyZIP215")
+	}
+}
+
+func TestZIP215RegularSignatures(t *testing.T) {
+	for _, m := range newSignedMessages(t, 8) {
+		if !VerifyZIP215(m.public, m.message, m.sig) {
+			t.Errorf("valid signature rejected")
+		}
+		if VerifyZIP215(m.public, []byte("tampered"), m.sig) {
+			t.Errorf("signature of different message accepted")
+		}
+	}
+
+	for i, v := range mixedOrderVectors {
+		public := PublicKey(decodeHex(t, v.A))
+		sig := append(decodeHex(t, v.R), decodeHex(t, v.S)...)
+		if !VerifyZIP215(public, []byte(v.M), sig) {
+			t.Errorf("#%d: low order residue rejected", i)
+		}
+	}
+}
+
+func TestStrictVerify(t *testing.T) {
+	for _, m := range newSignedMessages(t, 8) {
+		if !StrictVerify(m.public, m.message, m.sig) {
+			t.Errorf("valid signature rejected")
+		}
+		if StrictVerify(m.public, []byte("tampered"), m.sig) {
+			t.Errorf("signature of different message accepted")
+		}
+	}
+}
+
+// TestVerificationModes pins the accept/reject decision of every
+// verification mode on the edge-case corpora above.
+func TestVerificationModes(t *testing.T) {
+	modes := []struct {
+		name   string
+		verify func(PublicKey, []byte, []byte) bool
+		// Expected number of accepted combinations of small-order A and
+		// R with S = 0, split by which of A and R have canonical
+		// encodings: both (64 cases), only R (48 cases), only A (48
+		// cases) and neither (36 cases).
+		smallOrder [4]int
+		// Expected result for the mixed-order vectors.
+		mixedOrder bool
+	}{
+		// The cofactorless equation holds for S = 0 only in the few
+		// cases where R happens to equal -[k]A.
+		{"Verify", Verify, [4]int{8, 4, 0, 0}, false},
+		{"VerifyCofactored", VerifyCofactored, [4]int{64, 48, 0, 0}, true},
+		{"VerifyZIP215", VerifyZIP215, [4]int{64, 48, 48, 36}, true},
+		{"StrictVerify", StrictVerify, [4]int{0, 0, 0, 0}, false},
+	}
+
+	message := []byte("Zcash")
+	for _, mode := range modes {
+		var accepted [4]int
+		for i, A := range smallOrderEncodings {
+			for j, R := range smallOrderEncodings {
+				public := PublicKey(decodeHex(t, A))
+				sig := append(decodeHex(t, R), make([]byte, 32)...)
+
+				// The first eight encodings are canonical.
+				class := 0
+				if i >= 8 {
+					class |= 1
+				}
+				if j >= 8 {
+					class |= 2
+				}
+				if mode.verify(public, message, sig) {
+					accepted[class]++
+				}
+			}
+		}
+		if accepted != mode.smallOrder {
+			t.Errorf("%s: accepted %v small-order cases, want %v", mode.name, accepted, mode.smallOrder)
+		}
+
+		for i, v := range mixedOrderVectors {
+			public := PublicKey(decodeHex(t, v.A))
+			sig := append(decodeHex(t, v.R), decodeHex(t, v.S)...)
+			if got := mode.verify(public, []byte(v.M), sig); got != mode.mixedOrder {
+				t.Errorf("%s: mixed order #%d: got %v, want %v", mode.name, i, got, mode.mixedOrder)
+			}
+		}
+	}
+}
+
+// ed25519Vector is an entry of testdata/ed25519vectors.json, the corpus of
+// filippo.io/mostly-harmless/ed25519vectors, copied unchanged. Flags describe
+// the edge cases that each signature exercises.
+type ed25519Vector struct {
+	A, R, S, M string
+	Flags      []string
+}
+
+func (v *ed25519Vector) has(flag string) bool {
+	for _, f := range v.Flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// TestEd25519Vectors checks every verification mode against the
+// ed25519vectors corpus. All of its signatures are valid under ZIP-215, which
+// the corpus's own tests check against ed25519consensus, and each other mode
+// must reject exactly the classes its documentation lists.
+func TestEd25519Vectors(t *testing.T) {
+	var vectors []ed25519Vector
+	if err := json.Unmarshal(readTestdata(t, "ed25519vectors.json"), &vectors); err != nil {
+		t.Fatal(err)
+	}
+	if len(vectors) == 0 {
+		t.Fatal("no test vectors found")
+	}
+
+	smallOrder := make(map[string]bool)
+	for _, e := range smallOrderEncodings {
+		smallOrder[e] = true
+	}
+
+	modes := []struct {
+		name   string
+		verify func(PublicKey, []byte, []byte) bool
+		accept func(v *ed25519Vector) bool
+	}{
+		// The same set as crypto/ed25519.
+		{"Verify", Verify, func(v *ed25519Vector) bool {
+			return !v.has("LowOrderResidue") && !v.has("NonCanonicalR")
+		}},
+		{"VerifyCofactored", VerifyCofactored, func(v *ed25519Vector) bool {
+			return !v.has("NonCanonicalR")
+		}},
+		{"VerifyZIP215", VerifyZIP215, func(v *ed25519Vector) bool {
+			return true
+		}},
+		{"StrictVerify", StrictVerify, func(v *ed25519Vector) bool {
+			return !v.has("LowOrderResidue") && !v.has("NonCanonicalR") &&
+				!v.has("NonCanonicalA") && !v.has("LowOrderR") && !v.has("LowOrderA")
+		}},
+	}
+
+	var batch BatchVerifier
+	for i, v := range vectors {
+		if v.has("LowOrderA") && !smallOrder[v.A] || v.has("LowOrderR") && !smallOrder[v.R] {
+			t.Errorf("#%d: small-order encoding missing from smallOrderEncodings", i)
+		}
+
+		public := PublicKey(decodeHex(t, v.A))
+		sig := append(decodeHex(t, v.R), decodeHex(t, v.S)...)
+		for _, mode := range modes {
+			if got, want := mode.verify(public, []byte(v.M), sig), mode.accept(&v); got != want {
+				t.Errorf("#%d %v: %s = %v, want %v", i, v.Flags, mode.name, got, want)
+			}
+		}
+		if err := batch.Add(public, []byte(v.M), sig); err != nil {
+			t.Errorf("#%d: batch rejected encoding: %s", i, err)
+		}
+	}
+	if !batch.VerifyAll() {
+		t.Errorf("batch verification disagrees with VerifyZIP215")
+	}
+}
+
+// addL returns s + L, which is congruent to s but not canonical.
+func addL(s []byte) []byte {
+	out := make([]byte, 32)
+	var carry int
+	for i := range out {
+		sum := int(s[i]) + int(scMinusOne[i]) + carry
+		if i == 0 {
+			sum++ // L = (L-1) + 1
+		}
+		out[i] = byte(sum)
+		carry = sum >> 8
+	}
+	return out
+}
+
+func TestMalleability(t *testing.T) {
+	for _, m := range newSignedMessages(t, 8) {
+		malleated := append(append([]byte(nil), m.sig[:32]...), addL(m.sig[32:])...)
+
+		for _, mode := range []struct {
+			name   string
+			verify func(PublicKey, []byte, []byte) bool
+		}{
+			{"Verify", Verify},
+			{"VerifyCofactored", VerifyCofactored},
+			{"VerifyZIP215", VerifyZIP215},
+			{"StrictVerify", StrictVerify},
+		} {
+			if !mode.verify(m.public, m.message, m.sig) {
+				t.Errorf("%s: valid signature rejected", mode.name)
+			}
+			if mode.verify(m.public, m.message, malleated) {
+				t.Errorf("%s: signature with S + L accepted", mode.name)
+			}
+		}
+
+		var batch BatchVerifier
+		if err := batch.Add(m.public, m.message, malleated); err == nil {
+			t.Errorf("batch accepted signature with S + L")
+		}
+	}
+}
+
+func TestVerifyWithOptionsStdlibParity(t *testing.T) {
+	message := []byte("VerifyWithOptions")
+	digest := sha512.Sum512(message)
+
+	for i := 0; i < 16; i++ {
+		stdPublic, stdPrivate, err := stded25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		public := PublicKey(stdPublic)
+
+		for _, mode := range signingModes {
+			msg := message
+			if mode.prehash {
+				msg = digest[:]
+			}
+			sig, err := stdPrivate.Sign(nil, msg, mode.stdOpts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			otherMsg := append([]byte(nil), msg...)
+			otherMsg[0] ^= 1
+			otherSig := append([]byte(nil), sig...)
+			otherSig[40] ^= 1
+			otherContext := *mode.stdOpts
+			otherContext.Context += "x"
+
+			for _, test := range []struct {
+				name    string
+				msg     []byte
+				sig     []byte
+				stdOpts *stded25519.Options
+			}{
+				{"valid", msg, sig, mode.stdOpts},
+				{"wrong message", otherMsg, sig, mode.stdOpts},
+				{"tampered signature", msg, otherSig, mode.stdOpts},
+				{"wrong context", msg, sig, &otherContext},
+				{"pure Ed25519", msg, sig, &stded25519.Options{}},
+				{"Ed25519ph", msg, sig, &stded25519.Options{Hash: crypto.SHA512}},
+			} {
+				opts := &Options{Hash: test.stdOpts.Hash, Context: test.stdOpts.Context}
+				got := VerifyWithOptions(public, test.msg, test.sig, opts)
+				want := stded25519.VerifyWithOptions(stdPublic, test.msg, test.sig, test.stdOpts)
+				if (got == nil) != (want == nil) {
+					t.Errorf("%s, %s: got %v, crypto/ed25519 returned %v", mode.name, test.name, got, want)
+				}
+				if got != nil && want != nil && got.Error() != want.Error() {
+					t.Errorf("%s, %s: got error %q, crypto/ed25519 returned %q", mode.name, test.name, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestVerifyWithOptionsErrors(t *testing.T) {
+	public, private, _ := GenerateKey(rand.Reader)
+	message := []byte("message")
+	sig := mustSign(t, private, message)
+
+	for _, test := range []struct {
+		name    string
+		public  PublicKey
+		message []byte
+		opts    *Options
+	}{
+		{"SHA-256", public, message, &Options{Hash: crypto.SHA256}},
+		{"Ed25519ph with a short digest", public, message, &Options{Hash: crypto.SHA512}},
+		{"long Ed25519ctx context", public, message, &Options{Context: string(make([]byte, 256))}},
+		{"long Ed25519ph context", public, make([]byte, 64), &Options{Hash: crypto.SHA512, Context: string(make([]byte, 256))}},
+		{"unknown mode", public, message, &Options{Mode: ModeStrict + 1}},
+		{"negative mode", public, message, &Options{Mode: -1}},
+	} {
+		err := VerifyWithOptions(test.public, test.message, sig, test.opts)
+		if err == nil || err == ErrInvalidSignature {
+			t.Errorf("%s: got %v, want an options error", test.name, err)
+		}
+	}
+
+	if err := VerifyWithOptions(public[:31], message, sig, nil); err != ErrBadPublicKeyLength {
+		t.Errorf("short public key: got %v, want ErrBadPublicKeyLength", err)
+	}
+	if err := VerifyWithOptions(public, message, sig[:63], &Options{}); err != ErrBadSignatureLength {
+		t.Errorf("short signature: got %v, want ErrBadSignatureLength", err)
+	}
+	if err := VerifyWithOptions(public, []byte("other"), sig, &Options{Mode: ModeZIP215}); err != ErrInvalidSignature {
+		t.Errorf("wrong message: got %v, want ErrInvalidSignature", err)
+	}
+	smallOrder := PublicKey(decodeHex(t, smallOrderEncodings[0]))
+	if err := VerifyWithOptions(smallOrder, message, sig, &Options{Mode: ModeStrict}); err != ErrInvalidPublicKey {
+		t.Errorf("small-order key in strict mode: got %v, want ErrInvalidPublicKey", err)
+	}
+}
+
+func TestVerifyWithOptionsModes(t *testing.T) {
+	for _, test := range []struct {
+		mode  VerifyMode
+		mixed bool
+	}{
+		{ModeDefault, false},
+		{ModeCofactored, true},
+		{ModeZIP215, true},
+		{ModeStrict, false},
+	} {
+		for i, v := range mixedOrderVectors {
+			public := PublicKey(decodeHex(t, v.A))
+			sig := append(decodeHex(t, v.R), decodeHex(t, v.S)...)
+			err := VerifyWithOptions(public, []byte(v.M), sig, &Options{Mode: test.mode})
+			if (err == nil) != test.mixed {
+				t.Errorf("mode %d: mixed order #%d: got %v", test.mode, i, err)
+			}
+		}
+
+		// Every mode accepts regular signatures in every variant.
+		public, private, _ := GenerateKey(rand.Reader)
+		digest := sha512.Sum512([]byte("message"))
+		for _, signing := range signingModes {
+			msg := []byte("message")
+			if signing.prehash {
+				msg = digest[:]
+			}
+			sig, err := SignWithRand(nil, private, msg, signing.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			opts := &Options{Mode: test.mode}
+			if signing.opts != nil {
+				opts.Hash, opts.Context = signing.opts.Hash, signing.opts.Context
+			}
+			if err := VerifyWithOptions(public, msg, sig, opts); err != nil {
+				t.Errorf("mode %d: %s: %v", test.mode, signing.name, err)
+			}
+		}
+	}
+}
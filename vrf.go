@@ -0,0 +1,265 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"crypto/sha512"
+	"errors"
+
+	"github.com/agl/ed25519/edwards25519"
+)
+
+// The ECVRF of RFC 9381 proves that Gamma = xH, where H is the input alpha
+// hashed to the curve together with the public key, with a Chaum-Pedersen
+// proof (c, s) whose challenge c is truncated to 16 bytes. The output beta is
+// a hash of the cofactor times Gamma, so it depends only on the key and
+// alpha, never on the proof.
+
+const (
+	// VRFProofSize is the size, in bytes, of a VRF proof pi.
+	VRFProofSize = 80
+	// VRFOutputSize is the size, in bytes, of a VRF output beta.
+	VRFOutputSize = 64
+)
+
+var (
+	errBadVRFProof   = errors.New("ed25519: malformed VRF proof")
+	errVRFHashFailed = errors.New("ed25519: VRF input could not be hashed to the curve")
+)
+
+// VRF is an elliptic curve verifiable random function on edwards25519. The
+// holder of a private key can compute a pseudorandom output for any input and
+// prove that it did so correctly, and anyone with the public key can check
+// the proof and obtain the same output.
+type VRF struct {
+	suite byte
+	// draft05 leaves the public key and the trailing zero byte out of the
+	// challenge, and the trailing zero byte out of the output hash.
+	draft05 bool
+	// encodeToCurve returns ECVRF_encode_to_curve of salt and alpha, a
+	// point of prime order.
+	encodeToCurve func(suite byte, salt, alpha []byte) (*edwards25519.Point, error)
+}
+
+// NewVRFTAI returns ECVRF-EDWARDS25519-SHA512-TAI, suite 3 of RFC 9381,
+// which hashes to the curve by try and increment.
+func NewVRFTAI() *VRF {
+	return &VRF{suite: 0x03, encodeToCurve: vrfEncodeToCurveTAI}
+}
+
+// NewVRFELL2 returns ECVRF-EDWARDS25519-SHA512-ELL2, suite 4 of RFC 9381,
+// which hashes to the curve with the edwards25519_XMD:SHA-512_ELL2_NU_ suite
+// of RFC 9380. Unlike NewVRFTAI it runs in constant time in alpha.
+func NewVRFELL2() *VRF {
+	return &VRF{suite: 0x04, encodeToCurve: vrfEncodeToCurveELL2}
+}
+
+// vrfEncodeToCurveELL2 implements ECVRF_encode_to_curve_h2c_suite of
+// RFC 9381, Section 5.4.1.2.
+func vrfEncodeToCurveELL2(suite byte, salt, alpha []byte) (*edwards25519.Point, error) {
+	dst := append([]byte("ECVRF_edwards25519_XMD:SHA-512_ELL2_NU_"), suite)
+	msg := append(append(make([]byte, 0, len(salt)+len(alpha)), salt...), alpha...)
+	return encodeToCurveEdwards25519(msg, dst)
+}
+
+// NewVRFDraft05 returns ECVRF-ED25519-SHA512-Elligator2 of
+// draft-irtf-cfrg-vrf-05, which is unchanged since draft 03 and implemented
+// by the crypto_vrf_ietfdraft03 functions of Algorand's libsodium fork, for
+// checking proofs made by systems built on it. It is not interoperable with
+// RFC 9381: alpha is hashed to the curve with one SHA-512 hash, whose sign bit
+// is cleared, and the Elligator 2 map of the drafts, the challenge doesn't
+// cover the public key, and there is no trailing zero byte in the hashes.
+// The nonce, the 16-byte challenge and the 80-byte layout of the proof are
+// the same.
+//
+// New systems should use NewVRFELL2 or NewVRFTAI instead.
+func NewVRFDraft05() *VRF {
+	return &VRF{suite: 0x04, draft05: true, encodeToCurve: vrfEncodeToCurveDraft05}
+}
+
+// vrfEncodeToCurveDraft05 implements ECVRF_hash_to_curve_elligator2_25519 of
+// draft-irtf-cfrg-vrf-05, Section 5.4.1.2.
+func vrfEncodeToCurveDraft05(suite byte, salt, alpha []byte) (*edwards25519.Point, error) {
+	h := sha512.New()
+	h.Write([]byte{suite, 0x01})
+	h.Write(salt)
+	h.Write(alpha)
+	var r [32]byte
+	copy(r[:], h.Sum(nil))
+
+	// FeFromBytes ignores the top bit, which the draft clears. The result
+	// is the point with y = (u-1)/(u+1) and a sign of 0.
+	var fr, u edwards25519.FieldElement
+	edwards25519.FeFromBytes(&fr, &r)
+	elligator2(&u, &fr)
+	edwards25519.FeToBytes(&r, &u)
+	P, err := edwards25519.MontgomeryToEdwards(r, 0)
+	if err != nil {
+		return nil, errVRFHashFailed
+	}
+	return P.MultByCofactor(P), nil
+}
+
+// vrfEncodeToCurveTAI implements ECVRF_encode_to_curve_try_and_increment of
+// RFC 9381, Section 5.4.1.1.
+func vrfEncodeToCurveTAI(suite byte, salt, alpha []byte) (*edwards25519.Point, error) {
+	var digest [64]byte
+	for ctr := 0; ctr < 256; ctr++ {
+		h := sha512.New()
+		h.Write([]byte{suite, 0x01})
+		h.Write(salt)
+		h.Write(alpha)
+		h.Write([]byte{byte(ctr), 0x00})
+		h.Sum(digest[:0])
+		if P, err := new(edwards25519.Point).SetCanonicalBytes(digest[:32]); err == nil {
+			return P.MultByCofactor(P), nil
+		}
+	}
+	return nil, errVRFHashFailed
+}
+
+// challenge implements ECVRF_challenge_generation of RFC 9381, Section
+// 5.4.3, and returns c as a scalar.
+func (v *VRF) challenge(Y, H, Gamma, U, V *edwards25519.Point) *edwards25519.Scalar {
+	h := sha512.New()
+	h.Write([]byte{v.suite, 0x02})
+	if !v.draft05 {
+		h.Write(Y.Bytes())
+	}
+	for _, P := range []*edwards25519.Point{H, Gamma, U, V} {
+		h.Write(P.Bytes())
+	}
+	if !v.draft05 {
+		h.Write([]byte{0x00})
+	}
+	var c [32]byte
+	copy(c[:16], h.Sum(nil))
+	s, _ := edwards25519.NewScalar().SetCanonicalBytes(c[:])
+	return s
+}
+
+// proofToHash returns beta for Gamma, as in ECVRF_proof_to_hash of RFC 9381,
+// Section 5.2.
+func (v *VRF) proofToHash(Gamma *edwards25519.Point) []byte {
+	h := sha512.New()
+	h.Write([]byte{v.suite, 0x03})
+	h.Write(new(edwards25519.Point).MultByCofactor(Gamma).Bytes())
+	if !v.draft05 {
+		h.Write([]byte{0x00})
+	}
+	return h.Sum(nil)
+}
+
+// decodeVRFProof implements ECVRF_decode_proof of RFC 9381, Section 5.4.4.
+func decodeVRFProof(pi []byte) (Gamma *edwards25519.Point, c, s *edwards25519.Scalar, err error) {
+	if len(pi) != VRFProofSize {
+		return nil, nil, nil, errBadVRFProof
+	}
+	Gamma, err = new(edwards25519.Point).SetCanonicalBytes(pi[:32])
+	if err != nil {
+		return nil, nil, nil, errBadVRFProof
+	}
+	var cBytes [32]byte
+	copy(cBytes[:16], pi[32:48])
+	c, _ = edwards25519.NewScalar().SetCanonicalBytes(cBytes[:])
+	s, err = edwards25519.NewScalar().SetCanonicalBytes(pi[48:])
+	if err != nil {
+		return nil, nil, nil, errBadVRFProof
+	}
+	return Gamma, c, s, nil
+}
+
+// Prove returns the proof pi that beta, which ProofToHash extracts from it,
+// is the VRF output of privateKey for alpha. Proofs are deterministic: the
+// nonce is derived from the private key and alpha as in RFC 8032.
+func (v *VRF) Prove(privateKey PrivateKey, alpha []byte) ([]byte, error) {
+	k, err := privateKey.Expand()
+	if err != nil {
+		return nil, err
+	}
+	defer k.Wipe()
+
+	Y, err := new(edwards25519.Point).SetCanonicalBytes(k.publicKey[:])
+	if err != nil {
+		return nil, ErrInvalidPublicKey
+	}
+	H, err := v.encodeToCurve(v.suite, k.publicKey[:], alpha)
+	if err != nil {
+		return nil, err
+	}
+	hBytes := H.Bytes()
+
+	// H has prime order, so the clamped scalar can be reduced.
+	var wide [64]byte
+	copy(wide[:], k.scalar[:])
+	x, _ := edwards25519.NewScalar().SetUniformBytes(wide[:])
+	wipeBytes(wide[:])
+	Gamma := new(edwards25519.Point).ScalarMult(x, H)
+
+	// ECVRF_nonce_generation_RFC8032 of Section 5.4.2.2.
+	h := sha512.New()
+	h.Write(k.prefix[:])
+	h.Write(hBytes)
+	h.Sum(wide[:0])
+	nonce, _ := edwards25519.NewScalar().SetUniformBytes(wide[:])
+	wipeBytes(wide[:])
+
+	U := new(edwards25519.Point).ScalarBaseMult(nonce)
+	V := new(edwards25519.Point).ScalarMult(nonce, H)
+	c := v.challenge(Y, H, Gamma, U, V)
+	s := edwards25519.NewScalar().MultiplyAdd(c, x, nonce)
+	*x = edwards25519.Scalar{}
+	*nonce = edwards25519.Scalar{}
+
+	pi := make([]byte, 0, VRFProofSize)
+	pi = append(pi, Gamma.Bytes()...)
+	pi = append(pi, c.Bytes()[:16]...)
+	return append(pi, s.Bytes()...), nil
+}
+
+// ProofToHash returns the VRF output beta of the proof pi. It only checks
+// that pi is well formed, not that it is valid: use Verify, which also
+// returns beta, for proofs that aren't trusted.
+func (v *VRF) ProofToHash(pi []byte) ([]byte, error) {
+	Gamma, _, _, err := decodeVRFProof(pi)
+	if err != nil {
+		return nil, err
+	}
+	return v.proofToHash(Gamma), nil
+}
+
+// Verify reports whether pi is a valid proof for alpha under publicKey and,
+// if so, returns the VRF output beta. publicKey must be the canonical
+// encoding of a point that isn't of small order, as ECVRF_validate_key of
+// RFC 9381 requires; the check is always made.
+func (v *VRF) Verify(publicKey PublicKey, alpha, pi []byte) (bool, []byte) {
+	if len(publicKey) != PublicKeySize {
+		return false, nil
+	}
+	Y, err := new(edwards25519.Point).SetCanonicalBytes(publicKey)
+	if err != nil || Y.IsSmallOrder() {
+		return false, nil
+	}
+	Gamma, c, s, err := decodeVRFProof(pi)
+	if err != nil {
+		return false, nil
+	}
+	H, err := v.encodeToCurve(v.suite, publicKey, alpha)
+	if err != nil {
+		return false, nil
+	}
+
+	// U = sB - cY and V = sH - cGamma. Negate the points rather than c, as
+	// Y and Gamma may have a small-order component.
+	minusY := new(edwards25519.Point).Negate(Y)
+	minusGamma := new(edwards25519.Point).Negate(Gamma)
+	U := new(edwards25519.Point).VarTimeDoubleScalarBaseMult(c, minusY, s)
+	V := new(edwards25519.Point).VarTimeMultiScalarMult(
+		[]*edwards25519.Scalar{s, c}, []*edwards25519.Point{H, minusGamma})
+	if v.challenge(Y, H, Gamma, U, V).Equal(c) != 1 {
+		return false, nil
+	}
+	return true, v.proofToHash(Gamma)
+}
@@ -0,0 +1,282 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	"github.com/agl/ed25519/edwards25519"
+)
+
+type vrfTest struct {
+	seed, alpha, pi, beta string
+}
+
+// vrfTAITests are the examples of RFC 9381, Appendix B.3, which use the keys
+// of RFC 8032, Section 7.1, tests 1 to 3.
+var vrfTAITests = []vrfTest{
+	{
+		"9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60",
+		"",
+		"8657106690b5526245a92b003bb079ccd1a92130477671f6fc01ad16f26f723f26f8a57ccaed74ee1b190bed1f479d9727d2d0f9b005a6e456a35d4fb0daab1268a1b0db10836d9826a528ca76567805",
+		"90cf1df3b703cce59e2a35b925d411164068269d7b2d29f3301c03dd757876ff66b71dda49d2de59d03450451af026798e8f81cd2e333de5cdf4f3e140fdd8ae",
+	},
+	{
+		"4ccd089b28ff96da9db6c346ec114e0f5b8a319f35aba624da8cf6ed4fb8a6fb",
+		"72",
+		"f3141cd382dc42909d19ec5110469e4feae18300e94f304590abdced48aed5933bf0864a62558b3ed7f2fea45c92a465301b3bbf5e3e54ddf2d935be3b67926da3ef39226bbc355bdc9850112c8f4b02",
+		"eb4440665d3891d668e7e0fcaf587f1b4bd7fbfe99d0eb2211ccec90496310eb5e33821bc613efb94db5e5b54c70a848a0bef4553a41befc57663b56373a5031",
+	},
+	{
+		"c5aa8df43f9f837bedb7442f31dcb7b166d38535076f094b85ce3a2e0b4458f7",
+		"af82",
+		"9bc0f79119cc5604bf02d23b4caede71393cedfbb191434dd016d30177ccbf8096bb474e53895c362d8628ee9f9ea3c0e52c7a5c691b6c18c9979866568add7a2d41b00b05081ed0f58ee5e31b3a970e",
+		"645427e5d00c62a23fb703732fa5d892940935942101e456ecca7bb217c61c452118fec1219202a0edcf038bb6373241578be7217ba85a2687f7a0310b2df19f",
+	},
+}
+
+func testVRFVectors(t *testing.T, vrf *VRF, tests []vrfTest) {
+	for _, test := range tests {
+		private := NewKeyFromSeed(decodeHex(t, test.seed))
+		public := private.Public().(PublicKey)
+		alpha := decodeHex(t, test.alpha)
+
+		pi, err := vrf.Prove(private, alpha)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(pi); got != test.pi {
+			t.Errorf("%s: got pi %s, want %s", test.seed, got, test.pi)
+		}
+		beta, err := vrf.ProofToHash(pi)
+		if err != nil || hex.EncodeToString(beta) != test.beta {
+			t.Errorf("%s: got beta %x, %v, want %s", test.seed, beta, err, test.beta)
+		}
+		ok, verified := vrf.Verify(public, alpha, decodeHex(t, test.pi))
+		if !ok || hex.EncodeToString(verified) != test.beta {
+			t.Errorf("%s: verification gave %v, %x", test.seed, ok, verified)
+		}
+	}
+}
+
+// vrfELL2Tests are the examples of RFC 9381, Appendix B.4, for the same keys
+// and inputs as vrfTAITests.
+var vrfELL2Tests = []vrfTest{
+	{
+		"9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60",
+		"",
+		"7d9c633ffeee27349264cf5c667579fc583b4bda63ab71d001f89c10003ab46f14adf9a3cd8b8412d9038531e865c341cafa73589b023d14311c331a9ad15ff2fb37831e00f0acaa6d73bc9997b06501",
+		"9d574bf9b8302ec0fc1e21c3ec5368269527b87b462ce36dab2d14ccf80c53cccf6758f058c5b1c856b116388152bbe509ee3b9ecfe63d93c3b4346c1fbc6c54",
+	},
+	{
+		"4ccd089b28ff96da9db6c346ec114e0f5b8a319f35aba624da8cf6ed4fb8a6fb",
+		"72",
+		"47b327393ff2dd81336f8a2ef10339112401253b3c714eeda879f12c509072ef055b48372bb82efbdce8e10c8cb9a2f9d60e93908f93df1623ad78a86a028d6bc064dbfc75a6a57379ef855dc6733801",
+		"38561d6b77b71d30eb97a062168ae12b667ce5c28caccdf76bc88e093e4635987cd96814ce55b4689b3dd2947f80e59aac7b7675f8083865b46c89b2ce9cc735",
+	},
+	{
+		"c5aa8df43f9f837bedb7442f31dcb7b166d38535076f094b85ce3a2e0b4458f7",
+		"af82",
+		"926e895d308f5e328e7aa159c06eddbe56d06846abf5d98c2512235eaa57fdce35b46edfc655bc828d44ad09d1150f31374e7ef73027e14760d42e77341fe05467bb286cc2c9d7fde29120a0b2320d04",
+		"121b7f9b9aaaa29099fc04a94ba52784d44eac976dd1a3cca458733be5cd090a7b5fbd148444f17f8daf1fb55cb04b1ae85a626e30a54b4b0f8abf4a43314a58",
+	},
+}
+
+// vrfDraft05Tests are the examples for ECVRF-ED25519-SHA512-Elligator2 in
+// drafts 03 to 05 of draft-irtf-cfrg-vrf, with which the draft 03
+// implementation in Algorand's libsodium fork is tested.
+var vrfDraft05Tests = []vrfTest{
+	{
+		"9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60",
+		"",
+		"b6b4699f87d56126c9117a7da55bd0085246f4c56dbc95d20172612e9d38e8d7ca65e573a126ed88d4e30a46f80a666854d675cf3ba81de0de043c3774f061560f55edc256a787afe701677c0f602900",
+		"5b49b554d05c0cd5a5325376b3387de59d924fd1e13ded44648ab33c21349a603f25b84ec5ed887995b33da5e3bfcb87cd2f64521c4c62cf825cffabbe5d31cc",
+	},
+	{
+		"4ccd089b28ff96da9db6c346ec114e0f5b8a319f35aba624da8cf6ed4fb8a6fb",
+		"72",
+		"ae5b66bdf04b4c010bfe32b2fc126ead2107b697634f6f7337b9bff8785ee111200095ece87dde4dbe87343f6df3b107d91798c8a7eb1245d3bb9c5aafb093358c13e6ae1111a55717e895fd15f99f07",
+		"94f4487e1b2fec954309ef1289ecb2e15043a2461ecc7b2ae7d4470607ef82eb1cfa97d84991fe4a7bfdfd715606bc27e2967a6c557cfb5875879b671740b7d8",
+	},
+	{
+		"c5aa8df43f9f837bedb7442f31dcb7b166d38535076f094b85ce3a2e0b4458f7",
+		"af82",
+		"dfa2cba34b611cc8c833a6ea83b8eb1bb5e2ef2dd1b0c481bc42ff36ae7847f6ab52b976cfd5def172fa412defde270c8b8bdfbaae1c7ece17d9833b1bcf31064fff78ef493f820055b561ece45e1009",
+		"2031837f582cd17a9af9e0c7ef5a6540e3453ed894b62c293686ca3c1e319dde9d0aa489a4b59a9594fc2328bc3deff3c8a0929a369a72b1180a596e016b5ded",
+	},
+}
+
+func TestVRFTAIVectors(t *testing.T) {
+	testVRFVectors(t, NewVRFTAI(), vrfTAITests)
+}
+
+func TestVRFELL2Vectors(t *testing.T) {
+	testVRFVectors(t, NewVRFELL2(), vrfELL2Tests)
+}
+
+func TestVRFDraft05Vectors(t *testing.T) {
+	testVRFVectors(t, NewVRFDraft05(), vrfDraft05Tests)
+
+	// The suite string is that of RFC 9381's ELL2 suite, but the proofs
+	// don't verify under it.
+	test := vrfDraft05Tests[1]
+	public := NewKeyFromSeed(decodeHex(t, test.seed)).Public().(PublicKey)
+	if ok, _ := NewVRFELL2().Verify(public, decodeHex(t, test.alpha), decodeHex(t, test.pi)); ok {
+		t.Error("draft-05 proof verified under RFC 9381")
+	}
+}
+
+// TestVRFSuitesDiffer checks that the suites hash to different points, so
+// neither their proofs nor their outputs agree, and that a proof for one
+// doesn't verify under the other.
+func TestVRFSuitesDiffer(t *testing.T) {
+	private := NewKeyFromSeed(decodeHex(t, vrfTAITests[0].seed))
+	public := private.Public().(PublicKey)
+	alpha := []byte("alpha")
+	tai, ell2 := NewVRFTAI(), NewVRFELL2()
+
+	hTAI, _ := tai.encodeToCurve(tai.suite, public, alpha)
+	hELL2, _ := ell2.encodeToCurve(ell2.suite, public, alpha)
+	if hTAI.Equal(hELL2) == 1 {
+		t.Fatal("both suites hash to the same point")
+	}
+	piTAI, _ := tai.Prove(private, alpha)
+	piELL2, _ := ell2.Prove(private, alpha)
+	betaTAI, _ := tai.ProofToHash(piTAI)
+	betaELL2, _ := ell2.ProofToHash(piELL2)
+	if bytes.Equal(piTAI, piELL2) || bytes.Equal(betaTAI, betaELL2) {
+		t.Error("both suites gave the same result")
+	}
+	if ok, _ := ell2.Verify(public, alpha, piTAI); ok {
+		t.Error("TAI proof verified as ELL2")
+	}
+	if ok, _ := tai.Verify(public, alpha, piELL2); ok {
+		t.Error("ELL2 proof verified as TAI")
+	}
+}
+
+// TestEncodeToCurveEdwards25519 uses the test vector of RFC 9380 for
+// edwards25519_XMD:SHA-512_ELL2_NU_ and an empty message.
+func TestEncodeToCurveEdwards25519(t *testing.T) {
+	dst := []byte("QUUX-V01-CS02-with-edwards25519_XMD:SHA-512_ELL2_NU_")
+	u, err := hashToFieldEdwards25519(nil, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b [32]byte
+	edwards25519.FeToBytes(&b, u)
+	if got := hex.EncodeToString(b[:]); got != "1d64304a37f0a0f793504c897d427b5d5032dff932db527fad038142b97f3e7f" {
+		t.Errorf("u = %s", got)
+	}
+
+	P, err := encodeToCurveEdwards25519(nil, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// x = 0x1ff2b70ecf862799e11b7ae744e3489aa058ce805dd323a936375a84695e76da,
+	// which is even, and y = 0x222e314d04a4...7f0f9b.
+	if got := hex.EncodeToString(P.Bytes()); got != "9b0f7f682dabce2190b14e21a175f39eb6a6b29fff2a9f5e72d5a4044d312e22" {
+		t.Errorf("P = %s", got)
+	}
+}
+
+func TestExpandMessageXMDErrors(t *testing.T) {
+	if _, err := expandMessageXMD(nil, make([]byte, 256), 48); err == nil {
+		t.Error("256-byte tag accepted")
+	}
+	if _, err := expandMessageXMD(nil, []byte("dst"), 255*64+1); err == nil {
+		t.Error("over-long output accepted")
+	}
+}
+
+func testVRFErrors(t *testing.T, vrf *VRF) {
+	public, private, _ := GenerateKey(rand.Reader)
+	alpha := []byte("alpha")
+	pi, err := vrf.Prove(private, alpha)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok, beta := vrf.Verify(public, alpha, pi)
+	if !ok {
+		t.Fatal("proof rejected")
+	}
+	if ok, _ := vrf.Verify(public, []byte("other"), pi); ok {
+		t.Error("proof of another alpha accepted")
+	}
+	other, _, _ := GenerateKey(rand.Reader)
+	if ok, _ := vrf.Verify(other, alpha, pi); ok {
+		t.Error("proof under another key accepted")
+	}
+
+	// Mutate Gamma, c and s in turn.
+	for _, i := range []int{0, 17, 31, 32, 40, 47, 48, 60, 78} {
+		bad := append([]byte(nil), pi...)
+		bad[i] ^= 1
+		if ok, out := vrf.Verify(public, alpha, bad); ok || out != nil {
+			t.Errorf("proof with byte %d changed accepted", i)
+		}
+	}
+	if ok, _ := vrf.Verify(public, alpha, pi[:VRFProofSize-1]); ok {
+		t.Error("short proof accepted")
+	}
+
+	// s plus the group order isn't canonical.
+	order := decodeHex(t, "edd3f55c1a631258d69cf7a2def9de1400000000000000000000000000000010")
+	bad := append([]byte(nil), pi...)
+	carry := 0
+	for i := range order {
+		carry += int(bad[48+i]) + int(order[i])
+		bad[48+i] = byte(carry)
+		carry >>= 8
+	}
+	if ok, _ := vrf.Verify(public, alpha, bad); ok {
+		t.Error("non-canonical s accepted")
+	}
+	if _, err := vrf.ProofToHash(bad); err == nil {
+		t.Error("ProofToHash accepted non-canonical s")
+	}
+
+	// Gamma plus a point of order 2 gives the same output but a different
+	// challenge.
+	gamma, _ := new(edwards25519.Point).SetCanonicalBytes(pi[:32])
+	order2, _ := new(edwards25519.Point).SetCanonicalBytes(decodeHex(t, "ecffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f"))
+	bad = append(gamma.Add(gamma, order2).Bytes(), pi[32:]...)
+	if b, err := vrf.ProofToHash(bad); err != nil || !bytes.Equal(b, beta) {
+		t.Errorf("Gamma with an order-2 component gave output %x, %v", b, err)
+	}
+	if ok, _ := vrf.Verify(public, alpha, bad); ok {
+		t.Error("Gamma with an order-2 component accepted")
+	}
+
+	for _, enc := range smallOrderEncodings {
+		if ok, _ := vrf.Verify(decodeHex(t, enc), alpha, pi); ok {
+			t.Errorf("small-order public key %s accepted", enc)
+		}
+	}
+
+	// Proofs are deterministic.
+	again, _ := vrf.Prove(private, alpha)
+	if !bytes.Equal(again, pi) {
+		t.Error("second proof differs")
+	}
+	if b, _ := vrf.ProofToHash(again); !bytes.Equal(b, beta) {
+		t.Error("ProofToHash differs from Verify")
+	}
+}
+
+func TestVRFTAIErrors(t *testing.T) {
+	testVRFErrors(t, NewVRFTAI())
+}
+
+func TestVRFELL2Errors(t *testing.T) {
+	testVRFErrors(t, NewVRFELL2())
+}
+
+func TestVRFDraft05Errors(t *testing.T) {
+	testVRFErrors(t, NewVRFDraft05())
+}
@@ -0,0 +1,197 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"errors"
+
+	"github.com/agl/ed25519/edwards25519"
+)
+
+// VXEdDSA, from the same specification as XEdDSA, is a verifiable random
+// function: the signature (V, h, s) proves in zero knowledge that V = aBv,
+// where Bv is a point hashed from the public key and the message with
+// Elligator 2, and the output is a hash of V. Only the holder of the private
+// key can compute it, yet anyone with the public key can check it, and it is
+// the same for every signature of a message.
+//
+// This is the construction of the specification. libsignal's VRF,
+// generalized_xveddsa_25519, puts label sets in its hashes, so its signatures
+// and outputs differ and don't verify here.
+
+// VXEdDSASignatureSize is the size, in bytes, of a VXEdDSA signature.
+const VXEdDSASignatureSize = 96
+
+var errHashToPoint = errors.New("ed25519: message hashes to an exceptional point")
+
+// feChi sets out to z^((p-1)/2), which is 1 if z is a non-zero square, -1 if
+// it isn't a square, and 0 if it is zero.
+func feChi(out, z *edwards25519.FieldElement) {
+	// (p-1)/2 = 2^254 - 10.
+	var e [32]byte
+	for i := range e {
+		e[i] = 0xff
+	}
+	e[0] = 0xf6
+	e[31] = 0x3f
+	fePow(out, z, &e)
+}
+
+// elligator2 sets u to the image of r under the Elligator 2 map of the
+// XEdDSA specification, the u-coordinate of a point of Curve25519. The drafts
+// of the ECVRF before RFC 9381 use the same map.
+func elligator2(u, r *edwards25519.FieldElement) {
+	var one, a, u1, w, t edwards25519.FieldElement
+	edwards25519.FeOne(&one)
+	a[0] = 486662
+
+	// u1 = -A / (1 + 2r^2)
+	edwards25519.FeSquare2(&t, r)
+	edwards25519.FeAdd(&t, &t, &one)
+	edwards25519.FeInvert(&t, &t)
+	edwards25519.FeMul(&u1, &a, &t)
+	edwards25519.FeNeg(&u1, &u1)
+
+	// w = u1(u1^2 + Au1 + 1)
+	edwards25519.FeAdd(&t, &u1, &a)
+	edwards25519.FeMul(&t, &t, &u1)
+	edwards25519.FeAdd(&t, &t, &one)
+	edwards25519.FeMul(&w, &t, &u1)
+
+	// If w isn't a square, u1 is on the twist and u2 = -A - u1 is on the
+	// curve.
+	var chi edwards25519.FieldElement
+	feChi(&chi, &w)
+	edwards25519.FeAdd(&chi, &chi, &one)
+	edwards25519.FeNeg(&t, &a)
+	edwards25519.FeSub(&t, &t, &u1)
+	edwards25519.FeCMove(&u1, &t, 1-edwards25519.FeIsNonZero(&chi))
+	*u = u1
+}
+
+// vxeddsaHashToPoint implements hash_to_point of the XEdDSA specification:
+// the low 255 bits of hash_2 of x are mapped to a u-coordinate with
+// Elligator 2, the top bit selects the sign of x, and the point is multiplied
+// by the cofactor.
+func vxeddsaHashToPoint(x ...[]byte) (*edwards25519.Point, error) {
+	digest := xeddsaHash(2, x...)
+	var b [32]byte
+	copy(b[:], digest[:32])
+	sign := int(b[31] >> 7)
+
+	// FeFromBytes ignores the top bit.
+	var r, u edwards25519.FieldElement
+	edwards25519.FeFromBytes(&r, &b)
+	elligator2(&u, &r)
+	edwards25519.FeToBytes(&b, &u)
+
+	// Only u = -1, with negligible probability, can fail.
+	P, err := edwards25519.MontgomeryToEdwards(b, sign)
+	if err != nil {
+		return nil, errHashToPoint
+	}
+	return P.MultByCofactor(P), nil
+}
+
+// vxeddsaOutput returns the VRF output for V, the first 32 bytes of hash_5 of
+// cV.
+func vxeddsaOutput(V *edwards25519.Point) [32]byte {
+	digest := xeddsaHash(5, new(edwards25519.Point).MultByCofactor(V).Bytes())
+	var v [32]byte
+	copy(v[:], digest[:32])
+	return v
+}
+
+// VXEdDSASign signs message with the X25519 private key x25519Priv, following
+// vxeddsa_sign of the XEdDSA specification, and returns the signature and
+// the VRF output. random must be 64 bytes from a cryptographically secure
+// source, fresh for every signature; the signature depends on it, but the
+// output doesn't.
+//
+// As with XEdDSASign, x25519Priv may be any X25519 private key, and is
+// clamped as X25519 does. It returns an error only in the negligibly likely
+// case that message hashes to no point.
+func VXEdDSASign(x25519Priv [32]byte, message []byte, random [64]byte) ([VXEdDSASignatureSize]byte, [32]byte, error) {
+	var sig [VXEdDSASignatureSize]byte
+	var v [32]byte
+	A, a := xeddsaKeyPair(&x25519Priv)
+	aBytes := a.Bytes()
+	defer wipeBytes(aBytes)
+
+	Bv, err := vxeddsaHashToPoint(A, message)
+	if err != nil {
+		return sig, v, err
+	}
+	V := new(edwards25519.Point).ScalarMult(a, Bv)
+	VBytes := V.Bytes()
+
+	digest := xeddsaHash(3, aBytes, VBytes, random[:])
+	r, _ := edwards25519.NewScalar().SetUniformBytes(digest[:])
+	R := new(edwards25519.Point).ScalarBaseMult(r).Bytes()
+	Rv := new(edwards25519.Point).ScalarMult(r, Bv).Bytes()
+
+	digest = xeddsaHash(4, A, VBytes, R, Rv, message)
+	h, _ := edwards25519.NewScalar().SetUniformBytes(digest[:])
+	s := edwards25519.NewScalar().MultiplyAdd(h, a, r)
+
+	copy(sig[:32], VBytes)
+	copy(sig[32:64], h.Bytes())
+	copy(sig[64:], s.Bytes())
+	wipeBytes(digest[:])
+	*a = edwards25519.Scalar{}
+	*r = edwards25519.Scalar{}
+	return sig, vxeddsaOutput(V), nil
+}
+
+// VXEdDSAVerify reports whether sig is a valid VXEdDSA signature of message by
+// the X25519 public key x25519Pub, following vxeddsa_verify of the XEdDSA
+// specification, and if so returns the VRF output, which is the same for
+// every valid signature of message by x25519Pub.
+//
+// As with XEdDSAVerify, x25519Pub must be canonical and public keys whose
+// Edwards point has small order are rejected.
+func VXEdDSAVerify(x25519Pub [32]byte, message, sig []byte) (bool, [32]byte) {
+	var v [32]byte
+	if len(sig) != VXEdDSASignatureSize || sig[63]&0xe0 != 0 || sig[95]&0xe0 != 0 {
+		return false, v
+	}
+	A, err := edwards25519.MontgomeryToEdwards(x25519Pub, 0)
+	if err != nil || A.IsSmallOrder() {
+		return false, v
+	}
+	V, err := new(edwards25519.Point).SetBytes(sig[:32])
+	if err != nil || V.IsSmallOrder() {
+		return false, v
+	}
+	ABytes := A.Bytes()
+	Bv, err := vxeddsaHashToPoint(ABytes, message)
+	if err != nil || Bv.IsSmallOrder() {
+		return false, v
+	}
+
+	// h must equal a hash reduced modulo the group order, so one that isn't
+	// canonical can't match. B and Bv have prime order, so s can be reduced.
+	h, err := edwards25519.NewScalar().SetCanonicalBytes(sig[32:64])
+	if err != nil {
+		return false, v
+	}
+	var wide [64]byte
+	copy(wide[:], sig[64:])
+	s, _ := edwards25519.NewScalar().SetUniformBytes(wide[:])
+
+	// Negate the points rather than h, as A and V may have a small-order
+	// component.
+	minusA := new(edwards25519.Point).Negate(A)
+	minusV := new(edwards25519.Point).Negate(V)
+	R := new(edwards25519.Point).VarTimeDoubleScalarBaseMult(h, minusA, s)
+	Rv := new(edwards25519.Point).VarTimeMultiScalarMult(
+		[]*edwards25519.Scalar{s, h}, []*edwards25519.Point{Bv, minusV})
+	digest := xeddsaHash(4, ABytes, sig[:32], R.Bytes(), Rv.Bytes(), message)
+	check, _ := edwards25519.NewScalar().SetUniformBytes(digest[:])
+	if check.Equal(h) != 1 {
+		return false, v
+	}
+	return true, vxeddsaOutput(V)
+}
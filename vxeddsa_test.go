@@ -0,0 +1,213 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	"golang.org/x/crypto/curve25519"
+)
+
+// vxeddsaTests were computed with the same Python implementation as
+// xeddsaTests, for the keys and messages of xeddsaTests. They are not from
+// libsignal, whose VRF is not the one of the specification.
+var vxeddsaTests = []struct {
+	random, sig, output string
+}{
+	{
+		"e270b0dc1955c0170ae776089461f8d05da8230e165e41575d75a2e5215c3107590fc823a67ac8d9570c1e1a1d7b7eacd1e1c173dccf31cd89f769eae88ad89c",
+		"23ec3956cd83a151a7fddef8ea16fc17a988527cdbcc1b463449a80c90f54de5b618e4672aa014aa182387e7bbecfee014d3ce46a8d91b981866fd954fc57401ce4a6308e96b44625e37b896f3cf10f64d245150ba2b0fe4e94bc39145057f0b",
+		"633f256d17b177ecdae539110d94479afff1f829f48ebd8e297eedc821e06bec",
+	},
+	{
+		"93253ee7df7fde1b4f96cc391cd96f4b3c180fd469debecbf762e05103ef07853b478e1c1f70815ed6f92e30f40f2c9aedb2a327e7b2932fd9645d9e8a4a822c",
+		"7b9adfcba50250c2b43d29edb84c2437b5b8b9eb24360c5ffe88a4ffc6df9da908d104cfef9d3c9b39b283f204875cfd8583ddb3217145c97be29ac97a8bd5042f4a58c6ce6dc5efe75e7518f2e5a8cd51c2d82d5d614acad3f922a0c7df6905",
+		"abf51b2a41958f541dfe02ee9253eb4af1458583a4bf2f404b7ba2bc04ad2cc6",
+	},
+	{
+		"1ea9617d0367aa9b44fd4d0ab2b4295bba8c39785a3d82bc06008f5a58fc25b56a44e8e889d620658dde1649c15c83517e61598d45a6d7dee20866b50056ba85",
+		"481d49ebfdb7dc70c7e4d23545ca1a8686926829499b1eba6bee13c048c081d6b2f796fd839c48a5c0a0af76ea8d5cb723317a11ff80d5fcdf9e86a504208609e4bbbece3ab437d7f495a906c7d554b0c4948ab02f8797f45516d442c317d905",
+		"efd3e043995ec45526f2b1ee650e987e366ea784e266745a2a0991eca3c6814b",
+	},
+}
+
+func TestVXEdDSAVectors(t *testing.T) {
+	for i, test := range vxeddsaTests {
+		private := [32]byte(decodeHex(t, xeddsaTests[i].private))
+		public := [32]byte(decodeHex(t, xeddsaTests[i].public))
+		message := decodeHex(t, xeddsaTests[i].message)
+		sig, output, err := VXEdDSASign(private, message, [64]byte(decodeHex(t, test.random)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(sig[:]); got != test.sig {
+			t.Errorf("%d: got signature %s, want %s", i, got, test.sig)
+		}
+		if got := hex.EncodeToString(output[:]); got != test.output {
+			t.Errorf("%d: got output %s, want %s", i, got, test.output)
+		}
+		ok, verified := VXEdDSAVerify(public, message, sig[:])
+		if !ok || verified != output {
+			t.Errorf("%d: verification gave %v, %x", i, ok, verified)
+		}
+	}
+}
+
+// TestVXEdDSAHashToPoint checks hash_to_point against the Python
+// implementation, for inputs that take both branches of Elligator 2 with both
+// signs.
+func TestVXEdDSAHashToPoint(t *testing.T) {
+	for _, test := range []struct{ input, point string }{
+		{"point 0", "5259959829709781464281737fc5f234ea26bb18a24c5e03f7de2f3eb3449341"},
+		{"point 1", "0778ba965ab2950c0c43e061c9ab990a2f85f9318001cf37e1024f75cf3114b5"},
+		{"point 2", "42f65ca35a7e9fe2872f3a40bb493bbf1b69e5a3433721ed7f0abb9d7e054295"},
+		{"point 5", "d3c6074215063a29ac58f8d0b6e818aca1f4d55ff18a3ed18c34f3bc42dfaf75"},
+	} {
+		P, err := vxeddsaHashToPoint([]byte(test.input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(P.Bytes()); got != test.point {
+			t.Errorf("%q: got %s, want %s", test.input, got, test.point)
+		}
+	}
+}
+
+func TestVXEdDSAOutput(t *testing.T) {
+	var private [32]byte
+	rand.Read(private[:])
+	public, err := curve25519.X25519(private[:], curve25519.Basepoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	private[0] &= 248
+	private[31] &= 127
+	private[31] |= 64
+
+	outputs := make(map[[32]byte]bool)
+	for i := 0; i < 10; i++ {
+		message := []byte{byte(i)}
+		var first [32]byte
+		for j := 0; j < 3; j++ {
+			var random [64]byte
+			rand.Read(random[:])
+			sig, output, err := VXEdDSASign(private, message, random)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ok, verified := VXEdDSAVerify([32]byte(public), message, sig[:])
+			if !ok || verified != output {
+				t.Fatalf("message %d: verification gave %v, %x", i, ok, verified)
+			}
+			if j == 0 {
+				first = output
+			} else if output != first {
+				t.Fatalf("message %d: outputs %x and %x differ", i, first, output)
+			}
+		}
+		outputs[first] = true
+	}
+	if len(outputs) != 10 {
+		t.Errorf("10 messages gave %d distinct outputs", len(outputs))
+	}
+
+	// The outputs of different messages shouldn't be related: about half of
+	// the bits of each pair should differ.
+	var list [][32]byte
+	for v := range outputs {
+		list = append(list, v)
+	}
+	for i := 1; i < len(list); i++ {
+		differ := 0
+		for j := range list[i] {
+			for x := list[i][j] ^ list[i-1][j]; x != 0; x &= x - 1 {
+				differ++
+			}
+		}
+		if differ < 80 || differ > 176 {
+			t.Errorf("outputs %x and %x differ in %d bits", list[i-1], list[i], differ)
+		}
+	}
+}
+
+// TestVXEdDSAUnclampedKeys signs with unclamped crypto/ecdh X25519 keys, as
+// TestXEdDSAUnclampedKeys does, and checks that the proofs verify under their
+// public keys with the same output.
+func TestVXEdDSAUnclampedKeys(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		var b [32]byte
+		var random [64]byte
+		rand.Read(b[:])
+		rand.Read(random[:])
+		b[0] |= byte(i%7) + 1
+		if i%2 == 0 {
+			b[31] |= 0x80
+		} else {
+			b[31] &= 0xbf
+		}
+		priv, err := ecdh.X25519().NewPrivateKey(b[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		public := [32]byte(priv.PublicKey().Bytes())
+		message := []byte("message")
+
+		sig, output, err := VXEdDSASign([32]byte(priv.Bytes()), message, random)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ok, verified := VXEdDSAVerify(public, message, sig[:])
+		if !ok {
+			t.Errorf("%x: proof rejected under its own public key", b)
+		} else if verified != output {
+			t.Errorf("%x: verified output %x, want %x", b, verified, output)
+		}
+	}
+}
+
+func TestVXEdDSAVerifyErrors(t *testing.T) {
+	test := vxeddsaTests[1]
+	public := [32]byte(decodeHex(t, xeddsaTests[1].public))
+	message := decodeHex(t, xeddsaTests[1].message)
+	sig := decodeHex(t, test.sig)
+
+	if ok, _ := VXEdDSAVerify(public, message, sig[:95]); ok {
+		t.Error("short signature accepted")
+	}
+	if ok, _ := VXEdDSAVerify(public, append(message, 0), sig); ok {
+		t.Error("signature of another message accepted")
+	}
+	for i := 0; i < len(sig); i++ {
+		bad := append([]byte(nil), sig...)
+		bad[i] ^= 4
+		if ok, v := VXEdDSAVerify(public, message, bad); ok || v != [32]byte{} {
+			t.Errorf("signature with byte %d changed gave %v, %x", i, ok, v)
+		}
+	}
+
+	// V of small order.
+	bad := append([]byte(nil), sig...)
+	copy(bad, decodeHex(t, smallOrderEncodings[0]))
+	if ok, _ := VXEdDSAVerify(public, message, bad); ok {
+		t.Error("small-order V accepted")
+	}
+	for _, u := range []string{
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		"0100000000000000000000000000000000000000000000000000000000000000",
+	} {
+		if ok, _ := VXEdDSAVerify([32]byte(decodeHex(t, u)), message, sig); ok {
+			t.Errorf("small-order public key %s accepted", u)
+		}
+	}
+
+	// An XEdDSA signature is not a VXEdDSA signature.
+	xsig := decodeHex(t, xeddsaTests[1].sig)
+	if ok, _ := VXEdDSAVerify(public, message, append(xsig, make([]byte, 32)...)); ok {
+		t.Error("padded XEdDSA signature accepted")
+	}
+}
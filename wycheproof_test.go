@@ -0,0 +1,95 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+// wycheproofFile is ed25519_test.json from Project Wycheproof
+// (https://github.com/C2SP/wycheproof, testvectors_v1), which is distributed
+// under the Apache License 2.0. It was called eddsa_test.json in older
+// releases.
+const wycheproofFile = "testdata/wycheproof_ed25519_test.json"
+
+type wycheproofVectors struct {
+	Algorithm     string `json:"algorithm"`
+	NumberOfTests int    `json:"numberOfTests"`
+	TestGroups    []struct {
+		PublicKey struct {
+			PK string `json:"pk"`
+		} `json:"publicKey"`
+		Tests []struct {
+			TcID    int      `json:"tcId"`
+			Comment string   `json:"comment"`
+			Flags   []string `json:"flags"`
+			Msg     string   `json:"msg"`
+			Sig     string   `json:"sig"`
+			Result  string   `json:"result"`
+		} `json:"tests"`
+	} `json:"testGroups"`
+}
+
+func TestWycheproof(t *testing.T) {
+	data, err := os.ReadFile(wycheproofFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vectors wycheproofVectors
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatal(err)
+	}
+	if vectors.Algorithm != "EDDSA" {
+		t.Fatalf("unexpected algorithm %q", vectors.Algorithm)
+	}
+
+	// Every Wycheproof EdDSA vector is either valid or invalid in all of
+	// the verification modes: none exercise the differences between them.
+	modes := []struct {
+		name   string
+		verify func(PublicKey, []byte, []byte) bool
+	}{
+		{"Verify", Verify},
+		{"StrictVerify", StrictVerify},
+		{"VerifyCofactored", VerifyCofactored},
+		{"VerifyZIP215", VerifyZIP215},
+	}
+
+	var count int
+	for _, group := range vectors.TestGroups {
+		public := PublicKey(decodeHex(t, group.PublicKey.PK))
+		for _, test := range group.Tests {
+			count++
+			message, sig := decodeHex(t, test.Msg), decodeHex(t, test.Sig)
+
+			var want bool
+			switch test.Result {
+			case "valid":
+				want = true
+			case "invalid":
+				want = false
+			default:
+				t.Fatalf("tcId %d: unexpected result %q", test.TcID, test.Result)
+			}
+
+			for _, mode := range modes {
+				if got := mode.verify(public, message, sig); got != want {
+					t.Errorf("tcId %d (%s, %v): %s returned %v, want %v", test.TcID, test.Comment, test.Flags, mode.name, got, want)
+				}
+			}
+
+			var batch BatchVerifier
+			got := batch.Add(public, message, sig) == nil && batch.VerifyAll()
+			if got != want {
+				t.Errorf("tcId %d (%s, %v): batch verification returned %v, want %v", test.TcID, test.Comment, test.Flags, got, want)
+			}
+		}
+	}
+	if count != vectors.NumberOfTests {
+		t.Errorf("ran %d tests, file claims %d", count, vectors.NumberOfTests)
+	}
+}
@@ -0,0 +1,182 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"crypto/ecdh"
+	"crypto/sha512"
+
+	"github.com/agl/ed25519/edwards25519"
+)
+
+// Edwards25519 and Curve25519 are birationally equivalent: the point (x, y)
+// of the Edwards curve maps to the point of the Montgomery curve with
+// u = (1+y)/(1-y), so an Ed25519 public key determines an X25519 public key.
+// The map is undefined at y = 1, the identity, and sends the other points of
+// small order to the small-order u-coordinates that X25519 implementations
+// reject or turn into an all-zero shared secret, so they are refused.
+
+// PublicKeyToX25519 returns the X25519 public key of the Ed25519 public key
+// publicKey, as crypto_sign_ed25519_pk_to_curve25519 of libsodium does. The
+// result is the public key of the X25519 private key that libsodium's
+// crypto_sign_ed25519_sk_to_curve25519 derives from the same seed.
+//
+// publicKey must be the canonical encoding of a point in the prime-order
+// subgroup. It returns ErrBadPublicKeyLength, ErrInvalidPublicKey,
+// ErrNonCanonicalKey, ErrSmallOrderKey or ErrMixedOrderKey as ParsePublicKey
+// does with KeyValidationOptions.RequirePrimeOrder. libsodium also rejects
+// points of small order and most of mixed order, but accepts those with a
+// component of order 2 and the non-canonical encodings of points of large
+// order; for the keys that both accept the results are the same.
+func PublicKeyToX25519(publicKey PublicKey) ([32]byte, error) {
+	var u [32]byte
+	if _, err := ParsePublicKey(publicKey, &KeyValidationOptions{RequirePrimeOrder: true}); err != nil {
+		return u, err
+	}
+
+	// FeFromBytes ignores the sign of x in the top bit.
+	var y, one, num, den edwards25519.FieldElement
+	copy(u[:], publicKey)
+	edwards25519.FeFromBytes(&y, &u)
+	edwards25519.FeOne(&one)
+	edwards25519.FeAdd(&num, &one, &y)
+	edwards25519.FeSub(&den, &one, &y)
+	edwards25519.FeInvert(&den, &den)
+	edwards25519.FeMul(&num, &num, &den)
+	edwards25519.FeToBytes(&u, &num)
+	return u, nil
+}
+
+// ConvertPublicKeysToX25519 converts each of publicKeys as PublicKeyToX25519
+// does, with the same results, but shares the field inversions of 1-y among
+// the keys with Montgomery's trick, so that the batch costs a single
+// inversion, and checks that the keys are in the prime-order subgroup in
+// variable time, which is safe as they are public. The i-th error is that of
+// PublicKeyToX25519 for publicKeys[i], or nil; a key that fails leaves a zero
+// result and doesn't affect the others.
+func ConvertPublicKeysToX25519(publicKeys []PublicKey) ([][32]byte, []error) {
+	out := make([][32]byte, len(publicKeys))
+	errs := make([]error, len(publicKeys))
+	valid := make([]int, 0, len(publicKeys))
+	nums := make([]edwards25519.FieldElement, 0, len(publicKeys))
+	dens := make([]edwards25519.FieldElement, 0, len(publicKeys))
+	for i, publicKey := range publicKeys {
+		// The keys are public, so the variable-time check of their order
+		// is safe. FromBytes leaves Z = 1, so A.Y is y.
+		var A edwards25519.ExtendedGroupElement
+		if err := decodePublicKey(&A, publicKey, &KeyValidationOptions{RequirePrimeOrder: true}, true); err != nil {
+			errs[i] = err
+			continue
+		}
+		var one, num, den edwards25519.FieldElement
+		edwards25519.FeOne(&one)
+		edwards25519.FeAdd(&num, &one, &A.Y)
+		edwards25519.FeSub(&den, &one, &A.Y)
+		valid = append(valid, i)
+		nums = append(nums, num)
+		dens = append(dens, den)
+	}
+	if len(valid) == 0 {
+		return out, errs
+	}
+
+	// products[j] is the product of dens[:j+1]. None of them is zero: the
+	// identity, the only point with y = 1, was rejected above.
+	products := make([]edwards25519.FieldElement, len(dens))
+	products[0] = dens[0]
+	for j := 1; j < len(dens); j++ {
+		edwards25519.FeMul(&products[j], &products[j-1], &dens[j])
+	}
+	var inv, denInv edwards25519.FieldElement
+	edwards25519.FeInvert(&inv, &products[len(products)-1])
+	for j := len(dens) - 1; j >= 0; j-- {
+		// inv is the inverse of products[j], so it times products[j-1] is
+		// the inverse of dens[j].
+		if j > 0 {
+			edwards25519.FeMul(&denInv, &inv, &products[j-1])
+			edwards25519.FeMul(&inv, &inv, &dens[j])
+		} else {
+			denInv = inv
+		}
+		edwards25519.FeMul(&nums[j], &nums[j], &denInv)
+		edwards25519.FeToBytes(&out[valid[j]], &nums[j])
+	}
+	return out, errs
+}
+
+// PrivateKeyToX25519 returns the X25519 private key of the Ed25519 private key
+// privateKey, as crypto_sign_ed25519_sk_to_curve25519 of libsodium does: the
+// first half of the SHA-512 hash of the seed, which is also the Ed25519
+// secret scalar, clamped as X25519 requires. Its public key is the result of
+// PublicKeyToX25519 for the public key of privateKey.
+//
+// The result is secret, and worth wiping when no longer needed.
+func PrivateKeyToX25519(privateKey PrivateKey) ([32]byte, error) {
+	var scalar [32]byte
+	if len(privateKey) != PrivateKeySize {
+		return scalar, ErrBadPrivateKeyLength
+	}
+	digest := sha512.Sum512(privateKey[:32])
+	defer wipeBytes(digest[:])
+	copy(scalar[:], digest[:32])
+	scalar[0] &= 248
+	scalar[31] &= 127
+	scalar[31] |= 64
+	return scalar, nil
+}
+
+// SharedSecret returns the X25519 shared secret of the Ed25519 private key
+// privateKey and the Ed25519 public key peerPublicKey, both converted as by
+// PrivateKeyToX25519 and PublicKeyToX25519. Both parties get the same result
+// from their own private key and the other's public key.
+//
+// peerPublicKey is checked before conversion, with the errors of
+// PublicKeyToX25519, so peers of small or mixed order, which could force a
+// predictable result, are rejected, as is an all-zero result.
+//
+// The result is a raw Diffie-Hellman output, which isn't uniformly random and
+// mustn't be used as a key directly: pass it through a KDF such as HKDF,
+// together with both public keys, to derive keys from it.
+func SharedSecret(privateKey PrivateKey, peerPublicKey PublicKey) ([32]byte, error) {
+	var shared [32]byte
+	pub, err := peerPublicKey.ToECDH()
+	if err != nil {
+		return shared, err
+	}
+	priv, err := privateKey.ToECDH()
+	if err != nil {
+		return shared, err
+	}
+	// ECDH fails if the result is all zeros.
+	b, err := priv.ECDH(pub)
+	if err != nil {
+		return shared, ErrSmallOrderKey
+	}
+	copy(shared[:], b)
+	wipeBytes(b)
+	return shared, nil
+}
+
+// ToECDH returns pub converted as by PublicKeyToX25519, as a crypto/ecdh
+// X25519 public key, with the errors of PublicKeyToX25519.
+func (pub PublicKey) ToECDH() (*ecdh.PublicKey, error) {
+	u, err := PublicKeyToX25519(pub)
+	if err != nil {
+		return nil, err
+	}
+	return ecdh.X25519().NewPublicKey(u[:])
+}
+
+// ToECDH returns priv converted as by PrivateKeyToX25519, as a crypto/ecdh
+// X25519 private key. Its public key is the result of ToECDH for the public
+// key of priv, and its ECDH method gives the results of SharedSecret.
+func (priv PrivateKey) ToECDH() (*ecdh.PrivateKey, error) {
+	scalar, err := PrivateKeyToX25519(priv)
+	if err != nil {
+		return nil, err
+	}
+	defer wipeBytes(scalar[:])
+	return ecdh.X25519().NewPrivateKey(scalar[:])
+}
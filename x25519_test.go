@@ -0,0 +1,327 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	"golang.org/x/crypto/curve25519"
+)
+
+// libsodiumX25519Tests are the results of
+// crypto_sign_ed25519_pk_to_curve25519 of libsodium 1.0.18 for the keys of
+// libsodiumTests, the key of RFC 8032, Section 7.1, test 1, and the base
+// point.
+var libsodiumX25519Tests = []struct {
+	ed25519, x25519 string
+}{
+	{"9754bb3b69db8091cf11d6175fe7e7c55f15f6adde6f8fd9aae936bbb9a4a3b2", "e6fc331944cd85122e78ece47c21a9c1a3e3d44d57f393e3fb525d062520cb19"},
+	{"a06c41258268c3f3ea13218f8bb4a569dfd70d7715752edac0cdfe2a05bf4bb0", "79efc10d68858c3c1ba3bb53f4307068048948dec12e177141b166cf6c70e541"},
+	{"fa0a44d0d6b9a39dd5a7f15f18fe5ab9bf6e368e0b51264a5d65059191023e80", "1653d9b196106abd2c979d7d6468ae36e69b25fb76109b6dad4faa4f494b6272"},
+	{"d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a", "d85e07ec22b0ad881537c2f44d662d1a143cf830c57aca4305d85c7a90f6b62e"},
+	{"5866666666666666666666666666666666666666666666666666666666666666", "0900000000000000000000000000000000000000000000000000000000000000"},
+}
+
+func TestPublicKeyToX25519Libsodium(t *testing.T) {
+	for _, test := range libsodiumX25519Tests {
+		u, err := PublicKeyToX25519(decodeHex(t, test.ed25519))
+		if err != nil {
+			t.Errorf("%s: %v", test.ed25519, err)
+			continue
+		}
+		if got := hex.EncodeToString(u[:]); got != test.x25519 {
+			t.Errorf("%s: got %s, want %s", test.ed25519, got, test.x25519)
+		}
+	}
+
+	// Flipping the sign of x negates the point, which has the same u.
+	negated := decodeHex(t, libsodiumX25519Tests[0].ed25519)
+	negated[31] ^= 0x80
+	if u, err := PublicKeyToX25519(negated); err != nil || hex.EncodeToString(u[:]) != libsodiumX25519Tests[0].x25519 {
+		t.Errorf("negated key gave %x, %v", u, err)
+	}
+}
+
+func TestPublicKeyToX25519Errors(t *testing.T) {
+	// y = 1, the identity, where 1 - y is zero.
+	identity := append([]byte{1}, make([]byte, 31)...)
+	if _, err := PublicKeyToX25519(identity); err != ErrSmallOrderKey {
+		t.Errorf("y = 1 gave %v", err)
+	}
+	for _, enc := range smallOrderEncodings {
+		if _, err := PublicKeyToX25519(decodeHex(t, enc)); err != ErrSmallOrderKey && err != ErrNonCanonicalKey {
+			t.Errorf("small-order %s gave %v", enc, err)
+		}
+	}
+
+	// y = p + 9, a non-canonical encoding of the point with y = 9, which
+	// has a component of order 2. libsodium 1.0.18 converts both: its
+	// subgroup check only looks at the x-coordinate of L times the point,
+	// which is zero for the points of order 2 too.
+	nonCanonical := decodeHex(t, "f6ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f")
+	for _, tc := range []struct {
+		name string
+		key  []byte
+		want error
+	}{
+		{"non-canonical", nonCanonical, ErrNonCanonicalKey},
+		{"order 2 component", decodeHex(t, "0900000000000000000000000000000000000000000000000000000000000000"), ErrMixedOrderKey},
+		{"mixed order", decodeHex(t, "10eb7c3acfb2bed3e0d6ab89bf5a3d6afddd1176ce4812e38d9fd485058fdb1f"), ErrMixedOrderKey},
+		{"off curve", offCurve, ErrInvalidPublicKey},
+		{"short", identity[:31], ErrBadPublicKeyLength},
+	} {
+		if _, err := PublicKeyToX25519(tc.key); err != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
+
+// libsodiumX25519Private are the results of
+// crypto_sign_ed25519_sk_to_curve25519 of libsodium 1.0.18 for the keys of
+// libsodiumTests, and libsodiumSharedSecrets the results of
+// crypto_scalarmult between each converted private key and the next
+// converted public key, in a cycle.
+var (
+	libsodiumX25519Private = []string{
+		"70c407980c96262a5e3d1b1f9b6db04f52f40791e16a148eacb2376ed8abdb58",
+		"40e19e4162e7c5be09d80dfbd85c88e3af1edb6982a763d6c15f1b56235ec156",
+		"b879fff72620c770b00a56a3a9c2e1333dcf779183fa3f62551a7d24ca529d74",
+	}
+	libsodiumSharedSecrets = []string{
+		"c0f326cf04b82f750decc6cc2a9de5fc3926042c51d8b1ccfd831c1412dfaf60",
+		"04404da9812f4b7e87fce1ae0b78b0e248768dd6baf69d19b7754eaf5118c82a",
+		"e7571ba0a20f18479e1ec39f63cb7222e8de5179cbc185826151eed64ab57f1f",
+	}
+)
+
+func TestConvertPublicKeysToX25519(t *testing.T) {
+	var good []PublicKey
+	for _, tc := range libsodiumX25519Tests {
+		good = append(good, decodeHex(t, tc.ed25519))
+	}
+	bad := []PublicKey{
+		append([]byte{1}, make([]byte, 31)...),
+		offCurve,
+		decodeHex(t, "f6ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f"),
+		make([]byte, 31),
+		// A mixed-order point, rejected by the variable-time check.
+		decodeHex(t, "10eb7c3acfb2bed3e0d6ab89bf5a3d6afddd1176ce4812e38d9fd485058fdb1f"),
+	}
+	for _, enc := range smallOrderEncodings {
+		bad = append(bad, decodeHex(t, enc))
+	}
+	// Invalid keys between valid ones must not disturb their results.
+	var keys []PublicKey
+	for _, key := range bad {
+		public, _, _ := GenerateKey(rand.Reader)
+		keys = append(keys, public, key)
+	}
+	keys = append(keys, good...)
+
+	got, errs := ConvertPublicKeysToX25519(keys)
+	if len(got) != len(keys) || len(errs) != len(keys) {
+		t.Fatalf("got %d results and %d errors for %d keys", len(got), len(errs), len(keys))
+	}
+	failed := 0
+	for i, key := range keys {
+		want, err := PublicKeyToX25519(key)
+		if errs[i] != err || got[i] != want {
+			t.Errorf("key %x: got %x, %v, want %x, %v", key, got[i], errs[i], want, err)
+		}
+		if err != nil {
+			failed++
+		}
+	}
+	if failed != len(bad) {
+		t.Errorf("%d keys failed, want %d", failed, len(bad))
+	}
+
+	if got, errs := ConvertPublicKeysToX25519(nil); len(got) != 0 || len(errs) != 0 {
+		t.Errorf("empty batch gave %v, %v", got, errs)
+	}
+	if _, errs := ConvertPublicKeysToX25519(bad); errs[0] == nil {
+		t.Error("all-invalid batch gave no errors")
+	}
+}
+
+func BenchmarkConvertPublicKeysToX25519(b *testing.B) {
+	keys := make([]PublicKey, 10000)
+	for i := range keys {
+		keys[i], _, _ = GenerateKey(rand.Reader)
+	}
+	b.Run("batch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ConvertPublicKeysToX25519(keys)
+		}
+	})
+	b.Run("single", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, key := range keys {
+				PublicKeyToX25519(key)
+			}
+		}
+	})
+}
+
+func TestPrivateKeyToX25519Libsodium(t *testing.T) {
+	for i, test := range libsodiumTests {
+		scalar, err := PrivateKeyToX25519(decodeHex(t, test.sk))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(scalar[:]); got != libsodiumX25519Private[i] {
+			t.Errorf("%s: got %s, want %s", test.pk, got, libsodiumX25519Private[i])
+		}
+
+		public, err := curve25519.X25519(scalar[:], curve25519.Basepoint)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(public) != libsodiumX25519Tests[i].x25519 {
+			t.Errorf("%s: public key of the converted private key is %x", test.pk, public)
+		}
+
+		next := libsodiumTests[(i+1)%len(libsodiumTests)]
+		peer, err := PublicKeyToX25519(decodeHex(t, next.pk))
+		if err != nil {
+			t.Fatal(err)
+		}
+		shared, err := curve25519.X25519(scalar[:], peer[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(shared); got != libsodiumSharedSecrets[i] {
+			t.Errorf("%s with %s: got %s, want %s", test.pk, next.pk, got, libsodiumSharedSecrets[i])
+		}
+	}
+
+	if _, err := PrivateKeyToX25519(make([]byte, 32)); err != ErrBadPrivateKeyLength {
+		t.Errorf("seed-sized key gave %v", err)
+	}
+}
+
+func TestSharedSecret(t *testing.T) {
+	for i, test := range libsodiumTests {
+		next := libsodiumTests[(i+1)%len(libsodiumTests)]
+		shared, err := SharedSecret(decodeHex(t, test.sk), decodeHex(t, next.pk))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(shared[:]); got != libsodiumSharedSecrets[i] {
+			t.Errorf("%s with %s: got %s, want %s", test.pk, next.pk, got, libsodiumSharedSecrets[i])
+		}
+	}
+
+	for i := 0; i < 10; i++ {
+		publicA, privateA, _ := GenerateKey(rand.Reader)
+		publicB, privateB, _ := GenerateKey(rand.Reader)
+		ab, err1 := SharedSecret(privateA, publicB)
+		ba, err2 := SharedSecret(privateB, publicA)
+		if err1 != nil || err2 != nil {
+			t.Fatal(err1, err2)
+		}
+		if ab != ba {
+			t.Fatalf("A·b = %x but B·a = %x", ab, ba)
+		}
+	}
+}
+
+func TestSharedSecretErrors(t *testing.T) {
+	_, private, _ := GenerateKey(rand.Reader)
+	for _, enc := range smallOrderEncodings {
+		if _, err := SharedSecret(private, decodeHex(t, enc)); err != ErrSmallOrderKey && err != ErrNonCanonicalKey {
+			t.Errorf("small-order peer %s gave %v", enc, err)
+		}
+	}
+	if _, err := SharedSecret(private, decodeHex(t, "10eb7c3acfb2bed3e0d6ab89bf5a3d6afddd1176ce4812e38d9fd485058fdb1f")); err != ErrMixedOrderKey {
+		t.Errorf("mixed-order peer gave %v", err)
+	}
+	if _, err := SharedSecret(private, offCurve); err != ErrInvalidPublicKey {
+		t.Errorf("off-curve peer gave %v", err)
+	}
+	public, _, _ := GenerateKey(rand.Reader)
+	if _, err := SharedSecret(private[:32], public); err != ErrBadPrivateKeyLength {
+		t.Errorf("short private key gave %v", err)
+	}
+}
+
+func TestToECDH(t *testing.T) {
+	for i, test := range libsodiumTests {
+		priv, err := PrivateKey(decodeHex(t, test.sk)).ToECDH()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if priv.Curve() != ecdh.X25519() {
+			t.Fatalf("%s: private key on %v", test.pk, priv.Curve())
+		}
+		if got := hex.EncodeToString(priv.Bytes()); got != libsodiumX25519Private[i] {
+			t.Errorf("%s: got private key %s, want %s", test.pk, got, libsodiumX25519Private[i])
+		}
+		pub, err := PublicKey(decodeHex(t, test.pk)).ToECDH()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(pub.Bytes()); got != libsodiumX25519Tests[i].x25519 {
+			t.Errorf("%s: got public key %s, want %s", test.pk, got, libsodiumX25519Tests[i].x25519)
+		}
+		if !priv.PublicKey().Equal(pub) {
+			t.Errorf("%s: private key has public key %x", test.pk, priv.PublicKey().Bytes())
+		}
+
+		next := libsodiumTests[(i+1)%len(libsodiumTests)]
+		peer, err := PublicKey(decodeHex(t, next.pk)).ToECDH()
+		if err != nil {
+			t.Fatal(err)
+		}
+		shared, err := priv.ECDH(peer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(shared); got != libsodiumSharedSecrets[i] {
+			t.Errorf("%s with %s: got %s, want %s", test.pk, next.pk, got, libsodiumSharedSecrets[i])
+		}
+		own, _ := SharedSecret(decodeHex(t, test.sk), decodeHex(t, next.pk))
+		if !bytes.Equal(shared, own[:]) {
+			t.Errorf("%s with %s: ECDH gave %x, SharedSecret %x", test.pk, next.pk, shared, own)
+		}
+	}
+
+	// Against keys made by crypto/ecdh itself.
+	_, private, _ := GenerateKey(rand.Reader)
+	priv, err := private.ToECDH()
+	if err != nil {
+		t.Fatal(err)
+	}
+	remote, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err1 := priv.ECDH(remote.PublicKey())
+	b, err2 := remote.ECDH(priv.PublicKey())
+	if err1 != nil || err2 != nil || !bytes.Equal(a, b) {
+		t.Errorf("shared secrets %x, %v and %x, %v", a, err1, b, err2)
+	}
+}
+
+func TestToECDHErrors(t *testing.T) {
+	for _, enc := range smallOrderEncodings {
+		if _, err := PublicKey(decodeHex(t, enc)).ToECDH(); err != ErrSmallOrderKey && err != ErrNonCanonicalKey {
+			t.Errorf("small-order %s gave %v", enc, err)
+		}
+	}
+	if _, err := PublicKey(offCurve).ToECDH(); err != ErrInvalidPublicKey {
+		t.Errorf("off-curve key gave %v", err)
+	}
+	if _, err := PublicKey(make([]byte, 31)).ToECDH(); err != ErrBadPublicKeyLength {
+		t.Errorf("short public key gave %v", err)
+	}
+	if _, err := PrivateKey(make([]byte, 32)).ToECDH(); err != ErrBadPrivateKeyLength {
+		t.Errorf("seed-sized private key gave %v", err)
+	}
+}
@@ -0,0 +1,301 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"crypto/ecdh"
+	"crypto/hkdf"
+	cryptorand "crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"io"
+)
+
+// X3DH, specified by Signal at https://signal.org/docs/specifications/x3dh/,
+// lets Alice agree on a key with Bob while he is offline, from a prekey
+// bundle he published: his identity key IKB, a signed prekey SPKB with his
+// signature of it, and optionally a one-time prekey OPKB. Alice combines her
+// identity key IKA and an ephemeral key EKA with these in four X25519
+// exchanges,
+//
+//	DH1 = DH(IKA, SPKB), DH2 = DH(EKA, IKB), DH3 = DH(EKA, SPKB),
+//	DH4 = DH(EKA, OPKB),
+//
+// the last only if there was a one-time prekey, and derives the shared
+// secret SK from them with HKDF-SHA256. Here the identity keys are Ed25519
+// keys, converted for the exchanges as by PrivateKeyToX25519 and
+// PublicKeyToX25519, and the signed prekey is signed with XEdDSA under the
+// converted identity key, as Signal's clients sign with their X25519
+// identity keys. The caller encrypts the first message with SK and the
+// associated data AD, and goes on to the session protocol.
+
+const (
+	// X3DHSecretSize is the size, in bytes, of the shared secret SK.
+	X3DHSecretSize = 32
+	// X3DHAssociatedDataSize is the size, in bytes, of the associated data
+	// AD, Encode(IKA) || Encode(IKB).
+	X3DHAssociatedDataSize = 66
+)
+
+var (
+	// ErrX3DHBundleSignature is returned when the signature of the signed
+	// prekey of a bundle doesn't verify under its identity key.
+	ErrX3DHBundleSignature = errors.New("ed25519: invalid X3DH signed prekey signature")
+
+	// ErrX3DHPrekeyMismatch is returned by X3DHRespond when the prekeys
+	// passed in are not the ones named by the initial message, including a
+	// missing one-time prekey that the message says was used.
+	ErrX3DHPrekeyMismatch = errors.New("ed25519: X3DH prekeys do not match the initial message")
+
+	errX3DHLowOrder = errors.New("ed25519: X3DH key exchange with a low-order key")
+)
+
+// X3DHPrekey is a public X25519 prekey with the identifier by which its
+// owner finds the private key.
+type X3DHPrekey struct {
+	ID        uint32
+	PublicKey [32]byte
+}
+
+// X3DHPrivatePrekey is a prekey of its owner, with the private key.
+type X3DHPrivatePrekey struct {
+	ID         uint32
+	PrivateKey [32]byte
+}
+
+// GenerateX3DHPrekey generates a prekey with identifier id, using entropy
+// from rand. If rand is nil, crypto/rand.Reader will be used.
+func GenerateX3DHPrekey(id uint32, rand io.Reader) (*X3DHPrivatePrekey, error) {
+	if rand == nil {
+		rand = cryptorand.Reader
+	}
+	k := &X3DHPrivatePrekey{ID: id}
+	if _, err := io.ReadFull(rand, k.PrivateKey[:]); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// Public returns the public prekey of k.
+func (k *X3DHPrivatePrekey) Public() X3DHPrekey {
+	priv, _ := ecdh.X25519().NewPrivateKey(k.PrivateKey[:])
+	p := X3DHPrekey{ID: k.ID}
+	copy(p.PublicKey[:], priv.PublicKey().Bytes())
+	return p
+}
+
+// x3dhEncode returns Encode(PK) of the specification, the curve type byte
+// 0x05 followed by the u-coordinate.
+func x3dhEncode(u [32]byte) []byte {
+	return append([]byte{0x05}, u[:]...)
+}
+
+// SignX3DHPrekey returns the XEdDSA signature of Encode(prekey.PublicKey) by
+// the X25519 key converted from identity, for the signed prekey of a bundle,
+// using the randomness of rand, or of crypto/rand.Reader if rand is nil.
+func SignX3DHPrekey(identity PrivateKey, prekey X3DHPrekey, rand io.Reader) ([]byte, error) {
+	if rand == nil {
+		rand = cryptorand.Reader
+	}
+	priv, err := PrivateKeyToX25519(identity)
+	if err != nil {
+		return nil, err
+	}
+	defer wipeBytes(priv[:])
+	var random [64]byte
+	if _, err := io.ReadFull(rand, random[:]); err != nil {
+		return nil, err
+	}
+	sig, err := XEdDSASign(priv, x3dhEncode(prekey.PublicKey), random)
+	if err != nil {
+		return nil, err
+	}
+	return sig[:], nil
+}
+
+// X3DHPrekeyBundle is the bundle that a party publishes so that others can
+// start sessions with it.
+type X3DHPrekeyBundle struct {
+	// IdentityKey is the Ed25519 identity key of the owner.
+	IdentityKey PublicKey
+	// SignedPrekey is rotated from time to time, and signed by the
+	// identity key in SignedPrekeySignature, as by SignX3DHPrekey.
+	SignedPrekey          X3DHPrekey
+	SignedPrekeySignature []byte
+	// OneTimePrekey is nil once the server has run out of them.
+	OneTimePrekey *X3DHPrekey
+}
+
+// Verify checks that the identity key of b is valid and has signed its
+// signed prekey. It returns the errors of PublicKeyToX25519 for the identity
+// key, or ErrX3DHBundleSignature.
+func (b *X3DHPrekeyBundle) Verify() error {
+	ik, err := PublicKeyToX25519(b.IdentityKey)
+	if err != nil {
+		return err
+	}
+	if !XEdDSAVerify(ik, x3dhEncode(b.SignedPrekey.PublicKey), b.SignedPrekeySignature) {
+		return ErrX3DHBundleSignature
+	}
+	return nil
+}
+
+// X3DHInitialMessage is what the initiator sends along with its first
+// ciphertext, for the responder to compute the same secret.
+type X3DHInitialMessage struct {
+	// IdentityKey is the Ed25519 identity key of the initiator.
+	IdentityKey PublicKey
+	// EphemeralKey is the X25519 public key EKA.
+	EphemeralKey [32]byte
+	// SignedPrekeyID and OneTimePrekeyID name the prekeys of the bundle
+	// that were used; OneTimePrekeyID is nil if the bundle had none.
+	SignedPrekeyID  uint32
+	OneTimePrekeyID *uint32
+}
+
+// x3dhDH returns the X25519 exchange of priv and pub, refusing an all-zero
+// result.
+func x3dhDH(priv, pub [32]byte) ([]byte, error) {
+	k, err := ecdh.X25519().NewPrivateKey(priv[:])
+	if err != nil {
+		return nil, err
+	}
+	p, err := ecdh.X25519().NewPublicKey(pub[:])
+	if err != nil {
+		return nil, err
+	}
+	shared, err := k.ECDH(p)
+	if err != nil {
+		return nil, errX3DHLowOrder
+	}
+	return shared, nil
+}
+
+// x3dhKDF returns HKDF-SHA256 of F || km, where F is 32 0xff bytes, with a
+// zero salt and the application's info string.
+func x3dhKDF(km [][]byte, info []byte) ([]byte, error) {
+	ikm := make([]byte, 32, 32+32*len(km))
+	for i := range ikm {
+		ikm[i] = 0xff
+	}
+	for _, dh := range km {
+		ikm = append(ikm, dh...)
+		wipeBytes(dh)
+	}
+	defer wipeBytes(ikm)
+	return hkdf.Key(sha256.New, ikm, make([]byte, sha256.Size), string(info), X3DHSecretSize)
+}
+
+// X3DHInitiate runs the initiator's side of X3DH with the identity key
+// identity against bundle, with an ephemeral key from rand, or from
+// crypto/rand.Reader if rand is nil. info identifies the application. It
+// verifies the bundle first, with the errors of Verify, and returns the
+// shared secret SK, the associated data AD and the initial message for the
+// responder. The one-time prekey is used if the bundle has one.
+func X3DHInitiate(identity PrivateKey, bundle *X3DHPrekeyBundle, info []byte, rand io.Reader) (sk, ad []byte, msg *X3DHInitialMessage, err error) {
+	if rand == nil {
+		rand = cryptorand.Reader
+	}
+	var ek [32]byte
+	if _, err := io.ReadFull(rand, ek[:]); err != nil {
+		return nil, nil, nil, err
+	}
+	defer wipeBytes(ek[:])
+	return x3dhInitiate(identity, bundle, info, ek)
+}
+
+func x3dhInitiate(identity PrivateKey, bundle *X3DHPrekeyBundle, info []byte, ek [32]byte) (sk, ad []byte, msg *X3DHInitialMessage, err error) {
+	if err := bundle.Verify(); err != nil {
+		return nil, nil, nil, err
+	}
+	ika, err := PrivateKeyToX25519(identity)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer wipeBytes(ika[:])
+	ikaPub, err := PublicKeyToX25519(identity.Public().(PublicKey))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	ikb, _ := PublicKeyToX25519(bundle.IdentityKey)
+
+	ekPriv, err := ecdh.X25519().NewPrivateKey(ek[:])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	msg = &X3DHInitialMessage{
+		IdentityKey:    append(PublicKey(nil), identity[32:]...),
+		SignedPrekeyID: bundle.SignedPrekey.ID,
+	}
+	copy(msg.EphemeralKey[:], ekPriv.PublicKey().Bytes())
+
+	pairs := [][2][32]byte{
+		{ika, bundle.SignedPrekey.PublicKey},
+		{ek, ikb},
+		{ek, bundle.SignedPrekey.PublicKey},
+	}
+	if bundle.OneTimePrekey != nil {
+		id := bundle.OneTimePrekey.ID
+		msg.OneTimePrekeyID = &id
+		pairs = append(pairs, [2][32]byte{ek, bundle.OneTimePrekey.PublicKey})
+	}
+	km := make([][]byte, len(pairs))
+	for i, pair := range pairs {
+		if km[i], err = x3dhDH(pair[0], pair[1]); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	if sk, err = x3dhKDF(km, info); err != nil {
+		return nil, nil, nil, err
+	}
+	return sk, append(x3dhEncode(ikaPub), x3dhEncode(ikb)...), msg, nil
+}
+
+// X3DHRespond runs the responder's side of X3DH for the initial message msg,
+// with the identity key identity and the private prekeys that msg names:
+// signedPrekey, and oneTimePrekey, which must be nil if msg used none. It
+// returns the shared secret SK and the associated data AD of X3DHInitiate,
+// or ErrX3DHPrekeyMismatch if the prekeys are not the ones named by msg.
+// The caller should delete the one-time prekey once the first message
+// decrypts.
+func X3DHRespond(identity PrivateKey, signedPrekey, oneTimePrekey *X3DHPrivatePrekey, msg *X3DHInitialMessage, info []byte) (sk, ad []byte, err error) {
+	if signedPrekey == nil || signedPrekey.ID != msg.SignedPrekeyID ||
+		(msg.OneTimePrekeyID == nil) != (oneTimePrekey == nil) ||
+		(oneTimePrekey != nil && oneTimePrekey.ID != *msg.OneTimePrekeyID) {
+		return nil, nil, ErrX3DHPrekeyMismatch
+	}
+	ikb, err := PrivateKeyToX25519(identity)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer wipeBytes(ikb[:])
+	ikbPub, err := PublicKeyToX25519(identity.Public().(PublicKey))
+	if err != nil {
+		return nil, nil, err
+	}
+	ika, err := PublicKeyToX25519(msg.IdentityKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pairs := [][2][32]byte{
+		{signedPrekey.PrivateKey, ika},
+		{ikb, msg.EphemeralKey},
+		{signedPrekey.PrivateKey, msg.EphemeralKey},
+	}
+	if oneTimePrekey != nil {
+		pairs = append(pairs, [2][32]byte{oneTimePrekey.PrivateKey, msg.EphemeralKey})
+	}
+	km := make([][]byte, len(pairs))
+	for i, pair := range pairs {
+		if km[i], err = x3dhDH(pair[0], pair[1]); err != nil {
+			return nil, nil, err
+		}
+	}
+	if sk, err = x3dhKDF(km, info); err != nil {
+		return nil, nil, err
+	}
+	return sk, append(x3dhEncode(ika), x3dhEncode(ikbPub)...), nil
+}
@@ -0,0 +1,232 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func decodeKey32(t *testing.T, s string) [32]byte {
+	t.Helper()
+	var k [32]byte
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != 32 {
+		t.Fatalf("bad key %q", s)
+	}
+	copy(k[:], b)
+	return k
+}
+
+// x3dhParties returns the identity keys of Alice and Bob, and Bob's signed
+// and one-time prekeys and bundle, with fixed keys: the seeds of RFC 8032,
+// Section 7.1, tests 1 and 2, and the X25519 private keys of RFC 7748,
+// Section 6.1.
+func x3dhParties(t *testing.T) (alice, bob PrivateKey, spk, opk *X3DHPrivatePrekey, bundle *X3DHPrekeyBundle) {
+	aliceSeed := decodeKey32(t, "9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60")
+	bobSeed := decodeKey32(t, "4ccd089b28ff96da9db6c346ec114e0f5b8a319f35aba624da8cf6ed4fb8a6fb")
+	alice, bob = NewKeyFromSeed(aliceSeed[:]), NewKeyFromSeed(bobSeed[:])
+	spk = &X3DHPrivatePrekey{ID: 1, PrivateKey: decodeKey32(t, "77076d0a7318a57d3c16c17251b26645df4c2f87ebc0992ab177fba51db92c2a")}
+	opk = &X3DHPrivatePrekey{ID: 7, PrivateKey: decodeKey32(t, "5dab087e624a8a4b79e17f8b83800ee66f3bb1292618b6fd1c2f8b27ff88e0eb")}
+	sig, err := SignX3DHPrekey(bob, spk.Public(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opkPublic := opk.Public()
+	bundle = &X3DHPrekeyBundle{
+		IdentityKey:           bob.Public().(PublicKey),
+		SignedPrekey:          spk.Public(),
+		SignedPrekeySignature: sig,
+		OneTimePrekey:         &opkPublic,
+	}
+	return alice, bob, spk, opk, bundle
+}
+
+// TestX3DHVector checks the secrets of fixed keys and ephemeral key, with and
+// without the one-time prekey, which were also computed with the Ed25519 to
+// X25519 conversions and X25519 of libsodium and a separate HKDF.
+func TestX3DHVector(t *testing.T) {
+	alice, bob, spk, opk, bundle := x3dhParties(t)
+	ek := decodeKey32(t, "a546e36bf0527c9d3b16154b82465edd62144c0ac1fc5a18506a2244ba449ac4")
+	info := []byte("MyProtocol")
+	wantAD := "05d85e07ec22b0ad881537c2f44d662d1a143cf830c57aca4305d85c7a90f6b62e0525c704c594b88afc00a76b69d1ed2b984d7e22550f3ed0802d04fbcd07d38d47"
+
+	for _, test := range []struct {
+		opk *X3DHPrivatePrekey
+		sk  string
+	}{
+		{opk, "fef55f80c3b27617878d92c16da1e01800f389f0c8c0f98c2a7ef3bb278de9d1"},
+		{nil, "6fab0342ff6b634628ce110d985c9fbf000efbe23a8cfa034dec036fee188804"},
+	} {
+		b := *bundle
+		if test.opk == nil {
+			b.OneTimePrekey = nil
+		}
+		sk, ad, msg, err := x3dhInitiate(alice, &b, info, ek)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(sk); got != test.sk {
+			t.Errorf("got SK %s, want %s", got, test.sk)
+		}
+		if got := hex.EncodeToString(ad); got != wantAD {
+			t.Errorf("got AD %s, want %s", got, wantAD)
+		}
+		if got := hex.EncodeToString(msg.EphemeralKey[:]); got != "1c9fd88f45606d932a80c71824ae151d15d73e77de38e8e000852e614fae7019" {
+			t.Errorf("got ephemeral public key %s", got)
+		}
+		if (msg.OneTimePrekeyID == nil) != (test.opk == nil) {
+			t.Errorf("one-time prekey ID %v with prekey %v", msg.OneTimePrekeyID, test.opk)
+		}
+
+		skB, adB, err := X3DHRespond(bob, spk, test.opk, msg, info)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(sk, skB) || !bytes.Equal(ad, adB) {
+			t.Error("the responder derived a different SK or AD")
+		}
+	}
+}
+
+func TestX3DH(t *testing.T) {
+	_, alice, _ := GenerateKey(nil)
+	_, bob, _ := GenerateKey(nil)
+	spk, err := GenerateX3DHPrekey(42, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opk, _ := GenerateX3DHPrekey(43, nil)
+	sig, err := SignX3DHPrekey(bob, spk.Public(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opkPublic := opk.Public()
+	bundle := &X3DHPrekeyBundle{bob.Public().(PublicKey), spk.Public(), sig, &opkPublic}
+
+	sk, ad, msg, err := X3DHInitiate(alice, bundle, []byte("test"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sk) != X3DHSecretSize || len(ad) != X3DHAssociatedDataSize {
+		t.Errorf("got %d bytes of SK and %d of AD", len(sk), len(ad))
+	}
+	if msg.SignedPrekeyID != 42 || msg.OneTimePrekeyID == nil || *msg.OneTimePrekeyID != 43 {
+		t.Errorf("initial message names prekeys %d and %v", msg.SignedPrekeyID, msg.OneTimePrekeyID)
+	}
+	skB, adB, err := X3DHRespond(bob, spk, opk, msg, []byte("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(sk, skB) || !bytes.Equal(ad, adB) {
+		t.Fatal("the parties derived different secrets")
+	}
+	if other, _, _, _ := X3DHInitiate(alice, bundle, []byte("test"), nil); bytes.Equal(other, sk) {
+		t.Error("two runs gave the same SK")
+	}
+	if skB, _, _ := X3DHRespond(bob, spk, opk, msg, []byte("other")); bytes.Equal(skB, sk) {
+		t.Error("a different info string gave the same SK")
+	}
+}
+
+func TestX3DHBundleSignature(t *testing.T) {
+	alice, _, _, _, bundle := x3dhParties(t)
+	if err := bundle.Verify(); err != nil {
+		t.Fatalf("valid bundle rejected: %v", err)
+	}
+
+	bad := *bundle
+	bad.SignedPrekeySignature = append([]byte(nil), bundle.SignedPrekeySignature...)
+	bad.SignedPrekeySignature[0] ^= 1
+	if _, _, _, err := X3DHInitiate(alice, &bad, nil, nil); err != ErrX3DHBundleSignature {
+		t.Errorf("altered signature: got %v", err)
+	}
+
+	// A prekey swapped in by the server isn't covered by the signature.
+	swapped := *bundle
+	other, _ := GenerateX3DHPrekey(bundle.SignedPrekey.ID, nil)
+	swapped.SignedPrekey = other.Public()
+	if err := swapped.Verify(); err != ErrX3DHBundleSignature {
+		t.Errorf("swapped signed prekey: got %v", err)
+	}
+
+	// Nor is a signature by another identity, or none at all.
+	forged := *bundle
+	forged.SignedPrekeySignature, _ = SignX3DHPrekey(alice, bundle.SignedPrekey, nil)
+	if err := forged.Verify(); err != ErrX3DHBundleSignature {
+		t.Errorf("signature by another identity: got %v", err)
+	}
+	forged.SignedPrekeySignature = nil
+	if err := forged.Verify(); err != ErrX3DHBundleSignature {
+		t.Errorf("missing signature: got %v", err)
+	}
+
+	badIdentity := *bundle
+	badIdentity.IdentityKey = make(PublicKey, PublicKeySize)
+	if err := badIdentity.Verify(); err == nil {
+		t.Error("bundle with a small-order identity key accepted")
+	}
+}
+
+func TestX3DHOneTimePrekeys(t *testing.T) {
+	alice, bob, spk, opk, bundle := x3dhParties(t)
+
+	// Without a one-time prekey the exchange uses three DHs.
+	noOPK := *bundle
+	noOPK.OneTimePrekey = nil
+	sk, _, msg, err := X3DHInitiate(alice, &noOPK, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.OneTimePrekeyID != nil {
+		t.Error("initial message names a one-time prekey that wasn't in the bundle")
+	}
+	if _, _, err := X3DHRespond(bob, spk, opk, msg, nil); err != ErrX3DHPrekeyMismatch {
+		t.Errorf("unused one-time prekey passed: got %v", err)
+	}
+	skB, _, err := X3DHRespond(bob, spk, nil, msg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(sk, skB) {
+		t.Error("secrets without a one-time prekey differ")
+	}
+
+	// A message that used a one-time prekey needs it, the right one.
+	_, _, msg, err = X3DHInitiate(alice, bundle, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := X3DHRespond(bob, spk, nil, msg, nil); err != ErrX3DHPrekeyMismatch {
+		t.Errorf("missing one-time prekey: got %v", err)
+	}
+	wrong, _ := GenerateX3DHPrekey(8, nil)
+	if _, _, err := X3DHRespond(bob, spk, wrong, msg, nil); err != ErrX3DHPrekeyMismatch {
+		t.Errorf("wrong one-time prekey: got %v", err)
+	}
+	otherSPK := *spk
+	otherSPK.ID++
+	if _, _, err := X3DHRespond(bob, &otherSPK, opk, msg, nil); err != ErrX3DHPrekeyMismatch {
+		t.Errorf("wrong signed prekey: got %v", err)
+	}
+	if _, _, err := X3DHRespond(bob, nil, opk, msg, nil); err != ErrX3DHPrekeyMismatch {
+		t.Errorf("missing signed prekey: got %v", err)
+	}
+}
+
+func TestX3DHLowOrderPrekey(t *testing.T) {
+	alice, bob, spk, opk, bundle := x3dhParties(t)
+	low := *bundle
+	low.OneTimePrekey = &X3DHPrekey{ID: 7}
+	if _, _, _, err := X3DHInitiate(alice, &low, nil, nil); err == nil {
+		t.Error("low-order one-time prekey accepted")
+	}
+	_, _, msg, _ := X3DHInitiate(alice, bundle, nil, nil)
+	msg.EphemeralKey = [32]byte{1}
+	if _, _, err := X3DHRespond(bob, spk, opk, msg, nil); err == nil {
+		t.Error("low-order ephemeral key accepted")
+	}
+}
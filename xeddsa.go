@@ -0,0 +1,138 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"crypto/sha512"
+	"crypto/subtle"
+
+	"github.com/agl/ed25519/edwards25519"
+)
+
+// XEdDSA, specified by Signal at https://signal.org/docs/specifications/xeddsa/,
+// signs with an X25519 private key. The Edwards public key of the scalar k is
+// only determined by the X25519 public key up to the sign of x, so the signer
+// uses whichever of k and -k has the Edwards public key A with a sign of 0,
+// which the verifier can then compute from the u-coordinate alone. The
+// signatures are Ed25519 signatures under A, with a nonce derived from the
+// scalar, the message and 64 random bytes.
+
+// xeddsaHash returns hash_i of the XEdDSA specification, the SHA-512 hash of
+// the 32-byte little-endian encoding of 2^256 - 1 - i followed by parts.
+func xeddsaHash(i byte, parts ...[]byte) [64]byte {
+	var prefix [32]byte
+	for j := range prefix {
+		prefix[j] = 0xff
+	}
+	prefix[0] -= i
+	h := sha512.New()
+	h.Write(prefix[:])
+	for _, p := range parts {
+		h.Write(p)
+	}
+	var digest [64]byte
+	h.Sum(digest[:0])
+	return digest
+}
+
+// xeddsaKeyPair implements calculate_key_pair of the XEdDSA specification: it
+// returns the Edwards public key A, with a sign bit of 0, and the scalar a,
+// which is k or -k modulo L so that aB = A. k is first clamped, as X25519
+// clamps it and libsignal does before signing, so that A corresponds to the
+// X25519 public key of k even if k wasn't clamped already. A clamped k is a
+// multiple of 8 below 8L, so a is never zero.
+func xeddsaKeyPair(k *[32]byte) ([]byte, *edwards25519.Scalar) {
+	var wide [64]byte
+	copy(wide[:], k[:])
+	wide[0] &= 248
+	wide[31] &= 127
+	wide[31] |= 64
+	a, _ := edwards25519.NewScalar().SetUniformBytes(wide[:])
+	wipeBytes(wide[:])
+	A := new(edwards25519.Point).ScalarBaseMult(a).Bytes()
+
+	// The sign of x isn't revealed by the X25519 public key, so negate in
+	// constant time.
+	aBytes := a.Bytes()
+	negated := edwards25519.NewScalar().Negate(a).Bytes()
+	subtle.ConstantTimeCopy(int(A[31]>>7), aBytes, negated)
+	a.SetCanonicalBytes(aBytes)
+	wipeBytes(aBytes)
+	wipeBytes(negated)
+	A[31] &= 0x7f
+	return A, a
+}
+
+// XEdDSASign signs message with the X25519 private key x25519Priv, following
+// xeddsa_sign of the XEdDSA specification. random must be 64 bytes from a
+// cryptographically secure source, fresh for every signature.
+//
+// x25519Priv may be any X25519 private key, such as the bytes of an
+// ecdh.PrivateKey: it is clamped as X25519 does. The signature verifies with
+// XEdDSAVerify under the X25519 public key of x25519Priv, and with Verify
+// under the Ed25519 public key that XEdDSAVerify derives from it. The error
+// is always nil.
+func XEdDSASign(x25519Priv [32]byte, message []byte, random [64]byte) ([64]byte, error) {
+	var sig [64]byte
+	A, a := xeddsaKeyPair(&x25519Priv)
+	aBytes := a.Bytes()
+	defer wipeBytes(aBytes)
+
+	digest := xeddsaHash(1, aBytes, message, random[:])
+	r, _ := edwards25519.NewScalar().SetUniformBytes(digest[:])
+	R := new(edwards25519.Point).ScalarBaseMult(r).Bytes()
+
+	h := sha512.New()
+	h.Write(R)
+	h.Write(A)
+	h.Write(message)
+	h.Sum(digest[:0])
+	k, _ := edwards25519.NewScalar().SetUniformBytes(digest[:])
+	s := edwards25519.NewScalar().MultiplyAdd(k, a, r)
+
+	copy(sig[:32], R)
+	copy(sig[32:], s.Bytes())
+	wipeBytes(digest[:])
+	*a = edwards25519.Scalar{}
+	*r = edwards25519.Scalar{}
+	return sig, nil
+}
+
+// XEdDSAVerify reports whether sig is a valid XEdDSA signature of message by
+// the X25519 public key x25519Pub, following xeddsa_verify of the XEdDSA
+// specification: x25519Pub must be canonical, and S less than 2^253 rather
+// than the group order. In addition, public keys whose Edwards point has small
+// order, for which anyone could produce signatures, are rejected.
+//
+// As in libsignal, the top bit of S is the sign of the Edwards public key,
+// and is cleared before use. XEdDSASign always leaves it 0, but libsignal's
+// calculateSignature doesn't negate the key and sets it instead.
+func XEdDSAVerify(x25519Pub [32]byte, message, sig []byte) bool {
+	if len(sig) != SignatureSize || sig[63]&0x60 != 0 {
+		return false
+	}
+	A, err := edwards25519.MontgomeryToEdwards(x25519Pub, int(sig[63]>>7))
+	if err != nil || A.IsSmallOrder() {
+		return false
+	}
+
+	var digest [64]byte
+	h := sha512.New()
+	h.Write(sig[:32])
+	h.Write(A.Bytes())
+	h.Write(message)
+	h.Sum(digest[:0])
+	var k, s [32]byte
+	edwards25519.ScReduce(&k, &digest)
+	copy(s[:], sig[32:])
+	s[31] &= 0x7f
+
+	minusA := new(edwards25519.Point).Negate(A).ExtendedGroupElement()
+	var R edwards25519.ProjectiveGroupElement
+	edwards25519.GeDoubleScalarMultVartime(&R, &k, &minusA, &s)
+	var check [32]byte
+	R.ToBytes(&check)
+	return subtle.ConstantTimeCompare(check[:], sig[:32]) == 1
+}
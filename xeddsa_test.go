@@ -0,0 +1,235 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	"golang.org/x/crypto/curve25519"
+)
+
+// xeddsaTests were computed with an implementation of the XEdDSA
+// specification in Python, from clamped private keys. The Edwards public keys
+// of the first and last have a sign of 1, so those private keys are negated.
+var xeddsaTests = []struct {
+	private, public, message, random, sig, edwards string
+}{
+	{
+		"d89a67eae269ce63128418c4449305075f26a22c7a562170744883303be40e4f",
+		"01c1457b8670cd1a25fbdecc787feac4afea227e39ac41bd5aaaddb488266f61",
+		"",
+		"7488f34375e71b2188069793613a3d0b21700a8b583031a1ce74e9f42c4d6ed8dfcf94e5973386d0bafce75dde1c2a82899262082dd292ffd564a16176c73295",
+		"faf9193484cc77382a873ebf9075d1a33a8ccae83efbe1567d623bef3e9a16a752ba6b638a51e3777f62fae33003987e5900f1bccd0806d524d6081a7ffbeb0c",
+		"5444feb4b85ae67bf536dc288123308f454ee8ffeba2e97466e3761fd8fa575a",
+	},
+	{
+		"c0e8342ec9734af2eca1a3770e3deb9ee2850d7f0d259d143aaf4883d99c1449",
+		"6498d97106637199484506a5b4667fe399f4537ae72b28155709c1b8a2eba467",
+		"41fc70fb6c958bce4865fadd17",
+		"9a3b25b7990cf9dc1ddc3abe23258c4f5b8e692743c71e822a15e8df3d89d7e82e0b38836da8d44c5548eada1c196c1645cf71b05c831976faf9180c3138da1d",
+		"7752c80d83885afe116082e1f69025aeb37f3d93db82e0d80dfd6ae6f1af948a2bcd96369cd481d739fcedb2ceb45fbf1e268634d9d754695daf78e6efb37407",
+		"0a91659a148fe459c5f19ad864397bf12738c9697ea26ffa2ba4018a7d56e423",
+	},
+	{
+		"789a64c19d3bc0c638add455c3925fc2f8a027f1efa04168c92a4b7f2fe7ff5e",
+		"3d317a11d960593cb5479e8c859c3bc378576c795dea28dc32dee68297078551",
+		"4de0da51ade3b600bd18dad3e54c1e1e0471ca81e515691d18fb",
+		"365d82928dddba8f17dca1db28a0773dca7dbea769cd5d3b8963f15a62f74a6c91a8ce37b14e5ecae94b7a8ca96d4f20e7b1a1a29754932b320b18d4f0a7c375",
+		"8c7aa597914ecb18da3fc71de0787610e4986f56b6fced15533aefb99892dbc2d255001d991c7402fd0c3cb0e0fd3b938a63f88ca8593f203d8694c7f358c504",
+		"bd53ddf416deb4a282f105fab727d4b3baf2c382445c19c8c4ef80c17d9a3376",
+	},
+}
+
+func TestXEdDSAVectors(t *testing.T) {
+	for _, test := range xeddsaTests {
+		private := [32]byte(decodeHex(t, test.private))
+		public := [32]byte(decodeHex(t, test.public))
+		message := decodeHex(t, test.message)
+		sig, err := XEdDSASign(private, message, [64]byte(decodeHex(t, test.random)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(sig[:]); got != test.sig {
+			t.Errorf("%s: got %s, want %s", test.private, got, test.sig)
+		}
+		if !XEdDSAVerify(public, message, sig[:]) {
+			t.Errorf("%s: signature rejected", test.private)
+		}
+		if !Verify(decodeHex(t, test.edwards), message, sig[:]) {
+			t.Errorf("%s: signature rejected under the Edwards key", test.private)
+		}
+	}
+}
+
+func TestXEdDSARoundTrip(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		var private [32]byte
+		var random [64]byte
+		rand.Read(private[:])
+		rand.Read(random[:])
+		public, err := curve25519.X25519(private[:], curve25519.Basepoint)
+		if err != nil {
+			t.Fatal(err)
+		}
+		private[0] &= 248
+		private[31] &= 127
+		private[31] |= 64
+		message := []byte("message")
+
+		sig, err := XEdDSASign(private, message, random)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !XEdDSAVerify([32]byte(public), message, sig[:]) {
+			t.Fatalf("%x: signature rejected", private)
+		}
+		if XEdDSAVerify([32]byte(public), []byte("other"), sig[:]) {
+			t.Fatalf("%x: signature of another message accepted", private)
+		}
+
+		// The signature is an Ed25519 signature under the Edwards key
+		// with a sign of 0, which converts back to the X25519 key.
+		edwards, _ := xeddsaKeyPair(&private)
+		if edwards[31]&0x80 != 0 {
+			t.Fatalf("%x: Edwards key %x has a sign of 1", private, edwards)
+		}
+		if !Verify(edwards, message, sig[:]) {
+			t.Fatalf("%x: signature rejected by Verify", private)
+		}
+		if u, err := PublicKeyToX25519(edwards); err != nil || !bytes.Equal(u[:], public) {
+			t.Fatalf("%x: Edwards key converts to %x, %v", private, u, err)
+		}
+	}
+}
+
+// TestXEdDSAUnclampedKeys signs with X25519 private keys as crypto/ecdh
+// holds them, which are not clamped, and verifies under their public keys.
+// TestXEdDSALibsignal checks testSignature from the Curve25519 tests of
+// libsignal, made by its calculateSignature: the X25519 key
+// aliceIdentityPrivate signs aliceEphemeralPublic, with its 0x05 type byte.
+// The Edwards key of aliceIdentityPrivate has sign 1, which libsignal puts in
+// the top bit of S.
+func TestXEdDSALibsignal(t *testing.T) {
+	private := [32]byte(decodeHex(t, "c097248412e58bf05df487968205132794178e367637f5818f81e0e6ce73e865"))
+	public := [32]byte(decodeHex(t, "ab7e717d4a163b7d9a1d8071dfe9dcf8cdcd1cea3339b6356be84d887e322c64"))
+	message := decodeHex(t, "05edce9d9c415ca78cb7252e72c2c4a554d3eb29485a0e1d503118d1a82d99fb4a")
+	sig := decodeHex(t, "5de88ca9a89b4a115da79109c67c9c7464a3e4180274f1cb8c63c2984e286dfbede82deb9dcd9fae0bfbb821569b3d9001bd8130cd11d486cef047bd60b86e88")
+
+	if x, _ := curve25519.X25519(private[:], curve25519.Basepoint); !bytes.Equal(x, public[:]) {
+		t.Fatalf("X25519 public key %x, want %x", x, public)
+	}
+	if !XEdDSAVerify(public, message, sig) {
+		t.Fatal("libsignal signature rejected")
+	}
+	// As in libsignal's test, no single bit flip verifies.
+	for i := 0; i < len(sig)*8; i++ {
+		bad := append([]byte(nil), sig...)
+		bad[i/8] ^= 1 << (i % 8)
+		if XEdDSAVerify(public, message, bad) {
+			t.Errorf("signature with bit %d flipped accepted", i)
+		}
+	}
+
+	ours, err := XEdDSASign(private, message, [64]byte{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ours[63]&0x80 != 0 || !XEdDSAVerify(public, message, ours[:]) {
+		t.Error("XEdDSASign signature for the libsignal key rejected")
+	}
+}
+
+func TestXEdDSAUnclampedKeys(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		var b [32]byte
+		var random [64]byte
+		rand.Read(b[:])
+		rand.Read(random[:])
+		// Make sure the key isn't already clamped.
+		b[0] |= byte(i%7) + 1
+		if i%2 == 0 {
+			b[31] |= 0x80
+		} else {
+			b[31] &= 0xbf
+		}
+		priv, err := ecdh.X25519().NewPrivateKey(b[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		public := [32]byte(priv.PublicKey().Bytes())
+		message := []byte("message")
+
+		sig, err := XEdDSASign([32]byte(priv.Bytes()), message, random)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !XEdDSAVerify(public, message, sig[:]) {
+			t.Errorf("%x: signature rejected under its own public key", b)
+		}
+	}
+}
+
+func TestXEdDSAVerifyErrors(t *testing.T) {
+	test := xeddsaTests[1]
+	public := [32]byte(decodeHex(t, test.public))
+	message := decodeHex(t, test.message)
+	sig := decodeHex(t, test.sig)
+
+	if XEdDSAVerify(public, message, sig[:63]) {
+		t.Error("short signature accepted")
+	}
+	for i := 0; i < len(sig); i++ {
+		bad := append([]byte(nil), sig...)
+		bad[i] ^= 4
+		if XEdDSAVerify(public, message, bad) {
+			t.Errorf("signature with byte %d changed accepted", i)
+		}
+	}
+	// The top bit of S selects the Edwards key of sign 1 instead.
+	bad := append([]byte(nil), sig...)
+	bad[63] |= 0x80
+	if XEdDSAVerify(public, message, bad) {
+		t.Error("signature accepted under the Edwards key of sign 1")
+	}
+	// The specification only requires S < 2^253, so S plus the group
+	// order is accepted too.
+	order := decodeHex(t, "edd3f55c1a631258d69cf7a2def9de1400000000000000000000000000000010")
+	malleable := append([]byte(nil), sig...)
+	carry := 0
+	for i := range order {
+		carry += int(malleable[32+i]) + int(order[i])
+		malleable[32+i] = byte(carry)
+		carry >>= 8
+	}
+	if !XEdDSAVerify(public, message, malleable) {
+		t.Error("S plus the group order rejected")
+	}
+
+	badKey := public
+	badKey[31] |= 0x80
+	if XEdDSAVerify(badKey, message, sig) {
+		t.Error("non-canonical public key accepted")
+	}
+	for _, u := range []string{
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		"0100000000000000000000000000000000000000000000000000000000000000",
+		"ecffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f",
+	} {
+		if XEdDSAVerify([32]byte(decodeHex(t, u)), message, sig) {
+			t.Errorf("small-order public key %s accepted", u)
+		}
+	}
+
+	// The zero key clamps to 2^254, whose X25519 public key it signs for.
+	zeroPublic, _ := curve25519.X25519(make([]byte, 32), curve25519.Basepoint)
+	zeroSig, err := XEdDSASign([32]byte{}, message, [64]byte{})
+	if err != nil || !XEdDSAVerify([32]byte(zeroPublic), message, zeroSig[:]) {
+		t.Errorf("zero private key: %v", err)
+	}
+}
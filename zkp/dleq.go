@@ -0,0 +1,207 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zkp
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/agl/ed25519/edwards25519"
+	"github.com/agl/ed25519/internal/dleq"
+)
+
+// A Chaum-Pedersen proof shows that A = xG and B = xH for the same secret x
+// without revealing it, which VOPRFs, verifiable shuffles and attestations of
+// rotated keys need. The proof is (c, s), made non-interactive with the
+// challenge
+//
+//	c = H(dleqDomain || label || G || H || A || B || t2 || t3)
+//
+// over the commitments t2 and t3 of package internal/dleq, which also
+// computes the VOPRF proofs of package oprf. H and the length prefixes are
+// as for Schnorr proofs, and label separates the proofs of different
+// protocols, so that a proof made for one can't be presented to another.
+//
+// A batch proof shows that log_G(A) = log_H[i](B[i]) for every i with a
+// single proof: with weights d[i] hashed from all the points, it proves that
+// log_G(A) = log_M(Z) for M = sum(d[i] * H[i]) and Z = sum(d[i] * B[i]), as
+// RFC 9497 does for its batches. A cheating prover would have to predict the
+// weights.
+
+const (
+	dleqDomain      = "github.com/agl/ed25519/zkp DLEQ v1"
+	dleqBatchDomain = "github.com/agl/ed25519/zkp DLEQ batch v1"
+)
+
+// DLEQProofSize is the size, in bytes, of a serialized DLEQProof.
+const DLEQProofSize = 64
+
+var (
+	// ErrInvalidPoint is returned by the DLEQ functions for a point that is
+	// not in the prime-order subgroup, or a generator that is the identity.
+	ErrInvalidPoint = errors.New("zkp: point is not a valid DLEQ element")
+
+	errDLEQWitness = errors.New("zkp: x is not the common discrete logarithm")
+	errBatchSize   = errors.New("zkp: empty or mismatched DLEQ batch")
+)
+
+// DLEQProof is a Chaum-Pedersen proof of equality of discrete logarithms.
+type DLEQProof struct {
+	// C is the challenge.
+	C *edwards25519.Scalar
+	// S is the response r - c*x.
+	S *edwards25519.Scalar
+}
+
+// Bytes returns the DLEQProofSize-byte encoding of p, C followed by S.
+func (p *DLEQProof) Bytes() []byte {
+	return append(p.C.Bytes(), p.S.Bytes()...)
+}
+
+// ParseDLEQProof returns the proof encoded as b by Bytes. It returns
+// ErrInvalidProof if C or S is not canonically encoded.
+func ParseDLEQProof(b []byte) (*DLEQProof, error) {
+	if len(b) != DLEQProofSize {
+		return nil, ErrInvalidProof
+	}
+	c, err := edwards25519.NewScalar().SetCanonicalBytes(b[:32])
+	if err != nil {
+		return nil, ErrInvalidProof
+	}
+	s, err := edwards25519.NewScalar().SetCanonicalBytes(b[32:])
+	if err != nil {
+		return nil, ErrInvalidProof
+	}
+	return &DLEQProof{C: c, S: s}, nil
+}
+
+// checkDLEQPoints returns ErrInvalidPoint unless every point is in the
+// prime-order subgroup and the generators G and H are not the identity.
+func checkDLEQPoints(G, H, A, B *edwards25519.Point) error {
+	identity := edwards25519.NewIdentityPoint()
+	if G.Equal(identity) == 1 || H.Equal(identity) == 1 {
+		return ErrInvalidPoint
+	}
+	for _, p := range []*edwards25519.Point{G, H, A, B} {
+		if !p.IsTorsionFree() {
+			return ErrInvalidPoint
+		}
+	}
+	return nil
+}
+
+func dleqChallenge(label []byte, G, H, A, B *edwards25519.Point) func(t2, t3 *edwards25519.Point) *edwards25519.Scalar {
+	return func(t2, t3 *edwards25519.Point) *edwards25519.Scalar {
+		return transcriptHash([]byte(dleqDomain), label, G.Bytes(), H.Bytes(), A.Bytes(), B.Bytes(), t2.Bytes(), t3.Bytes())
+	}
+}
+
+// DLEQProve proves that A = xG and B = xH, for the protocol or context named
+// by transcriptLabel, with the randomness of rand, or of crypto/rand.Reader
+// if rand is nil. It returns ErrInvalidPoint as DLEQVerify does, and an
+// error if x is not the discrete logarithm of A and B.
+func DLEQProve(x *edwards25519.Scalar, G, H, A, B *edwards25519.Point, transcriptLabel []byte, rand io.Reader) (*DLEQProof, error) {
+	if err := checkDLEQPoints(G, H, A, B); err != nil {
+		return nil, err
+	}
+	if new(edwards25519.Point).ScalarMult(x, G).Equal(A) != 1 || new(edwards25519.Point).ScalarMult(x, H).Equal(B) != 1 {
+		return nil, errDLEQWitness
+	}
+	r, err := randomScalar(rand)
+	if err != nil {
+		return nil, err
+	}
+	c, s := dleq.Prove(x, r, G, H, dleqChallenge(transcriptLabel, G, H, A, B))
+	return &DLEQProof{C: c, S: s}, nil
+}
+
+// DLEQVerify checks that proof shows log_G(A) = log_H(B) for
+// transcriptLabel. It returns ErrInvalidPoint if a point is not in the
+// prime-order subgroup, or G or H is the identity, and ErrInvalidProof if
+// the proof doesn't verify.
+func DLEQVerify(G, H, A, B *edwards25519.Point, proof *DLEQProof, transcriptLabel []byte) error {
+	if err := checkDLEQPoints(G, H, A, B); err != nil {
+		return err
+	}
+	if proof == nil || proof.C == nil || proof.S == nil {
+		return ErrInvalidProof
+	}
+	if !dleq.Verify(G, H, A, B, proof.C, proof.S, dleqChallenge(transcriptLabel, G, H, A, B)) {
+		return ErrInvalidProof
+	}
+	return nil
+}
+
+// dleqComposites returns M = sum(d[i] * H[i]) and Z = sum(d[i] * B[i]) for
+// a batch, with the weights d[i] hashed from a seed that commits to the
+// label and all the points.
+func dleqComposites(label []byte, G, A *edwards25519.Point, H, B []*edwards25519.Point) (M, Z *edwards25519.Point) {
+	parts := [][]byte{[]byte(dleqBatchDomain), label, G.Bytes(), A.Bytes()}
+	for i := range H {
+		parts = append(parts, H[i].Bytes(), B[i].Bytes())
+	}
+	seed := transcriptDigest(parts...)
+	d := make([]*edwards25519.Scalar, len(H))
+	var index [4]byte
+	for i := range d {
+		binary.BigEndian.PutUint32(index[:], uint32(i))
+		d[i] = transcriptHash(seed, index[:])
+	}
+	M = new(edwards25519.Point).VarTimeMultiScalarMult(d, H)
+	Z = new(edwards25519.Point).VarTimeMultiScalarMult(d, B)
+	return M, Z
+}
+
+func checkDLEQBatch(G, A *edwards25519.Point, H, B []*edwards25519.Point) error {
+	if len(H) == 0 || len(H) != len(B) {
+		return errBatchSize
+	}
+	for i := range H {
+		if err := checkDLEQPoints(G, H[i], A, B[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DLEQProveBatch proves with a single proof that A = xG and B[i] = xH[i] for
+// every i, like DLEQProve. H and B must have the same, non-zero, length.
+func DLEQProveBatch(x *edwards25519.Scalar, G, A *edwards25519.Point, H, B []*edwards25519.Point, transcriptLabel []byte, rand io.Reader) (*DLEQProof, error) {
+	if err := checkDLEQBatch(G, A, H, B); err != nil {
+		return nil, err
+	}
+	if new(edwards25519.Point).ScalarMult(x, G).Equal(A) != 1 {
+		return nil, errDLEQWitness
+	}
+	for i := range H {
+		if new(edwards25519.Point).ScalarMult(x, H[i]).Equal(B[i]) != 1 {
+			return nil, errDLEQWitness
+		}
+	}
+	r, err := randomScalar(rand)
+	if err != nil {
+		return nil, err
+	}
+	M, Z := dleqComposites(transcriptLabel, G, A, H, B)
+	c, s := dleq.Prove(x, r, G, M, dleqChallenge(transcriptLabel, G, M, A, Z))
+	return &DLEQProof{C: c, S: s}, nil
+}
+
+// DLEQVerifyBatch checks a proof of DLEQProveBatch, with the errors of
+// DLEQVerify.
+func DLEQVerifyBatch(G, A *edwards25519.Point, H, B []*edwards25519.Point, proof *DLEQProof, transcriptLabel []byte) error {
+	if err := checkDLEQBatch(G, A, H, B); err != nil {
+		return err
+	}
+	if proof == nil || proof.C == nil || proof.S == nil {
+		return ErrInvalidProof
+	}
+	M, Z := dleqComposites(transcriptLabel, G, A, H, B)
+	if !dleq.Verify(G, M, A, Z, proof.C, proof.S, dleqChallenge(transcriptLabel, G, M, A, Z)) {
+		return ErrInvalidProof
+	}
+	return nil
+}
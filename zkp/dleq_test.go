@@ -0,0 +1,199 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zkp
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"encoding/hex"
+	"testing"
+
+	"github.com/agl/ed25519/edwards25519"
+)
+
+// hashScalar returns SHA-512 of m reduced to a scalar.
+func hashScalar(m string) *edwards25519.Scalar {
+	h := sha512.Sum512([]byte(m))
+	s, _ := edwards25519.NewScalar().SetUniformBytes(h[:])
+	return s
+}
+
+// dleqStatement returns the secret x of the fixtures, the generator as G,
+// and H[i] = [SHA-512("zkp dleq test H" || i)]G for n > 0, or H with
+// SHA-512("zkp dleq test H") alone for n = 0.
+func dleqStatement(n int) (x *edwards25519.Scalar, G, A *edwards25519.Point, H, B []*edwards25519.Point) {
+	x = hashScalar("zkp dleq test x")
+	G = edwards25519.NewGeneratorPoint()
+	A = new(edwards25519.Point).ScalarBaseMult(x)
+	messages := []string{"zkp dleq test H"}
+	if n > 0 {
+		messages = nil
+		for i := 0; i < n; i++ {
+			messages = append(messages, "zkp dleq test H"+string(rune(i)))
+		}
+	}
+	for _, m := range messages {
+		h := new(edwards25519.Point).ScalarBaseMult(hashScalar(m))
+		H = append(H, h)
+		B = append(B, new(edwards25519.Point).ScalarMult(x, h))
+	}
+	return x, G, A, H, B
+}
+
+var label = []byte("test label")
+
+func TestDLEQVectors(t *testing.T) {
+	// Computed with a Python implementation of the transcripts, with r
+	// reduced from the bytes 0 to 63.
+	const (
+		single = "c112190e073da4ce841b96dd7bdeea608978dc3801090bd6dd233202a816b00eb763b59549d1bc511721f4bf5ec47fdf4c5937cc1d0bdd279de472cafcce3d07"
+		batch  = "309462fedc0b19ff832660f7c9154f292f426757a25afd1b1a69b9b1644e9a04678d1bd510e43af7cf2e905fb62ebdf5a853404c824b9620811cae8d2079a50e"
+	)
+	x, G, A, H, B := dleqStatement(0)
+	proof, err := DLEQProve(x, G, H[0], A, B[0], label, counterRand())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hex.EncodeToString(proof.Bytes()); got != single {
+		t.Errorf("proof %s, want %s", got, single)
+	}
+	parsed, err := ParseDLEQProof(decodeHex(t, single))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := DLEQVerify(G, H[0], A, B[0], parsed, label); err != nil {
+		t.Errorf("vector proof: %v", err)
+	}
+
+	x, G, A, H, B = dleqStatement(3)
+	proof, err = DLEQProveBatch(x, G, A, H, B, label, counterRand())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hex.EncodeToString(proof.Bytes()); got != batch {
+		t.Errorf("batch proof %s, want %s", got, batch)
+	}
+	parsed, err = ParseDLEQProof(decodeHex(t, batch))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := DLEQVerifyBatch(G, A, H, B, parsed, label); err != nil {
+		t.Errorf("vector batch proof: %v", err)
+	}
+}
+
+func TestDLEQTampering(t *testing.T) {
+	x, G, A, H, B := dleqStatement(0)
+	proof, err := DLEQProve(x, G, H[0], A, B[0], label, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := DLEQVerify(G, H[0], A, B[0], proof, label); err != nil {
+		t.Fatalf("valid proof: %v", err)
+	}
+	if err := DLEQVerify(G, H[0], A, B[0], proof, []byte("other label")); err != ErrInvalidProof {
+		t.Errorf("other label: got %v", err)
+	}
+	// Swapping the roles of the pairs changes the statement.
+	if err := DLEQVerify(H[0], G, B[0], A, proof, label); err != ErrInvalidProof {
+		t.Errorf("swapped pairs: got %v", err)
+	}
+	other := new(edwards25519.Point).Add(B[0], G)
+	if err := DLEQVerify(G, H[0], A, other, proof, label); err != ErrInvalidProof {
+		t.Errorf("B with a different logarithm: got %v", err)
+	}
+	enc := proof.Bytes()
+	for _, i := range []int{0, 31, 32, 63} {
+		tampered := append([]byte(nil), enc...)
+		tampered[i] ^= 1
+		p, err := ParseDLEQProof(tampered)
+		if err != nil {
+			continue
+		}
+		if err := DLEQVerify(G, H[0], A, B[0], p, label); err != ErrInvalidProof {
+			t.Errorf("byte %d flipped: got %v", i, err)
+		}
+	}
+
+	// No proof can be made for a false statement.
+	if _, err := DLEQProve(x, G, H[0], A, other, label, nil); err == nil {
+		t.Error("proof made for a false statement")
+	}
+}
+
+func TestDLEQBatch(t *testing.T) {
+	x, G, A, H, B := dleqStatement(5)
+	proof, err := DLEQProveBatch(x, G, A, H, B, label, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := DLEQVerifyBatch(G, A, H, B, proof, label); err != nil {
+		t.Fatalf("valid batch proof: %v", err)
+	}
+	if err := DLEQVerifyBatch(G, A, H[:4], B[:4], proof, label); err != ErrInvalidProof {
+		t.Errorf("truncated batch: got %v", err)
+	}
+	reordered := []*edwards25519.Point{H[1], H[0], H[2], H[3], H[4]}
+	reorderedB := []*edwards25519.Point{B[1], B[0], B[2], B[3], B[4]}
+	if err := DLEQVerifyBatch(G, A, reordered, reorderedB, proof, label); err != ErrInvalidProof {
+		t.Errorf("reordered batch: got %v", err)
+	}
+	bad := append([]*edwards25519.Point(nil), B...)
+	bad[3] = new(edwards25519.Point).Add(B[3], G)
+	if err := DLEQVerifyBatch(G, A, H, bad, proof, label); err != ErrInvalidProof {
+		t.Errorf("one wrong B: got %v", err)
+	}
+	if _, err := DLEQProveBatch(x, G, A, H, bad, label, nil); err == nil {
+		t.Error("batch proof made for a false statement")
+	}
+	if _, err := DLEQProveBatch(x, G, A, H, B[:4], label, nil); err == nil {
+		t.Error("mismatched batch accepted")
+	}
+	if err := DLEQVerifyBatch(G, A, nil, nil, proof, label); err == nil {
+		t.Error("empty batch accepted")
+	}
+}
+
+func TestDLEQInvalidPoints(t *testing.T) {
+	x, G, A, H, B := dleqStatement(0)
+	proof, err := DLEQProve(x, G, H[0], A, B[0], label, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity := edwards25519.NewIdentityPoint()
+	torsion, err := new(edwards25519.Point).SetBytes(decodeHex(t, "26e8958fc2b227b045c3f489f2ef98f0d5dfac05d3c63339b13802886d53fc05"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mixed := new(edwards25519.Point).Add(B[0], torsion)
+	for _, tc := range []struct {
+		name       string
+		G, H, A, B *edwards25519.Point
+	}{
+		{"identity G", identity, H[0], identity, B[0]},
+		{"identity H", G, identity, A, identity},
+		{"mixed-order B", G, H[0], A, mixed},
+		{"small-order H", G, torsion, A, B[0]},
+	} {
+		if err := DLEQVerify(tc.G, tc.H, tc.A, tc.B, proof, label); err != ErrInvalidPoint {
+			t.Errorf("%s: got %v", tc.name, err)
+		}
+	}
+	if err := DLEQVerify(G, H[0], A, B[0], &DLEQProof{}, label); err != ErrInvalidProof {
+		t.Errorf("empty proof: got %v", err)
+	}
+	for _, b := range [][]byte{
+		make([]byte, DLEQProofSize+1),
+		// C = L, which is not canonical.
+		append(decodeHex(t, "edd3f55c1a631258d69cf7a2def9de1400000000000000000000000000000010"), make([]byte, 32)...),
+	} {
+		if _, err := ParseDLEQProof(b); err != ErrInvalidProof {
+			t.Errorf("ParseDLEQProof(%x): got %v", b, err)
+		}
+	}
+	if _, err := DLEQProve(x, G, H[0], A, B[0], label, bytes.NewReader(nil)); err == nil {
+		t.Error("empty randomness accepted")
+	}
+}
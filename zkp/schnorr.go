@@ -0,0 +1,153 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package zkp implements non-interactive zero-knowledge proofs over the
+// prime-order subgroup of edwards25519: the Schnorr proof of knowledge of a
+// discrete logarithm of RFC 8235, and Chaum-Pedersen proofs of equality of
+// discrete logarithms.
+package zkp
+
+import (
+	cryptorand "crypto/rand"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/agl/ed25519/edwards25519"
+)
+
+// A Schnorr proof, RFC 8235, Section 3, shows that the prover knows the
+// private key a of the public key A = [a]G. The prover picks a random v,
+// computes V = [v]G, the challenge c = H(G || V || A || UserID) and
+// r = v - a*c mod n, and publishes V and r, which the verifier checks with
+// V = [r]G + [c]A. H is SHA-512, reduced modulo the group order, and each
+// item of its input is prefixed by its length as four big-endian bytes so
+// that no two transcripts are the same. The UserID binds the proof to the
+// prover, so that no one else can replay it as their own. The optional
+// OtherInfo of the RFC is not used.
+
+// ProofSize is the size, in bytes, of a serialized Proof.
+const ProofSize = 64
+
+var (
+	// ErrInvalidProof is returned by VerifyZKP and the DLEQ verifiers for
+	// a proof that doesn't verify, and by ParseProof and ParseDLEQProof for
+	// one that is malformed.
+	ErrInvalidProof = errors.New("zkp: invalid proof")
+	// ErrInvalidPublicKey is returned for a public key of small order, or
+	// one that is not the public key of the private key.
+	ErrInvalidPublicKey = errors.New("zkp: invalid public key")
+
+	errZeroPrivateKey = errors.New("zkp: private key is zero")
+)
+
+// Proof is a Schnorr proof of knowledge of a discrete logarithm.
+type Proof struct {
+	// V is the commitment [v]G.
+	V *edwards25519.Point
+	// R is the response v - a*c.
+	R *edwards25519.Scalar
+}
+
+// Bytes returns the ProofSize-byte encoding of p, V followed by R.
+func (p *Proof) Bytes() []byte {
+	return append(p.V.Bytes(), p.R.Bytes()...)
+}
+
+// ParseProof returns the proof encoded as b by Bytes. It returns
+// ErrInvalidProof if V or R is not canonically encoded.
+func ParseProof(b []byte) (*Proof, error) {
+	if len(b) != ProofSize {
+		return nil, ErrInvalidProof
+	}
+	V, err := new(edwards25519.Point).SetCanonicalBytes(b[:32])
+	if err != nil {
+		return nil, ErrInvalidProof
+	}
+	R, err := edwards25519.NewScalar().SetCanonicalBytes(b[32:])
+	if err != nil {
+		return nil, ErrInvalidProof
+	}
+	return &Proof{V: V, R: R}, nil
+}
+
+// transcriptDigest returns SHA-512 of parts, each after its length.
+func transcriptDigest(parts ...[]byte) []byte {
+	h := sha512.New()
+	var length [4]byte
+	for _, p := range parts {
+		binary.BigEndian.PutUint32(length[:], uint32(len(p)))
+		h.Write(length[:])
+		h.Write(p)
+	}
+	return h.Sum(nil)
+}
+
+// transcriptHash returns transcriptDigest of parts reduced to a scalar.
+func transcriptHash(parts ...[]byte) *edwards25519.Scalar {
+	c, _ := edwards25519.NewScalar().SetUniformBytes(transcriptDigest(parts...))
+	return c
+}
+
+// schnorrChallenge returns c = H(G || V || A || UserID).
+func schnorrChallenge(V, A *edwards25519.Point, userID []byte) *edwards25519.Scalar {
+	return transcriptHash(edwards25519.NewGeneratorPoint().Bytes(), V.Bytes(), A.Bytes(), userID)
+}
+
+// randomScalar returns a scalar reduced from 64 bytes of rand, or of
+// crypto/rand.Reader if rand is nil.
+func randomScalar(rand io.Reader) (*edwards25519.Scalar, error) {
+	if rand == nil {
+		rand = cryptorand.Reader
+	}
+	var b [64]byte
+	if _, err := io.ReadFull(rand, b[:]); err != nil {
+		return nil, err
+	}
+	return edwards25519.NewScalar().SetUniformBytes(b[:])
+}
+
+// GenerateZKP proves knowledge of priv, the private key of pub = [priv]G, for
+// the prover identified by userID, with the randomness of rand, or of
+// crypto/rand.Reader if rand is nil. It returns ErrInvalidPublicKey if pub is
+// not the public key of priv.
+func GenerateZKP(priv *edwards25519.Scalar, pub *edwards25519.Point, userID []byte, rand io.Reader) (*Proof, error) {
+	if priv.Equal(edwards25519.NewScalar()) == 1 {
+		return nil, errZeroPrivateKey
+	}
+	if new(edwards25519.Point).ScalarBaseMult(priv).Equal(pub) != 1 {
+		return nil, ErrInvalidPublicKey
+	}
+	v, err := randomScalar(rand)
+	if err != nil {
+		return nil, err
+	}
+	V := new(edwards25519.Point).ScalarBaseMult(v)
+	c := schnorrChallenge(V, pub, userID)
+	// r = v - a*c
+	r := edwards25519.NewScalar().Multiply(priv, c)
+	r.Subtract(v, r)
+	return &Proof{V: V, R: r}, nil
+}
+
+// VerifyZKP checks that proof shows knowledge of the private key of pub by
+// the prover identified by userID. It returns ErrInvalidPublicKey if pub has
+// small order, as RFC 8235 requires [h]A not to be the identity, and
+// ErrInvalidProof if the proof doesn't verify.
+func VerifyZKP(pub *edwards25519.Point, proof *Proof, userID []byte) error {
+	if pub.IsSmallOrder() {
+		return ErrInvalidPublicKey
+	}
+	if proof == nil || proof.V == nil || proof.R == nil {
+		return ErrInvalidProof
+	}
+	c := schnorrChallenge(proof.V, pub, userID)
+	// V = [r]G + [c]A
+	V := new(edwards25519.Point).VarTimeDoubleScalarBaseMult(c, pub, proof.R)
+	if V.Equal(proof.V) != 1 {
+		return ErrInvalidProof
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zkp
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"encoding/hex"
+	"testing"
+
+	"github.com/agl/ed25519/edwards25519"
+)
+
+func decodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func testKey() (*edwards25519.Scalar, *edwards25519.Point) {
+	h := sha512.Sum512([]byte("zkp test private key"))
+	a, _ := edwards25519.NewScalar().SetUniformBytes(h[:])
+	return a, new(edwards25519.Point).ScalarBaseMult(a)
+}
+
+// counterRand returns the bytes 0, 1, 2, ..., 63.
+func counterRand() *bytes.Reader {
+	b := make([]byte, 64)
+	for i := range b {
+		b[i] = byte(i)
+	}
+	return bytes.NewReader(b)
+}
+
+func TestSchnorrVector(t *testing.T) {
+	a, A := testKey()
+	// Computed with a Python implementation of the transcript, with the
+	// private key reduced from SHA-512("zkp test private key") and v from
+	// the bytes 0 to 63.
+	const (
+		wantA     = "d0d2be5da790fce300902d8cfc0295d188f2298a177647479f59bb6dd3e51ede"
+		wantProof = "f9302fcb3a2937cff4950e4c6272340e171b0a65ed680d8fca72087ab4da078d16f8b70a56995cac5cc31c45494e11cce39a28fa9a521bffc3725b1a17c06603"
+	)
+	if got := hex.EncodeToString(A.Bytes()); got != wantA {
+		t.Fatalf("public key %s, want %s", got, wantA)
+	}
+	proof, err := GenerateZKP(a, A, []byte("alice"), counterRand())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hex.EncodeToString(proof.Bytes()); got != wantProof {
+		t.Errorf("proof %s, want %s", got, wantProof)
+	}
+	parsed, err := ParseProof(decodeHex(t, wantProof))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyZKP(A, parsed, []byte("alice")); err != nil {
+		t.Errorf("vector proof: %v", err)
+	}
+}
+
+func TestSchnorrProofs(t *testing.T) {
+	a, A := testKey()
+	proof, err := GenerateZKP(a, A, []byte("alice"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyZKP(A, proof, []byte("alice")); err != nil {
+		t.Fatalf("valid proof: %v", err)
+	}
+
+	// The proof of alice is not one for bob, nor for the empty UserID.
+	for _, userID := range []string{"bob", "", "alic", "alicee"} {
+		if err := VerifyZKP(A, proof, []byte(userID)); err != ErrInvalidProof {
+			t.Errorf("UserID %q: got %v", userID, err)
+		}
+	}
+	bob, err := GenerateZKP(a, A, []byte("bob"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyZKP(A, bob, []byte("alice")); err != ErrInvalidProof {
+		t.Errorf("proof for bob accepted for alice: %v", err)
+	}
+
+	// A proof for another key.
+	other := new(edwards25519.Point).Add(A, edwards25519.NewGeneratorPoint())
+	if err := VerifyZKP(other, proof, []byte("alice")); err != ErrInvalidProof {
+		t.Errorf("other key: got %v", err)
+	}
+
+	// Tampering with either half of the encoding.
+	enc := proof.Bytes()
+	for _, i := range []int{0, 31, 32, 40} {
+		tampered := append([]byte(nil), enc...)
+		tampered[i] ^= 1
+		p, err := ParseProof(tampered)
+		if err != nil {
+			continue
+		}
+		if err := VerifyZKP(A, p, []byte("alice")); err != ErrInvalidProof {
+			t.Errorf("byte %d flipped: got %v", i, err)
+		}
+	}
+}
+
+func TestSchnorrErrors(t *testing.T) {
+	a, A := testKey()
+	if _, err := GenerateZKP(a, edwards25519.NewGeneratorPoint(), nil, nil); err != ErrInvalidPublicKey {
+		t.Errorf("mismatched key: got %v", err)
+	}
+	if _, err := GenerateZKP(edwards25519.NewScalar(), edwards25519.NewIdentityPoint(), nil, nil); err == nil {
+		t.Error("zero private key accepted")
+	}
+	if _, err := GenerateZKP(a, A, nil, bytes.NewReader(make([]byte, 63))); err == nil {
+		t.Error("short randomness accepted")
+	}
+
+	// The identity has small order, and anyone can prove that they know
+	// its discrete logarithm.
+	zero := &Proof{V: edwards25519.NewIdentityPoint(), R: edwards25519.NewScalar()}
+	if err := VerifyZKP(edwards25519.NewIdentityPoint(), zero, nil); err != ErrInvalidPublicKey {
+		t.Errorf("identity public key: got %v", err)
+	}
+	if err := VerifyZKP(A, &Proof{}, nil); err != ErrInvalidProof {
+		t.Errorf("empty proof: got %v", err)
+	}
+
+	for _, b := range [][]byte{
+		make([]byte, ProofSize-1),
+		// R = L, which is not canonical.
+		append(edwards25519.NewGeneratorPoint().Bytes(), decodeHex(t, "edd3f55c1a631258d69cf7a2def9de1400000000000000000000000000000010")...),
+		// V with y = p, which is not canonical.
+		append(decodeHex(t, "edffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f"), make([]byte, 32)...),
+	} {
+		if _, err := ParseProof(b); err != ErrInvalidProof {
+			t.Errorf("ParseProof(%x): got %v", b, err)
+		}
+	}
+}